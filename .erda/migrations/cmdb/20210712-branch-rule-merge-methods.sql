@@ -0,0 +1 @@
+ALTER TABLE `dice_branch_rules` ADD COLUMN `allowed_merge_methods` varchar(255) NOT NULL DEFAULT '' COMMENT 'allowed merge methods separated by comma, empty means no limit';
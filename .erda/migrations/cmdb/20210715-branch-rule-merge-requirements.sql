@@ -0,0 +1,3 @@
+ALTER TABLE `dice_branch_rules` ADD COLUMN `required_check_runs` varchar(1024) NOT NULL DEFAULT '' COMMENT 'check run names required to succeed before merge, separated by comma';
+ALTER TABLE `dice_branch_rules` ADD COLUMN `required_approvals` int(11) NOT NULL DEFAULT 0 COMMENT 'number of approvals from non-authors required before merge';
+ALTER TABLE `dice_branch_rules` ADD COLUMN `dismiss_stale_approvals` tinyint(1) NOT NULL DEFAULT 0 COMMENT 'dismiss approvals when source branch is pushed';
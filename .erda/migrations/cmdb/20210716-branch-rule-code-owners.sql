@@ -0,0 +1 @@
+ALTER TABLE `dice_branch_rules` ADD COLUMN `require_code_owner_approval` tinyint(1) NOT NULL DEFAULT 0 COMMENT 'require approval from code owners of every changed path before merge';
@@ -0,0 +1,3 @@
+ALTER TABLE `dice_repos` ADD COLUMN `default_merge_method` varchar(32) NOT NULL DEFAULT '' COMMENT 'default merge method of merge requests, empty means merge';
+
+ALTER TABLE `dice_repo_merge_requests` ADD COLUMN `merge_method` varchar(32) NOT NULL DEFAULT '' COMMENT 'merge method, empty means repo default';
@@ -0,0 +1,23 @@
+CREATE TABLE `dice_repo_lfs_objects` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+  `repo_id` bigint(20) NOT NULL COMMENT 'repo id',
+  `oid` varchar(64) NOT NULL COMMENT 'sha256 of lfs object',
+  `size` bigint(20) NOT NULL DEFAULT 0 COMMENT 'object size in bytes',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_repo_oid` (`repo_id`, `oid`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COMMENT = 'git lfs objects of repos';
+
+CREATE TABLE `dice_repo_lfs_locks` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+  `repo_id` bigint(20) NOT NULL COMMENT 'repo id',
+  `path` varchar(191) NOT NULL COMMENT 'locked file path',
+  `ref` varchar(191) NOT NULL DEFAULT '' COMMENT 'ref name when locked',
+  `owner_id` varchar(64) NOT NULL DEFAULT '' COMMENT 'lock owner user id',
+  `owner_name` varchar(191) NOT NULL DEFAULT '' COMMENT 'lock owner name',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_repo_path` (`repo_id`, `path`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COMMENT = 'git lfs file locks of repos';
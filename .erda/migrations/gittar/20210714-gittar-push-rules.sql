@@ -0,0 +1,14 @@
+CREATE TABLE `dice_repo_push_rules` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+  `scope_type` varchar(32) NOT NULL COMMENT 'rule scope: org or repo',
+  `scope_id` bigint(20) NOT NULL COMMENT 'org id or repo id',
+  `commit_message_regex` varchar(1024) NOT NULL DEFAULT '' COMMENT 'regex the commit message must match',
+  `max_file_size` bigint(20) NOT NULL DEFAULT 0 COMMENT 'max blob size in bytes, 0 means unlimited',
+  `forbidden_paths` text NOT NULL COMMENT 'forbidden file path globs, one per line',
+  `allowed_email_domains` varchar(1024) NOT NULL DEFAULT '' COMMENT 'allowed author email domains, comma separated',
+  `secret_scan` tinyint(1) NOT NULL DEFAULT 0 COMMENT 'scan added lines for secrets',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_scope` (`scope_type`, `scope_id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COMMENT = 'push rules of orgs and repos';
@@ -0,0 +1,10 @@
+CREATE TABLE `dice_repo_merge_request_approvals` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+  `mr_id` bigint(20) NOT NULL COMMENT 'merge request id',
+  `user_id` varchar(150) NOT NULL COMMENT 'approver user id',
+  `commit_sha` varchar(150) NOT NULL DEFAULT '' COMMENT 'source commit when approved',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_mr_user` (`mr_id`, `user_id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COMMENT = 'approvals of merge requests';
@@ -0,0 +1 @@
+ALTER TABLE `dice_repo_merge_requests` ADD COLUMN `reviewer_ids` text NOT NULL COMMENT 'reviewer user ids separated by comma';
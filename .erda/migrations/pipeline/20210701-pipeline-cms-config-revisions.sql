@@ -0,0 +1,14 @@
+CREATE TABLE `dice_pipeline_cms_config_revisions` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+  `ns_id` bigint(20) unsigned NOT NULL COMMENT 'id of dice_pipeline_cms_ns',
+  `revision` bigint(20) unsigned NOT NULL COMMENT 'revision of ns, increase by each change operation',
+  `config_key` varchar(191) NOT NULL COMMENT 'config key',
+  `operation` varchar(32) NOT NULL COMMENT 'create, update, delete or rollback',
+  `detail` mediumtext NOT NULL COMMENT 'old and new config snapshot, encrypted value keeps cipher text',
+  `operator` varchar(191) NOT NULL DEFAULT '' COMMENT 'user id or internal client',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+  PRIMARY KEY (`id`),
+  KEY `idx_ns_revision` (`ns_id`, `revision`),
+  KEY `idx_ns_key` (`ns_id`, `config_key`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COMMENT = 'pipeline cms config revisions';
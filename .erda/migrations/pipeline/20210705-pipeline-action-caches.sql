@@ -0,0 +1,30 @@
+CREATE TABLE `dice_pipeline_action_caches` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+  `project_id` bigint(20) unsigned NOT NULL DEFAULT 0 COMMENT 'project id',
+  `app_id` bigint(20) unsigned NOT NULL DEFAULT 0 COMMENT 'application id',
+  `cluster_name` varchar(191) NOT NULL DEFAULT '' COMMENT 'cluster where cache stored',
+  `storage_type` varchar(64) NOT NULL DEFAULT '' COMMENT 'dice-cache-nfs-volume or dice-cache-oss',
+  `location` varchar(512) NOT NULL COMMENT 'cache file path or object key',
+  `cache_key` varchar(512) NOT NULL DEFAULT '' COMMENT 'resolved cache key',
+  `cache_path` varchar(512) NOT NULL DEFAULT '' COMMENT 'cached directory in action container',
+  `size_bytes` bigint(20) NOT NULL DEFAULT 0 COMMENT 'cache file size',
+  `hit_count` bigint(20) unsigned NOT NULL DEFAULT 0 COMMENT 'restored times',
+  `last_accessed_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'last stored or restored time, used by lru eviction',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_cluster_location` (`cluster_name`, `location`(300)),
+  KEY `idx_project_accessed` (`project_id`, `last_accessed_at`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COMMENT = 'pipeline action cache entries';
+
+CREATE TABLE `dice_pipeline_action_cache_stats` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+  `project_id` bigint(20) unsigned NOT NULL COMMENT 'project id',
+  `hits` bigint(20) unsigned NOT NULL DEFAULT 0 COMMENT 'exact key hits',
+  `partial_hits` bigint(20) unsigned NOT NULL DEFAULT 0 COMMENT 'restore keys hits',
+  `misses` bigint(20) unsigned NOT NULL DEFAULT 0 COMMENT 'misses',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_project` (`project_id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COMMENT = 'pipeline action cache hit statistics per project';
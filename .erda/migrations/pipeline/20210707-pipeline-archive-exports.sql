@@ -0,0 +1,15 @@
+CREATE TABLE `pipeline_archive_exports` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+  `time_created` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+  `time_updated` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+  `pipeline_id` bigint(20) unsigned NOT NULL COMMENT 'pipeline id',
+  `pipeline_source` varchar(32) NOT NULL DEFAULT '' COMMENT 'pipeline source',
+  `pipeline_yml_name` varchar(191) NOT NULL DEFAULT '' COMMENT 'pipeline yml name',
+  `status` varchar(32) NOT NULL DEFAULT '' COMMENT 'pipeline status',
+  `storage_type` varchar(32) NOT NULL DEFAULT '' COMMENT 'fs, oss or s3',
+  `segment` varchar(512) NOT NULL COMMENT 'segment file path or object key',
+  `segment_line` int(11) NOT NULL DEFAULT 0 COMMENT 'line number inside segment, starts from 0',
+  `archived_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'time archived into pipeline_archives',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_pipeline_id` (`pipeline_id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COMMENT = 'index of archived pipelines exported to cold storage';
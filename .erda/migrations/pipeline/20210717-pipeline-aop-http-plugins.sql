@@ -0,0 +1,14 @@
+CREATE TABLE `pipeline_aop_http_plugins` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key',
+  `time_created` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+  `time_updated` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+  `name` varchar(191) NOT NULL COMMENT 'unique plugin name',
+  `type` varchar(32) NOT NULL DEFAULT '' COMMENT 'tune type, pipeline or task',
+  `trigger` varchar(64) NOT NULL DEFAULT '' COMMENT 'tune trigger',
+  `url` varchar(1024) NOT NULL DEFAULT '' COMMENT 'http address to post tune context to',
+  `timeout_sec` bigint(20) NOT NULL DEFAULT 10 COMMENT 'timeout of one invoke',
+  `failure_policy` varchar(32) NOT NULL DEFAULT 'ignore' COMMENT 'ignore or fail',
+  `headers` text NOT NULL COMMENT 'request headers, json object with encrypted values',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_name` (`name`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COMMENT = 'aop external http plugins registered by api';
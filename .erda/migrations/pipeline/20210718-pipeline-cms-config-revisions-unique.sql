@@ -0,0 +1 @@
+ALTER TABLE `dice_pipeline_cms_config_revisions` ADD UNIQUE KEY `uk_ns_key_revision` (`ns_id`, `config_key`, `revision`);
@@ -0,0 +1,10 @@
+CREATE TABLE `pipeline_status_stream_events` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key, used as event id of status stream',
+  `time_created` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'created time',
+  `time_updated` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'updated time',
+  `pipeline_id` bigint(20) unsigned NOT NULL COMMENT 'pipeline id',
+  `labels` text NOT NULL COMMENT 'pipeline labels when event published, json object',
+  `event` text NOT NULL COMMENT 'status stream event, json object',
+  PRIMARY KEY (`id`),
+  KEY `idx_time_created` (`time_created`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COMMENT = 'pipeline status stream events shared by all pipeline instances';
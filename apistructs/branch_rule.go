@@ -28,6 +28,16 @@ type BranchRule struct {
 	Workspace string `json:"workspace"`
 	// 制品可部署的环境
 	ArtifactWorkspace string `json:"artifactWorkspace"`
+	// 允许的 mr 合并方式，逗号分隔，为空表示不限制 eg:squash,rebase
+	AllowedMergeMethods string `json:"allowedMergeMethods"`
+	// 合并前需成功的 check run 名称，逗号分隔，mr 流水线可直接填写流水线文件路径 eg:unit-test,pipeline.yml
+	RequiredCheckRuns string `json:"requiredCheckRuns"`
+	// 合并前需要的非作者审批人数
+	RequiredApprovals int `json:"requiredApprovals"`
+	// 源分支有新推送时作废已有审批
+	DismissStaleApprovals bool `json:"dismissStaleApprovals"`
+	// 每个修改的文件都需要 CODEOWNERS 中的负责人审批
+	RequireCodeOwnerApproval bool `json:"requireCodeOwnerApproval"`
 }
 type QueryBranchRuleRequest struct {
 	ProjectID int64 `query:"projectId"`
@@ -40,15 +50,20 @@ type QueryBranchRuleResponse struct {
 }
 
 type CreateBranchRuleRequest struct {
-	ScopeType         ScopeType `json:"scopeType"`
-	ScopeID           int64     `json:"scopeId"`
-	Rule              string    `json:"rule"`
-	IsProtect         bool      `json:"isProtect"`
-	NeedApproval      bool      `json:"needApproval"`
-	IsTriggerPipeline bool      `json:"isTriggerPipeline"`
-	Workspace         string    `json:"workspace"`
-	ArtifactWorkspace string    `json:"artifactWorkspace"`
-	Desc              string    `json:"desc"`
+	ScopeType                ScopeType `json:"scopeType"`
+	ScopeID                  int64     `json:"scopeId"`
+	Rule                     string    `json:"rule"`
+	IsProtect                bool      `json:"isProtect"`
+	NeedApproval             bool      `json:"needApproval"`
+	IsTriggerPipeline        bool      `json:"isTriggerPipeline"`
+	Workspace                string    `json:"workspace"`
+	ArtifactWorkspace        string    `json:"artifactWorkspace"`
+	Desc                     string    `json:"desc"`
+	AllowedMergeMethods      string    `json:"allowedMergeMethods"`
+	RequiredCheckRuns        string    `json:"requiredCheckRuns"`
+	RequiredApprovals        int       `json:"requiredApprovals"`
+	DismissStaleApprovals    bool      `json:"dismissStaleApprovals"`
+	RequireCodeOwnerApproval bool      `json:"requireCodeOwnerApproval"`
 }
 
 type CreateBranchRuleResponse struct {
@@ -57,14 +72,19 @@ type CreateBranchRuleResponse struct {
 }
 
 type UpdateBranchRuleRequest struct {
-	ID                int64  `json:"-"`
-	Rule              string `json:"rule"`
-	IsProtect         bool   `json:"isProtect"`
-	NeedApproval      bool   `json:"needApproval"`
-	IsTriggerPipeline bool   `json:"isTriggerPipeline"`
-	Desc              string `json:"desc"`
-	Workspace         string `json:"workspace"`
-	ArtifactWorkspace string `json:"artifactWorkspace"`
+	ID                       int64  `json:"-"`
+	Rule                     string `json:"rule"`
+	IsProtect                bool   `json:"isProtect"`
+	NeedApproval             bool   `json:"needApproval"`
+	IsTriggerPipeline        bool   `json:"isTriggerPipeline"`
+	Desc                     string `json:"desc"`
+	Workspace                string `json:"workspace"`
+	ArtifactWorkspace        string `json:"artifactWorkspace"`
+	AllowedMergeMethods      string `json:"allowedMergeMethods"`
+	RequiredCheckRuns        string `json:"requiredCheckRuns"`
+	RequiredApprovals        int    `json:"requiredApprovals"`
+	DismissStaleApprovals    bool   `json:"dismissStaleApprovals"`
+	RequireCodeOwnerApproval bool   `json:"requireCodeOwnerApproval"`
 }
 
 type UpdateBranchRuleResponse struct {
@@ -47,6 +47,16 @@ const (
 	ISTIO_ALIYUN            ClusterInfoMapKey = "ISTIO_ALIYUN"            // 是否用aliyn asm，true or false
 	ISTIO_INSTALLED         ClusterInfoMapKey = "ISTIO_INSTALLED"         // 是否启用了 istio
 	ISTIO_VERSION           ClusterInfoMapKey = "ISTIO_VERSION"           // istio 的版本
+
+	PIPELINE_STORAGE_TYPE              ClusterInfoMapKey = "PIPELINE_STORAGE_TYPE"              // 流水线上下文与缓存的存储类型，为 oss 时使用对象存储代替网盘
+	PIPELINE_STORAGE_OSS_ENDPOINT      ClusterInfoMapKey = "PIPELINE_STORAGE_OSS_ENDPOINT"      // S3 兼容对象存储地址，例如 minio:9000，https:// 前缀表示开启 TLS
+	PIPELINE_STORAGE_OSS_REGION        ClusterInfoMapKey = "PIPELINE_STORAGE_OSS_REGION"        // 对象存储 region，可为空
+	PIPELINE_STORAGE_OSS_BUCKET        ClusterInfoMapKey = "PIPELINE_STORAGE_OSS_BUCKET"        // 对象存储 bucket
+	PIPELINE_STORAGE_OSS_PREFIX        ClusterInfoMapKey = "PIPELINE_STORAGE_OSS_PREFIX"        // object key 前缀，可为空
+	PIPELINE_STORAGE_OSS_ACCESS_KEY    ClusterInfoMapKey = "PIPELINE_STORAGE_OSS_ACCESS_KEY"    // 对象存储 access key
+	PIPELINE_STORAGE_OSS_SECRET_KEY    ClusterInfoMapKey = "PIPELINE_STORAGE_OSS_SECRET_KEY"    // 对象存储 secret key，只在 pipeline 中使用，task 使用 STS 临时凭证
+	PIPELINE_STORAGE_OSS_STS_ENDPOINT  ClusterInfoMapKey = "PIPELINE_STORAGE_OSS_STS_ENDPOINT"  // STS AssumeRole 地址，为空时使用对象存储地址
+	PIPELINE_STORAGE_OSS_SESSION_TOKEN ClusterInfoMapKey = "PIPELINE_STORAGE_OSS_SESSION_TOKEN" // STS 临时凭证 session token，由 pipeline 通过环境变量下发给 action agent
 )
 
 type ClusterInfoResponse struct {
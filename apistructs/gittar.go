@@ -286,6 +286,12 @@ type MergeRequestInfo struct {
 	RebaseBranch         string       `json:"rebaseBranch" default:"-"`
 	EventName            string       `json:"eventName"`
 	CheckRuns            CheckRuns    `json:"checkRuns,omitempty"`
+	// 合并方式，为空时使用仓库默认合并方式
+	MergeMethod string `json:"mergeMethod"`
+	// 目标分支规则要求的合并条件
+	MergeRequirements *MergeRequirements `json:"mergeRequirements,omitempty"`
+	// 评审人，创建时会自动添加修改文件的 CODEOWNERS 负责人
+	ReviewerIds []string `json:"reviewerIds"`
 }
 
 type MergeStatusInfo struct {
@@ -293,6 +299,9 @@ type MergeStatusInfo struct {
 	IsMerged    bool   `json:"isMerged"`
 	HasError    bool   `json:"hasError"`
 	ErrorMsg    string `json:"errorMsg"`
+
+	MergeMethod MergeMethod `json:"mergeMethod"`
+	ConflictMsg string      `json:"conflictMsg,omitempty"`
 }
 
 // GittarCreateMergeResponse 创建mr响应
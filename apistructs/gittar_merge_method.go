@@ -0,0 +1,98 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MergeMethod mr 合并方式
+type MergeMethod string
+
+const (
+	// MergeMethodMerge 创建合并提交
+	MergeMethodMerge MergeMethod = "merge"
+	// MergeMethodSquash 将源分支所有提交压缩为一个提交
+	MergeMethodSquash MergeMethod = "squash"
+	// MergeMethodRebase 将源分支提交变基到目标分支后快进
+	MergeMethodRebase MergeMethod = "rebase"
+	// MergeMethodFastForward 仅允许快进合并
+	MergeMethodFastForward MergeMethod = "ff-only"
+)
+
+// DefaultMergeMethod 未指定合并方式时使用
+const DefaultMergeMethod = MergeMethodMerge
+
+var mergeMethods = []MergeMethod{MergeMethodMerge, MergeMethodSquash, MergeMethodRebase, MergeMethodFastForward}
+
+func (m MergeMethod) String() string {
+	return string(m)
+}
+
+func (m MergeMethod) Valid() bool {
+	for _, method := range mergeMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseMergeMethods 解析逗号分隔的合并方式，eg: merge,squash
+func ParseMergeMethods(s string) ([]MergeMethod, error) {
+	var methods []MergeMethod
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		method := MergeMethod(item)
+		if !method.Valid() {
+			return nil, fmt.Errorf("invalid merge method: %s", item)
+		}
+		methods = append(methods, method)
+	}
+	return methods, nil
+}
+
+// IsMergeMethodAllowed allowed 为逗号分隔的合并方式，为空表示不限制
+func IsMergeMethodAllowed(method MergeMethod, allowed string) bool {
+	methods, err := ParseMergeMethods(allowed)
+	if err != nil {
+		return false
+	}
+	if len(methods) == 0 {
+		return true
+	}
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultMergeMethodRequest 设置仓库默认合并方式
+type DefaultMergeMethodRequest struct {
+	AppID int64 `json:"appId"`
+	// 为空表示重置为系统默认合并方式
+	MergeMethod MergeMethod `json:"mergeMethod"`
+}
+
+// DefaultMergeMethodResponse 设置仓库默认合并方式响应
+type DefaultMergeMethodResponse struct {
+	Header
+	Data DefaultMergeMethodRequest `json:"data"`
+}
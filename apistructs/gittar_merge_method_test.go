@@ -0,0 +1,40 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMergeMethods(t *testing.T) {
+	methods, err := ParseMergeMethods("")
+	assert.NoError(t, err)
+	assert.Empty(t, methods)
+
+	methods, err = ParseMergeMethods("squash, ff-only")
+	assert.NoError(t, err)
+	assert.Equal(t, []MergeMethod{MergeMethodSquash, MergeMethodFastForward}, methods)
+
+	_, err = ParseMergeMethods("merge,cherry-pick")
+	assert.Error(t, err)
+}
+
+func TestIsMergeMethodAllowed(t *testing.T) {
+	assert.True(t, IsMergeMethodAllowed(MergeMethodMerge, ""))
+	assert.True(t, IsMergeMethodAllowed(MergeMethodRebase, "rebase,ff-only"))
+	assert.False(t, IsMergeMethodAllowed(MergeMethodMerge, "rebase,ff-only"))
+	assert.False(t, IsMergeMethodAllowed(MergeMethodMerge, "merge,unknown"))
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+import (
+	"strings"
+	"time"
+)
+
+// MergeRequestApproval mr 审批记录
+type MergeRequestApproval struct {
+	UserID string       `json:"userId"`
+	User   *UserInfoDto `json:"user,omitempty"`
+	// 审批时 mr 源分支的 commit
+	CommitSha string `json:"commitSha"`
+	// 审批后源分支有新推送
+	Stale     bool      `json:"stale"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RequiredCheckRunStatus 分支规则要求的 check run 在 mr 最新提交上的状态
+type RequiredCheckRunStatus struct {
+	Name string `json:"name"`
+	// 为空表示未上报
+	Status    CheckRunStatus `json:"status"`
+	Result    CheckRunResult `json:"result"`
+	Satisfied bool           `json:"satisfied"`
+}
+
+// MergeRequirements 目标分支规则要求的合并条件
+type MergeRequirements struct {
+	Satisfied             bool                      `json:"satisfied"`
+	RequiredCheckRuns     []*RequiredCheckRunStatus `json:"requiredCheckRuns"`
+	RequiredApprovals     int                       `json:"requiredApprovals"`
+	DismissStaleApprovals bool                      `json:"dismissStaleApprovals"`
+	Approvals             []*MergeRequestApproval   `json:"approvals"`
+	// 计入要求的审批数
+	ValidApprovals int `json:"validApprovals"`
+	// 需要 CODEOWNERS 负责人审批的文件，按命中的规则分组
+	CodeOwnerApprovals []*CodeOwnerApproval `json:"codeOwnerApprovals"`
+	// 未满足的条件说明
+	Unmet []string `json:"unmet"`
+	// 不影响合并的提示，例如 CODEOWNERS 负责人无法解析
+	Warnings []string `json:"warnings"`
+}
+
+// CodeOwnerApproval 命中同一条 CODEOWNERS 规则的文件及负责人审批状态
+type CodeOwnerApproval struct {
+	Pattern string `json:"pattern"`
+	// CODEOWNERS 中的负责人 eg: @dev, @role:Lead
+	Owners []string `json:"owners"`
+	// 解析后的负责人用户 id
+	OwnerIDs []string `json:"ownerIds"`
+	Paths    []string `json:"paths"`
+	// 负责人均无法解析为项目成员，该组不作为合并条件
+	NoResolvableOwner bool `json:"noResolvableOwner"`
+	Satisfied         bool `json:"satisfied"`
+}
+
+// MakeCheckRunName mr 流水线的 check run 名称为 <源分支>/<流水线文件路径>
+func MakeCheckRunName(sourceBranch, ymlPath string) string {
+	return sourceBranch + "/" + ymlPath
+}
+
+// CheckRunYmlPath 返回 mr 流水线 check run 对应的流水线文件路径，名称不符合规则时返回原名称
+func CheckRunYmlPath(checkRunName, sourceBranch string) string {
+	return strings.TrimPrefix(checkRunName, sourceBranch+"/")
+}
+
+// ParseRequiredCheckRuns 解析逗号分隔的 check run 名称
+func ParseRequiredCheckRuns(s string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
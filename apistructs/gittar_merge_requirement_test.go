@@ -0,0 +1,32 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRequiredCheckRuns(t *testing.T) {
+	assert.Empty(t, ParseRequiredCheckRuns(""))
+	assert.Equal(t, []string{"unit-test", "pipeline.yml", "feature/a/.dice/pipelines/ci.yaml"},
+		ParseRequiredCheckRuns("unit-test, pipeline.yml,,unit-test, feature/a/.dice/pipelines/ci.yaml"))
+}
+
+func TestCheckRunYmlPath(t *testing.T) {
+	name := MakeCheckRunName("feature/a", ".dice/pipelines/ci.yml")
+	assert.Equal(t, ".dice/pipelines/ci.yml", CheckRunYmlPath(name, "feature/a"))
+	assert.Equal(t, name, CheckRunYmlPath(name, "feature/b"))
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+const (
+	PushRuleScopeOrg  = "org"
+	PushRuleScopeRepo = "repo"
+)
+
+// PushRule gittar 推送规则，组织级和仓库级规则都会校验
+type PushRule struct {
+	ScopeType string `json:"scopeType"`
+	ScopeID   int64  `json:"scopeId"`
+	// 提交信息需匹配的正则，eg: ^[A-Z]+-\d+
+	CommitMessageRegex string `json:"commitMessageRegex"`
+	// 单个文件最大字节数，0 表示不限制
+	MaxFileSize int64 `json:"maxFileSize"`
+	// 禁止提交的文件路径，eg: *.jar, dist/**
+	ForbiddenPaths []string `json:"forbiddenPaths"`
+	// 允许的提交作者邮箱域名，为空表示不限制
+	AllowedEmailDomains []string `json:"allowedEmailDomains"`
+	// 扫描新增行中的密钥
+	SecretScan bool `json:"secretScan"`
+}
+
+// IsEmpty 未配置任何规则
+func (r *PushRule) IsEmpty() bool {
+	return r.CommitMessageRegex == "" && r.MaxFileSize <= 0 && len(r.ForbiddenPaths) == 0 &&
+		len(r.AllowedEmailDomains) == 0 && !r.SecretScan
+}
+
+// PushRulesData 仓库生效的推送规则
+type PushRulesData struct {
+	Org  *PushRule `json:"org"`
+	Repo *PushRule `json:"repo"`
+}
+
+type PushRulesResponse struct {
+	Header
+	Data PushRulesData `json:"data"`
+}
@@ -41,6 +41,8 @@ const (
 	LabelBindPipelineQueueID             = "__bind_queue_id"
 	LabelBindPipelineQueueCustomPriority = "__bind_queue_custom_priority"
 
+	LabelPipelineConcurrencyGroup = "__concurrency_group"
+
 	LabelUserID = "userID"
 
 	// ---------------------- snippet some global labels
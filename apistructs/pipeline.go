@@ -574,6 +574,16 @@ type ValidBranch struct {
 	Workspace string `json:"workspace"`
 	// 制品可部署的环境
 	ArtifactWorkspace string `json:"artifactWorkspace"`
+	// 允许的 mr 合并方式，为空表示不限制
+	AllowedMergeMethods string `json:"allowedMergeMethods"`
+	// 合并前需成功的 check run 名称，逗号分隔
+	RequiredCheckRuns string `json:"requiredCheckRuns"`
+	// 合并前需要的非作者审批人数
+	RequiredApprovals int `json:"requiredApprovals"`
+	// 源分支有新推送时作废已有审批
+	DismissStaleApprovals bool `json:"dismissStaleApprovals"`
+	// 每个修改的文件都需要 CODEOWNERS 中的负责人审批
+	RequireCodeOwnerApproval bool `json:"requireCodeOwnerApproval"`
 }
 
 func (branch *ValidBranch) GetPermissionResource() string {
@@ -0,0 +1,63 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+// PipelineActionCacheOperation action 缓存操作类型
+type PipelineActionCacheOperation string
+
+var (
+	PipelineActionCacheOperationRestore PipelineActionCacheOperation = "restore"
+	PipelineActionCacheOperationStore   PipelineActionCacheOperation = "store"
+)
+
+// PipelineActionCacheRestoreResult action 缓存恢复结果
+type PipelineActionCacheRestoreResult string
+
+var (
+	PipelineActionCacheRestoreHit        PipelineActionCacheRestoreResult = "hit"         // key 精确命中
+	PipelineActionCacheRestorePartialHit PipelineActionCacheRestoreResult = "partial-hit" // restore_keys 前缀命中
+	PipelineActionCacheRestoreMiss       PipelineActionCacheRestoreResult = "miss"
+)
+
+// PipelineActionCacheReport agent 上报的单个缓存的恢复或保存结果
+type PipelineActionCacheReport struct {
+	Operation   PipelineActionCacheOperation     `json:"operation"`
+	StorageType string                           `json:"storageType"`
+	Path        string                           `json:"path"`     // 容器内被缓存的目录
+	Key         string                           `json:"key"`      // 计算后的 key
+	Location    string                           `json:"location"` // 缓存文件路径或 object key
+	Result      PipelineActionCacheRestoreResult `json:"result,omitempty"`
+	SizeBytes   int64                            `json:"sizeBytes,omitempty"` // 保存的缓存大小
+}
+
+type PipelineActionCacheStatsRequest struct {
+	ProjectID uint64 `schema:"projectID"`
+}
+
+// PipelineActionCacheStats 项目维度的 action 缓存统计
+type PipelineActionCacheStats struct {
+	ProjectID      uint64  `json:"projectID"`
+	Hits           uint64  `json:"hits"`
+	PartialHits    uint64  `json:"partialHits"`
+	Misses         uint64  `json:"misses"`
+	HitRatio       float64 `json:"hitRatio"` // (hits + partialHits) / total
+	Entries        uint64  `json:"entries"`
+	TotalSizeBytes int64   `json:"totalSizeBytes"`
+	QuotaBytes     int64   `json:"quotaBytes"`
+}
+
+type PipelineActionCacheStatsResponse struct {
+	Header
+	Data *PipelineActionCacheStats `json:"data"`
+}
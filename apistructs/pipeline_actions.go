@@ -109,6 +109,9 @@ type ActionCallback struct {
 	// machine stat
 	MachineStat *PipelineTaskMachineStat `json:"machineStat,omitempty"`
 
+	// action caches restore/store result
+	CacheReports []PipelineActionCacheReport `json:"cacheReports,omitempty"`
+
 	// behind
 	PipelineID     uint64 `json:"pipelineID"`
 	PipelineTaskID uint64 `json:"pipelineTaskID"`
@@ -0,0 +1,128 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/erda-project/erda/pkg/strutil"
+)
+
+// PipelineAOPHTTPPluginFailurePolicy 外部插件调用失败时的处理策略
+type PipelineAOPHTTPPluginFailurePolicy string
+
+var (
+	// PipelineAOPHTTPPluginFailurePolicyIgnore 忽略失败，继续执行调用链
+	PipelineAOPHTTPPluginFailurePolicyIgnore PipelineAOPHTTPPluginFailurePolicy = "ignore"
+	// PipelineAOPHTTPPluginFailurePolicyFail 中断调用链并返回错误
+	PipelineAOPHTTPPluginFailurePolicyFail PipelineAOPHTTPPluginFailurePolicy = "fail"
+)
+
+func (p PipelineAOPHTTPPluginFailurePolicy) String() string { return string(p) }
+func (p PipelineAOPHTTPPluginFailurePolicy) IsValid() bool {
+	switch p {
+	case PipelineAOPHTTPPluginFailurePolicyIgnore, PipelineAOPHTTPPluginFailurePolicyFail:
+		return true
+	default:
+		return false
+	}
+}
+
+var (
+	PipelineAOPHTTPPluginDefaultTimeoutSec    int64 = 10
+	PipelineAOPHTTPPluginMaxTimeoutSec        int64 = 300
+	PipelineAOPHTTPPluginDefaultFailurePolicy       = PipelineAOPHTTPPluginFailurePolicyIgnore
+)
+
+// PipelineAOPHTTPPlugin represents an out-of-process AOP tune point invoked over HTTP.
+type PipelineAOPHTTPPlugin struct {
+	// Name is the unique name of plugin.
+	// +required
+	Name string `json:"name"`
+
+	// Type is the tune type, pipeline or task.
+	// +required
+	Type string `json:"type"`
+
+	// Trigger is the tune trigger, such as pipeline_before_exec, task_after_exec.
+	// +required
+	Trigger string `json:"trigger"`
+
+	// URL is the http address to post TuneContext to.
+	// +required
+	URL string `json:"url"`
+
+	// TimeoutSec is the timeout of one invoke.
+	// If not present, will use default timeout.
+	// +optional
+	TimeoutSec int64 `json:"timeoutSec,omitempty"`
+
+	// FailurePolicy defines what to do when invoke failed.
+	// If not present, will use default policy: ignore.
+	// +optional
+	FailurePolicy PipelineAOPHTTPPluginFailurePolicy `json:"failurePolicy,omitempty"`
+
+	// Headers will be set into request.
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Validate validate and handle plugin.
+func (p *PipelineAOPHTTPPlugin) Validate() error {
+	// name
+	if err := strutil.Validate(p.Name, strutil.MinLenValidator(1), strutil.MaxRuneCountValidator(191)); err != nil {
+		return fmt.Errorf("invalid name: %v", err)
+	}
+	// type & trigger, detail checked by aop
+	if p.Type == "" {
+		return fmt.Errorf("missing type")
+	}
+	if p.Trigger == "" {
+		return fmt.Errorf("missing trigger")
+	}
+	// url
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %v", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid url: %s, must be http(s) address", p.URL)
+	}
+	// timeout
+	if p.TimeoutSec == 0 {
+		p.TimeoutSec = PipelineAOPHTTPPluginDefaultTimeoutSec
+	}
+	if p.TimeoutSec < 0 || p.TimeoutSec > PipelineAOPHTTPPluginMaxTimeoutSec {
+		return fmt.Errorf("timeoutSec must in range (0, %d]", PipelineAOPHTTPPluginMaxTimeoutSec)
+	}
+	// failure policy
+	if p.FailurePolicy == "" {
+		p.FailurePolicy = PipelineAOPHTTPPluginDefaultFailurePolicy
+	}
+	if !p.FailurePolicy.IsValid() {
+		return fmt.Errorf("invalid failurePolicy: %s", p.FailurePolicy)
+	}
+	return nil
+}
+
+// PipelineAOPHTTPPluginResponse is the response body external plugin should return.
+type PipelineAOPHTTPPluginResponse struct {
+	// Success false means plugin handle failed, failure policy will be applied
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+	// Data will be merged back into TuneContext by key
+	Data map[string]json.RawMessage `json:"data,omitempty"`
+}
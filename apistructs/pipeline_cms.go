@@ -150,3 +150,84 @@ type PipelineCmsConfigOperations struct {
 	CanEdit     bool `json:"canEdit"`
 	CanDelete   bool `json:"canDelete"` // CanDelete 仅在删除单个配置项时生效。若删除 ns，则所有配置项均会被删除
 }
+
+// PipelineCmsConfigRevisionOperation 配置项变更操作
+type PipelineCmsConfigRevisionOperation string
+
+var (
+	PipelineCmsConfigRevisionOperationCreate   PipelineCmsConfigRevisionOperation = "create"
+	PipelineCmsConfigRevisionOperationUpdate   PipelineCmsConfigRevisionOperation = "update"
+	PipelineCmsConfigRevisionOperationDelete   PipelineCmsConfigRevisionOperation = "delete"
+	PipelineCmsConfigRevisionOperationRollback PipelineCmsConfigRevisionOperation = "rollback"
+)
+
+// PipelineCmsConfigMaskedValue 加密存储的配置项在变更记录中的展示值
+const PipelineCmsConfigMaskedValue = "******"
+
+// PipelineCmsConfigRevision 配置项变更记录
+type PipelineCmsConfigRevision struct {
+	Revision  uint64                             `json:"revision"`
+	Key       string                             `json:"key"`
+	Operation PipelineCmsConfigRevisionOperation `json:"operation"`
+	// Old 变更前的值，为 nil 表示变更前不存在
+	Old *PipelineCmsConfigRevisionValue `json:"old,omitempty"`
+	// New 变更后的值，为 nil 表示变更后不存在
+	New         *PipelineCmsConfigRevisionValue `json:"new,omitempty"`
+	Operator    string                          `json:"operator"`
+	TimeCreated *time.Time                      `json:"timeCreated"`
+}
+
+// PipelineCmsConfigRevisionValue 变更记录中的配置项，加密存储的配置项 value 使用掩码展示
+type PipelineCmsConfigRevisionValue struct {
+	Value       string                `json:"value"`
+	EncryptInDB bool                  `json:"encryptInDB"`
+	Type        PipelineCmsConfigType `json:"type"`
+	Comment     string                `json:"comment"`
+	From        string                `json:"from"`
+}
+
+// PipelineCmsListConfigRevisionsRequest 查询 pipeline 配置管理 变更记录 请求体.
+type PipelineCmsListConfigRevisionsRequest struct {
+	PipelineSource PipelineSource `json:"pipelineSource"`
+	Keys           []string       `json:"keys"` // 只获取指定 key 的变更记录
+}
+
+// PipelineCmsListConfigRevisionsResponse 查询 pipeline 配置管理 变更记录 返回体. 按 revision 倒序.
+type PipelineCmsListConfigRevisionsResponse struct {
+	Header
+	Data []PipelineCmsConfigRevision `json:"data"`
+}
+
+// PipelineCmsDiffConfigRevisionsRequest 对比 pipeline 配置管理 两个 revision 请求体.
+type PipelineCmsDiffConfigRevisionsRequest struct {
+	PipelineSource PipelineSource `json:"pipelineSource"`
+	// FromRevision 为 0 表示第一次变更之前
+	FromRevision uint64 `json:"fromRevision"`
+	// ToRevision 为 0 表示最新 revision
+	ToRevision uint64 `json:"toRevision"`
+}
+
+// PipelineCmsConfigDiff 配置项在两个 revision 之间的差异
+type PipelineCmsConfigDiff struct {
+	Key  string                          `json:"key"`
+	From *PipelineCmsConfigRevisionValue `json:"from,omitempty"`
+	To   *PipelineCmsConfigRevisionValue `json:"to,omitempty"`
+}
+
+// PipelineCmsDiffConfigRevisionsResponse 对比 pipeline 配置管理 两个 revision 返回体.
+type PipelineCmsDiffConfigRevisionsResponse struct {
+	Header
+	Data []PipelineCmsConfigDiff `json:"data"`
+}
+
+// PipelineCmsRollbackConfigsRequest 回滚 pipeline 配置管理 namespace 到指定 revision 请求体.
+type PipelineCmsRollbackConfigsRequest struct {
+	PipelineSource PipelineSource `json:"pipelineSource"`
+	// Revision 为 0 表示回滚到第一次变更之前
+	Revision uint64 `json:"revision"`
+}
+
+// PipelineCmsRollbackConfigsResponse 回滚 pipeline 配置管理 namespace 返回体.
+type PipelineCmsRollbackConfigsResponse struct {
+	Header
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+import (
+	"time"
+)
+
+// PipelineCompareChangeType 对比项的变化类型
+type PipelineCompareChangeType string
+
+const (
+	PipelineCompareChangeTypeAdded   PipelineCompareChangeType = "added"
+	PipelineCompareChangeTypeRemoved PipelineCompareChangeType = "removed"
+	PipelineCompareChangeTypeChanged PipelineCompareChangeType = "changed"
+)
+
+// PipelineCompareRequest 对比两次流水线运行
+type PipelineCompareRequest struct {
+	// BasePipelineID 对比基准，一般为上一次成功的运行
+	BasePipelineID uint64 `json:"basePipelineID"`
+	// TargetPipelineID 需要排查的运行
+	TargetPipelineID uint64 `json:"targetPipelineID"`
+}
+
+type PipelineCompareResponse struct {
+	Header
+	Data *PipelineCompareData `json:"data"`
+}
+
+// PipelineCompareData 两次流水线运行的结构化差异，secret 均已脱敏
+type PipelineCompareData struct {
+	Base   PipelineCompareSummary `json:"base"`
+	Target PipelineCompareSummary `json:"target"`
+
+	// PipelineYml 渲染后的 pipeline.yml 差异
+	PipelineYml PipelineCompareYmlDiff `json:"pipelineYml"`
+	// RunParams 运行时参数差异，只包含变化的参数
+	RunParams []PipelineCompareValueDiff `json:"runParams"`
+	// Envs 环境变量差异，只包含变化的环境变量
+	Envs []PipelineCompareValueDiff `json:"envs"`
+	// Commits base 与 target 之间的提交
+	Commits PipelineCompareCommits `json:"commits"`
+	// Tasks 按任务名对齐的任务差异，包含所有任务
+	Tasks []PipelineCompareTaskDiff `json:"tasks"`
+}
+
+// PipelineCompareSummary 单次运行的概要
+type PipelineCompareSummary struct {
+	PipelineID  uint64         `json:"pipelineID"`
+	Status      PipelineStatus `json:"status"`
+	Branch      string         `json:"branch,omitempty"`
+	Commit      string         `json:"commit,omitempty"`
+	CostTimeSec int64          `json:"costTimeSec"`
+	TimeBegin   *time.Time     `json:"timeBegin,omitempty"`
+}
+
+// PipelineCompareYmlDiff pipeline.yml 差异，Diff 为 unified 格式
+type PipelineCompareYmlDiff struct {
+	Changed bool   `json:"changed"`
+	Diff    string `json:"diff,omitempty"`
+}
+
+// PipelineCompareValueDiff 键值对差异
+type PipelineCompareValueDiff struct {
+	Key    string                    `json:"key"`
+	Type   PipelineCompareChangeType `json:"type"`
+	Base   string                    `json:"base,omitempty"`
+	Target string                    `json:"target,omitempty"`
+}
+
+// PipelineCompareCommits 提交范围，查询失败时 Error 不为空，不影响其他对比项
+type PipelineCompareCommits struct {
+	Repo         string         `json:"repo,omitempty"`
+	From         string         `json:"from,omitempty"`
+	To           string         `json:"to,omitempty"`
+	Commits      []CommitDetail `json:"commits,omitempty"`
+	CommitsCount int            `json:"commitsCount"`
+	Error        string         `json:"error,omitempty"`
+}
+
+// PipelineCompareTaskDiff 任务差异，任务只在一侧存在时另一侧为空
+type PipelineCompareTaskDiff struct {
+	Name   string                   `json:"name"`
+	Base   *PipelineCompareTaskInfo `json:"base,omitempty"`
+	Target *PipelineCompareTaskInfo `json:"target,omitempty"`
+
+	StatusChanged        bool `json:"statusChanged"`
+	ActionVersionChanged bool `json:"actionVersionChanged"`
+	ImageChanged         bool `json:"imageChanged"`
+	CacheKeysChanged     bool `json:"cacheKeysChanged"`
+	// CostTimeSecDelta target 相对 base 的耗时变化，任一侧无耗时信息时为空
+	CostTimeSecDelta *int64 `json:"costTimeSecDelta,omitempty"`
+}
+
+// PipelineCompareTaskInfo 单侧任务信息
+type PipelineCompareTaskInfo struct {
+	TaskID        uint64         `json:"taskID"`
+	Type          string         `json:"type"`
+	ActionVersion string         `json:"actionVersion,omitempty"`
+	Image         string         `json:"image,omitempty"`
+	Status        PipelineStatus `json:"status"`
+	CostTimeSec   int64          `json:"costTimeSec"`
+	CacheKeys     []string       `json:"cacheKeys,omitempty"`
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+// 并发组相关事件
+const (
+	// PipelineEventReasonConcurrencyGroupCanceled 被同一并发组内新运行的流水线取消
+	PipelineEventReasonConcurrencyGroupCanceled = "ConcurrencyGroupCanceled"
+	// PipelineEventReasonConcurrencyGroupWaiting 等待同一并发组内的流水线结束
+	PipelineEventReasonConcurrencyGroupWaiting = "ConcurrencyGroupWaiting"
+)
+
+// PipelineConcurrencyOptions 流水线并发组配置，同一并发组内同时只有一条流水线在运行，与队列容量无关
+type PipelineConcurrencyOptions struct {
+	// Group 渲染后的并发组，同时记录在标签 LabelPipelineConcurrencyGroup 中
+	Group string `json:"group"`
+	// CancelInProgress 运行时取消组内运行中的流水线；为 false 时排队等待组内流水线结束
+	CancelInProgress bool `json:"cancelInProgress,omitempty"`
+	// Waiting 是否正在等待组内其他流水线结束
+	Waiting bool `json:"waiting,omitempty"`
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// CoverageFormat 覆盖率文件格式
+type CoverageFormat string
+
+const (
+	CoverageFormatCobertura CoverageFormat = "cobertura"
+	CoverageFormatJaCoCo    CoverageFormat = "jacoco"
+	CoverageFormatLCOV      CoverageFormat = "lcov"
+	CoverageFormatGoCover   CoverageFormat = "gocover"
+)
+
+// CoverageMetaKey action 回调时覆盖率结果对应的 metadata key
+const CoverageMetaKey = "coverage"
+
+func (f CoverageFormat) String() string {
+	return string(f)
+}
+
+func (f CoverageFormat) Valid() bool {
+	switch f {
+	case CoverageFormatCobertura, CoverageFormatJaCoCo, CoverageFormatLCOV, CoverageFormatGoCover:
+		return true
+	default:
+		return false
+	}
+}
+
+// ActionCoverage action 声明的覆盖率文件
+type ActionCoverage struct {
+	Format CoverageFormat `json:"format" yaml:"format"`
+	// Path 覆盖率文件路径，相对路径基于 action 工作目录
+	Path string `json:"path" yaml:"path"`
+}
+
+// CoverageCounter 覆盖计数
+type CoverageCounter struct {
+	Covered int64 `json:"covered"`
+	Total   int64 `json:"total"`
+}
+
+// Rate 覆盖率百分比，保留两位小数
+func (c CoverageCounter) Rate() float64 {
+	if c.Total <= 0 {
+		return 0
+	}
+	return math.Round(float64(c.Covered)/float64(c.Total)*10000) / 100
+}
+
+func (c *CoverageCounter) Add(o CoverageCounter) {
+	c.Covered += o.Covered
+	c.Total += o.Total
+}
+
+// CoveragePackage 包级别覆盖率
+// go 的 coverprofile 以语句为单位统计，记录在 Lines 中，且没有分支覆盖率
+type CoveragePackage struct {
+	Name     string          `json:"name"`
+	Lines    CoverageCounter `json:"lines"`
+	Branches CoverageCounter `json:"branches"`
+}
+
+// CoverageReport 覆盖率报告
+type CoverageReport struct {
+	Format   CoverageFormat    `json:"format"`
+	Lines    CoverageCounter   `json:"lines"`
+	Branches CoverageCounter   `json:"branches"`
+	Packages []CoveragePackage `json:"packages"`
+}
+
+// Merge 合并另一份报告，同名包计数累加
+func (r *CoverageReport) Merge(o *CoverageReport) {
+	if o == nil {
+		return
+	}
+	index := make(map[string]int, len(r.Packages))
+	for i, pkg := range r.Packages {
+		index[pkg.Name] = i
+	}
+	for _, pkg := range o.Packages {
+		if i, ok := index[pkg.Name]; ok {
+			r.Packages[i].Lines.Add(pkg.Lines)
+			r.Packages[i].Branches.Add(pkg.Branches)
+			continue
+		}
+		index[pkg.Name] = len(r.Packages)
+		r.Packages = append(r.Packages, pkg)
+	}
+	r.Aggregate()
+}
+
+// Aggregate 按包名排序并汇总总体计数
+func (r *CoverageReport) Aggregate() {
+	sort.Slice(r.Packages, func(i, j int) bool { return r.Packages[i].Name < r.Packages[j].Name })
+	r.Lines, r.Branches = CoverageCounter{}, CoverageCounter{}
+	for _, pkg := range r.Packages {
+		r.Lines.Add(pkg.Lines)
+		r.Branches.Add(pkg.Branches)
+	}
+}
+
+// PipelineCoverageTrendRequest 查询应用分支的覆盖率趋势
+type PipelineCoverageTrendRequest struct {
+	AppID   uint64           `schema:"appID"`
+	Branch  string           `schema:"branch"`
+	Sources []PipelineSource `schema:"source"`
+	// Limit 返回最近多少次流水线，默认 20，最大 100
+	Limit int `schema:"limit"`
+}
+
+func (req *PipelineCoverageTrendRequest) Validate() error {
+	if req.AppID == 0 {
+		return fmt.Errorf("missing appID")
+	}
+	if req.Branch == "" {
+		return fmt.Errorf("missing branch")
+	}
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+	return nil
+}
+
+// PipelineCoverageDeltaRequest 查询 MR 源分支相对目标分支的覆盖率变化
+type PipelineCoverageDeltaRequest struct {
+	AppID        uint64           `schema:"appID"`
+	SourceBranch string           `schema:"sourceBranch"`
+	TargetBranch string           `schema:"targetBranch"`
+	Sources      []PipelineSource `schema:"source"`
+}
+
+func (req *PipelineCoverageDeltaRequest) Validate() error {
+	if req.AppID == 0 {
+		return fmt.Errorf("missing appID")
+	}
+	if req.SourceBranch == "" {
+		return fmt.Errorf("missing sourceBranch")
+	}
+	if req.TargetBranch == "" {
+		return fmt.Errorf("missing targetBranch")
+	}
+	return nil
+}
+
+// PipelineCoverageSummary 一次流水线的覆盖率
+type PipelineCoverageSummary struct {
+	PipelineID  uint64          `json:"pipelineID"`
+	Branch      string          `json:"branch"`
+	Commit      string          `json:"commit,omitempty"`
+	TimeCreated *time.Time      `json:"timeCreated,omitempty"`
+	Lines       CoverageCounter `json:"lines"`
+	Branches    CoverageCounter `json:"branches"`
+	LineRate    float64         `json:"lineRate"`
+	BranchRate  float64         `json:"branchRate"`
+}
+
+// PipelineCoveragePackageDelta 包级别覆盖率变化，源分支或目标分支不存在该包时对应覆盖率为 0
+type PipelineCoveragePackageDelta struct {
+	Name             string  `json:"name"`
+	SourceLineRate   float64 `json:"sourceLineRate"`
+	TargetLineRate   float64 `json:"targetLineRate"`
+	LineRateDelta    float64 `json:"lineRateDelta"`
+	SourceBranchRate float64 `json:"sourceBranchRate"`
+	TargetBranchRate float64 `json:"targetBranchRate"`
+	BranchRateDelta  float64 `json:"branchRateDelta"`
+}
+
+// PipelineCoverageDelta 源分支最新覆盖率相对目标分支最新覆盖率的变化
+type PipelineCoverageDelta struct {
+	Source          *PipelineCoverageSummary       `json:"source"`
+	Target          *PipelineCoverageSummary       `json:"target"`
+	LineRateDelta   float64                        `json:"lineRateDelta"`
+	BranchRateDelta float64                        `json:"branchRateDelta"`
+	Packages        []PipelineCoveragePackageDelta `json:"packages,omitempty"`
+}
+
+type PipelineCoverageTrendResponse struct {
+	Header
+	Data []PipelineCoverageSummary `json:"data"`
+}
+
+type PipelineCoverageDeltaResponse struct {
+	Header
+	Data *PipelineCoverageDelta `json:"data"`
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoverageCounter_Rate(t *testing.T) {
+	assert.Equal(t, float64(0), CoverageCounter{}.Rate())
+	assert.Equal(t, float64(66.67), CoverageCounter{Covered: 2, Total: 3}.Rate())
+	assert.Equal(t, float64(100), CoverageCounter{Covered: 5, Total: 5}.Rate())
+}
+
+func TestCoverageReport_Merge(t *testing.T) {
+	r := &CoverageReport{Packages: []CoveragePackage{
+		{Name: "b", Lines: CoverageCounter{Covered: 1, Total: 2}},
+	}}
+	r.Merge(&CoverageReport{Packages: []CoveragePackage{
+		{Name: "a", Lines: CoverageCounter{Covered: 3, Total: 4}, Branches: CoverageCounter{Covered: 1, Total: 2}},
+		{Name: "b", Lines: CoverageCounter{Covered: 1, Total: 2}},
+	}})
+	r.Merge(nil)
+
+	assert.Len(t, r.Packages, 2)
+	assert.Equal(t, "a", r.Packages[0].Name)
+	assert.Equal(t, CoverageCounter{Covered: 2, Total: 4}, r.Packages[1].Lines)
+	assert.Equal(t, CoverageCounter{Covered: 5, Total: 8}, r.Lines)
+	assert.Equal(t, CoverageCounter{Covered: 1, Total: 2}, r.Branches)
+}
+
+func TestPipelineCoverageTrendRequest_Validate(t *testing.T) {
+	req := PipelineCoverageTrendRequest{}
+	assert.Error(t, req.Validate())
+	req.AppID = 1
+	assert.Error(t, req.Validate())
+	req.Branch = "master"
+	assert.NoError(t, req.Validate())
+	assert.Equal(t, 20, req.Limit)
+	req.Limit = 1000
+	assert.NoError(t, req.Validate())
+	assert.Equal(t, 100, req.Limit)
+}
+
+func TestPipelineCoverageDeltaRequest_Validate(t *testing.T) {
+	req := PipelineCoverageDeltaRequest{AppID: 1, SourceBranch: "feature/a"}
+	assert.Error(t, req.Validate())
+	req.TargetBranch = "master"
+	assert.NoError(t, req.Validate())
+}
@@ -48,6 +48,38 @@ type PipelineCronDTO struct {
 	PipelineYmlName string     `json:"pipelineYmlName"` // 一个分支下可以有多个 pipeline 文件，每个分支可以有单独的 cron 逻辑
 	BasePipelineID  uint64     `json:"basePipelineID"`  // 用于记录最开始创建出这条 cron 记录的 pipeline id
 	Enable          *bool      `json:"enable"`          // 1 true, 0 false
+
+	ConcurrencyPolicy PipelineCronConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+}
+
+// PipelineCronConcurrencyPolicy 定时触发时，上一次触发的流水线仍在运行时的处理策略
+type PipelineCronConcurrencyPolicy string
+
+var (
+	// PipelineCronConcurrencyPolicyAllow 允许并发，行为与之前保持一致
+	PipelineCronConcurrencyPolicyAllow PipelineCronConcurrencyPolicy = "Allow"
+	// PipelineCronConcurrencyPolicyForbid 存在运行中的流水线时跳过本次触发
+	PipelineCronConcurrencyPolicyForbid PipelineCronConcurrencyPolicy = "Forbid"
+	// PipelineCronConcurrencyPolicyReplace 取消运行中的流水线，使用本次触发替代
+	PipelineCronConcurrencyPolicyReplace PipelineCronConcurrencyPolicy = "Replace"
+)
+
+func (p PipelineCronConcurrencyPolicy) String() string {
+	return string(p)
+}
+
+func (p PipelineCronConcurrencyPolicy) Valid() bool {
+	switch p {
+	case PipelineCronConcurrencyPolicyAllow, PipelineCronConcurrencyPolicyForbid, PipelineCronConcurrencyPolicyReplace:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsAllow 为空时等同于 Allow，兼容老的 cron
+func (p PipelineCronConcurrencyPolicy) IsAllow() bool {
+	return p == "" || p == PipelineCronConcurrencyPolicyAllow
 }
 
 type PipelineCronCreateRequest struct {
@@ -0,0 +1,130 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+// PipelineDryRunRequest 试运行请求，只解析 pipeline.yml 并返回完整的执行计划，不会创建任何数据
+type PipelineDryRunRequest struct {
+	// PipelineYml 流水线定义
+	// +required
+	PipelineYml string `json:"pipelineYml"`
+
+	// PipelineYmlName 默认为 pipeline.yml
+	// +optional
+	PipelineYmlName string `json:"pipelineYmlName"`
+
+	// PipelineSource 用于查询配置管理中的配置项
+	// +optional
+	PipelineSource PipelineSource `json:"pipelineSource"`
+
+	// ClusterName 用于解析平台级别配置项，为空则不解析
+	// +optional
+	ClusterName string `json:"clusterName"`
+
+	// +optional
+	Labels map[string]string `json:"labels"`
+
+	// +optional
+	NormalLabels map[string]string `json:"normalLabels"`
+
+	// Envs 优先级高于 pipeline.yml 中的 envs
+	// +optional
+	Envs map[string]string `json:"envs"`
+
+	// ConfigManageNamespaces 流水线会使用的配置管理命名空间
+	// +optional
+	ConfigManageNamespaces []string `json:"configManageNamespaces"`
+
+	// RunParams 运行时的输入参数
+	// +optional
+	RunParams PipelineRunParams `json:"runParams"`
+
+	IdentityInfo
+}
+
+type PipelineDryRunResponse struct {
+	Header
+	Data *PipelineDryRunPlan `json:"data"`
+}
+
+// PipelineDryRunPlan 流水线执行计划
+type PipelineDryRunPlan struct {
+	PipelineYmlName string                 `json:"pipelineYmlName"`
+	PipelineSource  PipelineSource         `json:"pipelineSource,omitempty"`
+	Version         string                 `json:"version"`
+	Cron            string                 `json:"cron,omitempty"`
+	Envs            map[string]string      `json:"envs,omitempty"`
+	RunParams       PipelineRunParams      `json:"runParams,omitempty"` // 已填充默认值
+	Stages          []*PipelineDryRunStage `json:"stages"`
+	// Secrets 流水线引用的配置项，只包含名称
+	Secrets []PipelineDryRunSecret `json:"secrets,omitempty"`
+	Warns   []string               `json:"warns,omitempty"`
+}
+
+type PipelineDryRunStage struct {
+	Actions []*PipelineDryRunAction `json:"actions"`
+}
+
+// PipelineDryRunAction 展开后的 action
+type PipelineDryRunAction struct {
+	Alias    string                 `json:"alias"`
+	Type     string                 `json:"type"`
+	Version  string                 `json:"version,omitempty"`
+	Image    string                 `json:"image,omitempty"`
+	Commands []string               `json:"commands,omitempty"`
+	Params   map[string]interface{} `json:"params,omitempty"` // 已替换运行参数，配置项保留占位符
+	Needs    []string               `json:"needs"`
+
+	If              string                        `json:"if,omitempty"`
+	ConditionResult PipelineDryRunConditionResult `json:"conditionResult,omitempty"`
+	ConditionMsg    string                        `json:"conditionMsg,omitempty"`
+
+	Loop    *PipelineTaskLoop     `json:"loop,omitempty"`
+	Retry   *PipelineTaskRetry    `json:"retry,omitempty"`
+	Timeout int64                 `json:"timeout,omitempty"` // unit: second
+	Matrix  *PipelineDryRunMatrix `json:"matrix,omitempty"`
+
+	ExecutorKind string `json:"executorKind,omitempty"`
+	ExecutorName string `json:"executorName,omitempty"`
+
+	// Snippet 嵌套流水线展开后的执行计划
+	Snippet *PipelineDryRunPlan `json:"snippet,omitempty"`
+}
+
+// PipelineDryRunMatrix 由 matrix 展开的 action 的来源
+type PipelineDryRunMatrix struct {
+	Origin string            `json:"origin"`
+	Values map[string]string `json:"values"`
+}
+
+// PipelineDryRunConditionResult if 条件在试运行时的计算结果
+type PipelineDryRunConditionResult string
+
+var (
+	PipelineDryRunConditionResultTrue    PipelineDryRunConditionResult = "true"
+	PipelineDryRunConditionResultFalse   PipelineDryRunConditionResult = "false"
+	PipelineDryRunConditionResultUnknown PipelineDryRunConditionResult = "unknown" // 依赖运行时上下文，例如 outputs
+	PipelineDryRunConditionResultError   PipelineDryRunConditionResult = "error"
+)
+
+// PipelineDryRunSecret 流水线引用的配置项
+type PipelineDryRunSecret struct {
+	Name string `json:"name"`
+	// Found 是否能解析到
+	Found bool `json:"found"`
+	// From 配置项来源: 配置管理命名空间名称或 platform
+	From string `json:"from,omitempty"`
+}
+
+// PipelineDryRunSecretFromPlatform 平台级别配置项
+const PipelineDryRunSecretFromPlatform = "platform"
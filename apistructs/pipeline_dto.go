@@ -83,6 +83,10 @@ type (
 		IsAutoRun bool `json:"isAutoRun,omitempty"` // 创建后是否自动开始执行
 
 		CallbackURLs []string `json:"callbackURLs,omitempty"`
+
+		TimeoutOptions *PipelineTimeoutOptions `json:"timeoutOptions,omitempty"` // 超时配置及排队耗时
+
+		ConcurrencyOptions *PipelineConcurrencyOptions `json:"concurrencyOptions,omitempty"` // 并发组配置
 	}
 
 	PipelineUser struct {
@@ -28,12 +28,19 @@ type PipelineQueueMode string
 var (
 	PipelineQueueModeStrict PipelineQueueMode = "STRICT"
 	PipelineQueueModeLoose  PipelineQueueMode = "LOOSE"
+	// PipelineQueueModeFair pop pipelines from per-tenant virtual sub-queues by weight,
+	// tenant is project if pipeline has projectID label, otherwise pipeline source.
+	PipelineQueueModeFair PipelineQueueMode = "FAIR"
 )
 
+// PipelineQueueFairWeightLabelPrefix is the queue label key prefix to declare tenant weight in FAIR mode,
+// e.g. `fair-weight/project-1: "3"`, `fair-weight/source-dice: "2"`. Default weight is 1.
+const PipelineQueueFairWeightLabelPrefix = "fair-weight/"
+
 func (m PipelineQueueMode) String() string { return string(m) }
 func (m PipelineQueueMode) IsValid() bool {
 	switch m {
-	case PipelineQueueModeStrict, PipelineQueueModeLoose:
+	case PipelineQueueModeStrict, PipelineQueueModeLoose, PipelineQueueModeFair:
 		return true
 	default:
 		return false
@@ -59,6 +66,22 @@ type PipelineQueue struct {
 	TimeUpdated *time.Time `json:"timeUpdated,omitempty"`
 
 	Usage *pb.QueueUsage `json:"usage"`
+	// TenantUsages show how each tenant is sharing the queue
+	TenantUsages []*PipelineQueueTenantUsage `json:"tenantUsages,omitempty"`
+}
+
+// PipelineQueueTenantUsage represents one tenant's usage inside a queue.
+type PipelineQueueTenantUsage struct {
+	Tenant          string  `json:"tenant"`
+	Weight          int64   `json:"weight"`
+	ProcessingCount int64   `json:"processingCount"`
+	PendingCount    int64   `json:"pendingCount"`
+	InUseCPU        float64 `json:"inUseCPU"`
+	InUseMemoryMB   float64 `json:"inUseMemoryMB"`
+	// Share is the percentage of processing count this tenant occupied
+	Share float64 `json:"share"`
+	// ExpectedShare is the percentage calculated by weight among active tenants
+	ExpectedShare float64 `json:"expectedShare"`
 }
 
 // ScheduleStrategyInsidePipelineQueue represents the schedule strategy of workflows inside a queue.
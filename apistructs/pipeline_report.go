@@ -49,6 +49,8 @@ var (
 	PipelineReportTypeBasic   PipelineReportType = "basic"
 	PipelineReportTypeAPITest PipelineReportType = "api-test"
 	PipelineReportTypeEvent   PipelineReportType = "event"
+	// PipelineReportTypeCoverage 代码覆盖率报告，由 coverage-report 插件根据 action 声明的覆盖率文件生成
+	PipelineReportTypeCoverage PipelineReportType = "coverage"
 )
 
 // PipelineReportMeta 流水线报告元数据，前端根据该数据拼装报告详情界面
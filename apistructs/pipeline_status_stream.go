@@ -0,0 +1,74 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PipelineStatusStreamEventType 状态流事件类型
+type PipelineStatusStreamEventType string
+
+var (
+	PipelineStatusStreamEventTypePipeline PipelineStatusStreamEventType = "pipeline" // 流水线状态变更
+	PipelineStatusStreamEventTypeStage    PipelineStatusStreamEventType = "stage"    // 阶段状态变更
+	PipelineStatusStreamEventTypeTask     PipelineStatusStreamEventType = "task"     // 任务状态变更，包含循环次数
+	PipelineStatusStreamEventTypeEvent    PipelineStatusStreamEventType = "event"    // 流水线事件，例如排队、定时跳过等
+	// PipelineStatusStreamEventTypeReset 无法从 lastEventID 续传时下发，客户端需要重新获取流水线详情
+	PipelineStatusStreamEventTypeReset PipelineStatusStreamEventType = "reset"
+)
+
+// PipelineStatusStreamEvent 状态流中的单个事件
+type PipelineStatusStreamEvent struct {
+	ID         string                        `json:"id"`
+	Type       PipelineStatusStreamEventType `json:"type"`
+	PipelineID uint64                        `json:"pipelineID,omitempty"`
+	StageID    uint64                        `json:"stageID,omitempty"`
+	TaskID     uint64                        `json:"taskID,omitempty"`
+	TaskName   string                        `json:"taskName,omitempty"`
+	Status     PipelineStatus                `json:"status,omitempty"`
+	// LoopedTimes 任务已循环次数
+	LoopedTimes uint64         `json:"loopedTimes,omitempty"`
+	CostTimeSec int64          `json:"costTimeSec,omitempty"`
+	Event       *PipelineEvent `json:"event,omitempty"`
+	Timestamp   time.Time      `json:"timestamp"`
+}
+
+// PipelineStatusStreamRequest 订阅状态流，pipelineID 与 labelSelector 至少指定一个
+type PipelineStatusStreamRequest struct {
+	PipelineID uint64 `schema:"pipelineID"`
+	// LabelSelector 格式为 k1=v1,k2=v2，需全部匹配
+	LabelSelector string `schema:"labelSelector"`
+	// LastEventID 断线重连时从该事件之后续传，也可以通过 Last-Event-ID 请求头指定
+	LastEventID string `schema:"lastEventID"`
+}
+
+// ParseLabelSelector 解析 k1=v1,k2=v2 格式的 label selector
+func ParseLabelSelector(selector string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, kv := range strings.Split(selector, ",") {
+		kv = strings.TrimSpace(kv)
+		if kv == "" {
+			continue
+		}
+		idx := strings.Index(kv, "=")
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid label selector: %s", kv)
+		}
+		labels[strings.TrimSpace(kv[:idx])] = strings.TrimSpace(kv[idx+1:])
+	}
+	return labels, nil
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	labels, err := ParseLabelSelector("appID=1, branch = master,,")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"appID": "1", "branch": "master"}, labels)
+
+	labels, err = ParseLabelSelector("")
+	assert.NoError(t, err)
+	assert.Empty(t, labels)
+
+	_, err = ParseLabelSelector("appID")
+	assert.Error(t, err)
+	_, err = ParseLabelSelector("=1")
+	assert.Error(t, err)
+}
@@ -43,8 +43,9 @@ type PipelineTaskDTO struct {
 }
 
 type PipelineTaskExtra struct {
-	UUID         string `json:"uuid"`
-	AllowFailure bool   `json:"allowFailure"`
+	UUID          string                     `json:"uuid"`
+	AllowFailure  bool                       `json:"allowFailure"`
+	RetryAttempts []PipelineTaskRetryAttempt `json:"retryAttempts,omitempty"` // 失败重试的历史执行记录
 }
 
 type PipelineTaskResult struct {
@@ -157,6 +158,66 @@ var PipelineTaskDefaultLoopStrategy = LoopStrategy{
 	IntervalSec:     2,  // 默认时间间隔为 5s
 }
 
+type PipelineTaskRetryBackoff string
+
+var (
+	PipelineTaskRetryBackoffFixed       PipelineTaskRetryBackoff = "fixed"
+	PipelineTaskRetryBackoffExponential PipelineTaskRetryBackoff = "exponential"
+)
+
+// PipelineTaskRetryReason 表示触发失败重试的原因
+type PipelineTaskRetryReason string
+
+var (
+	PipelineTaskRetryReasonFailed        PipelineTaskRetryReason = "failed"         // 任意失败
+	PipelineTaskRetryReasonTimeout       PipelineTaskRetryReason = "timeout"        // 执行超时
+	PipelineTaskRetryReasonExecutorError PipelineTaskRetryReason = "executor_error" // 执行器异常，例如创建、启动失败
+	PipelineTaskRetryReasonImagePull     PipelineTaskRetryReason = "image_pull"     // 镜像拉取失败
+	PipelineTaskRetryReasonOOM           PipelineTaskRetryReason = "oom"            // 内存溢出
+	PipelineTaskRetryReasonExitCode      PipelineTaskRetryReason = "exit_code"      // 命中指定的退出码
+)
+
+func (r PipelineTaskRetryReason) Valid() bool {
+	switch r {
+	case PipelineTaskRetryReasonFailed, PipelineTaskRetryReasonTimeout, PipelineTaskRetryReasonExecutorError,
+		PipelineTaskRetryReasonImagePull, PipelineTaskRetryReasonOOM:
+		return true
+	default:
+		return false
+	}
+}
+
+// PipelineTaskRetry 失败重试配置，与 loop 不同，只在任务失败时根据失败原因重新执行
+type PipelineTaskRetry struct {
+	MaxAttempts    int                       `json:"maxAttempts" yaml:"max_attempts"`                            // 最大执行次数，包含第一次执行
+	Backoff        PipelineTaskRetryBackoff  `json:"backoff,omitempty" yaml:"backoff,omitempty"`                 // 退避策略，默认 fixed
+	IntervalSec    uint64                    `json:"intervalSec,omitempty" yaml:"interval_sec,omitempty"`        // 重试间隔，exponential 时为初始间隔
+	MaxIntervalSec uint64                    `json:"maxIntervalSec,omitempty" yaml:"max_interval_sec,omitempty"` // exponential 时的最大间隔
+	When           []PipelineTaskRetryReason `json:"when,omitempty" yaml:"when,omitempty"`                       // 触发重试的失败原因，为空且未声明 exitCodes 时任意失败均重试
+	ExitCodes      []int                     `json:"exitCodes,omitempty" yaml:"exit_codes,omitempty"`            // 触发重试的退出码
+}
+
+var PipelineTaskDefaultRetryIntervalSec uint64 = 10
+
+// PipelineTaskRetryOptions 运行时的重试信息
+type PipelineTaskRetryOptions struct {
+	Retry    *PipelineTaskRetry         `json:"retry,omitempty"`    // task 指定的重试配置
+	Attempts []PipelineTaskRetryAttempt `json:"attempts,omitempty"` // 已失败的执行记录
+}
+
+// PipelineTaskRetryAttempt 表示一次失败的执行
+type PipelineTaskRetryAttempt struct {
+	Attempt     int                     `json:"attempt"`               // 第几次执行，从 1 开始
+	LoopedTimes uint64                  `json:"loopedTimes,omitempty"` // 所属的循环次数
+	Status      PipelineStatus          `json:"status"`
+	Reason      PipelineTaskRetryReason `json:"reason"`
+	Desc        string                  `json:"desc,omitempty"`
+	TimeBegin   time.Time               `json:"timeBegin"`
+	TimeEnd     time.Time               `json:"timeEnd"`
+	CostTimeSec int64                   `json:"costTimeSec"`
+	Errors      []ErrorResponse         `json:"errors,omitempty"`
+}
+
 /**
 desc: xxx
 priority:
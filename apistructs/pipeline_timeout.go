@@ -0,0 +1,72 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+import (
+	"time"
+)
+
+// PipelineTimeoutReason 流水线超时原因
+type PipelineTimeoutReason string
+
+const (
+	// PipelineTimeoutReasonQueue 排队超时
+	PipelineTimeoutReasonQueue PipelineTimeoutReason = "QueueTimeout"
+	// PipelineTimeoutReasonExec 流水线执行超时
+	PipelineTimeoutReasonExec PipelineTimeoutReason = "ExecTimeout"
+	// PipelineTimeoutReasonStage stage 执行超时
+	PipelineTimeoutReasonStage PipelineTimeoutReason = "StageTimeout"
+)
+
+func (r PipelineTimeoutReason) String() string {
+	return string(r)
+}
+
+// PipelineTimeoutOptions 流水线级别超时配置，排队时间与执行时间分开计算，单位均为秒，<= 0 表示不限制
+type PipelineTimeoutOptions struct {
+	TimeoutSec      int64 `json:"timeoutSec,omitempty"`
+	QueueTimeoutSec int64 `json:"queueTimeoutSec,omitempty"`
+	// HasStageTimeout 是否有 stage 配置了超时时间
+	HasStageTimeout bool `json:"hasStageTimeout,omitempty"`
+
+	// TimeExecBegin 出队开始执行的时间，执行超时从该时间开始计算
+	TimeExecBegin *time.Time `json:"timeExecBegin,omitempty"`
+	// QueueCostTimeSec 排队耗时
+	QueueCostTimeSec int64 `json:"queueCostTimeSec,omitempty"`
+	// Reason 超时原因
+	Reason PipelineTimeoutReason `json:"reason,omitempty"`
+	// TimeoutStageID 超时的 stage
+	TimeoutStageID uint64 `json:"timeoutStageID,omitempty"`
+}
+
+// NeedWatch 是否需要在执行过程中检查超时
+func (opt *PipelineTimeoutOptions) NeedWatch() bool {
+	return opt != nil && (opt.TimeoutSec > 0 || opt.HasStageTimeout)
+}
+
+// QueueDeadline 根据排队开始时间计算排队截止时间，无需限制时返回 false
+func (opt *PipelineTimeoutOptions) QueueDeadline(timeQueueBegin time.Time) (time.Time, bool) {
+	if opt == nil || opt.QueueTimeoutSec <= 0 {
+		return time.Time{}, false
+	}
+	return timeQueueBegin.Add(time.Duration(opt.QueueTimeoutSec) * time.Second), true
+}
+
+// ExecDeadline 根据出队时间计算执行截止时间，无需限制时返回 false
+func (opt *PipelineTimeoutOptions) ExecDeadline() (time.Time, bool) {
+	if opt == nil || opt.TimeoutSec <= 0 || opt.TimeExecBegin == nil {
+		return time.Time{}, false
+	}
+	return opt.TimeExecBegin.Add(time.Duration(opt.TimeoutSec) * time.Second), true
+}
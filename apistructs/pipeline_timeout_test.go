@@ -0,0 +1,49 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelineTimeoutOptions(t *testing.T) {
+	var nilOpt *PipelineTimeoutOptions
+	assert.False(t, nilOpt.NeedWatch())
+	_, ok := nilOpt.QueueDeadline(time.Now())
+	assert.False(t, ok)
+	_, ok = nilOpt.ExecDeadline()
+	assert.False(t, ok)
+
+	now := time.Now()
+	opt := &PipelineTimeoutOptions{QueueTimeoutSec: 60}
+	assert.False(t, opt.NeedWatch())
+	deadline, ok := opt.QueueDeadline(now)
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(time.Minute), deadline)
+
+	// 执行超时从出队时间开始计算
+	opt = &PipelineTimeoutOptions{TimeoutSec: 120}
+	assert.True(t, opt.NeedWatch())
+	_, ok = opt.ExecDeadline()
+	assert.False(t, ok)
+	opt.TimeExecBegin = &now
+	deadline, ok = opt.ExecDeadline()
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(time.Minute*2), deadline)
+
+	assert.True(t, (&PipelineTimeoutOptions{HasStageTimeout: true}).NeedWatch())
+}
@@ -37,12 +37,16 @@ const (
 
 type PipelineYml struct {
 	// 用于构造 pipeline yml
-	Version         string                 `json:"version"`                   // 版本
-	Envs            map[string]string      `json:"envs,omitempty"`            // 环境变量
-	Cron            string                 `json:"cron,omitempty"`            // 定时配置
-	CronCompensator *CronCompensator       `json:"cronCompensator,omitempty"` // 定时补偿配置
-	Stages          [][]*PipelineYmlAction `json:"stages"`                    // 流水线
-	FlatActions     []*PipelineYmlAction   `json:"flatActions"`               // 展平了的流水线
+	Version           string                 `json:"version"`                     // 版本
+	Envs              map[string]string      `json:"envs,omitempty"`              // 环境变量
+	Cron              string                 `json:"cron,omitempty"`              // 定时配置
+	CronCompensator   *CronCompensator       `json:"cronCompensator,omitempty"`   // 定时补偿配置
+	ConcurrencyPolicy string                 `json:"concurrencyPolicy,omitempty"` // 定时触发并发策略: Allow/Forbid/Replace
+	Timeout           int64                  `json:"timeout,omitempty"`           // 执行超时时间，单位秒，不包含排队时间
+	QueueTimeout      int64                  `json:"queueTimeout,omitempty"`      // 排队超时时间，单位秒
+	Stages            [][]*PipelineYmlAction `json:"stages"`                      // 流水线
+	StageTimeouts     []int64                `json:"stageTimeouts,omitempty"`     // 各 stage 的超时时间，单位秒，与 stages 一一对应
+	FlatActions       []*PipelineYmlAction   `json:"flatActions"`                 // 展平了的流水线
 
 	Params []*PipelineParam `json:"params,omitempty"` // 流水线输入
 
@@ -102,7 +106,13 @@ type PipelineYmlAction struct {
 	SnippetConfig *SnippetConfig         `json:"snippet_config,omitempty" yaml:"snippet_config,omitempty"` // snippet 的配置
 	If            string                 `json:"if,omitempty"`                                             // 条件执行
 	Loop          *PipelineTaskLoop      `json:"loop,omitempty"`                                           // 循环执行
+	Retry         *PipelineTaskRetry     `json:"retry,omitempty"`                                          // 失败重试
+	Coverage      *ActionCoverage        `json:"coverage,omitempty"`                                       // 覆盖率文件
+	Needs         []string               `json:"needs,omitempty"`                                          // 显式声明依赖的 actions
 	SnippetStages *SnippetStages         `json:"snippetStages,omitempty"`                                  // snippetStages snippet 展开
+	Matrix        *ActionMatrix          `json:"matrix,omitempty"`                                         // 矩阵配置
+	MatrixActions []*PipelineYmlAction   `json:"matrixActions,omitempty"`                                  // matrixActions matrix 展开
+	MatrixValues  map[string]string      `json:"matrixValues,omitempty"`                                   // matrix 展开后 action 的维度值
 }
 
 type SnippetStages struct {
@@ -179,12 +189,22 @@ type PipelineOutputWithValue struct {
 	Value interface{} `json:"value,omitempty"` // 具体的值
 }
 
+type ActionMatrix struct {
+	Dimensions  map[string][]string `json:"dimensions,omitempty"`  // 维度
+	Include     []map[string]string `json:"include,omitempty"`     // 额外追加的组合
+	Exclude     []map[string]string `json:"exclude,omitempty"`     // 需要排除的组合
+	MaxParallel int                 `json:"maxParallel,omitempty"` // 最大并行数
+}
+
 type ActionCache struct {
 	// 缓存生成的 key 或者是用户指定的 key
 	// 用户指定的话 需要 {{basePath}}/路径/{{endPath}} 来自定义 key
 	// 用户没有指定 key 有一定的生成规则, 具体生成规则看 prepare.go 的 setActionCacheStorageAndBinds 方法
+	// key 中可以使用 ${{ hashFiles('**/go.sum') }} 表达式，在 action 容器中根据文件内容计算
 	Key  string `yaml:"key,omitempty"`
 	Path string `yaml:"path,omitempty"` // 指定那个目录被缓存, 只能是由 / 开始的绝对路径
+	// RestoreKeys key 未命中时按顺序使用的前缀列表，命中前缀的缓存中取最近更新的一个
+	RestoreKeys []string `yaml:"restore_keys,omitempty" json:"restoreKeys,omitempty"`
 }
 
 type CronCompensator struct {
@@ -0,0 +1,77 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package apistructs
+
+// PipelineYmlDiagnosticSeverity pipeline.yml 诊断级别
+type PipelineYmlDiagnosticSeverity string
+
+var (
+	PipelineYmlDiagnosticSeverityError   PipelineYmlDiagnosticSeverity = "error"
+	PipelineYmlDiagnosticSeverityWarning PipelineYmlDiagnosticSeverity = "warning"
+)
+
+// PipelineYmlPosition 位置，行列均从 1 开始；0 表示未知
+type PipelineYmlPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// PipelineYmlRange 左闭右开的位置区间
+type PipelineYmlRange struct {
+	Start PipelineYmlPosition `json:"start"`
+	End   PipelineYmlPosition `json:"end"`
+}
+
+// PipelineYmlQuickFix 快速修复建议，Range 为空时表示仅有提示、无法自动修复
+type PipelineYmlQuickFix struct {
+	Title   string            `json:"title"`
+	Range   *PipelineYmlRange `json:"range,omitempty"`
+	NewText string            `json:"newText,omitempty"`
+}
+
+// PipelineYmlDiagnostic pipeline.yml 诊断信息
+type PipelineYmlDiagnostic struct {
+	Severity PipelineYmlDiagnosticSeverity `json:"severity"`
+	Message  string                        `json:"message"`
+	Range    PipelineYmlRange              `json:"range"`
+	// StageIndex 从 0 开始，-1 表示与 stage 无关
+	StageIndex  int                   `json:"stageIndex"`
+	ActionAlias string                `json:"actionAlias,omitempty"`
+	Field       string                `json:"field,omitempty"`
+	QuickFixes  []PipelineYmlQuickFix `json:"quickFixes,omitempty"`
+}
+
+// PipelineYmlLintRequest 校验 pipeline.yml 请求
+type PipelineYmlLintRequest struct {
+	PipelineYmlContent string `json:"pipelineYmlContent"`
+	// SkipActionParams 为 true 时不从扩展市场查询 action spec 校验 params
+	SkipActionParams bool `json:"skipActionParams"`
+}
+
+type PipelineYmlLintResponse struct {
+	Header
+	Data *PipelineYmlLintResponseData `json:"data"`
+}
+
+type PipelineYmlLintResponseData struct {
+	// Valid 为 true 表示没有 error 级别的诊断
+	Valid       bool                    `json:"valid"`
+	Diagnostics []PipelineYmlDiagnostic `json:"diagnostics"`
+}
+
+// PipelineYmlSchemaResponse pipeline.yml 的 JSON Schema
+type PipelineYmlSchemaResponse struct {
+	Header
+	Data map[string]interface{} `json:"data"`
+}
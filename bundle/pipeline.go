@@ -392,3 +392,25 @@ func (b *Bundle) ParsePipelineYmlGraph(req apistructs.PipelineYmlParseGraphReque
 	}
 	return graphResp.Data, nil
 }
+
+// DryRunPipeline 试运行流水线，返回解析后的执行计划，不会创建流水线
+func (b *Bundle) DryRunPipeline(req apistructs.PipelineDryRunRequest) (*apistructs.PipelineDryRunPlan, error) {
+	host, err := b.urls.Pipeline()
+	if err != nil {
+		return nil, err
+	}
+	hc := b.hc
+
+	var dryRunResp apistructs.PipelineDryRunResponse
+	httpResp, err := hc.Post(host).Path("/api/pipelines/actions/dry-run").
+		Header(httputil.InternalHeader, "bundle").
+		JSONBody(&req).
+		Do().JSON(&dryRunResp)
+	if err != nil {
+		return nil, apierrors.ErrInvoke.InternalError(err)
+	}
+	if !httpResp.IsOK() || !dryRunResp.Success {
+		return nil, toAPIError(httpResp.StatusCode(), dryRunResp.Error)
+	}
+	return dryRunResp.Data, nil
+}
@@ -125,3 +125,66 @@ func (b *Bundle) ListPipelineCmsNs(req apistructs.PipelineCmsListNsRequest) ([]a
 	}
 	return listResp.Data, nil
 }
+
+func (b *Bundle) ListPipelineCmsNsConfigRevisions(ns string, req apistructs.PipelineCmsListConfigRevisionsRequest) ([]apistructs.PipelineCmsConfigRevision, error) {
+	host, err := b.urls.Pipeline()
+	if err != nil {
+		return nil, err
+	}
+	hc := b.hc
+
+	var listResp apistructs.PipelineCmsListConfigRevisionsResponse
+	httpResp, err := hc.Get(host).Path(fmt.Sprintf("/api/pipelines/cms/ns/%s/revisions", ns)).
+		Header(httputil.InternalHeader, "bundle").
+		JSONBody(&req).
+		Do().JSON(&listResp)
+	if err != nil {
+		return nil, apierrors.ErrInvoke.InternalError(err)
+	}
+	if !httpResp.IsOK() || !listResp.Success {
+		return nil, toAPIError(httpResp.StatusCode(), listResp.Error)
+	}
+	return listResp.Data, nil
+}
+
+func (b *Bundle) DiffPipelineCmsNsConfigRevisions(ns string, req apistructs.PipelineCmsDiffConfigRevisionsRequest) ([]apistructs.PipelineCmsConfigDiff, error) {
+	host, err := b.urls.Pipeline()
+	if err != nil {
+		return nil, err
+	}
+	hc := b.hc
+
+	var diffResp apistructs.PipelineCmsDiffConfigRevisionsResponse
+	httpResp, err := hc.Get(host).Path(fmt.Sprintf("/api/pipelines/cms/ns/%s/revisions/actions/diff", ns)).
+		Header(httputil.InternalHeader, "bundle").
+		JSONBody(&req).
+		Do().JSON(&diffResp)
+	if err != nil {
+		return nil, apierrors.ErrInvoke.InternalError(err)
+	}
+	if !httpResp.IsOK() || !diffResp.Success {
+		return nil, toAPIError(httpResp.StatusCode(), diffResp.Error)
+	}
+	return diffResp.Data, nil
+}
+
+func (b *Bundle) RollbackPipelineCmsNsConfigs(ns string, req apistructs.PipelineCmsRollbackConfigsRequest) error {
+	host, err := b.urls.Pipeline()
+	if err != nil {
+		return err
+	}
+	hc := b.hc
+
+	var rollbackResp apistructs.PipelineCmsRollbackConfigsResponse
+	httpResp, err := hc.Post(host).Path(fmt.Sprintf("/api/pipelines/cms/ns/%s/actions/rollback", ns)).
+		Header(httputil.InternalHeader, "bundle").
+		JSONBody(&req).
+		Do().JSON(&rollbackResp)
+	if err != nil {
+		return apierrors.ErrInvoke.InternalError(err)
+	}
+	if !httpResp.IsOK() || !rollbackResp.Success {
+		return toAPIError(httpResp.StatusCode(), rollbackResp.Error)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package agenttool
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// 匹配 ${{ hashFiles('a', "b") }}
+	hashFilesRe    = regexp.MustCompile(`\${{\s*hashFiles\(([^)]*)\)\s*}}`)
+	hashFilesArgRe = regexp.MustCompile(`'([^']*)'|"([^"]*)"`)
+)
+
+const (
+	globMetaChars  = "*?["
+	globDoubleStar = "**"
+)
+
+// RenderHashFiles 计算表达式中所有的 hashFiles，相对路径基于 baseDir
+func RenderHashFiles(expr, baseDir string) (string, error) {
+	var renderErr error
+	rendered := hashFilesRe.ReplaceAllStringFunc(expr, func(sub string) string {
+		var patterns []string
+		for _, arg := range hashFilesArgRe.FindAllStringSubmatch(hashFilesRe.FindStringSubmatch(sub)[1], -1) {
+			patterns = append(patterns, arg[1]+arg[2])
+		}
+		hash, err := HashFiles(baseDir, patterns...)
+		if err != nil && renderErr == nil {
+			renderErr = err
+		}
+		return hash
+	})
+	if renderErr != nil {
+		return "", renderErr
+	}
+	return rendered, nil
+}
+
+// HashFiles 计算匹配 patterns 的所有文件的 sha256，支持 ** 匹配多级目录
+// 按路径排序后对每个文件内容的 sha256 再次计算 sha256，没有匹配的文件时返回空字符串
+func HashFiles(baseDir string, patterns ...string) (string, error) {
+	matched := make(map[string]struct{})
+	for _, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(baseDir, pattern)
+		}
+		files, err := globFiles(filepath.Clean(pattern))
+		if err != nil {
+			return "", err
+		}
+		for _, file := range files {
+			matched[file] = struct{}{}
+		}
+	}
+	if len(matched) == 0 {
+		return "", nil
+	}
+
+	var files []string
+	for file := range matched {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	hasher := sha256.New()
+	for _, file := range files {
+		fileHash, err := hashFile(file)
+		if err != nil {
+			return "", err
+		}
+		hasher.Write(fileHash)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// globFiles 从 pattern 中不含通配符的最长目录开始遍历，返回匹配的普通文件
+func globFiles(pattern string) ([]string, error) {
+	segments := strings.Split(pattern, string(filepath.Separator))
+	i := 0
+	for ; i < len(segments); i++ {
+		if strings.ContainsAny(segments[i], globMetaChars) {
+			break
+		}
+	}
+	root := string(filepath.Separator) + filepath.Join(segments[:i]...)
+	if i == len(segments) {
+		// 不含通配符，直接判断文件
+		if fi, err := os.Stat(root); err == nil && fi.Mode().IsRegular() {
+			return []string{root}, nil
+		}
+		return nil, nil
+	}
+	rest := segments[i:]
+
+	var files []string
+	err := filepath.Walk(root, func(file string, fi os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		ok, err := matchSegments(rest, strings.Split(rel, string(filepath.Separator)))
+		if err != nil {
+			return err
+		}
+		if ok {
+			files = append(files, file)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// matchSegments 按目录层级匹配，** 可以匹配零或多级目录
+func matchSegments(patterns, names []string) (bool, error) {
+	if len(patterns) == 0 {
+		return len(names) == 0, nil
+	}
+	if patterns[0] == globDoubleStar {
+		for i := 0; i <= len(names); i++ {
+			ok, err := matchSegments(patterns[1:], names[i:])
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+	if len(names) == 0 {
+		return false, nil
+	}
+	ok, err := filepath.Match(patterns[0], names[0])
+	if err != nil {
+		return false, fmt.Errorf("invalid hashFiles pattern %q: %v", strings.Join(patterns, "/"), err)
+	}
+	if !ok {
+		return false, nil
+	}
+	return matchSegments(patterns[1:], names[1:])
+}
+
+func hashFile(file string) ([]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return nil, err
+	}
+	return hasher.Sum(nil), nil
+}
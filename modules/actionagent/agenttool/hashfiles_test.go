@@ -0,0 +1,83 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package agenttool
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hashfiles")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "git-checkout", "sub"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "git-checkout", "go.sum"), []byte("a"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "git-checkout", "sub", "go.sum"), []byte("b"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "git-checkout", "go.mod"), []byte("c"), 0644))
+
+	all, err := HashFiles(dir, "**/go.sum")
+	assert.NoError(t, err)
+	assert.Len(t, all, 64)
+
+	// 绝对路径与相对路径结果一致
+	abs, err := HashFiles("/", filepath.Join(dir, "**", "go.sum"))
+	assert.NoError(t, err)
+	assert.Equal(t, all, abs)
+
+	root, err := HashFiles(dir, "git-checkout/go.sum")
+	assert.NoError(t, err)
+	assert.NotEqual(t, all, root)
+
+	none, err := HashFiles(dir, "**/package-lock.json")
+	assert.NoError(t, err)
+	assert.Equal(t, "", none)
+
+	// 内容变化后 hash 变化
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "git-checkout", "sub", "go.sum"), []byte("bb"), 0644))
+	changed, err := HashFiles(dir, "**/go.sum")
+	assert.NoError(t, err)
+	assert.NotEqual(t, all, changed)
+
+	key, err := RenderHashFiles("go-${{ hashFiles('**/go.sum', \"**/go.mod\") }}", dir)
+	assert.NoError(t, err)
+	multi, err := HashFiles(dir, "**/go.sum", "**/go.mod")
+	assert.NoError(t, err)
+	assert.Equal(t, "go-"+multi, key)
+}
+
+func TestMatchSegments(t *testing.T) {
+	for _, c := range []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"**/go.sum", "go.sum", true},
+		{"**/go.sum", "a/b/go.sum", true},
+		{"a/**/b/*.txt", "a/b/c.txt", true},
+		{"a/**/b/*.txt", "a/x/y/b/c.txt", true},
+		{"a/*/c.txt", "a/x/y/c.txt", false},
+		{"*.sum", "a/go.sum", false},
+	} {
+		ok, err := matchSegments(strings.Split(c.pattern, "/"), strings.Split(c.name, "/"))
+		assert.NoError(t, err)
+		assert.Equal(t, c.match, ok, c.pattern+" "+c.name)
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package agenttool
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/minio/minio-go"
+	"github.com/minio/minio-go/pkg/credentials"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/pkg/retry"
+)
+
+const (
+	objectStoragePartSize      = 16 << 20 // 分片上传每片大小
+	objectStorageRetryTimes    = 5
+	objectStorageRetryInterval = time.Second * 3
+)
+
+// ErrObjectNotFound 对象不存在，optional 上下文或缓存未命中时出现
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectStorage S3 兼容对象存储客户端，使用底层分片 API 实现流式上传与断点续传下载
+type ObjectStorage struct {
+	core   *minio.Core
+	bucket string
+}
+
+// NewObjectStorage sessionToken 不为空时使用 STS 临时凭证
+func NewObjectStorage(endpoint, region, bucket, accessKey, secretKey, sessionToken string, secure bool) (*ObjectStorage, error) {
+	client, err := minio.NewWithOptions(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, sessionToken),
+		Secure: secure,
+		Region: region,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectStorage{core: &minio.Core{Client: client}, bucket: bucket}, nil
+}
+
+// UploadDir 将 dir 边打包压缩边分片上传到 key，无需在本地生成临时文件，返回上传的字节数
+func (s *ObjectStorage) UploadDir(key, dir string) (int64, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(TarGz(pw, dir))
+	}()
+	size, err := s.upload(key, pr)
+	if err != nil {
+		// 终止打包
+		pr.CloseWithError(err)
+		return 0, errors.Wrapf(err, "failed to upload %s to object %s", dir, key)
+	}
+	return size, nil
+}
+
+func (s *ObjectStorage) upload(key string, r io.Reader) (int64, error) {
+	uploadID, err := s.core.NewMultipartUpload(s.bucket, key, minio.PutObjectOptions{ContentType: "application/gzip"})
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	var parts []minio.CompletePart
+	buf := make([]byte, objectStoragePartSize)
+	for partID := 1; ; partID++ {
+		n, readErr := io.ReadFull(r, buf)
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			s.abort(key, uploadID)
+			return 0, readErr
+		}
+		// 最后一片可能为空，但至少需要上传一片
+		if n > 0 || partID == 1 {
+			var part minio.ObjectPart
+			err := retry.DoWithInterval(func() error {
+				var err error
+				part, err = s.core.PutObjectPart(s.bucket, key, uploadID, partID, bytes.NewReader(buf[:n]), int64(n), "", "", nil)
+				return err
+			}, objectStorageRetryTimes, objectStorageRetryInterval)
+			if err != nil {
+				s.abort(key, uploadID)
+				return 0, err
+			}
+			parts = append(parts, minio.CompletePart{PartNumber: part.PartNumber, ETag: part.ETag})
+			size += int64(n)
+		}
+		if readErr != nil {
+			break
+		}
+	}
+	if _, err := s.core.CompleteMultipartUpload(s.bucket, key, uploadID, parts); err != nil {
+		s.abort(key, uploadID)
+		return 0, err
+	}
+	return size, nil
+}
+
+func (s *ObjectStorage) abort(key, uploadID string) {
+	if err := s.core.AbortMultipartUpload(s.bucket, key, uploadID); err != nil {
+		logrus.Printf("failed to abort multipart upload, object: %s, uploadID: %s, err: %v", key, uploadID, err)
+	}
+}
+
+// DownloadAndExtract 下载 key 并解压到 destDir
+// 下载中断时从已下载的位置继续，并通过 ETag 保证续传的是同一个对象
+func (s *ObjectStorage) DownloadAndExtract(key, destDir string) error {
+	info, err := s.core.StatObject(s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		if isObjectNotFound(err) {
+			return ErrObjectNotFound
+		}
+		return err
+	}
+
+	f, err := ioutil.TempFile("", "object-*.tar.gz")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+
+	var offset int64
+	err = retry.DoWithInterval(func() error {
+		for offset < info.Size {
+			opts := minio.GetObjectOptions{}
+			if err := opts.SetMatchETag(info.ETag); err != nil {
+				return err
+			}
+			if offset > 0 {
+				if err := opts.SetRange(offset, 0); err != nil {
+					return err
+				}
+			}
+			body, _, err := s.core.GetObject(s.bucket, key, opts)
+			if err != nil {
+				return err
+			}
+			n, err := io.Copy(f, body)
+			body.Close()
+			offset += n
+			if err != nil {
+				logrus.Printf("download object %s interrupted at %d/%d bytes, err: %v", key, offset, info.Size, err)
+				return err
+			}
+			if n == 0 {
+				return errors.Errorf("unexpected empty response when download object %s at %d/%d bytes", key, offset, info.Size)
+			}
+		}
+		return nil
+	}, objectStorageRetryTimes, objectStorageRetryInterval)
+	if err != nil {
+		return errors.Wrapf(err, "failed to download object %s", key)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := UnTarGz(f, destDir); err != nil {
+		return errors.Wrapf(err, "failed to extract object %s into %s", key, destDir)
+	}
+	return nil
+}
+
+// LatestObjectWithPrefix 返回以 prefix 开头的对象中最近更新的一个，不存在时返回 ErrObjectNotFound
+func (s *ObjectStorage) LatestObjectWithPrefix(prefix string) (string, error) {
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	var latest minio.ObjectInfo
+	for obj := range s.core.Client.ListObjectsV2(s.bucket, prefix, true, doneCh) {
+		if obj.Err != nil {
+			return "", obj.Err
+		}
+		if latest.Key == "" || obj.LastModified.After(latest.LastModified) {
+			latest = obj
+		}
+	}
+	if latest.Key == "" {
+		return "", ErrObjectNotFound
+	}
+	return latest.Key, nil
+}
+
+// Exists 判断对象是否存在
+func (s *ObjectStorage) Exists(key string) (bool, error) {
+	if _, err := s.core.StatObject(s.bucket, key, minio.StatObjectOptions{}); err != nil {
+		if isObjectNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Remove 删除对象
+func (s *ObjectStorage) Remove(key string) error {
+	return s.core.RemoveObject(s.bucket, key)
+}
+
+func isObjectNotFound(err error) bool {
+	resp := minio.ToErrorResponse(err)
+	return resp.StatusCode == http.StatusNotFound || resp.Code == "NoSuchKey"
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package agenttool
+
+import (
+	archivetar "archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTarGzRoundTrip(t *testing.T) {
+	src, err := ioutil.TempDir("", "targz-src")
+	assert.NoError(t, err)
+	defer os.RemoveAll(src)
+	dst, err := ioutil.TempDir("", "targz-dst")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dst)
+
+	ns := filepath.Join(src, "git-checkout")
+	assert.NoError(t, os.MkdirAll(filepath.Join(ns, "sub", "empty"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(ns, "a.txt"), []byte("a"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(ns, "sub", "run.sh"), []byte("echo"), 0755))
+	assert.NoError(t, os.Symlink("a.txt", filepath.Join(ns, "link")))
+
+	var buf bytes.Buffer
+	assert.NoError(t, TarGz(&buf, ns))
+	assert.NoError(t, UnTarGz(&buf, dst))
+
+	b, err := ioutil.ReadFile(filepath.Join(dst, "git-checkout", "a.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "a", string(b))
+	fi, err := os.Stat(filepath.Join(dst, "git-checkout", "sub", "run.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), fi.Mode().Perm())
+	fi, err = os.Stat(filepath.Join(dst, "git-checkout", "sub", "empty"))
+	assert.NoError(t, err)
+	assert.True(t, fi.IsDir())
+	link, err := os.Readlink(filepath.Join(dst, "git-checkout", "link"))
+	assert.NoError(t, err)
+	assert.Equal(t, "a.txt", link)
+}
+
+func TestUnTarGzIllegalPath(t *testing.T) {
+	dst, err := ioutil.TempDir("", "targz-dst")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dst)
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := archivetar.NewWriter(gw)
+	assert.NoError(t, tw.WriteHeader(&archivetar.Header{Name: "../evil", Mode: 0644, Size: 1, Typeflag: archivetar.TypeReg}))
+	_, err = tw.Write([]byte("x"))
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+
+	assert.Error(t, UnTarGz(&buf, dst))
+	_, err = os.Stat(filepath.Join(filepath.Dir(dst), "evil"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -37,6 +37,10 @@ func (agent *Agent) Callback() {
 	cb := &Callback{}
 	defer func() {
 		cb.Errors = append(cb.Errors, agent.MergeErrors()...)
+		cb.CacheReports = agent.CacheReports
+		if field := agent.coverageMetadataField(); field != nil {
+			cb.AppendMetadataFields([]*apistructs.MetadataField{field})
+		}
 		agent.LockPushedMetaFileMap.Lock()
 		defer agent.LockPushedMetaFileMap.Unlock()
 		if err := agent.callbackToPipelinePlatform(cb); err != nil {
@@ -92,7 +96,7 @@ func (agent *Agent) callbackToPipelinePlatform(cb *Callback) (err error) {
 	}
 
 	// 如果全部为空，则不需要回调
-	if len(cb.Metadata) == 0 && len(cb.Errors) == 0 && cb.MachineStat == nil {
+	if len(cb.Metadata) == 0 && len(cb.Errors) == 0 && cb.MachineStat == nil && len(cb.CacheReports) == 0 {
 		return nil
 	}
 
@@ -47,6 +47,16 @@ type Agent struct {
 	Ctx      context.Context
 	Cancel   context.CancelFunc // cancel when logic done
 	ExitCode int
+
+	// CacheReports action 缓存的恢复与保存结果，随最终回调上报
+	CacheReports []apistructs.PipelineActionCacheReport
+	taskCaches   map[string]*taskCache
+
+	// CoverageReport 解析后的覆盖率结果，随最终回调以 metadata 上报
+	CoverageReport *apistructs.CoverageReport
+
+	// SecretMasker 对 run 的 stdout/stderr 进行脱敏
+	SecretMasker *SecretMasker
 }
 
 type AgentArg struct {
@@ -56,6 +66,10 @@ type AgentArg struct {
 	Context  spec.PipelineTaskContext `json:"context,omitempty"`  // 上下文
 
 	PrivateEnvs map[string]string `json:"privateEnvs,omitempty"`
+	// SecretEnvKeys 值为 secret 的 env，日志中需要脱敏
+	SecretEnvKeys []string `json:"secretEnvKeys,omitempty"`
+
+	Coverage *apistructs.ActionCoverage `json:"coverage,omitempty"` // 执行结束后需要解析的覆盖率文件
 
 	PipelineID     uint64 `json:"pipelineID"`
 	PipelineTaskID uint64 `json:"pipelineTaskID"`
@@ -65,6 +65,7 @@ func (agent *Agent) Execute(r io.Reader) {
 		return
 	}
 	defer func() {
+		agent.collectCoverage()
 		agent.store()
 	}()
 
@@ -0,0 +1,272 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package actionagent
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/pkg/desensitize"
+)
+
+const (
+	// secretMaskText 替换 secret 的文本
+	secretMaskText = "******"
+	// addMaskCommand 脚本通过输出 ::add-mask::value 注册需要脱敏的值
+	addMaskCommand = "::add-mask::"
+	// secretMinMaskLength 过短的值容易误伤普通日志，不做脱敏
+	secretMinMaskLength = 4
+	// maskWriterMaxBuffer 不完整的行超过该长度时提前输出
+	maskWriterMaxBuffer = 4 * 1024
+	// maskWriterFlushInterval 不完整的行（例如交互提示、进度条）最长等待时间
+	maskWriterFlushInterval = time.Second
+)
+
+// SecretMasker 在日志写入前将 secret 及其常见编码替换为 ******
+type SecretMasker struct {
+	mu       sync.RWMutex
+	secrets  map[string]struct{}
+	replacer *strings.Replacer
+}
+
+func NewSecretMasker() *SecretMasker {
+	return &SecretMasker{secrets: make(map[string]struct{})}
+}
+
+// Add 注册 secret，同时注册 base64、url 编码后的值；
+// 日志按行脱敏，多行的 secret（例如 PEM 私钥）同时按行注册
+func (m *SecretMasker) Add(secrets ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, secret := range secrets {
+		secret = strings.TrimSpace(secret)
+		if len(secret) < secretMinMaskLength {
+			continue
+		}
+		for _, v := range secretVariants(secret) {
+			m.secrets[v] = struct{}{}
+		}
+		if !strings.ContainsAny(secret, "\r\n") {
+			continue
+		}
+		for _, line := range strings.FieldsFunc(secret, func(r rune) bool { return r == '\r' || r == '\n' }) {
+			if line = strings.TrimSpace(line); len(line) >= secretMinMaskLength {
+				m.secrets[line] = struct{}{}
+			}
+		}
+	}
+	m.rebuildReplacer()
+}
+
+// Mask 返回脱敏后的内容
+func (m *SecretMasker) Mask(s string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.replacer == nil {
+		return s
+	}
+	return m.replacer.Replace(s)
+}
+
+// PartialSuffixLen 返回 s 末尾可能是某个 secret 开头部分的最长长度，这部分需要等待后续内容才能判断是否脱敏
+func (m *SecretMasker) PartialSuffixLen(s string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var longest int
+	for secret := range m.secrets {
+		n := len(secret) - 1
+		if n > len(s) {
+			n = len(s)
+		}
+		for ; n > longest; n-- {
+			if strings.HasSuffix(s, secret[:n]) {
+				longest = n
+				break
+			}
+		}
+	}
+	return longest
+}
+
+func (m *SecretMasker) rebuildReplacer() {
+	values := make([]string, 0, len(m.secrets))
+	for v := range m.secrets {
+		values = append(values, v)
+	}
+	// 长的优先匹配，避免 secret 互为子串时部分泄露
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+	oldnew := make([]string, 0, len(values)*2)
+	for _, v := range values {
+		oldnew = append(oldnew, v, secretMaskText)
+	}
+	m.replacer = strings.NewReplacer(oldnew...)
+}
+
+// secretVariants 返回 secret 本身及常见编码，例如 echo $SECRET | base64
+func secretVariants(secret string) []string {
+	variants := []string{
+		secret,
+		base64.StdEncoding.EncodeToString([]byte(secret)),
+		base64.StdEncoding.EncodeToString([]byte(secret + "\n")),
+		base64.RawStdEncoding.EncodeToString([]byte(secret)),
+		base64.URLEncoding.EncodeToString([]byte(secret)),
+		url.QueryEscape(secret),
+		url.PathEscape(secret),
+	}
+	var result []string
+	seen := make(map[string]struct{}, len(variants))
+	for _, v := range variants {
+		if _, ok := seen[v]; ok || len(v) < secretMinMaskLength {
+			continue
+		}
+		seen[v] = struct{}{}
+		result = append(result, v)
+	}
+	return result
+}
+
+// setupSecretMasker 根据平台下发的 secret env 及名称疑似 secret 的 env 初始化脱敏
+func (agent *Agent) setupSecretMasker() {
+	agent.SecretMasker = NewSecretMasker()
+	for _, key := range agent.Arg.SecretEnvKeys {
+		agent.SecretMasker.Add(os.Getenv(key))
+	}
+	for _, kv := range os.Environ() {
+		kvs := strings.SplitN(kv, "=", 2)
+		if len(kvs) == 2 && desensitize.IsSensitiveKey(kvs[0]) {
+			agent.SecretMasker.Add(kvs[1])
+		}
+	}
+	agent.SecretMasker.Add(agent.EasyUse.OpenAPIToken, agent.EasyUse.TokenForBootstrap, os.Getenv(apistructs.EnvOpenapiToken))
+}
+
+// maskWriter 按行（\n 或 \r 结尾）脱敏后写入，处理 ::add-mask:: 命令；
+// 不完整的行超过 maskWriterMaxBuffer 或等待超过 maskWriterFlushInterval 时提前输出，
+// 末尾可能是 secret 开头部分的内容会保留到下次输出；需要调用 Close 输出剩余内容
+type maskWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	masker *SecretMasker
+	buf    bytes.Buffer
+	// midLine 当前行已部分输出，剩余部分不再识别为 ::add-mask:: 命令
+	midLine bool
+	done    chan struct{}
+	closed  bool
+}
+
+func newMaskWriter(w io.Writer, masker *SecretMasker) *maskWriter {
+	mw := &maskWriter{w: w, masker: masker, done: make(chan struct{})}
+	go mw.continuousFlush()
+	return mw
+}
+
+func (mw *maskWriter) Write(p []byte) (int, error) {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	mw.buf.Write(p)
+	for {
+		i := bytes.IndexAny(mw.buf.Bytes(), "\r\n")
+		if i < 0 {
+			break
+		}
+		line := string(mw.buf.Next(i + 1))
+		if _, err := io.WriteString(mw.w, mw.maskLine(line)); err != nil {
+			return len(p), err
+		}
+	}
+	if mw.buf.Len() > maskWriterMaxBuffer {
+		if err := mw.flushPartial(); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+func (mw *maskWriter) Close() error {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	if !mw.closed {
+		mw.closed = true
+		close(mw.done)
+	}
+	if mw.buf.Len() == 0 {
+		return nil
+	}
+	line := mw.buf.String()
+	mw.buf.Reset()
+	_, err := io.WriteString(mw.w, mw.maskLine(line))
+	return err
+}
+
+func (mw *maskWriter) continuousFlush() {
+	ticker := time.NewTicker(maskWriterFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-mw.done:
+			return
+		case <-ticker.C:
+			mw.mu.Lock()
+			_ = mw.flushPartial()
+			mw.mu.Unlock()
+		}
+	}
+}
+
+// flushPartial 输出不完整的行中可以确定脱敏结果的部分，调用方需持有锁
+func (mw *maskWriter) flushPartial() error {
+	content := mw.buf.String()
+	if content == "" {
+		return nil
+	}
+	// 可能是 ::add-mask:: 命令，需要等待整行
+	if !mw.midLine {
+		trimmed := strings.TrimLeft(content, " \t")
+		if strings.HasPrefix(trimmed, addMaskCommand) || strings.HasPrefix(addMaskCommand, trimmed) {
+			return nil
+		}
+	}
+	n := len(content) - mw.masker.PartialSuffixLen(content)
+	if n <= 0 {
+		return nil
+	}
+	mw.buf.Next(n)
+	mw.midLine = true
+	_, err := io.WriteString(mw.w, mw.masker.Mask(content[:n]))
+	return err
+}
+
+func (mw *maskWriter) maskLine(line string) string {
+	midLine := mw.midLine
+	mw.midLine = false
+	content := strings.TrimSpace(line)
+	if !midLine && strings.HasPrefix(content, addMaskCommand) {
+		mw.masker.Add(strings.TrimPrefix(content, addMaskCommand))
+		return addMaskCommand + secretMaskText + line[len(strings.TrimRight(line, "\r\n")):]
+	}
+	return mw.masker.Mask(line)
+}
@@ -0,0 +1,261 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package actionagent
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/actionagent/agenttool"
+	"github.com/erda-project/erda/modules/pipeline/pipengine/pvolumes"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+	"github.com/erda-project/erda/pkg/filehelper"
+)
+
+// taskCache 运行时的 action 缓存，restore 时计算 key，store 时复用同一个 key
+type taskCache struct {
+	vo          apistructs.MetadataField
+	dynamic     bool
+	key         string   // 静态缓存为目录 hash，动态缓存为计算后的 key
+	restoreKeys []string // 计算后的 restore_keys 前缀
+	exactHit    bool
+
+	storage *agenttool.ObjectStorage // 对象存储类型的缓存使用
+}
+
+func (agent *Agent) getTaskCache(vo apistructs.MetadataField) (*taskCache, error) {
+	if c, ok := agent.taskCaches[vo.Name]; ok {
+		return c, nil
+	}
+	c := &taskCache{vo: vo, key: vo.Labels[pvolumes.TaskCacheHashName]}
+	if tmpl, ok := vo.Labels[pvolumes.TaskCacheKey]; ok {
+		c.dynamic = true
+		key, err := agent.renderTaskCacheKey(tmpl)
+		if err != nil {
+			return nil, err
+		}
+		c.key = key
+		var restoreKeys []string
+		if s := vo.Labels[pvolumes.TaskCacheRestoreKeys]; s != "" {
+			if err := json.Unmarshal([]byte(s), &restoreKeys); err != nil {
+				return nil, errors.Wrapf(err, "invalid restore_keys of cache %s", c.path())
+			}
+		}
+		for _, restoreKey := range restoreKeys {
+			prefix, err := agent.renderTaskCacheKey(restoreKey)
+			if err != nil {
+				return nil, err
+			}
+			if prefix != "" {
+				c.restoreKeys = append(c.restoreKeys, prefix)
+			}
+		}
+	}
+	if vo.Type == string(spec.StoreTypeDiceCacheOSS) {
+		storage, err := newObjectStorage()
+		if err != nil {
+			return nil, err
+		}
+		c.storage = storage
+	}
+	if agent.taskCaches == nil {
+		agent.taskCaches = make(map[string]*taskCache)
+	}
+	agent.taskCaches[vo.Name] = c
+	return c, nil
+}
+
+// renderTaskCacheKey 计算 key 中的 hashFiles 表达式，相对路径基于容器上下文目录
+func (agent *Agent) renderTaskCacheKey(tmpl string) (string, error) {
+	key, err := agenttool.RenderHashFiles(tmpl, agent.EasyUse.ContainerContext)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to render cache key %q", tmpl)
+	}
+	return pvolumes.SanitizeTaskCacheKey(key), nil
+}
+
+func (c *taskCache) path() string {
+	return c.vo.Labels[pvolumes.TaskCachePath]
+}
+
+// location 返回 key 对应的缓存文件路径或 object key
+func (c *taskCache) location(key string) string {
+	if c.storage != nil {
+		if !c.dynamic {
+			return c.vo.Value
+		}
+		return path.Join(c.vo.Value, key+pvolumes.ObjectStorageCompressionSuffix)
+	}
+	return filepath.Join(c.vo.Value, key+pvolumes.TaskCacheCompressionSuffix)
+}
+
+func (c *taskCache) exists(location string) (bool, error) {
+	if c.storage != nil {
+		return c.storage.Exists(location)
+	}
+	return filehelper.CheckExist(location, false) == nil, nil
+}
+
+// latestWithPrefix 返回 key 以 prefix 开头的缓存中最近更新的一个
+func (c *taskCache) latestWithPrefix(prefix string) (string, error) {
+	if c.storage != nil {
+		location, err := c.storage.LatestObjectWithPrefix(path.Join(c.vo.Value, prefix))
+		if err == agenttool.ErrObjectNotFound {
+			return "", nil
+		}
+		return location, err
+	}
+	files, err := ioutil.ReadDir(c.vo.Value)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	var latest os.FileInfo
+	for _, fi := range files {
+		if !fi.Mode().IsRegular() || !strings.HasPrefix(fi.Name(), prefix) || !strings.HasSuffix(fi.Name(), pvolumes.TaskCacheCompressionSuffix) {
+			continue
+		}
+		if latest == nil || fi.ModTime().After(latest.ModTime()) {
+			latest = fi
+		}
+	}
+	if latest == nil {
+		return "", nil
+	}
+	return filepath.Join(c.vo.Value, latest.Name()), nil
+}
+
+func (c *taskCache) restore(location string) error {
+	destDir := filepath.Dir(c.path())
+	if c.storage != nil {
+		return c.storage.DownloadAndExtract(location, destDir)
+	}
+	return agenttool.UnTar(location, destDir)
+}
+
+func (c *taskCache) store(location string) (int64, error) {
+	if c.storage != nil {
+		return c.storage.UploadDir(location, c.path())
+	}
+	if err := agenttool.Tar(location, c.path()); err != nil {
+		return 0, err
+	}
+	fi, err := os.Stat(location)
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+// restoreCache 按 key、restore_keys 的顺序查找缓存并解压，缓存失败不影响 action 执行
+func (agent *Agent) restoreCache(in apistructs.MetadataField) {
+	report := apistructs.PipelineActionCacheReport{
+		Operation:   apistructs.PipelineActionCacheOperationRestore,
+		StorageType: in.Type,
+		Path:        in.Labels[pvolumes.TaskCachePath],
+		Result:      apistructs.PipelineActionCacheRestoreMiss,
+	}
+	defer func() {
+		agent.CacheReports = append(agent.CacheReports, report)
+	}()
+
+	c, err := agent.getTaskCache(in)
+	if err != nil {
+		logrus.Printf("%s restore error: %v", in.Type, err)
+		return
+	}
+	report.Key = c.key
+	if c.key == "" {
+		logrus.Printf("not get action cache: %s, cache key is empty", c.path())
+		return
+	}
+
+	location := c.location(c.key)
+	result := apistructs.PipelineActionCacheRestoreHit
+	exist, err := c.exists(location)
+	if err != nil {
+		logrus.Printf("%s restore error: %v", in.Type, err)
+		return
+	}
+	if !exist {
+		location, result = "", apistructs.PipelineActionCacheRestorePartialHit
+		for _, prefix := range c.restoreKeys {
+			if location, err = c.latestWithPrefix(prefix); err != nil {
+				logrus.Printf("%s restore error: %v", in.Type, err)
+				return
+			}
+			if location != "" {
+				break
+			}
+		}
+	}
+	if location == "" {
+		logrus.Printf("not get action cache: %s", c.path())
+		return
+	}
+	if err := c.restore(location); err != nil {
+		logrus.Printf("%s restore error: %v", in.Type, err)
+		return
+	}
+	c.exactHit = result == apistructs.PipelineActionCacheRestoreHit
+	report.Location = location
+	report.Result = result
+	logrus.Printf("get action cache: %s success (%s, %s)", c.path(), result, location)
+}
+
+// storeCache 保存缓存，动态缓存的 key 精确命中时缓存内容不变，无需重复上传
+func (agent *Agent) storeCache(out apistructs.MetadataField) {
+	if filehelper.CheckExist(out.Labels[pvolumes.TaskCachePath], true) != nil {
+		logrus.Printf("upload action cache error: %s is not dir", out.Labels[pvolumes.TaskCachePath])
+		return
+	}
+	c, err := agent.getTaskCache(out)
+	if err != nil {
+		logrus.Printf("%s store error: %v", out.Type, err)
+		return
+	}
+	if c.key == "" {
+		logrus.Printf("skip upload action cache: %s, cache key is empty", c.path())
+		return
+	}
+	if c.dynamic && c.exactHit {
+		logrus.Printf("skip upload action cache: %s, cache hit on key %s", c.path(), c.key)
+		return
+	}
+	location := c.location(c.key)
+	size, err := c.store(location)
+	if err != nil {
+		logrus.Printf("%s store error: %v", out.Type, err)
+		return
+	}
+	agent.CacheReports = append(agent.CacheReports, apistructs.PipelineActionCacheReport{
+		Operation:   apistructs.PipelineActionCacheOperationStore,
+		StorageType: out.Type,
+		Path:        c.path(),
+		Key:         c.key,
+		Location:    location,
+		SizeBytes:   size,
+	})
+	logrus.Printf("upload action cache %s success", c.path())
+}
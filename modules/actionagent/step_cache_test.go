@@ -0,0 +1,88 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package actionagent
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/pipengine/pvolumes"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+func TestAgent_RestoreAndStoreDynamicCache(t *testing.T) {
+	root, err := ioutil.TempDir("", "action-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+
+	contextDir := filepath.Join(root, "context")
+	cacheDir := filepath.Join(root, "caches")
+	cachePath := filepath.Join(root, "home", ".m2")
+	assert.NoError(t, os.MkdirAll(filepath.Join(contextDir, "git-checkout"), 0755))
+	assert.NoError(t, os.MkdirAll(cacheDir, 0755))
+	assert.NoError(t, os.MkdirAll(cachePath, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(contextDir, "git-checkout", "pom.xml"), []byte("v1"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(cachePath, "dep.jar"), []byte("jar"), 0644))
+
+	vo := apistructs.MetadataField{
+		Name:  "action_cache_x",
+		Type:  string(spec.StoreTypeDiceCacheNFS),
+		Value: cacheDir,
+		Labels: map[string]string{
+			pvolumes.TaskCachePath:        cachePath,
+			pvolumes.TaskCacheKey:         "maven-${{ hashFiles('**/pom.xml') }}",
+			pvolumes.TaskCacheRestoreKeys: `["maven-"]`,
+		},
+	}
+	newAgent := func() *Agent {
+		return &Agent{EasyUse: EasyUse{ContainerContext: contextDir}}
+	}
+
+	// 首次运行未命中，保存缓存
+	agent := newAgent()
+	agent.restoreCache(vo)
+	agent.storeCache(vo)
+	assert.Len(t, agent.CacheReports, 2)
+	assert.Equal(t, apistructs.PipelineActionCacheRestoreMiss, agent.CacheReports[0].Result)
+	stored := agent.CacheReports[1]
+	assert.Equal(t, apistructs.PipelineActionCacheOperationStore, stored.Operation)
+	assert.True(t, stored.SizeBytes > 0)
+	assert.FileExists(t, stored.Location)
+
+	// key 不变时精确命中，不重复保存
+	assert.NoError(t, os.RemoveAll(cachePath))
+	agent = newAgent()
+	agent.restoreCache(vo)
+	agent.storeCache(vo)
+	assert.Len(t, agent.CacheReports, 1)
+	assert.Equal(t, apistructs.PipelineActionCacheRestoreHit, agent.CacheReports[0].Result)
+	assert.FileExists(t, filepath.Join(cachePath, "dep.jar"))
+
+	// pom.xml 变化后通过 restore_keys 前缀命中
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(contextDir, "git-checkout", "pom.xml"), []byte("v2"), 0644))
+	assert.NoError(t, os.RemoveAll(cachePath))
+	agent = newAgent()
+	agent.restoreCache(vo)
+	assert.Equal(t, apistructs.PipelineActionCacheRestorePartialHit, agent.CacheReports[0].Result)
+	assert.Equal(t, stored.Location, agent.CacheReports[0].Location)
+	assert.NotEqual(t, stored.Key, agent.CacheReports[0].Key)
+	assert.FileExists(t, filepath.Join(cachePath, "dep.jar"))
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package actionagent
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/pkg/qaparser/coverage"
+)
+
+// collectCoverage 解析 action 声明的覆盖率文件。
+// 覆盖率仅用于报告展示，文件不存在或解析失败时只打印日志，不影响任务结果。
+func (agent *Agent) collectCoverage() {
+	if agent.Arg == nil || agent.Arg.Coverage == nil {
+		return
+	}
+	path := agent.coverageFilePath()
+	if _, err := os.Stat(path); err != nil {
+		logrus.Warnf("skip collect coverage, file %s not found, err: %v", path, err)
+		return
+	}
+	report, err := coverage.IngestFile(agent.Arg.Coverage.Format, path)
+	if err != nil {
+		logrus.Warnf("failed to collect coverage from %s, err: %v", path, err)
+		return
+	}
+	agent.CoverageReport = report
+	logrus.Printf("collected %s coverage from %s, line coverage: %.2f%%, branch coverage: %.2f%%",
+		report.Format, path, report.Lines.Rate(), report.Branches.Rate())
+}
+
+// coverageFilePath 相对路径基于 action 工作目录
+func (agent *Agent) coverageFilePath() string {
+	path := os.ExpandEnv(agent.Arg.Coverage.Path)
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(agent.EasyUse.ContainerWd, path)
+}
+
+func (agent *Agent) coverageMetadataField() *apistructs.MetadataField {
+	if agent.CoverageReport == nil {
+		return nil
+	}
+	b, err := json.Marshal(agent.CoverageReport)
+	if err != nil {
+		logrus.Warnf("failed to marshal coverage report, err: %v", err)
+		return nil
+	}
+	return &apistructs.MetadataField{Name: apistructs.CoverageMetaKey, Value: string(b)}
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package actionagent
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+func TestAgent_CollectCoverage(t *testing.T) {
+	wd, err := ioutil.TempDir("", "action-coverage")
+	assert.NoError(t, err)
+	defer os.RemoveAll(wd)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(wd, "coverage.out"),
+		[]byte("mode: set\nexample.com/demo/a.go:1.1,2.2 3 1\nexample.com/demo/a.go:3.1,4.2 1 0\n"), 0644))
+
+	// 未声明覆盖率文件
+	agent := &Agent{Arg: &AgentArg{}, EasyUse: EasyUse{ContainerWd: wd}}
+	agent.collectCoverage()
+	assert.Nil(t, agent.CoverageReport)
+	assert.Nil(t, agent.coverageMetadataField())
+
+	// 文件不存在不影响任务
+	agent.Arg.Coverage = &apistructs.ActionCoverage{Format: apistructs.CoverageFormatGoCover, Path: "not-exist.out"}
+	agent.collectCoverage()
+	assert.Nil(t, agent.CoverageReport)
+	assert.Empty(t, agent.Errs)
+
+	// 相对路径基于工作目录
+	agent.Arg.Coverage.Path = "coverage.out"
+	agent.collectCoverage()
+	assert.NotNil(t, agent.CoverageReport)
+	assert.Equal(t, float64(75), agent.CoverageReport.Lines.Rate())
+
+	field := agent.coverageMetadataField()
+	assert.Equal(t, apistructs.CoverageMetaKey, field.Name)
+	var report apistructs.CoverageReport
+	assert.NoError(t, json.Unmarshal([]byte(field.Value), &report))
+	assert.Equal(t, *agent.CoverageReport, report)
+}
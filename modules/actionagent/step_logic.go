@@ -31,8 +31,15 @@ func (agent *Agent) logic() {
 	}
 
 	actionRun := exec.Command(agent.EasyUse.RunScript)
-	actionRun.Stdout = io.MultiWriter(os.Stdout, agent.EasyUse.RunMultiStdout)
-	actionRun.Stderr = io.MultiWriter(os.Stderr, agent.EasyUse.RunMultiStderr)
+	// stdout/stderr 脱敏后再输出与采集
+	stdout := newMaskWriter(io.MultiWriter(os.Stdout, agent.EasyUse.RunMultiStdout), agent.SecretMasker)
+	stderr := newMaskWriter(io.MultiWriter(os.Stderr, agent.EasyUse.RunMultiStderr), agent.SecretMasker)
+	defer func() {
+		_ = stdout.Close()
+		_ = stderr.Close()
+	}()
+	actionRun.Stdout = stdout
+	actionRun.Stderr = stderr
 	if err := actionRun.Start(); err != nil {
 		agent.AppendError(err)
 		return
@@ -76,7 +76,10 @@ func (agent *Agent) prepare() {
 		}
 	}
 
-	// 4. multiWriter of stdout/stderr
+	// 4. secret masker
+	agent.setupSecretMasker()
+
+	// 5. multiWriter of stdout/stderr
 	if f, err := filehelper.CreateFile3(agent.EasyUse.RunMultiStdoutFilePath, bytes.NewBufferString(""), 0644); err != nil {
 		logrus.Printf("failed to create multi stdout, err: %v\n", err)
 	} else {
@@ -46,8 +46,22 @@ func (agent *Agent) restore() {
 			}
 		case string(spec.StoreTypeDiceVolumeLocal), string(spec.StoreTypeDiceVolumeFake):
 			// nothing
+		// OSS 类型，restore 时下载对象存储中的 task namespace 并解压到 containerContext 下
 		case string(spec.StoreTypeOSS):
-			agent.AppendError(errors.New("[restore] not implemented now for OSS storage"))
+			storage, err := newObjectStorage()
+			if err != nil {
+				agent.AppendError(err)
+				continue
+			}
+			tarDir := agent.EasyUse.ContainerContext
+			if err := storage.DownloadAndExtract(in.Value, tarDir); err != nil {
+				if in.Optional {
+					logrus.Printf("[restore] ignore optional restore, type: %s, (prepare to download [%s] into [%s]), err: %v\n",
+						spec.StoreTypeOSS, in.Value, tarDir, err)
+					continue
+				}
+				agent.AppendError(err)
+			}
 
 		// dice-nfs-volume 类型，restore 时将 volume.path 下的 data (.tar) 解压到 containerContext 下
 		case string(spec.StoreTypeDiceVolumeNFS):
@@ -62,17 +76,8 @@ func (agent *Agent) restore() {
 				}
 				agent.AppendError(err)
 			}
-		case string(spec.StoreTypeDiceCacheNFS):
-			tarExecDir := filepath.Dir(in.Labels[pvolumes.TaskCachePath])
-			tarFile := in.Value + "/" + in.Labels[pvolumes.TaskCacheHashName] + pvolumes.TaskCacheCompressionSuffix
-			if filehelper.CheckExist(tarFile, false) != nil {
-				logrus.Printf("not get action cache: %s", in.Labels[pvolumes.TaskCachePath])
-				continue
-			}
-			if err := agenttool.UnTar(tarFile, tarExecDir); err != nil {
-				logrus.Printf("StoreTypeDiceCacheNFS untar error: %v", err)
-			}
-			logrus.Printf("get action cache: %s success", in.Labels[pvolumes.TaskCachePath])
+		case string(spec.StoreTypeDiceCacheNFS), string(spec.StoreTypeDiceCacheOSS):
+			agent.restoreCache(in)
 		default:
 			agent.AppendError(errors.Errorf("[restore] unsupported store type: %s", in.Type))
 		}
@@ -18,12 +18,10 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
 	"github.com/erda-project/erda/modules/actionagent/agenttool"
 	"github.com/erda-project/erda/modules/pipeline/pipengine/pvolumes"
 	"github.com/erda-project/erda/modules/pipeline/spec"
-	"github.com/erda-project/erda/pkg/filehelper"
 )
 
 func (agent *Agent) store() {
@@ -39,8 +37,17 @@ func (agent *Agent) store() {
 			}
 		case string(spec.StoreTypeDiceVolumeLocal), string(spec.StoreTypeDiceVolumeFake):
 			// nothing
+		// OSS 类型，store 时将对应 containerContext 下的 task namespace 压缩后流式上传至对象存储
 		case string(spec.StoreTypeOSS):
-			agent.AppendError(errors.New("[store] not implemented now for OSS storage"))
+			storage, err := newObjectStorage()
+			if err != nil {
+				agent.AppendError(err)
+				continue
+			}
+			tarDir := filepath.Join(agent.EasyUse.ContainerContext, out.Name)
+			if _, err := storage.UploadDir(out.Value, tarDir); err != nil {
+				agent.AppendError(err)
+			}
 
 		// dice-nfs-volume 类型，store 时将对应 containerContext 下的 task namespace 整个压缩为 volume.path 下的 data (.tar)
 		case string(spec.StoreTypeDiceVolumeNFS):
@@ -50,18 +57,18 @@ func (agent *Agent) store() {
 			if err != nil {
 				agent.AppendError(err)
 			}
-		case string(spec.StoreTypeDiceCacheNFS):
-			tarFile := out.Value + "/" + out.Labels[pvolumes.TaskCacheHashName] + pvolumes.TaskCacheCompressionSuffix
-			if filehelper.CheckExist(out.Labels[pvolumes.TaskCachePath], true) != nil {
-				logrus.Printf("upload action cache error: %s is not dir", out.Labels[pvolumes.TaskCachePath])
-				continue
-			}
-			if err := agenttool.Tar(tarFile, out.Labels[pvolumes.TaskCachePath]); err != nil {
-				logrus.Printf("StoreTypeDiceCacheNFS tar error: %v", err)
-			}
-			logrus.Printf("upload action cache %s success", out.Labels[pvolumes.TaskCachePath])
+		case string(spec.StoreTypeDiceCacheNFS), string(spec.StoreTypeDiceCacheOSS):
+			agent.storeCache(out)
 		default:
 			agent.AppendError(errors.Errorf("[store] unsupported store type: %s", out.Type))
 		}
 	}
 }
+
+func newObjectStorage() (*agenttool.ObjectStorage, error) {
+	cfg, err := pvolumes.ObjectStorageConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return agenttool.NewObjectStorage(cfg.Endpoint, cfg.Region, cfg.Bucket, cfg.AccessKey, cfg.SecretKey, cfg.SessionToken, cfg.Secure)
+}
@@ -26,6 +26,14 @@ type BranchRule struct {
 	Desc              string //规则说明
 	Workspace         string `json:"workspace"`
 	ArtifactWorkspace string `json:"artifactWorkspace"`
+	// 允许的 mr 合并方式，逗号分隔
+	AllowedMergeMethods string `json:"allowedMergeMethods"`
+	// 合并前需成功的 check run 名称，逗号分隔
+	RequiredCheckRuns     string `json:"requiredCheckRuns"`
+	RequiredApprovals     int    `json:"requiredApprovals"`
+	DismissStaleApprovals bool   `json:"dismissStaleApprovals"`
+	// 需要 CODEOWNERS 负责人审批
+	RequireCodeOwnerApproval bool `json:"requireCodeOwnerApproval"`
 }
 
 // TableName 设置模型对应数据库表名称
@@ -35,15 +43,20 @@ func (BranchRule) TableName() string {
 
 func (rule *BranchRule) ToApiData() *apistructs.BranchRule {
 	return &apistructs.BranchRule{
-		ID:                rule.ID,
-		Rule:              rule.Rule,
-		ScopeID:           rule.ScopeID,
-		ScopeType:         rule.ScopeType,
-		IsProtect:         rule.IsProtect,
-		NeedApproval:      rule.NeedApproval,
-		IsTriggerPipeline: rule.IsTriggerPipeline,
-		Desc:              rule.Desc,
-		Workspace:         rule.Workspace,
-		ArtifactWorkspace: rule.ArtifactWorkspace,
+		ID:                       rule.ID,
+		Rule:                     rule.Rule,
+		ScopeID:                  rule.ScopeID,
+		ScopeType:                rule.ScopeType,
+		IsProtect:                rule.IsProtect,
+		NeedApproval:             rule.NeedApproval,
+		IsTriggerPipeline:        rule.IsTriggerPipeline,
+		Desc:                     rule.Desc,
+		Workspace:                rule.Workspace,
+		ArtifactWorkspace:        rule.ArtifactWorkspace,
+		AllowedMergeMethods:      rule.AllowedMergeMethods,
+		RequiredCheckRuns:        rule.RequiredCheckRuns,
+		RequiredApprovals:        rule.RequiredApprovals,
+		DismissStaleApprovals:    rule.DismissStaleApprovals,
+		RequireCodeOwnerApproval: rule.RequireCodeOwnerApproval,
 	}
 }
@@ -108,6 +108,11 @@ func (branchRule *BranchRule) Update(request apistructs.UpdateBranchRuleRequest)
 	rule.Workspace = request.Workspace
 	rule.ArtifactWorkspace = request.ArtifactWorkspace
 	rule.NeedApproval = request.NeedApproval
+	rule.AllowedMergeMethods = request.AllowedMergeMethods
+	rule.RequiredCheckRuns = request.RequiredCheckRuns
+	rule.RequiredApprovals = request.RequiredApprovals
+	rule.DismissStaleApprovals = request.DismissStaleApprovals
+	rule.RequireCodeOwnerApproval = request.RequireCodeOwnerApproval
 	err = branchRule.CheckRuleValid(&rule)
 	if err != nil {
 		return nil, err
@@ -121,15 +126,20 @@ func (branchRule *BranchRule) Update(request apistructs.UpdateBranchRuleRequest)
 
 func (branchRule *BranchRule) Create(request apistructs.CreateBranchRuleRequest) (*apistructs.BranchRule, error) {
 	rule := model.BranchRule{
-		ScopeType:         request.ScopeType,
-		ScopeID:           request.ScopeID,
-		Rule:              request.Rule,
-		IsProtect:         request.IsProtect,
-		IsTriggerPipeline: request.IsTriggerPipeline,
-		Workspace:         request.Workspace,
-		ArtifactWorkspace: request.ArtifactWorkspace,
-		NeedApproval:      request.NeedApproval,
-		Desc:              request.Desc,
+		ScopeType:                request.ScopeType,
+		ScopeID:                  request.ScopeID,
+		Rule:                     request.Rule,
+		IsProtect:                request.IsProtect,
+		IsTriggerPipeline:        request.IsTriggerPipeline,
+		Workspace:                request.Workspace,
+		ArtifactWorkspace:        request.ArtifactWorkspace,
+		NeedApproval:             request.NeedApproval,
+		Desc:                     request.Desc,
+		AllowedMergeMethods:      request.AllowedMergeMethods,
+		RequiredCheckRuns:        request.RequiredCheckRuns,
+		RequiredApprovals:        request.RequiredApprovals,
+		DismissStaleApprovals:    request.DismissStaleApprovals,
+		RequireCodeOwnerApproval: request.RequireCodeOwnerApproval,
 	}
 	err := branchRule.CheckRuleValid(&rule)
 	if err != nil {
@@ -172,6 +182,13 @@ func isRuleMatch(sourceRule, targetRule string) bool {
 }
 
 func (branchRule *BranchRule) CheckRuleValid(newBranchRule *model.BranchRule) error {
+	// check merge methods
+	if _, err := apistructs.ParseMergeMethods(newBranchRule.AllowedMergeMethods); err != nil {
+		return err
+	}
+	if newBranchRule.RequiredApprovals < 0 {
+		return fmt.Errorf("invalid requiredApprovals: %d", newBranchRule.RequiredApprovals)
+	}
 	// check duplicate
 	currentRules, err := branchRule.Query(newBranchRule.ScopeType, newBranchRule.ScopeID)
 	if err != nil {
@@ -610,7 +610,7 @@ func (e *Endpoints) checkrunCreate(ctx context.Context, r *http.Request, vars ma
 			PipelineID: strconv.FormatUint(resPipeline.ID, 10),
 			Commit:     gitEvent.Content.SourceSha,
 		}
-		request.Name = gitEvent.Content.SourceBranch + "/" + each
+		request.Name = apistructs.MakeCheckRunName(gitEvent.Content.SourceBranch, each)
 		request.Status = apistructs.CheckRunStatusInProgress
 		_, err = e.bdl.CreateCheckRun(appID, request)
 		if err != nil {
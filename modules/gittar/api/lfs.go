@@ -0,0 +1,375 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/modules/gittar/models"
+	"github.com/erda-project/erda/modules/gittar/pkg/lfs"
+	"github.com/erda-project/erda/modules/gittar/webcontext"
+)
+
+var (
+	ERROR_LFS_OBJECT_NOT_FOUND = errors.New("lfs object not found")
+	ERROR_LFS_INVALID_OBJECT   = errors.New("invalid lfs object")
+)
+
+// LFSBatch POST /info/lfs/objects/batch
+func LFSBatch(ctx *webcontext.Context) {
+	if _, err := ctx.Service.LFSContentStore(); err != nil {
+		abortLFS(ctx, http.StatusNotFound, err)
+		return
+	}
+	var req lfs.BatchRequest
+	if err := json.NewDecoder(ctx.GetRequestBody()).Decode(&req); err != nil {
+		abortLFS(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if req.Operation != lfs.OperationUpload && req.Operation != lfs.OperationDownload {
+		abortLFS(ctx, http.StatusUnprocessableEntity, errors.New("invalid operation: "+req.Operation))
+		return
+	}
+	if !req.SupportBasicTransfer() {
+		abortLFS(ctx, http.StatusUnprocessableEntity, errors.New("only basic transfer is supported"))
+		return
+	}
+	if req.HashAlgo != "" && req.HashAlgo != lfs.HashAlgo {
+		abortLFS(ctx, http.StatusConflict, errors.New("only sha256 hash algo is supported"))
+		return
+	}
+	if req.Operation == lfs.OperationUpload {
+		if code, err := checkLFSWritable(ctx); err != nil {
+			abortLFS(ctx, code, err)
+			return
+		}
+	}
+
+	oids := make([]string, 0, len(req.Objects))
+	for _, object := range req.Objects {
+		oids = append(oids, object.Oid)
+	}
+	existObjects, err := ctx.Service.GetLFSObjects(ctx.Repository.ID, oids)
+	if err != nil {
+		abortLFS(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if req.Operation == lfs.OperationUpload {
+		var incoming int64
+		for _, object := range req.Objects {
+			if _, ok := existObjects[object.Oid]; !ok && object.Valid() {
+				incoming += object.Size
+			}
+		}
+		if err := ctx.Service.CheckLFSQuota(ctx.Repository.ID, incoming); err != nil {
+			abortLFS(ctx, lfsErrorCode(err), err)
+			return
+		}
+	}
+
+	baseURL := lfsBaseURL(ctx)
+	header := map[string]string{}
+	if auth := ctx.GetHeader("Authorization"); auth != "" {
+		header["Authorization"] = auth
+	}
+	resp := lfs.BatchResponse{
+		Transfer: lfs.TransferBasic,
+		HashAlgo: lfs.HashAlgo,
+		Objects:  make([]*lfs.ObjectResponse, 0, len(req.Objects)),
+	}
+	for _, object := range req.Objects {
+		objectResp := &lfs.ObjectResponse{Pointer: object, Authenticated: true}
+		resp.Objects = append(resp.Objects, objectResp)
+		if !object.Valid() {
+			objectResp.Error = &lfs.ObjectError{Code: http.StatusUnprocessableEntity, Message: ERROR_LFS_INVALID_OBJECT.Error()}
+			continue
+		}
+		exist, ok := existObjects[object.Oid]
+		href := baseURL + "/objects/" + object.Oid
+		switch req.Operation {
+		case lfs.OperationDownload:
+			if !ok {
+				objectResp.Error = &lfs.ObjectError{Code: http.StatusNotFound, Message: ERROR_LFS_OBJECT_NOT_FOUND.Error()}
+				continue
+			}
+			objectResp.Size = exist.Size
+			objectResp.Actions = map[string]*lfs.Action{
+				lfs.ActionDownload: {Href: href, Header: header},
+			}
+		case lfs.OperationUpload:
+			// 已存在的对象无需上传
+			if ok {
+				continue
+			}
+			objectResp.Actions = map[string]*lfs.Action{
+				lfs.ActionUpload: {Href: href, Header: header},
+				lfs.ActionVerify: {Href: baseURL + "/verify", Header: header},
+			}
+		}
+	}
+	writeLFSJSON(ctx, http.StatusOK, &resp)
+}
+
+// LFSDownload GET /info/lfs/objects/:oid
+func LFSDownload(ctx *webcontext.Context) {
+	store, err := ctx.Service.LFSContentStore()
+	if err != nil {
+		abortLFS(ctx, http.StatusNotFound, err)
+		return
+	}
+	oid := ctx.Param("oid")
+	if !lfs.ValidOid(oid) {
+		abortLFS(ctx, http.StatusUnprocessableEntity, ERROR_LFS_INVALID_OBJECT)
+		return
+	}
+	object, err := ctx.Service.GetLFSObject(ctx.Repository.ID, oid)
+	if err != nil {
+		abortLFS(ctx, lfsErrorCode(err), err)
+		return
+	}
+	reader, err := store.Get(ctx.Repository.ID, oid)
+	if err != nil {
+		abortLFS(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer reader.Close()
+	ctx.Header("Content-Length", strconv.FormatInt(object.Size, 10))
+	if err := ctx.EchoContext.Stream(http.StatusOK, "application/octet-stream", reader); err != nil {
+		logrus.Errorf("failed to send lfs object, repo: %d, oid: %s, err: %v", ctx.Repository.ID, oid, err)
+	}
+}
+
+// LFSUpload PUT /info/lfs/objects/:oid
+func LFSUpload(ctx *webcontext.Context) {
+	store, err := ctx.Service.LFSContentStore()
+	if err != nil {
+		abortLFS(ctx, http.StatusNotFound, err)
+		return
+	}
+	if code, err := checkLFSWritable(ctx); err != nil {
+		abortLFS(ctx, code, err)
+		return
+	}
+	p := lfs.Pointer{Oid: ctx.Param("oid"), Size: ctx.HttpRequest().ContentLength}
+	if !p.Valid() {
+		abortLFS(ctx, http.StatusUnprocessableEntity, ERROR_LFS_INVALID_OBJECT)
+		return
+	}
+	// 并发上传同一对象时只记录一次
+	if _, err := ctx.Service.GetLFSObject(ctx.Repository.ID, p.Oid); err == nil {
+		ctx.Status(http.StatusOK)
+		return
+	}
+	if err := ctx.Service.CheckLFSQuota(ctx.Repository.ID, p.Size); err != nil {
+		abortLFS(ctx, lfsErrorCode(err), err)
+		return
+	}
+	if err := store.Put(ctx.Repository.ID, p, ctx.GetRequestBody()); err != nil {
+		abortLFS(ctx, lfsErrorCode(err), err)
+		return
+	}
+	if err := ctx.Service.CreateLFSObject(ctx.Repository.ID, p); err != nil {
+		abortLFS(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
+
+// LFSVerify POST /info/lfs/verify
+func LFSVerify(ctx *webcontext.Context) {
+	var p lfs.Pointer
+	if err := json.NewDecoder(ctx.GetRequestBody()).Decode(&p); err != nil {
+		abortLFS(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if !p.Valid() {
+		abortLFS(ctx, http.StatusUnprocessableEntity, ERROR_LFS_INVALID_OBJECT)
+		return
+	}
+	object, err := ctx.Service.GetLFSObject(ctx.Repository.ID, p.Oid)
+	if err != nil {
+		abortLFS(ctx, lfsErrorCode(err), err)
+		return
+	}
+	if object.Size != p.Size {
+		abortLFS(ctx, http.StatusUnprocessableEntity, lfs.ErrSizeMismatch)
+		return
+	}
+	writeLFSJSON(ctx, http.StatusOK, &p)
+}
+
+// LFSCreateLock POST /info/lfs/locks
+func LFSCreateLock(ctx *webcontext.Context) {
+	if code, err := checkLFSWritable(ctx); err != nil {
+		abortLFS(ctx, code, err)
+		return
+	}
+	var req lfs.LockCreateRequest
+	if err := json.NewDecoder(ctx.GetRequestBody()).Decode(&req); err != nil {
+		abortLFS(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if req.Path == "" {
+		abortLFS(ctx, http.StatusBadRequest, errors.New("path is empty"))
+		return
+	}
+	var ref string
+	if req.Ref != nil {
+		ref = req.Ref.Name
+	}
+	lock, err := ctx.Service.CreateLFSLock(ctx.Repository, ctx.User, req.Path, ref)
+	if err == models.ErrLFSLockExists {
+		writeLFSJSON(ctx, http.StatusConflict, &lfs.LockResponse{Lock: lock.ToLock(), Message: err.Error()})
+		return
+	}
+	if err != nil {
+		abortLFS(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	writeLFSJSON(ctx, http.StatusCreated, &lfs.LockResponse{Lock: lock.ToLock()})
+}
+
+// LFSListLocks GET /info/lfs/locks
+func LFSListLocks(ctx *webcontext.Context) {
+	id, _ := strconv.ParseInt(ctx.Query("id"), 10, 64)
+	cursor, _ := strconv.ParseInt(ctx.Query("cursor"), 10, 64)
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	locks, nextCursor, err := ctx.Service.ListLFSLocks(ctx.Repository.ID, ctx.Query("path"), id, cursor, limit)
+	if err != nil {
+		abortLFS(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	resp := lfs.LockListResponse{Locks: []*lfs.Lock{}, NextCursor: nextCursor}
+	for _, lock := range locks {
+		resp.Locks = append(resp.Locks, lock.ToLock())
+	}
+	writeLFSJSON(ctx, http.StatusOK, &resp)
+}
+
+// LFSVerifyLocks POST /info/lfs/locks/verify
+func LFSVerifyLocks(ctx *webcontext.Context) {
+	if code, err := checkLFSWritable(ctx); err != nil {
+		abortLFS(ctx, code, err)
+		return
+	}
+	var req lfs.LockVerifyRequest
+	if err := json.NewDecoder(ctx.GetRequestBody()).Decode(&req); err != nil {
+		abortLFS(ctx, http.StatusBadRequest, err)
+		return
+	}
+	cursor, _ := strconv.ParseInt(req.Cursor, 10, 64)
+	locks, nextCursor, err := ctx.Service.ListLFSLocks(ctx.Repository.ID, "", 0, cursor, req.Limit)
+	if err != nil {
+		abortLFS(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	resp := lfs.LockVerifyResponse{Ours: []*lfs.Lock{}, Theirs: []*lfs.Lock{}, NextCursor: nextCursor}
+	for _, lock := range locks {
+		if lock.OwnerID == ctx.User.Id {
+			resp.Ours = append(resp.Ours, lock.ToLock())
+		} else {
+			resp.Theirs = append(resp.Theirs, lock.ToLock())
+		}
+	}
+	writeLFSJSON(ctx, http.StatusOK, &resp)
+}
+
+// LFSUnlock POST /info/lfs/locks/:id/unlock
+func LFSUnlock(ctx *webcontext.Context) {
+	if code, err := checkLFSWritable(ctx); err != nil {
+		abortLFS(ctx, code, err)
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		abortLFS(ctx, http.StatusBadRequest, ERROR_ARG_ID)
+		return
+	}
+	var req lfs.LockDeleteRequest
+	if err := json.NewDecoder(ctx.GetRequestBody()).Decode(&req); err != nil {
+		abortLFS(ctx, http.StatusBadRequest, err)
+		return
+	}
+	lock, err := ctx.Service.DeleteLFSLock(ctx.Repository, ctx.User, id, req.Force)
+	if err != nil {
+		abortLFS(ctx, lfsErrorCode(err), err)
+		return
+	}
+	writeLFSJSON(ctx, http.StatusOK, &lfs.LockResponse{Lock: lock.ToLock()})
+}
+
+// checkLFSWritable 上传对象和操作锁需要 push 权限，仓库锁定时禁止写入
+func checkLFSWritable(ctx *webcontext.Context) (int, error) {
+	isLocked, err := ctx.Service.GetRepoLocked(ctx.Repository.ProjectId, ctx.Repository.ApplicationId)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if isLocked {
+		return http.StatusForbidden, ERROR_REPO_LOCKED
+	}
+	if err := ctx.CheckPermission(models.PermissionPush); err != nil {
+		return http.StatusForbidden, err
+	}
+	return http.StatusOK, nil
+}
+
+// lfsBaseURL 返回 http(s)://<host>/<repo>/info/lfs
+func lfsBaseURL(ctx *webcontext.Context) string {
+	r := ctx.HttpRequest()
+	scheme := "http"
+	if r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") {
+		scheme = "https"
+	}
+	path := r.URL.Path
+	if idx := strings.Index(path, "/info/lfs"); idx >= 0 {
+		path = path[:idx]
+	}
+	return scheme + "://" + r.Host + path + "/info/lfs"
+}
+
+func lfsErrorCode(err error) int {
+	switch err {
+	case gorm.ErrRecordNotFound:
+		return http.StatusNotFound
+	case models.ErrLFSQuotaExceeded:
+		return http.StatusInsufficientStorage
+	case models.ErrLFSLockNotOwner:
+		return http.StatusForbidden
+	case lfs.ErrHashMismatch, lfs.ErrSizeMismatch:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func abortLFS(ctx *webcontext.Context, code int, err error) {
+	writeLFSJSON(ctx, code, &lfs.ErrorResponse{Message: err.Error()})
+}
+
+func writeLFSJSON(ctx *webcontext.Context, code int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusInternalServerError, err)
+		return
+	}
+	ctx.Data(code, lfs.MediaType, body)
+}
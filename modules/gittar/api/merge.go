@@ -15,6 +15,7 @@ package api
 
 import (
 	"errors"
+	"net/http"
 	"strconv"
 	"strings"
 
@@ -34,8 +35,9 @@ func CheckMergeStatus(ctx *webcontext.Context) {
 
 	sourceBranch := ctx.Query("sourceBranch")
 	targetBranch := ctx.Query("targetBranch")
+	mergeMethod := ctx.Repository.ResolveMergeMethod(ctx.Query("mergeMethod"))
 
-	conflictInfo, err := ctx.Repository.GetMergeStatus(sourceBranch, targetBranch)
+	conflictInfo, err := ctx.Repository.GetMergeStatusWithMethod(sourceBranch, targetBranch, mergeMethod)
 
 	if err != nil {
 		ctx.Abort(err)
@@ -125,7 +127,8 @@ func CreateMergeRequest(ctx *webcontext.Context) {
 		request.EventName = apistructs.GitCreateMREvent
 		ctx.Service.TriggerEvent(ctx.Repository, apistructs.GitCreateMREvent, request)
 		// check-run
-		conflictInfo, err := ctx.Repository.GetMergeStatus(request.SourceBranch, request.TargetBranch)
+		conflictInfo, err := ctx.Repository.GetMergeStatusWithMethod(request.SourceBranch, request.TargetBranch,
+			ctx.Repository.ResolveMergeMethod(request.MergeMethod))
 		if err != nil {
 			ctx.Abort(err)
 			return
@@ -239,7 +242,8 @@ func UpdateMergeRequest(ctx *webcontext.Context) {
 		}
 		// check-run
 		result.MergeUserId = ctx.User.Id
-		conflictInfo, err := ctx.Repository.GetMergeStatus(result.SourceBranch, result.TargetBranch)
+		conflictInfo, err := ctx.Repository.GetMergeStatusWithMethod(result.SourceBranch, result.TargetBranch,
+			ctx.Repository.ResolveMergeMethod(result.MergeMethod))
 		if err != nil {
 			ctx.Abort(err)
 			return
@@ -281,6 +285,18 @@ func Merge(ctx *webcontext.Context) {
 	}
 	commit, err := ctx.Service.Merge(ctx.Repository, ctx.User, id, &mergeOptions)
 	if err != nil {
+		// 合并条件未满足时返回各项条件的状态
+		if unmet, ok := err.(models.ErrMergeRequirementsUnmet); ok {
+			ctx.AbortWithData(http.StatusMethodNotAllowed, &webcontext.ApiData{
+				Success: false,
+				Err: apistructs.ErrorResponse{
+					Code: "MergeRequirementsUnmet",
+					Msg:  unmet.Error(),
+				},
+				Data: unmet.Requirements,
+			})
+			return
+		}
 		ctx.Abort(err)
 		return
 	}
@@ -367,6 +383,50 @@ func ReopenMR(ctx *webcontext.Context) {
 	ctx.Success(result)
 }
 
+// ApproveMR 审批 mr
+func ApproveMR(ctx *webcontext.Context) {
+	id := ctx.ParamInt32("id", 0)
+	if id == 0 {
+		ctx.Abort(ERROR_ARG_ID)
+		return
+	}
+	result, err := ctx.Service.ApproveMR(ctx.Repository, ctx.User, id)
+	if err != nil {
+		ctx.Abort(err)
+		return
+	}
+	ctx.Success(result)
+}
+
+// UnapproveMR 撤销 mr 审批
+func UnapproveMR(ctx *webcontext.Context) {
+	id := ctx.ParamInt32("id", 0)
+	if id == 0 {
+		ctx.Abort(ERROR_ARG_ID)
+		return
+	}
+	if err := ctx.Service.UnapproveMR(ctx.Repository, ctx.User, id); err != nil {
+		ctx.Abort(err)
+		return
+	}
+	ctx.Success("")
+}
+
+// GetMRCodeOwners mr 修改文件的 CODEOWNERS 负责人
+func GetMRCodeOwners(ctx *webcontext.Context) {
+	id := ctx.ParamInt32("id", 0)
+	if id == 0 {
+		ctx.Abort(ERROR_ARG_ID)
+		return
+	}
+	result, err := ctx.Service.GetMRCodeOwners(ctx.Repository, id)
+	if err != nil {
+		ctx.Abort(err)
+		return
+	}
+	ctx.Success(result)
+}
+
 func QueryNotes(ctx *webcontext.Context) {
 	id := ctx.ParamInt32("id", 0)
 	if id == 0 {
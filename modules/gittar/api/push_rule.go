@@ -0,0 +1,84 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/gittar/webcontext"
+)
+
+// GetPushRules 仓库生效的组织级和仓库级推送规则
+func GetPushRules(ctx *webcontext.Context) {
+	result, err := ctx.Service.GetRepoPushRules(ctx.Repository)
+	if err != nil {
+		ctx.Abort(err)
+		return
+	}
+	ctx.Success(result)
+}
+
+// SetPushRule 设置仓库级推送规则
+func SetPushRule(ctx *webcontext.Context) {
+	var rule apistructs.PushRule
+	if err := ctx.BindJSON(&rule); err != nil {
+		ctx.AbortWithStatus(400, errors.New("request body parse failed"))
+		return
+	}
+	result, err := ctx.Service.SetRepoPushRule(ctx.Repository, ctx.User, &rule)
+	if err != nil {
+		ctx.Abort(err)
+		return
+	}
+	ctx.Success(result)
+}
+
+// GetOrgPushRule 组织级推送规则
+func GetOrgPushRule(ctx *webcontext.Context) {
+	orgID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatus(400, ERROR_ARG_ID)
+		return
+	}
+	result, err := ctx.Service.GetPushRule(apistructs.PushRuleScopeOrg, orgID)
+	if err != nil {
+		ctx.Abort(err)
+		return
+	}
+	ctx.Success(result)
+}
+
+// SetOrgPushRule 设置组织级推送规则，组织下所有仓库生效
+func SetOrgPushRule(ctx *webcontext.Context) {
+	orgID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatus(400, ERROR_ARG_ID)
+		return
+	}
+	var rule apistructs.PushRule
+	if err := ctx.BindJSON(&rule); err != nil {
+		ctx.AbortWithStatus(400, errors.New("request body parse failed"))
+		return
+	}
+	rule.ScopeType = apistructs.PushRuleScopeOrg
+	rule.ScopeID = orgID
+	result, err := ctx.Service.SavePushRule(&rule)
+	if err != nil {
+		ctx.Abort(err)
+		return
+	}
+	ctx.Success(result)
+}
@@ -1033,6 +1033,28 @@ func SetLocked(context *webcontext.Context) {
 	context.Success(result)
 }
 
+// SetDefaultMergeMethod 设置仓库默认 mr 合并方式
+func SetDefaultMergeMethod(context *webcontext.Context) {
+	repository := context.Repository
+	if repository.ApplicationId == 0 {
+		context.Abort(ERROR_ARG_ID)
+		return
+	}
+	var req apistructs.DefaultMergeMethodRequest
+	err := context.BindJSON(&req)
+	if err != nil {
+		context.Abort(err)
+		return
+	}
+	req.AppID = repository.ApplicationId
+	result, err := context.Service.SetDefaultMergeMethod(repository, context.User, &req)
+	if err != nil {
+		context.Abort(err)
+		return
+	}
+	context.Success(result)
+}
+
 // GetArchive 打包下载
 func GetArchive(ctx *webcontext.Context) {
 	fileName := ctx.Param("*")
@@ -388,6 +388,7 @@ func openRepository(repo *models.Repo) (*gitmodule.Repository, error) {
 	gitRepository.ApplicationId = repo.AppID
 	gitRepository.OrgId = repo.OrgID
 	gitRepository.Size = repo.Size
+	gitRepository.DefaultMergeMethod = repo.DefaultMergeMethod
 	gitRepository.Url = conf.GittarUrl() + "/" + repo.Path
 	if repo.IsExternal {
 		repoPath := path.Join(conf.RepoRoot(), repo.Path)
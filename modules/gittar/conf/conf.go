@@ -50,6 +50,18 @@ type Conf struct {
 	GitGCMaxNum              int    `env:"GIT_GC_MAX_NUM" default:"1"`
 	GitGCCronExpression      string `env:"GIT_GC_CRON_EXPRESSION" default:"0 0 1 * * ?"`
 
+	// git lfs config
+	LFSEnabled         bool   `env:"GITTAR_LFS_ENABLED" default:"true"`
+	LFSStorageType     string `env:"GITTAR_LFS_STORAGE_TYPE" default:"fs"` // fs, oss or s3
+	LFSPathPrefix      string `env:"GITTAR_LFS_PATH_PREFIX" default:"/repository/.lfs"`
+	LFSEndpoint        string `env:"GITTAR_LFS_ENDPOINT"`
+	LFSRegion          string `env:"GITTAR_LFS_REGION"`
+	LFSAccessKeyID     string `env:"GITTAR_LFS_ACCESS_KEY_ID"`
+	LFSAccessKeySecret string `env:"GITTAR_LFS_ACCESS_KEY_SECRET"`
+	LFSBucket          string `env:"GITTAR_LFS_BUCKET"`
+	LFSSecure          bool   `env:"GITTAR_LFS_SECURE" default:"true"`
+	LFSRepoQuota       int64  `env:"GITTAR_LFS_REPO_QUOTA" default:"10737418240"` // 单仓库 LFS 存储上限，单位 Byte，0 表示不限制
+
 	// ory/kratos config
 	OryEnabled    bool   `default:"false" env:"ORY_ENABLED"`
 	OryKratosAddr string `default:"kratos:4433" env:"KRATOS_ADDR"`
@@ -195,3 +207,47 @@ func OryCompatibleClientID() string {
 func OryCompatibleClientSecret() string {
 	return ""
 }
+
+// LFSEnabled 是否开启 git lfs
+func LFSEnabled() bool {
+	return cfg.LFSEnabled
+}
+
+// LFSStorageType lfs 对象存储类型
+func LFSStorageType() string {
+	return cfg.LFSStorageType
+}
+
+// LFSPathPrefix lfs 对象存储路径前缀
+func LFSPathPrefix() string {
+	return cfg.LFSPathPrefix
+}
+
+func LFSEndpoint() string {
+	return cfg.LFSEndpoint
+}
+
+func LFSRegion() string {
+	return cfg.LFSRegion
+}
+
+func LFSAccessKeyID() string {
+	return cfg.LFSAccessKeyID
+}
+
+func LFSAccessKeySecret() string {
+	return cfg.LFSAccessKeySecret
+}
+
+func LFSBucket() string {
+	return cfg.LFSBucket
+}
+
+func LFSSecure() bool {
+	return cfg.LFSSecure
+}
+
+// LFSRepoQuota 单仓库 lfs 存储上限，0 表示不限制
+func LFSRepoQuota() int64 {
+	return cfg.LFSRepoQuota
+}
@@ -180,13 +180,17 @@ func RunProcess(service string, c *webcontext.Context) {
 
 		repository := c.MustGet("repository").(*gitmodule.Repository)
 		if preReceiveHook(pushEvents, c) {
-
+			pack, cleanup, ok := checkPushRules(pushEvents, reqBody, c)
+			if !ok {
+				return
+			}
 			runCommand2(c.GetWriter(), gitCommand(
 				version,
 				service,
 				"--stateless-rpc",
 				repository.DiskPath(),
-			), bytes.NewReader(header), reqBody)
+			), bytes.NewReader(header), pack)
+			cleanup()
 			go PostReceiveHook(pushEvents, c)
 		}
 	} else {
@@ -17,6 +17,8 @@ package helper
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"strconv"
 	"strings"
 
@@ -27,9 +29,13 @@ import (
 	"github.com/erda-project/erda/modules/gittar/event"
 	"github.com/erda-project/erda/modules/gittar/models"
 	"github.com/erda-project/erda/modules/gittar/pkg/gitmodule"
+	"github.com/erda-project/erda/modules/gittar/pkg/pushpolicy"
 	"github.com/erda-project/erda/modules/gittar/webcontext"
 )
 
+// 推送信息中最多展示的违规条数
+const maxReportedViolations = 20
+
 // protect branch
 func preReceiveHook(pushEvents []*models.PayloadPushEvent, c *webcontext.Context) bool {
 	for _, pushEvent := range pushEvents {
@@ -113,6 +119,93 @@ func preReceiveHook(pushEvents []*models.PayloadPushEvent, c *webcontext.Context
 	return true
 }
 
+// checkPushRules 逐个提交校验推送规则，通过后返回缓存的 pack 供 receive-pack 继续读取
+// 任一 ref 违规时整个推送被拒绝，每个 ref 返回一行 ng
+func checkPushRules(pushEvents []*models.PayloadPushEvent, pack io.Reader, c *webcontext.Context) (io.Reader, func(), bool) {
+	reject := func(refStates []string, errMsg string) (io.Reader, func(), bool) {
+		c.Status(200)
+		c.GetWriter().Write(NewReportStatuses("unpack ok", refStates, errMsg))
+		return nil, nil, false
+	}
+	rejectAll := func(reason string) (io.Reader, func(), bool) {
+		var refStates []string
+		for _, pushEvent := range pushEvents {
+			refStates = append(refStates, "ng "+pushEvent.Ref+" "+reason)
+		}
+		return reject(refStates, reason)
+	}
+
+	rules, err := c.Service.GetEffectivePushRules(c.Repository)
+	if err != nil {
+		logrus.Errorf("failed to get push rules, repo: %s, err: %v", c.Repository.Path, err)
+		return rejectAll("failed to get push rules")
+	}
+	if len(rules) == 0 {
+		return pack, func() {}, true
+	}
+
+	quarantine, err := pushpolicy.NewQuarantine(c.Repository.DiskPath(), pack)
+	if err != nil {
+		logrus.Errorf("failed to unpack objects, repo: %s, err: %v", c.Repository.Path, err)
+		return rejectAll("failed to check push rules")
+	}
+	withSecretScan := pushpolicy.NeedSecretScan(rules)
+	var (
+		refStates  []string
+		messages   []string
+		violations int
+	)
+	for _, pushEvent := range pushEvents {
+		if pushEvent.IsDelete {
+			refStates = append(refStates, "ng "+pushEvent.Ref+" other refs violate push rules")
+			continue
+		}
+		commits, err := quarantine.NewCommits(pushEvent.After, withSecretScan)
+		if err != nil {
+			quarantine.Close()
+			logrus.Errorf("failed to list pushed commits, repo: %s, ref: %s, err: %v", c.Repository.Path, pushEvent.Ref, err)
+			return rejectAll("failed to check push rules")
+		}
+		refViolations, err := pushpolicy.Check(rules, commits)
+		if err != nil {
+			quarantine.Close()
+			return rejectAll(err.Error())
+		}
+		if len(refViolations) == 0 {
+			refStates = append(refStates, "ng "+pushEvent.Ref+" other refs violate push rules")
+			continue
+		}
+		reason := "push rule violated, " + refViolations[0].String()
+		if len(refViolations) > 1 {
+			reason += fmt.Sprintf(" (and %d more)", len(refViolations)-1)
+		}
+		refStates = append(refStates, "ng "+pushEvent.Ref+" "+reason)
+		for _, v := range refViolations {
+			violations++
+			if violations <= maxReportedViolations {
+				messages = append(messages, pushEvent.Ref+": "+v.String())
+			}
+		}
+	}
+	if violations > 0 {
+		quarantine.Close()
+		if violations > maxReportedViolations {
+			messages = append(messages, fmt.Sprintf("... %d more violations", violations-maxReportedViolations))
+		}
+		return reject(refStates, "push rejected by push rules:\n"+strings.Join(messages, "\n"))
+	}
+
+	spooled, err := quarantine.Pack()
+	if err != nil {
+		quarantine.Close()
+		return rejectAll("failed to check push rules")
+	}
+	return spooled, func() {
+		spooled.Close()
+		quarantine.Close()
+	}, true
+}
+
 // trigger event
 func PostReceiveHook(pushEvents []*models.PayloadPushEvent, c *webcontext.Context) {
 	pusher := c.MustGet("user").(*models.User)
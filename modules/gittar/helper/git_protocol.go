@@ -59,11 +59,16 @@ func NewPtkLine(line string) []byte {
 }
 
 func NewReportStatus(unpackStatus string, refState string, errMsg string) []byte {
+	return NewReportStatuses(unpackStatus, []string{refState}, errMsg)
+}
+
+// NewReportStatuses 多个 ref 的推送结果，每个 ref 一行
+func NewReportStatuses(unpackStatus string, refStates []string, errMsg string) []byte {
 	var statusBuffer bytes.Buffer
 	var totalBuffer bytes.Buffer
 	var headLines []string
 	headLines = append(headLines, unpackStatus)
-	headLines = append(headLines, refState)
+	headLines = append(headLines, refStates...)
 	for _, line := range headLines {
 		statusBuffer.Write(NewPtkLine(line))
 	}
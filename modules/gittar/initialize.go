@@ -14,6 +14,7 @@
 package gittar
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/labstack/echo"
@@ -28,9 +29,11 @@ import (
 	"github.com/erda-project/erda/modules/gittar/models"
 	"github.com/erda-project/erda/modules/gittar/pkg/gc"
 	"github.com/erda-project/erda/modules/gittar/pkg/gitmodule"
+	"github.com/erda-project/erda/modules/gittar/pkg/lfs"
 	"github.com/erda-project/erda/modules/gittar/profiling"
 	"github.com/erda-project/erda/modules/gittar/webcontext"
 	"github.com/erda-project/erda/pkg/discover"
+	"github.com/erda-project/erda/pkg/storage"
 	"github.com/erda-project/erda/pkg/ucauth"
 	// "terminus.io/dice/telemetry/promxp"
 )
@@ -76,6 +79,12 @@ func Initialize() error {
 	webcontext.WithBundle(diceBundle)
 	webcontext.WithUCAuth(ucUserAuth)
 
+	lfsStore, err := newLFSContentStore()
+	if err != nil {
+		return fmt.Errorf("failed to init lfs content store, err: %v", err)
+	}
+	models.WithLFSContentStore(lfsStore)
+
 	e := echo.New()
 	systemGroup := e.Group("/_system")
 	{
@@ -85,6 +94,8 @@ func Initialize() error {
 		systemGroup.DELETE("/repos/:id", webcontext.WrapHandler(api.DeleteRepo))
 		systemGroup.DELETE("/apps/:id", webcontext.WrapHandler(api.DeleteRepoByApp))
 		systemGroup.PUT("/apps/:id", webcontext.WrapHandler(api.UpdateRepoByApp))
+		systemGroup.GET("/orgs/:id/push-rules", webcontext.WrapHandler(api.GetOrgPushRule))
+		systemGroup.PUT("/orgs/:id/push-rules", webcontext.WrapHandler(api.SetOrgPushRule))
 
 		systemGroup.POST("/migration/new_auth", webcontext.WrapHandler(api.MigrationNewAuth))
 	}
@@ -151,6 +162,16 @@ func addApiRoutes(g *echo.Group) {
 	// implements the service_rpc function
 	g.POST("/git-:service", webcontext.WrapHandler(api.ServiceRepoRPC))
 
+	// git lfs
+	g.POST("/info/lfs/objects/batch", webcontext.WrapHandler(api.LFSBatch))
+	g.GET("/info/lfs/objects/:oid", webcontext.WrapHandler(api.LFSDownload))
+	g.PUT("/info/lfs/objects/:oid", webcontext.WrapHandler(api.LFSUpload))
+	g.POST("/info/lfs/verify", webcontext.WrapHandler(api.LFSVerify))
+	g.GET("/info/lfs/locks", webcontext.WrapHandler(api.LFSListLocks))
+	g.POST("/info/lfs/locks", webcontext.WrapHandler(api.LFSCreateLock))
+	g.POST("/info/lfs/locks/verify", webcontext.WrapHandler(api.LFSVerifyLocks))
+	g.POST("/info/lfs/locks/:id/unlock", webcontext.WrapHandler(api.LFSUnlock))
+
 	g.GET("/commits/*", webcontext.WrapHandlerWithRepoCheck(api.GetRepoCommits))
 	g.POST("/commits", webcontext.WrapHandler(api.CreateCommit))
 
@@ -161,6 +182,9 @@ func addApiRoutes(g *echo.Group) {
 	g.DELETE("/branches/*", webcontext.WrapHandler(api.DeleteRepoBranch))
 	g.PUT("/branch/default/*", webcontext.WrapHandler(api.SetRepoDefaultBranch))
 	g.POST("/locked", webcontext.WrapHandler(api.SetLocked))
+	g.PUT("/merge-method", webcontext.WrapHandler(api.SetDefaultMergeMethod))
+	g.GET("/push-rules", webcontext.WrapHandler(api.GetPushRules))
+	g.PUT("/push-rules", webcontext.WrapHandler(api.SetPushRule))
 	g.GET("/stats/*", webcontext.WrapHandler(api.GetRepoStats))
 	g.GET("/stats", webcontext.WrapHandler(api.GetRepoStats))
 	g.GET("/tags", webcontext.WrapHandler(api.GetRepoTags))
@@ -187,6 +211,9 @@ func addApiRoutes(g *echo.Group) {
 	g.POST("/merge-requests/:id/merge", webcontext.WrapHandler(api.Merge))
 	g.POST("/merge-requests/:id/close", webcontext.WrapHandler(api.CloseMR))
 	g.POST("/merge-requests/:id/reopen", webcontext.WrapHandler(api.ReopenMR))
+	g.POST("/merge-requests/:id/approve", webcontext.WrapHandler(api.ApproveMR))
+	g.DELETE("/merge-requests/:id/approve", webcontext.WrapHandler(api.UnapproveMR))
+	g.GET("/merge-requests/:id/code-owners", webcontext.WrapHandler(api.GetMRCodeOwners))
 	g.GET("/merge-requests/:id/notes", webcontext.WrapHandler(api.QueryNotes))
 	g.POST("/merge-requests/:id/notes", webcontext.WrapHandler(api.CreateNotes))
 	g.POST("/check-runs", webcontext.WrapHandler(api.CreateCheckRun))
@@ -206,3 +233,27 @@ func addApiRoutes(g *echo.Group) {
 	g.GET("/archive/*", webcontext.WrapHandlerWithRepoCheck(api.GetArchive))
 
 }
+
+// newLFSContentStore 根据配置创建 lfs 对象存储，未开启 lfs 时返回 nil
+func newLFSContentStore() (*lfs.ContentStore, error) {
+	if !conf.LFSEnabled() {
+		return nil, nil
+	}
+	var s storage.Storager
+	storageType := storage.Type(conf.LFSStorageType())
+	switch storageType {
+	case storage.TypeFileSystem:
+		s = storage.NewFS()
+	case storage.TypeOSS:
+		s = storage.NewOSS(conf.LFSEndpoint(), conf.LFSAccessKeyID(), conf.LFSAccessKeySecret(), conf.LFSBucket(), nil, nil)
+	case storage.TypeS3:
+		s = storage.NewS3(conf.LFSEndpoint(), conf.LFSRegion(), conf.LFSAccessKeyID(), conf.LFSAccessKeySecret(),
+			conf.LFSBucket(), conf.LFSSecure())
+	default:
+		return nil, fmt.Errorf("invalid lfs storage type: %s", storageType)
+	}
+	if storageType != storage.TypeFileSystem && (conf.LFSEndpoint() == "" || conf.LFSBucket() == "") {
+		return nil, fmt.Errorf("endpoint and bucket are required for lfs storage type: %s", storageType)
+	}
+	return lfs.NewContentStore(s, conf.LFSPathPrefix()), nil
+}
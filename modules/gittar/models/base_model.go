@@ -13,7 +13,10 @@
 
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Model base model definition, including fields `ID`, `CreatedAt`, `UpdatedAt`, `DeletedAt`, which could be embedded in your models
 //    type User struct {
@@ -25,3 +28,14 @@ type BaseModel struct {
 	UpdatedAt time.Time  `json:"-"`
 	DeletedAt *time.Time `sql:"index" json:"-"`
 }
+
+// splitTrimmed 按 sep 切分字符串，去除首尾空白并忽略空项
+func splitTrimmed(s string, sep string) []string {
+	var result []string
+	for _, item := range strings.Split(s, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,249 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/gittar/pkg/codeowners"
+	"github.com/erda-project/erda/modules/gittar/pkg/gitmodule"
+)
+
+// 解析 CODEOWNERS 时分页查询项目成员的每页数量
+const codeOwnerMembersPageSize = 100
+
+// getCodeOwnersFile 读取提交中的 CODEOWNERS，不存在时返回 nil
+func getCodeOwnersFile(repo *gitmodule.Repository, commit *gitmodule.Commit) (*codeowners.File, error) {
+	for _, path := range codeowners.Paths {
+		entry, err := repo.GetTreeEntryByPath(commit.ID, path)
+		if err != nil || entry.IsDir() {
+			continue
+		}
+		rd, err := entry.Blob().Data()
+		if err != nil {
+			return nil, err
+		}
+		content, err := ioutil.ReadAll(rd)
+		if err != nil {
+			return nil, err
+		}
+		file, errs := codeowners.Parse(string(content))
+		for _, err := range errs {
+			logrus.Warnf("invalid %s in repo %s, %v", path, repo.Path, err)
+		}
+		return file, nil
+	}
+	return nil, nil
+}
+
+// getCodeOwnerGroups mr 修改的文件按目标分支 CODEOWNERS 命中的规则分组，无负责人的文件不返回
+func (svc *Service) getCodeOwnerGroups(repo *gitmodule.Repository, sourceCommit, targetCommit *gitmodule.Commit) ([]*apistructs.CodeOwnerApproval, error) {
+	file, err := getCodeOwnersFile(repo, targetCommit)
+	if err != nil || file == nil {
+		return nil, err
+	}
+	paths, err := repo.GetChangedPaths(sourceCommit, targetCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		groups []*apistructs.CodeOwnerApproval
+		rules  = map[int]*apistructs.CodeOwnerApproval{}
+		owners = map[int][]codeowners.Owner{}
+	)
+	for _, path := range paths {
+		rule := file.Match(path)
+		if rule == nil || len(rule.Owners) == 0 {
+			continue
+		}
+		group, ok := rules[rule.Line]
+		if !ok {
+			group = &apistructs.CodeOwnerApproval{Pattern: rule.Pattern, OwnerIDs: []string{}}
+			for _, owner := range rule.Owners {
+				group.Owners = append(group.Owners, owner.String())
+			}
+			rules[rule.Line] = group
+			owners[rule.Line] = rule.Owners
+			groups = append(groups, group)
+		}
+		group.Paths = append(group.Paths, path)
+	}
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	members, err := svc.listProjectMembers(repo.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	for line, group := range rules {
+		group.OwnerIDs = resolveCodeOwners(owners[line], members)
+		group.NoResolvableOwner = len(group.OwnerIDs) == 0
+		if group.NoResolvableOwner {
+			logrus.Warnf("no resolvable owner in code owners %s of %s, repo: %s",
+				strings.Join(group.Owners, " "), group.Pattern, repo.Path)
+		}
+	}
+	return groups, nil
+}
+
+// listProjectMembers 分页查询项目的全部成员
+func (svc *Service) listProjectMembers(projectID int64) ([]apistructs.Member, error) {
+	var members []apistructs.Member
+	for pageNo := 1; ; pageNo++ {
+		list, err := svc.bundle.ListMembers(apistructs.MemberListRequest{
+			ScopeType: apistructs.ProjectScope,
+			ScopeID:   projectID,
+			PageNo:    pageNo,
+			PageSize:  codeOwnerMembersPageSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, list...)
+		if len(list) < codeOwnerMembersPageSize {
+			return members, nil
+		}
+	}
+}
+
+// resolveCodeOwners 将用户名、邮箱和项目角色解析为项目成员 id
+func resolveCodeOwners(owners []codeowners.Owner, members []apistructs.Member) []string {
+	ids := []string{}
+	seen := map[string]bool{}
+	add := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, owner := range owners {
+		for _, member := range members {
+			if member.Removed || member.Deleted {
+				continue
+			}
+			switch owner.Type {
+			case codeowners.OwnerTypeRole:
+				for _, role := range member.Roles {
+					if strings.EqualFold(role, owner.Name) {
+						add(member.UserID)
+						break
+					}
+				}
+			case codeowners.OwnerTypeUser:
+				if member.Name == owner.Name || (member.Email != "" && strings.EqualFold(member.Email, owner.Name)) {
+					add(member.UserID)
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// GetMRCodeOwners mr 修改文件的 CODEOWNERS 负责人
+func (svc *Service) GetMRCodeOwners(repo *gitmodule.Repository, mergeId int) ([]*apistructs.CodeOwnerApproval, error) {
+	var mergeRequest MergeRequest
+	err := svc.db.Where("repo_id = ? and repo_merge_id = ?", repo.ID, mergeId).First(&mergeRequest).Error
+	if err != nil {
+		return nil, err
+	}
+	sourceCommit, err := repo.GetCommit(mergeRequest.SourceSha)
+	if err != nil {
+		return nil, err
+	}
+	// 与合并条件一致，以目标分支最新提交计算修改的文件
+	targetCommit, err := repo.GetBranchCommit(mergeRequest.TargetBranch)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := svc.getCodeOwnerGroups(repo, sourceCommit, targetCommit)
+	if err != nil {
+		return nil, err
+	}
+	if groups == nil {
+		groups = []*apistructs.CodeOwnerApproval{}
+	}
+	return groups, nil
+}
+
+// getCodeOwnerReviewers mr 修改文件的负责人，不包含作者
+func (svc *Service) getCodeOwnerReviewers(repo *gitmodule.Repository, sourceCommit, targetCommit *gitmodule.Commit, authorID string) ([]string, error) {
+	groups, err := svc.getCodeOwnerGroups(repo, sourceCommit, targetCommit)
+	if err != nil {
+		return nil, err
+	}
+	var ownerIDs []string
+	for _, group := range groups {
+		ownerIDs = append(ownerIDs, group.OwnerIDs...)
+	}
+	return mergeReviewerIDs(authorID, ownerIDs), nil
+}
+
+// recomputeCodeOwnerReviewers 目标分支变更时重新计算评审人
+// 未指定评审人时去掉原目标分支 CODEOWNERS 自动添加的负责人，再添加新目标分支的负责人
+func (svc *Service) recomputeCodeOwnerReviewers(repo *gitmodule.Repository, mergeRequest *MergeRequest,
+	sourceCommit, targetCommit *gitmodule.Commit, oldTargetSha string, reviewersSpecified bool) string {
+	reviewerIDs := strings.Split(mergeRequest.ReviewerIds, ",")
+	if !reviewersSpecified {
+		oldTargetCommit, err := repo.GetCommit(oldTargetSha)
+		if err == nil {
+			var oldCodeOwners []string
+			oldCodeOwners, err = svc.getCodeOwnerReviewers(repo, sourceCommit, oldTargetCommit, mergeRequest.AuthorId)
+			reviewerIDs = removeReviewerIDs(reviewerIDs, oldCodeOwners)
+		}
+		if err != nil {
+			logrus.Errorf("failed to get code owners of old target, repo: %s, err: %v", repo.Path, err)
+		}
+	}
+	codeOwners, err := svc.getCodeOwnerReviewers(repo, sourceCommit, targetCommit, mergeRequest.AuthorId)
+	if err != nil {
+		logrus.Errorf("failed to get code owners, repo: %s, err: %v", repo.Path, err)
+	}
+	return strings.Join(mergeReviewerIDs(mergeRequest.AuthorId, reviewerIDs, codeOwners), ",")
+}
+
+// removeReviewerIDs 从评审人中去掉 remove 中的用户
+func removeReviewerIDs(ids, remove []string) []string {
+	removed := map[string]bool{}
+	for _, id := range remove {
+		removed[id] = true
+	}
+	result := []string{}
+	for _, id := range ids {
+		if !removed[id] {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// mergeReviewerIDs 合并去重评审人，排除作者
+func mergeReviewerIDs(authorID string, lists ...[]string) []string {
+	result := []string{}
+	seen := map[string]bool{authorID: true}
+	for _, list := range lists {
+		for _, id := range list {
+			if id = strings.TrimSpace(id); id != "" && !seen[id] {
+				seen[id] = true
+				result = append(result, id)
+			}
+		}
+	}
+	return result
+}
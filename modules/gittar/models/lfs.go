@@ -0,0 +1,131 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/modules/gittar/conf"
+	"github.com/erda-project/erda/modules/gittar/pkg/lfs"
+)
+
+var (
+	ErrLFSQuotaExceeded = errors.New("lfs storage quota exceeded")
+	ErrLFSNotEnabled    = errors.New("lfs is not enabled")
+)
+
+var lfsContentStore *lfs.ContentStore
+
+// WithLFSContentStore 配置 lfs 对象存储
+func WithLFSContentStore(store *lfs.ContentStore) {
+	lfsContentStore = store
+}
+
+// LFSObject 仓库 lfs 对象记录，用于配额统计和仓库删除时回收
+type LFSObject struct {
+	ID        int64
+	RepoID    int64  `gorm:"index:idx_repo_id"`
+	Oid       string `gorm:"size:64"`
+	Size      int64
+	CreatedAt time.Time
+}
+
+func (svc *Service) LFSContentStore() (*lfs.ContentStore, error) {
+	if !conf.LFSEnabled() || lfsContentStore == nil {
+		return nil, ErrLFSNotEnabled
+	}
+	return lfsContentStore, nil
+}
+
+// GetLFSObjects 返回已存在的对象，key 为 oid
+func (svc *Service) GetLFSObjects(repoID int64, oids []string) (map[string]*LFSObject, error) {
+	result := map[string]*LFSObject{}
+	if len(oids) == 0 {
+		return result, nil
+	}
+	var objects []*LFSObject
+	err := svc.db.Where("repo_id = ? and oid in (?)", repoID, oids).Find(&objects).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, object := range objects {
+		result[object.Oid] = object
+	}
+	return result, nil
+}
+
+func (svc *Service) GetLFSObject(repoID int64, oid string) (*LFSObject, error) {
+	var object LFSObject
+	err := svc.db.Where("repo_id = ? and oid = ?", repoID, oid).First(&object).Error
+	if err != nil {
+		return nil, err
+	}
+	return &object, nil
+}
+
+func (svc *Service) CreateLFSObject(repoID int64, p lfs.Pointer) error {
+	object := LFSObject{
+		RepoID:    repoID,
+		Oid:       p.Oid,
+		Size:      p.Size,
+		CreatedAt: time.Now(),
+	}
+	return svc.db.Where("repo_id = ? and oid = ?", repoID, p.Oid).FirstOrCreate(&object).Error
+}
+
+// GetLFSUsedSize 仓库已使用的 lfs 存储大小
+func (svc *Service) GetLFSUsedSize(repoID int64) (int64, error) {
+	var size int64
+	err := svc.db.Model(&LFSObject{}).Where("repo_id = ?", repoID).
+		Select("COALESCE(SUM(size), 0)").Row().Scan(&size)
+	return size, err
+}
+
+// CheckLFSQuota 检查新增 incoming 字节后是否超过仓库配额
+func (svc *Service) CheckLFSQuota(repoID int64, incoming int64) error {
+	quota := conf.LFSRepoQuota()
+	if quota <= 0 || incoming <= 0 {
+		return nil
+	}
+	used, err := svc.GetLFSUsedSize(repoID)
+	if err != nil {
+		return err
+	}
+	if used+incoming > quota {
+		return ErrLFSQuotaExceeded
+	}
+	return nil
+}
+
+// RemoveLFSObjects 删除仓库所有 lfs 对象和锁
+func (svc *Service) RemoveLFSObjects(repo *Repo) error {
+	var objects []*LFSObject
+	if err := svc.db.Where("repo_id = ?", repo.ID).Find(&objects).Error; err != nil {
+		return err
+	}
+	if store, err := svc.LFSContentStore(); err == nil {
+		for _, object := range objects {
+			if err := store.Delete(repo.ID, object.Oid); err != nil {
+				logrus.Errorf("failed to delete lfs object, repo: %d, oid: %s, err: %v", repo.ID, object.Oid, err)
+			}
+		}
+	}
+	if err := svc.db.Where("repo_id = ?", repo.ID).Delete(&LFSObject{}).Error; err != nil {
+		return err
+	}
+	return svc.db.Where("repo_id = ?", repo.ID).Delete(&LFSLock{}).Error
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/erda-project/erda/modules/gittar/pkg/gitmodule"
+	"github.com/erda-project/erda/modules/gittar/pkg/lfs"
+)
+
+const defaultLFSLockLimit = 100
+
+var (
+	ErrLFSLockExists   = errors.New("lfs lock already exists")
+	ErrLFSLockNotOwner = errors.New("lfs lock is owned by others")
+)
+
+// LFSLock git lfs 文件锁
+type LFSLock struct {
+	ID        int64
+	RepoID    int64  `gorm:"index:idx_repo_id"`
+	Path      string `gorm:"size:191"`
+	Ref       string
+	OwnerID   string
+	OwnerName string
+	CreatedAt time.Time
+}
+
+func (l *LFSLock) ToLock() *lfs.Lock {
+	return &lfs.Lock{
+		ID:       strconv.FormatInt(l.ID, 10),
+		Path:     l.Path,
+		LockedAt: l.CreatedAt,
+		Owner:    &lfs.LockOwner{Name: l.OwnerName},
+	}
+}
+
+// CreateLFSLock 路径已被锁定时返回已存在的锁和 ErrLFSLockExists
+func (svc *Service) CreateLFSLock(repo *gitmodule.Repository, user *User, path string, ref string) (*LFSLock, error) {
+	var lock LFSLock
+	err := svc.db.Where("repo_id = ? and path = ?", repo.ID, path).First(&lock).Error
+	if err == nil {
+		return &lock, ErrLFSLockExists
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	lock = LFSLock{
+		RepoID:    repo.ID,
+		Path:      path,
+		Ref:       ref,
+		OwnerID:   user.Id,
+		OwnerName: user.NickName,
+		CreatedAt: time.Now(),
+	}
+	if lock.OwnerName == "" {
+		lock.OwnerName = user.Name
+	}
+	if err := svc.db.Create(&lock).Error; err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// ListLFSLocks cursor 为上一页最后一个锁的 id，返回下一页的 cursor
+func (svc *Service) ListLFSLocks(repoID int64, path string, id int64, cursor int64, limit int) ([]*LFSLock, string, error) {
+	if limit <= 0 || limit > defaultLFSLockLimit {
+		limit = defaultLFSLockLimit
+	}
+	query := svc.db.Where("repo_id = ?", repoID)
+	if path != "" {
+		query = query.Where("path = ?", path)
+	}
+	if id > 0 {
+		query = query.Where("id = ?", id)
+	}
+	if cursor > 0 {
+		query = query.Where("id > ?", cursor)
+	}
+	var locks []*LFSLock
+	if err := query.Order("id asc").Limit(limit + 1).Find(&locks).Error; err != nil {
+		return nil, "", err
+	}
+	var nextCursor string
+	if len(locks) > limit {
+		locks = locks[:limit]
+		nextCursor = strconv.FormatInt(locks[limit-1].ID, 10)
+	}
+	return locks, nextCursor, nil
+}
+
+// DeleteLFSLock 只有锁的所有者可以解锁，force 解锁他人的锁需要仓库管理权限
+func (svc *Service) DeleteLFSLock(repo *gitmodule.Repository, user *User, id int64, force bool) (*LFSLock, error) {
+	var lock LFSLock
+	if err := svc.db.Where("repo_id = ? and id = ?", repo.ID, id).First(&lock).Error; err != nil {
+		return nil, err
+	}
+	if lock.OwnerID != user.Id {
+		if !force {
+			return &lock, ErrLFSLockNotOwner
+		}
+		if err := svc.CheckPermission(repo, user, PermissionRepoLocked, nil); err != nil {
+			return nil, err
+		}
+	}
+	if err := svc.db.Delete(&lock).Error; err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
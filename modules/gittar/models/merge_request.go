@@ -17,6 +17,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jinzhu/gorm"
@@ -63,6 +64,8 @@ type MergeQueryCondition struct {
 type MergeOptions struct {
 	RemoveSourceBranch bool   `json:"removeSourceBranch"`
 	CommitMessage      string `json:"CommitMessage"`
+	// 合并方式，为空时使用 mr 或仓库配置的合并方式
+	MergeMethod string `json:"mergeMethod"`
 }
 
 //MergeRequest model
@@ -89,6 +92,9 @@ type MergeRequest struct {
 	CloseAt            *time.Time
 	Score              int `gorm:"size:150;index:idx_score"`
 	ScoreNum           int `gorm:"size:150;index:idx_score_num"`
+	MergeMethod        string
+	// 评审人 id，逗号分隔
+	ReviewerIds string `gorm:"type:text"`
 }
 
 type MrCheckRun struct {
@@ -122,6 +128,8 @@ func (mergeRequest *MergeRequest) ToInfo(repo *gitmodule.Repository) *apistructs
 	result.AppID = repo.ApplicationId
 	result.Score = mergeRequest.Score
 	result.ScoreNum = mergeRequest.ScoreNum
+	result.MergeMethod = mergeRequest.MergeMethod
+	result.ReviewerIds = splitTrimmed(mergeRequest.ReviewerIds, ",")
 
 	if mergeRequest.SourceBranch != "" && mergeRequest.TargetBranch != "" {
 		result.DefaultCommitMessage = fmt.Sprintf("Merge branch '%s' into '%s'", mergeRequest.SourceBranch, mergeRequest.TargetBranch)
@@ -172,6 +180,9 @@ func (svc *Service) CreateMergeRequest(repo *gitmodule.Repository, user *User, i
 	if err != nil {
 		return nil, err
 	}
+	if err := checkMergeMethod(info.MergeMethod); err != nil {
+		return nil, err
+	}
 	var lastMr MergeRequest
 	err = svc.db.Where("repo_id = ? ", info.RepoID).Order("repo_merge_id desc").FirstOrInit(&lastMr).Error
 	if err != nil {
@@ -188,6 +199,13 @@ func (svc *Service) CreateMergeRequest(repo *gitmodule.Repository, user *User, i
 		return nil, err
 	}
 
+	// 自动添加修改文件的 CODEOWNERS 负责人为评审人
+	codeOwners, err := svc.getCodeOwnerReviewers(repo, sourceCommit, targetCommit, user.Id)
+	if err != nil {
+		logrus.Errorf("failed to get code owners, repo: %s, err: %v", repo.Path, err)
+	}
+	reviewerIDs := mergeReviewerIDs(user.Id, info.ReviewerIds, codeOwners)
+
 	mergeRequest := MergeRequest{
 		RepoID:             repo.ID,
 		Title:              info.Title,
@@ -201,6 +219,8 @@ func (svc *Service) CreateMergeRequest(repo *gitmodule.Repository, user *User, i
 		TargetSha:          targetCommit.ID,
 		RemoveSourceBranch: info.RemoveSourceBranch,
 		RepoMergeId:        lastMr.RepoMergeId + 1,
+		MergeMethod:        info.MergeMethod,
+		ReviewerIds:        strings.Join(reviewerIDs, ","),
 	}
 	err = svc.db.Create(&mergeRequest).Error
 	if err != nil {
@@ -232,6 +252,7 @@ func (svc *Service) UpdateMergeRequest(repo *gitmodule.Repository, user *User, i
 		}
 	}
 
+	oldTargetBranch, oldTargetSha := mergeRequest.TargetBranch, mergeRequest.TargetSha
 	if info.ScoreNum > mergeRequest.ScoreNum { //更新评分
 		mergeRequest.Score = info.Score
 		mergeRequest.ScoreNum = info.ScoreNum
@@ -242,6 +263,14 @@ func (svc *Service) UpdateMergeRequest(repo *gitmodule.Repository, user *User, i
 		mergeRequest.Description = info.Description
 		mergeRequest.RemoveSourceBranch = info.RemoveSourceBranch
 		mergeRequest.AssigneeId = info.AssigneeId
+		if err := checkMergeMethod(info.MergeMethod); err != nil {
+			return nil, err
+		}
+		mergeRequest.MergeMethod = info.MergeMethod
+		// 未传评审人时保持不变
+		if info.ReviewerIds != nil {
+			mergeRequest.ReviewerIds = strings.Join(mergeReviewerIDs(mergeRequest.AuthorId, info.ReviewerIds), ",")
+		}
 	}
 
 	if len(info.State) > 0 {
@@ -258,6 +287,11 @@ func (svc *Service) UpdateMergeRequest(repo *gitmodule.Repository, user *User, i
 		info.TargetSha = targetCommit.ID
 		mergeRequest.TargetSha = targetCommit.ID
 	}
+	// 目标分支变更后按新目标分支的 CODEOWNERS 重新计算评审人
+	if mergeRequest.TargetBranch != oldTargetBranch && sourceCommit != nil && targetCommit != nil {
+		mergeRequest.ReviewerIds = svc.recomputeCodeOwnerReviewers(repo, &mergeRequest, sourceCommit, targetCommit,
+			oldTargetSha, info.ReviewerIds != nil)
+	}
 	err = svc.db.Save(&mergeRequest).Error
 	if err != nil {
 		return nil, err
@@ -297,6 +331,12 @@ func (svc *Service) GetMergeRequestDetail(repo *gitmodule.Repository, mergeId in
 	}
 	result := mergeRequest.ToInfo(repo)
 	result.IsCheckRunValid, err = svc.IsCheckRunsValid(repo, mergeRequest.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mergeRequest.State == MERGE_REQUEST_OPEN {
+		result.MergeRequirements, err = svc.GetMergeRequirements(repo, &mergeRequest)
+	}
 	return result, err
 }
 
@@ -318,7 +358,8 @@ func (svc *Service) SyncMergeRequest(repo *gitmodule.Repository, branch string,
 		if flag {
 			go func(mergeRequest MergeRequest) {
 				// check-run
-				conflictInfo, err := repo.GetMergeStatus(mergeRequest.SourceBranch, mergeRequest.TargetBranch)
+				conflictInfo, err := repo.GetMergeStatusWithMethod(mergeRequest.SourceBranch, mergeRequest.TargetBranch,
+					repo.ResolveMergeMethod(mergeRequest.MergeMethod))
 				if err != nil {
 					logrus.Info("has conflict, err: ", err)
 					return
@@ -425,7 +466,29 @@ func (svc *Service) Merge(repo *gitmodule.Repository, user *User, mergeId int, m
 		return nil, err
 	}
 
-	mergeStatus, err := repo.GetMergeStatus(mergeRequest.SourceBranch, mergeRequest.TargetBranch)
+	if err := checkMergeMethod(mergeOptions.MergeMethod); err != nil {
+		return nil, err
+	}
+	mergeMethod := repo.ResolveMergeMethod(mergeOptions.MergeMethod, mergeRequest.MergeMethod)
+	if !repo.IsMergeMethodAllowed(mergeRequest.TargetBranch, mergeMethod) {
+		return nil, fmt.Errorf("merge method %s is not allowed on branch %s", mergeMethod, mergeRequest.TargetBranch)
+	}
+
+	// 以源分支最新提交校验 check run 和审批
+	sourceCommit, err := repo.GetBranchCommit(mergeRequest.SourceBranch)
+	if err != nil {
+		return nil, err
+	}
+	mergeRequest.SourceSha = sourceCommit.ID
+	requirements, err := svc.GetMergeRequirements(repo, &mergeRequest)
+	if err != nil {
+		return nil, err
+	}
+	if !requirements.Satisfied {
+		return nil, ErrMergeRequirementsUnmet{Requirements: requirements}
+	}
+
+	mergeStatus, err := repo.GetMergeStatusWithMethod(mergeRequest.SourceBranch, mergeRequest.TargetBranch, mergeMethod)
 	if err != nil {
 		return nil, err
 	}
@@ -434,7 +497,7 @@ func (svc *Service) Merge(repo *gitmodule.Repository, user *User, mergeId int, m
 	}
 
 	if mergeStatus.HasConflict {
-		return nil, errors.New("has conflict")
+		return nil, gitmodule.ErrMergeConflict{Reason: mergeStatus.ConflictMsg}
 	}
 
 	if repo.IsProtectBranch(mergeRequest.TargetBranch) ||
@@ -447,13 +510,13 @@ func (svc *Service) Merge(repo *gitmodule.Repository, user *User, mergeId int, m
 
 	if mergeOptions.CommitMessage == "" {
 		mergeOptions.CommitMessage = fmt.Sprintf("Merge branch '%s' into '%s'", mergeRequest.SourceBranch, mergeRequest.TargetBranch)
+		if mergeMethod == apistructs.MergeMethodSquash && mergeRequest.Title != "" {
+			mergeOptions.CommitMessage = mergeRequest.Title
+		}
 	}
-	_, err = repo.GetBranchCommit(mergeRequest.SourceBranch)
-	if err != nil {
-		return nil, err
-	}
-
-	commit, err := repo.Merge(mergeRequest.SourceBranch, mergeRequest.TargetBranch, user.ToGitSignature(), mergeOptions.CommitMessage)
+	// 只合并已校验过的源分支提交, 防止校验后推送的提交绕过合并条件
+	commit, err := repo.MergeWithMethod(mergeRequest.SourceBranch, sourceCommit.ID, mergeRequest.TargetBranch, mergeMethod,
+		user.ToGitSignature(), mergeOptions.CommitMessage)
 
 	now := time.Now()
 	if err == nil {
@@ -528,6 +591,9 @@ func (svc *Service) ReopenMR(repo *gitmodule.Repository, user *User, mergeId int
 }
 
 func (svc *Service) RemoveMR(repository *Repo) error {
+	if err := svc.removeRepoMRApprovals(repository.ID); err != nil {
+		logrus.Errorf("failed to remove merge request approvals, repo: %d, err: %v", repository.ID, err)
+	}
 	req := &MergeRequest{}
 	svc.db.Where("repo_id =? ", repository.ID).Delete(&req)
 	svc.RemoveCheckRuns(req.ID)
@@ -548,6 +614,13 @@ func (svc *Service) CountMR(repo *gitmodule.Repository, state string) (int, erro
 	return count, nil
 }
 
+func checkMergeMethod(method string) error {
+	if method != "" && !apistructs.MergeMethod(method).Valid() {
+		return fmt.Errorf("invalid merge method: %s", method)
+	}
+	return nil
+}
+
 func getMrUserRole(mergeRequest MergeRequest, userID string) []string {
 	var roleList []string
 	if mergeRequest.AuthorId == userID {
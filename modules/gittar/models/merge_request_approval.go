@@ -0,0 +1,184 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/gittar/pkg/gitmodule"
+	"github.com/erda-project/erda/modules/gittar/pkg/mergecheck"
+	"github.com/erda-project/erda/modules/gittar/uc"
+)
+
+var ErrApproveOwnMR = errors.New("can not approve your own merge request")
+
+// ErrMergeRequirementsUnmet 目标分支规则要求的合并条件未满足
+type ErrMergeRequirementsUnmet struct {
+	Requirements *apistructs.MergeRequirements
+}
+
+func (e ErrMergeRequirementsUnmet) Error() string {
+	return "merge requirements not met: " + strings.Join(e.Requirements.Unmet, "; ")
+}
+
+// MergeRequestApproval mr 审批，每人保留最后一次审批
+type MergeRequestApproval struct {
+	ID        int64
+	MrID      int64  `gorm:"unique_index:uk_mr_user"`
+	UserID    string `gorm:"size:150;unique_index:uk_mr_user"`
+	CommitSha string `gorm:"size:150"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (a *MergeRequestApproval) ToApiData() *apistructs.MergeRequestApproval {
+	return &apistructs.MergeRequestApproval{
+		UserID:    a.UserID,
+		CommitSha: a.CommitSha,
+		CreatedAt: a.UpdatedAt,
+	}
+}
+
+func (svc *Service) getOpenMergeRequest(repo *gitmodule.Repository, mergeId int) (*MergeRequest, error) {
+	var mergeRequest MergeRequest
+	err := svc.db.Where("repo_id = ? and repo_merge_id = ?", repo.ID, mergeId).First(&mergeRequest).Error
+	if err != nil {
+		return nil, err
+	}
+	if mergeRequest.State != MERGE_REQUEST_OPEN {
+		return nil, errors.New("invalid state " + mergeRequest.State)
+	}
+	return &mergeRequest, nil
+}
+
+// ApproveMR 审批 mr 当前的源分支提交，重复审批时更新审批的提交
+func (svc *Service) ApproveMR(repo *gitmodule.Repository, user *User, mergeId int) (*apistructs.MergeRequestApproval, error) {
+	mergeRequest, err := svc.getOpenMergeRequest(repo, mergeId)
+	if err != nil {
+		return nil, err
+	}
+	if mergeRequest.AuthorId == user.Id {
+		return nil, ErrApproveOwnMR
+	}
+	if err := svc.CheckPermission(repo, user, PermissionCreateMR, getMrUserRole(*mergeRequest, user.Id)); err != nil {
+		return nil, err
+	}
+	commit, err := repo.GetBranchCommit(mergeRequest.SourceBranch)
+	if err != nil {
+		return nil, err
+	}
+
+	var approval MergeRequestApproval
+	err = svc.db.Where("mr_id = ? and user_id = ?", mergeRequest.ID, user.Id).First(&approval).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	approval.MrID = mergeRequest.ID
+	approval.UserID = user.Id
+	approval.CommitSha = commit.ID
+	if err := svc.db.Save(&approval).Error; err != nil {
+		return nil, err
+	}
+	return approval.ToApiData(), nil
+}
+
+// UnapproveMR 撤销自己的审批
+func (svc *Service) UnapproveMR(repo *gitmodule.Repository, user *User, mergeId int) error {
+	mergeRequest, err := svc.getOpenMergeRequest(repo, mergeId)
+	if err != nil {
+		return err
+	}
+	return svc.db.Where("mr_id = ? and user_id = ?", mergeRequest.ID, user.Id).Delete(&MergeRequestApproval{}).Error
+}
+
+func (svc *Service) listMRApprovals(mrID int64) ([]*apistructs.MergeRequestApproval, error) {
+	var approvals []MergeRequestApproval
+	if err := svc.db.Where("mr_id = ?", mrID).Order("updated_at").Find(&approvals).Error; err != nil {
+		return nil, err
+	}
+	result := make([]*apistructs.MergeRequestApproval, 0, len(approvals))
+	for _, approval := range approvals {
+		data := approval.ToApiData()
+		dto, err := uc.FindUserByIdWithDesensitize(approval.UserID)
+		if err == nil {
+			data.User = dto
+		} else {
+			logrus.Errorf("get user from uc error: %v", err)
+		}
+		result = append(result, data)
+	}
+	return result, nil
+}
+
+// removeRepoMRApprovals 删除仓库下所有 mr 的审批
+func (svc *Service) removeRepoMRApprovals(repoID int64) error {
+	mrIDs := svc.db.Model(&MergeRequest{}).Select("id").Where("repo_id = ?", repoID).QueryExpr()
+	return svc.db.Where("mr_id in (?)", mrIDs).Delete(&MergeRequestApproval{}).Error
+}
+
+// GetMergeRequirements 计算 mr 的合并条件，仅保护分支按规则校验
+func (svc *Service) GetMergeRequirements(repo *gitmodule.Repository, mergeRequest *MergeRequest) (*apistructs.MergeRequirements, error) {
+	rule := repo.GetBranchRule(mergeRequest.TargetBranch)
+	if !rule.IsProtect {
+		rule = &apistructs.ValidBranch{Name: mergeRequest.TargetBranch}
+	}
+
+	var checkRuns []*CheckRun
+	// check run 关联的是仓库内的 mr 序号
+	err := svc.db.Where("mr_id = ? and repo_id = ? and commit = ?", mergeRequest.RepoMergeId, repo.ID, mergeRequest.SourceSha).
+		Find(&checkRuns).Error
+	if err != nil {
+		return nil, err
+	}
+	apiCheckRuns := make([]*apistructs.CheckRun, 0, len(checkRuns))
+	for _, checkRun := range checkRuns {
+		apiCheckRuns = append(apiCheckRuns, &apistructs.CheckRun{
+			ID:     checkRun.ID,
+			Name:   checkRun.Name,
+			MrID:   checkRun.MrID,
+			Commit: checkRun.Commit,
+			Status: checkRun.Status,
+			Result: checkRun.Result,
+		})
+	}
+
+	approvals, err := svc.listMRApprovals(mergeRequest.ID)
+	if err != nil {
+		return nil, err
+	}
+	result := mergecheck.Evaluate(rule, mergeRequest.AuthorId, mergeRequest.SourceBranch, mergeRequest.SourceSha, apiCheckRuns, approvals)
+
+	if rule.RequireCodeOwnerApproval {
+		sourceCommit, err := repo.GetCommit(mergeRequest.SourceSha)
+		if err != nil {
+			return nil, err
+		}
+		targetCommit, err := repo.GetBranchCommit(mergeRequest.TargetBranch)
+		if err != nil {
+			return nil, err
+		}
+		groups, err := svc.getCodeOwnerGroups(repo, sourceCommit, targetCommit)
+		if err != nil {
+			return nil, err
+		}
+		mergecheck.CheckCodeOwners(result, groups)
+	}
+	return result, nil
+}
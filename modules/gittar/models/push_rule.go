@@ -0,0 +1,135 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/gittar/pkg/gitmodule"
+	"github.com/erda-project/erda/modules/gittar/pkg/pushpolicy"
+)
+
+// PushRule 推送规则，按组织或仓库配置
+type PushRule struct {
+	ID                  int64
+	ScopeType           string `gorm:"size:32;unique_index:uk_scope"`
+	ScopeID             int64  `gorm:"unique_index:uk_scope"`
+	CommitMessageRegex  string `gorm:"size:1024"`
+	MaxFileSize         int64
+	ForbiddenPaths      string `gorm:"type:text"` // 按行分隔
+	AllowedEmailDomains string `gorm:"size:1024"`
+	SecretScan          bool
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+func splitRuleList(s string, sep string) []string {
+	return splitTrimmed(s, sep)
+}
+
+func (r *PushRule) ToApiData() *apistructs.PushRule {
+	return &apistructs.PushRule{
+		ScopeType:           r.ScopeType,
+		ScopeID:             r.ScopeID,
+		CommitMessageRegex:  r.CommitMessageRegex,
+		MaxFileSize:         r.MaxFileSize,
+		ForbiddenPaths:      splitRuleList(r.ForbiddenPaths, "\n"),
+		AllowedEmailDomains: splitRuleList(r.AllowedEmailDomains, ","),
+		SecretScan:          r.SecretScan,
+	}
+}
+
+// GetPushRule 未配置时返回 nil
+func (svc *Service) GetPushRule(scopeType string, scopeID int64) (*apistructs.PushRule, error) {
+	var rule PushRule
+	err := svc.db.Where("scope_type = ? and scope_id = ?", scopeType, scopeID).First(&rule).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rule.ToApiData(), nil
+}
+
+// SavePushRule 创建或更新推送规则
+func (svc *Service) SavePushRule(rule *apistructs.PushRule) (*apistructs.PushRule, error) {
+	if rule.ScopeType != apistructs.PushRuleScopeOrg && rule.ScopeType != apistructs.PushRuleScopeRepo {
+		return nil, fmt.Errorf("invalid scope type: %s", rule.ScopeType)
+	}
+	if err := pushpolicy.Validate(rule); err != nil {
+		return nil, err
+	}
+	var current PushRule
+	err := svc.db.Where("scope_type = ? and scope_id = ?", rule.ScopeType, rule.ScopeID).First(&current).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	current.ScopeType = rule.ScopeType
+	current.ScopeID = rule.ScopeID
+	current.CommitMessageRegex = rule.CommitMessageRegex
+	current.MaxFileSize = rule.MaxFileSize
+	current.ForbiddenPaths = strings.Join(rule.ForbiddenPaths, "\n")
+	current.AllowedEmailDomains = strings.Join(rule.AllowedEmailDomains, ",")
+	current.SecretScan = rule.SecretScan
+	if err := svc.db.Save(&current).Error; err != nil {
+		return nil, err
+	}
+	return current.ToApiData(), nil
+}
+
+// GetRepoPushRules 仓库的组织级和仓库级规则
+func (svc *Service) GetRepoPushRules(repo *gitmodule.Repository) (*apistructs.PushRulesData, error) {
+	var (
+		data apistructs.PushRulesData
+		err  error
+	)
+	if data.Org, err = svc.GetPushRule(apistructs.PushRuleScopeOrg, repo.OrgId); err != nil {
+		return nil, err
+	}
+	if data.Repo, err = svc.GetPushRule(apistructs.PushRuleScopeRepo, repo.ID); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// SetRepoPushRule 设置仓库级规则
+func (svc *Service) SetRepoPushRule(repo *gitmodule.Repository, user *User, rule *apistructs.PushRule) (*apistructs.PushRule, error) {
+	if err := svc.CheckPermission(repo, user, PermissionRepoLocked, nil); err != nil {
+		return nil, err
+	}
+	rule.ScopeType = apistructs.PushRuleScopeRepo
+	rule.ScopeID = repo.ID
+	return svc.SavePushRule(rule)
+}
+
+// GetEffectivePushRules 推送时需校验的规则，组织级和仓库级都需满足
+func (svc *Service) GetEffectivePushRules(repo *gitmodule.Repository) ([]*apistructs.PushRule, error) {
+	data, err := svc.GetRepoPushRules(repo)
+	if err != nil {
+		return nil, err
+	}
+	var rules []*apistructs.PushRule
+	for _, rule := range []*apistructs.PushRule{data.Org, data.Repo} {
+		if rule != nil && !rule.IsEmpty() {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
@@ -16,6 +16,7 @@ package models
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path"
 	"strconv"
@@ -32,18 +33,19 @@ import (
 var ModeExternal = "external"
 
 type Repo struct {
-	ID          int64
-	OrgID       int64
-	ProjectID   int64
-	AppID       int64
-	OrgName     string `gorm:"size:150;index:idx_org_name"`
-	ProjectName string `gorm:"size:150;index:idx_project_name"`
-	AppName     string `gorm:"size:150;index:idx_app_name"`
-	Path        string `gorm:"size:150;index:idx_path"`
-	IsLocked    bool   `gorm:"size:150;index:idx_is_locked"`
-	Size        int64
-	IsExternal  bool
-	Config      string
+	ID                 int64
+	OrgID              int64
+	ProjectID          int64
+	AppID              int64
+	OrgName            string `gorm:"size:150;index:idx_org_name"`
+	ProjectName        string `gorm:"size:150;index:idx_project_name"`
+	AppName            string `gorm:"size:150;index:idx_app_name"`
+	Path               string `gorm:"size:150;index:idx_path"`
+	IsLocked           bool   `gorm:"size:150;index:idx_is_locked"`
+	Size               int64
+	IsExternal         bool
+	Config             string
+	DefaultMergeMethod string `gorm:"size:32"`
 }
 
 func (Repo) TableName() string {
@@ -140,6 +142,22 @@ func (svc *Service) SetLocked(repo *gitmodule.Repository, user *User, info *apis
 	return info, nil
 }
 
+func (svc *Service) SetDefaultMergeMethod(repo *gitmodule.Repository, user *User, info *apistructs.DefaultMergeMethodRequest) (*apistructs.DefaultMergeMethodRequest, error) {
+	if err := svc.CheckPermission(repo, user, PermissionRepoLocked, nil); err != nil {
+		return nil, err
+	}
+	// 为空表示重置为系统默认合并方式
+	if info.MergeMethod != "" && !info.MergeMethod.Valid() {
+		return nil, fmt.Errorf("invalid merge method: %s", info.MergeMethod)
+	}
+
+	err := svc.db.Table("dice_repos").Where("app_id = ?", info.AppID).Update("default_merge_method", info.MergeMethod).Error
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
 func (svc *Service) DeleteRepo(repo *Repo) error {
 	repoPath := repo.DiskPath()
 	logrus.Infof("remove gitRepo %v", repoPath)
@@ -166,6 +184,10 @@ func (svc *Service) DeleteRepo(repo *Repo) error {
 		return err
 	}
 	err = svc.RemoveMR(repo)
+	if err != nil {
+		return err
+	}
+	err = svc.RemoveLFSObjects(repo)
 	return err
 }
 
@@ -0,0 +1,236 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package codeowners 解析 CODEOWNERS 文件并匹配文件路径的负责人
+//
+// 每行格式为 `<pattern> <owner>...`，路径规则与 .gitignore 一致，后面的规则优先。
+// 负责人支持用户名 @dev、邮箱 dev@erda.cloud 和项目角色 @role:Lead。
+package codeowners
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Paths CODEOWNERS 文件的查找位置，按顺序使用第一个存在的文件
+var Paths = []string{"CODEOWNERS", ".erda/CODEOWNERS", "docs/CODEOWNERS"}
+
+const (
+	OwnerTypeUser = "user"
+	OwnerTypeRole = "role"
+
+	rolePrefix = "@role:"
+)
+
+// Owner 负责人，Name 为用户名、邮箱或项目角色
+type Owner struct {
+	Type string
+	Name string
+}
+
+func (o Owner) String() string {
+	if o.Type == OwnerTypeRole {
+		return rolePrefix + o.Name
+	}
+	if strings.Contains(o.Name, "@") {
+		return o.Name
+	}
+	return "@" + o.Name
+}
+
+// Rule CODEOWNERS 中的一行规则
+type Rule struct {
+	Pattern string
+	Owners  []Owner
+	Line    int
+
+	re *regexp.Regexp
+}
+
+// File 解析后的 CODEOWNERS
+type File struct {
+	Rules []*Rule
+}
+
+// ParseError 无效的行，解析时跳过
+type ParseError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// Parse 解析 CODEOWNERS 内容，无效的行会被跳过并返回对应的错误
+func Parse(content string) (*File, []error) {
+	var (
+		file = &File{}
+		errs []error
+	)
+	for i, line := range strings.Split(content, "\n") {
+		lineNo := i + 1
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		rule := &Rule{Pattern: fields[0], Line: lineNo}
+		re, err := compilePattern(rule.Pattern)
+		if err != nil {
+			errs = append(errs, &ParseError{Line: lineNo, Msg: err.Error()})
+			continue
+		}
+		rule.re = re
+
+		var ownerErr error
+		for _, field := range fields[1:] {
+			// 行尾注释
+			if strings.HasPrefix(field, "#") {
+				break
+			}
+			owner, err := parseOwner(field)
+			if err != nil {
+				ownerErr = err
+				break
+			}
+			rule.Owners = append(rule.Owners, owner)
+		}
+		if ownerErr != nil {
+			errs = append(errs, &ParseError{Line: lineNo, Msg: ownerErr.Error()})
+			continue
+		}
+		file.Rules = append(file.Rules, rule)
+	}
+	return file, errs
+}
+
+func parseOwner(s string) (Owner, error) {
+	switch {
+	case strings.HasPrefix(s, rolePrefix):
+		if role := strings.TrimPrefix(s, rolePrefix); role != "" {
+			return Owner{Type: OwnerTypeRole, Name: role}, nil
+		}
+	case strings.HasPrefix(s, "@"):
+		if name := strings.TrimPrefix(s, "@"); name != "" && !strings.ContainsAny(name, "@:") {
+			return Owner{Type: OwnerTypeUser, Name: name}, nil
+		}
+	case strings.Count(s, "@") == 1 && !strings.HasSuffix(s, "@"):
+		return Owner{Type: OwnerTypeUser, Name: s}, nil
+	}
+	return Owner{}, fmt.Errorf("invalid owner %q", s)
+}
+
+// Match 返回路径匹配的最后一条规则，未匹配时返回 nil
+func (f *File) Match(path string) *Rule {
+	path = strings.TrimPrefix(path, "/")
+	for i := len(f.Rules) - 1; i >= 0; i-- {
+		if f.Rules[i].re.MatchString(path) {
+			return f.Rules[i]
+		}
+	}
+	return nil
+}
+
+// Owners 路径的负责人
+func (f *File) Owners(path string) []Owner {
+	if rule := f.Match(path); rule != nil {
+		return rule.Owners
+	}
+	return nil
+}
+
+// compilePattern 将 gitignore 风格的规则转换为正则
+// 含 / 的规则从仓库根目录匹配，否则匹配任意目录；以 / 结尾或最后一级不含通配符时同时匹配目录下的所有文件
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	p := pattern
+	anchored := strings.HasPrefix(p, "/")
+	p = strings.TrimPrefix(p, "/")
+	dirOnly := strings.HasSuffix(p, "/")
+	p = strings.TrimSuffix(p, "/")
+	if p == "" {
+		return nil, fmt.Errorf("invalid pattern %q", pattern)
+	}
+	if strings.Contains(p, "/") {
+		anchored = true
+	}
+
+	var buf strings.Builder
+	buf.WriteString("^")
+	if !anchored {
+		buf.WriteString("(?:.*/)?")
+	}
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		if segment == "**" {
+			if last {
+				buf.WriteString(".*")
+			} else {
+				buf.WriteString("(?:.*/)?")
+			}
+			continue
+		}
+		expr, err := globToRegexp(segment)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+		buf.WriteString(expr)
+		if !last {
+			buf.WriteString("/")
+		}
+	}
+
+	lastSegment := segments[len(segments)-1]
+	switch {
+	case lastSegment == "**":
+	case dirOnly:
+		buf.WriteString("/.*")
+	case !strings.ContainsAny(lastSegment, "*?["):
+		buf.WriteString("(?:/.*)?")
+	}
+	buf.WriteString("$")
+	return regexp.Compile(buf.String())
+}
+
+func globToRegexp(glob string) (string, error) {
+	var buf strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			buf.WriteString("[^/]*")
+		case '?':
+			buf.WriteString("[^/]")
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				buf.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated character class")
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			buf.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const content = `# 默认负责人
+*                   @lead dev@erda.cloud
+
+*.go                @gopher
+/docs/              @role:PM
+apps/               @role:Dev   # 任意目录下的 apps
+/build/logs/        @ops
+docs/*.md           @writer
+**/config/**        @ops
+/scripts/deploy     @ops
+/vendor/
+`
+
+func TestParse(t *testing.T) {
+	file, errs := Parse(content)
+	assert.Empty(t, errs)
+	require.Len(t, file.Rules, 9)
+
+	assert.Equal(t, []Owner{{OwnerTypeUser, "lead"}, {OwnerTypeUser, "dev@erda.cloud"}}, file.Rules[0].Owners)
+	assert.Equal(t, []Owner{{OwnerTypeRole, "Dev"}}, file.Rules[3].Owners)
+	assert.Equal(t, "@role:Dev", file.Rules[3].Owners[0].String())
+	assert.Equal(t, "dev@erda.cloud", file.Rules[0].Owners[1].String())
+	assert.Equal(t, 4, file.Rules[1].Line)
+	assert.Empty(t, file.Rules[8].Owners)
+}
+
+func TestParse_InvalidLines(t *testing.T) {
+	file, errs := Parse("*.go @gopher\n[abc @x\n*.js @\n*.md @role:\n*.py dev@\n*.rb @a @b")
+	require.Len(t, errs, 4)
+	assert.Equal(t, "line 2: invalid pattern \"[abc\": unterminated character class", errs[0].Error())
+	assert.Equal(t, 3, errs[1].(*ParseError).Line)
+	require.Len(t, file.Rules, 2)
+	assert.Equal(t, "*.rb", file.Rules[1].Pattern)
+}
+
+func TestMatch(t *testing.T) {
+	file, _ := Parse(content)
+	cases := map[string]string{
+		"README.md":                   "*",
+		"main.go":                     "*.go",
+		"pkg/util/util.go":            "*.go",
+		"docs/index.md":               "docs/*.md",
+		"docs/guide/index.md":         "/docs/",
+		"docs/main.go":                "/docs/",
+		"src/docs/a.txt":              "*",
+		"apps/web/index.js":           "apps/",
+		"modules/apps/api/main.js":    "apps/",
+		"build/logs/a.log":            "/build/logs/",
+		"deploy/build/logs/a.log":     "*",
+		"config/app.yml":              "**/config/**",
+		"modules/a/config/app.yml":    "**/config/**",
+		"scripts/deploy":              "/scripts/deploy",
+		"scripts/deploy/prod.sh":      "/scripts/deploy",
+		"scripts/deployment.sh":       "*",
+		"vendor/github.com/x/main.go": "/vendor/",
+	}
+	for path, pattern := range cases {
+		rule := file.Match(path)
+		require.NotNil(t, rule, path)
+		assert.Equal(t, pattern, rule.Pattern, path)
+	}
+
+	// vendor 下的文件无负责人
+	assert.Empty(t, file.Owners("vendor/a.go"))
+	assert.Equal(t, []Owner{{OwnerTypeUser, "gopher"}}, file.Owners("/cmd/main.go"))
+
+	empty, _ := Parse("")
+	assert.Nil(t, empty.Match("main.go"))
+}
+
+func TestCompilePattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**/b", "a/x/y/c", false},
+		{"file?.txt", "dir/file1.txt", true},
+		{"file[0-9].txt", "file1.txt", true},
+		{"file[!0-9].txt", "file1.txt", false},
+		{"\\#notes", "#notes", true},
+		{"build/", "build", false},
+		{"build", "build", true},
+	}
+	for _, c := range cases {
+		re, err := compilePattern(c.pattern)
+		require.NoError(t, err, c.pattern)
+		assert.Equal(t, c.match, re.MatchString(c.path), "%s %s", c.pattern, c.path)
+	}
+	_, err := compilePattern("/")
+	assert.Error(t, err)
+}
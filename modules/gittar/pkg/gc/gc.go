@@ -66,7 +66,8 @@ func repositoryClean() {
 	// initialize a waitGroup according to the number of concurrent
 	var wait = limit_sync_group.NewSemaphore(concurrentNum)
 	for _, projectFileInfo := range projectFileInfos {
-		if !projectFileInfo.IsDir() {
+		// 跳过 .lfs 等非仓库目录
+		if !projectFileInfo.IsDir() || strings.HasPrefix(projectFileInfo.Name(), ".") {
 			continue
 		}
 		var projectPath = repositoryRootAddr + "/" + projectFileInfo.Name()
@@ -59,3 +59,35 @@ func IsErrNoMergeBase(err error) bool {
 func (err ErrNoMergeBase) Error() string {
 	return "no merge based found"
 }
+
+type ErrMergeConflict struct {
+	Reason string
+}
+
+func IsErrMergeConflict(err error) bool {
+	_, ok := err.(ErrMergeConflict)
+	return ok
+}
+
+func (err ErrMergeConflict) Error() string {
+	if err.Reason == "" {
+		return "has conflict"
+	}
+	return "has conflict: " + err.Reason
+}
+
+// ErrSourceBranchMoved 校验合并条件后源分支又有新提交
+type ErrSourceBranchMoved struct {
+	Branch   string
+	Expected string
+	Actual   string
+}
+
+func IsErrSourceBranchMoved(err error) bool {
+	_, ok := err.(ErrSourceBranchMoved)
+	return ok
+}
+
+func (err ErrSourceBranchMoved) Error() string {
+	return "branch " + err.Branch + " moved from " + err.Expected + " to " + err.Actual + ", please retry"
+}
@@ -11,6 +11,7 @@
 // You should have received a copy of the GNU Affero General Public License
 // along with this program. If not, see <http://www.gnu.org/licenses/>.
 
+//go:build !codeanalysis
 // +build !codeanalysis
 
 package gitmodule
@@ -45,15 +46,16 @@ type Repository struct {
 	Bundle        *bundle.Bundle
 	branchRules   []*apistructs.BranchRule
 
-	Size       int64   `json:"-"`
-	RootPath   string  `json:"-"`
-	RefName    string  `json:"-"` //需要调用ParseRefAndTreePath才能得到
-	TreePath   string  `json:"-"` //需要调用ParseRefAndTreePath才能得到
-	RefType    string  `json:"-"`
-	Commit     *Commit `json:"-"`
-	tree       *Tree   `json:"-"` //只有RefType是tree才有值
-	IsLocked   bool    `json:"-"`
-	IsExternal bool    // 是否是外置仓库
+	Size               int64   `json:"-"`
+	RootPath           string  `json:"-"`
+	RefName            string  `json:"-"` //需要调用ParseRefAndTreePath才能得到
+	TreePath           string  `json:"-"` //需要调用ParseRefAndTreePath才能得到
+	RefType            string  `json:"-"`
+	Commit             *Commit `json:"-"`
+	tree               *Tree   `json:"-"` //只有RefType是tree才有值
+	IsLocked           bool    `json:"-"`
+	IsExternal         bool    // 是否是外置仓库
+	DefaultMergeMethod string  `json:"-"` // 仓库默认 mr 合并方式
 }
 
 const (
@@ -64,16 +66,20 @@ const (
 )
 
 func (repo *Repository) IsProtectBranch(branch string) bool {
+	return repo.GetBranchRule(branch).IsProtect
+}
+
+// GetBranchRule 分支匹配的规则，获取规则失败时按未配置处理
+func (repo *Repository) GetBranchRule(branch string) *apistructs.ValidBranch {
 	// repo是http请求级别的实例，一个请求中不重复更新规则
 	if repo.branchRules == nil {
 		rules, err := repo.Bundle.GetAppBranchRules(uint64(repo.ApplicationId))
 		if err != nil {
-			return false
+			return diceworkspace.GetValidBranchByGitReference(branch, nil)
 		}
 		repo.branchRules = rules
 	}
-	gitReference := diceworkspace.GetValidBranchByGitReference(branch, repo.branchRules)
-	return gitReference.IsProtect
+	return diceworkspace.GetValidBranchByGitReference(branch, repo.branchRules)
 }
 
 func (repo *Repository) IsProtectBranchWithRules(branch string, rules []*apistructs.BranchRule) bool {
@@ -81,6 +87,15 @@ func (repo *Repository) IsProtectBranchWithRules(branch string, rules []*apistru
 	return gitReference.IsProtect
 }
 
+// IsMergeMethodAllowed 保护分支按分支规则限制 mr 合并方式
+func (repo *Repository) IsMergeMethodAllowed(branch string, method apistructs.MergeMethod) bool {
+	rule := repo.GetBranchRule(branch)
+	if !rule.IsProtect {
+		return true
+	}
+	return apistructs.IsMergeMethodAllowed(method, rule.AllowedMergeMethods)
+}
+
 func (repo *Repository) ParseRefAndTreePath(path string) error {
 	hasRefMatched := false
 	var refName string
@@ -178,6 +178,61 @@ func (repo *Repository) GetDiffFile(newCommit *Commit, oldCommit *Commit, oldPat
 
 }
 
+// GetChangedPaths 与合并基础比较修改的文件路径，不读取文件内容，重命名时新旧路径都返回
+func (repo *Repository) GetChangedPaths(newCommit *Commit, oldCommit *Commit) ([]string, error) {
+	baseCommit, err := repo.GetMergeBase(newCommit, oldCommit)
+	if err != nil {
+		return nil, err
+	}
+	rawRepo, err := repo.GetRawRepo()
+	if err != nil {
+		return nil, err
+	}
+	oidOld, _ := git.NewOid(baseCommit.TreeSha)
+	treeOld, err := rawRepo.LookupTree(oidOld)
+	if err != nil {
+		return nil, err
+	}
+	oidNew, _ := git.NewOid(newCommit.TreeSha)
+	treeNew, err := rawRepo.LookupTree(oidNew)
+	if err != nil {
+		return nil, err
+	}
+
+	options, _ := git.DefaultDiffOptions()
+	diff, err := rawRepo.DiffTreeToTree(treeOld, treeNew, &options)
+	if err != nil {
+		return nil, err
+	}
+	defer diff.Free()
+	findOptions, err := git.DefaultDiffFindOptions()
+	if err != nil {
+		return nil, err
+	}
+	if err := diff.FindSimilar(&findOptions); err != nil {
+		return nil, err
+	}
+	num, err := diff.NumDeltas()
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	seen := map[string]bool{}
+	for i := 0; i < num; i++ {
+		delta, err := diff.Delta(i)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range []string{delta.OldFile.Path, delta.NewFile.Path} {
+			if path != "" && !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths, nil
+}
+
 func (repo *Repository) GetDiff(newCommit *Commit, oldCommit *Commit) (*Diff, error) {
 	return repo.GetDiffWithOptions(newCommit, oldCommit, NewDefaultDiffOptions())
 }
@@ -16,9 +16,14 @@
 package gitmodule
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"strings"
 
 	git "github.com/libgit2/git2go/v30"
+
+	"github.com/erda-project/erda/apistructs"
 )
 
 type MergeStatusInfo struct {
@@ -26,6 +31,9 @@ type MergeStatusInfo struct {
 	IsMerged    bool   `json:"isMerged"`
 	HasError    bool   `json:"hasError"`
 	ErrorMsg    string `json:"errorMsg"`
+
+	MergeMethod apistructs.MergeMethod `json:"mergeMethod"`
+	ConflictMsg string                 `json:"conflictMsg,omitempty"`
 }
 
 type MergeInfo struct {
@@ -123,18 +131,36 @@ func (repo *Repository) getMergeInfo(ourBranch string, theirBranch string) (*Mer
 	}, nil
 
 }
+
+// ResolveMergeMethod 依次使用指定的合并方式、仓库默认合并方式、系统默认合并方式
+func (repo *Repository) ResolveMergeMethod(methods ...string) apistructs.MergeMethod {
+	for _, method := range append(methods, repo.DefaultMergeMethod) {
+		if apistructs.MergeMethod(method).Valid() {
+			return apistructs.MergeMethod(method)
+		}
+	}
+	return apistructs.DefaultMergeMethod
+}
+
 func (repo *Repository) GetMergeStatus(ourBranch string, theirBranch string) (*MergeStatusInfo, error) {
+	return repo.GetMergeStatusWithMethod(ourBranch, theirBranch, apistructs.MergeMethodMerge)
+}
+
+// GetMergeStatusWithMethod 按合并方式检测冲突
+func (repo *Repository) GetMergeStatusWithMethod(ourBranch string, theirBranch string, method apistructs.MergeMethod) (*MergeStatusInfo, error) {
 
 	info, err := repo.getMergeInfo(ourBranch, theirBranch)
 	if err != nil {
 		return &MergeStatusInfo{
-			HasError: true,
-			ErrorMsg: err.Error(),
+			HasError:    true,
+			ErrorMsg:    err.Error(),
+			MergeMethod: method,
 		}, nil
 	}
 
 	result := &MergeStatusInfo{
 		HasConflict: false,
+		MergeMethod: method,
 	}
 	//没有commits差异 认已经合并
 	commitsCount, err := repo.CommitsCountBetween(info.OurCommit, info.BaseCommit)
@@ -151,28 +177,227 @@ func (repo *Repository) GetMergeStatus(ourBranch string, theirBranch string) (*M
 		return nil, err
 	}
 
-	options, _ := git.DefaultMergeOptions()
-	index, err := rawRepo.MergeTrees(info.BaseTree, info.OurTree, info.TheirTree, &options)
-	if err != nil {
-		return nil, err
+	switch method {
+	case apistructs.MergeMethodFastForward:
+		if !info.canFastForward() {
+			result.HasConflict = true
+			result.ConflictMsg = errCanNotFastForward.Reason
+		}
+	case apistructs.MergeMethodRebase:
+		// 只重放不创建提交
+		_, err := repo.replayCommits(rawRepo, info, nil)
+		if IsErrMergeConflict(err) {
+			result.HasConflict = true
+			result.ConflictMsg = err.(ErrMergeConflict).Reason
+		} else if err != nil {
+			return nil, err
+		}
+	default:
+		options, _ := git.DefaultMergeOptions()
+		index, err := rawRepo.MergeTrees(info.BaseTree, info.OurTree, info.TheirTree, &options)
+		if err != nil {
+			return nil, err
+		}
+		result.HasConflict = index.HasConflicts()
 	}
-	result.HasConflict = index.HasConflicts()
 
 	return result, nil
 }
 
 func (repo *Repository) Merge(ourBranch string, theirBranch string, signature *Signature, message string) (*Commit, error) {
+	return repo.MergeWithMethod(ourBranch, "", theirBranch, apistructs.MergeMethodMerge, signature, message)
+}
+
+// MergeWithMethod 按合并方式将 ourBranch 合并到 theirBranch
+// ourSha 不为空时, ourBranch 最新提交必须与之一致, 否则放弃合并
+func (repo *Repository) MergeWithMethod(ourBranch string, ourSha string, theirBranch string, method apistructs.MergeMethod,
+	signature *Signature, message string) (*Commit, error) {
 
 	info, err := repo.getMergeInfo(ourBranch, theirBranch)
 	if err != nil {
 		return nil, err
 	}
+	if ourSha != "" && info.OurCommit.ID != ourSha {
+		return nil, ErrSourceBranchMoved{Branch: ourBranch, Expected: ourSha, Actual: info.OurCommit.ID}
+	}
 
 	rawRepo, err := repo.GetRawRepo()
 	if err != nil {
 		return nil, err
 	}
 
+	sig := &git.Signature{
+		Name:  signature.Name,
+		Email: signature.Email,
+		When:  signature.When,
+	}
+
+	switch method {
+	case apistructs.MergeMethodMerge:
+		return repo.mergeCommit(rawRepo, info, sig, message)
+	case apistructs.MergeMethodSquash:
+		return repo.squashCommit(rawRepo, info, sig, message)
+	case apistructs.MergeMethodRebase:
+		// 目标分支没有新提交时直接快进
+		if info.canFastForward() {
+			return repo.fastForward(rawRepo, info, info.OurCommit.Git2Oid(), "merge: fast-forward")
+		}
+		newOid, err := repo.replayCommits(rawRepo, info, sig)
+		if err != nil {
+			return nil, err
+		}
+		return repo.fastForward(rawRepo, info, newOid, "merge: rebase "+ourBranch)
+	case apistructs.MergeMethodFastForward:
+		if !info.canFastForward() {
+			return nil, errCanNotFastForward
+		}
+		return repo.fastForward(rawRepo, info, info.OurCommit.Git2Oid(), "merge: fast-forward")
+	default:
+		return nil, fmt.Errorf("invalid merge method: %s", method)
+	}
+}
+
+var errCanNotFastForward = ErrMergeConflict{Reason: "target branch has diverged, can not fast-forward"}
+
+// canFastForward 目标分支即为合并基准时可以快进
+func (info *MergeInfo) canFastForward() bool {
+	return info.TheirCommit.ID == info.BaseCommit.ID
+}
+
+// commitsToReplay 返回 base..our 之间的非合并提交，按提交顺序排列
+func (repo *Repository) commitsToReplay(info *MergeInfo) ([]*Commit, error) {
+	stdout, err := NewCommand("rev-list", "--reverse", "--topo-order", "--no-merges",
+		info.BaseCommit.ID+".."+info.OurCommit.ID).RunInDirBytes(repo.DiskPath())
+	if err != nil {
+		return nil, err
+	}
+	return repo.parsePrettyFormatLogToList(bytes.TrimSpace(stdout))
+}
+
+// replayCommits 将源分支提交依次重放到目标分支上，返回最后一个提交
+// committer 为空时只检测冲突，不创建提交
+func (repo *Repository) replayCommits(rawRepo *git.Repository, info *MergeInfo, committer *git.Signature) (*git.Oid, error) {
+	commits, err := repo.commitsToReplay(info)
+	if err != nil {
+		return nil, err
+	}
+	onto, err := rawRepo.LookupCommit(info.TheirCommit.Git2Oid())
+	if err != nil {
+		return nil, err
+	}
+	ontoTree := info.TheirTree
+	options, err := git.DefaultMergeOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, commit := range commits {
+		pick, err := rawRepo.LookupCommit(commit.Git2Oid())
+		if err != nil {
+			return nil, err
+		}
+		pickTree, err := pick.Tree()
+		if err != nil {
+			return nil, err
+		}
+		parent := pick.Parent(0)
+		if parent == nil {
+			return nil, fmt.Errorf("commit %s has no parent", commit.ID)
+		}
+		parentTree, err := parent.Tree()
+		if err != nil {
+			return nil, err
+		}
+		index, err := rawRepo.MergeTrees(parentTree, ontoTree, pickTree, &options)
+		if err != nil {
+			return nil, err
+		}
+		if index.HasConflicts() {
+			return nil, ErrMergeConflict{Reason: fmt.Sprintf("could not apply %s %s", commit.ID[:7], commit.Summary())}
+		}
+		newTreeOid, err := index.WriteTreeTo(rawRepo)
+		if err != nil {
+			return nil, err
+		}
+		// 变更已存在于目标分支，跳过空提交
+		if newTreeOid.Equal(ontoTree.Id()) {
+			continue
+		}
+		ontoTree, err = rawRepo.LookupTree(newTreeOid)
+		if err != nil {
+			return nil, err
+		}
+		if committer == nil {
+			continue
+		}
+		newOid, err := rawRepo.CreateCommit("", pick.Author(), committer, pick.Message(), ontoTree, onto)
+		if err != nil {
+			return nil, err
+		}
+		onto, err = rawRepo.LookupCommit(newOid)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return onto.Id(), nil
+}
+
+// fastForward 将目标分支指向 newOid，目标分支已被更新时返回错误
+func (repo *Repository) fastForward(rawRepo *git.Repository, info *MergeInfo, newOid *git.Oid, reflog string) (*Commit, error) {
+	ref, err := rawRepo.References.Lookup(BRANCH_PREFIX + info.TheirBranch)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Target() == nil || ref.Target().String() != info.TheirCommit.ID {
+		return nil, errors.New("target branch has been updated, please retry")
+	}
+	if _, err := ref.SetTarget(newOid, reflog); err != nil {
+		return nil, err
+	}
+	return repo.GetCommit(newOid.String())
+}
+
+// squashCommit 将合并结果作为目标分支上的单个提交
+func (repo *Repository) squashCommit(rawRepo *git.Repository, info *MergeInfo, sig *git.Signature, message string) (*Commit, error) {
+	newTree, err := repo.mergeTree(rawRepo, info)
+	if err != nil {
+		return nil, err
+	}
+	commits, err := repo.commitsToReplay(info)
+	if err != nil {
+		return nil, err
+	}
+	parentCommit, err := rawRepo.LookupCommit(info.TheirCommit.Git2Oid())
+	if err != nil {
+		return nil, err
+	}
+	newOid, err := rawRepo.CreateCommit(BRANCH_PREFIX+info.TheirBranch, sig, sig, composeSquashMessage(message, commits), newTree, parentCommit)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetCommit(newOid.String())
+}
+
+// composeSquashMessage 在提交信息后附加被压缩的提交列表
+func composeSquashMessage(message string, commits []*Commit) string {
+	message = strings.TrimSpace(message)
+	if message == "" && len(commits) > 0 {
+		message = commits[0].Summary()
+	}
+	if len(commits) == 0 {
+		return message
+	}
+	var buf strings.Builder
+	buf.WriteString(message)
+	buf.WriteString("\n")
+	for _, commit := range commits {
+		buf.WriteString("\n* ")
+		buf.WriteString(commit.Summary())
+	}
+	return buf.String()
+}
+
+func (repo *Repository) mergeTree(rawRepo *git.Repository, info *MergeInfo) (*git.Tree, error) {
 	options, err := git.DefaultMergeOptions()
 	if err != nil {
 		return nil, err
@@ -184,24 +409,23 @@ func (repo *Repository) Merge(ourBranch string, theirBranch string, signature *S
 	}
 
 	if index.HasConflicts() {
-		return nil, errors.New("has conflict")
+		return nil, ErrMergeConflict{}
 	}
 	newTreeOid, err := index.WriteTreeTo(rawRepo)
 	if err != nil {
 		return nil, err
 	}
 
-	newTree, err := rawRepo.LookupTree(newTreeOid)
+	return rawRepo.LookupTree(newTreeOid)
+}
+
+// mergeCommit 创建包含两个父提交的合并提交
+func (repo *Repository) mergeCommit(rawRepo *git.Repository, info *MergeInfo, sig *git.Signature, message string) (*Commit, error) {
+	newTree, err := repo.mergeTree(rawRepo, info)
 	if err != nil {
 		return nil, err
 	}
 
-	sig := &git.Signature{
-		Name:  signature.Name,
-		Email: signature.Email,
-		When:  signature.When,
-	}
-
 	parentOid, err := git.NewOid(info.TheirCommit.ID)
 	if err != nil {
 		return nil, err
@@ -219,7 +443,7 @@ func (repo *Repository) Merge(ourBranch string, theirBranch string, signature *S
 	if err != nil {
 		return nil, err
 	}
-	newOid, err := rawRepo.CreateCommit(BRANCH_PREFIX+theirBranch, sig, sig, message, newTree, parentCommit, parentCommit2)
+	newOid, err := rawRepo.CreateCommit(BRANCH_PREFIX+info.TheirBranch, sig, sig, message, newTree, parentCommit, parentCommit2)
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,42 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// +build !codeanalysis
+
+package gitmodule
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComposeSquashMessage(t *testing.T) {
+	commits := []*Commit{
+		{CommitMessage: "feat: add foo\n\nfoo detail"},
+		{CommitMessage: "fix: foo typo"},
+	}
+	assert.Equal(t, "Squash feature/foo\n\n* feat: add foo\n* fix: foo typo", composeSquashMessage("Squash feature/foo\n", commits))
+	assert.Equal(t, "feat: add foo\n\n* feat: add foo\n* fix: foo typo", composeSquashMessage("", commits))
+	assert.Equal(t, "Squash feature/foo", composeSquashMessage("Squash feature/foo", nil))
+}
+
+func TestMergeInfo_CanFastForward(t *testing.T) {
+	info := &MergeInfo{
+		TheirCommit: &Commit{ID: "a"},
+		BaseCommit:  &Commit{ID: "a"},
+	}
+	assert.True(t, info.canFastForward())
+	info.BaseCommit = &Commit{ID: "b"}
+	assert.False(t, info.canFastForward())
+}
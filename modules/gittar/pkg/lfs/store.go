@@ -0,0 +1,99 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package lfs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+
+	"github.com/erda-project/erda/pkg/storage"
+)
+
+var (
+	ErrHashMismatch = errors.New("lfs object hash mismatch")
+	ErrSizeMismatch = errors.New("lfs object size mismatch")
+)
+
+// ContentStore 将 LFS 对象保存在 storage 中，按仓库隔离
+type ContentStore struct {
+	storage    storage.Storager
+	pathPrefix string
+}
+
+func NewContentStore(s storage.Storager, pathPrefix string) *ContentStore {
+	return &ContentStore{storage: s, pathPrefix: pathPrefix}
+}
+
+// Path <prefix>/<repoID>/<oid[0:2]>/<oid[2:4]>/<oid>
+func (s *ContentStore) Path(repoID int64, oid string) string {
+	return filepath.Join(s.pathPrefix, strconv.FormatInt(repoID, 10), oid[0:2], oid[2:4], oid)
+}
+
+// Get 读取对象，调用方负责关闭
+func (s *ContentStore) Get(repoID int64, oid string) (io.ReadCloser, error) {
+	r, err := s.storage.Read(s.Path(repoID, oid))
+	if err != nil {
+		return nil, err
+	}
+	if rc, ok := r.(io.ReadCloser); ok {
+		return rc, nil
+	}
+	return io.NopCloser(r), nil
+}
+
+// Put 直接写入对象路径，内容与 pointer 不一致或写入失败时删除已写入的对象并返回错误。
+// 对象只有在校验通过并记录到 dice_repo_lfs_objects 后才会被下载，无需先写临时路径
+func (s *ContentStore) Put(repoID int64, p Pointer, r io.Reader) error {
+	if !p.Valid() {
+		return fmt.Errorf("invalid lfs object: %s", p.Oid)
+	}
+	hash := sha256.New()
+	counter := &countingReader{r: io.TeeReader(r, hash)}
+	path := s.Path(repoID, p.Oid)
+	if err := s.storage.Write(path, counter); err != nil {
+		_ = s.storage.Delete(path)
+		return err
+	}
+	var verifyErr error
+	if counter.n != p.Size {
+		verifyErr = ErrSizeMismatch
+	} else if hex.EncodeToString(hash.Sum(nil)) != p.Oid {
+		verifyErr = ErrHashMismatch
+	}
+	if verifyErr != nil {
+		_ = s.storage.Delete(path)
+		return verifyErr
+	}
+	return nil
+}
+
+func (s *ContentStore) Delete(repoID int64, oid string) error {
+	return s.storage.Delete(s.Path(repoID, oid))
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package lfs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/pkg/storage"
+)
+
+func TestValidOid(t *testing.T) {
+	assert.True(t, ValidOid(strings.Repeat("a1", 32)))
+	assert.False(t, ValidOid(strings.Repeat("A1", 32)))
+	assert.False(t, ValidOid("../../etc/passwd"))
+	assert.False(t, ValidOid(strings.Repeat("a", 63)))
+}
+
+func TestBatchRequest_SupportBasicTransfer(t *testing.T) {
+	assert.True(t, (&BatchRequest{}).SupportBasicTransfer())
+	assert.True(t, (&BatchRequest{Transfers: []string{"lfs-standalone-file", "basic"}}).SupportBasicTransfer())
+	assert.False(t, (&BatchRequest{Transfers: []string{"tus"}}).SupportBasicTransfer())
+}
+
+func TestContentStore(t *testing.T) {
+	store := NewContentStore(storage.NewFS(), t.TempDir())
+	content := "hello lfs"
+	sum := sha256.Sum256([]byte(content))
+	p := Pointer{Oid: hex.EncodeToString(sum[:]), Size: int64(len(content))}
+
+	// 内容不一致时不保留对象
+	err := store.Put(1, Pointer{Oid: p.Oid, Size: p.Size}, strings.NewReader("hello LFS"))
+	assert.Equal(t, ErrHashMismatch, err)
+	_, err = os.Stat(store.Path(1, p.Oid))
+	assert.True(t, os.IsNotExist(err))
+
+	err = store.Put(1, Pointer{Oid: p.Oid, Size: p.Size + 1}, strings.NewReader(content))
+	assert.Equal(t, ErrSizeMismatch, err)
+
+	assert.NoError(t, store.Put(1, p, strings.NewReader(content)))
+	r, err := store.Get(1, p.Oid)
+	assert.NoError(t, err)
+	b, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+	assert.Equal(t, content, string(b))
+
+	// 按仓库隔离
+	_, err = store.Get(2, p.Oid)
+	assert.Error(t, err)
+
+	assert.NoError(t, store.Delete(1, p.Oid))
+	_, err = store.Get(1, p.Oid)
+	assert.Error(t, err)
+}
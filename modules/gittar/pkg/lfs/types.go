@@ -0,0 +1,157 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package lfs
+
+import (
+	"regexp"
+	"time"
+)
+
+// Git LFS API 协议，参考 https://github.com/git-lfs/git-lfs/tree/main/docs/api
+
+const (
+	// MediaType LFS API 请求与响应的 Content-Type
+	MediaType = "application/vnd.git-lfs+json"
+
+	OperationUpload   = "upload"
+	OperationDownload = "download"
+
+	ActionUpload   = "upload"
+	ActionDownload = "download"
+	ActionVerify   = "verify"
+
+	TransferBasic = "basic"
+	HashAlgo      = "sha256"
+)
+
+var oidRegexp = regexp.MustCompile(`^[a-f0-9]{64}$`)
+
+// ValidOid 校验 sha256 oid
+func ValidOid(oid string) bool {
+	return oidRegexp.MatchString(oid)
+}
+
+type Ref struct {
+	Name string `json:"name"`
+}
+
+// Pointer 对象指针
+type Pointer struct {
+	Oid  string `json:"oid"`
+	Size int64  `json:"size"`
+}
+
+func (p Pointer) Valid() bool {
+	return ValidOid(p.Oid) && p.Size >= 0
+}
+
+// BatchRequest POST /info/lfs/objects/batch
+type BatchRequest struct {
+	Operation string    `json:"operation"`
+	Transfers []string  `json:"transfers,omitempty"`
+	Ref       *Ref      `json:"ref,omitempty"`
+	Objects   []Pointer `json:"objects"`
+	HashAlgo  string    `json:"hash_algo,omitempty"`
+}
+
+// SupportBasicTransfer 未指定 transfers 时默认为 basic
+func (r *BatchRequest) SupportBasicTransfer() bool {
+	if len(r.Transfers) == 0 {
+		return true
+	}
+	for _, t := range r.Transfers {
+		if t == TransferBasic {
+			return true
+		}
+	}
+	return false
+}
+
+type BatchResponse struct {
+	Transfer string            `json:"transfer"`
+	Objects  []*ObjectResponse `json:"objects"`
+	HashAlgo string            `json:"hash_algo"`
+}
+
+type ObjectResponse struct {
+	Pointer
+	Authenticated bool               `json:"authenticated,omitempty"`
+	Actions       map[string]*Action `json:"actions,omitempty"`
+	Error         *ObjectError       `json:"error,omitempty"`
+}
+
+type Action struct {
+	Href      string            `json:"href"`
+	Header    map[string]string `json:"header,omitempty"`
+	ExpiresIn int               `json:"expires_in,omitempty"`
+}
+
+type ObjectError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrorResponse LFS API 错误响应
+type ErrorResponse struct {
+	Message          string `json:"message"`
+	DocumentationURL string `json:"documentation_url,omitempty"`
+	RequestID        string `json:"request_id,omitempty"`
+}
+
+type LockOwner struct {
+	Name string `json:"name"`
+}
+
+type Lock struct {
+	ID       string     `json:"id"`
+	Path     string     `json:"path"`
+	LockedAt time.Time  `json:"locked_at"`
+	Owner    *LockOwner `json:"owner,omitempty"`
+}
+
+// LockCreateRequest POST /info/lfs/locks
+type LockCreateRequest struct {
+	Path string `json:"path"`
+	Ref  *Ref   `json:"ref,omitempty"`
+}
+
+type LockResponse struct {
+	Lock    *Lock  `json:"lock,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// LockListResponse GET /info/lfs/locks
+type LockListResponse struct {
+	Locks      []*Lock `json:"locks"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// LockVerifyRequest POST /info/lfs/locks/verify
+type LockVerifyRequest struct {
+	Ref    *Ref   `json:"ref,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+}
+
+type LockVerifyResponse struct {
+	Ours       []*Lock `json:"ours"`
+	Theirs     []*Lock `json:"theirs"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// LockDeleteRequest POST /info/lfs/locks/:id/unlock
+type LockDeleteRequest struct {
+	Force bool `json:"force,omitempty"`
+	Ref   *Ref `json:"ref,omitempty"`
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package mergecheck 根据目标分支规则计算 mr 的合并条件
+package mergecheck
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+// Evaluate 计算合并条件是否满足
+// checkRuns 为 mr 最新提交上的 check run，按流水线文件路径匹配规则，同名的以最后一次为准；作者本人的审批不计入
+func Evaluate(rule *apistructs.ValidBranch, authorID, sourceBranch, headSha string,
+	checkRuns []*apistructs.CheckRun, approvals []*apistructs.MergeRequestApproval) *apistructs.MergeRequirements {
+	result := &apistructs.MergeRequirements{
+		RequiredCheckRuns:     []*apistructs.RequiredCheckRunStatus{},
+		RequiredApprovals:     rule.RequiredApprovals,
+		DismissStaleApprovals: rule.DismissStaleApprovals,
+		Approvals:             []*apistructs.MergeRequestApproval{},
+		CodeOwnerApprovals:    []*apistructs.CodeOwnerApproval{},
+		Unmet:                 []string{},
+		Warnings:              []string{},
+	}
+
+	latest := map[string]*apistructs.CheckRun{}
+	for _, checkRun := range checkRuns {
+		if checkRun.Commit != headSha {
+			continue
+		}
+		// mr 流水线的 check run 同时可按原名称和流水线文件路径匹配
+		for _, name := range []string{checkRun.Name, apistructs.CheckRunYmlPath(checkRun.Name, sourceBranch)} {
+			if last, ok := latest[name]; !ok || checkRun.ID > last.ID {
+				latest[name] = checkRun
+			}
+		}
+	}
+	for _, name := range apistructs.ParseRequiredCheckRuns(rule.RequiredCheckRuns) {
+		status := &apistructs.RequiredCheckRunStatus{Name: name}
+		if checkRun, ok := latest[name]; ok {
+			status.Status = checkRun.Status
+			status.Result = checkRun.Result
+			status.Satisfied = checkRun.Status == apistructs.CheckRunStatusCompleted &&
+				checkRun.Result == apistructs.CheckRunResultSuccess
+		}
+		result.RequiredCheckRuns = append(result.RequiredCheckRuns, status)
+		if status.Satisfied {
+			continue
+		}
+		switch {
+		case status.Status == "":
+			result.Unmet = append(result.Unmet, fmt.Sprintf("check run %s has not run on %s", name, shortSha(headSha)))
+		case status.Status != apistructs.CheckRunStatusCompleted:
+			result.Unmet = append(result.Unmet, fmt.Sprintf("check run %s is in progress", name))
+		default:
+			result.Unmet = append(result.Unmet, fmt.Sprintf("check run %s is %s", name, status.Result))
+		}
+	}
+
+	for _, approval := range approvals {
+		if approval.UserID == authorID {
+			continue
+		}
+		approval.Stale = approval.CommitSha != headSha
+		result.Approvals = append(result.Approvals, approval)
+		if !approval.Stale || !rule.DismissStaleApprovals {
+			result.ValidApprovals++
+		}
+	}
+	if result.ValidApprovals < rule.RequiredApprovals {
+		result.Unmet = append(result.Unmet, fmt.Sprintf("%d of %d required approvals", result.ValidApprovals, rule.RequiredApprovals))
+	}
+
+	result.Satisfied = len(result.Unmet) == 0
+	return result
+}
+
+// CheckCodeOwners 每组文件都需要至少一个负责人的有效审批，需在 Evaluate 之后调用
+// 负责人均无法解析为项目成员的组无法审批，只提示不作为合并条件
+func CheckCodeOwners(result *apistructs.MergeRequirements, groups []*apistructs.CodeOwnerApproval) {
+	approved := map[string]bool{}
+	for _, approval := range result.Approvals {
+		if !approval.Stale || !result.DismissStaleApprovals {
+			approved[approval.UserID] = true
+		}
+	}
+	for _, group := range groups {
+		group.Satisfied = false
+		group.NoResolvableOwner = len(group.OwnerIDs) == 0
+		if group.NoResolvableOwner {
+			result.CodeOwnerApprovals = append(result.CodeOwnerApprovals, group)
+			result.Warnings = append(result.Warnings, fmt.Sprintf("no resolvable owner in code owners %s of %s",
+				strings.Join(group.Owners, " "), group.Pattern))
+			continue
+		}
+		for _, ownerID := range group.OwnerIDs {
+			if approved[ownerID] {
+				group.Satisfied = true
+				break
+			}
+		}
+		result.CodeOwnerApprovals = append(result.CodeOwnerApprovals, group)
+		if !group.Satisfied {
+			result.Unmet = append(result.Unmet, fmt.Sprintf("approval required from code owners %s of %s",
+				strings.Join(group.Owners, " "), group.Pattern))
+		}
+	}
+	result.Satisfied = len(result.Unmet) == 0
+}
+
+func shortSha(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package mergecheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+const (
+	head  = "2222222222222222222222222222222222222222"
+	stale = "1111111111111111111111111111111111111111"
+)
+
+func TestEvaluate_NoRequirements(t *testing.T) {
+	result := Evaluate(&apistructs.ValidBranch{}, "author", "feature/a", head, nil, nil)
+	assert.True(t, result.Satisfied)
+	assert.Empty(t, result.Unmet)
+}
+
+func TestEvaluate_CheckRuns(t *testing.T) {
+	rule := &apistructs.ValidBranch{RequiredCheckRuns: "unit-test, lint,sonar,e2e"}
+	checkRuns := []*apistructs.CheckRun{
+		{ID: 1, Name: "unit-test", Commit: head, Status: apistructs.CheckRunStatusCompleted, Result: apistructs.CheckRunResultFailure},
+		// 重新运行后成功
+		{ID: 2, Name: "unit-test", Commit: head, Status: apistructs.CheckRunStatusCompleted, Result: apistructs.CheckRunResultSuccess},
+		{ID: 3, Name: "lint", Commit: head, Status: apistructs.CheckRunStatusInProgress},
+		{ID: 4, Name: "sonar", Commit: head, Status: apistructs.CheckRunStatusCompleted, Result: apistructs.CheckRunResultTimeout},
+		// 旧提交上的结果不计入
+		{ID: 5, Name: "e2e", Commit: stale, Status: apistructs.CheckRunStatusCompleted, Result: apistructs.CheckRunResultSuccess},
+	}
+	result := Evaluate(rule, "author", "feature/a", head, checkRuns, nil)
+	assert.False(t, result.Satisfied)
+	assert.Len(t, result.RequiredCheckRuns, 4)
+	assert.True(t, result.RequiredCheckRuns[0].Satisfied)
+	assert.Equal(t, []string{
+		"check run lint is in progress",
+		"check run sonar is timeout",
+		"check run e2e has not run on 22222222",
+	}, result.Unmet)
+}
+
+// check run 由 mr 流水线按 <源分支>/<流水线文件路径> 上报，规则中填写流水线文件路径
+func TestEvaluate_PipelineCheckRuns(t *testing.T) {
+	rule := &apistructs.ValidBranch{RequiredCheckRuns: "pipeline.yml,.dice/pipelines/ci.yml,feature/a/pipeline.yml"}
+	checkRuns := []*apistructs.CheckRun{
+		{ID: 1, MrID: 3, Name: apistructs.MakeCheckRunName("feature/a", "pipeline.yml"), Commit: head,
+			Status: apistructs.CheckRunStatusInProgress},
+		{ID: 2, MrID: 3, Name: apistructs.MakeCheckRunName("feature/a", "pipeline.yml"), Commit: head,
+			Status: apistructs.CheckRunStatusCompleted, Result: apistructs.CheckRunResultSuccess},
+		{ID: 3, MrID: 3, Name: apistructs.MakeCheckRunName("feature/a", ".dice/pipelines/ci.yml"), Commit: head,
+			Status: apistructs.CheckRunStatusCompleted, Result: apistructs.CheckRunResultFailure},
+	}
+	result := Evaluate(rule, "author", "feature/a", head, checkRuns, nil)
+	assert.False(t, result.Satisfied)
+	assert.True(t, result.RequiredCheckRuns[0].Satisfied)
+	assert.False(t, result.RequiredCheckRuns[1].Satisfied)
+	// 原名称同样可以匹配
+	assert.True(t, result.RequiredCheckRuns[2].Satisfied)
+	assert.Equal(t, []string{"check run .dice/pipelines/ci.yml is failure"}, result.Unmet)
+}
+
+func TestEvaluate_Approvals(t *testing.T) {
+	approvals := func() []*apistructs.MergeRequestApproval {
+		return []*apistructs.MergeRequestApproval{
+			{UserID: "author", CommitSha: head},
+			{UserID: "u1", CommitSha: head},
+			{UserID: "u2", CommitSha: stale},
+		}
+	}
+
+	rule := &apistructs.ValidBranch{RequiredApprovals: 2}
+	result := Evaluate(rule, "author", "feature/a", head, nil, approvals())
+	assert.True(t, result.Satisfied)
+	assert.Equal(t, 2, result.ValidApprovals)
+	assert.Len(t, result.Approvals, 2)
+	assert.True(t, result.Approvals[1].Stale)
+
+	rule.DismissStaleApprovals = true
+	result = Evaluate(rule, "author", "feature/a", head, nil, approvals())
+	assert.False(t, result.Satisfied)
+	assert.Equal(t, 1, result.ValidApprovals)
+	assert.Equal(t, []string{"1 of 2 required approvals"}, result.Unmet)
+}
+
+func TestCheckCodeOwners(t *testing.T) {
+	rule := &apistructs.ValidBranch{DismissStaleApprovals: true}
+	approvals := []*apistructs.MergeRequestApproval{
+		{UserID: "u1", CommitSha: head},
+		{UserID: "u2", CommitSha: stale},
+	}
+	result := Evaluate(rule, "author", "feature/a", head, nil, approvals)
+	CheckCodeOwners(result, []*apistructs.CodeOwnerApproval{
+		{Pattern: "*.go", Owners: []string{"@u1", "@role:Lead"}, OwnerIDs: []string{"u1", "u3"}, Paths: []string{"main.go"}},
+		{Pattern: "/docs/", Owners: []string{"@u2"}, OwnerIDs: []string{"u2"}, Paths: []string{"docs/a.md"}},
+		{Pattern: "/deploy/", Owners: []string{"@author"}, OwnerIDs: []string{"author"}, Paths: []string{"deploy/a.yml"}},
+	})
+	assert.False(t, result.Satisfied)
+	require.Len(t, result.CodeOwnerApprovals, 3)
+	assert.True(t, result.CodeOwnerApprovals[0].Satisfied)
+	// 过期的审批和作者本人不计入
+	assert.False(t, result.CodeOwnerApprovals[1].Satisfied)
+	assert.False(t, result.CodeOwnerApprovals[2].Satisfied)
+	assert.Equal(t, []string{
+		"approval required from code owners @u2 of /docs/",
+		"approval required from code owners @author of /deploy/",
+	}, result.Unmet)
+
+	result = Evaluate(rule, "author", "feature/a", head, nil, approvals[:1])
+	CheckCodeOwners(result, nil)
+	assert.True(t, result.Satisfied)
+
+	// 负责人无法解析时只提示，不阻塞合并
+	result = Evaluate(rule, "author", "feature/a", head, nil, approvals[:1])
+	CheckCodeOwners(result, []*apistructs.CodeOwnerApproval{
+		{Pattern: "/legacy/", Owners: []string{"@left-user"}, OwnerIDs: []string{}, Paths: []string{"legacy/a.go"}},
+	})
+	assert.True(t, result.Satisfied)
+	assert.True(t, result.CodeOwnerApprovals[0].NoResolvableOwner)
+	assert.Equal(t, []string{"no resolvable owner in code owners @left-user of /legacy/"}, result.Warnings)
+}
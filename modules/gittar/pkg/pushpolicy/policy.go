@@ -0,0 +1,207 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package pushpolicy 推送规则校验，在 pre-receive 阶段逐个提交检查提交信息、文件和新增内容
+package pushpolicy
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+const (
+	RuleCommitMessage = "commit_message"
+	RuleMaxFileSize   = "max_file_size"
+	RuleForbiddenPath = "forbidden_path"
+	RuleAuthorEmail   = "author_email"
+	RuleSecret        = "secret"
+)
+
+// Commit 待校验的提交
+type Commit struct {
+	ID          string
+	AuthorEmail string
+	Message     string
+	Files       []File
+	// Secrets 新增内容中疑似密钥的位置，读取 diff 时逐行扫描，不保留新增内容
+	Secrets []Secret
+}
+
+// File 提交中新增或修改的文件
+type File struct {
+	Path   string
+	BlobID string
+	Size   int64
+}
+
+// Secret 提交中新增的疑似密钥
+type Secret struct {
+	Path   string
+	Number int
+	Name   string
+}
+
+// Violation 违反的规则
+type Violation struct {
+	Commit  string
+	Rule    string
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("commit %s: %s", ShortID(v.Commit), v.Message)
+}
+
+// ShortID 提交 id 前 8 位
+func ShortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// Validate 校验规则配置是否合法
+func Validate(rule *apistructs.PushRule) error {
+	if rule.CommitMessageRegex != "" {
+		if _, err := regexp.Compile(rule.CommitMessageRegex); err != nil {
+			return fmt.Errorf("invalid commitMessageRegex: %v", err)
+		}
+	}
+	if rule.MaxFileSize < 0 {
+		return fmt.Errorf("invalid maxFileSize: %d", rule.MaxFileSize)
+	}
+	for _, pattern := range rule.ForbiddenPaths {
+		if strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("empty forbidden path")
+		}
+		if _, err := path.Match(strings.TrimSuffix(pattern, "/**"), ""); err != nil {
+			return fmt.Errorf("invalid forbidden path %q: %v", pattern, err)
+		}
+	}
+	for _, domain := range rule.AllowedEmailDomains {
+		if strings.TrimSpace(domain) == "" || strings.Contains(domain, "@") {
+			return fmt.Errorf("invalid email domain %q", domain)
+		}
+	}
+	return nil
+}
+
+// NeedSecretScan 是否需要扫描新增内容，未开启密钥扫描时可跳过 diff
+func NeedSecretScan(rules []*apistructs.PushRule) bool {
+	for _, rule := range rules {
+		if rule.SecretScan {
+			return true
+		}
+	}
+	return false
+}
+
+// Check 按规则逐个校验提交，所有规则都需满足
+func Check(rules []*apistructs.PushRule, commits []*Commit) ([]Violation, error) {
+	var violations []Violation
+	for _, rule := range rules {
+		var msgRe *regexp.Regexp
+		if rule.CommitMessageRegex != "" {
+			re, err := regexp.Compile(rule.CommitMessageRegex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid commitMessageRegex: %v", err)
+			}
+			msgRe = re
+		}
+		for _, commit := range commits {
+			violations = append(violations, checkCommit(rule, msgRe, commit)...)
+		}
+	}
+	return violations, nil
+}
+
+func checkCommit(rule *apistructs.PushRule, msgRe *regexp.Regexp, commit *Commit) []Violation {
+	var violations []Violation
+	add := func(ruleName, format string, args ...interface{}) {
+		violations = append(violations, Violation{
+			Commit:  commit.ID,
+			Rule:    ruleName,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if msgRe != nil && !msgRe.MatchString(commit.Message) {
+		add(RuleCommitMessage, "message does not match %q", rule.CommitMessageRegex)
+	}
+	if len(rule.AllowedEmailDomains) > 0 && !emailDomainAllowed(commit.AuthorEmail, rule.AllowedEmailDomains) {
+		add(RuleAuthorEmail, "author email %q is not in allowed domains %s",
+			commit.AuthorEmail, strings.Join(rule.AllowedEmailDomains, ","))
+	}
+	for _, file := range commit.Files {
+		if rule.MaxFileSize > 0 && file.Size > rule.MaxFileSize {
+			add(RuleMaxFileSize, "file %s is %d bytes, exceeds limit %d bytes", file.Path, file.Size, rule.MaxFileSize)
+		}
+		for _, pattern := range rule.ForbiddenPaths {
+			if MatchPath(pattern, file.Path) {
+				add(RuleForbiddenPath, "file %s matches forbidden path %q", file.Path, pattern)
+				break
+			}
+		}
+	}
+	if rule.SecretScan {
+		for _, secret := range commit.Secrets {
+			add(RuleSecret, "possible %s found in %s:%d", secret.Name, secret.Path, secret.Number)
+		}
+	}
+	return violations
+}
+
+func emailDomainAllowed(email string, domains []string) bool {
+	idx := strings.LastIndex(email, "@")
+	if idx < 0 {
+		return false
+	}
+	emailDomain := strings.ToLower(email[idx+1:])
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "."))
+		// 允许子域名
+		if emailDomain == domain || strings.HasSuffix(emailDomain, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPath 匹配文件路径
+// dir/** 匹配目录下所有文件；不含 / 的模式匹配任意目录下的文件名；其余按 path.Match 匹配完整路径
+func MatchPath(pattern, filePath string) bool {
+	pattern = strings.TrimPrefix(strings.TrimSpace(pattern), "/")
+	if strings.HasSuffix(pattern, "/**") {
+		dir := strings.TrimSuffix(pattern, "/**")
+		if ok, _ := path.Match(dir, filePath); ok {
+			return true
+		}
+		// 逐级匹配父目录
+		for p := path.Dir(filePath); p != "." && p != "/"; p = path.Dir(p) {
+			if ok, _ := path.Match(dir, p); ok {
+				return true
+			}
+		}
+		return false
+	}
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(filePath))
+		return ok
+	}
+	ok, _ := path.Match(pattern, filePath)
+	return ok
+}
@@ -0,0 +1,309 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pushpolicy
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	zeroID       = "0000000000000000000000000000000000000000"
+	submoduleMod = "160000"
+	spooledPack  = "incoming.pack"
+)
+
+var (
+	commitIDRe = regexp.MustCompile(`^[0-9a-f]{40}$`)
+	hunkRe     = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+)
+
+// Quarantine 推送对象隔离区
+// pre-receive 阶段新对象尚未写入仓库，先将 pack 解析到临时目录，以仓库对象目录作为 alternates 读取新提交
+type Quarantine struct {
+	repoPath string
+	dir      string
+	env      []string
+	hasPack  bool
+}
+
+// NewQuarantine 缓存推送的 pack 并建立索引，使用完需调用 Close 清理
+func NewQuarantine(repoPath string, pack io.Reader) (*Quarantine, error) {
+	repoPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "gittar-quarantine-")
+	if err != nil {
+		return nil, err
+	}
+	q := &Quarantine{
+		repoPath: repoPath,
+		dir:      dir,
+		env: []string{
+			"GIT_DIR=" + repoPath,
+			"GIT_OBJECT_DIRECTORY=" + filepath.Join(dir, "objects"),
+			"GIT_ALTERNATE_OBJECT_DIRECTORIES=" + filepath.Join(repoPath, "objects"),
+		},
+	}
+	if err := q.spool(pack); err != nil {
+		q.Close()
+		return nil, err
+	}
+	return q, nil
+}
+
+func (q *Quarantine) spool(pack io.Reader) error {
+	if err := os.MkdirAll(filepath.Join(q.dir, "objects", "pack"), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(q.dir, spooledPack))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	size, err := io.Copy(f, pack)
+	if err != nil {
+		return fmt.Errorf("failed to read pack: %v", err)
+	}
+	// 仅删除分支时没有 pack
+	if size == 0 {
+		return nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := q.git(f, "index-pack", "--stdin", "--fix-thin"); err != nil {
+		return err
+	}
+	q.hasPack = true
+	return nil
+}
+
+// Pack 返回缓存的原始 pack，供后续 receive-pack 使用
+func (q *Quarantine) Pack() (io.ReadCloser, error) {
+	return os.Open(filepath.Join(q.dir, spooledPack))
+}
+
+// Close 清理隔离区
+func (q *Quarantine) Close() error {
+	return os.RemoveAll(q.dir)
+}
+
+// NewCommits 返回 after 可达但仓库中尚不存在的提交，按从旧到新排列
+func (q *Quarantine) NewCommits(after string, withSecretScan bool) ([]*Commit, error) {
+	if !q.hasPack {
+		return nil, nil
+	}
+	out, err := q.git(nil, "log", "--reverse", "--format=%H%x00%ae%x00%B%x1e", after, "--not", "--all")
+	if err != nil {
+		return nil, err
+	}
+	var commits []*Commit
+	byID := map[string]*Commit{}
+	for _, record := range strings.Split(string(out), "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commit := &Commit{
+			ID:          fields[0],
+			AuthorEmail: fields[1],
+			Message:     strings.TrimRight(fields[2], "\n"),
+		}
+		commits = append(commits, commit)
+		byID[commit.ID] = commit
+	}
+	if len(commits) == 0 {
+		return nil, nil
+	}
+	if err := q.fillFiles(commits, byID); err != nil {
+		return nil, err
+	}
+	if withSecretScan {
+		if err := q.scanAddedLines(commits, byID); err != nil {
+			return nil, err
+		}
+	}
+	return commits, nil
+}
+
+func commitIDsInput(commits []*Commit) io.Reader {
+	var buf bytes.Buffer
+	for _, commit := range commits {
+		buf.WriteString(commit.ID + "\n")
+	}
+	return &buf
+}
+
+// fillFiles 读取每个提交新增或修改的文件及大小，合并提交不校验
+func (q *Quarantine) fillFiles(commits []*Commit, byID map[string]*Commit) error {
+	out, err := q.git(commitIDsInput(commits), "diff-tree", "--stdin", "-r", "--root", "--no-renames", "-z")
+	if err != nil {
+		return err
+	}
+	var (
+		current *Commit
+		blobIDs []string
+		tokens  = strings.Split(string(out), "\x00")
+	)
+	for i := 0; i < len(tokens); i++ {
+		token := strings.TrimPrefix(tokens[i], "\n")
+		if c, ok := byID[token]; ok {
+			current = c
+			continue
+		}
+		if !strings.HasPrefix(token, ":") || i+1 >= len(tokens) {
+			continue
+		}
+		i++
+		// :<old mode> <new mode> <old sha> <new sha> <status>
+		meta := strings.Fields(token)
+		if current == nil || len(meta) != 5 || meta[1] == submoduleMod || meta[3] == zeroID {
+			continue
+		}
+		current.Files = append(current.Files, File{Path: tokens[i], BlobID: meta[3]})
+		blobIDs = append(blobIDs, meta[3])
+	}
+	if len(blobIDs) == 0 {
+		return nil
+	}
+	sizes, err := q.blobSizes(blobIDs)
+	if err != nil {
+		return err
+	}
+	for _, commit := range commits {
+		for i := range commit.Files {
+			commit.Files[i].Size = sizes[commit.Files[i].BlobID]
+		}
+	}
+	return nil
+}
+
+func (q *Quarantine) blobSizes(blobIDs []string) (map[string]int64, error) {
+	out, err := q.git(strings.NewReader(strings.Join(blobIDs, "\n")+"\n"), "cat-file", "--batch-check")
+	if err != nil {
+		return nil, err
+	}
+	sizes := make(map[string]int64, len(blobIDs))
+	for _, line := range strings.Split(string(out), "\n") {
+		// <sha> blob <size>
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[1] != "blob" {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		sizes[fields[0]] = size
+	}
+	return sizes, nil
+}
+
+// scanAddedLines 逐行读取每个提交新增的内容并扫描密钥，只记录命中的位置
+func (q *Quarantine) scanAddedLines(commits []*Commit, byID map[string]*Commit) error {
+	var (
+		current  *Commit
+		filePath string
+		lineNo   int
+		inHunk   bool
+	)
+	return q.gitScan(commitIDsInput(commits), func(line string) {
+		switch {
+		case commitIDRe.MatchString(line) && byID[line] != nil:
+			current, filePath, inHunk = byID[line], "", false
+		case strings.HasPrefix(line, "diff --git "):
+			filePath, inHunk = "", false
+		case !inHunk && strings.HasPrefix(line, "+++ "):
+			filePath = parseDiffPath(strings.TrimPrefix(line, "+++ "))
+		case strings.HasPrefix(line, "@@ "):
+			inHunk = false
+			if m := hunkRe.FindStringSubmatch(line); m != nil {
+				lineNo, _ = strconv.Atoi(m[1])
+				inHunk = true
+			}
+		case inHunk && strings.HasPrefix(line, "+"):
+			if current != nil && filePath != "" {
+				if name, ok := ScanSecret(line[1:]); ok {
+					current.Secrets = append(current.Secrets, Secret{Path: filePath, Number: lineNo, Name: name})
+				}
+			}
+			lineNo++
+		}
+	}, "diff-tree", "--stdin", "-r", "--root", "--no-renames", "--no-color", "--no-ext-diff", "-p", "-U0")
+}
+
+func parseDiffPath(s string) string {
+	if s == "/dev/null" {
+		return ""
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	return strings.TrimPrefix(s, "b/")
+}
+
+func (q *Quarantine) git(stdin io.Reader, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = q.repoPath
+	cmd.Env = append(os.Environ(), q.env...)
+	cmd.Stdin = stdin
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s failed: %v %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// gitScan 逐行处理 git 输出，不在内存中保留完整输出
+func (q *Quarantine) gitScan(stdin io.Reader, handle func(line string), args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = q.repoPath
+	cmd.Env = append(os.Environ(), q.env...)
+	cmd.Stdin = stdin
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("git %s failed: %v", args[0], err)
+	}
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		handle(scanner.Text())
+	}
+	scanErr := scanner.Err()
+	if scanErr != nil {
+		// 读完剩余输出，保证 git 进程可以退出
+		_, _ = io.Copy(io.Discard, stdout)
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("git %s failed: %v %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return scanErr
+}
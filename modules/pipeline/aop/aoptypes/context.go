@@ -55,6 +55,31 @@ func (ctx *TuneContext) PutKV(k, v interface{}) {
 	ctx.Context = context.WithValue(ctx.Context, k, v)
 }
 
+// SerializableContext 用于将 TuneContext 序列化后传递给外部插件
+type SerializableContext struct {
+	Type     TuneType           `json:"type"`
+	Trigger  TuneTrigger        `json:"trigger"`
+	Pipeline spec.Pipeline      `json:"pipeline"`
+	Task     *spec.PipelineTask `json:"task,omitempty"`
+}
+
+// Serialize 返回可序列化的上下文，敏感信息会被移除
+func (ctx *TuneContext) Serialize() SerializableContext {
+	sc := SerializableContext{
+		Type:     ctx.SDK.TuneType,
+		Trigger:  ctx.SDK.TuneTrigger,
+		Pipeline: ctx.SDK.Pipeline,
+	}
+	sc.Pipeline.Snapshot.Secrets = nil
+	sc.Pipeline.Snapshot.PlatformSecrets = nil
+	if ctx.SDK.TuneType == TuneTypeTask {
+		task := ctx.SDK.Task
+		task.Extra.PrivateEnvs = nil
+		sc.Task = &task
+	}
+	return sc
+}
+
 func (ctx *TuneContext) TryGet(k interface{}) (interface{}, bool) {
 	v := ctx.Context.Value(k)
 	if v == nil {
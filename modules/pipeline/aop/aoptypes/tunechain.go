@@ -13,7 +13,25 @@
 
 package aoptypes
 
-import "github.com/sirupsen/logrus"
+import (
+	"errors"
+
+	"github.com/sirupsen/logrus"
+)
+
+// AbortError 表示调音点处理失败且需要中断调用链
+type AbortError struct {
+	Err error
+}
+
+func (e *AbortError) Error() string { return e.Err.Error() }
+func (e *AbortError) Unwrap() error { return e.Err }
+
+// IsAbortError 判断调音点是否要求中断，调用方需要据此将 pipeline 或 task 置为失败
+func IsAbortError(err error) bool {
+	var abortErr *AbortError
+	return errors.As(err, &abortErr)
+}
 
 // TuneChain 表示一组有序 TunePoint
 type TuneChain []TunePoint
@@ -27,6 +45,10 @@ func (chain TuneChain) Handle(ctx *TuneContext) error {
 		logrus.Debugf("begin handle tune point, type: %s, trigger: %s, name: %s", point.Type(), ctx.SDK.TuneTrigger, point.Name())
 		if err := point.Handle(ctx); err != nil {
 			logrus.Errorf("end handle tune point, type: %s, trigger: %s, name: %s, failed, err: %v", point.Type(), ctx.SDK.TuneTrigger, point.Name(), err)
+			// 中断调用链
+			if IsAbortError(err) {
+				return err
+			}
 		} else {
 			logrus.Debugf("end handle tune point, type: %s, trigger: %s, name: %s, success", point.Type(), ctx.SDK.TuneTrigger, point.Name())
 		}
@@ -21,6 +21,16 @@ const (
 	TuneTypeTask     TuneType = "task"     // task 级别调节
 )
 
+func (t TuneType) String() string { return string(t) }
+func (t TuneType) IsValid() bool {
+	switch t {
+	case TuneTypePipeline, TuneTypeTask:
+		return true
+	default:
+		return false
+	}
+}
+
 // TuneTrigger 调节的触发时机
 type TuneTrigger string
 
@@ -43,6 +53,25 @@ const (
 	TuneTriggerTaskAfterWait     TuneTrigger = "task_after_wait"
 )
 
+func (t TuneTrigger) String() string { return string(t) }
+
+// IsValid 判断触发时机是否属于对应的调节类型
+func (t TuneTrigger) IsValid(typ TuneType) bool {
+	switch t {
+	case TuneTriggerPipelineBeforeExec, TuneTriggerPipelineInQueuePrecheckBeforePop, TuneTriggerPipelineAfterExec:
+		return typ == TuneTypePipeline
+	case TuneTriggerTaskBeforeExec, TuneTriggerTaskAfterExec,
+		TuneTriggerTaskBeforePrepare, TuneTriggerTaskAfterPrepare,
+		TuneTriggerTaskBeforeCreate, TuneTriggerTaskAfterCreate,
+		TuneTriggerTaskBeforeStart, TuneTriggerTaskAfterStart,
+		TuneTriggerTaskBeforeQueue, TuneTriggerTaskAfterQueue,
+		TuneTriggerTaskBeforeWait, TuneTriggerTaskAfterWait:
+		return typ == TuneTypeTask
+	default:
+		return false
+	}
+}
+
 // TunePoint 调音点
 type TunePoint interface {
 	Type() TuneType
@@ -0,0 +1,222 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package aop
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/aop/aoptypes"
+	"github.com/erda-project/erda/modules/pipeline/aop/plugins/httpplugin"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+	"github.com/erda-project/erda/pkg/encryption"
+)
+
+// configHTTPPlugins 保存通过配置注册的外部插件，各实例配置一致
+// httpPlugins 为实际生效的外部插件，由配置插件与数据库中 API 注册的插件合并而成，同名时以 API 注册的为准
+// 外部插件在内置插件之后执行，key 为插件名
+var configHTTPPlugins = make(map[string]*httpplugin.Plugin)
+var httpPlugins = make(map[string]*httpplugin.Plugin)
+var httpPluginsLock sync.RWMutex
+
+// httpPluginsReloadInterval 定时从数据库加载外部插件，使多实例之间保持一致
+var httpPluginsReloadInterval = time.Second * 10
+
+// httpPluginCrypt 加解密持久化的 header 值，与配置管理中的加密配置使用同一密钥
+var httpPluginCrypt *encryption.RsaCrypt
+
+func validateHTTPPlugin(cfg *apistructs.PipelineAOPHTTPPlugin) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	typ := aoptypes.TuneType(cfg.Type)
+	if !typ.IsValid() {
+		return fmt.Errorf("invalid type: %s", cfg.Type)
+	}
+	if !aoptypes.TuneTrigger(cfg.Trigger).IsValid(typ) {
+		return fmt.Errorf("invalid trigger: %s for type: %s", cfg.Trigger, cfg.Type)
+	}
+	return nil
+}
+
+// registerConfigHTTPPlugin 注册通过配置声明的外部插件，不持久化
+func registerConfigHTTPPlugin(cfg apistructs.PipelineAOPHTTPPlugin) error {
+	if err := validateHTTPPlugin(&cfg); err != nil {
+		return err
+	}
+	httpPluginsLock.Lock()
+	defer httpPluginsLock.Unlock()
+	configHTTPPlugins[cfg.Name] = httpplugin.New(cfg)
+	if _, ok := httpPlugins[cfg.Name]; !ok {
+		httpPlugins[cfg.Name] = configHTTPPlugins[cfg.Name]
+	}
+	return nil
+}
+
+// RegisterHTTPPlugin 注册外部插件并持久化，同名插件会被覆盖，返回隐藏 header 值后的插件配置
+func RegisterHTTPPlugin(cfg apistructs.PipelineAOPHTTPPlugin) (apistructs.PipelineAOPHTTPPlugin, error) {
+	if err := validateHTTPPlugin(&cfg); err != nil {
+		return cfg, err
+	}
+	// header 中可能包含认证信息，加密后持久化
+	encryptedHeaders, err := encryptHTTPPluginHeaders(cfg.Headers)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to encrypt http plugin headers, err: %v", err)
+	}
+	if err := globalSDK.DBClient.UpsertAOPHTTPPlugin(&spec.PipelineAOPHTTPPlugin{
+		Name:          cfg.Name,
+		Type:          cfg.Type,
+		Trigger:       cfg.Trigger,
+		URL:           cfg.URL,
+		TimeoutSec:    cfg.TimeoutSec,
+		FailurePolicy: cfg.FailurePolicy,
+		Headers:       encryptedHeaders,
+	}); err != nil {
+		return cfg, fmt.Errorf("failed to save http plugin, err: %v", err)
+	}
+	return redactHTTPPluginHeaders(cfg), reloadHTTPPlugins()
+}
+
+// UnregisterHTTPPlugin 删除通过 API 注册的外部插件，配置注册的插件需修改配置删除
+func UnregisterHTTPPlugin(name string) error {
+	exist, err := globalSDK.DBClient.DeleteAOPHTTPPlugin(name)
+	if err != nil {
+		return fmt.Errorf("failed to delete http plugin, err: %v", err)
+	}
+	if !exist {
+		return fmt.Errorf("http plugin not found, name: %s", name)
+	}
+	return reloadHTTPPlugins()
+}
+
+// ListHTTPPlugins 返回所有生效的外部插件配置，按名称排序，header 值会被隐藏
+func ListHTTPPlugins() []apistructs.PipelineAOPHTTPPlugin {
+	httpPluginsLock.RLock()
+	defer httpPluginsLock.RUnlock()
+	plugins := make([]apistructs.PipelineAOPHTTPPlugin, 0, len(httpPlugins))
+	for _, plugin := range httpPlugins {
+		plugins = append(plugins, redactHTTPPluginHeaders(plugin.Config()))
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// redactHTTPPluginHeaders header 中可能包含认证信息，只返回 key
+func redactHTTPPluginHeaders(cfg apistructs.PipelineAOPHTTPPlugin) apistructs.PipelineAOPHTTPPlugin {
+	if len(cfg.Headers) == 0 {
+		return cfg
+	}
+	headers := make(map[string]string, len(cfg.Headers))
+	for k := range cfg.Headers {
+		headers[k] = apistructs.SECRECT_PLACEHOLDER
+	}
+	cfg.Headers = headers
+	return cfg
+}
+
+// reloadHTTPPlugins 从数据库加载 API 注册的插件，与配置插件合并后替换当前生效的插件
+func reloadHTTPPlugins() error {
+	dbPlugins, err := globalSDK.DBClient.ListAOPHTTPPlugins()
+	if err != nil {
+		return fmt.Errorf("failed to list http plugins, err: %v", err)
+	}
+	httpPluginsLock.Lock()
+	defer httpPluginsLock.Unlock()
+	httpPlugins = mergeHTTPPlugins(configHTTPPlugins, dbPlugins)
+	return nil
+}
+
+func mergeHTTPPlugins(configPlugins map[string]*httpplugin.Plugin, dbPlugins []spec.PipelineAOPHTTPPlugin) map[string]*httpplugin.Plugin {
+	plugins := make(map[string]*httpplugin.Plugin, len(configPlugins)+len(dbPlugins))
+	for name, plugin := range configPlugins {
+		plugins[name] = plugin
+	}
+	for _, dbPlugin := range dbPlugins {
+		cfg := dbPlugin.Convert2Config()
+		if err := validateHTTPPlugin(&cfg); err != nil {
+			logrus.Errorf("AOP: skip invalid http plugin from db, name: %s, err: %v", cfg.Name, err)
+			continue
+		}
+		headers, err := decryptHTTPPluginHeaders(cfg.Headers)
+		if err != nil {
+			logrus.Errorf("AOP: skip http plugin from db, failed to decrypt headers, name: %s, err: %v", cfg.Name, err)
+			continue
+		}
+		cfg.Headers = headers
+		plugins[cfg.Name] = httpplugin.New(cfg)
+	}
+	return plugins
+}
+
+func encryptHTTPPluginHeaders(headers map[string]string) (map[string]string, error) {
+	return cryptHTTPPluginHeaders(headers, func(v string) (string, error) {
+		return httpPluginCrypt.Encrypt(v, encryption.Base64)
+	})
+}
+
+func decryptHTTPPluginHeaders(headers map[string]string) (map[string]string, error) {
+	return cryptHTTPPluginHeaders(headers, func(v string) (string, error) {
+		return httpPluginCrypt.Decrypt(v, encryption.Base64)
+	})
+}
+
+// cryptHTTPPluginHeaders 逐个加解密 header 值，返回新的 headers
+func cryptHTTPPluginHeaders(headers map[string]string, crypt func(string) (string, error)) (map[string]string, error) {
+	if len(headers) == 0 {
+		return headers, nil
+	}
+	if httpPluginCrypt == nil {
+		return nil, fmt.Errorf("missing crypt for http plugin headers")
+	}
+	result := make(map[string]string, len(headers))
+	for k, v := range headers {
+		cv, err := crypt(v)
+		if err != nil {
+			return nil, fmt.Errorf("header: %s, err: %v", k, err)
+		}
+		result[k] = cv
+	}
+	return result, nil
+}
+
+// continuousReloadHTTPPlugins 定时同步其他实例注册或删除的插件
+func continuousReloadHTTPPlugins() {
+	ticker := time.NewTicker(httpPluginsReloadInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := reloadHTTPPlugins(); err != nil {
+			logrus.Errorf("AOP: failed to reload http plugins, err: %v", err)
+		}
+	}
+}
+
+// getHTTPTuneChain 返回指定类型和触发时机下的外部插件调用链
+func getHTTPTuneChain(typ aoptypes.TuneType, trigger aoptypes.TuneTrigger) aoptypes.TuneChain {
+	httpPluginsLock.RLock()
+	defer httpPluginsLock.RUnlock()
+	var chain aoptypes.TuneChain
+	for _, plugin := range httpPlugins {
+		cfg := plugin.Config()
+		if cfg.Type == typ.String() && cfg.Trigger == trigger.String() {
+			chain = append(chain, plugin)
+		}
+	}
+	sort.Slice(chain, func(i, j int) bool { return chain[i].Name() < chain[j].Name() })
+	return chain
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package aop
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/aop/plugins/httpplugin"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+	"github.com/erda-project/erda/pkg/encryption"
+)
+
+func TestMergeHTTPPlugins(t *testing.T) {
+	configPlugins := map[string]*httpplugin.Plugin{
+		"audit": httpplugin.New(apistructs.PipelineAOPHTTPPlugin{Name: "audit", Type: "pipeline", Trigger: "pipeline_after_exec", URL: "http://audit"}),
+		"check": httpplugin.New(apistructs.PipelineAOPHTTPPlugin{Name: "check", Type: "pipeline", Trigger: "pipeline_before_exec", URL: "http://old"}),
+	}
+	dbPlugins := []spec.PipelineAOPHTTPPlugin{
+		{Name: "check", Type: "pipeline", Trigger: "pipeline_before_exec", URL: "http://new"},
+		{Name: "invalid", Type: "task", Trigger: "pipeline_before_exec", URL: "http://invalid"},
+	}
+	plugins := mergeHTTPPlugins(configPlugins, dbPlugins)
+	assert.Len(t, plugins, 2)
+	assert.Equal(t, "http://audit", plugins["audit"].Config().URL)
+	// api 注册的插件覆盖配置插件，并填充默认值
+	assert.Equal(t, "http://new", plugins["check"].Config().URL)
+	assert.Equal(t, apistructs.PipelineAOPHTTPPluginDefaultTimeoutSec, plugins["check"].Config().TimeoutSec)
+	// 配置插件本身不受影响
+	assert.Equal(t, "http://old", configPlugins["check"].Config().URL)
+}
+
+func TestRedactHTTPPluginHeaders(t *testing.T) {
+	cfg := apistructs.PipelineAOPHTTPPlugin{Name: "check", Headers: map[string]string{"Authorization": "Bearer token"}}
+	redacted := redactHTTPPluginHeaders(cfg)
+	assert.Equal(t, map[string]string{"Authorization": apistructs.SECRECT_PLACEHOLDER}, redacted.Headers)
+	assert.Equal(t, "Bearer token", cfg.Headers["Authorization"])
+
+	assert.Nil(t, redactHTTPPluginHeaders(apistructs.PipelineAOPHTTPPlugin{Name: "empty"}).Headers)
+}
+
+func TestHTTPPluginHeadersCrypt(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pubKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	encodeKey := func(typ string, b []byte) string {
+		return base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: b}))
+	}
+	httpPluginCrypt = encryption.NewRSAScrypt(encryption.RSASecret{
+		PublicKey:          encodeKey("PUBLIC KEY", pubKey),
+		PublicKeyDataType:  encryption.Base64,
+		PrivateKey:         encodeKey("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key)),
+		PrivateKeyDataType: encryption.Base64,
+		PrivateKeyType:     encryption.PKCS1,
+	})
+	defer func() { httpPluginCrypt = nil }()
+
+	headers := map[string]string{"Authorization": "Bearer token"}
+	encrypted, err := encryptHTTPPluginHeaders(headers)
+	require.NoError(t, err)
+	assert.NotEqual(t, "Bearer token", encrypted["Authorization"])
+
+	// 从数据库加载时解密
+	plugins := mergeHTTPPlugins(nil, []spec.PipelineAOPHTTPPlugin{
+		{Name: "check", Type: "pipeline", Trigger: "pipeline_before_exec", URL: "http://check", Headers: encrypted},
+		{Name: "broken", Type: "pipeline", Trigger: "pipeline_before_exec", URL: "http://broken", Headers: headers},
+	})
+	require.Len(t, plugins, 1)
+	assert.Equal(t, headers, plugins["check"].Config().Headers)
+}
@@ -16,12 +16,16 @@ package aop
 import (
 	"sync"
 
+	"github.com/sirupsen/logrus"
+
 	"github.com/erda-project/erda/bundle"
 	"github.com/erda-project/erda/modules/pipeline/aop/aoptypes"
 	"github.com/erda-project/erda/modules/pipeline/aop/plugins/pipeline"
 	"github.com/erda-project/erda/modules/pipeline/aop/plugins/task"
+	"github.com/erda-project/erda/modules/pipeline/conf"
 	"github.com/erda-project/erda/modules/pipeline/dbclient"
 	"github.com/erda-project/erda/modules/pipeline/services/reportsvc"
+	"github.com/erda-project/erda/pkg/encryption"
 )
 
 // tuneGroup 保存所有 tune chain
@@ -31,13 +35,14 @@ var once sync.Once
 var initialized bool
 var globalSDK aoptypes.SDK
 
-func Initialize(bdl *bundle.Bundle, dbClient *dbclient.Client, report *reportsvc.ReportSvc) {
+func Initialize(bdl *bundle.Bundle, dbClient *dbclient.Client, report *reportsvc.ReportSvc, rsaCrypt *encryption.RsaCrypt) {
 	once.Do(func() {
 		initialized = true
 
 		globalSDK.Bundle = bdl
 		globalSDK.DBClient = dbClient
 		globalSDK.Report = report
+		httpPluginCrypt = rsaCrypt
 
 		tuneGroup = aoptypes.TuneGroup{
 			// pipeline level
@@ -45,5 +50,17 @@ func Initialize(bdl *bundle.Bundle, dbClient *dbclient.Client, report *reportsvc
 			// task level
 			aoptypes.TuneTypeTask: task.TuneTriggerChains,
 		}
+
+		// external http plugins from config
+		for _, plugin := range conf.AOPHTTPPlugins() {
+			if err := registerConfigHTTPPlugin(plugin); err != nil {
+				logrus.Errorf("AOP: failed to register http plugin from config, name: %s, err: %v", plugin.Name, err)
+			}
+		}
+		// external http plugins registered by api
+		if err := reloadHTTPPlugins(); err != nil {
+			logrus.Errorf("AOP: failed to load http plugins, err: %v", err)
+		}
+		go continuousReloadHTTPPlugins()
 	})
 }
@@ -0,0 +1,104 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package httpplugin 实现通过 HTTP 调用的外部 AOP 插件，无需修改代码重新编译即可扩展调音点
+package httpplugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/aop/aoptypes"
+	"github.com/erda-project/erda/pkg/httpclient"
+)
+
+type Plugin struct {
+	cfg apistructs.PipelineAOPHTTPPlugin
+}
+
+// New 使用已校验过的配置创建插件
+func New(cfg apistructs.PipelineAOPHTTPPlugin) *Plugin { return &Plugin{cfg: cfg} }
+
+func (p *Plugin) Type() aoptypes.TuneType { return aoptypes.TuneType(p.cfg.Type) }
+func (p *Plugin) Name() string            { return "http-" + p.cfg.Name }
+func (p *Plugin) Config() apistructs.PipelineAOPHTTPPlugin {
+	return p.cfg
+}
+
+func (p *Plugin) Handle(ctx *aoptypes.TuneContext) error {
+	err := p.invoke(ctx)
+	if err == nil {
+		return nil
+	}
+	err = fmt.Errorf("http plugin %s failed, url: %s, err: %v", p.cfg.Name, p.cfg.URL, err)
+	if p.cfg.FailurePolicy != apistructs.PipelineAOPHTTPPluginFailurePolicyFail {
+		return err
+	}
+	// 队列预检查失败时直接返回不可重试的检查结果
+	if ctx.SDK.TuneTrigger == aoptypes.TuneTriggerPipelineInQueuePrecheckBeforePop {
+		ctx.PutKV(apistructs.PipelinePreCheckResultContextKey, apistructs.PipelineQueueValidateResult{
+			Success: false,
+			Reason:  err.Error(),
+		})
+	}
+	return &aoptypes.AbortError{Err: err}
+}
+
+func (p *Plugin) invoke(ctx *aoptypes.TuneContext) error {
+	timeout := time.Second * time.Duration(p.cfg.TimeoutSec)
+	req := httpclient.New(httpclient.WithTimeout(timeout, timeout)).Post(p.cfg.URL).
+		JSONBody(ctx.Serialize())
+	for k, v := range p.cfg.Headers {
+		req = req.Header(k, v)
+	}
+	var body bytes.Buffer
+	resp, err := req.Do().Body(&body)
+	if err != nil {
+		return err
+	}
+	if !resp.IsOK() {
+		return fmt.Errorf("status code: %d, body: %s", resp.StatusCode(), body.String())
+	}
+	var result apistructs.PipelineAOPHTTPPluginResponse
+	if err := json.Unmarshal(body.Bytes(), &result); err != nil {
+		return fmt.Errorf("failed to unmarshal response body, err: %v, body: %s", err, body.String())
+	}
+	if !result.Success {
+		return fmt.Errorf("plugin handle failed, err: %s", result.Error)
+	}
+	return mergeData(ctx, result.Data)
+}
+
+// mergeData 将插件返回的数据合并回上下文
+func mergeData(ctx *aoptypes.TuneContext, data map[string]json.RawMessage) error {
+	for k, raw := range data {
+		var v interface{}
+		switch k {
+		case apistructs.PipelinePreCheckResultContextKey:
+			var checkResult apistructs.PipelineQueueValidateResult
+			if err := json.Unmarshal(raw, &checkResult); err != nil {
+				return fmt.Errorf("invalid data of key %s, err: %v", k, err)
+			}
+			v = checkResult
+		default:
+			if err := json.Unmarshal(raw, &v); err != nil {
+				return fmt.Errorf("invalid data of key %s, err: %v", k, err)
+			}
+		}
+		ctx.PutKV(k, v)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package httpplugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/aop/aoptypes"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+func newTestContext(trigger aoptypes.TuneTrigger) *aoptypes.TuneContext {
+	ctx := &aoptypes.TuneContext{Context: context.Background()}
+	ctx.SDK.TuneType = aoptypes.TuneTypePipeline
+	ctx.SDK.TuneTrigger = trigger
+	ctx.SDK.Pipeline = spec.Pipeline{PipelineBase: spec.PipelineBase{ID: 1}}
+	ctx.SDK.Pipeline.Snapshot.Secrets = map[string]string{"password": "123456"}
+	return ctx
+}
+
+func TestPluginHandle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var sc aoptypes.SerializableContext
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&sc))
+		assert.Equal(t, uint64(1), sc.Pipeline.ID)
+		assert.Nil(t, sc.Pipeline.Snapshot.Secrets)
+		assert.Equal(t, "token", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"success":true,"data":{"precheck_result":{"success":false,"reason":"not compliant"},"report":"ok"}}`))
+	}))
+	defer server.Close()
+
+	cfg := apistructs.PipelineAOPHTTPPlugin{
+		Name: "compliance", Type: "pipeline", Trigger: string(aoptypes.TuneTriggerPipelineInQueuePrecheckBeforePop),
+		URL: server.URL, Headers: map[string]string{"Authorization": "token"},
+	}
+	assert.NoError(t, cfg.Validate())
+	ctx := newTestContext(aoptypes.TuneTriggerPipelineInQueuePrecheckBeforePop)
+	assert.NoError(t, New(cfg).Handle(ctx))
+
+	result, ok := ctx.TryGet(apistructs.PipelinePreCheckResultContextKey)
+	assert.True(t, ok)
+	assert.Equal(t, apistructs.PipelineQueueValidateResult{Success: false, Reason: "not compliant"}, result)
+	report, ok := ctx.TryGet("report")
+	assert.True(t, ok)
+	assert.Equal(t, "ok", report)
+}
+
+func TestPluginFailurePolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cfg := apistructs.PipelineAOPHTTPPlugin{
+		Name: "compliance", Type: "pipeline", Trigger: string(aoptypes.TuneTriggerPipelineInQueuePrecheckBeforePop),
+		URL: server.URL,
+	}
+	assert.NoError(t, cfg.Validate())
+
+	// ignore
+	ctx := newTestContext(aoptypes.TuneTriggerPipelineInQueuePrecheckBeforePop)
+	err := New(cfg).Handle(ctx)
+	assert.Error(t, err)
+	var abortErr *aoptypes.AbortError
+	assert.False(t, errors.As(err, &abortErr))
+	_, ok := ctx.TryGet(apistructs.PipelinePreCheckResultContextKey)
+	assert.False(t, ok)
+
+	// fail
+	cfg.FailurePolicy = apistructs.PipelineAOPHTTPPluginFailurePolicyFail
+	ctx = newTestContext(aoptypes.TuneTriggerPipelineInQueuePrecheckBeforePop)
+	err = aoptypes.TuneChain{New(cfg)}.Handle(ctx)
+	assert.True(t, errors.As(err, &abortErr))
+	result, ok := ctx.TryGet(apistructs.PipelinePreCheckResultContextKey)
+	assert.True(t, ok)
+	assert.False(t, result.(apistructs.PipelineQueueValidateResult).Success)
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package coverage_report
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/aop/aoptypes"
+)
+
+type Plugin struct {
+	aoptypes.TaskBaseTunePoint
+}
+
+func New() *Plugin { return &Plugin{} }
+
+func (p *Plugin) Name() string { return "coverage-report" }
+func (p *Plugin) Handle(ctx *aoptypes.TuneContext) error {
+	// 仅处理声明了 coverage 的任务
+	if ctx.SDK.Task.Extra.Action.Coverage == nil {
+		return nil
+	}
+
+	var report *apistructs.CoverageReport
+	for _, v := range ctx.SDK.Task.Result.Metadata {
+		if v.Name != apistructs.CoverageMetaKey {
+			continue
+		}
+		if err := json.Unmarshal([]byte(v.Value), &report); err != nil {
+			return fmt.Errorf("unmarshal coverage report error: %v", err)
+		}
+	}
+	if report == nil {
+		return nil
+	}
+
+	_, err := ctx.SDK.Report.Create(apistructs.PipelineReportCreateRequest{
+		PipelineID: ctx.SDK.Pipeline.ID,
+		Type:       apistructs.PipelineReportTypeCoverage,
+		Meta: map[string]interface{}{
+			"taskId":   ctx.SDK.Task.ID,
+			"taskName": ctx.SDK.Task.Name,
+			"coverage": report,
+		},
+	})
+	return err
+}
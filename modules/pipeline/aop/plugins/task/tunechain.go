@@ -17,6 +17,7 @@ import (
 	"github.com/erda-project/erda/modules/pipeline/aop/aoptypes"
 	"github.com/erda-project/erda/modules/pipeline/aop/plugins/task/plugins/autotest_cookie_keep_after"
 	"github.com/erda-project/erda/modules/pipeline/aop/plugins/task/plugins/autotest_cookie_keep_before"
+	"github.com/erda-project/erda/modules/pipeline/aop/plugins/task/plugins/coverage_report"
 	"github.com/erda-project/erda/modules/pipeline/aop/plugins/task/plugins/echo"
 	"github.com/erda-project/erda/modules/pipeline/aop/plugins/task/plugins/unit_test_report"
 )
@@ -72,6 +73,7 @@ var TuneTriggerChains = map[aoptypes.TuneTrigger]aoptypes.TuneChain{
 	aoptypes.TuneTriggerTaskAfterExec: []aoptypes.TunePoint{
 		echo.New(),
 		unit_test_report.New(),
+		coverage_report.New(),
 		autotest_cookie_keep_after.New(),
 	},
 }
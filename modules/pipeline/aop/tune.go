@@ -30,6 +30,10 @@ func Handle(ctx *aoptypes.TuneContext) error {
 	trigger := ctx.SDK.TuneTrigger
 	logrus.Debugf("AOP: type: %s, trigger: %s", typ, trigger)
 	chain := tuneGroup.GetTuneChainByTypeAndTrigger(typ, trigger)
+	// append external http plugins
+	if httpChain := getHTTPTuneChain(typ, trigger); len(httpChain) > 0 {
+		chain = append(append(aoptypes.TuneChain{}, chain...), httpChain...)
+	}
 	if chain == nil || len(chain) == 0 {
 		logrus.Debugf("AOP: type: %s, trigger: %s, tune chain is empty", typ, trigger)
 		return nil
@@ -31,7 +31,7 @@ func init() {
 	dbClient, _ := dbclient.New()
 	report := reportsvc.New(reportsvc.WithDBClient(dbClient))
 
-	Initialize(bdl, dbClient, report)
+	Initialize(bdl, dbClient, report, nil)
 }
 
 func TestHandlePipeline(t *testing.T) {
@@ -20,7 +20,6 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 
 	"github.com/erda-project/erda/apistructs"
 	"github.com/erda-project/erda/modules/pipeline/dbclient"
@@ -32,6 +31,7 @@ import (
 const (
 	CtxKeyPipelineSource = "pipelineSource"
 	CtxKeyForceDelete    = "forceDelete"
+	CtxKeyOperator       = "operator"
 )
 
 type pipelineCm struct {
@@ -132,27 +132,41 @@ func (c *pipelineCm) UpdateConfigs(ctx context.Context, ns string, kvs map[strin
 			},
 		})
 	}
-	// tx
-	txSession := c.dbClient.NewSession()
-	defer txSession.Close()
-	if err := txSession.Begin(); err != nil {
-		return err
-	}
-	err = c.dbClient.UpdateCmsNsConfigs(cmsNs, configs, dbclient.WithTxSession(txSession.Session))
-	if err != nil {
-		if rbErr := txSession.Rollback(); rbErr != nil {
-			logrus.Errorf("[alert] failed to rollback tx session when update pipeline cms ns configs failed, pipelineSource: %s, ns: %s, rbErr: %v, err: %v",
-				pipelineSource, ns, rbErr, err)
+	return c.execInTx(pipelineSource, ns, cmsNsID, "update", func(txOp dbclient.SessionOption) error {
+		// 记录变更前的值，用于生成变更记录
+		var keys []string
+		for _, config := range configs {
+			keys = append(keys, config.Key)
+		}
+		existConfigs, err := c.dbClient.GetCmsNsConfigs(spec.PipelineCmsNs{ID: cmsNsID}, keys, txOp)
+		if err != nil {
 			return err
 		}
-		return err
-	}
-	if cmErr := txSession.Commit(); cmErr != nil {
-		logrus.Errorf("[alert] failed to commit tx session when update pipeline cms ns configs success, pipelineSource: %s, ns: %s, cmErr: %v, err: %v",
-			pipelineSource, ns, cmErr, err)
-		return cmErr
-	}
-	return nil
+		oldStates := make(map[string]*spec.PipelineCmsConfigSnapshot, len(existConfigs))
+		for _, config := range existConfigs {
+			oldStates[config.Key] = config.Snapshot()
+		}
+		var changes []configChange
+		for _, config := range configs {
+			oldSnapshot, newSnapshot := oldStates[config.Key], config.Snapshot()
+			equal, err := c.snapshotEqual(oldSnapshot, newSnapshot)
+			if err != nil {
+				return errors.Errorf("config key: %s, err: %v", config.Key, err)
+			}
+			if equal {
+				continue
+			}
+			operation := apistructs.PipelineCmsConfigRevisionOperationUpdate
+			if oldSnapshot == nil {
+				operation = apistructs.PipelineCmsConfigRevisionOperationCreate
+			}
+			changes = append(changes, configChange{key: config.Key, operation: operation, old: oldSnapshot, new: newSnapshot})
+		}
+		if err := c.dbClient.UpdateCmsNsConfigs(cmsNs, configs, txOp); err != nil {
+			return err
+		}
+		return c.recordRevisions(cmsNsID, changes, getOperatorFromContext(ctx), txOp)
+	})
 }
 
 func (c *pipelineCm) DeleteConfigs(ctx context.Context, ns string, keys ...string) error {
@@ -198,7 +212,27 @@ func (c *pipelineCm) DeleteConfigs(ctx context.Context, ns string, keys ...strin
 		}
 	}
 
-	return c.dbClient.DeleteCmsNsConfigs(cmsNs, keys)
+	if len(keys) == 0 {
+		return nil
+	}
+	return c.execInTx(pipelineSource, ns, cmsNs.ID, "delete", func(txOp dbclient.SessionOption) error {
+		existConfigs, err := c.dbClient.GetCmsNsConfigs(cmsNs, keys, txOp)
+		if err != nil {
+			return err
+		}
+		var changes []configChange
+		for _, config := range existConfigs {
+			changes = append(changes, configChange{
+				key:       config.Key,
+				operation: apistructs.PipelineCmsConfigRevisionOperationDelete,
+				old:       config.Snapshot(),
+			})
+		}
+		if err := c.dbClient.DeleteCmsNsConfigs(cmsNs, keys, txOp); err != nil {
+			return err
+		}
+		return c.recordRevisions(cmsNs.ID, changes, getOperatorFromContext(ctx), txOp)
+	})
 }
 
 func (c *pipelineCm) GetConfigs(ctx context.Context, ns string, globalDecrypt bool, keys ...apistructs.PipelineCmsConfigKey) (map[string]apistructs.PipelineCmsConfigValue, error) {
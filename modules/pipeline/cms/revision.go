@@ -0,0 +1,404 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cms
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/dbclient"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+	"github.com/erda-project/erda/pkg/encryption"
+)
+
+// sealedValueSep 分隔 sealed value 中被加密的 AES key 与密文
+const sealedValueSep = "."
+
+// configChange 一次变更操作中单个配置项的变化，old/new 为 nil 表示不存在
+type configChange struct {
+	key       string
+	operation apistructs.PipelineCmsConfigRevisionOperation
+	old       *spec.PipelineCmsConfigSnapshot
+	new       *spec.PipelineCmsConfigSnapshot
+}
+
+func (c *pipelineCm) ListConfigRevisions(ctx context.Context, ns string, keys ...string) ([]apistructs.PipelineCmsConfigRevision, error) {
+	pipelineSource, err := getPipelineSourceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cmsNs, exist, err := c.dbClient.GetCmsNs(pipelineSource, ns)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, nil
+	}
+	revisions, err := c.listRevisions(cmsNs.ID, keys)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]apistructs.PipelineCmsConfigRevision, 0, len(revisions))
+	// 最新的变更在前
+	for i := len(revisions) - 1; i >= 0; i-- {
+		revision := revisions[i]
+		result = append(result, apistructs.PipelineCmsConfigRevision{
+			Revision:    revision.Revision,
+			Key:         revision.Key,
+			Operation:   revision.Operation,
+			Old:         maskSnapshot(revision.Detail.Old),
+			New:         maskSnapshot(revision.Detail.New),
+			Operator:    revision.Operator,
+			TimeCreated: revision.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+func (c *pipelineCm) DiffConfigRevisions(ctx context.Context, ns string, fromRevision, toRevision uint64) ([]apistructs.PipelineCmsConfigDiff, error) {
+	pipelineSource, err := getPipelineSourceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cmsNs, exist, err := c.dbClient.GetCmsNs(pipelineSource, ns)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, nil
+	}
+	revisions, err := c.listRevisions(cmsNs.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if toRevision == 0 && len(revisions) > 0 {
+		toRevision = revisions[len(revisions)-1].Revision
+	}
+	fromStates := configStatesAtRevision(revisions, fromRevision)
+	toStates := configStatesAtRevision(revisions, toRevision)
+
+	var diffs []apistructs.PipelineCmsConfigDiff
+	for _, key := range sortedStateKeys(fromStates) {
+		equal, err := c.snapshotEqual(fromStates[key], toStates[key])
+		if err != nil {
+			return nil, errors.Errorf("config key: %s, err: %v", key, err)
+		}
+		if equal {
+			continue
+		}
+		diffs = append(diffs, apistructs.PipelineCmsConfigDiff{
+			Key:  key,
+			From: maskSnapshot(fromStates[key]),
+			To:   maskSnapshot(toStates[key]),
+		})
+	}
+	return diffs, nil
+}
+
+func (c *pipelineCm) RollbackConfigs(ctx context.Context, ns string, revision uint64) error {
+	pipelineSource, err := getPipelineSourceFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	cmsNs, exist, err := c.dbClient.GetCmsNs(pipelineSource, ns)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return errors.Errorf("cms ns not found, pipelineSource: %s, ns: %s", pipelineSource, ns)
+	}
+
+	return c.execInTx(pipelineSource, ns, cmsNs.ID, "rollback", func(txOp dbclient.SessionOption) error {
+		latestRevision, err := c.dbClient.GetCmsNsLatestRevision(cmsNs.ID, txOp)
+		if err != nil {
+			return err
+		}
+		if revision > latestRevision {
+			return errors.Errorf("revision %d not found, latest revision: %d", revision, latestRevision)
+		}
+		revisions, err := c.listRevisions(cmsNs.ID, nil, txOp)
+		if err != nil {
+			return err
+		}
+		targetStates := configStatesAtRevision(revisions, revision)
+		keys := sortedStateKeys(targetStates)
+
+		// 只处理有变更记录的配置项，没有变更记录的配置项保持不变
+		currentConfigs, err := c.dbClient.GetCmsNsConfigs(cmsNs, keys, txOp)
+		if err != nil {
+			return err
+		}
+		currentStates := make(map[string]*spec.PipelineCmsConfigSnapshot, len(currentConfigs))
+		for _, config := range currentConfigs {
+			currentStates[config.Key] = config.Snapshot()
+		}
+
+		var changes []configChange
+		var deleteKeys []string
+		for _, key := range keys {
+			target, current := targetStates[key], currentStates[key]
+			equal, err := c.snapshotEqual(current, target)
+			if err != nil {
+				return errors.Errorf("config key: %s, err: %v", key, err)
+			}
+			if equal {
+				continue
+			}
+			if target == nil {
+				deleteKeys = append(deleteKeys, key)
+			} else if err := c.dbClient.InsertOrUpdateCmsNsConfig(cmsNs, target.ToConfig(cmsNs.ID, key), txOp); err != nil {
+				return err
+			}
+			changes = append(changes, configChange{
+				key:       key,
+				operation: apistructs.PipelineCmsConfigRevisionOperationRollback,
+				old:       current,
+				new:       target,
+			})
+		}
+		if err := c.dbClient.DeleteCmsNsConfigs(cmsNs, deleteKeys, txOp); err != nil {
+			return err
+		}
+		return c.recordRevisions(cmsNs.ID, changes, getOperatorFromContext(ctx), txOp)
+	})
+}
+
+// recordRevisions 为一次变更操作中所有配置项的变化生成同一个新 revision，需在 execInTx 中调用
+func (c *pipelineCm) recordRevisions(nsID uint64, changes []configChange, operator string, ops ...dbclient.SessionOption) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	latestRevision, err := c.dbClient.GetCmsNsLatestRevision(nsID, ops...)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].key < changes[j].key })
+	revisions := make([]spec.PipelineCmsConfigRevision, 0, len(changes))
+	for _, change := range changes {
+		oldSnapshot, err := c.sealSnapshot(change.old)
+		if err != nil {
+			return errors.Errorf("config key: %s, err: %v", change.key, err)
+		}
+		newSnapshot, err := c.sealSnapshot(change.new)
+		if err != nil {
+			return errors.Errorf("config key: %s, err: %v", change.key, err)
+		}
+		revisions = append(revisions, spec.PipelineCmsConfigRevision{
+			NsID:      nsID,
+			Revision:  latestRevision + 1,
+			Key:       change.key,
+			Operation: change.operation,
+			Detail: spec.PipelineCmsConfigRevisionDetail{
+				Old: oldSnapshot,
+				New: newSnapshot,
+			},
+			Operator: operator,
+		})
+	}
+	return c.dbClient.CreateCmsNsConfigRevisions(revisions, ops...)
+}
+
+// listRevisions 按 revision 正序返回变更记录，sealed 的值会被解开
+func (c *pipelineCm) listRevisions(nsID uint64, keys []string, ops ...dbclient.SessionOption) ([]spec.PipelineCmsConfigRevision, error) {
+	revisions, err := c.dbClient.ListCmsNsConfigRevisions(nsID, keys, ops...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range revisions {
+		if revisions[i].Detail.Old, err = c.openSnapshot(revisions[i].Detail.Old); err != nil {
+			return nil, errors.Errorf("revision: %d, config key: %s, err: %v", revisions[i].Revision, revisions[i].Key, err)
+		}
+		if revisions[i].Detail.New, err = c.openSnapshot(revisions[i].Detail.New); err != nil {
+			return nil, errors.Errorf("revision: %d, config key: %s, err: %v", revisions[i].Revision, revisions[i].Key, err)
+		}
+	}
+	return revisions, nil
+}
+
+// sealSnapshot 未加密存储的配置项使用信封加密后写入变更记录：
+// 随机生成 AES key 加密 value，AES key 再使用 cms 的 rsa 公钥加密
+func (c *pipelineCm) sealSnapshot(s *spec.PipelineCmsConfigSnapshot) (*spec.PipelineCmsConfigSnapshot, error) {
+	if s == nil || s.Encrypt || s.Sealed {
+		return s, nil
+	}
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	encryptedKey, err := c.rsaCrypt.Encrypt(string(dataKey), encryption.Base64)
+	if err != nil {
+		return nil, err
+	}
+	sealed := *s
+	sealed.Value = encryptedKey + sealedValueSep + base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(s.Value), nil))
+	sealed.Sealed = true
+	return &sealed, nil
+}
+
+// openSnapshot 解开 sealSnapshot 加密的值
+func (c *pipelineCm) openSnapshot(s *spec.PipelineCmsConfigSnapshot) (*spec.PipelineCmsConfigSnapshot, error) {
+	if s == nil || !s.Sealed {
+		return s, nil
+	}
+	parts := strings.SplitN(s.Value, sealedValueSep, 2)
+	if len(parts) != 2 {
+		return nil, errors.New("invalid sealed value")
+	}
+	dataKey, err := c.rsaCrypt.Decrypt(parts[0], encryption.Base64)
+	if err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM([]byte(dataKey))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("invalid sealed value")
+	}
+	value, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, err
+	}
+	opened := *s
+	opened.Value = string(value)
+	opened.Sealed = false
+	return &opened, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// snapshotEqual 比较两个快照，加密存储的值解密后比较
+func (c *pipelineCm) snapshotEqual(a, b *spec.PipelineCmsConfigSnapshot) (bool, error) {
+	if a == nil || b == nil {
+		return a == nil && b == nil, nil
+	}
+	if !a.EqualExceptValue(*b) {
+		return false, nil
+	}
+	if a.Value == b.Value || !a.Encrypt {
+		return a.Value == b.Value, nil
+	}
+	aValue, err := c.decryptValueIfNeeded(true, a.Value)
+	if err != nil {
+		return false, err
+	}
+	bValue, err := c.decryptValueIfNeeded(true, b.Value)
+	if err != nil {
+		return false, err
+	}
+	return aValue == bValue, nil
+}
+
+// execInTx 锁定 ns 后在同一个事务中执行 do，同一 ns 下的变更串行执行
+func (c *pipelineCm) execInTx(pipelineSource apistructs.PipelineSource, ns string, nsID uint64, action string, do func(txOp dbclient.SessionOption) error) error {
+	txSession := c.dbClient.NewSession()
+	defer txSession.Close()
+	if err := txSession.Begin(); err != nil {
+		return err
+	}
+	txOp := dbclient.WithTxSession(txSession.Session)
+	err := c.dbClient.LockCmsNs(nsID, txOp)
+	if err == nil {
+		err = do(txOp)
+	}
+	if err != nil {
+		if rbErr := txSession.Rollback(); rbErr != nil {
+			logrus.Errorf("[alert] failed to rollback tx session when %s pipeline cms ns configs failed, pipelineSource: %s, ns: %s, rbErr: %v, err: %v",
+				action, pipelineSource, ns, rbErr, err)
+		}
+		return err
+	}
+	if cmErr := txSession.Commit(); cmErr != nil {
+		logrus.Errorf("[alert] failed to commit tx session when %s pipeline cms ns configs success, pipelineSource: %s, ns: %s, cmErr: %v",
+			action, pipelineSource, ns, cmErr)
+		return cmErr
+	}
+	return nil
+}
+
+// configStatesAtRevision 计算每个有变更记录的配置项在指定 revision 时的状态，revisions 需按 revision 正序。
+// 若配置项在 revision 及之前有变更，取最后一次变更后的值；否则取之后第一次变更前的值。
+// 值为 nil 表示配置项在该 revision 时不存在。
+func configStatesAtRevision(revisions []spec.PipelineCmsConfigRevision, revision uint64) map[string]*spec.PipelineCmsConfigSnapshot {
+	states := make(map[string]*spec.PipelineCmsConfigSnapshot)
+	for _, r := range revisions {
+		if r.Revision <= revision {
+			states[r.Key] = r.Detail.New
+			continue
+		}
+		if _, ok := states[r.Key]; !ok {
+			states[r.Key] = r.Detail.Old
+		}
+	}
+	return states
+}
+
+func sortedStateKeys(states map[string]*spec.PipelineCmsConfigSnapshot) []string {
+	keys := make([]string, 0, len(states))
+	for key := range states {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// maskSnapshot 转换为对外展示的值，加密存储的值使用掩码
+func maskSnapshot(s *spec.PipelineCmsConfigSnapshot) *apistructs.PipelineCmsConfigRevisionValue {
+	if s == nil {
+		return nil
+	}
+	value := s.Value
+	if s.Encrypt {
+		value = apistructs.PipelineCmsConfigMaskedValue
+	}
+	return &apistructs.PipelineCmsConfigRevisionValue{
+		Value:       value,
+		EncryptInDB: s.Encrypt,
+		Type:        s.Type,
+		Comment:     s.Extra.Comment,
+		From:        s.Extra.From,
+	}
+}
+
+func getOperatorFromContext(ctx context.Context) string {
+	operator, _ := ctx.Value(CtxKeyOperator).(string)
+	return operator
+}
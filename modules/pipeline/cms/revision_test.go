@@ -0,0 +1,135 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cms
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+	"github.com/erda-project/erda/pkg/encryption"
+)
+
+func snapshot(value string) *spec.PipelineCmsConfigSnapshot {
+	return &spec.PipelineCmsConfigSnapshot{Value: value, Type: apistructs.PipelineCmsConfigTypeKV}
+}
+
+func TestConfigStatesAtRevision(t *testing.T) {
+	revisions := []spec.PipelineCmsConfigRevision{
+		// a 在记录变更前已存在
+		{Revision: 1, Key: "a", Detail: spec.PipelineCmsConfigRevisionDetail{Old: snapshot("a0"), New: snapshot("a1")}},
+		{Revision: 1, Key: "b", Detail: spec.PipelineCmsConfigRevisionDetail{New: snapshot("b1")}},
+		{Revision: 2, Key: "b", Detail: spec.PipelineCmsConfigRevisionDetail{Old: snapshot("b1"), New: snapshot("b2")}},
+		{Revision: 3, Key: "a", Detail: spec.PipelineCmsConfigRevisionDetail{Old: snapshot("a1")}},
+		{Revision: 3, Key: "c", Detail: spec.PipelineCmsConfigRevisionDetail{New: snapshot("c3")}},
+	}
+
+	states := configStatesAtRevision(revisions, 0)
+	assert.Equal(t, "a0", states["a"].Value)
+	assert.Nil(t, states["b"])
+	assert.Nil(t, states["c"])
+
+	states = configStatesAtRevision(revisions, 2)
+	assert.Equal(t, "a1", states["a"].Value)
+	assert.Equal(t, "b2", states["b"].Value)
+	assert.Nil(t, states["c"])
+
+	states = configStatesAtRevision(revisions, 3)
+	assert.Nil(t, states["a"])
+	assert.Equal(t, "b2", states["b"].Value)
+	assert.Equal(t, "c3", states["c"].Value)
+	assert.Equal(t, []string{"a", "b", "c"}, sortedStateKeys(states))
+}
+
+func TestSnapshotEqual(t *testing.T) {
+	c := &pipelineCm{}
+
+	equal, err := c.snapshotEqual(nil, nil)
+	assert.NoError(t, err)
+	assert.True(t, equal)
+
+	equal, err = c.snapshotEqual(snapshot("v"), nil)
+	assert.NoError(t, err)
+	assert.False(t, equal)
+
+	equal, err = c.snapshotEqual(snapshot("v"), snapshot("v"))
+	assert.NoError(t, err)
+	assert.True(t, equal)
+
+	equal, err = c.snapshotEqual(snapshot("v1"), snapshot("v2"))
+	assert.NoError(t, err)
+	assert.False(t, equal)
+
+	commented := snapshot("v")
+	commented.Extra.Comment = "comment"
+	equal, err = c.snapshotEqual(snapshot("v"), commented)
+	assert.NoError(t, err)
+	assert.False(t, equal)
+}
+
+func TestMaskSnapshot(t *testing.T) {
+	assert.Nil(t, maskSnapshot(nil))
+
+	plain := maskSnapshot(snapshot("plain"))
+	assert.Equal(t, "plain", plain.Value)
+	assert.False(t, plain.EncryptInDB)
+
+	encrypted := snapshot("cipher text")
+	encrypted.Encrypt = true
+	masked := maskSnapshot(encrypted)
+	assert.Equal(t, apistructs.PipelineCmsConfigMaskedValue, masked.Value)
+	assert.True(t, masked.EncryptInDB)
+}
+
+func TestSealSnapshot(t *testing.T) {
+	publicKey, privateKey, err := encryption.GenRsaKey(2048)
+	assert.NoError(t, err)
+	c := &pipelineCm{rsaCrypt: encryption.NewRSAScrypt(encryption.RSASecret{
+		PublicKey:          base64.StdEncoding.EncodeToString(publicKey),
+		PublicKeyDataType:  encryption.Base64,
+		PrivateKey:         base64.StdEncoding.EncodeToString(privateKey),
+		PrivateKeyDataType: encryption.Base64,
+		PrivateKeyType:     encryption.PKCS1,
+	})}
+
+	// 超过 rsa 单次加密长度的值也可以保存
+	plain := snapshot(strings.Repeat("secret", 1000))
+	sealed, err := c.sealSnapshot(plain)
+	assert.NoError(t, err)
+	assert.True(t, sealed.Sealed)
+	assert.NotContains(t, sealed.Value, "secret")
+	assert.False(t, plain.Sealed)
+
+	opened, err := c.openSnapshot(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, plain, opened)
+
+	// 加密存储的值本身为密文，不再加密
+	encrypted := snapshot("cipher text")
+	encrypted.Encrypt = true
+	sealed, err = c.sealSnapshot(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, encrypted, sealed)
+
+	sealed, err = c.sealSnapshot(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, sealed)
+
+	_, err = c.openSnapshot(&spec.PipelineCmsConfigSnapshot{Value: "invalid", Sealed: true})
+	assert.Error(t, err)
+}
@@ -32,6 +32,12 @@ type ConfigManager interface {
 	DeleteConfigs(ctx context.Context, ns string, keys ...string) error
 	// GetConfigs 获取 ns 下配置。若 ns 不存在，则返回空。若指定 keys，则只获取指定 key 的配置
 	GetConfigs(ctx context.Context, ns string, globalDecrypt bool, keys ...apistructs.PipelineCmsConfigKey) (map[string]apistructs.PipelineCmsConfigValue, error)
+	// ListConfigRevisions 获取 ns 下配置的变更记录，最新的在前。若指定 keys，则只获取指定 key 的变更记录
+	ListConfigRevisions(ctx context.Context, ns string, keys ...string) ([]apistructs.PipelineCmsConfigRevision, error)
+	// DiffConfigRevisions 对比 ns 在两个 revision 时的配置差异，加密存储的值使用掩码
+	DiffConfigRevisions(ctx context.Context, ns string, fromRevision, toRevision uint64) ([]apistructs.PipelineCmsConfigDiff, error)
+	// RollbackConfigs 将 ns 下有变更记录的配置回滚到指定 revision 时的状态，回滚本身也会生成新的 revision
+	RollbackConfigs(ctx context.Context, ns string, revision uint64) error
 }
 
 func transformKeysToStrSlice(keys ...apistructs.PipelineCmsConfigKey) []string {
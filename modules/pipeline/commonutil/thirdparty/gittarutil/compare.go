@@ -0,0 +1,41 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package gittarutil
+
+import (
+	"strconv"
+
+	"github.com/erda-project/erda/pkg/httpclient"
+	"github.com/erda-project/erda/pkg/httpclientutil"
+)
+
+type CompareCommits struct {
+	From         string   `json:"from"`
+	To           string   `json:"to"`
+	Commits      []Commit `json:"commits"`
+	CommitsCount int      `json:"commitsCount"`
+}
+
+// CompareCommits 查询 from 中有而 to 中没有的提交
+func (r *Repo) CompareCommits(from, to string, limit int) (CompareCommits, error) {
+	var result CompareCommits
+	req := httpclient.New().Get(r.GittarAddr).
+		Path("/"+r.Repo+"/compare/"+from+"..."+to+"/commits").
+		Param("limit", strconv.Itoa(limit))
+
+	if err := httpclientutil.DoJson(req, &result); err != nil {
+		return CompareCommits{}, err
+	}
+	return result, nil
+}
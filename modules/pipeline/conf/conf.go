@@ -19,6 +19,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/erda-project/erda/apistructs"
 	"github.com/erda-project/erda/pkg/envconf"
 )
 
@@ -43,6 +44,28 @@ type Conf struct {
 	BuildCacheCleanJobCron string        `env:"BUILD_CACHE_CLEAN_JOB_CRON" default:"0 0 0 * * ?"`
 	BuildCacheExpireIn     time.Duration `env:"BUILD_CACHE_EXPIRE_IN" default:"168h"`
 
+	// action cache
+	ActionCacheCleanJobCron   string `env:"ACTION_CACHE_CLEAN_JOB_CRON" default:"0 30 * * * ?"`
+	ActionCacheProjectQuotaMB int64  `env:"ACTION_CACHE_PROJECT_QUOTA_MB" default:"20480"`
+	// 淘汰网盘缓存时，集群网盘在 pipeline 容器内的挂载目录，格式: cluster1:/netdata/cluster1,cluster2:/netdata/cluster2
+	ActionCacheNFSMountPointsStr string `env:"ACTION_CACHE_NFS_MOUNT_POINTS"`
+	ActionCacheNFSMountPoints    map[string]string
+
+	// archive export
+	ArchiveExportEnabled         bool          `env:"ARCHIVE_EXPORT_ENABLED" default:"false"`
+	ArchiveExportJobCron         string        `env:"ARCHIVE_EXPORT_JOB_CRON" default:"0 0 3 * * ?"`
+	ArchiveExportAfter           time.Duration `env:"ARCHIVE_EXPORT_AFTER" default:"720h"`
+	ArchiveExportSegmentSize     int           `env:"ARCHIVE_EXPORT_SEGMENT_SIZE" default:"500"`
+	ArchiveExportMaxSegments     int           `env:"ARCHIVE_EXPORT_MAX_SEGMENTS_PER_RUN" default:"20"`
+	ArchiveExportStorageType     string        `env:"ARCHIVE_EXPORT_STORAGE_TYPE" default:"fs"`
+	ArchiveExportPath            string        `env:"ARCHIVE_EXPORT_PATH" default:"/netdata/devops/pipeline-archives"`
+	ArchiveExportEndpoint        string        `env:"ARCHIVE_EXPORT_ENDPOINT"`
+	ArchiveExportRegion          string        `env:"ARCHIVE_EXPORT_REGION"`
+	ArchiveExportBucket          string        `env:"ARCHIVE_EXPORT_BUCKET"`
+	ArchiveExportAccessKeyID     string        `env:"ARCHIVE_EXPORT_ACCESS_KEY_ID"`
+	ArchiveExportAccessKeySecret string        `env:"ARCHIVE_EXPORT_ACCESS_KEY_SECRET"`
+	ArchiveExportSecure          bool          `env:"ARCHIVE_EXPORT_SECURE" default:"true"`
+
 	// bundle
 	GittarAddr    string `env:"GITTAR_ADDR" required:"false"`
 	OpenAPIAddr   string `env:"OPENAPI_ADDR" required:"false"`
@@ -87,6 +110,13 @@ type Conf struct {
 
 	// API-Test
 	APITestNetportalAccessK8sNamespaceBlacklist string `env:"APITEST_NETPORTAL_ACCESS_K8S_NAMESPACE_BLACKLIST" default:"default,kube-system"`
+
+	// EnableLocalActionExecutor run actions with commands as host processes, for development and integration test
+	EnableLocalActionExecutor bool `env:"ENABLE_LOCAL_ACTION_EXECUTOR" default:"false"`
+
+	// aop external http plugins, json array of apistructs.PipelineAOPHTTPPlugin
+	AOPHTTPPluginsStr string `env:"AOP_HTTP_PLUGINS"`
+	AOPHTTPPlugins    []apistructs.PipelineAOPHTTPPlugin
 }
 
 var cfg Conf
@@ -97,6 +127,12 @@ func Load() {
 
 	// actionTypeMapping
 	checkActionTypeMapping(&cfg)
+
+	// aop http plugins
+	checkAOPHTTPPlugins(&cfg)
+
+	// action cache nfs mount points
+	checkActionCacheNFSMountPoints(&cfg)
 }
 
 // ListenAddr 返回 pipeline 服务监听地址.
@@ -167,6 +203,86 @@ func BuildCacheExpireIn() time.Duration {
 	return cfg.BuildCacheExpireIn
 }
 
+// ActionCacheCleanJobCron 返回按项目配额淘汰 action 缓存任务的定时配置.
+func ActionCacheCleanJobCron() string {
+	return cfg.ActionCacheCleanJobCron
+}
+
+// ActionCacheProjectQuotaBytes 返回每个项目 action 缓存的总大小上限.
+func ActionCacheProjectQuotaBytes() int64 {
+	return cfg.ActionCacheProjectQuotaMB << 20
+}
+
+// ActionCacheNFSMountPoints 返回集群网盘在 pipeline 容器内的挂载目录，key 为集群名.
+func ActionCacheNFSMountPoints() map[string]string {
+	return cfg.ActionCacheNFSMountPoints
+}
+
+// ArchiveExportEnabled 返回是否开启归档流水线导出到冷存储.
+func ArchiveExportEnabled() bool {
+	return cfg.ArchiveExportEnabled
+}
+
+// ArchiveExportJobCron 返回归档流水线导出任务的定时配置.
+func ArchiveExportJobCron() string {
+	return cfg.ArchiveExportJobCron
+}
+
+// ArchiveExportAfter 返回归档多久之后导出到冷存储.
+func ArchiveExportAfter() time.Duration {
+	return cfg.ArchiveExportAfter
+}
+
+// ArchiveExportSegmentSize 返回每个导出分段包含的流水线个数.
+func ArchiveExportSegmentSize() int {
+	return cfg.ArchiveExportSegmentSize
+}
+
+// ArchiveExportMaxSegmentsPerRun 返回每次导出任务最多导出的分段个数.
+func ArchiveExportMaxSegmentsPerRun() int {
+	return cfg.ArchiveExportMaxSegments
+}
+
+// ArchiveExportStorageType 返回冷存储类型，fs/oss/s3.
+func ArchiveExportStorageType() string {
+	return cfg.ArchiveExportStorageType
+}
+
+// ArchiveExportPath 返回冷存储路径，fs 为目录，oss/s3 为对象 key 前缀.
+func ArchiveExportPath() string {
+	return cfg.ArchiveExportPath
+}
+
+// ArchiveExportEndpoint 返回 oss/s3 的 endpoint.
+func ArchiveExportEndpoint() string {
+	return cfg.ArchiveExportEndpoint
+}
+
+// ArchiveExportRegion 返回 s3 的 region.
+func ArchiveExportRegion() string {
+	return cfg.ArchiveExportRegion
+}
+
+// ArchiveExportBucket 返回 oss/s3 的 bucket.
+func ArchiveExportBucket() string {
+	return cfg.ArchiveExportBucket
+}
+
+// ArchiveExportAccessKeyID 返回 oss/s3 的 access key id.
+func ArchiveExportAccessKeyID() string {
+	return cfg.ArchiveExportAccessKeyID
+}
+
+// ArchiveExportAccessKeySecret 返回 oss/s3 的 access key secret.
+func ArchiveExportAccessKeySecret() string {
+	return cfg.ArchiveExportAccessKeySecret
+}
+
+// ArchiveExportSecure 返回 s3 是否使用 https.
+func ArchiveExportSecure() bool {
+	return cfg.ArchiveExportSecure
+}
+
 // GittarAddr 返回 gittar 的集群内部地址.
 func GittarAddr() string {
 	return cfg.GittarAddr
@@ -276,3 +392,13 @@ func QueueLoopHandleIntervalSec() uint64 {
 func APITestNetportalAccessK8sNamespaceBlacklist() []string {
 	return strings.Split(cfg.APITestNetportalAccessK8sNamespaceBlacklist, ",")
 }
+
+// EnableLocalActionExecutor 返回是否使用 local executor 以宿主机进程运行有 commands 的 action.
+func EnableLocalActionExecutor() bool {
+	return cfg.EnableLocalActionExecutor
+}
+
+// AOPHTTPPlugins 返回通过配置注册的 AOP 外部插件.
+func AOPHTTPPlugins() []apistructs.PipelineAOPHTTPPlugin {
+	return cfg.AOPHTTPPlugins
+}
@@ -16,6 +16,8 @@
 package conf
 
 import (
+	"encoding/json"
+
 	"github.com/sirupsen/logrus"
 
 	"github.com/erda-project/erda/pkg/strutil"
@@ -33,3 +35,24 @@ func checkActionTypeMapping(cfg *Conf) {
 		cfg.ActionTypeMapping[vv[0]] = vv[1]
 	}
 }
+
+func checkAOPHTTPPlugins(cfg *Conf) {
+	if cfg.AOPHTTPPluginsStr == "" {
+		return
+	}
+	if err := json.Unmarshal([]byte(cfg.AOPHTTPPluginsStr), &cfg.AOPHTTPPlugins); err != nil {
+		logrus.Errorf("[alert] invalid aop http plugins: %q, err: %v", cfg.AOPHTTPPluginsStr, err)
+	}
+}
+
+func checkActionCacheNFSMountPoints(cfg *Conf) {
+	cfg.ActionCacheNFSMountPoints = make(map[string]string)
+	for _, v := range strutil.Split(cfg.ActionCacheNFSMountPointsStr, ",", true) {
+		vv := strutil.Split(v, ":", true)
+		if len(vv) != 2 {
+			logrus.Errorf("[alert] invalid action cache nfs mount point: %q", v)
+			continue
+		}
+		cfg.ActionCacheNFSMountPoints[vv[0]] = vv[1]
+	}
+}
@@ -210,10 +210,23 @@ func (client *Client) UpdateWholeStatusCancel(p *spec.Pipeline, ops ...SessionOp
 		err = errors.Wrap(err, "failed to update whole pipeline status to stopByUser")
 	}()
 
+	return client.updateWholeStatusEnd(p, apistructs.PipelineStatusStopByUser, ops...)
+}
+
+// UpdateWholeStatusTimeout 将流水线及执行中的任务更新为超时，未开始的任务更新为无需执行
+func (client *Client) UpdateWholeStatusTimeout(p *spec.Pipeline, ops ...SessionOption) (err error) {
+	defer func() {
+		err = errors.Wrap(err, "failed to update whole pipeline status to timeout")
+	}()
+
+	return client.updateWholeStatusEnd(p, apistructs.PipelineStatusTimeout, ops...)
+}
+
+func (client *Client) updateWholeStatusEnd(p *spec.Pipeline, endStatus apistructs.PipelineStatus, ops ...SessionOption) (err error) {
 	session := client.NewSession(ops...)
 	defer session.Close()
 
-	cancelTime := time.Now()
+	endTime := time.Now()
 
 	stages, err := client.ListPipelineStageByPipelineID(p.ID)
 	if err != nil {
@@ -231,11 +244,11 @@ func (client *Client) UpdateWholeStatusCancel(p *spec.Pipeline, ops ...SessionOp
 			if task.Status == apistructs.PipelineStatusDisabled {
 				continue
 			}
-			task.Status = apistructs.PipelineStatusStopByUser
-			task.TimeEnd = cancelTime
+			task.Status = endStatus
+			task.TimeEnd = endTime
 			if task.TimeBegin.IsZero() {
 				task.Status = apistructs.PipelineStatusNoNeedBySystem
-				task.TimeBegin = cancelTime
+				task.TimeBegin = endTime
 			}
 			task.CostTimeSec = costtimeutil.CalculateTaskCostTimeSec(task)
 			if err = client.UpdatePipelineTask(task.ID, task); err != nil {
@@ -245,11 +258,11 @@ func (client *Client) UpdateWholeStatusCancel(p *spec.Pipeline, ops ...SessionOp
 	}
 
 	if !p.Status.IsEndStatus() {
-		p.Status = apistructs.PipelineStatusStopByUser
-		p.TimeEnd = &cancelTime
+		p.Status = endStatus
+		p.TimeEnd = &endTime
 		if p.IsSnippet && (p.TimeBegin == nil || p.TimeBegin.IsZero()) {
 			p.Status = apistructs.PipelineStatusNoNeedBySystem
-			p.TimeBegin = &cancelTime
+			p.TimeBegin = &endTime
 		}
 		p.CostTimeSec = costtimeutil.CalculatePipelineCostTimeSec(p)
 		if err = client.UpdatePipelineBase(p.ID, &p.PipelineBase, ops...); err != nil {
@@ -0,0 +1,157 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package dbclient
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+// UpsertActionCache 保存缓存文件记录，location 已存在时更新大小与访问时间
+func (client *Client) UpsertActionCache(cache *spec.PipelineActionCache, ops ...SessionOption) error {
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	var exist spec.PipelineActionCache
+	ok, err := session.Where("cluster_name = ? AND location = ?", cache.ClusterName, cache.Location).Get(&exist)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		_, err = session.InsertOne(cache)
+		return err
+	}
+	cache.ID = exist.ID
+	cache.HitCount = exist.HitCount
+	_, err = session.ID(exist.ID).Cols("project_id", "app_id", "storage_type", "cache_key", "cache_path", "size_bytes", "last_accessed_at").
+		Update(cache)
+	return err
+}
+
+// TouchActionCache 缓存被命中时增加命中次数并更新访问时间
+func (client *Client) TouchActionCache(clusterName, location string, accessedAt time.Time, ops ...SessionOption) error {
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	_, err := session.Where("cluster_name = ? AND location = ?", clusterName, location).
+		Incr("hit_count").Cols("last_accessed_at").
+		Update(&spec.PipelineActionCache{LastAccessedAt: accessedAt})
+	return err
+}
+
+// ListActionCachesByProject 按访问时间从旧到新列出项目下的缓存
+func (client *Client) ListActionCachesByProject(projectID uint64, ops ...SessionOption) ([]spec.PipelineActionCache, error) {
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	var caches []spec.PipelineActionCache
+	if err := session.Where("project_id = ?", projectID).Asc("last_accessed_at", "id").Find(&caches); err != nil {
+		return nil, err
+	}
+	return caches, nil
+}
+
+// ListActionCacheProjectsOverQuota 列出缓存总大小超过 quota 的项目及其缓存大小
+func (client *Client) ListActionCacheProjectsOverQuota(quotaBytes int64, ops ...SessionOption) (map[uint64]int64, error) {
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	type projectSize struct {
+		ProjectID uint64
+		Size      int64
+	}
+	var sizes []projectSize
+	err := session.Table(spec.PipelineActionCache{}.TableName()).
+		Select("project_id, SUM(size_bytes) AS size").
+		GroupBy("project_id").Having(fmt.Sprintf("SUM(size_bytes) > %d", quotaBytes)).
+		Find(&sizes)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint64]int64, len(sizes))
+	for _, s := range sizes {
+		result[s.ProjectID] = s.Size
+	}
+	return result, nil
+}
+
+// SumActionCacheSize 返回项目下的缓存数量与总大小
+func (client *Client) SumActionCacheSize(projectID uint64, ops ...SessionOption) (uint64, int64, error) {
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	cache := spec.PipelineActionCache{}
+	count, err := session.Where("project_id = ?", projectID).Count(&cache)
+	if err != nil {
+		return 0, 0, err
+	}
+	size, err := session.Where("project_id = ?", projectID).SumInt(&cache, "size_bytes")
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint64(count), size, nil
+}
+
+func (client *Client) DeleteActionCache(id uint64, ops ...SessionOption) error {
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	_, err := session.ID(id).Delete(&spec.PipelineActionCache{})
+	return err
+}
+
+// IncrActionCacheStat 根据缓存恢复结果累加项目的命中统计
+func (client *Client) IncrActionCacheStat(projectID uint64, result apistructs.PipelineActionCacheRestoreResult, ops ...SessionOption) error {
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	var col string
+	switch result {
+	case apistructs.PipelineActionCacheRestoreHit:
+		col = "hits"
+	case apistructs.PipelineActionCacheRestorePartialHit:
+		col = "partial_hits"
+	default:
+		col = "misses"
+	}
+
+	var stat spec.PipelineActionCacheStat
+	ok, err := session.Where("project_id = ?", projectID).Get(&stat)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		stat.ProjectID = projectID
+		if _, err := session.InsertOne(&stat); err != nil {
+			return err
+		}
+	}
+	_, err = session.ID(stat.ID).Incr(col).Update(&spec.PipelineActionCacheStat{})
+	return err
+}
+
+// GetActionCacheStat 获取项目的命中统计，不存在时返回空统计
+func (client *Client) GetActionCacheStat(projectID uint64, ops ...SessionOption) (spec.PipelineActionCacheStat, error) {
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	stat := spec.PipelineActionCacheStat{ProjectID: projectID}
+	if _, err := session.Where("project_id = ?", projectID).Get(&stat); err != nil {
+		return spec.PipelineActionCacheStat{}, err
+	}
+	return stat, nil
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package dbclient
+
+import (
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+// UpsertAOPHTTPPlugin 保存外部插件，同名插件已存在时覆盖
+func (client *Client) UpsertAOPHTTPPlugin(plugin *spec.PipelineAOPHTTPPlugin, ops ...SessionOption) error {
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	var exist spec.PipelineAOPHTTPPlugin
+	ok, err := session.Where("name = ?", plugin.Name).Get(&exist)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		_, err = session.InsertOne(plugin)
+		return err
+	}
+	plugin.ID = exist.ID
+	_, err = session.ID(exist.ID).Cols("type", "trigger", "url", "timeout_sec", "failure_policy", "headers").
+		Update(plugin)
+	return err
+}
+
+// DeleteAOPHTTPPlugin 删除外部插件，返回插件是否存在
+func (client *Client) DeleteAOPHTTPPlugin(name string, ops ...SessionOption) (bool, error) {
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	affected, err := session.Where("name = ?", name).Delete(&spec.PipelineAOPHTTPPlugin{})
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (client *Client) ListAOPHTTPPlugins(ops ...SessionOption) ([]spec.PipelineAOPHTTPPlugin, error) {
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	var plugins []spec.PipelineAOPHTTPPlugin
+	if err := session.Asc("name").Find(&plugins); err != nil {
+		return nil, err
+	}
+	return plugins, nil
+}
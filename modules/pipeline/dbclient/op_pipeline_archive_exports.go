@@ -0,0 +1,69 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package dbclient
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+// ListPipelineArchivesBefore 按 ID 顺序查询 before 之前归档的记录
+func (client *Client) ListPipelineArchivesBefore(before time.Time, limit int, ops ...SessionOption) ([]spec.PipelineArchive, error) {
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	var archives []spec.PipelineArchive
+	if err := session.Where("time_created < ?", before).Asc("id").Limit(limit).Find(&archives); err != nil {
+		return nil, err
+	}
+	return archives, nil
+}
+
+// ConfirmPipelineArchivesExported 在同一事务中写入导出索引并删除已导出的归档记录
+func (client *Client) ConfirmPipelineArchivesExported(exports []spec.PipelineArchiveExport, archiveIDs []uint64) (err error) {
+	txSession := client.NewSession()
+	defer txSession.Close()
+	if err := txSession.Begin(); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := txSession.Rollback(); rbErr != nil {
+				logrus.Errorf("[alert] failed to rollback when confirm pipeline archives exported failed, rollbackErr: %v", rbErr)
+			}
+			return
+		}
+		err = txSession.Commit()
+	}()
+
+	if _, err := txSession.Insert(&exports); err != nil {
+		return err
+	}
+	if _, err := txSession.In("id", archiveIDs).Delete(&spec.PipelineArchive{}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (client *Client) GetPipelineArchiveExportByPipelineID(pipelineID uint64, ops ...SessionOption) (spec.PipelineArchiveExport, bool, error) {
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	export := spec.PipelineArchiveExport{PipelineID: pipelineID}
+	exist, err := session.Get(&export)
+	return export, exist, err
+}
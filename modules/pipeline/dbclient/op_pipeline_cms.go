@@ -154,3 +154,63 @@ func (client *Client) GetCmsNsConfigs(cmsNs spec.PipelineCmsNs, keys []string, o
 	}
 	return configs, nil
 }
+
+// LockCmsNs 在事务中锁定 ns，同一 ns 下的变更串行执行，保证 revision 不重复
+func (client *Client) LockCmsNs(nsID uint64, ops ...SessionOption) error {
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	var cmsNs spec.PipelineCmsNs
+	exist, err := session.ID(nsID).ForUpdate().Get(&cmsNs)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return errors.Errorf("cms ns not found, id: %d", nsID)
+	}
+	return nil
+}
+
+// GetCmsNsLatestRevision 获取 ns 当前最新的 revision，无变更记录时返回 0
+func (client *Client) GetCmsNsLatestRevision(nsID uint64, ops ...SessionOption) (uint64, error) {
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	var revision spec.PipelineCmsConfigRevision
+	exist, err := session.Where("ns_id = ?", nsID).Desc("revision").Limit(1).Get(&revision)
+	if err != nil {
+		return 0, err
+	}
+	if !exist {
+		return 0, nil
+	}
+	return revision.Revision, nil
+}
+
+func (client *Client) CreateCmsNsConfigRevisions(revisions []spec.PipelineCmsConfigRevision, ops ...SessionOption) error {
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	for i := range revisions {
+		if _, err := session.InsertOne(&revisions[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListCmsNsConfigRevisions 按 revision 正序返回变更记录。若指定 keys，则只获取指定 key 的变更记录
+func (client *Client) ListCmsNsConfigRevisions(nsID uint64, keys []string, ops ...SessionOption) ([]spec.PipelineCmsConfigRevision, error) {
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	var revisions []spec.PipelineCmsConfigRevision
+	session.Where("ns_id = ?", nsID)
+	if len(keys) > 0 {
+		session.In("config_key", keys)
+	}
+	if err := session.Asc("revision", "id").Find(&revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
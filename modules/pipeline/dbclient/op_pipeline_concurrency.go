@@ -0,0 +1,70 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package dbclient
+
+import (
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+// concurrencyGroupScopeLabels 并发组在同一 pipelineSource 下按应用、项目隔离
+var concurrencyGroupScopeLabels = []string{apistructs.LabelPipelineConcurrencyGroup, apistructs.LabelAppID, apistructs.LabelProjectID}
+
+// ListConcurrencyGroupRunningPipelineIDs 查询与 p 处于同一并发组的其他运行中流水线
+func (client *Client) ListConcurrencyGroupRunningPipelineIDs(p *spec.Pipeline, ops ...SessionOption) ([]uint64, error) {
+	if p.Labels[apistructs.LabelPipelineConcurrencyGroup] == "" {
+		return nil, nil
+	}
+
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	// 运行中的流水线数量有限，先查运行中的流水线，再根据标签过滤
+	var runningPipelineIDs []uint64
+	if err := session.Table(&spec.PipelineBase{}).Select("id").
+		In("status", apistructs.ReconcilerRunningStatuses()).
+		Where("pipeline_source = ?", p.PipelineSource).
+		Where("is_snippet = ?", false).
+		Where("id != ?", p.ID).
+		Find(&runningPipelineIDs); err != nil {
+		return nil, err
+	}
+	if len(runningPipelineIDs) == 0 {
+		return nil, nil
+	}
+	labelsMap, err := client.ListPipelineLabelsByTypeAndTargetIDs(apistructs.PipelineLabelTypeInstance, runningPipelineIDs, ops...)
+	if err != nil {
+		return nil, err
+	}
+	var result []uint64
+	for _, id := range runningPipelineIDs {
+		if inSameConcurrencyGroup(p.Labels, labelsMap[id]) {
+			result = append(result, id)
+		}
+	}
+	return result, nil
+}
+
+func inSameConcurrencyGroup(labels map[string]string, otherLabels []spec.PipelineLabel) bool {
+	others := make(map[string]string, len(otherLabels))
+	for _, label := range otherLabels {
+		others[label.Key] = label.Value
+	}
+	for _, key := range concurrencyGroupScopeLabels {
+		if labels[key] != others[key] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package dbclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+func TestInSameConcurrencyGroup(t *testing.T) {
+	labels := map[string]string{
+		apistructs.LabelPipelineConcurrencyGroup: "master",
+		apistructs.LabelAppID:                    "1",
+		apistructs.LabelProjectID:                "2",
+		apistructs.LabelBranch:                   "master",
+	}
+	makeLabels := func(group, appID string) []spec.PipelineLabel {
+		return []spec.PipelineLabel{
+			{Key: apistructs.LabelPipelineConcurrencyGroup, Value: group},
+			{Key: apistructs.LabelAppID, Value: appID},
+			{Key: apistructs.LabelProjectID, Value: "2"},
+			{Key: apistructs.LabelBranch, Value: "develop"},
+		}
+	}
+	assert.True(t, inSameConcurrencyGroup(labels, makeLabels("master", "1")))
+	assert.False(t, inSameConcurrencyGroup(labels, makeLabels("develop", "1")))
+	assert.False(t, inSameConcurrencyGroup(labels, makeLabels("master", "3")))
+	assert.False(t, inSameConcurrencyGroup(labels, nil))
+}
@@ -16,6 +16,7 @@ package dbclient
 import (
 	"github.com/mitchellh/mapstructure"
 
+	"github.com/erda-project/erda/modules/pipeline/conf"
 	"github.com/erda-project/erda/modules/pipeline/spec"
 )
 
@@ -29,12 +30,26 @@ var defaultAPITestActionExecutor = spec.PipelineConfig{
 	},
 }
 
+// defaultLocalActionExecutor provide local action-executor, only added when enabled
+var defaultLocalActionExecutor = spec.PipelineConfig{
+	Type: spec.PipelineConfigTypeActionExecutor,
+	Value: spec.ActionExecutorConfig{
+		Kind:    string(spec.PipelineTaskExecutorKindLocal),
+		Name:    spec.PipelineTaskExecutorNameLocalDefault,
+		Options: nil,
+	},
+}
+
 func (client *Client) ListPipelineConfigsOfActionExecutor() (configs []spec.PipelineConfig, cfgChan chan spec.ActionExecutorConfig, err error) {
 	if err := client.Find(&configs, spec.PipelineConfig{Type: spec.PipelineConfigTypeActionExecutor}); err != nil {
 		return nil, nil, err
 	}
 	// add default api-test action executor
 	configs = append(configs, defaultAPITestActionExecutor)
+	// add default local action executor if enabled
+	if conf.EnableLocalActionExecutor() {
+		configs = append(configs, defaultLocalActionExecutor)
+	}
 	cfgChan = make(chan spec.ActionExecutorConfig, 100)
 	for _, c := range configs {
 		var r spec.ActionExecutorConfig
@@ -0,0 +1,63 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package dbclient
+
+import (
+	"time"
+
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+func (client *Client) CreatePipelineStatusStreamEvent(e *spec.PipelineStatusStreamEvent, ops ...SessionOption) error {
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	_, err := session.InsertOne(e)
+	return err
+}
+
+// ListPipelineStatusStreamEvents 按 ID 升序返回 afterID 之后的事件
+func (client *Client) ListPipelineStatusStreamEvents(afterID uint64, limit int, ops ...SessionOption) ([]spec.PipelineStatusStreamEvent, error) {
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	var events []spec.PipelineStatusStreamEvent
+	if err := session.Where("id > ?", afterID).Asc("id").Limit(limit).Find(&events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetPipelineStatusStreamEventIDRange 返回当前保留的最小与最大事件 ID，没有事件时均为 0
+func (client *Client) GetPipelineStatusStreamEventIDRange(ops ...SessionOption) (minID, maxID uint64, err error) {
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	var first, last spec.PipelineStatusStreamEvent
+	if _, err := session.Cols("id").Asc("id").Limit(1).Get(&first); err != nil {
+		return 0, 0, err
+	}
+	if _, err := session.Cols("id").Desc("id").Limit(1).Get(&last); err != nil {
+		return 0, 0, err
+	}
+	return first.ID, last.ID, nil
+}
+
+// DeletePipelineStatusStreamEventsBefore 删除 before 之前创建的事件
+func (client *Client) DeletePipelineStatusStreamEventsBefore(before time.Time, ops ...SessionOption) (int64, error) {
+	session := client.NewSession(ops...)
+	defer session.Close()
+
+	return session.Where("time_created < ?", before).Delete(&spec.PipelineStatusStreamEvent{})
+}
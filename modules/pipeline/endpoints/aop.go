@@ -0,0 +1,89 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/aop"
+	"github.com/erda-project/erda/modules/pipeline/services/apierrors"
+	"github.com/erda-project/erda/modules/pkg/user"
+	"github.com/erda-project/erda/pkg/httpserver"
+	"github.com/erda-project/erda/pkg/httpserver/errorresp"
+)
+
+func (e *Endpoints) registerAOPHTTPPlugin(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	// validate request
+	if r.ContentLength == 0 {
+		return apierrors.ErrRegisterAOPHTTPPlugin.MissingParameter("request body").ToResp(), nil
+	}
+
+	// decode request
+	var req apistructs.PipelineAOPHTTPPlugin
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierrors.ErrRegisterAOPHTTPPlugin.InvalidParameter(fmt.Errorf("failed to unmarshal request body, err: %v", err)).ToResp(), nil
+	}
+
+	// check authentication
+	identityInfo, err := user.GetIdentityInfo(r)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+	if !identityInfo.IsInternalClient() {
+		return apierrors.ErrRegisterAOPHTTPPlugin.AccessDenied().ToResp(), nil
+	}
+
+	// do register
+	plugin, err := aop.RegisterHTTPPlugin(req)
+	if err != nil {
+		return apierrors.ErrRegisterAOPHTTPPlugin.InvalidParameter(err).ToResp(), nil
+	}
+
+	return httpserver.OkResp(plugin)
+}
+
+func (e *Endpoints) listAOPHTTPPlugins(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	// check authentication
+	identityInfo, err := user.GetIdentityInfo(r)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+	if !identityInfo.IsInternalClient() {
+		return apierrors.ErrListAOPHTTPPlugins.AccessDenied().ToResp(), nil
+	}
+
+	return httpserver.OkResp(aop.ListHTTPPlugins())
+}
+
+func (e *Endpoints) unregisterAOPHTTPPlugin(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	// check authentication
+	identityInfo, err := user.GetIdentityInfo(r)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+	if !identityInfo.IsInternalClient() {
+		return apierrors.ErrUnregisterAOPHTTPPlugin.AccessDenied().ToResp(), nil
+	}
+
+	// do unregister
+	if err := aop.UnregisterHTTPPlugin(vars[pathAOPPluginName]); err != nil {
+		return apierrors.ErrUnregisterAOPHTTPPlugin.NotFound().ToResp(), nil
+	}
+
+	return httpserver.OkResp(nil)
+}
@@ -44,3 +44,22 @@ func (e *Endpoints) reportBuildCache(ctx context.Context, r *http.Request, vars
 
 	return httpserver.OkResp(nil)
 }
+
+func (e *Endpoints) getActionCacheStats(ctx context.Context, r *http.Request, vars map[string]string) (
+	httpserver.Responser, error) {
+
+	var req apistructs.PipelineActionCacheStatsRequest
+	if err := e.queryStringDecoder.Decode(&req, r.URL.Query()); err != nil {
+		return apierrors.ErrGetActionCacheStats.InvalidParameter(err).ToResp(), nil
+	}
+	if req.ProjectID == 0 {
+		return apierrors.ErrGetActionCacheStats.MissingParameter("projectID").ToResp(), nil
+	}
+
+	stats, err := e.buildCacheSvc.GetActionCacheStats(req.ProjectID)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+
+	return httpserver.OkResp(stats)
+}
@@ -108,7 +108,7 @@ func (e *Endpoints) updateCmsNsConfigs(ctx context.Context, r *http.Request, var
 		}
 	}
 
-	if err := e.cmSvc.UpdateConfigs(req.PipelineSource, ns, req.KVs); err != nil {
+	if err := e.cmSvc.UpdateConfigs(req.PipelineSource, ns, req.KVs, getCmsOperator(identityInfo)); err != nil {
 		return errorresp.ErrResp(err)
 	}
 
@@ -140,7 +140,7 @@ func (e *Endpoints) deleteCmsNsConfigs(ctx context.Context, r *http.Request, var
 	if req.DeleteNS {
 		opErr = e.cmSvc.DeleteNS(req.PipelineSource, ns)
 	} else {
-		opErr = e.cmSvc.DeleteConfigs(req.PipelineSource, ns, req.DeleteKeys, req.DeleteForce)
+		opErr = e.cmSvc.DeleteConfigs(req.PipelineSource, ns, req.DeleteKeys, req.DeleteForce, getCmsOperator(identityInfo))
 	}
 
 	if opErr != nil {
@@ -201,3 +201,95 @@ func (e *Endpoints) listCmsNs(ctx context.Context, r *http.Request, vars map[str
 	}
 	return httpserver.OkResp(namespaces)
 }
+
+func (e *Endpoints) listCmsNsConfigRevisions(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	// 鉴权
+	identityInfo, err := user.GetIdentityInfo(r)
+	if err != nil {
+		return apierrors.ErrListPipelineCmsConfigRevisions.NotLogin().ToResp(), nil
+	}
+	// 只允许内部调用
+	if !identityInfo.IsInternalClient() {
+		return apierrors.ErrListPipelineCmsConfigRevisions.AccessDenied().ToResp(), nil
+	}
+
+	// 参数解析
+	// ns
+	ns := vars[pathNs]
+	// req
+	var req apistructs.PipelineCmsListConfigRevisionsRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return apierrors.ErrListPipelineCmsConfigRevisions.InvalidParameter(err).ToResp(), nil
+		}
+	}
+
+	revisions, err := e.cmSvc.ListConfigRevisions(req.PipelineSource, ns, req.Keys...)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+	return httpserver.OkResp(revisions)
+}
+
+func (e *Endpoints) diffCmsNsConfigRevisions(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	// 鉴权
+	identityInfo, err := user.GetIdentityInfo(r)
+	if err != nil {
+		return apierrors.ErrDiffPipelineCmsConfigRevisions.NotLogin().ToResp(), nil
+	}
+	// 只允许内部调用
+	if !identityInfo.IsInternalClient() {
+		return apierrors.ErrDiffPipelineCmsConfigRevisions.AccessDenied().ToResp(), nil
+	}
+
+	// 参数解析
+	// ns
+	ns := vars[pathNs]
+	// req
+	var req apistructs.PipelineCmsDiffConfigRevisionsRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return apierrors.ErrDiffPipelineCmsConfigRevisions.InvalidParameter(err).ToResp(), nil
+		}
+	}
+
+	diffs, err := e.cmSvc.DiffConfigRevisions(req.PipelineSource, ns, req.FromRevision, req.ToRevision)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+	return httpserver.OkResp(diffs)
+}
+
+func (e *Endpoints) rollbackCmsNsConfigs(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+	// 鉴权
+	identityInfo, err := user.GetIdentityInfo(r)
+	if err != nil {
+		return apierrors.ErrRollbackPipelineCmsConfigs.NotLogin().ToResp(), nil
+	}
+	// 只允许内部调用
+	if !identityInfo.IsInternalClient() {
+		return apierrors.ErrRollbackPipelineCmsConfigs.AccessDenied().ToResp(), nil
+	}
+
+	// 参数解析
+	// ns
+	ns := vars[pathNs]
+	// req
+	var req apistructs.PipelineCmsRollbackConfigsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierrors.ErrRollbackPipelineCmsConfigs.InvalidParameter(err).ToResp(), nil
+	}
+
+	if err := e.cmSvc.RollbackConfigs(req.PipelineSource, ns, req.Revision, getCmsOperator(identityInfo)); err != nil {
+		return errorresp.ErrResp(err)
+	}
+	return httpserver.OkResp(nil)
+}
+
+// getCmsOperator 变更记录中的操作人，优先使用用户 ID，否则使用内部调用方
+func getCmsOperator(identityInfo apistructs.IdentityInfo) string {
+	if identityInfo.UserID != "" {
+		return identityInfo.UserID
+	}
+	return identityInfo.InternalClient
+}
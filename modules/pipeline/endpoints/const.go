@@ -39,4 +39,5 @@ const (
 	pathTaskID        = "taskID"
 	pathNs            = "ns"
 	pathQueueID       = "queueID"
+	pathAOPPluginName = "pluginName"
 )
@@ -198,12 +198,20 @@ func (e *Endpoints) Routes() []httpserver.Endpoint {
 		{Path: "/api/pipelines/cms/ns/{ns}", Method: http.MethodPost, Handler: e.updateCmsNsConfigs},
 		{Path: "/api/pipelines/cms/ns/{ns}", Method: http.MethodDelete, Handler: e.deleteCmsNsConfigs},
 		{Path: "/api/pipelines/cms/ns/{ns}", Method: http.MethodGet, Handler: e.getCmsNsConfigs},
+		{Path: "/api/pipelines/cms/ns/{ns}/revisions", Method: http.MethodGet, Handler: e.listCmsNsConfigRevisions},
+		{Path: "/api/pipelines/cms/ns/{ns}/revisions/actions/diff", Method: http.MethodGet, Handler: e.diffCmsNsConfigRevisions},
+		{Path: "/api/pipelines/cms/ns/{ns}/actions/rollback", Method: http.MethodPost, Handler: e.rollbackCmsNsConfigs},
 
 		// pipeline related actions
 		{Path: "/api/pipelines/actions/batch-create", Method: http.MethodPost, Handler: e.pipelineBatchCreate},
 		{Path: "/api/pipelines/actions/pipeline-yml-graph", Method: http.MethodPost, Handler: e.pipelineYmlGraph},
+		{Path: "/api/pipelines/actions/pipeline-yml-lint", Method: http.MethodPost, Handler: e.pipelineYmlLint},
+		{Path: "/api/pipelines/actions/pipeline-yml-schema", Method: http.MethodGet, Handler: e.pipelineYmlSchema},
+		{Path: "/api/pipelines/actions/dry-run", Method: http.MethodPost, Handler: e.pipelineDryRun},
+		{Path: "/api/pipelines/actions/compare", Method: http.MethodGet, Handler: e.pipelineCompare},
 		{Path: "/api/pipelines/actions/statistics", Method: http.MethodGet, Handler: e.pipelineStatistic},
 		{Path: "/api/pipelines/actions/task-view", Method: http.MethodGet, Handler: e.pipelineTaskView},
+		{Path: "/api/pipelines/actions/status-stream", Method: http.MethodGet, WriterHandler: e.pipelineStatusStream},
 
 		// pipeline cron
 		{Path: "/api/pipeline-crons", Method: http.MethodGet, Handler: e.pipelineCronPaging},
@@ -220,12 +228,18 @@ func (e *Endpoints) Routes() []httpserver.Endpoint {
 		{Path: "/api/pipeline-queues/{queueID}", Method: http.MethodPut, Handler: e.updatePipelineQueue},
 		{Path: "/api/pipeline-queues/{queueID}", Method: http.MethodDelete, Handler: e.deletePipelineQueue},
 
+		// aop external http plugins
+		{Path: "/api/pipeline-aop-http-plugins", Method: http.MethodPost, Handler: e.registerAOPHTTPPlugin},
+		{Path: "/api/pipeline-aop-http-plugins", Method: http.MethodGet, Handler: e.listAOPHTTPPlugins},
+		{Path: "/api/pipeline-aop-http-plugins/{pluginName}", Method: http.MethodDelete, Handler: e.unregisterAOPHTTPPlugin},
+
 		// build artifact
 		{Path: "/api/build-artifacts/{sha}", Method: http.MethodGet, Handler: e.queryBuildArtifact},
 		{Path: "/api/build-artifacts", Method: http.MethodPost, Handler: e.registerBuildArtifact},
 
 		// build cache
 		{Path: "/api/build-caches", Method: http.MethodPost, Handler: e.reportBuildCache},
+		{Path: "/api/build-caches/actions/stats", Method: http.MethodGet, Handler: e.getActionCacheStats},
 
 		// platform callback
 		{Path: "/api/pipelines/actions/callback", Method: http.MethodPost, Handler: e.pipelineCallback},
@@ -241,5 +255,7 @@ func (e *Endpoints) Routes() []httpserver.Endpoint {
 		// reports
 		{Path: "/api/pipeline-reportsets/{pipelineID}", Method: http.MethodGet, Handler: e.queryPipelineReportSet},
 		{Path: "/api/pipeline-reportsets", Method: http.MethodGet, Handler: e.pagingPipelineReportSets},
+		{Path: "/api/pipeline-reports/coverage/trend", Method: http.MethodGet, Handler: e.queryCoverageTrend},
+		{Path: "/api/pipeline-reports/coverage/delta", Method: http.MethodGet, Handler: e.queryCoverageDelta},
 	}
 }
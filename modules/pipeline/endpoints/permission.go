@@ -20,6 +20,7 @@ import (
 
 	"github.com/erda-project/erda/apistructs"
 	"github.com/erda-project/erda/modules/pipeline/services/apierrors"
+	"github.com/erda-project/erda/modules/pipeline/services/cmsvc"
 	"github.com/erda-project/erda/modules/pkg/user"
 )
 
@@ -43,6 +44,29 @@ func (e *Endpoints) checkAppPermission(r *http.Request, appID uint64, permission
 	return e.permissionSvc.CheckApp(identityInfo, appID, permissionAction)
 }
 
+// checkConfigManageNamespacesPermission 校验用户是否有权访问所有配置管理命名空间，
+// 非应用级别的命名空间只允许内部调用访问
+func (e *Endpoints) checkConfigManageNamespacesPermission(identityInfo apistructs.IdentityInfo, namespaces []string, permissionAction string) error {
+	if identityInfo.IsInternalClient() {
+		return nil
+	}
+	checkedApps := make(map[uint64]struct{})
+	for _, ns := range namespaces {
+		appID, ok := cmsvc.ParseAppIDFromSecretNamespace(ns)
+		if !ok {
+			return apierrors.ErrCheckPermission.AccessDenied()
+		}
+		if _, checked := checkedApps[appID]; checked {
+			continue
+		}
+		if err := e.permissionSvc.CheckApp(identityInfo, appID, permissionAction); err != nil {
+			return err
+		}
+		checkedApps[appID] = struct{}{}
+	}
+	return nil
+}
+
 // CheckBranch 方便用户直接使用分支进行鉴权
 func (e *Endpoints) checkBranchPermission(r *http.Request, appIDStr, branch string, permissionAction string) error {
 	identityInfo, err := user.GetIdentityInfo(r)
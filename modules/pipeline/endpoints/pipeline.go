@@ -351,6 +351,117 @@ func (e *Endpoints) pipelineYmlGraph(ctx context.Context, r *http.Request, vars
 	return httpserver.OkResp(graph)
 }
 
+// pipelineYmlLint 校验 pipeline.yml，返回带位置信息的诊断结果
+func (e *Endpoints) pipelineYmlLint(ctx context.Context, r *http.Request, vars map[string]string) (
+	httpserver.Responser, error) {
+
+	var req apistructs.PipelineYmlLintRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierrors.ErrLintPipelineYml.InvalidParameter("request body").ToResp(), nil
+	}
+
+	result, err := e.pipelineSvc.LintPipelineYml(&req)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+
+	return httpserver.OkResp(result)
+}
+
+// pipelineYmlSchema 返回 pipeline.yml 的 JSON Schema，包含扩展市场中 action 的 params 定义
+func (e *Endpoints) pipelineYmlSchema(ctx context.Context, r *http.Request, vars map[string]string) (
+	httpserver.Responser, error) {
+
+	schema, err := e.pipelineSvc.PipelineYmlSchema()
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+
+	return httpserver.OkResp(schema)
+}
+
+// pipelineCompare 对比两次流水线运行
+func (e *Endpoints) pipelineCompare(ctx context.Context, r *http.Request, vars map[string]string) (
+	httpserver.Responser, error) {
+
+	var req apistructs.PipelineCompareRequest
+	for _, param := range []struct {
+		name string
+		id   *uint64
+	}{
+		{"basePipelineID", &req.BasePipelineID},
+		{"targetPipelineID", &req.TargetPipelineID},
+	} {
+		name, id := param.name, param.id
+		v := r.URL.Query().Get(name)
+		if v == "" {
+			return apierrors.ErrComparePipeline.MissingParameter(name).ToResp(), nil
+		}
+		parsed, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return apierrors.ErrComparePipeline.InvalidParameter(strutil.Concat(name, ": ", v)).ToResp(), nil
+		}
+		*id = parsed
+	}
+
+	// 身份校验
+	if _, err := user.GetIdentityInfo(r); err != nil {
+		return errorresp.ErrResp(err)
+	}
+
+	// 只能对比同一应用下的流水线，并校验用户在两条流水线对应分支下均有 GET 权限
+	var appIDs []string
+	for _, pipelineID := range []uint64{req.BasePipelineID, req.TargetPipelineID} {
+		p, err := e.pipelineSvc.Get(pipelineID)
+		if err != nil {
+			return errorresp.ErrResp(err)
+		}
+		if err := e.checkBranchPermission(r, p.Labels[apistructs.LabelAppID], p.Labels[apistructs.LabelBranch], apistructs.GetAction); err != nil {
+			return errorresp.ErrResp(err)
+		}
+		appIDs = append(appIDs, p.Labels[apistructs.LabelAppID])
+	}
+	if appIDs[0] != appIDs[1] {
+		return apierrors.ErrComparePipeline.InvalidParameter("pipelines do not belong to the same application").ToResp(), nil
+	}
+
+	data, err := e.pipelineSvc.Compare(req.BasePipelineID, req.TargetPipelineID)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+
+	return httpserver.OkResp(data)
+}
+
+// pipelineDryRun 试运行，返回完整解析后的执行计划，不创建任何数据
+func (e *Endpoints) pipelineDryRun(ctx context.Context, r *http.Request, vars map[string]string) (
+	httpserver.Responser, error) {
+
+	var req apistructs.PipelineDryRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apierrors.ErrDryRunPipeline.InvalidParameter("request body").ToResp(), nil
+	}
+
+	// 身份校验
+	identityInfo, err := user.GetIdentityInfo(r)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+	req.IdentityInfo = identityInfo
+
+	// 只能读取有权限的命名空间中的配置项名称
+	if err := e.checkConfigManageNamespacesPermission(identityInfo, req.ConfigManageNamespaces, apistructs.GetAction); err != nil {
+		return errorresp.ErrResp(err)
+	}
+
+	plan, err := e.pipelineSvc.DryRun(&req)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+
+	return httpserver.OkResp(plan)
+}
+
 // pipelineStatistic pipeline 状态分类统计
 func (e *Endpoints) pipelineStatistic(ctx context.Context, r *http.Request, vars map[string]string) (
 	httpserver.Responser, error) {
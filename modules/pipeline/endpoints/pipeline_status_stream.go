@@ -0,0 +1,119 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/events"
+	"github.com/erda-project/erda/modules/pipeline/services/apierrors"
+	"github.com/erda-project/erda/pkg/httpserver/errorresp"
+)
+
+const statusStreamHeartbeatInterval = time.Second * 15
+
+// pipelineStatusStream 通过 SSE 推送流水线状态变更，支持 Last-Event-ID 断线续传
+func (e *Endpoints) pipelineStatusStream(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	var req apistructs.PipelineStatusStreamRequest
+	if err := e.queryStringDecoder.Decode(&req, r.URL.Query()); err != nil {
+		return errorresp.ErrWrite(apierrors.ErrStreamPipelineStatus.InvalidParameter(err), w)
+	}
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		req.LastEventID = lastEventID
+	}
+	labels, err := apistructs.ParseLabelSelector(req.LabelSelector)
+	if err != nil {
+		return errorresp.ErrWrite(apierrors.ErrStreamPipelineStatus.InvalidParameter(err), w)
+	}
+	if req.PipelineID == 0 && len(labels) == 0 {
+		return errorresp.ErrWrite(apierrors.ErrStreamPipelineStatus.MissingParameter("pipelineID or labelSelector"), w)
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return errorresp.ErrWrite(apierrors.ErrStreamPipelineStatus.InternalError(fmt.Errorf("streaming unsupported")), w)
+	}
+
+	// 先订阅再查询快照，保证快照之后的变更不会丢失
+	sub, replay, reset, cursor, err := events.SubscribeStatusStream(events.StreamFilter{PipelineID: req.PipelineID, Labels: labels}, req.LastEventID)
+	if err != nil {
+		return errorresp.ErrWrite(apierrors.ErrStreamPipelineStatus.InternalError(err), w)
+	}
+	defer events.UnsubscribeStatusStream(sub)
+
+	var initial []apistructs.PipelineStatusStreamEvent
+	if reset {
+		initial = append(initial, apistructs.PipelineStatusStreamEvent{ID: cursor, Type: apistructs.PipelineStatusStreamEventTypeReset, Timestamp: time.Now()})
+	}
+	if (req.LastEventID == "" || reset) && req.PipelineID > 0 {
+		snapshot, err := e.pipelineSvc.StatusStreamSnapshot(req.PipelineID, cursor)
+		if err != nil {
+			return errorresp.ErrWrite(err, w)
+		}
+		initial = append(initial, snapshot...)
+	}
+	initial = append(initial, replay...)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, se := range initial {
+		if err := writeStatusStreamEvent(w, se); err != nil {
+			logrus.Debugf("pipeline status stream: failed to write event, err: %v", err)
+			return nil
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(statusStreamHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		case se, ok := <-sub.C():
+			// 订阅被关闭（例如消费过慢），客户端使用 Last-Event-ID 重连即可续传
+			if !ok {
+				return nil
+			}
+			if err := writeStatusStreamEvent(w, se); err != nil {
+				logrus.Debugf("pipeline status stream: failed to write event, err: %v", err)
+				return nil
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func writeStatusStreamEvent(w http.ResponseWriter, se apistructs.PipelineStatusStreamEvent) error {
+	b, err := json.Marshal(se)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", se.ID, se.Type, b)
+	return err
+}
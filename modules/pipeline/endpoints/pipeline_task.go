@@ -105,9 +105,11 @@ func (e *Endpoints) taskBootstrapInfo(ctx context.Context, r *http.Request, vars
 	}
 	// bootstrapInfoData
 	bootstrapInfo := actionagent.AgentArg{
-		Commands:    task.Extra.Action.Commands,
-		Context:     task.Context,
-		PrivateEnvs: task.Extra.PrivateEnvs,
+		Commands:      task.Extra.Action.Commands,
+		Context:       task.Context,
+		PrivateEnvs:   task.Extra.PrivateEnvs,
+		SecretEnvKeys: task.Extra.SecretEnvKeys,
+		Coverage:      task.Extra.Action.Coverage,
 	}
 	b, err := json.Marshal(&bootstrapInfo)
 	if err != nil {
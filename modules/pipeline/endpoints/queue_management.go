@@ -82,6 +82,7 @@ func (e *Endpoints) getPipelineQueue(ctx context.Context, r *http.Request, vars
 
 	// set usage
 	queue.Usage = e.reconciler.QueueManager.QueryQueueUsage(queue)
+	queue.TenantUsages = e.reconciler.QueueManager.QueryQueueTenantUsages(queue)
 
 	return httpserver.OkResp(queue)
 }
@@ -73,3 +73,47 @@ func (e *Endpoints) pagingPipelineReportSets(ctx context.Context, r *http.Reques
 
 	return httpserver.OkResp(pagingResult)
 }
+
+func (e *Endpoints) queryCoverageTrend(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+
+	// 请求参数
+	var req apistructs.PipelineCoverageTrendRequest
+	if err := e.queryStringDecoder.Decode(&req, r.URL.Query()); err != nil {
+		return apierrors.ErrQueryCoverageTrend.InvalidParameter(err).ToResp(), nil
+	}
+
+	// identity
+	if _, err := user.GetIdentityInfo(r); err != nil {
+		return apierrors.ErrQueryCoverageTrend.AccessDenied().ToResp(), nil
+	}
+
+	// query
+	trend, err := e.reportSvc.GetCoverageTrend(req)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+
+	return httpserver.OkResp(trend)
+}
+
+func (e *Endpoints) queryCoverageDelta(ctx context.Context, r *http.Request, vars map[string]string) (httpserver.Responser, error) {
+
+	// 请求参数
+	var req apistructs.PipelineCoverageDeltaRequest
+	if err := e.queryStringDecoder.Decode(&req, r.URL.Query()); err != nil {
+		return apierrors.ErrQueryCoverageDelta.InvalidParameter(err).ToResp(), nil
+	}
+
+	// identity
+	if _, err := user.GetIdentityInfo(r); err != nil {
+		return apierrors.ErrQueryCoverageDelta.AccessDenied().ToResp(), nil
+	}
+
+	// query
+	delta, err := e.reportSvc.GetCoverageDelta(req)
+	if err != nil {
+		return errorresp.ErrResp(err)
+	}
+
+	return httpserver.OkResp(delta)
+}
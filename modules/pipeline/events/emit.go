@@ -14,6 +14,8 @@
 package events
 
 import (
+	"time"
+
 	"github.com/erda-project/erda/apistructs"
 
 	"github.com/erda-project/erda/modules/pipeline/spec"
@@ -56,6 +58,21 @@ func EmitPipelineStreamEvent(pipelineID uint64, events []*apistructs.PipelineEve
 	mgr.ch <- event
 }
 
+// EmitPipelineStreamReasonEvent 发送单条带 reason 的 pipeline 流式事件
+func EmitPipelineStreamReasonEvent(pipelineID uint64, component, level, reason, message string) {
+	now := time.Now()
+	se := apistructs.PipelineEvent{
+		Reason:         reason,
+		Message:        message,
+		Source:         apistructs.PipelineEventSource{Component: component},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+		Type:           level,
+	}
+	EmitPipelineStreamEvent(pipelineID, []*apistructs.PipelineEvent{&se})
+}
+
 func EmitTaskEvent(task *spec.PipelineTask, p *spec.Pipeline) {
 	event := &PipelineTaskEvent{DefaultEvent: defaultEvent}
 
@@ -45,6 +45,7 @@ func (*DefaultEvent) HandleWebSocket() error { return nil }
 func (*DefaultEvent) HandleDingDing() error  { return nil }
 func (*DefaultEvent) HandleHTTP() error      { return nil }
 func (*DefaultEvent) HandleDB() error        { return nil }
+func (*DefaultEvent) HandleStream() error    { return nil }
 
 const (
 	SenderPipeline = "pipeline"
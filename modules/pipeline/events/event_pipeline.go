@@ -214,3 +214,17 @@ func makeDingDingMsg(e *PipelineEvent) (string, error) {
 
 	return msg, nil
 }
+
+func (e *PipelineEvent) HandleStream() error {
+	hub.setPipelineLabels(e.Pipeline.ID, e.Pipeline.MergeLabels())
+	hub.publish(apistructs.PipelineStatusStreamEvent{
+		Type:        apistructs.PipelineStatusStreamEventTypePipeline,
+		PipelineID:  e.Pipeline.ID,
+		Status:      e.Pipeline.Status,
+		CostTimeSec: e.Content().(apistructs.PipelineInstanceEventData).CostTimeSec,
+	})
+	if e.Pipeline.Status.IsEndStatus() {
+		hub.forgetPipeline(e.Pipeline.ID)
+	}
+	return nil
+}
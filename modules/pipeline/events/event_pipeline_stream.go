@@ -67,3 +67,15 @@ func (e *PipelineStreamEvent) HandleDB() error {
 	}
 	return nil
 }
+
+func (e *PipelineStreamEvent) HandleStream() error {
+	for _, pe := range e.Events {
+		hub.publish(apistructs.PipelineStatusStreamEvent{
+			Type:       apistructs.PipelineStatusStreamEventTypeEvent,
+			PipelineID: e.PipelineID,
+			Event:      pe,
+			Timestamp:  pe.LastTimestamp,
+		})
+	}
+	return nil
+}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/erda-project/erda/apistructs"
 	"github.com/erda-project/erda/modules/pipeline/commonutil/costtimeutil"
+	"github.com/erda-project/erda/modules/pipeline/commonutil/statusutil"
 	"github.com/erda-project/erda/modules/pipeline/spec"
 	"github.com/erda-project/erda/modules/pkg/websocket"
 )
@@ -118,3 +119,45 @@ func (e *PipelineTaskEvent) HandleWebSocket() error {
 
 	return e.DefaultEvent.wsClient.EmitEvent(context.Background(), wsEvent)
 }
+
+func (e *PipelineTaskEvent) HandleStream() error {
+	state := e.Task.Status
+	if e.Task.Type == "manual-review" {
+		state = e.Task.Status.ChangeStateForManualReview()
+	}
+	var loopedTimes uint64
+	if e.Task.Extra.LoopOptions != nil {
+		loopedTimes = e.Task.Extra.LoopOptions.LoopedTimes
+	}
+
+	hub.setPipelineLabels(e.Pipeline.ID, e.Pipeline.MergeLabels())
+	hub.publish(apistructs.PipelineStatusStreamEvent{
+		Type:        apistructs.PipelineStatusStreamEventTypeTask,
+		PipelineID:  e.Pipeline.ID,
+		StageID:     e.Task.StageID,
+		TaskID:      e.Task.ID,
+		TaskName:    e.Task.Name,
+		Status:      state,
+		LoopedTimes: loopedTimes,
+		CostTimeSec: e.Content().(apistructs.PipelineTaskEventData).CostTimeSec,
+	})
+
+	// stage 状态由 stage 下所有 task 计算得出，变化时才推送
+	tasks, err := e.dbClient.ListPipelineTasksByStageID(e.Task.StageID)
+	if err != nil {
+		return err
+	}
+	stageStatus, err := statusutil.CalculatePipelineStageStatus(&spec.PipelineStageWithTask{PipelineTasks: tasks})
+	if err != nil {
+		return err
+	}
+	if hub.updateStageStatus(e.Pipeline.ID, e.Task.StageID, stageStatus) {
+		hub.publish(apistructs.PipelineStatusStreamEvent{
+			Type:       apistructs.PipelineStatusStreamEventTypeStage,
+			PipelineID: e.Pipeline.ID,
+			StageID:    e.Task.StageID,
+			Status:     stageStatus,
+		})
+	}
+	return nil
+}
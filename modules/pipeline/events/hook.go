@@ -19,6 +19,7 @@ type Hook interface {
 	HandleDingDing
 	HandleHTTP
 	HandleDB
+	HandleStream
 }
 
 type HandleWebhook interface{ HandleWebhook() error }
@@ -26,6 +27,7 @@ type HandleWebSocket interface{ HandleWebSocket() error }
 type HandleDingDing interface{ HandleDingDing() error }
 type HandleHTTP interface{ HandleHTTP() error }
 type HandleDB interface{ HandleDB() error }
+type HandleStream interface{ HandleStream() error }
 
 type HookType string
 
@@ -35,4 +37,5 @@ const (
 	HookTypeDINGDING  HookType = "DINGDING"
 	HookTypeHTTP      HookType = "HTTP"
 	HookTypeDB        HookType = "DB"
+	HookTypeStream    HookType = "STREAM"
 )
@@ -29,7 +29,9 @@ var mgr EventManager
 
 var defaultEvent DefaultEvent
 
-func Initialize(bdl *bundle.Bundle, wsClient *websocket.Publisher, dbClient *dbclient.Client) {
+var hub *streamHub
+
+func Initialize(bdl *bundle.Bundle, wsClient *websocket.Publisher, dbClient *dbclient.Client) error {
 	mgr = EventManager{
 		ch: make(chan Event, 100),
 	}
@@ -40,9 +42,20 @@ func Initialize(bdl *bundle.Bundle, wsClient *websocket.Publisher, dbClient *dbc
 		dbClient: dbClient,
 	}
 
+	var err error
+	hub, err = newStreamHub(dbClient)
+	if err != nil {
+		return err
+	}
+	go hub.run()
+	go hub.continuousPoll()
+	go hub.continuousGC()
+
 	go func() {
 		for {
 			e := <-mgr.ch
+			// 状态流需要保证顺序，串行处理
+			hub.enqueue(e)
 			go func() {
 				//logrus.Debugf("received an %s Event: %s (kind: %s, header: %+v, sender: %s, content: %+v)",
 				//	e.Kind(), e, e.Kind(), e.Header(), e.Sender(), e.Content())
@@ -55,6 +68,7 @@ func Initialize(bdl *bundle.Bundle, wsClient *websocket.Publisher, dbClient *dbc
 			}()
 		}
 	}()
+	return nil
 }
 
 func handle(e Event, hook HookType, handleFunc func() error) {
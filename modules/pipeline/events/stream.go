@@ -0,0 +1,403 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/dbclient"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+const (
+	streamQueueSize      = 1000
+	subscriberBufferSize = 256
+
+	// streamMaxReplay 续传时最多补发的事件数，超过时需要重置
+	streamMaxReplay = 4096
+	// streamPollBatch 每次从存储拉取的事件数
+	streamPollBatch = 500
+	// streamPollInterval 拉取间隔，本实例发布事件后会立即拉取
+	streamPollInterval = time.Second
+	// streamGapTimeout 事件 ID 不连续时等待较小 ID 提交的时长。
+	// 多实例并发写入时，自增 ID 较小的事件可能晚于较大的提交，超时后视为 ID 被跳过（回滚、自增步长等）
+	streamGapTimeout = time.Second * 3
+	// streamRetention 事件保留时长，超过后无法续传
+	streamRetention  = time.Hour * 24
+	streamGCInterval = time.Hour
+)
+
+// streamStore 状态流事件的共享存储
+type streamStore interface {
+	CreatePipelineStatusStreamEvent(e *spec.PipelineStatusStreamEvent, ops ...dbclient.SessionOption) error
+	ListPipelineStatusStreamEvents(afterID uint64, limit int, ops ...dbclient.SessionOption) ([]spec.PipelineStatusStreamEvent, error)
+	GetPipelineStatusStreamEventIDRange(ops ...dbclient.SessionOption) (minID, maxID uint64, err error)
+	DeletePipelineStatusStreamEventsBefore(before time.Time, ops ...dbclient.SessionOption) (int64, error)
+}
+
+// streamHub 将状态变更事件写入共享存储，并从存储中拉取事件广播给本实例的订阅者。
+// 事件由处理流水线的实例写入，订阅者可以连接任意实例；事件 ID 为存储中的自增 ID，
+// 可跨实例、跨重启续传，超过 streamRetention 的事件会被清理，无法续传时下发 reset 事件。
+// 事件按 ID 顺序广播，ID 不连续时等待较小 ID 提交（最多 streamGapTimeout），
+// 因此已广播的 ID 之前不会再出现新事件，续传只需补发之后的事件。
+type streamHub struct {
+	queue  chan Event
+	store  streamStore
+	notify chan struct{}
+
+	gapTimeout time.Duration
+
+	// pollMu 保证同时只有一个拉取
+	pollMu sync.Mutex
+	// firstSeen 已拉取但因 ID 不连续未广播的事件首次拉取到的时间，仅拉取协程访问
+	firstSeen map[uint64]time.Time
+
+	mu          sync.Mutex
+	lastID      uint64 // 已广播的最新事件 ID，之前的事件均已确定
+	subscribers map[*StreamSubscriber]struct{}
+
+	// 用于补全事件的 labels 与计算阶段状态，仅在发出事件的实例上维护，流水线结束后清理
+	labelsMu       sync.Mutex
+	pipelineLabels map[uint64]map[string]string
+	stageStatuses  map[uint64]map[uint64]apistructs.PipelineStatus // pipelineID -> stageID -> status
+}
+
+// StreamFilter 订阅过滤条件，PipelineID 与 Labels 同时指定时需同时满足
+type StreamFilter struct {
+	PipelineID uint64
+	Labels     map[string]string
+}
+
+// StreamSubscriber 订阅者，消费过慢时会被关闭，客户端可通过 lastEventID 续传
+type StreamSubscriber struct {
+	filter StreamFilter
+	ch     chan apistructs.PipelineStatusStreamEvent
+	closed bool
+	// lastID 订阅者已收到的最新事件 ID，用于跳过重复事件
+	lastID uint64
+}
+
+// C 返回事件 channel，channel 关闭代表订阅结束
+func (s *StreamSubscriber) C() <-chan apistructs.PipelineStatusStreamEvent {
+	return s.ch
+}
+
+func newStreamHub(store streamStore) (*streamHub, error) {
+	_, maxID, err := store.GetPipelineStatusStreamEventIDRange()
+	if err != nil {
+		return nil, err
+	}
+	return &streamHub{
+		queue:          make(chan Event, streamQueueSize),
+		store:          store,
+		notify:         make(chan struct{}, 1),
+		gapTimeout:     streamGapTimeout,
+		firstSeen:      make(map[uint64]time.Time),
+		lastID:         maxID,
+		subscribers:    make(map[*StreamSubscriber]struct{}),
+		pipelineLabels: make(map[uint64]map[string]string),
+		stageStatuses:  make(map[uint64]map[uint64]apistructs.PipelineStatus),
+	}, nil
+}
+
+// run 串行处理事件，保证同一实例内事件顺序与发出顺序一致
+func (h *streamHub) run() {
+	for e := range h.queue {
+		handle(e, HookTypeStream, e.HandleStream)
+	}
+}
+
+// continuousPoll 从共享存储拉取新事件并广播
+func (h *streamHub) continuousPoll() {
+	ticker := time.NewTicker(streamPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-h.notify:
+		}
+		if err := h.poll(); err != nil {
+			logrus.Errorf("pipeline status stream: failed to poll events, err: %v", err)
+		}
+	}
+}
+
+// continuousGC 清理过期事件，多个实例同时清理不影响结果
+func (h *streamHub) continuousGC() {
+	ticker := time.NewTicker(streamGCInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if _, err := h.store.DeletePipelineStatusStreamEventsBefore(time.Now().Add(-streamRetention)); err != nil {
+			logrus.Errorf("pipeline status stream: failed to gc events, err: %v", err)
+		}
+	}
+}
+
+func (h *streamHub) enqueue(e Event) {
+	h.queue <- e
+}
+
+func makeEventID(id uint64) string {
+	return strconv.FormatUint(id, 10)
+}
+
+func parseEventID(id string) (uint64, bool) {
+	seq, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return seq, true
+}
+
+func convertStreamEvent(e spec.PipelineStatusStreamEvent) apistructs.PipelineStatusStreamEvent {
+	se := e.Event
+	se.ID = makeEventID(e.ID)
+	return se
+}
+
+// publish 写入共享存储，由拉取协程广播
+func (h *streamHub) publish(events ...apistructs.PipelineStatusStreamEvent) {
+	for _, se := range events {
+		if se.Timestamp.IsZero() {
+			se.Timestamp = time.Now()
+		}
+		e := &spec.PipelineStatusStreamEvent{
+			PipelineID: se.PipelineID,
+			Labels:     h.getPipelineLabels(se.PipelineID),
+			Event:      se,
+		}
+		if err := h.store.CreatePipelineStatusStreamEvent(e); err != nil {
+			logrus.Errorf("pipeline status stream: failed to save event, pipelineID: %d, err: %v", se.PipelineID, err)
+		}
+	}
+	select {
+	case h.notify <- struct{}{}:
+	default:
+	}
+}
+
+// poll 拉取 lastID 之后的事件，按 ID 顺序广播给订阅者。
+// 每次都从 lastID 之后拉取，晚提交的较小 ID 事件可以补上；ID 不连续时等待 gapTimeout 后再跳过。
+func (h *streamHub) poll() error {
+	h.pollMu.Lock()
+	defer h.pollMu.Unlock()
+
+	h.mu.Lock()
+	lastID := h.lastID
+	h.mu.Unlock()
+
+	var pending []spec.PipelineStatusStreamEvent
+	for afterID := lastID; ; {
+		events, err := h.store.ListPipelineStatusStreamEvents(afterID, streamPollBatch)
+		if err != nil {
+			return err
+		}
+		pending = append(pending, events...)
+		if len(events) < streamPollBatch {
+			break
+		}
+		afterID = events[len(events)-1].ID
+	}
+
+	now := time.Now()
+	var ready []spec.PipelineStatusStreamEvent
+	for _, e := range pending {
+		if _, ok := h.firstSeen[e.ID]; !ok {
+			h.firstSeen[e.ID] = now
+		}
+	}
+	for _, e := range pending {
+		if e.ID != lastID+1 && now.Sub(h.firstSeen[e.ID]) < h.gapTimeout {
+			break
+		}
+		ready = append(ready, e)
+		lastID = e.ID
+	}
+	for id := range h.firstSeen {
+		if id <= lastID {
+			delete(h.firstSeen, id)
+		}
+	}
+	h.broadcast(ready)
+	return nil
+}
+
+// broadcast 广播按 ID 升序排列且已确定的事件
+func (h *streamHub) broadcast(events []spec.PipelineStatusStreamEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, e := range events {
+		if e.ID <= h.lastID {
+			continue
+		}
+		h.lastID = e.ID
+		se := convertStreamEvent(e)
+		for sub := range h.subscribers {
+			// 续传位置可能领先于本实例已广播的位置
+			if e.ID <= sub.lastID || !sub.filter.match(e.PipelineID, e.Labels) {
+				continue
+			}
+			select {
+			case sub.ch <- se:
+				sub.lastID = e.ID
+			default:
+				// 消费过慢，关闭订阅，由客户端续传
+				logrus.Warnf("pipeline status stream: subscriber too slow, closed, filter: %+v", sub.filter)
+				h.unsubscribeLocked(sub)
+			}
+		}
+	}
+}
+
+// subscribe 注册订阅者，并返回 lastEventID 之后需要补发的事件；
+// 无法续传时 reset 为 true。返回的 cursor 为注册时已广播的最新事件 ID，可用于标记补发快照。
+// 补发范围为 (lastEventID, cursor]，之后的事件由广播下发，两者不重叠；补发在锁外查询，不阻塞广播。
+func (h *streamHub) subscribe(filter StreamFilter, lastEventID string) (sub *StreamSubscriber, replay []apistructs.PipelineStatusStreamEvent, reset bool, cursor string, err error) {
+	var (
+		lastID     uint64
+		needReplay bool
+	)
+	if lastEventID != "" {
+		var ok bool
+		if lastID, ok = parseEventID(lastEventID); ok {
+			minID, maxID, err := h.store.GetPipelineStatusStreamEventIDRange()
+			if err != nil {
+				return nil, nil, false, "", err
+			}
+			// 事件 ID 不存在，或之后的事件已被清理
+			needReplay = lastID <= maxID && lastID+1 >= minID
+		}
+		reset = !needReplay
+	}
+
+	sub = &StreamSubscriber{filter: filter, ch: make(chan apistructs.PipelineStatusStreamEvent, subscriberBufferSize)}
+	h.mu.Lock()
+	upTo := h.lastID
+	sub.lastID = upTo
+	if needReplay && lastID > upTo {
+		// 续传位置领先于本实例已广播的位置，跳过已收到的事件
+		sub.lastID = lastID
+	}
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+	cursor = makeEventID(upTo)
+
+	if !needReplay {
+		return sub, nil, reset, cursor, nil
+	}
+
+	// 补发 (lastID, upTo] 之间的事件，这些事件均已确定，查询结果不会再变化
+	for afterID := lastID; afterID < upTo; {
+		events, err := h.store.ListPipelineStatusStreamEvents(afterID, streamPollBatch)
+		if err != nil {
+			h.unsubscribe(sub)
+			return nil, nil, false, "", err
+		}
+		for _, e := range events {
+			if e.ID > upTo {
+				break
+			}
+			if filter.match(e.PipelineID, e.Labels) {
+				replay = append(replay, convertStreamEvent(e))
+			}
+		}
+		if len(replay) > streamMaxReplay {
+			return sub, nil, true, cursor, nil
+		}
+		if len(events) < streamPollBatch {
+			break
+		}
+		afterID = events[len(events)-1].ID
+	}
+	return sub, replay, false, cursor, nil
+}
+
+func (h *streamHub) unsubscribe(sub *StreamSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.unsubscribeLocked(sub)
+}
+
+func (h *streamHub) unsubscribeLocked(sub *StreamSubscriber) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(h.subscribers, sub)
+	close(sub.ch)
+}
+
+func (h *streamHub) setPipelineLabels(pipelineID uint64, labels map[string]string) {
+	h.labelsMu.Lock()
+	defer h.labelsMu.Unlock()
+	h.pipelineLabels[pipelineID] = labels
+}
+
+func (h *streamHub) getPipelineLabels(pipelineID uint64) map[string]string {
+	h.labelsMu.Lock()
+	defer h.labelsMu.Unlock()
+	return h.pipelineLabels[pipelineID]
+}
+
+// updateStageStatus 记录阶段状态，返回状态是否发生变化
+func (h *streamHub) updateStageStatus(pipelineID, stageID uint64, status apistructs.PipelineStatus) bool {
+	h.labelsMu.Lock()
+	defer h.labelsMu.Unlock()
+	stages, ok := h.stageStatuses[pipelineID]
+	if !ok {
+		stages = make(map[uint64]apistructs.PipelineStatus)
+		h.stageStatuses[pipelineID] = stages
+	}
+	if stages[stageID] == status {
+		return false
+	}
+	stages[stageID] = status
+	return true
+}
+
+// forgetPipeline 流水线结束后清理缓存，已保存的事件自带 labels，仍可用于续传
+func (h *streamHub) forgetPipeline(pipelineID uint64) {
+	h.labelsMu.Lock()
+	defer h.labelsMu.Unlock()
+	delete(h.stageStatuses, pipelineID)
+	delete(h.pipelineLabels, pipelineID)
+}
+
+func (f StreamFilter) match(pipelineID uint64, labels map[string]string) bool {
+	if f.PipelineID > 0 && f.PipelineID != pipelineID {
+		return false
+	}
+	for k, v := range f.Labels {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// SubscribeStatusStream 订阅流水线状态流，详见 streamHub.subscribe
+func SubscribeStatusStream(filter StreamFilter, lastEventID string) (*StreamSubscriber, []apistructs.PipelineStatusStreamEvent, bool, string, error) {
+	return hub.subscribe(filter, lastEventID)
+}
+
+// UnsubscribeStatusStream 取消订阅
+func UnsubscribeStatusStream(sub *StreamSubscriber) {
+	hub.unsubscribe(sub)
+}
@@ -0,0 +1,257 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/dbclient"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+// memStreamStore 内存实现的共享存储，多个 streamHub 共用时模拟多实例
+type memStreamStore struct {
+	events []spec.PipelineStatusStreamEvent
+	nextID uint64
+}
+
+func (m *memStreamStore) CreatePipelineStatusStreamEvent(e *spec.PipelineStatusStreamEvent, _ ...dbclient.SessionOption) error {
+	m.nextID++
+	e.ID = m.nextID
+	e.TimeCreated = time.Now()
+	m.events = append(m.events, *e)
+	return nil
+}
+
+// reserveID 分配 ID 但暂不提交，模拟并发写入时较小 ID 晚提交
+func (m *memStreamStore) reserveID() uint64 {
+	m.nextID++
+	return m.nextID
+}
+
+func (m *memStreamStore) commitReserved(id uint64, e spec.PipelineStatusStreamEvent) {
+	e.ID = id
+	e.TimeCreated = time.Now()
+	for i := range m.events {
+		if m.events[i].ID > id {
+			m.events = append(m.events[:i], append([]spec.PipelineStatusStreamEvent{e}, m.events[i:]...)...)
+			return
+		}
+	}
+	m.events = append(m.events, e)
+}
+
+func (m *memStreamStore) ListPipelineStatusStreamEvents(afterID uint64, limit int, _ ...dbclient.SessionOption) ([]spec.PipelineStatusStreamEvent, error) {
+	var result []spec.PipelineStatusStreamEvent
+	for _, e := range m.events {
+		if e.ID > afterID && len(result) < limit {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+func (m *memStreamStore) GetPipelineStatusStreamEventIDRange(_ ...dbclient.SessionOption) (uint64, uint64, error) {
+	if len(m.events) == 0 {
+		return 0, 0, nil
+	}
+	return m.events[0].ID, m.events[len(m.events)-1].ID, nil
+}
+
+func (m *memStreamStore) DeletePipelineStatusStreamEventsBefore(before time.Time, _ ...dbclient.SessionOption) (int64, error) {
+	var kept []spec.PipelineStatusStreamEvent
+	for _, e := range m.events {
+		if !e.TimeCreated.Before(before) {
+			kept = append(kept, e)
+		}
+	}
+	deleted := int64(len(m.events) - len(kept))
+	m.events = kept
+	return deleted, nil
+}
+
+func newTestStreamHub(t *testing.T, store streamStore) *streamHub {
+	h, err := newStreamHub(store)
+	assert.NoError(t, err)
+	return h
+}
+
+func TestStreamHub_SubscribeAndResume(t *testing.T) {
+	h := newTestStreamHub(t, &memStreamStore{})
+	h.setPipelineLabels(1, map[string]string{"appID": "1"})
+	h.setPipelineLabels(2, map[string]string{"appID": "2"})
+
+	sub, replay, reset, cursor, err := h.subscribe(StreamFilter{PipelineID: 1}, "")
+	assert.NoError(t, err)
+	assert.Empty(t, replay)
+	assert.False(t, reset)
+	assert.Equal(t, makeEventID(0), cursor)
+
+	h.publish(
+		apistructs.PipelineStatusStreamEvent{Type: apistructs.PipelineStatusStreamEventTypePipeline, PipelineID: 1, Status: apistructs.PipelineStatusRunning},
+		apistructs.PipelineStatusStreamEvent{Type: apistructs.PipelineStatusStreamEventTypePipeline, PipelineID: 2, Status: apistructs.PipelineStatusRunning},
+		apistructs.PipelineStatusStreamEvent{Type: apistructs.PipelineStatusStreamEventTypeTask, PipelineID: 1, TaskID: 10, LoopedTimes: 2},
+	)
+	assert.NoError(t, h.poll())
+	first := <-sub.C()
+	assert.Equal(t, makeEventID(1), first.ID)
+	second := <-sub.C()
+	assert.Equal(t, uint64(10), second.TaskID)
+	assert.Equal(t, uint64(2), second.LoopedTimes)
+	h.unsubscribe(sub)
+	h.unsubscribe(sub) // 重复取消订阅不会 panic
+
+	// 从第一个事件之后续传
+	_, replay, reset, _, err = h.subscribe(StreamFilter{PipelineID: 1}, first.ID)
+	assert.NoError(t, err)
+	assert.False(t, reset)
+	assert.Equal(t, []apistructs.PipelineStatusStreamEvent{second}, replay)
+
+	// label selector
+	_, replay, _, _, _ = h.subscribe(StreamFilter{Labels: map[string]string{"appID": "2"}}, makeEventID(0))
+	assert.Len(t, replay, 1)
+	assert.Equal(t, uint64(2), replay[0].PipelineID)
+
+	// 流水线结束后 labels 被清理，已保存的事件仍可按 label 续传
+	h.forgetPipeline(2)
+	_, replay, _, _, _ = h.subscribe(StreamFilter{Labels: map[string]string{"appID": "2"}}, makeEventID(0))
+	assert.Len(t, replay, 1)
+
+	// 非法或不存在的事件 ID 无法续传
+	_, _, reset, _, _ = h.subscribe(StreamFilter{PipelineID: 1}, "other-1")
+	assert.True(t, reset)
+	_, _, reset, _, _ = h.subscribe(StreamFilter{PipelineID: 1}, makeEventID(100))
+	assert.True(t, reset)
+}
+
+func TestStreamHub_CrossInstance(t *testing.T) {
+	store := &memStreamStore{}
+	producer := newTestStreamHub(t, store)
+	consumer := newTestStreamHub(t, store)
+
+	sub, _, _, _, err := consumer.subscribe(StreamFilter{PipelineID: 1}, "")
+	assert.NoError(t, err)
+	producer.publish(apistructs.PipelineStatusStreamEvent{PipelineID: 1, Status: apistructs.PipelineStatusRunning})
+	producer.publish(apistructs.PipelineStatusStreamEvent{PipelineID: 1, Status: apistructs.PipelineStatusSuccess})
+	assert.NoError(t, consumer.poll())
+	first := <-sub.C()
+	assert.Equal(t, apistructs.PipelineStatusRunning, first.Status)
+	assert.Equal(t, apistructs.PipelineStatusSuccess, (<-sub.C()).Status)
+
+	// 在另一个实例上续传，本实例尚未拉取的事件由广播下发
+	resumed, replay, reset, _, err := producer.subscribe(StreamFilter{PipelineID: 1}, first.ID)
+	assert.NoError(t, err)
+	assert.False(t, reset)
+	assert.Empty(t, replay)
+	assert.NoError(t, producer.poll())
+	assert.Equal(t, apistructs.PipelineStatusSuccess, (<-resumed.C()).Status)
+	_, replay, _, _, _ = producer.subscribe(StreamFilter{PipelineID: 1}, first.ID)
+	assert.Len(t, replay, 1)
+
+	// 续传位置领先于本实例已拉取的位置时，不会重复下发
+	lagging := newTestStreamHub(t, store)
+	lagging.lastID = 0
+	sub, replay, reset, _, err = lagging.subscribe(StreamFilter{PipelineID: 1}, makeEventID(2))
+	assert.NoError(t, err)
+	assert.False(t, reset)
+	assert.Empty(t, replay)
+	assert.NoError(t, lagging.poll())
+	select {
+	case se := <-sub.C():
+		t.Fatalf("unexpected duplicated event: %+v", se)
+	default:
+	}
+}
+
+func TestStreamHub_Expired(t *testing.T) {
+	store := &memStreamStore{}
+	h := newTestStreamHub(t, store)
+	sub, _, _, _, _ := h.subscribe(StreamFilter{PipelineID: 1}, "")
+	for i := 0; i < subscriberBufferSize+1; i++ {
+		h.publish(apistructs.PipelineStatusStreamEvent{PipelineID: 1})
+	}
+	assert.NoError(t, h.poll())
+
+	// 消费过慢的订阅者会被关闭
+	assert.True(t, sub.closed)
+	_, ok := <-sub.C()
+	for ok {
+		_, ok = <-sub.C()
+	}
+
+	// 事件过期被清理后需要重置
+	deleted, err := store.DeletePipelineStatusStreamEventsBefore(time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(subscriberBufferSize+1), deleted)
+	h.publish(apistructs.PipelineStatusStreamEvent{PipelineID: 1})
+	assert.NoError(t, h.poll())
+	_, _, reset, _, _ := h.subscribe(StreamFilter{PipelineID: 1}, makeEventID(1))
+	assert.True(t, reset)
+	_, replay, reset, _, _ := h.subscribe(StreamFilter{PipelineID: 1}, makeEventID(subscriberBufferSize+1))
+	assert.False(t, reset)
+	assert.Len(t, replay, 1)
+}
+
+func TestStreamHub_LateCommit(t *testing.T) {
+	store := &memStreamStore{}
+	h := newTestStreamHub(t, store)
+	sub, _, _, _, err := h.subscribe(StreamFilter{PipelineID: 1}, "")
+	assert.NoError(t, err)
+
+	h.publish(apistructs.PipelineStatusStreamEvent{PipelineID: 1, Status: apistructs.PipelineStatusAnalyzed})
+	late := store.reserveID()
+	h.publish(apistructs.PipelineStatusStreamEvent{PipelineID: 1, Status: apistructs.PipelineStatusSuccess})
+	assert.NoError(t, h.poll())
+	assert.Equal(t, apistructs.PipelineStatusAnalyzed, (<-sub.C()).Status)
+	// ID 不连续时等待较小 ID 提交，不提前广播
+	select {
+	case se := <-sub.C():
+		t.Fatalf("unexpected event before gap is filled: %+v", se)
+	default:
+	}
+	assert.Equal(t, uint64(1), h.lastID)
+
+	// 较小 ID 晚提交后按 ID 顺序广播
+	store.commitReserved(late, spec.PipelineStatusStreamEvent{PipelineID: 1,
+		Event: apistructs.PipelineStatusStreamEvent{PipelineID: 1, Status: apistructs.PipelineStatusRunning}})
+	assert.NoError(t, h.poll())
+	assert.Equal(t, apistructs.PipelineStatusRunning, (<-sub.C()).Status)
+	assert.Equal(t, apistructs.PipelineStatusSuccess, (<-sub.C()).Status)
+
+	// 续传不会跳过晚提交的事件
+	_, replay, reset, _, err := h.subscribe(StreamFilter{PipelineID: 1}, makeEventID(1))
+	assert.NoError(t, err)
+	assert.False(t, reset)
+	assert.Len(t, replay, 2)
+
+	// 一直未提交的 ID 超时后跳过
+	h.gapTimeout = 0
+	store.reserveID()
+	h.publish(apistructs.PipelineStatusStreamEvent{PipelineID: 1, Status: apistructs.PipelineStatusFailed})
+	assert.NoError(t, h.poll())
+	assert.Equal(t, apistructs.PipelineStatusFailed, (<-sub.C()).Status)
+}
+
+func TestStreamHub_UpdateStageStatus(t *testing.T) {
+	h := newTestStreamHub(t, &memStreamStore{})
+	assert.True(t, h.updateStageStatus(1, 1, apistructs.PipelineStatusRunning))
+	assert.False(t, h.updateStageStatus(1, 1, apistructs.PipelineStatusRunning))
+	assert.True(t, h.updateStageStatus(1, 1, apistructs.PipelineStatusSuccess))
+	h.forgetPipeline(1)
+	assert.True(t, h.updateStageStatus(1, 1, apistructs.PipelineStatusSuccess))
+}
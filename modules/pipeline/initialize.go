@@ -16,6 +16,7 @@ package pipeline
 
 import (
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/gorilla/schema"
@@ -29,12 +30,14 @@ import (
 	"github.com/erda-project/erda/modules/pipeline/dbclient"
 	"github.com/erda-project/erda/modules/pipeline/endpoints"
 	"github.com/erda-project/erda/modules/pipeline/events"
+	"github.com/erda-project/erda/modules/pipeline/metrics"
 	"github.com/erda-project/erda/modules/pipeline/pexpr/pexpr_params"
 	"github.com/erda-project/erda/modules/pipeline/pipengine"
 	"github.com/erda-project/erda/modules/pipeline/pipengine/pvolumes"
 	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler"
 	"github.com/erda-project/erda/modules/pipeline/services/actionagentsvc"
 	"github.com/erda-project/erda/modules/pipeline/services/appsvc"
+	"github.com/erda-project/erda/modules/pipeline/services/archivesvc"
 	"github.com/erda-project/erda/modules/pipeline/services/buildartifactsvc"
 	"github.com/erda-project/erda/modules/pipeline/services/buildcachesvc"
 	"github.com/erda-project/erda/modules/pipeline/services/cmsvc"
@@ -47,6 +50,7 @@ import (
 	"github.com/erda-project/erda/modules/pipeline/services/reportsvc"
 	"github.com/erda-project/erda/modules/pipeline/services/snippetsvc"
 	"github.com/erda-project/erda/modules/pkg/websocket"
+	"github.com/erda-project/erda/pkg/encryption"
 	"github.com/erda-project/erda/pkg/httpserver"
 	"github.com/erda-project/erda/pkg/jsonstore"
 	"github.com/erda-project/erda/pkg/jsonstore/etcd"
@@ -112,7 +116,15 @@ func do() (*httpserver.Server, error) {
 
 	// init services
 	appSvc := appsvc.New(bdl)
-	cmSvc := cmsvc.New(bdl, dbClient)
+	// 配置管理与 aop 外部插件共用加密密钥
+	rsaCrypt := encryption.NewRSAScrypt(encryption.RSASecret{
+		PublicKey:          conf.CmsBase64EncodedRsaPublicKey(),
+		PublicKeyDataType:  encryption.Base64,
+		PrivateKey:         conf.CmsBase64EncodedRsaPrivateKey(),
+		PrivateKeyDataType: encryption.Base64,
+		PrivateKeyType:     encryption.PKCS1,
+	})
+	cmSvc := cmsvc.New(bdl, dbClient, cmsvc.WithRsaCrypt(rsaCrypt))
 	buildArtifactSvc := buildartifactsvc.New(dbClient)
 	buildCacheSvc := buildcachesvc.New(dbClient)
 	permissionSvc := permissionsvc.New(bdl)
@@ -122,13 +134,23 @@ func do() (*httpserver.Server, error) {
 	pipelineCronSvc := pipelinecronsvc.New(dbClient, crondSvc)
 	reportSvc := reportsvc.New(reportsvc.WithDBClient(dbClient))
 	queueManage := queuemanage.New(queuemanage.WithDBClient(dbClient))
+	archiveStorage, err := archivesvc.NewStorageFromConf()
+	if err != nil {
+		return nil, err
+	}
+	archiveSvc := archivesvc.New(
+		archivesvc.WithDBClient(dbClient),
+		archivesvc.WithStorage(archiveStorage, conf.ArchiveExportPath()),
+		archivesvc.WithPolicy(conf.ArchiveExportAfter(), conf.ArchiveExportSegmentSize(), conf.ArchiveExportMaxSegmentsPerRun()),
+	)
+	crondSvc.WithArchiveExportFunc(archiveSvc.ExportArchives)
 
 	// pipeline engine
 	engine := pipengine.New(dbClient)
 
 	// init services
 	pipelineSvc := pipelinesvc.New(appSvc, cmSvc, crondSvc, actionAgentSvc, extMarketSvc, pipelineCronSvc,
-		permissionSvc, queueManage, dbClient, bdl, publisher, engine, js, etcdctl)
+		permissionSvc, queueManage, archiveSvc, dbClient, bdl, publisher, engine, js, etcdctl)
 
 	pipelineFun := &reconciler.PipelineSvcFunc{
 		CronNotExecuteCompensate: pipelineSvc.CronNotExecuteCompensateById,
@@ -170,11 +192,13 @@ func do() (*httpserver.Server, error) {
 	)
 
 	server := httpserver.New(conf.ListenAddr())
-	//server.Router().Path("/metrics").Methods(http.MethodGet).Handler(promxp.Handler("pipeline"))
+	server.Router().Path("/metrics").Methods(http.MethodGet).Handler(metrics.Handler())
 	server.RegisterEndpoint(ep.Routes())
 
 	// 加载 event manager
-	events.Initialize(bdl, publisher, dbClient)
+	if err := events.Initialize(bdl, publisher, dbClient); err != nil {
+		return nil, err
+	}
 
 	// 同步 pipeline 表拆分后的 commit 字段和 org_name 字段
 	go pipelineSvc.SyncAfterSplitTable()
@@ -183,7 +207,7 @@ func do() (*httpserver.Server, error) {
 	go pipeline_network_hook_client.RegisterLifecycleHookClient(dbClient)
 
 	// aop
-	aop.Initialize(bdl, dbClient, reportSvc)
+	aop.Initialize(bdl, dbClient, reportSvc, rsaCrypt)
 
 	// engine start after all dependencies done
 	engine.Start()
@@ -0,0 +1,231 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler/queuemanage/types"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+// 暴露给 prometheus 的指标
+// label 只使用取值有限的字段（状态、执行器类型、队列 ID 等），不使用 pipelineID、taskID、分支等，避免基数膨胀
+const (
+	namespace = "erda"
+	subsystem = "pipeline"
+
+	labelStatus    = "status"
+	labelExecutor  = "executor"
+	labelOperation = "operation"
+	labelQueueID   = "queue_id"
+	labelLock      = "lock"
+	labelResult    = "result"
+
+	resultSuccess = "success"
+	resultFailed  = "failed"
+)
+
+// DLock 名称，作为 lock label 的取值
+const (
+	DLockReconciler     = "reconciler"
+	DLockCronCompensate = "cron_compensate"
+	DLockArchiveExport  = "archive_export"
+)
+
+var (
+	// durationBuckets 流水线和任务的执行时长分布，从 5s 到 4h
+	durationBuckets = []float64{5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600, 7200, 14400}
+
+	registry = prometheus.NewRegistry()
+
+	pipelineDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "duration_seconds",
+		Help:      "Duration of finished pipelines by end status.",
+		Buckets:   durationBuckets,
+	}, []string{labelStatus})
+
+	taskDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "task_duration_seconds",
+		Help:      "Duration of finished pipeline tasks by end status and executor kind.",
+		Buckets:   durationBuckets,
+	}, []string{labelStatus, labelExecutor})
+
+	reconcileDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "reconcile_duration_seconds",
+		Help:      "Latency of one reconciler loop, from loading pipeline to dispatching schedulable tasks.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	dlockAcquireTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "dlock_acquire_total",
+		Help:      "Total distributed lock acquisitions by lock and result.",
+	}, []string{labelLock, labelResult})
+
+	dlockLostTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "dlock_lost_total",
+		Help:      "Total distributed locks lost while being held.",
+	}, []string{labelLock})
+
+	executorRequestTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "executor_requests_total",
+		Help:      "Total action executor API requests by executor kind and operation.",
+	}, []string{labelExecutor, labelOperation})
+
+	executorErrorTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "executor_errors_total",
+		Help:      "Total failed action executor API requests by executor kind and operation.",
+	}, []string{labelExecutor, labelOperation})
+
+	queueCollector = &queueStatsCollector{
+		pending: prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, "queue_pending"),
+			"Number of pipelines pending in queue.", []string{labelQueueID}, nil),
+		processing: prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, "queue_processing"),
+			"Number of pipelines processing in queue.", []string{labelQueueID}, nil),
+		oldestPendingAge: prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, "queue_oldest_pending_age_seconds"),
+			"Age of the oldest pending pipeline in queue, 0 if nothing is pending.", []string{labelQueueID}, nil),
+	}
+)
+
+func init() {
+	registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		pipelineDuration,
+		taskDuration,
+		reconcileDuration,
+		dlockAcquireTotal,
+		dlockLostTotal,
+		executorRequestTotal,
+		executorErrorTotal,
+		queueCollector,
+	)
+}
+
+// Handler 返回 /metrics 的 http handler
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// PipelineEnd 记录终态流水线的执行时长，未开始执行的流水线不记录
+func PipelineEnd(p spec.Pipeline) {
+	if p.CostTimeSec < 0 {
+		return
+	}
+	pipelineDuration.WithLabelValues(p.Status.String()).Observe(float64(p.CostTimeSec))
+}
+
+// TaskEnd 记录终态任务的执行时长
+func TaskEnd(task spec.PipelineTask, executorKind string) {
+	if task.CostTimeSec < 0 {
+		return
+	}
+	taskDuration.WithLabelValues(task.Status.String(), executorKind).Observe(float64(task.CostTimeSec))
+}
+
+// ReconcileObserve 记录一次 reconcile 的耗时
+func ReconcileObserve(begin time.Time) {
+	reconcileDuration.Observe(time.Since(begin).Seconds())
+}
+
+// DLockAcquire 记录一次加锁结果
+func DLockAcquire(lock string, err error) {
+	dlockAcquireTotal.WithLabelValues(lock, result(err)).Inc()
+}
+
+// DLockLost 记录一次锁丢失
+func DLockLost(lock string) {
+	dlockLostTotal.WithLabelValues(lock).Inc()
+}
+
+// ExecutorRequest 记录一次 action executor 接口调用
+func ExecutorRequest(executorKind, operation string, err error) {
+	executorRequestTotal.WithLabelValues(executorKind, operation).Inc()
+	if err != nil {
+		executorErrorTotal.WithLabelValues(executorKind, operation).Inc()
+	}
+}
+
+// SetQueueStatsLister 设置队列统计的数据来源，采集时实时读取
+func SetQueueStatsLister(lister QueueStatsLister) {
+	queueCollector.lock.Lock()
+	defer queueCollector.lock.Unlock()
+	queueCollector.lister = lister
+}
+
+func result(err error) string {
+	if err != nil {
+		return resultFailed
+	}
+	return resultSuccess
+}
+
+// QueueStatsLister 提供所有队列的实时统计
+type QueueStatsLister interface {
+	ListQueueStats() []types.QueueStats
+}
+
+// queueStatsCollector 采集时读取队列统计，已删除的队列不会残留指标
+type queueStatsCollector struct {
+	pending          *prometheus.Desc
+	processing       *prometheus.Desc
+	oldestPendingAge *prometheus.Desc
+
+	lock   sync.RWMutex
+	lister QueueStatsLister
+}
+
+func (c *queueStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.pending
+	ch <- c.processing
+	ch <- c.oldestPendingAge
+}
+
+func (c *queueStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.lock.RLock()
+	lister := c.lister
+	c.lock.RUnlock()
+	if lister == nil {
+		return
+	}
+	now := time.Now()
+	for _, stats := range lister.ListQueueStats() {
+		var age float64
+		if stats.OldestPendingTime != nil {
+			age = now.Sub(*stats.OldestPendingTime).Seconds()
+		}
+		ch <- prometheus.MustNewConstMetric(c.pending, prometheus.GaugeValue, float64(stats.PendingCount), stats.ID)
+		ch <- prometheus.MustNewConstMetric(c.processing, prometheus.GaugeValue, float64(stats.ProcessingCount), stats.ID)
+		ch <- prometheus.MustNewConstMetric(c.oldestPendingAge, prometheus.GaugeValue, age, stats.ID)
+	}
+}
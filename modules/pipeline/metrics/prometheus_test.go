@@ -0,0 +1,85 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package metrics
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler/queuemanage/types"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+type fakeQueueStatsLister []types.QueueStats
+
+func (l fakeQueueStatsLister) ListQueueStats() []types.QueueStats { return l }
+
+func TestDLockAndExecutorCounters(t *testing.T) {
+	DLockAcquire(DLockReconciler, nil)
+	DLockAcquire(DLockReconciler, fmt.Errorf("timeout"))
+	DLockLost(DLockReconciler)
+	assert.Equal(t, float64(1), testutil.ToFloat64(dlockAcquireTotal.WithLabelValues(DLockReconciler, resultSuccess)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(dlockAcquireTotal.WithLabelValues(DLockReconciler, resultFailed)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(dlockLostTotal.WithLabelValues(DLockReconciler)))
+
+	ExecutorRequest("K8SJOB", "create", nil)
+	ExecutorRequest("K8SJOB", "create", fmt.Errorf("conflict"))
+	assert.Equal(t, float64(2), testutil.ToFloat64(executorRequestTotal.WithLabelValues("K8SJOB", "create")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(executorErrorTotal.WithLabelValues("K8SJOB", "create")))
+}
+
+func TestPipelineAndTaskDuration(t *testing.T) {
+	// 未开始执行的不记录
+	PipelineEnd(spec.Pipeline{PipelineBase: spec.PipelineBase{Status: apistructs.PipelineStatusStopByUser, CostTimeSec: -1}})
+	PipelineEnd(spec.Pipeline{PipelineBase: spec.PipelineBase{Status: apistructs.PipelineStatusSuccess, CostTimeSec: 30}})
+	TaskEnd(spec.PipelineTask{Status: apistructs.PipelineStatusFailed, CostTimeSec: 10}, "K8SJOB")
+	assert.Equal(t, 1, testutil.CollectAndCount(pipelineDuration))
+	assert.Equal(t, 1, testutil.CollectAndCount(taskDuration))
+}
+
+func TestQueueStatsCollector(t *testing.T) {
+	SetQueueStatsLister(nil)
+	assert.Equal(t, 0, testutil.CollectAndCount(queueCollector))
+
+	oldest := time.Now().Add(-time.Minute)
+	SetQueueStatsLister(fakeQueueStatsLister{
+		{ID: "1", PendingCount: 2, ProcessingCount: 1, OldestPendingTime: &oldest},
+		{ID: "2"},
+	})
+	defer SetQueueStatsLister(nil)
+	assert.Equal(t, 6, testutil.CollectAndCount(queueCollector))
+
+	expected := `
+# HELP erda_pipeline_queue_pending Number of pipelines pending in queue.
+# TYPE erda_pipeline_queue_pending gauge
+erda_pipeline_queue_pending{queue_id="1"} 2
+erda_pipeline_queue_pending{queue_id="2"} 0
+`
+	assert.NoError(t, testutil.CollectAndCompare(queueCollector, strings.NewReader(expected), "erda_pipeline_queue_pending"))
+}
+
+func TestHandler(t *testing.T) {
+	ReconcileObserve(time.Now())
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, 200, rec.Code)
+	assert.Contains(t, rec.Body.String(), "erda_pipeline_reconcile_duration_seconds_count 1")
+}
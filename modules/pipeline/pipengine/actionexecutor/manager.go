@@ -59,7 +59,7 @@ func (m *Manager) Initialize(cfgs chan spec.ActionExecutorConfig) error {
 			return err
 		}
 
-		m.executors[name] = actionExecutor
+		m.executors[name] = withMetrics(actionExecutor)
 		logrus.Infof("=> kind [%s], name [%s], created", c.Kind, c.Name)
 	}
 
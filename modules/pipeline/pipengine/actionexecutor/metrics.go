@@ -0,0 +1,90 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package actionexecutor
+
+import (
+	"context"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/metrics"
+	"github.com/erda-project/erda/modules/pipeline/pipengine/actionexecutor/types"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+// metricsExecutor 包装 executor，记录各接口的调用次数和错误次数
+type metricsExecutor struct {
+	types.ActionExecutor
+}
+
+func withMetrics(e types.ActionExecutor) types.ActionExecutor {
+	return &metricsExecutor{ActionExecutor: e}
+}
+
+func (e *metricsExecutor) record(operation string, err error) {
+	metrics.ExecutorRequest(e.Kind().String(), operation, err)
+}
+
+func (e *metricsExecutor) Exist(ctx context.Context, action *spec.PipelineTask) (bool, bool, error) {
+	created, started, err := e.ActionExecutor.Exist(ctx, action)
+	e.record("exist", err)
+	return created, started, err
+}
+
+func (e *metricsExecutor) Create(ctx context.Context, action *spec.PipelineTask) (interface{}, error) {
+	data, err := e.ActionExecutor.Create(ctx, action)
+	e.record("create", err)
+	return data, err
+}
+
+func (e *metricsExecutor) Start(ctx context.Context, action *spec.PipelineTask) (interface{}, error) {
+	data, err := e.ActionExecutor.Start(ctx, action)
+	e.record("start", err)
+	return data, err
+}
+
+func (e *metricsExecutor) Update(ctx context.Context, action *spec.PipelineTask) (interface{}, error) {
+	data, err := e.ActionExecutor.Update(ctx, action)
+	e.record("update", err)
+	return data, err
+}
+
+func (e *metricsExecutor) Status(ctx context.Context, action *spec.PipelineTask) (apistructs.PipelineStatusDesc, error) {
+	desc, err := e.ActionExecutor.Status(ctx, action)
+	e.record("status", err)
+	return desc, err
+}
+
+func (e *metricsExecutor) Inspect(ctx context.Context, action *spec.PipelineTask) (interface{}, error) {
+	data, err := e.ActionExecutor.Inspect(ctx, action)
+	e.record("inspect", err)
+	return data, err
+}
+
+func (e *metricsExecutor) Cancel(ctx context.Context, action *spec.PipelineTask) (interface{}, error) {
+	data, err := e.ActionExecutor.Cancel(ctx, action)
+	e.record("cancel", err)
+	return data, err
+}
+
+func (e *metricsExecutor) Remove(ctx context.Context, action *spec.PipelineTask) (interface{}, error) {
+	data, err := e.ActionExecutor.Remove(ctx, action)
+	e.record("remove", err)
+	return data, err
+}
+
+func (e *metricsExecutor) BatchDelete(ctx context.Context, actions []*spec.PipelineTask) (interface{}, error) {
+	data, err := e.ActionExecutor.BatchDelete(ctx, actions)
+	e.record("batch_delete", err)
+	return data, err
+}
@@ -16,5 +16,6 @@ package actionexecutor
 import (
 	_ "github.com/erda-project/erda/modules/pipeline/pipengine/actionexecutor/plugins/apitest"
 	_ "github.com/erda-project/erda/modules/pipeline/pipengine/actionexecutor/plugins/demo"
+	_ "github.com/erda-project/erda/modules/pipeline/pipengine/actionexecutor/plugins/local"
 	_ "github.com/erda-project/erda/modules/pipeline/pipengine/actionexecutor/plugins/scheduler"
 )
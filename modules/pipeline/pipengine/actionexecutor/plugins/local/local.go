@@ -0,0 +1,227 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package local 实现了在宿主机上以普通进程方式执行 task commands 的 action executor，
+// 用于本地开发、集成测试以及自托管 agent 场景，无需依赖 Kubernetes。
+package local
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/pipengine/actionexecutor/types"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+var Kind = types.Kind(spec.PipelineTaskExecutorKindLocal)
+
+// options
+const (
+	OptionWorkdirRoot = "workdir_root" // 所有 task 工作目录的根目录，默认为系统临时目录
+	OptionShell       = "shell"        // 执行 commands 的 shell，默认为 /bin/sh
+	OptionInheritEnv  = "inherit_env"  // 是否继承 pipeline 服务自身的环境变量，默认只继承 PATH 和 HOME
+	OptionPushLog     = "push_log"     // 是否推送日志到 collector，默认为 true
+)
+
+const (
+	defaultShell       = "/bin/sh"
+	defaultWorkdirName = "pipeline-local-executor"
+)
+
+func init() {
+	types.MustRegister(Kind, func(name types.Name, options map[string]string) (types.ActionExecutor, error) {
+		return New(name, options)
+	})
+}
+
+type define struct {
+	name    types.Name
+	options map[string]string
+
+	workdirRoot string
+	shell       string
+	inheritEnv  bool
+	logPusher   *logPusher
+
+	lock      sync.Mutex
+	processes map[string]*process
+}
+
+// New create a local action executor.
+func New(name types.Name, options map[string]string) (*define, error) {
+	d := &define{
+		name:        name,
+		options:     options,
+		workdirRoot: options[OptionWorkdirRoot],
+		shell:       options[OptionShell],
+		processes:   make(map[string]*process),
+	}
+	if d.workdirRoot == "" {
+		d.workdirRoot = filepath.Join(os.TempDir(), defaultWorkdirName)
+	}
+	if d.shell == "" {
+		d.shell = defaultShell
+	}
+	if v := options[OptionInheritEnv]; v != "" {
+		inheritEnv, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid option %s: %s, err: %v", OptionInheritEnv, v, err)
+		}
+		d.inheritEnv = inheritEnv
+	}
+	pushLog := true
+	if v := options[OptionPushLog]; v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid option %s: %s, err: %v", OptionPushLog, v, err)
+		}
+		pushLog = b
+	}
+	if pushLog {
+		d.logPusher = newLogPusher()
+	}
+	if err := os.MkdirAll(d.workdirRoot, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create workdir root: %s, err: %v", d.workdirRoot, err)
+	}
+	return d, nil
+}
+
+func (d *define) Kind() types.Kind { return Kind }
+func (d *define) Name() types.Name { return d.name }
+
+func (d *define) Exist(ctx context.Context, task *spec.PipelineTask) (created bool, started bool, err error) {
+	if p := d.getProcess(task); p != nil {
+		return true, p.isStarted(), nil
+	}
+	// 进程信息只保存在内存中，服务重启后未结束的 task 会被重新创建
+	if task.Status.IsEndStatus() {
+		return true, true, nil
+	}
+	return false, false, nil
+}
+
+func (d *define) Create(ctx context.Context, task *spec.PipelineTask) (interface{}, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	key := makeProcessKey(task)
+	if _, ok := d.processes[key]; ok {
+		logrus.Warnf("local: task already created, pipelineID: %d, taskID: %d", task.PipelineID, task.ID)
+		return nil, nil
+	}
+	p, err := newProcess(d, task)
+	if err != nil {
+		return nil, err
+	}
+	d.processes[key] = p
+	return nil, nil
+}
+
+func (d *define) Start(ctx context.Context, task *spec.PipelineTask) (interface{}, error) {
+	p := d.getProcess(task)
+	if p == nil {
+		return nil, fmt.Errorf("task not created, pipelineID: %d, taskID: %d", task.PipelineID, task.ID)
+	}
+	return nil, p.start()
+}
+
+func (d *define) Update(ctx context.Context, task *spec.PipelineTask) (interface{}, error) {
+	return nil, nil
+}
+
+func (d *define) Status(ctx context.Context, task *spec.PipelineTask) (apistructs.PipelineStatusDesc, error) {
+	p := d.getProcess(task)
+	if p == nil {
+		if task.Status.IsEndStatus() {
+			return apistructs.PipelineStatusDesc{Status: task.Status}, nil
+		}
+		return apistructs.PipelineStatusDesc{Status: apistructs.PipelineStatusAnalyzed}, nil
+	}
+	return p.statusDesc(), nil
+}
+
+func (d *define) Inspect(ctx context.Context, task *spec.PipelineTask) (interface{}, error) {
+	p := d.getProcess(task)
+	if p == nil {
+		return nil, fmt.Errorf("task not created, pipelineID: %d, taskID: %d", task.PipelineID, task.ID)
+	}
+	return p.inspect(), nil
+}
+
+func (d *define) Cancel(ctx context.Context, task *spec.PipelineTask) (interface{}, error) {
+	p := d.getProcess(task)
+	if p == nil {
+		return nil, nil
+	}
+	return nil, p.cancel()
+}
+
+func (d *define) Remove(ctx context.Context, task *spec.PipelineTask) (interface{}, error) {
+	d.lock.Lock()
+	key := makeProcessKey(task)
+	p := d.processes[key]
+	delete(d.processes, key)
+	d.lock.Unlock()
+
+	if p == nil {
+		return nil, nil
+	}
+	if err := p.cancel(); err != nil {
+		logrus.Errorf("local: failed to cancel process before remove, pipelineID: %d, taskID: %d, err: %v",
+			task.PipelineID, task.ID, err)
+	}
+	// 等待进程退出后再清理工作目录
+	select {
+	case <-p.done:
+	case <-time.After(killGracePeriod * 2):
+	}
+	return nil, os.RemoveAll(p.workdir)
+}
+
+func (d *define) BatchDelete(ctx context.Context, tasks []*spec.PipelineTask) (interface{}, error) {
+	for _, task := range tasks {
+		if _, err := d.Remove(ctx, task); err != nil {
+			logrus.Errorf("local: failed to remove task, pipelineID: %d, taskID: %d, err: %v", task.PipelineID, task.ID, err)
+		}
+	}
+	return nil, nil
+}
+
+func (d *define) getProcess(task *spec.PipelineTask) *process {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.processes[makeProcessKey(task)]
+}
+
+// makeProcessKey 与 scheduler 的 jobID 保持一致，循环和重试时使用不同的 key
+func makeProcessKey(task *spec.PipelineTask) string {
+	key := task.Extra.UUID
+	if key == "" {
+		key = fmt.Sprintf("pipeline-task-%d", task.ID)
+	}
+	if opt := task.Extra.LoopOptions; opt != nil && opt.CalculatedLoop != nil && opt.CalculatedLoop.Strategy.MaxTimes > 0 {
+		key = fmt.Sprintf("%s-loop-%d", key, opt.LoopedTimes)
+	}
+	if opt := task.Extra.RetryOptions; opt != nil && len(opt.Attempts) > 0 {
+		key = fmt.Sprintf("%s-retry-%d", key, len(opt.Attempts))
+	}
+	return key
+}
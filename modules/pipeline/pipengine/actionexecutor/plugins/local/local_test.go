@@ -0,0 +1,156 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// +build !windows
+
+package local
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+	"github.com/erda-project/erda/pkg/parser/pipelineyml"
+)
+
+func newTestExecutor(t *testing.T) *define {
+	d, err := New("local", map[string]string{
+		OptionWorkdirRoot: t.TempDir(),
+		OptionPushLog:     "false",
+	})
+	assert.NoError(t, err)
+	return d
+}
+
+func newTestTask(id uint64, timeout time.Duration, commands ...string) *spec.PipelineTask {
+	task := &spec.PipelineTask{ID: id, PipelineID: 1}
+	task.Extra.Timeout = timeout
+	task.Extra.Action = pipelineyml.Action{Commands: commands}
+	task.Extra.PublicEnvs = map[string]string{"PUBLIC_ENV": "public"}
+	task.Extra.PrivateEnvs = map[string]string{"PRIVATE_ENV": "private"}
+	return task
+}
+
+func runAndWait(t *testing.T, d *define, task *spec.PipelineTask, wait time.Duration) apistructs.PipelineStatusDesc {
+	ctx := context.Background()
+	_, err := d.Create(ctx, task)
+	assert.NoError(t, err)
+	created, started, err := d.Exist(ctx, task)
+	assert.NoError(t, err)
+	assert.True(t, created)
+	assert.False(t, started)
+	_, err = d.Start(ctx, task)
+	assert.NoError(t, err)
+
+	p := d.getProcess(task)
+	select {
+	case <-p.done:
+	case <-time.After(wait):
+	}
+	status, err := d.Status(ctx, task)
+	assert.NoError(t, err)
+	return status
+}
+
+func TestLocalSuccess(t *testing.T) {
+	d := newTestExecutor(t)
+	task := newTestTask(1, time.Minute, "echo $PUBLIC_ENV", "echo $PRIVATE_ENV >&2", "pwd")
+	status := runAndWait(t, d, task, time.Second*10)
+	assert.Equal(t, apistructs.PipelineStatusSuccess, status.Status)
+
+	p := d.getProcess(task)
+	stdout, err := ioutil.ReadFile(filepath.Join(p.workdir, logDirName, "stdout.log"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(stdout), "public")
+	assert.Contains(t, string(stdout), p.workdir)
+	stderr, err := ioutil.ReadFile(filepath.Join(p.workdir, logDirName, "stderr.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, "private\n", string(stderr))
+
+	_, err = d.Remove(context.Background(), task)
+	assert.NoError(t, err)
+	assert.NoDirExists(t, p.workdir)
+}
+
+func TestLocalFailed(t *testing.T) {
+	d := newTestExecutor(t)
+	status := runAndWait(t, d, newTestTask(2, time.Minute, "echo before", "exit 3", "echo after"), time.Second*10)
+	assert.Equal(t, apistructs.PipelineStatusFailed, status.Status)
+	assert.Equal(t, "exit code: 3", status.Desc)
+}
+
+func TestLocalTimeout(t *testing.T) {
+	d := newTestExecutor(t)
+	status := runAndWait(t, d, newTestTask(3, time.Millisecond*500, "sleep 30"), time.Second*10)
+	assert.Equal(t, apistructs.PipelineStatusTimeout, status.Status)
+}
+
+func TestLocalCancel(t *testing.T) {
+	d := newTestExecutor(t)
+	task := newTestTask(4, time.Minute, "sleep 30 &", "sleep 30")
+	status := runAndWait(t, d, task, time.Millisecond*200)
+	assert.Equal(t, apistructs.PipelineStatusRunning, status.Status)
+
+	_, err := d.Cancel(context.Background(), task)
+	assert.NoError(t, err)
+	select {
+	case <-d.getProcess(task).done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("process not exit after cancel")
+	}
+	status, err = d.Status(context.Background(), task)
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.PipelineStatusStopByUser, status.Status)
+}
+
+func TestLocalCreateWithoutCommands(t *testing.T) {
+	d := newTestExecutor(t)
+	_, err := d.Create(context.Background(), newTestTask(5, time.Minute))
+	assert.Error(t, err)
+}
+
+func TestReadLogLine(t *testing.T) {
+	r := bufio.NewReaderSize(strings.NewReader("short\n"+strings.Repeat("x", 100)+"\r\nafter long line\nlast"), 16)
+
+	line, truncated, err := readLogLine(r, 32)
+	assert.NoError(t, err)
+	assert.False(t, truncated)
+	assert.Equal(t, "short", line)
+
+	// 超长行被截断，后续输出仍能读取
+	line, truncated, err = readLogLine(r, 32)
+	assert.NoError(t, err)
+	assert.True(t, truncated)
+	assert.Equal(t, strings.Repeat("x", 32), line)
+
+	line, truncated, err = readLogLine(r, 32)
+	assert.NoError(t, err)
+	assert.False(t, truncated)
+	assert.Equal(t, "after long line", line)
+
+	line, _, err = readLogLine(r, 32)
+	assert.NoError(t, err)
+	assert.Equal(t, "last", line)
+
+	_, _, err = readLogLine(r, 32)
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package local
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/pkg/discover"
+	"github.com/erda-project/erda/pkg/httpclient"
+)
+
+const (
+	asyncPushInterval = time.Second * 3
+	maxBufferedLines  = 100000
+)
+
+// logPusher 异步批量推送日志到 collector，与 scheduler 执行的 task 使用相同的日志 ID，可以通过原有方式查询日志
+type logPusher struct {
+	lock  sync.Mutex
+	lines []apistructs.LogPushLine
+}
+
+func newLogPusher() *logPusher {
+	pusher := &logPusher{}
+	go pusher.loop()
+	return pusher
+}
+
+func (l *logPusher) add(logID, stream, content string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	// collector 不可用时避免无限堆积
+	if len(l.lines) >= maxBufferedLines {
+		l.lines = l.lines[1:]
+	}
+	l.lines = append(l.lines, apistructs.LogPushLine{
+		ID:        logID,
+		Source:    string(apistructs.DashboardSpotLogSourceJob),
+		Stream:    &stream,
+		Timestamp: time.Now().UnixNano(),
+		Content:   content,
+	})
+}
+
+func (l *logPusher) loop() {
+	ticker := time.NewTicker(asyncPushInterval)
+	for range ticker.C {
+		l.push()
+	}
+}
+
+func (l *logPusher) push() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if len(l.lines) == 0 {
+		return
+	}
+	if err := pushCollectorLog(l.lines); err != nil {
+		logrus.Errorf("local: %v", err)
+		// not refresh logs, try together at next time
+		return
+	}
+	l.lines = nil
+}
+
+func pushCollectorLog(lines []apistructs.LogPushLine) error {
+	var respBody bytes.Buffer
+	resp, err := httpclient.New(httpclient.WithCompleteRedirect()).
+		Post(discover.Collector()).
+		Path("/collect/logs/job").
+		JSONBody(lines).
+		Header("Content-Type", "application/json").
+		Do().
+		Body(&respBody)
+	if err != nil {
+		return fmt.Errorf("failed to push log to collector, err: %v", err)
+	}
+	if !resp.IsOK() {
+		return fmt.Errorf("failed to push log to collector, resp body: %s", respBody.String())
+	}
+	return nil
+}
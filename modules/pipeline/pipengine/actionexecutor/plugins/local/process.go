@@ -0,0 +1,330 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package local
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+const (
+	scriptFileName  = "run.sh"
+	logDirName      = "logs"
+	killGracePeriod = time.Second * 10
+
+	// maxLogLineBytes 单行日志的最大长度，超出部分会被截断
+	maxLogLineBytes        = 1024 * 1024
+	truncatedLogLineSuffix = " ...(truncated)"
+)
+
+// process 表示一个 task 对应的宿主机进程
+type process struct {
+	key      string
+	workdir  string
+	shell    string
+	envs     []string
+	timeout  time.Duration
+	logID    string
+	pusher   *logPusher
+	commands []string
+
+	lock      sync.Mutex
+	cmd       *exec.Cmd
+	started   bool
+	finished  bool
+	cancelled bool
+	timedOut  bool
+	exitCode  int
+	err       error
+	timeBegin time.Time
+	timeEnd   time.Time
+
+	done chan struct{}
+}
+
+func newProcess(d *define, task *spec.PipelineTask) (*process, error) {
+	commands := task.Extra.Action.Commands
+	if len(commands) == 0 {
+		return nil, fmt.Errorf("local executor only support action with commands, pipelineID: %d, taskID: %d, action type: %s",
+			task.PipelineID, task.ID, task.Type)
+	}
+	key := makeProcessKey(task)
+	p := &process{
+		key:      key,
+		workdir:  filepath.Join(d.workdirRoot, key),
+		shell:    d.shell,
+		timeout:  task.Extra.Timeout,
+		logID:    task.Extra.UUID,
+		pusher:   d.logPusher,
+		commands: commands,
+		done:     make(chan struct{}),
+	}
+	// 每次创建都使用干净的工作目录
+	if err := os.RemoveAll(p.workdir); err != nil {
+		return nil, fmt.Errorf("failed to clean workdir: %s, err: %v", p.workdir, err)
+	}
+	if err := os.MkdirAll(filepath.Join(p.workdir, logDirName), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create workdir: %s, err: %v", p.workdir, err)
+	}
+	script := "set -e\n" + strings.Join(commands, "\n") + "\n"
+	if err := ioutil.WriteFile(filepath.Join(p.workdir, scriptFileName), []byte(script), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write script, err: %v", err)
+	}
+	p.envs = makeEnvs(d.inheritEnv, task, p.workdir)
+	return p, nil
+}
+
+// makeEnvs 按优先级从低到高合并：宿主机环境变量、public envs、private envs
+func makeEnvs(inheritEnv bool, task *spec.PipelineTask, workdir string) []string {
+	envs := make(map[string]string)
+	if inheritEnv {
+		for _, kv := range os.Environ() {
+			if i := strings.Index(kv, "="); i > 0 {
+				envs[kv[:i]] = kv[i+1:]
+			}
+		}
+	} else {
+		for _, k := range []string{"PATH", "HOME"} {
+			if v, ok := os.LookupEnv(k); ok {
+				envs[k] = v
+			}
+		}
+	}
+	for k, v := range task.Extra.PublicEnvs {
+		envs[k] = v
+	}
+	for k, v := range task.Extra.PrivateEnvs {
+		envs[k] = v
+	}
+	envs["WORKDIR"] = workdir
+	result := make([]string, 0, len(envs))
+	for k, v := range envs {
+		result = append(result, k+"="+v)
+	}
+	return result
+}
+
+func (p *process) isStarted() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.started
+}
+
+// start 启动进程，保证幂等
+func (p *process) start() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.started {
+		return nil
+	}
+
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if p.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+	}
+	cmd := exec.Command(p.shell, scriptFileName)
+	cmd.Dir = p.workdir
+	cmd.Env = p.envs
+	setProcessGroup(cmd)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		cancel()
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return fmt.Errorf("failed to start process, err: %v", err)
+	}
+	p.cmd = cmd
+	p.started = true
+	p.timeBegin = time.Now()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go p.collectLog(&wg, stdout, apistructs.CollectorLogPushStreamStdout)
+	go p.collectLog(&wg, stderr, apistructs.CollectorLogPushStreamStderr)
+
+	// timeout
+	go func() {
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				p.lock.Lock()
+				p.timedOut = true
+				p.lock.Unlock()
+				_ = p.kill()
+			}
+		case <-p.done:
+		}
+	}()
+
+	go func() {
+		defer cancel()
+		// 需要先读完输出再 wait
+		wg.Wait()
+		err := cmd.Wait()
+		p.lock.Lock()
+		p.finished = true
+		p.timeEnd = time.Now()
+		p.err = err
+		p.exitCode = cmd.ProcessState.ExitCode()
+		p.lock.Unlock()
+		close(p.done)
+	}()
+	return nil
+}
+
+// collectLog 将输出写入工作目录下的日志文件，并推送到 collector
+func (p *process) collectLog(wg *sync.WaitGroup, r io.Reader, stream string) {
+	defer wg.Done()
+	f, err := os.OpenFile(filepath.Join(p.workdir, logDirName, stream+".log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err == nil {
+		defer f.Close()
+	}
+	reader := bufio.NewReaderSize(r, 64*1024)
+	for {
+		line, truncated, err := readLogLine(reader, maxLogLineBytes)
+		if err != nil && line == "" {
+			break
+		}
+		if truncated {
+			line += truncatedLogLineSuffix
+		}
+		if f != nil {
+			_, _ = f.WriteString(line + "\n")
+		}
+		if p.pusher != nil {
+			p.pusher.add(p.logID, stream, line)
+		}
+		if err != nil {
+			break
+		}
+	}
+	// 读取异常时避免管道阻塞
+	_, _ = io.Copy(ioutil.Discard, r)
+}
+
+// readLogLine 读取一行输出，超过 max 的部分被丢弃，保证超长行之后的输出仍能继续读取
+func readLogLine(r *bufio.Reader, max int) (line string, truncated bool, err error) {
+	var buf []byte
+	for {
+		frag, isPrefix, err := r.ReadLine()
+		if err != nil {
+			return string(buf), truncated, err
+		}
+		if remain := max - len(buf); len(frag) > remain {
+			frag = frag[:remain]
+			truncated = true
+		}
+		buf = append(buf, frag...)
+		if !isPrefix {
+			return string(buf), truncated, nil
+		}
+	}
+}
+
+// cancel 终止进程组，保证幂等
+func (p *process) cancel() error {
+	p.lock.Lock()
+	if !p.started || p.finished {
+		p.lock.Unlock()
+		return nil
+	}
+	p.cancelled = true
+	p.lock.Unlock()
+	return p.kill()
+}
+
+// kill 先发送 SIGTERM，超过宽限期后发送 SIGKILL
+func (p *process) kill() error {
+	if err := terminateProcessGroup(p.cmd); err != nil {
+		return err
+	}
+	go func() {
+		select {
+		case <-p.done:
+		case <-time.After(killGracePeriod):
+			_ = killProcessGroup(p.cmd)
+		}
+	}()
+	return nil
+}
+
+func (p *process) statusDesc() apistructs.PipelineStatusDesc {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	switch {
+	case !p.started:
+		return apistructs.PipelineStatusDesc{Status: apistructs.PipelineStatusCreated}
+	case !p.finished:
+		return apistructs.PipelineStatusDesc{Status: apistructs.PipelineStatusRunning}
+	case p.timedOut:
+		return apistructs.PipelineStatusDesc{Status: apistructs.PipelineStatusTimeout, Desc: fmt.Sprintf("process timeout after %s", p.timeout)}
+	case p.cancelled:
+		return apistructs.PipelineStatusDesc{Status: apistructs.PipelineStatusStopByUser, Desc: "process cancelled"}
+	case p.err == nil:
+		return apistructs.PipelineStatusDesc{Status: apistructs.PipelineStatusSuccess}
+	case p.exitCode > 0:
+		return apistructs.PipelineStatusDesc{Status: apistructs.PipelineStatusFailed, Desc: fmt.Sprintf("exit code: %d", p.exitCode)}
+	default:
+		// 被信号终止等非正常退出
+		return apistructs.PipelineStatusDesc{Status: apistructs.PipelineStatusError, Desc: p.err.Error()}
+	}
+}
+
+// Inspection is the result of Inspect.
+type Inspection struct {
+	Workdir   string                        `json:"workdir"`
+	Commands  []string                      `json:"commands"`
+	Pid       int                           `json:"pid,omitempty"`
+	Status    apistructs.PipelineStatusDesc `json:"status"`
+	ExitCode  int                           `json:"exitCode"`
+	TimeBegin time.Time                     `json:"timeBegin,omitempty"`
+	TimeEnd   time.Time                     `json:"timeEnd,omitempty"`
+}
+
+func (p *process) inspect() Inspection {
+	status := p.statusDesc()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	ins := Inspection{
+		Workdir:   p.workdir,
+		Commands:  p.commands,
+		Status:    status,
+		ExitCode:  p.exitCode,
+		TimeBegin: p.timeBegin,
+		TimeEnd:   p.timeEnd,
+	}
+	if p.cmd != nil && p.cmd.Process != nil {
+		ins.Pid = p.cmd.Process.Pid
+	}
+	return ins
+}
@@ -427,7 +427,8 @@ func transferToSchedulerJob(task *spec.PipelineTask) (job apistructs.JobFromUser
 		Volumes: func() []diceyml.Volume {
 			diceVolumes := make([]diceyml.Volume, 0)
 			for _, vo := range task.Extra.Volumes {
-				if vo.Type == string(spec.StoreTypeDiceVolumeFake) || vo.Type == string(spec.StoreTypeDiceCacheNFS) {
+				if vo.Type == string(spec.StoreTypeDiceVolumeFake) || vo.Type == string(spec.StoreTypeDiceCacheNFS) ||
+					vo.Type == string(spec.StoreTypeOSS) || vo.Type == string(spec.StoreTypeDiceCacheOSS) {
 					// fake volume,没有实际挂载行为,不传给scheduler
 					continue
 				}
@@ -528,12 +529,16 @@ func printActionInfo(action *spec.PipelineTask) string {
 		action.PipelineID, action.ID, action.Name, action.Extra.Namespace, makeJobID(action))
 }
 
-// makeJobID 返回 job id。若需要循环，则在 uuid 后追加当前是第几次执行。
+// makeJobID 返回 job id。若需要循环，则在 uuid 后追加当前是第几次执行；若失败重试过，则再追加当前是第几次重试。
 func makeJobID(action *spec.PipelineTask) string {
+	jobID := action.Extra.UUID
 	if action.Extra.LoopOptions != nil && action.Extra.LoopOptions.CalculatedLoop != nil && action.Extra.LoopOptions.CalculatedLoop.Strategy.MaxTimes > 0 {
-		return fmt.Sprintf("%s-loop-%d", action.Extra.UUID, action.Extra.LoopOptions.LoopedTimes)
+		jobID = fmt.Sprintf("%s-loop-%d", action.Extra.UUID, action.Extra.LoopOptions.LoopedTimes)
 	}
-	return action.Extra.UUID
+	if action.Extra.RetryOptions != nil && len(action.Extra.RetryOptions.Attempts) > 0 {
+		jobID = fmt.Sprintf("%s-retry-%d", jobID, len(action.Extra.RetryOptions.Attempts))
+	}
+	return jobID
 }
 
 // isJobIdempotent
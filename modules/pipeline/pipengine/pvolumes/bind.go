@@ -56,7 +56,8 @@ func GenerateTaskCommonBinds(mountPoint string) []apistructs.Bind {
 
 	storageURL := conf.StorageURL()
 	URL, _ := url.Parse(storageURL)
-	if URL.Scheme == "file" {
+	// 使用对象存储的集群可能没有网盘
+	if URL.Scheme == "file" && mountPoint != "" {
 		var storageBind apistructs.Bind
 		_path := filepath.Join(mountPoint, URL.Path)
 		storageBind = apistructs.Bind{
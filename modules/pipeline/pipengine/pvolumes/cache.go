@@ -16,12 +16,15 @@ package pvolumes
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/erda-project/erda/apistructs"
 	"github.com/erda-project/erda/modules/pipeline/spec"
 	"github.com/erda-project/erda/pkg/parser/diceyml"
+	"github.com/erda-project/erda/pkg/parser/pipelineyml"
 )
 
 const (
@@ -32,8 +35,38 @@ const (
 	TaskCacheCompressionSuffix = ".tar"
 	TaskCachePathBasePath      = "{{basePath}}"
 	TaskCachePathEndPath       = "{{endPath}}"
+	TaskCacheKey               = "action_cache_key"
+	TaskCacheRestoreKeys       = "action_cache_restore_keys"
 )
 
+var taskCacheKeyIllegalCharsRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// IsDynamicTaskCache key 中使用了 hashFiles 表达式或声明了 restore_keys 时，缓存文件名由 agent 在运行时计算
+func IsDynamicTaskCache(cache pipelineyml.ActionCache) bool {
+	return strings.Contains(cache.Key, "hashFiles(") || len(cache.RestoreKeys) > 0
+}
+
+// SanitizeTaskCacheKey 将计算后的 key 转换为合法的文件名
+func SanitizeTaskCacheKey(key string) string {
+	return taskCacheKeyIllegalCharsRe.ReplaceAllString(key, "-")
+}
+
+// makeDynamicTaskCacheLabels 生成动态缓存的 key 模板和 restore_keys 标签
+// 动态缓存统一存放在项目应用目录下，key 模板中的 {{basePath}} 无意义，{{endPath}} 替换为目录 hash
+func makeDynamicTaskCacheLabels(cache pipelineyml.ActionCache, hash string, labels map[string]string) {
+	key := cache.Key
+	if key == "" {
+		key = hash
+	}
+	key = strings.TrimPrefix(strings.ReplaceAll(key, TaskCachePathBasePath, ""), "/")
+	key = strings.ReplaceAll(key, TaskCachePathEndPath, hash)
+	labels[TaskCacheKey] = key
+	if len(cache.RestoreKeys) > 0 {
+		b, _ := json.Marshal(cache.RestoreKeys)
+		labels[TaskCacheRestoreKeys] = string(b)
+	}
+}
+
 func HandleTaskCacheVolumes(p *spec.Pipeline, task *spec.PipelineTask, diceYmlJob *diceyml.Job, mountPoint string) {
 	caches := task.Extra.Action.Caches
 	if len(caches) == 0 {
@@ -54,7 +87,10 @@ func HandleTaskCacheVolumes(p *spec.Pipeline, task *spec.PipelineTask, diceYmlJo
 
 		// key 为空就根据 hash 值和一些前缀生成一个固定的挂载目录
 		key := cache.Key
-		if key == "" {
+		if IsDynamicTaskCache(cache) {
+			// 动态缓存挂载项目应用目录，由 agent 计算文件名
+			key = filepath.Join(mountPoint, TaskCacheBasePath, projectID, appID)
+		} else if key == "" {
 			key = filepath.Join(mountPoint, TaskCacheBasePath, projectID, appID, hash)
 		} else {
 			// key 不为空就需要根据占位符替换成固定的挂载目录，其中只有非占位符之间是用户可自定义的一部分
@@ -74,6 +110,9 @@ func HandleTaskCacheVolumes(p *spec.Pipeline, task *spec.PipelineTask, diceYmlJo
 		labels[VoLabelKeyContextPath] = key
 		labels[TaskCacheHashName] = hash
 		labels[TaskCachePath] = cache.Path
+		if IsDynamicTaskCache(cache) {
+			makeDynamicTaskCacheLabels(cache, hash, labels)
+		}
 		var storage = apistructs.MetadataField{
 			Name:   TaskCacheMame + "_" + hash,
 			Type:   string(spec.StoreTypeDiceCacheNFS),
@@ -0,0 +1,55 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pvolumes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+	"github.com/erda-project/erda/pkg/parser/pipelineyml"
+)
+
+func TestHandleTaskCacheVolumes(t *testing.T) {
+	p := &spec.Pipeline{}
+	p.Labels = map[string]string{apistructs.LabelProjectID: "1", apistructs.LabelAppID: "2"}
+	task := &spec.PipelineTask{}
+	task.Extra.Action.Caches = []pipelineyml.ActionCache{
+		{Path: "/root/.m2"},
+		{Path: "/root/.npm", Key: "npm-${{ hashFiles('**/package-lock.json') }}-{{endPath}}", RestoreKeys: []string{"npm-"}},
+	}
+	job := &diceyml.Job{}
+	HandleTaskCacheVolumes(p, task, job, "/netdata")
+
+	assert.Len(t, task.Context.InStorages, 2)
+	m2 := task.Context.InStorages[0]
+	assert.Equal(t, "/netdata/actions/caches/1/2/"+m2.Labels[TaskCacheHashName], m2.Value)
+	assert.False(t, IsDynamicTaskCache(task.Extra.Action.Caches[0]))
+	_, ok := m2.Labels[TaskCacheKey]
+	assert.False(t, ok)
+
+	// 动态缓存挂载项目应用目录
+	npm := task.Context.InStorages[1]
+	assert.Equal(t, "/netdata/actions/caches/1/2", npm.Value)
+	assert.Equal(t, "npm-${{ hashFiles('**/package-lock.json') }}-"+npm.Labels[TaskCacheHashName], npm.Labels[TaskCacheKey])
+	assert.Equal(t, diceyml.Binds{m2.Value + ":" + m2.Value, npm.Value + ":" + npm.Value}, job.Binds)
+}
+
+func TestSanitizeTaskCacheKey(t *testing.T) {
+	assert.Equal(t, "go-linux-abc", SanitizeTaskCacheKey("go-linux-abc"))
+	assert.Equal(t, "a-b-c-", SanitizeTaskCacheKey("a/b c/"))
+}
@@ -0,0 +1,242 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pvolumes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/minio/minio-go"
+	"github.com/minio/minio-go/pkg/credentials"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+const (
+	// PipelineStorageTypeOSS 集群配置 PIPELINE_STORAGE_TYPE 为该值时，task 上下文与缓存使用对象存储
+	PipelineStorageTypeOSS = "oss"
+
+	VoLabelKeyObjectKey = "objectKey"
+
+	ObjectStorageCompressionSuffix = ".tar.gz"
+)
+
+// ObjectStorageConfig S3 兼容对象存储配置
+type ObjectStorageConfig struct {
+	Endpoint     string
+	Region       string
+	Bucket       string
+	Prefix       string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	Secure       bool
+	// STSEndpoint 完整地址，包含协议
+	STSEndpoint string
+}
+
+// GetObjectStorageConfig 从集群信息中获取对象存储配置，未开启时返回 false
+func GetObjectStorageConfig(clusterInfo apistructs.ClusterInfoData) (*ObjectStorageConfig, bool) {
+	if !strings.EqualFold(clusterInfo.Get(apistructs.PIPELINE_STORAGE_TYPE), PipelineStorageTypeOSS) {
+		return nil, false
+	}
+	cfg := newObjectStorageConfig(func(key string) string { return clusterInfo.Get(apistructs.ClusterInfoMapKey(key)) })
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, false
+	}
+	return cfg, true
+}
+
+// ObjectStorageConfigFromEnv 从环境变量中获取对象存储配置，供 action agent 使用
+func ObjectStorageConfigFromEnv() (*ObjectStorageConfig, error) {
+	cfg := newObjectStorageConfig(os.Getenv)
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("missing object storage config, endpoint: %q, bucket: %q", cfg.Endpoint, cfg.Bucket)
+	}
+	return cfg, nil
+}
+
+func newObjectStorageConfig(get func(key string) string) *ObjectStorageConfig {
+	cfg := ObjectStorageConfig{
+		Endpoint:  get(string(apistructs.PIPELINE_STORAGE_OSS_ENDPOINT)),
+		Region:    get(string(apistructs.PIPELINE_STORAGE_OSS_REGION)),
+		Bucket:    get(string(apistructs.PIPELINE_STORAGE_OSS_BUCKET)),
+		Prefix:    strings.Trim(get(string(apistructs.PIPELINE_STORAGE_OSS_PREFIX)), "/"),
+		AccessKey: get(string(apistructs.PIPELINE_STORAGE_OSS_ACCESS_KEY)),
+		SecretKey: get(string(apistructs.PIPELINE_STORAGE_OSS_SECRET_KEY)),
+
+		SessionToken: get(string(apistructs.PIPELINE_STORAGE_OSS_SESSION_TOKEN)),
+		STSEndpoint:  strings.TrimSuffix(get(string(apistructs.PIPELINE_STORAGE_OSS_STS_ENDPOINT)), "/"),
+	}
+	// minio client 只接收 host:port，协议通过 secure 指定
+	switch {
+	case strings.HasPrefix(cfg.Endpoint, "https://"):
+		cfg.Secure = true
+		cfg.Endpoint = strings.TrimPrefix(cfg.Endpoint, "https://")
+	case strings.HasPrefix(cfg.Endpoint, "http://"):
+		cfg.Endpoint = strings.TrimPrefix(cfg.Endpoint, "http://")
+	}
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	if cfg.STSEndpoint == "" {
+		cfg.STSEndpoint = cfg.endpointURL()
+	}
+	return &cfg
+}
+
+func (cfg *ObjectStorageConfig) endpointURL() string {
+	if cfg.Secure {
+		return "https://" + cfg.Endpoint
+	}
+	return "http://" + cfg.Endpoint
+}
+
+// AgentEnvs 返回 action agent 运行时需要的对象存储环境变量，只包含临时凭证，不包含集群的 access key
+func (cfg *ObjectStorageConfig) AgentEnvs(creds *ObjectStorageCredentials) map[string]string {
+	return map[string]string{
+		string(apistructs.PIPELINE_STORAGE_OSS_ENDPOINT):      cfg.endpointURL(),
+		string(apistructs.PIPELINE_STORAGE_OSS_REGION):        cfg.Region,
+		string(apistructs.PIPELINE_STORAGE_OSS_BUCKET):        cfg.Bucket,
+		string(apistructs.PIPELINE_STORAGE_OSS_PREFIX):        cfg.Prefix,
+		string(apistructs.PIPELINE_STORAGE_OSS_ACCESS_KEY):    creds.AccessKey,
+		string(apistructs.PIPELINE_STORAGE_OSS_SECRET_KEY):    creds.SecretKey,
+		string(apistructs.PIPELINE_STORAGE_OSS_SESSION_TOKEN): creds.SessionToken,
+	}
+}
+
+// NewClient 使用配置中的凭证创建对象存储客户端
+func (cfg *ObjectStorageConfig) NewClient() (*minio.Client, error) {
+	return minio.NewWithOptions(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, cfg.SessionToken),
+		Secure: cfg.Secure,
+		Region: cfg.Region,
+	})
+}
+
+// MakeTaskContextObjectKey 生成 task namespace 在对象存储中的 key，按流水线隔离
+// {prefix}/pipelines/{pipelineID}/context/{namespace}.tar.gz
+func (cfg *ObjectStorageConfig) MakeTaskContextObjectKey(pipelineID uint64, namespace string) string {
+	return path.Join(cfg.MakePipelineContextObjectPrefix(pipelineID), namespace+ObjectStorageCompressionSuffix)
+}
+
+// MakePipelineContextObjectPrefix 流水线上下文在对象存储中的目录，以 / 结尾
+// {prefix}/pipelines/{pipelineID}/context/
+func (cfg *ObjectStorageConfig) MakePipelineContextObjectPrefix(pipelineID uint64) string {
+	return path.Join(cfg.Prefix, "pipelines", strconv.FormatUint(pipelineID, 10), "context") + "/"
+}
+
+// RemovePipelineContextObjects 删除流水线上下文目录下的所有对象，在流水线 GC 时调用
+func (cfg *ObjectStorageConfig) RemovePipelineContextObjects(pipelineID uint64) error {
+	client, err := cfg.NewClient()
+	if err != nil {
+		return err
+	}
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	keysCh := make(chan string)
+	var listErr error
+	go func() {
+		defer close(keysCh)
+		for obj := range client.ListObjectsV2(cfg.Bucket, cfg.MakePipelineContextObjectPrefix(pipelineID), true, doneCh) {
+			if obj.Err != nil {
+				listErr = obj.Err
+				return
+			}
+			keysCh <- obj.Key
+		}
+	}()
+	var removeErrs []string
+	for removeErr := range client.RemoveObjects(cfg.Bucket, keysCh) {
+		removeErrs = append(removeErrs, fmt.Sprintf("%s: %v", removeErr.ObjectName, removeErr.Err))
+	}
+	if listErr != nil {
+		return listErr
+	}
+	if len(removeErrs) > 0 {
+		return fmt.Errorf("failed to remove objects, %s", strings.Join(removeErrs, "; "))
+	}
+	return nil
+}
+
+// GenerateTaskObjectStorage 生成 task namespace 对应的对象存储
+func GenerateTaskObjectStorage(cfg *ObjectStorageConfig, task spec.PipelineTask, namespace string) apistructs.MetadataField {
+	key := cfg.MakeTaskContextObjectKey(task.PipelineID, namespace)
+	return apistructs.MetadataField{
+		Name:  namespace,
+		Value: key,
+		Type:  string(spec.StoreTypeOSS),
+		Labels: map[string]string{
+			VoLabelKeyObjectKey:     key,
+			VoLabelKeyContainerPath: MakeTaskContainerWorkdir(namespace),
+			VoLabelKeyStageOrder:    fmt.Sprintf("%d", task.Extra.StageOrder),
+		},
+	}
+}
+
+// HandleTaskCacheObjectStorages 生成 action caches 对应的对象存储，key 规则与网盘缓存一致
+// 默认 {prefix}/caches/{projectID}/{appID}/{hash}.tar.gz
+func HandleTaskCacheObjectStorages(cfg *ObjectStorageConfig, p *spec.Pipeline, task *spec.PipelineTask) {
+	caches := task.Extra.Action.Caches
+	if len(caches) == 0 {
+		return
+	}
+
+	projectID := p.GetLabel(apistructs.LabelProjectID)
+	appID := p.GetLabel(apistructs.LabelAppID)
+	basePath := path.Join(cfg.Prefix, "caches", projectID, appID)
+
+	var storages []apistructs.MetadataField
+	for _, cache := range caches {
+		hasher := sha256.New()
+		hasher.Write([]byte(cache.Path))
+		hash := hex.EncodeToString(hasher.Sum(nil))
+
+		labels := map[string]string{
+			TaskCacheHashName: hash,
+			TaskCachePath:     cache.Path,
+		}
+
+		var key string
+		switch {
+		case IsDynamicTaskCache(cache):
+			// 动态缓存的 value 为 object key 前缀，由 agent 计算完整的 key
+			key = basePath
+			makeDynamicTaskCacheLabels(cache, hash, labels)
+		case cache.Key == "":
+			key = path.Join(basePath, hash) + ObjectStorageCompressionSuffix
+		default:
+			key = strings.ReplaceAll(cache.Key, " ", "")
+			key = strings.ReplaceAll(key, TaskCachePathBasePath, basePath)
+			key = strings.ReplaceAll(key, TaskCachePathEndPath, hash)
+			key = strings.TrimPrefix(path.Clean(key), "/") + ObjectStorageCompressionSuffix
+		}
+		labels[VoLabelKeyObjectKey] = key
+
+		storages = append(storages, apistructs.MetadataField{
+			Name:   TaskCacheMame + "_" + hash,
+			Type:   string(spec.StoreTypeDiceCacheOSS),
+			Value:  key,
+			Labels: labels,
+		})
+	}
+
+	task.Context.InStorages = append(task.Context.InStorages, storages...)
+	task.Context.OutStorages = append(task.Context.OutStorages, storages...)
+}
@@ -0,0 +1,255 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pvolumes
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+const (
+	stsDefaultRegion = "us-east-1"
+	stsVersion       = "2011-06-15"
+	stsTimeout       = time.Second * 10
+
+	// 临时凭证有效期，STS 要求最短 15 分钟，最长 12 小时
+	minTaskCredentialDuration = time.Minute * 15
+	maxTaskCredentialDuration = time.Hour * 12
+	// taskCredentialDurationBuffer task 超时后仍需要上传上下文与缓存
+	taskCredentialDurationBuffer = time.Minute * 10
+)
+
+// ObjectStorageCredentials STS 颁发的临时凭证
+type ObjectStorageCredentials struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	Expiration   time.Time
+}
+
+type objectStoragePolicy struct {
+	Version   string                         `json:"Version"`
+	Statement []objectStoragePolicyStatement `json:"Statement"`
+}
+
+type objectStoragePolicyStatement struct {
+	Effect    string                         `json:"Effect"`
+	Action    []string                       `json:"Action"`
+	Resource  []string                       `json:"Resource"`
+	Condition map[string]map[string][]string `json:"Condition,omitempty"`
+}
+
+// MakeTaskObjectStoragePolicy 生成 task 临时凭证的权限：
+// 只能读写当前流水线的上下文目录、task 引用的上下文，以及 task 声明的缓存
+func MakeTaskObjectStoragePolicy(cfg *ObjectStorageConfig, task *spec.PipelineTask) (string, error) {
+	objects := map[string]struct{}{
+		cfg.MakePipelineContextObjectPrefix(task.PipelineID) + "*": {},
+	}
+	listPrefixes := map[string]struct{}{}
+	for _, vo := range append(task.Context.InStorages, task.Context.OutStorages...) {
+		switch vo.Type {
+		case string(spec.StoreTypeOSS):
+			objects[vo.Value] = struct{}{}
+		case string(spec.StoreTypeDiceCacheOSS):
+			// 动态缓存的 value 为目录，由 agent 计算完整的 key 并按前缀查找
+			if _, ok := vo.Labels[TaskCacheKey]; ok {
+				dir := strings.TrimSuffix(vo.Value, "/") + "/"
+				objects[dir+"*"] = struct{}{}
+				listPrefixes[dir+"*"] = struct{}{}
+				continue
+			}
+			objects[vo.Value] = struct{}{}
+		}
+	}
+
+	bucketARN := "arn:aws:s3:::" + cfg.Bucket
+	policy := objectStoragePolicy{
+		Version: "2012-10-17",
+		Statement: []objectStoragePolicyStatement{
+			{
+				Effect:   "Allow",
+				Action:   []string{"s3:GetObject", "s3:PutObject", "s3:AbortMultipartUpload", "s3:ListMultipartUploadParts"},
+				Resource: sortedKeys(objects, func(k string) string { return bucketARN + "/" + k }),
+			},
+			{
+				// 未配置 region 时客户端需要查询 bucket 所在 region
+				Effect:   "Allow",
+				Action:   []string{"s3:GetBucketLocation"},
+				Resource: []string{bucketARN},
+			},
+		},
+	}
+	if len(listPrefixes) > 0 {
+		policy.Statement = append(policy.Statement, objectStoragePolicyStatement{
+			Effect:    "Allow",
+			Action:    []string{"s3:ListBucket"},
+			Resource:  []string{bucketARN},
+			Condition: map[string]map[string][]string{"StringLike": {"s3:prefix": sortedKeys(listPrefixes, nil)}},
+		})
+	}
+	b, err := json.Marshal(policy)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func sortedKeys(m map[string]struct{}, convert func(string) string) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		if convert != nil {
+			k = convert(k)
+		}
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// TaskObjectStorageCredentialDuration 临时凭证有效期，覆盖 task 的超时时间
+func TaskObjectStorageCredentialDuration(task *spec.PipelineTask) time.Duration {
+	if task.Extra.Timeout <= 0 {
+		return maxTaskCredentialDuration
+	}
+	d := task.Extra.Timeout + taskCredentialDurationBuffer
+	if d < minTaskCredentialDuration {
+		return minTaskCredentialDuration
+	}
+	if d > maxTaskCredentialDuration {
+		return maxTaskCredentialDuration
+	}
+	return d
+}
+
+type assumeRoleResponse struct {
+	Result struct {
+		Credentials struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"Credentials"`
+	} `xml:"AssumeRoleResult"`
+}
+
+// AssumeRole 通过 STS AssumeRole 获取只拥有 policy 权限的临时凭证
+func (cfg *ObjectStorageConfig) AssumeRole(policy string, duration time.Duration) (*ObjectStorageCredentials, error) {
+	form := url.Values{}
+	form.Set("Action", "AssumeRole")
+	form.Set("Version", stsVersion)
+	form.Set("DurationSeconds", strconv.FormatInt(int64(duration/time.Second), 10))
+	form.Set("Policy", policy)
+	body := form.Encode()
+
+	req, err := http.NewRequest(http.MethodPost, cfg.STSEndpoint, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	signSTSRequestV4(req, body, cfg.AccessKey, cfg.SecretKey, cfg.Region, time.Now().UTC())
+
+	resp, err := (&http.Client{Timeout: stsTimeout}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to assume role, status: %d, body: %s", resp.StatusCode, string(respBody))
+	}
+	var result assumeRoleResponse
+	if err := xml.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse assume role response, err: %v", err)
+	}
+	creds := result.Result.Credentials
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, fmt.Errorf("assume role response missing credentials")
+	}
+	return &ObjectStorageCredentials{
+		AccessKey:    creds.AccessKeyID,
+		SecretKey:    creds.SecretAccessKey,
+		SessionToken: creds.SessionToken,
+		Expiration:   creds.Expiration,
+	}, nil
+}
+
+// signSTSRequestV4 使用 AWS Signature V4 对 STS 请求签名
+func signSTSRequestV4(req *http.Request, body, accessKey, secretKey, region string, t time.Time) {
+	if region == "" {
+		region = stsDefaultRegion
+	}
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	canonicalURI := req.URL.EscapedPath()
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := strings.Join([]string{
+		"content-type:" + req.Header.Get("Content-Type"),
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+	}, "\n") + "\n"
+	canonicalRequest := strings.Join([]string{
+		req.Method, canonicalURI, req.URL.RawQuery, canonicalHeaders, signedHeaders, payloadHash,
+	}, "\n")
+
+	scope := path.Join(date, region, "sts", "aws4_request")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex(canonicalRequest)}, "\n")
+
+	signature := hex.EncodeToString(hmacSHA256(makeSigningKeyV4(secretKey, date, region, "sts"), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func makeSigningKeyV4(secretKey, date, region, service string) []byte {
+	signingKey := hmacSHA256([]byte("AWS4"+secretKey), date)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, service)
+	return hmacSHA256(signingKey, "aws4_request")
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
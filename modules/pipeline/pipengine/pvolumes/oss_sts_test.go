@@ -0,0 +1,112 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pvolumes
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+	"github.com/erda-project/erda/pkg/parser/pipelineyml"
+)
+
+func TestMakeTaskObjectStoragePolicy(t *testing.T) {
+	cfg := &ObjectStorageConfig{Endpoint: "minio:9000", Bucket: "pipeline", Prefix: "erda"}
+	p := &spec.Pipeline{}
+	p.Labels = map[string]string{apistructs.LabelProjectID: "1", apistructs.LabelAppID: "2"}
+	task := &spec.PipelineTask{PipelineID: 10}
+	task.Context.InStorages = []apistructs.MetadataField{{Type: string(spec.StoreTypeOSS), Value: "erda/pipelines/9/context/repo.tar.gz"}}
+	task.Extra.Action.Caches = []pipelineyml.ActionCache{
+		{Path: "/root/.m2"},
+		{Path: "/root/go/pkg/mod", Key: "go-${{ hashFiles('**/go.sum') }}"},
+	}
+	HandleTaskCacheObjectStorages(cfg, p, task)
+
+	policyStr, err := MakeTaskObjectStoragePolicy(cfg, task)
+	assert.NoError(t, err)
+	var policy objectStoragePolicy
+	assert.NoError(t, json.Unmarshal([]byte(policyStr), &policy))
+	assert.Len(t, policy.Statement, 3)
+	assert.Equal(t, []string{
+		"arn:aws:s3:::pipeline/erda/caches/1/2/*",
+		"arn:aws:s3:::pipeline/erda/caches/1/2/" + task.Context.InStorages[1].Labels[TaskCacheHashName] + ".tar.gz",
+		"arn:aws:s3:::pipeline/erda/pipelines/10/context/*",
+		"arn:aws:s3:::pipeline/erda/pipelines/9/context/repo.tar.gz",
+	}, policy.Statement[0].Resource)
+	assert.Equal(t, []string{"erda/caches/1/2/*"}, policy.Statement[2].Condition["StringLike"]["s3:prefix"])
+}
+
+func TestTaskObjectStorageCredentialDuration(t *testing.T) {
+	task := &spec.PipelineTask{}
+	assert.Equal(t, maxTaskCredentialDuration, TaskObjectStorageCredentialDuration(task))
+	task.Extra.Timeout = time.Minute
+	assert.Equal(t, minTaskCredentialDuration, TaskObjectStorageCredentialDuration(task))
+	task.Extra.Timeout = time.Hour
+	assert.Equal(t, time.Hour+taskCredentialDurationBuffer, TaskObjectStorageCredentialDuration(task))
+	task.Extra.Timeout = time.Hour * 24
+	assert.Equal(t, maxTaskCredentialDuration, TaskObjectStorageCredentialDuration(task))
+}
+
+func TestObjectStorageConfig_AssumeRole(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "AssumeRole", r.PostForm.Get("Action"))
+		assert.Equal(t, "3600", r.PostForm.Get("DurationSeconds"))
+		assert.Equal(t, `{"Version":"2012-10-17"}`, r.PostForm.Get("Policy"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=ak/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/us-east-1/sts/aws4_request")
+		fmt.Fprint(w, `<AssumeRoleResponse><AssumeRoleResult><Credentials>
+<AccessKeyId>tmp-ak</AccessKeyId><SecretAccessKey>tmp-sk</SecretAccessKey><SessionToken>token</SessionToken>
+<Expiration>2021-07-20T00:00:00Z</Expiration></Credentials></AssumeRoleResult></AssumeRoleResponse>`)
+	}))
+	defer server.Close()
+
+	cfg := &ObjectStorageConfig{AccessKey: "ak", SecretKey: "sk", STSEndpoint: server.URL}
+	creds, err := cfg.AssumeRole(`{"Version":"2012-10-17"}`, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, "tmp-ak", creds.AccessKey)
+	assert.Equal(t, "tmp-sk", creds.SecretKey)
+	assert.Equal(t, "token", creds.SessionToken)
+
+	cfg.SecretKey = "wrong"
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	_, err = cfg.AssumeRole("{}", time.Hour)
+	assert.Error(t, err)
+}
+
+// 使用 AWS 文档中的示例校验签名密钥的计算
+func TestMakeSigningKeyV4(t *testing.T) {
+	key := makeSigningKeyV4("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+	assert.Equal(t, "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d", hex.EncodeToString(key))
+}
+
+func TestSignSTSRequestV4(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "https://sts.amazonaws.com/", strings.NewReader("Action=AssumeRole"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	signSTSRequestV4(req, "Action=AssumeRole", "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+	auth := req.Header.Get("Authorization")
+	assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/sts/aws4_request, SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date, Signature="))
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pvolumes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+	"github.com/erda-project/erda/pkg/parser/pipelineyml"
+)
+
+func TestGetObjectStorageConfig(t *testing.T) {
+	_, ok := GetObjectStorageConfig(apistructs.ClusterInfoData{})
+	assert.False(t, ok)
+
+	cfg, ok := GetObjectStorageConfig(apistructs.ClusterInfoData{
+		apistructs.PIPELINE_STORAGE_TYPE:         "OSS",
+		apistructs.PIPELINE_STORAGE_OSS_ENDPOINT: "https://minio.local:9000/",
+		apistructs.PIPELINE_STORAGE_OSS_BUCKET:   "pipeline",
+		apistructs.PIPELINE_STORAGE_OSS_PREFIX:   "/erda/",
+	})
+	assert.True(t, ok)
+	assert.Equal(t, "minio.local:9000", cfg.Endpoint)
+	assert.True(t, cfg.Secure)
+	assert.Equal(t, "erda", cfg.Prefix)
+	assert.Equal(t, "https://minio.local:9000", cfg.STSEndpoint)
+	envs := cfg.AgentEnvs(&ObjectStorageCredentials{AccessKey: "tmp-ak", SecretKey: "tmp-sk", SessionToken: "token"})
+	assert.Equal(t, "https://minio.local:9000", envs[string(apistructs.PIPELINE_STORAGE_OSS_ENDPOINT)])
+	assert.Equal(t, "tmp-ak", envs[string(apistructs.PIPELINE_STORAGE_OSS_ACCESS_KEY)])
+	assert.Equal(t, "token", envs[string(apistructs.PIPELINE_STORAGE_OSS_SESSION_TOKEN)])
+	assert.Equal(t, "erda/pipelines/10/context/git-checkout.tar.gz", cfg.MakeTaskContextObjectKey(10, "git-checkout"))
+}
+
+func TestHandleTaskCacheObjectStorages(t *testing.T) {
+	cfg := &ObjectStorageConfig{Endpoint: "minio:9000", Bucket: "pipeline"}
+	p := &spec.Pipeline{}
+	p.Labels = map[string]string{apistructs.LabelProjectID: "1", apistructs.LabelAppID: "2"}
+	task := &spec.PipelineTask{}
+	task.Extra.Action.Caches = []pipelineyml.ActionCache{
+		{Path: "/root/.m2"},
+		{Path: "/root/.npm", Key: "{{basePath}}/npm/{{endPath}}"},
+		{Path: "/root/go/pkg/mod", Key: "{{basePath}}/go-${{ hashFiles('**/go.sum') }}", RestoreKeys: []string{"go-"}},
+	}
+	HandleTaskCacheObjectStorages(cfg, p, task)
+
+	assert.Len(t, task.Context.InStorages, 3)
+	assert.Len(t, task.Context.OutStorages, 3)
+	m2 := task.Context.InStorages[0]
+	assert.Equal(t, string(spec.StoreTypeDiceCacheOSS), m2.Type)
+	assert.Equal(t, "caches/1/2/"+m2.Labels[TaskCacheHashName]+".tar.gz", m2.Value)
+	npm := task.Context.InStorages[1]
+	assert.Equal(t, "caches/1/2/npm/"+npm.Labels[TaskCacheHashName]+".tar.gz", npm.Value)
+	gomod := task.Context.InStorages[2]
+	assert.Equal(t, "caches/1/2", gomod.Value)
+	assert.Equal(t, "go-${{ hashFiles('**/go.sum') }}", gomod.Labels[TaskCacheKey])
+	assert.Equal(t, `["go-"]`, gomod.Labels[TaskCacheRestoreKeys])
+}
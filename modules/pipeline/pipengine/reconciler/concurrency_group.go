@@ -0,0 +1,122 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package reconciler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/events"
+	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler/rlog"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+const (
+	// concurrencyGroupCheckInterval 等待并发组内其他流水线结束的检查间隔
+	concurrencyGroupCheckInterval = time.Second * 5
+)
+
+// waitConcurrencyGroup 流水线配置了并发组且不取消组内运行中的流水线时，入队前等待组内其他流水线结束，与队列容量无关。
+// 先标记为等待中再检查，同时运行的流水线按 ID 先后依次执行。
+func (r *Reconciler) waitConcurrencyGroup(pipelineID uint64) {
+	var eventEmitted bool
+	for {
+		p, err := r.dbClient.GetPipeline(pipelineID)
+		if err != nil {
+			rlog.PErrorf(pipelineID, "failed to get pipeline when waiting concurrency group, err: %v", err)
+			return
+		}
+		opt := p.Extra.ConcurrencyOptions
+		if opt == nil || opt.CancelInProgress || p.IsSnippet || p.Status.IsEndStatus() {
+			return
+		}
+		if !opt.Waiting {
+			if err := r.markConcurrencyGroupWaiting(&p, true); err != nil {
+				rlog.PErrorf(pipelineID, "failed to mark pipeline as waiting concurrency group, err: %v", err)
+				time.Sleep(concurrencyGroupCheckInterval)
+				continue
+			}
+		}
+
+		blockers, err := r.listConcurrencyGroupBlockers(&p)
+		if err != nil {
+			rlog.PErrorf(pipelineID, "failed to list pipelines in concurrency group %s, err: %v", opt.Group, err)
+			time.Sleep(concurrencyGroupCheckInterval)
+			continue
+		}
+		if len(blockers) == 0 {
+			if err := r.markConcurrencyGroupWaiting(&p, false); err != nil {
+				rlog.PErrorf(pipelineID, "failed to unmark pipeline as waiting concurrency group, err: %v", err)
+				time.Sleep(concurrencyGroupCheckInterval)
+				continue
+			}
+			return
+		}
+		if !eventEmitted {
+			rlog.PInfof(pipelineID, "waiting for pipelines in concurrency group %s: %v", opt.Group, blockers)
+			events.EmitPipelineStreamReasonEvent(pipelineID, EventComponentReconciler, events.EventLevelNormal,
+				apistructs.PipelineEventReasonConcurrencyGroupWaiting,
+				fmt.Sprintf("waiting for pipelines %v in the same concurrency group: %s", blockers, opt.Group))
+			eventEmitted = true
+		}
+		time.Sleep(concurrencyGroupCheckInterval)
+	}
+}
+
+// markConcurrencyGroupWaiting 更新等待标记，开始等待时流水线进入排队状态
+func (r *Reconciler) markConcurrencyGroupWaiting(p *spec.Pipeline, waiting bool) error {
+	p.Extra.ConcurrencyOptions.Waiting = waiting
+	if err := r.dbClient.UpdatePipelineExtraExtraInfoByPipelineID(p.ID, p.Extra); err != nil {
+		return err
+	}
+	if !waiting || p.Status != apistructs.PipelineStatusAnalyzed {
+		return nil
+	}
+	if err := r.dbClient.UpdatePipelineBaseStatus(p.ID, apistructs.PipelineStatusQueue); err != nil {
+		return err
+	}
+	p.Status = apistructs.PipelineStatusQueue
+	events.EmitPipelineInstanceEvent(p, p.GetRunUserID())
+	return nil
+}
+
+// listConcurrencyGroupBlockers 组内未在等待的运行中流水线，以及 ID 更小的等待中流水线
+func (r *Reconciler) listConcurrencyGroupBlockers(p *spec.Pipeline) ([]uint64, error) {
+	runningPipelineIDs, err := r.dbClient.ListConcurrencyGroupRunningPipelineIDs(p)
+	if err != nil {
+		return nil, err
+	}
+	var blockers []uint64
+	for _, runningPipelineID := range runningPipelineIDs {
+		extra, found, err := r.dbClient.GetPipelineExtraByPipelineID(runningPipelineID)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			continue
+		}
+		if isConcurrencyGroupBlocker(p.ID, runningPipelineID, extra.Extra.ConcurrencyOptions) {
+			blockers = append(blockers, runningPipelineID)
+		}
+	}
+	return blockers, nil
+}
+
+func isConcurrencyGroupBlocker(pipelineID, otherPipelineID uint64, otherOpt *apistructs.PipelineConcurrencyOptions) bool {
+	if otherOpt != nil && otherOpt.Waiting {
+		return otherPipelineID < pipelineID
+	}
+	return true
+}
@@ -0,0 +1,31 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+func TestIsConcurrencyGroupBlocker(t *testing.T) {
+	// 组内运行中且未在等待的流水线
+	assert.True(t, isConcurrencyGroupBlocker(2, 3, nil))
+	assert.True(t, isConcurrencyGroupBlocker(2, 3, &apistructs.PipelineConcurrencyOptions{Group: "master"}))
+	// 等待中的流水线按 ID 先后执行
+	assert.True(t, isConcurrencyGroupBlocker(2, 1, &apistructs.PipelineConcurrencyOptions{Group: "master", Waiting: true}))
+	assert.False(t, isConcurrencyGroupBlocker(2, 3, &apistructs.PipelineConcurrencyOptions{Group: "master", Waiting: true}))
+}
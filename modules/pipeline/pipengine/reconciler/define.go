@@ -52,8 +52,12 @@ type Reconciler struct {
 
 	// processingTasks store task id which is in processing
 	processingTasks sync.Map
+	// schedulableLock 保证并发计算 schedulable tasks 时 matrix max-parallel 计数准确
+	schedulableLock sync.Mutex
 	// teardownPipelines store pipeline id which is in the process of tear down
 	teardownPipelines sync.Map
+	// timeoutWatchers store pipeline id which is watching timeout
+	timeoutWatchers sync.Map
 
 	// svc
 	actionAgentSvc  *actionagentsvc.ActionAgentSvc
@@ -80,6 +84,7 @@ func New(js jsonstore.JsonStore, etcd *etcd.Store, bdl *bundle.Bundle, dbClient
 
 		processingTasks:   sync.Map{},
 		teardownPipelines: sync.Map{},
+		timeoutWatchers:   sync.Map{},
 
 		actionAgentSvc:  actionAgentSvc,
 		extMarketSvc:    extMarketSvc,
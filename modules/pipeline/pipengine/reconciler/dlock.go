@@ -17,6 +17,7 @@ import (
 	"context"
 	"strconv"
 
+	"github.com/erda-project/erda/modules/pipeline/metrics"
 	"github.com/erda-project/erda/pkg/dlock"
 	"github.com/erda-project/erda/pkg/strutil"
 )
@@ -31,11 +32,18 @@ func lockPipeline(ctx context.Context, pipelineID uint64, dLockLostFunc func())
 	if err != nil {
 		return nil, err
 	}
-	lock, err := dlock.New(lockKey, dLockLostFunc, dlock.WithTTL(30))
+	lock, err := dlock.New(lockKey, func() {
+		metrics.DLockLost(metrics.DLockReconciler)
+		if dLockLostFunc != nil {
+			dLockLostFunc()
+		}
+	}, dlock.WithTTL(30))
 	if err != nil {
 		return nil, err
 	}
-	if err := lock.Lock(ctx); err != nil {
+	err = lock.Lock(ctx)
+	metrics.DLockAcquire(metrics.DLockReconciler, err)
+	if err != nil {
 		return nil, err
 	}
 	return lock, nil
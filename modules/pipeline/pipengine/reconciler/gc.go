@@ -26,6 +26,7 @@ import (
 	"github.com/erda-project/erda/apistructs"
 	"github.com/erda-project/erda/modules/pipeline/pipengine/actionexecutor"
 	"github.com/erda-project/erda/modules/pipeline/pipengine/actionexecutor/types"
+	"github.com/erda-project/erda/modules/pipeline/pipengine/pvolumes"
 	"github.com/erda-project/erda/modules/pipeline/spec"
 	"github.com/erda-project/erda/pkg/jsonstore/storetypes"
 	"github.com/erda-project/erda/pkg/strutil"
@@ -211,6 +212,7 @@ func (r *Reconciler) gcNamespace(namespace string, subKeys ...string) error {
 		namespace, strutil.Join(subKeys, ", ", true))
 
 	affectedPipelineIDs := make([]uint64, 0, len(subKeys))
+	affectedClusterNames := make(map[uint64]string, len(subKeys)) // key: pipelineID
 
 	for _, subKey := range subKeys {
 		pipelineIDStr := strutil.TrimPrefixes(subKey, gcPrefixKey)
@@ -231,6 +233,9 @@ func (r *Reconciler) gcNamespace(namespace string, subKeys ...string) error {
 			}
 		}
 		affectedPipelineIDs = append(affectedPipelineIDs, pipelineID)
+		if found {
+			affectedClusterNames[pipelineID] = p.ClusterName
+		}
 	}
 
 	// group tasks by executorName
@@ -271,6 +276,10 @@ func (r *Reconciler) gcNamespace(namespace string, subKeys ...string) error {
 			continue
 		}
 	}
+	// 清理对象存储中的流水线上下文
+	if err := r.gcObjectStorageContexts(affectedClusterNames); err != nil {
+		batchDeleteErrs = append(batchDeleteErrs, err.Error())
+	}
 	if len(batchDeleteErrs) > 0 {
 		return fmt.Errorf("failed to gc namespace: %s, errs: %s", namespace, strings.Join(batchDeleteErrs, ", "))
 	}
@@ -284,6 +293,35 @@ func (r *Reconciler) gcNamespace(namespace string, subKeys ...string) error {
 	return nil
 }
 
+// gcObjectStorageContexts 删除对象存储中 {prefix}/pipelines/{pipelineID}/context/ 下的所有对象，未开启对象存储的集群直接跳过
+func (r *Reconciler) gcObjectStorageContexts(clusterNames map[uint64]string) error {
+	clusterInfos := make(map[string]apistructs.ClusterInfoData)
+	var errs []string
+	for pipelineID, clusterName := range clusterNames {
+		clusterInfo, ok := clusterInfos[clusterName]
+		if !ok {
+			var err error
+			clusterInfo, err = r.bdl.QueryClusterInfo(clusterName)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("failed to query cluster info, clusterName: %s, err: %v", clusterName, err))
+				continue
+			}
+			clusterInfos[clusterName] = clusterInfo
+		}
+		objectStorage, ok := pvolumes.GetObjectStorageConfig(clusterInfo)
+		if !ok {
+			continue
+		}
+		if err := objectStorage.RemovePipelineContextObjects(pipelineID); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to remove context objects, pipelineID: %d, err: %v", pipelineID, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
 func getPipelineNamespaceFromGCWatchedKey(key string) string {
 	return strutil.TrimPrefixes(key, etcdReconcilerGCWatchPrefix)
 }
@@ -44,6 +44,9 @@ func (r *Reconciler) Listen() {
 						return
 					}
 
+					// wait other pipelines in the same concurrency group, regardless of queue capacity
+					r.waitConcurrencyGroup(pipelineID)
+
 					// add into queue
 					popCh, needRetryIfErr, err := r.QueueManager.PutPipelineIntoQueue(pipelineID)
 					if err != nil {
@@ -61,7 +64,7 @@ func (r *Reconciler) Listen() {
 						return
 					}
 					rlog.PInfof(pipelineID, "added into queue, waiting to pop from the queue")
-					<-popCh
+					r.waitPopFromQueue(pipelineID, popCh)
 					rlog.PInfof(pipelineID, "pop from the queue, begin reconcile")
 
 					// construct context for pipeline reconciler
@@ -14,6 +14,7 @@
 package reconciler
 
 import (
+	"github.com/erda-project/erda/modules/pipeline/metrics"
 	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler/queuemanage/manager"
 )
 
@@ -21,6 +22,8 @@ import (
 func (r *Reconciler) loadQueueManger() error {
 	// init queue manager
 	r.QueueManager = manager.New(manager.WithDBClient(r.dbClient))
+	// expose queue stats to prometheus
+	metrics.SetQueueStatsLister(r.QueueManager)
 
 	return nil
 }
@@ -17,6 +17,7 @@ import (
 	"github.com/erda-project/erda-proto-go/pipeline/pb"
 	"github.com/erda-project/erda/apistructs"
 	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler/queuemanage/queue"
+	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler/queuemanage/types"
 )
 
 func (mgr *defaultManager) QueryQueueUsage(pq *apistructs.PipelineQueue) *pb.QueueUsage {
@@ -30,3 +31,25 @@ func (mgr *defaultManager) QueryQueueUsage(pq *apistructs.PipelineQueue) *pb.Que
 	usage := q.Usage()
 	return &usage
 }
+
+func (mgr *defaultManager) QueryQueueTenantUsages(pq *apistructs.PipelineQueue) []*apistructs.PipelineQueueTenantUsage {
+	mgr.qLock.RLock()
+	defer mgr.qLock.RUnlock()
+	q, ok := mgr.queueByID[queue.New(pq).ID()]
+	if !ok {
+		return nil
+	}
+
+	return q.TenantUsages()
+}
+
+func (mgr *defaultManager) ListQueueStats() []types.QueueStats {
+	mgr.qLock.RLock()
+	defer mgr.qLock.RUnlock()
+
+	stats := make([]types.QueueStats, 0, len(mgr.queueByID))
+	for _, q := range mgr.queueByID {
+		stats = append(stats, q.Stats())
+	}
+	return stats
+}
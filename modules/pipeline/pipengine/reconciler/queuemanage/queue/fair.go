@@ -0,0 +1,193 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package queue
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/pipengine/queue/priorityqueue"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+const defaultFairWeight int64 = 1
+
+// getPipelineTenant 获取流水线所属租户：优先使用项目，否则使用流水线来源
+func getPipelineTenant(p *spec.Pipeline) string {
+	if projectID := p.GetLabel(apistructs.LabelProjectID); projectID != "" {
+		return "project-" + projectID
+	}
+	return "source-" + p.PipelineSource.String()
+}
+
+// getTenantWeights 从队列 labels 中解析租户权重
+func getTenantWeights(pq *apistructs.PipelineQueue) map[string]int64 {
+	weights := make(map[string]int64)
+	for k, v := range pq.Labels {
+		if !strings.HasPrefix(k, apistructs.PipelineQueueFairWeightLabelPrefix) {
+			continue
+		}
+		weight, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || weight <= 0 {
+			continue
+		}
+		weights[strings.TrimPrefix(k, apistructs.PipelineQueueFairWeightLabelPrefix)] = weight
+	}
+	return weights
+}
+
+func getTenantWeight(weights map[string]int64, tenant string) int64 {
+	if weight, ok := weights[tenant]; ok {
+		return weight
+	}
+	return defaultFairWeight
+}
+
+// itemLess keeps the same order as priority queue: higher priority first, then earlier creation time.
+func itemLess(a, b priorityqueue.Item) bool {
+	if a.Priority() == b.Priority() {
+		return a.CreationTime().Before(b.CreationTime())
+	}
+	return a.Priority() > b.Priority()
+}
+
+// fairOrder 计算 FAIR 模式下 pending item 的尝试顺序。
+// 每个租户是一个虚拟子队列，子队列内部按优先级排序；
+// 每次从 (processing 数 / 权重) 最小的租户中取出队首，权重相同时即为轮询。
+func fairOrder(pendingByTenant map[string][]priorityqueue.Item, processingByTenant map[string]int64, weights map[string]int64) []priorityqueue.Item {
+	var tenants []string
+	total := 0
+	for tenant, items := range pendingByTenant {
+		sort.SliceStable(items, func(i, j int) bool { return itemLess(items[i], items[j]) })
+		tenants = append(tenants, tenant)
+		total += len(items)
+	}
+	sort.Strings(tenants)
+
+	counts := make(map[string]int64, len(processingByTenant))
+	for tenant, count := range processingByTenant {
+		counts[tenant] = count
+	}
+	heads := make(map[string]int, len(tenants))
+
+	ordered := make([]priorityqueue.Item, 0, total)
+	for len(ordered) < total {
+		var selected string
+		for _, tenant := range tenants {
+			if heads[tenant] >= len(pendingByTenant[tenant]) {
+				continue
+			}
+			if selected == "" {
+				selected = tenant
+				continue
+			}
+			// compare counts[tenant]/weight[tenant] with counts[selected]/weight[selected]
+			left := counts[tenant] * getTenantWeight(weights, selected)
+			right := counts[selected] * getTenantWeight(weights, tenant)
+			if left < right || (left == right &&
+				itemLess(pendingByTenant[tenant][heads[tenant]], pendingByTenant[selected][heads[selected]])) {
+				selected = tenant
+			}
+		}
+		ordered = append(ordered, pendingByTenant[selected][heads[selected]])
+		heads[selected]++
+		counts[selected]++
+	}
+	return ordered
+}
+
+// fairOrderedPendingItems return pending items in fair order.
+func (q *defaultQueue) fairOrderedPendingItems() []priorityqueue.Item {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	processingByTenant := make(map[string]int64)
+	q.eq.ProcessingQueue().Range(func(item priorityqueue.Item) (stopRange bool) {
+		if p := q.pipelineCaches[parsePipelineIDFromQueueItem(item)]; p != nil {
+			processingByTenant[getPipelineTenant(p)]++
+		}
+		return false
+	})
+	pendingByTenant := make(map[string][]priorityqueue.Item)
+	q.eq.PendingQueue().Range(func(item priorityqueue.Item) (stopRange bool) {
+		// item without cache will be removed when handled, put it into an empty tenant
+		var tenant string
+		if p := q.pipelineCaches[parsePipelineIDFromQueueItem(item)]; p != nil {
+			tenant = getPipelineTenant(p)
+		}
+		pendingByTenant[tenant] = append(pendingByTenant[tenant], item)
+		return false
+	})
+
+	return fairOrder(pendingByTenant, processingByTenant, getTenantWeights(q.pq))
+}
+
+// TenantUsages return each tenant's usage of the queue.
+func (q *defaultQueue) TenantUsages() []*apistructs.PipelineQueueTenantUsage {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	weights := getTenantWeights(q.pq)
+	usageByTenant := make(map[string]*apistructs.PipelineQueueTenantUsage)
+	getUsage := func(tenant string) *apistructs.PipelineQueueTenantUsage {
+		usage, ok := usageByTenant[tenant]
+		if !ok {
+			usage = &apistructs.PipelineQueueTenantUsage{Tenant: tenant, Weight: getTenantWeight(weights, tenant)}
+			usageByTenant[tenant] = usage
+		}
+		return usage
+	}
+
+	var totalProcessing int64
+	q.eq.ProcessingQueue().Range(func(item priorityqueue.Item) (stopRange bool) {
+		p := q.pipelineCaches[parsePipelineIDFromQueueItem(item)]
+		if p == nil {
+			return false
+		}
+		usage := getUsage(getPipelineTenant(p))
+		resources := p.GetPipelineAppliedResources()
+		usage.ProcessingCount++
+		usage.InUseCPU += resources.Requests.CPU
+		usage.InUseMemoryMB += resources.Requests.MemoryMB
+		totalProcessing++
+		return false
+	})
+	q.eq.PendingQueue().Range(func(item priorityqueue.Item) (stopRange bool) {
+		p := q.pipelineCaches[parsePipelineIDFromQueueItem(item)]
+		if p == nil {
+			return false
+		}
+		getUsage(getPipelineTenant(p)).PendingCount++
+		return false
+	})
+
+	var totalWeight int64
+	for _, usage := range usageByTenant {
+		totalWeight += usage.Weight
+	}
+	usages := make([]*apistructs.PipelineQueueTenantUsage, 0, len(usageByTenant))
+	for _, usage := range usageByTenant {
+		if totalProcessing > 0 {
+			usage.Share = float64(usage.ProcessingCount) / float64(totalProcessing)
+		}
+		if totalWeight > 0 {
+			usage.ExpectedShare = float64(usage.Weight) / float64(totalWeight)
+		}
+		usages = append(usages, usage)
+	}
+	sort.Slice(usages, func(i, j int) bool { return usages[i].Tenant < usages[j].Tenant })
+	return usages
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/pipengine/queue/priorityqueue"
+)
+
+func orderedKeys(items []priorityqueue.Item) []string {
+	var keys []string
+	for _, item := range items {
+		keys = append(keys, item.Key())
+	}
+	return keys
+}
+
+func TestFairOrder(t *testing.T) {
+	now := time.Now()
+	newItems := func(keys ...string) []priorityqueue.Item {
+		var items []priorityqueue.Item
+		for i, key := range keys {
+			items = append(items, priorityqueue.NewItem(key, 10, now.Add(time.Duration(i)*time.Second)))
+		}
+		return items
+	}
+
+	// round-robin
+	ordered := fairOrder(map[string][]priorityqueue.Item{
+		"project-1": newItems("1", "2", "3", "4"),
+		"project-2": newItems("5", "6"),
+	}, nil, nil)
+	assert.Equal(t, []string{"1", "5", "2", "6", "3", "4"}, orderedKeys(ordered))
+
+	// tenant already processing has lower priority
+	ordered = fairOrder(map[string][]priorityqueue.Item{
+		"project-1": newItems("1", "2"),
+		"project-2": newItems("5", "6"),
+	}, map[string]int64{"project-1": 2}, nil)
+	assert.Equal(t, []string{"5", "6", "1", "2"}, orderedKeys(ordered))
+
+	// weighted
+	ordered = fairOrder(map[string][]priorityqueue.Item{
+		"project-1": newItems("1", "2", "3", "4"),
+		"project-2": newItems("5", "6", "7"),
+	}, nil, map[string]int64{"project-1": 3})
+	assert.Equal(t, []string{"1", "5", "2", "3", "6", "4", "7"}, orderedKeys(ordered))
+}
+
+func TestGetTenantWeights(t *testing.T) {
+	weights := getTenantWeights(&apistructs.PipelineQueue{Labels: map[string]string{
+		"fair-weight/project-1":   "3",
+		"fair-weight/source-dice": "invalid",
+		"fair-weight/project-2":   "-1",
+		"other":                   "2",
+	}})
+	assert.Equal(t, map[string]int64{"project-1": 3}, weights)
+	assert.Equal(t, int64(3), getTenantWeight(weights, "project-1"))
+	assert.Equal(t, int64(1), getTenantWeight(weights, "project-2"))
+}
@@ -21,3 +21,10 @@ func (q *defaultQueue) IsStrictMode() bool {
 
 	return q.pq.Mode == apistructs.PipelineQueueModeStrict
 }
+
+func (q *defaultQueue) IsFairMode() bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.pq.Mode == apistructs.PipelineQueueModeFair
+}
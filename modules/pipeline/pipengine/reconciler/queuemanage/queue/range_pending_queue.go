@@ -56,7 +56,7 @@ func (q *defaultQueue) RangePendingQueue() {
 			q.unsetNeedReRangePendingQueueFlag()
 		}
 	}()
-	q.eq.PendingQueue().Range(func(item priorityqueue.Item) (stopRange bool) {
+	q.rangePendingItems(func(item priorityqueue.Item) (stopRange bool) {
 		// fast reRange
 		defer func() {
 			if q.needReRangePendingQueue() {
@@ -95,7 +95,13 @@ func (q *defaultQueue) RangePendingQueue() {
 		// precheck before run
 		customKVsOfAOP := map[interface{}]interface{}{}
 		ctx := aop.NewContextForPipeline(*p, aoptypes.TuneTriggerPipelineInQueuePrecheckBeforePop, customKVsOfAOP)
-		_ = aop.Handle(ctx)
+		if err := aop.Handle(ctx); aoptypes.IsAbortError(err) {
+			q.emitEvent(p, FailedQueue,
+				fmt.Sprintf("queue precheck aborted by aop, stop and remove from queue, reason: %v", err),
+				events.EventLevelWarning)
+			q.ensureMarkPipelineFailed(p)
+			return q.doStopAndRemove(item)
+		}
 		checkResultI, ok := ctx.TryGet(apistructs.PipelinePreCheckResultContextKey)
 		if !ok {
 			// no result, log and wait for another retry
@@ -149,6 +155,24 @@ func (q *defaultQueue) RangePendingQueue() {
 	})
 }
 
+// rangePendingItems range pending items by priority;
+// for fair mode, range items from tenant sub-queues by weight.
+func (q *defaultQueue) rangePendingItems(f func(item priorityqueue.Item) (stopRange bool)) {
+	if !q.IsFairMode() {
+		q.eq.PendingQueue().Range(f)
+		return
+	}
+	for _, item := range q.fairOrderedPendingItems() {
+		// item may be popped out by others
+		if !q.eq.InPending(item.Key()) {
+			continue
+		}
+		if f(item) {
+			return
+		}
+	}
+}
+
 func (q *defaultQueue) doPop(item priorityqueue.Item) (stopRange bool) {
 	// pop now
 	poppedKey := q.eq.PopPendingKey(item.Key())
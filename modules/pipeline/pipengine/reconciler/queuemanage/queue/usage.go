@@ -20,6 +20,7 @@ import (
 
 	"github.com/erda-project/erda-proto-go/pipeline/pb"
 	"github.com/erda-project/erda/modules/pipeline/pipengine/queue/priorityqueue"
+	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler/queuemanage/types"
 	"github.com/erda-project/erda/pkg/numeral"
 )
 
@@ -84,3 +85,22 @@ func (q *defaultQueue) Usage() pb.QueueUsage {
 		PendingDetails:    pendingDetails,
 	}
 }
+
+func (q *defaultQueue) Stats() types.QueueStats {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+
+	stats := types.QueueStats{
+		ID:              q.ID(),
+		PendingCount:    q.eq.PendingQueue().Len(),
+		ProcessingCount: q.eq.ProcessingQueue().Len(),
+	}
+	q.eq.PendingQueue().Range(func(item priorityqueue.Item) (stopRange bool) {
+		creationTime := item.CreationTime()
+		if stats.OldestPendingTime == nil || creationTime.Before(*stats.OldestPendingTime) {
+			stats.OldestPendingTime = &creationTime
+		}
+		return false
+	})
+	return stats
+}
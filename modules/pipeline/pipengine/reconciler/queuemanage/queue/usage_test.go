@@ -0,0 +1,41 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/pipengine/queue/priorityqueue"
+)
+
+func TestDefaultQueue_Stats(t *testing.T) {
+	q := New(&apistructs.PipelineQueue{ID: 1, Concurrency: 1})
+	stats := q.Stats()
+	assert.Equal(t, "1", stats.ID)
+	assert.Equal(t, 0, stats.PendingCount)
+	assert.Nil(t, stats.OldestPendingTime)
+
+	now := time.Now()
+	q.eq.Add("p-2", 10, now)
+	q.eq.Add("p-1", 10, now.Add(-time.Minute))
+	q.eq.ProcessingQueue().Add(priorityqueue.NewItem("p-0", 10, now.Add(-time.Hour)))
+	stats = q.Stats()
+	assert.Equal(t, 2, stats.PendingCount)
+	assert.Equal(t, 1, stats.ProcessingCount)
+	assert.True(t, now.Add(-time.Minute).Equal(*stats.OldestPendingTime))
+}
@@ -22,6 +22,8 @@ import (
 type QueueManager interface {
 	IdempotentAddQueue(pq *apistructs.PipelineQueue) Queue
 	QueryQueueUsage(pq *apistructs.PipelineQueue) *pb.QueueUsage
+	QueryQueueTenantUsages(pq *apistructs.PipelineQueue) []*apistructs.PipelineQueueTenantUsage
+	ListQueueStats() []QueueStats
 	PutPipelineIntoQueue(pipelineID uint64) (popCh <-chan struct{}, needRetryIfErr bool, err error)
 	PopOutPipelineFromQueue(pipelineID uint64)
 }
@@ -14,6 +14,8 @@
 package types
 
 import (
+	"time"
+
 	"github.com/erda-project/erda-proto-go/pipeline/pb"
 	"github.com/erda-project/erda/apistructs"
 	"github.com/erda-project/erda/modules/pipeline/spec"
@@ -24,9 +26,21 @@ type Queue interface {
 	Start(stopCh chan struct{})
 	ID() string
 	IsStrictMode() bool
+	IsFairMode() bool
 	Usage() pb.QueueUsage
+	Stats() QueueStats
+	TenantUsages() []*apistructs.PipelineQueueTenantUsage
 	Update(pq *apistructs.PipelineQueue)
 	RangePendingQueue()
 	AddPipelineIntoQueue(p *spec.Pipeline, doneCh chan struct{})
 	PopOutPipeline(p *spec.Pipeline)
 }
+
+// QueueStats 队列内存中的实时统计，用于监控指标
+type QueueStats struct {
+	ID              string
+	PendingCount    int
+	ProcessingCount int
+	// OldestPendingTime 排队最久的流水线进入队列的时间，没有排队中的流水线时为 nil
+	OldestPendingTime *time.Time
+}
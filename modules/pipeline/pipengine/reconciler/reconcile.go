@@ -27,6 +27,7 @@ import (
 	"github.com/erda-project/erda/apistructs"
 	"github.com/erda-project/erda/modules/pipeline/commonutil/statusutil"
 	"github.com/erda-project/erda/modules/pipeline/events"
+	"github.com/erda-project/erda/modules/pipeline/metrics"
 	"github.com/erda-project/erda/modules/pipeline/pipengine/actionexecutor"
 	"github.com/erda-project/erda/modules/pipeline/pipengine/actionexecutor/types"
 	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler/rlog"
@@ -36,6 +37,8 @@ import (
 
 // reconcile do pipeline reconcile.
 func (r *Reconciler) reconcile(ctx context.Context, pipelineID uint64) error {
+	reconcileBegin := time.Now()
+
 	// judge if dlock lost
 	if ctx.Err() != nil {
 		rlog.PWarnf(pipelineID, "no need reconcile, dlock already lost, err: %v", ctx.Err())
@@ -100,6 +103,9 @@ func (r *Reconciler) reconcile(ctx context.Context, pipelineID uint64) error {
 		return nil
 	}
 
+	// watch pipeline and stage timeout
+	r.watchTimeout(ctx, p)
+
 	logrus.Infof("reconciler: pipelineID: %d, pipeline is not completed, continue reconcile, currentStatus: %s",
 		p.ID, p.Status)
 
@@ -230,6 +236,7 @@ func (r *Reconciler) reconcile(ctx context.Context, pipelineID uint64) error {
 			return
 		}()
 	}
+	metrics.ReconcileObserve(reconcileBegin)
 	wg.Wait()
 
 	return nil
@@ -16,9 +16,11 @@ package reconciler
 import (
 	"sort"
 
+	"github.com/erda-project/erda/apistructs"
 	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler/rlog"
 	"github.com/erda-project/erda/modules/pipeline/spec"
 	"github.com/erda-project/erda/pkg/dag"
+	"github.com/erda-project/erda/pkg/parser/pipelineyml"
 	"github.com/erda-project/erda/pkg/strutil"
 )
 
@@ -34,7 +36,7 @@ func (r *Reconciler) getSchedulableTasks(p *spec.Pipeline, tasks []*spec.Pipelin
 	_dag, err := dag.New(dagNodes,
 		// pipeline DAG 中目前可以禁用任意节点，即 dag.WithAllowMarkArbitraryNodesAsDone=true
 		dag.WithAllowMarkArbitraryNodesAsDone(true),
-		// 不做 cycle check，因为 pipeline.yml 写法保证一定无环，即 dag.WithAllowNotCheckCycle=true
+		// 不做 cycle check，因为 pipeline.yml 解析时已通过 NeedsVisitor 校验无环，即 dag.WithAllowNotCheckCycle=true
 		dag.WithAllowNotCheckCycle(true),
 	)
 	if err != nil {
@@ -52,14 +54,32 @@ func (r *Reconciler) getSchedulableTasks(p *spec.Pipeline, tasks []*spec.Pipelin
 	for _, task := range tasks {
 		taskMap[task.Name] = task
 	}
-	var schedulableTasks []*spec.PipelineTask
+	var candidateTasks []*spec.PipelineTask
 	for nodeName := range schedulableNodeFromDAG {
 		// get task by nodeName
-		task := taskMap[nodeName]
+		candidateTasks = append(candidateTasks, taskMap[nodeName])
+	}
+	// 按创建顺序调度，matrix 展开的 tasks 按序号依次执行
+	sort.Slice(candidateTasks, func(i, j int) bool { return candidateTasks[i].ID < candidateTasks[j].ID })
+
+	r.schedulableLock.Lock()
+	defer r.schedulableLock.Unlock()
+	matrixRunning := r.countMatrixRunningTasks(tasks)
+	var schedulableTasks []*spec.PipelineTask
+	for _, task := range candidateTasks {
+		// matrix 展开的 tasks 同时执行的数量不能超过 max-parallel
+		origin := task.Extra.Action.MatrixOrigin
+		limited := origin != nil && origin.MaxParallel > 0
+		if limited && matrixRunning[origin.Alias] >= origin.MaxParallel {
+			continue
+		}
 		// if task is already processing by another goroutine, skip
 		if _, alreadyProcessing := r.processingTasks.LoadOrStore(task.ID, true); alreadyProcessing {
 			continue
 		}
+		if limited {
+			matrixRunning[origin.Alias]++
+		}
 		schedulableTasks = append(schedulableTasks, task)
 	}
 
@@ -79,3 +99,22 @@ func (r *Reconciler) getSchedulableTasks(p *spec.Pipeline, tasks []*spec.Pipelin
 
 	return schedulableTasks, nil
 }
+
+// countMatrixRunningTasks 统计每个 matrix action 展开后正在执行的 tasks 数量
+func (r *Reconciler) countMatrixRunningTasks(tasks []*spec.PipelineTask) map[pipelineyml.ActionAlias]int {
+	running := make(map[pipelineyml.ActionAlias]int)
+	for _, task := range tasks {
+		origin := task.Extra.Action.MatrixOrigin
+		if origin == nil || origin.MaxParallel <= 0 {
+			continue
+		}
+		if task.Status.IsEndStatus() || task.Status == apistructs.PipelineStatusDisabled {
+			continue
+		}
+		_, processing := r.processingTasks.Load(task.ID)
+		if processing || (task.Status != apistructs.PipelineStatusAnalyzed && task.Status != apistructs.PipelineStatusPaused) {
+			running[origin.Alias]++
+		}
+	}
+	return running
+}
@@ -13,6 +13,17 @@
 
 package reconciler
 
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+	"github.com/erda-project/erda/pkg/parser/pipelineyml"
+)
+
 //import (
 //	"sync"
 //	"testing"
@@ -60,3 +71,39 @@ package reconciler
 //		})
 //	}
 //}
+
+func TestGetSchedulableTasks_MatrixMaxParallel(t *testing.T) {
+	matrixTask := func(id uint64, index int, status apistructs.PipelineStatus) *spec.PipelineTask {
+		task := &spec.PipelineTask{ID: id, Name: fmt.Sprintf("test-%d", index), Status: status}
+		task.Extra.RunAfter = []string{"repo"}
+		task.Extra.Action.MatrixOrigin = &pipelineyml.MatrixOrigin{Alias: "test", Index: index, MaxParallel: 2}
+		return task
+	}
+	p := &spec.Pipeline{PipelineBase: spec.PipelineBase{ID: 1}}
+
+	r := Reconciler{}
+	tasks := []*spec.PipelineTask{
+		{ID: 1, Name: "repo", Status: apistructs.PipelineStatusSuccess},
+		matrixTask(2, 0, apistructs.PipelineStatusAnalyzed),
+		matrixTask(3, 1, apistructs.PipelineStatusAnalyzed),
+		matrixTask(4, 2, apistructs.PipelineStatusAnalyzed),
+	}
+	schedulableTasks, err := r.getSchedulableTasks(p, tasks)
+	assert.NoError(t, err)
+	assert.Len(t, schedulableTasks, 2)
+	assert.Equal(t, uint64(2), schedulableTasks[0].ID)
+	assert.Equal(t, uint64(3), schedulableTasks[1].ID)
+
+	// 两个 task 仍在执行，不能调度第三个
+	schedulableTasks, err = r.getSchedulableTasks(p, tasks)
+	assert.NoError(t, err)
+	assert.Empty(t, schedulableTasks)
+
+	// 一个 task 执行完成后调度第三个
+	tasks[1].Status = apistructs.PipelineStatusSuccess
+	r.processingTasks.Delete(tasks[1].ID)
+	schedulableTasks, err = r.getSchedulableTasks(p, tasks)
+	assert.NoError(t, err)
+	assert.Len(t, schedulableTasks, 1)
+	assert.Equal(t, uint64(4), schedulableTasks[0].ID)
+}
@@ -174,6 +174,7 @@ func (r *Reconciler) copyParentPipelineRunInfo(snippetPipeline *spec.Pipeline) e
 	}
 	snippetPipeline.Snapshot.PlatformSecrets = rootPipeline.Snapshot.PlatformSecrets
 	snippetPipeline.Snapshot.Secrets = rootPipeline.Snapshot.Secrets
+	snippetPipeline.Snapshot.EncryptSecretKeys = rootPipeline.Snapshot.EncryptSecretKeys
 	snippetPipeline.Snapshot.Envs = rootPipeline.Snapshot.Envs
 
 	// 处理 runParams，嵌套流水线的 runParams 即为 parentSnippetTask 的 params，已经在创建时存入，其中占位符需要被替换
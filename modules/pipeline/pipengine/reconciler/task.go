@@ -17,8 +17,8 @@ import (
 	"time"
 
 	"github.com/erda-project/erda/apistructs"
-	"github.com/erda-project/erda/modules/pipeline/aop"
 	"github.com/erda-project/erda/modules/pipeline/aop/aoptypes"
+	"github.com/erda-project/erda/modules/pipeline/metrics"
 	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler/rlog"
 	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler/taskrun"
 	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler/taskrun/taskop"
@@ -35,8 +35,11 @@ func reconcileTask(tr *taskrun.TaskRun) error {
 	// }()
 	// do aop
 	rlog.TDebugf(tr.P.ID, tr.Task.ID, "start do task aop")
-	if err := aop.Handle(aop.NewContextForTask(*tr.Task, *tr.P, aoptypes.TuneTriggerTaskBeforeExec)); err != nil {
-		rlog.TErrorf(tr.P.ID, tr.Task.ID, "failed to handle aop, type: %s, err: %v", aoptypes.TuneTriggerTaskBeforeExec, err)
+	if err := tr.HandleAOP(aoptypes.TuneTriggerTaskBeforeExec); err != nil {
+		rlog.TDebugf(tr.P.ID, tr.Task.ID, "end do task aop")
+		tr.MarkFailedByAOP(aoptypes.TuneTriggerTaskBeforeExec, err)
+		tr.Update()
+		return nil
 	}
 	rlog.TDebugf(tr.P.ID, tr.Task.ID, "end do task aop")
 
@@ -96,6 +99,11 @@ func reconcileTask(tr *taskrun.TaskRun) error {
 			// 没有异常，执行后续逻辑
 		}
 
+		// 失败重试，task 已重置为初始状态
+		if retried := handleTaskRetry(tr); retried {
+			continue
+		}
+
 		// 非终态，继续推进
 		if !tr.Task.Status.IsEndStatus() {
 			continue
@@ -112,6 +120,7 @@ func reconcileTask(tr *taskrun.TaskRun) error {
 		}
 
 		if tr.Task.Status.IsEndStatus() {
+			metrics.TaskEnd(*tr.Task, tr.Executor.Kind().String())
 			return nil
 		}
 	}
@@ -137,7 +146,8 @@ func resetTaskForAbnormalRetry(tr *taskrun.TaskRun, abnormalErrRetryTimes int) {
 	rlog.TDebugf(tr.P.ID, tr.Task.ID, "sleep %s before retry abnormal retry", interval.String())
 	time.Sleep(interval)
 
-	// 更新状态
+	// 更新状态，异常重试时不再等待失败重试的退避时间
+	tr.RetryPending = false
 	tr.Task.Status = apistructs.PipelineStatusAnalyzed
 	tr.Update()
 }
@@ -0,0 +1,50 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package reconciler
+
+import (
+	"time"
+
+	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler/rlog"
+	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler/taskrun"
+)
+
+// handleTaskRetry 任务失败需要重试时（taskrun 已在写入结束状态前记录本次执行并重置 task），等待退避时间；
+// 等待期间被外部取消时同样返回 true，由调用方根据 PExit 退出
+func handleTaskRetry(tr *taskrun.TaskRun) bool {
+	if !tr.RetryPending {
+		return false
+	}
+	tr.RetryPending = false
+
+	// 等待退避时间，期间响应外部取消
+	select {
+	case <-tr.Ctx.Done():
+		// 被外部取消，task 保持重置后的状态，重新推进时即为下一次执行
+		tr.PExit = true
+		rlog.TWarnf(tr.P.ID, tr.Task.ID, "received stop reconcile signal during retry backoff, reason: %s", tr.Ctx.Err())
+		return true
+	case <-time.After(tr.RetryInterval):
+	}
+
+	// 退避时间可能很长，等待结束后再次校验最新状态
+	tr.EnsureFetchLatestPipelineStatus()
+	if tr.QueriedPipelineStatus.IsEndStatus() {
+		rlog.TWarnf(tr.P.ID, tr.Task.ID, "pipeline is already end status (%s), not retry task after sleep", tr.QueriedPipelineStatus)
+		tr.Task.Status = tr.LastRetryAttemptStatus()
+		tr.Update()
+		return false
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package taskrun
+
+import (
+	"fmt"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/aop"
+	"github.com/erda-project/erda/modules/pipeline/aop/aoptypes"
+	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler/rlog"
+)
+
+// HandleAOP 执行 task 级别的调音点，只有调音点要求中断时才返回错误，其余错误仅记录日志
+func (tr *TaskRun) HandleAOP(trigger aoptypes.TuneTrigger) error {
+	err := aop.Handle(aop.NewContextForTask(*tr.Task, *tr.P, trigger))
+	if err == nil {
+		return nil
+	}
+	if aoptypes.IsAbortError(err) {
+		return err
+	}
+	rlog.TErrorf(tr.P.ID, tr.Task.ID, "failed to handle aop, type: %s, err: %v", trigger, err)
+	return nil
+}
+
+// MarkFailedByAOP 调音点要求中断时将 task 置为失败并记录原因，由调用方负责更新
+func (tr *TaskRun) MarkFailedByAOP(trigger aoptypes.TuneTrigger, err error) {
+	rlog.TErrorf(tr.P.ID, tr.Task.ID, "aborted by aop, type: %s, mark task failed, err: %v", trigger, err)
+	tr.Task.Status = apistructs.PipelineStatusFailed
+	tr.Task.Result.Errors = append(tr.Task.Result.Errors, apistructs.ErrorResponse{
+		Msg: fmt.Sprintf("aborted by aop (%s): %v", trigger, err),
+	})
+}
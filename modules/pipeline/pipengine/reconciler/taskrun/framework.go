@@ -22,7 +22,6 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/erda-project/erda/apistructs"
-	"github.com/erda-project/erda/modules/pipeline/aop"
 	"github.com/erda-project/erda/modules/pipeline/conf"
 	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler/rlog"
 	"github.com/erda-project/erda/pkg/loop"
@@ -41,7 +40,7 @@ func (tr *TaskRun) Do(itr TaskOp) error {
 		_ = loop.New(loop.WithDeclineRatio(2), loop.WithDeclineLimit(time.Minute)).
 			Do(func() (bool, error) { return tr.fetchLatestTask() == nil, nil })
 
-		if tr.Task.Status.IsEndStatus() {
+		if tr.Task.Status.IsEndStatus() || tr.RetryPending {
 			o.ExitCh <- struct{}{}
 			return
 		}
@@ -76,8 +75,15 @@ func (tr *TaskRun) Do(itr TaskOp) error {
 		}
 
 		// aop: before processing
-		if itr.TuneTriggers().BeforeProcessing != "" {
-			_ = aop.Handle(aop.NewContextForTask(*tr.Task, *tr.P, itr.TuneTriggers().BeforeProcessing))
+		if trigger := itr.TuneTriggers().BeforeProcessing; trigger != "" {
+			if err := tr.HandleAOP(trigger); err != nil {
+				// task 置为失败后由 handleProcessingResult 结束本次 op
+				tr.MarkFailedByAOP(trigger, err)
+				tr.PrepareRetry()
+				tr.Update()
+				handleProcessingResult(nil, nil)
+				return
+			}
 		}
 
 		// processing op
@@ -110,6 +116,8 @@ func (tr *TaskRun) waitOp(itr TaskOp, o *Elem) (result error) {
 		// if we invoke `tr.fetchLatestTask` method here before `update`,
 		// we will lost changes made by `WhenXXX` methods.
 
+		// 需要重试时在同一次更新中写入重置后的状态
+		tr.PrepareRetry()
 		tr.Update()
 
 		if len(errs) > 0 {
@@ -136,7 +144,9 @@ func (tr *TaskRun) waitOp(itr TaskOp, o *Elem) (result error) {
 			errs = append(errs, err.Error())
 		}
 		// aop
-		_ = aop.Handle(aop.NewContextForTask(*tr.Task, *tr.P, itr.TuneTriggers().AfterProcessing))
+		if err := tr.HandleAOP(itr.TuneTriggers().AfterProcessing); err != nil {
+			tr.MarkFailedByAOP(itr.TuneTriggers().AfterProcessing, err)
+		}
 
 	case err := <-o.ErrCh:
 		logrus.Errorf("reconciler: pipelineID: %d, task %q %s received error (%v)", tr.P.ID, tr.Task.Name, itr.Op(), err)
@@ -0,0 +1,226 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package taskrun
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler/rlog"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+var (
+	// 匹配 executor 返回描述中的退出码，例如 exit code: 137, exitCode=1
+	exitCodeRe = regexp.MustCompile(`(?i)exit[ _]?code[:= ]*(\d+)`)
+
+	imagePullKeywords = []string{"imagepullbackoff", "errimagepull", "image pull", "pull image", "pulling image"}
+	oomKeywords       = []string{"oomkilled", "out of memory", "oom killed"}
+)
+
+const (
+	exitCodeOOMKilled       = 137
+	defaultRetryMaxInterval = time.Minute * 10
+)
+
+// JudgeRetry 根据 task 的 retry 配置和失败原因判断是否需要重试，返回命中的失败原因。
+func (tr *TaskRun) JudgeRetry() (apistructs.PipelineTaskRetryReason, bool) {
+	opt := tr.Task.Extra.RetryOptions
+	if opt == nil || opt.Retry == nil {
+		return "", false
+	}
+	status := tr.Task.Status
+	// 用户取消、系统判定无需执行不重试
+	if !status.IsFailedStatus() || status == apistructs.PipelineStatusStopByUser || status == apistructs.PipelineStatusNoNeedBySystem {
+		return "", false
+	}
+	// 已达最大执行次数
+	if tr.CurrentAttempt() >= opt.Retry.MaxAttempts {
+		return "", false
+	}
+
+	reasons, exitCode, hasExitCode := parseFailedReasons(status, tr.EndStatusDesc.Desc)
+	if hasExitCode {
+		for _, code := range opt.Retry.ExitCodes {
+			if code == exitCode {
+				return apistructs.PipelineTaskRetryReasonExitCode, true
+			}
+		}
+	}
+	// 未声明任何条件时，任意失败均重试
+	if len(opt.Retry.When) == 0 && len(opt.Retry.ExitCodes) == 0 {
+		return reasons[len(reasons)-1], true
+	}
+	for _, reason := range reasons {
+		for _, when := range opt.Retry.When {
+			if reason == when {
+				return reason, true
+			}
+		}
+	}
+	return "", false
+}
+
+// CurrentAttempt 返回当前循环内是第几次执行，从 1 开始
+func (tr *TaskRun) CurrentAttempt() int {
+	opt := tr.Task.Extra.RetryOptions
+	if opt == nil {
+		return 1
+	}
+	var loopedTimes uint64
+	if tr.Task.Extra.LoopOptions != nil {
+		loopedTimes = tr.Task.Extra.LoopOptions.LoopedTimes
+	}
+	attempt := 1
+	for _, a := range opt.Attempts {
+		if a.LoopedTimes == loopedTimes {
+			attempt++
+		}
+	}
+	return attempt
+}
+
+// RecordRetryAttempt 记录本次失败的执行
+func (tr *TaskRun) RecordRetryAttempt(reason apistructs.PipelineTaskRetryReason) {
+	var loopedTimes uint64
+	if tr.Task.Extra.LoopOptions != nil {
+		loopedTimes = tr.Task.Extra.LoopOptions.LoopedTimes
+	}
+	tr.Task.Extra.RetryOptions.Attempts = append(tr.Task.Extra.RetryOptions.Attempts, apistructs.PipelineTaskRetryAttempt{
+		Attempt:     tr.CurrentAttempt(),
+		LoopedTimes: loopedTimes,
+		Status:      tr.Task.Status,
+		Reason:      reason,
+		Desc:        tr.EndStatusDesc.Desc,
+		TimeBegin:   tr.Task.TimeBegin,
+		TimeEnd:     tr.Task.TimeEnd,
+		CostTimeSec: tr.Task.CostTimeSec,
+		Errors:      tr.Task.Result.Errors,
+	})
+}
+
+// PrepareRetry 在写入任务结束状态前判断是否需要重试；需要时记录本次执行并重置任务，
+// 使失败状态与重置在同一次更新中写入，避免 pipeline 被判定为失败
+func (tr *TaskRun) PrepareRetry() {
+	if tr.RetryPending || !tr.Task.Status.IsEndStatus() {
+		return
+	}
+	reason, need := tr.JudgeRetry()
+	if !need {
+		return
+	}
+	// pipeline 终态则不重试 task
+	tr.EnsureFetchLatestPipelineStatus()
+	if tr.QueriedPipelineStatus.IsEndStatus() {
+		rlog.TWarnf(tr.P.ID, tr.Task.ID, "pipeline is already end status (%s), not try to retry task", tr.QueriedPipelineStatus)
+		return
+	}
+	tr.RetryInterval = tr.CalculateRetryInterval()
+	rlog.TWarnf(tr.P.ID, tr.Task.ID, "task failed (status: %s, reason: %s, attempt: %d/%d), retry after %s",
+		tr.Task.Status, reason, tr.CurrentAttempt(), tr.Task.Extra.RetryOptions.Retry.MaxAttempts, tr.RetryInterval.String())
+	tr.RecordRetryAttempt(reason)
+	tr.resetForRetry()
+	tr.RetryPending = true
+}
+
+// LastRetryAttemptStatus 返回最近一次记录的失败状态
+func (tr *TaskRun) LastRetryAttemptStatus() apistructs.PipelineStatus {
+	attempts := tr.Task.Extra.RetryOptions.Attempts
+	return attempts[len(attempts)-1].Status
+}
+
+func (tr *TaskRun) resetForRetry() {
+	tr.EndStatusDesc = apistructs.PipelineStatusDesc{}
+	tr.QuitQueueTimeout = false
+	tr.QuitWaitTimeout = false
+	tr.StopQueueLoop = false
+	tr.StopWaitLoop = false
+	tr.FakeTimeout = false
+
+	// 重置任务状态，重新开始执行
+	tr.Task.Status = apistructs.PipelineStatusAnalyzed
+	// 重置时间，全部以最后一次时间为准，历史时间记录在 retry attempts 中
+	tr.Task.CostTimeSec = -1
+	tr.Task.QueueTimeSec = -1
+	tr.Task.Extra.TimeBeginQueue = time.Time{}
+	tr.Task.Extra.TimeEndQueue = time.Time{}
+	tr.Task.TimeBegin = time.Time{}
+	tr.Task.TimeEnd = time.Time{}
+	// 重置任务结果
+	tr.Task.Result = apistructs.PipelineTaskResult{}
+	// 重置 Volume
+	tr.Task.Context = spec.PipelineTaskContext{}
+	tr.Task.Extra.Volumes = nil
+}
+
+// CalculateRetryInterval 根据退避策略计算下次重试前的等待时间
+func (tr *TaskRun) CalculateRetryInterval() time.Duration {
+	retry := tr.Task.Extra.RetryOptions.Retry
+	intervalSec := retry.IntervalSec
+	if intervalSec == 0 {
+		intervalSec = apistructs.PipelineTaskDefaultRetryIntervalSec
+	}
+	if retry.Backoff != apistructs.PipelineTaskRetryBackoffExponential {
+		return time.Second * time.Duration(intervalSec)
+	}
+	// 第一次重试使用初始间隔，之后每次翻倍
+	interval := time.Second * time.Duration(intervalSec) * time.Duration(1<<uint(tr.CurrentAttempt()-1))
+	maxInterval := time.Second * time.Duration(retry.MaxIntervalSec)
+	if maxInterval <= 0 {
+		maxInterval = defaultRetryMaxInterval
+	}
+	if interval > maxInterval {
+		interval = maxInterval
+	}
+	return interval
+}
+
+// parseFailedReasons 根据状态和描述解析失败原因，第一个一定为 failed，越靠后越具体
+func parseFailedReasons(status apistructs.PipelineStatus, desc string) (reasons []apistructs.PipelineTaskRetryReason, exitCode int, hasExitCode bool) {
+	reasons = append(reasons, apistructs.PipelineTaskRetryReasonFailed)
+	if status == apistructs.PipelineStatusTimeout {
+		reasons = append(reasons, apistructs.PipelineTaskRetryReasonTimeout)
+	}
+	if status.IsAbnormalFailedStatus() {
+		reasons = append(reasons, apistructs.PipelineTaskRetryReasonExecutorError)
+	}
+
+	if matches := exitCodeRe.FindStringSubmatch(desc); len(matches) == 2 {
+		if code, err := strconv.Atoi(matches[1]); err == nil {
+			exitCode, hasExitCode = code, true
+		}
+	}
+
+	lowerDesc := strings.ToLower(desc)
+	for _, keyword := range imagePullKeywords {
+		if strings.Contains(lowerDesc, keyword) {
+			reasons = append(reasons, apistructs.PipelineTaskRetryReasonImagePull)
+			break
+		}
+	}
+	oom := hasExitCode && exitCode == exitCodeOOMKilled
+	for _, keyword := range oomKeywords {
+		if strings.Contains(lowerDesc, keyword) {
+			oom = true
+			break
+		}
+	}
+	if oom {
+		reasons = append(reasons, apistructs.PipelineTaskRetryReasonOOM)
+	}
+	return
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package taskrun
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+func newRetryTaskRun(retry *apistructs.PipelineTaskRetry, status apistructs.PipelineStatus, desc string) *TaskRun {
+	task := &spec.PipelineTask{Status: status}
+	task.Extra.RetryOptions = &apistructs.PipelineTaskRetryOptions{Retry: retry}
+	return &TaskRun{Task: task, EndStatusDesc: apistructs.PipelineStatusDesc{Status: status, Desc: desc}}
+}
+
+func TestTaskRun_JudgeRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		retry      *apistructs.PipelineTaskRetry
+		status     apistructs.PipelineStatus
+		desc       string
+		wantNeed   bool
+		wantReason apistructs.PipelineTaskRetryReason
+	}{
+		{
+			name:     "success",
+			retry:    &apistructs.PipelineTaskRetry{MaxAttempts: 3},
+			status:   apistructs.PipelineStatusSuccess,
+			wantNeed: false,
+		},
+		{
+			name:     "stop by user",
+			retry:    &apistructs.PipelineTaskRetry{MaxAttempts: 3},
+			status:   apistructs.PipelineStatusStopByUser,
+			wantNeed: false,
+		},
+		{
+			name:       "any failure",
+			retry:      &apistructs.PipelineTaskRetry{MaxAttempts: 3},
+			status:     apistructs.PipelineStatusFailed,
+			wantNeed:   true,
+			wantReason: apistructs.PipelineTaskRetryReasonFailed,
+		},
+		{
+			name:       "image pull",
+			retry:      &apistructs.PipelineTaskRetry{MaxAttempts: 3, When: []apistructs.PipelineTaskRetryReason{apistructs.PipelineTaskRetryReasonImagePull}},
+			status:     apistructs.PipelineStatusFailed,
+			desc:       "Back-off pulling image, reason: ImagePullBackOff",
+			wantNeed:   true,
+			wantReason: apistructs.PipelineTaskRetryReasonImagePull,
+		},
+		{
+			name:       "oom by exit code",
+			retry:      &apistructs.PipelineTaskRetry{MaxAttempts: 3, When: []apistructs.PipelineTaskRetryReason{apistructs.PipelineTaskRetryReasonOOM}},
+			status:     apistructs.PipelineStatusFailed,
+			desc:       "container exited, exit code: 137",
+			wantNeed:   true,
+			wantReason: apistructs.PipelineTaskRetryReasonOOM,
+		},
+		{
+			name:       "exit code",
+			retry:      &apistructs.PipelineTaskRetry{MaxAttempts: 3, ExitCodes: []int{2}},
+			status:     apistructs.PipelineStatusFailed,
+			desc:       "exitCode=2",
+			wantNeed:   true,
+			wantReason: apistructs.PipelineTaskRetryReasonExitCode,
+		},
+		{
+			name:     "exit code not match",
+			retry:    &apistructs.PipelineTaskRetry{MaxAttempts: 3, ExitCodes: []int{2}},
+			status:   apistructs.PipelineStatusFailed,
+			desc:     "exitCode=1",
+			wantNeed: false,
+		},
+		{
+			name:       "executor error",
+			retry:      &apistructs.PipelineTaskRetry{MaxAttempts: 3, When: []apistructs.PipelineTaskRetryReason{apistructs.PipelineTaskRetryReasonExecutorError}},
+			status:     apistructs.PipelineStatusStartError,
+			wantNeed:   true,
+			wantReason: apistructs.PipelineTaskRetryReasonExecutorError,
+		},
+		{
+			name:     "max attempts 1",
+			retry:    &apistructs.PipelineTaskRetry{MaxAttempts: 1},
+			status:   apistructs.PipelineStatusFailed,
+			wantNeed: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := newRetryTaskRun(tt.retry, tt.status, tt.desc)
+			reason, need := tr.JudgeRetry()
+			assert.Equal(t, tt.wantNeed, need)
+			assert.Equal(t, tt.wantReason, reason)
+		})
+	}
+}
+
+func TestTaskRun_RecordRetryAttempt(t *testing.T) {
+	tr := newRetryTaskRun(&apistructs.PipelineTaskRetry{MaxAttempts: 2}, apistructs.PipelineStatusFailed, "")
+	_, need := tr.JudgeRetry()
+	assert.True(t, need)
+	tr.RecordRetryAttempt(apistructs.PipelineTaskRetryReasonFailed)
+	assert.Len(t, tr.Task.Extra.RetryOptions.Attempts, 1)
+	assert.Equal(t, 1, tr.Task.Extra.RetryOptions.Attempts[0].Attempt)
+	assert.Equal(t, 2, tr.CurrentAttempt())
+
+	// reach max attempts
+	_, need = tr.JudgeRetry()
+	assert.False(t, need)
+}
+
+func TestTaskRun_CalculateRetryInterval(t *testing.T) {
+	tr := newRetryTaskRun(&apistructs.PipelineTaskRetry{MaxAttempts: 5, IntervalSec: 5}, apistructs.PipelineStatusFailed, "")
+	assert.Equal(t, 5*time.Second, tr.CalculateRetryInterval())
+
+	tr = newRetryTaskRun(&apistructs.PipelineTaskRetry{MaxAttempts: 5, IntervalSec: 5, MaxIntervalSec: 15,
+		Backoff: apistructs.PipelineTaskRetryBackoffExponential}, apistructs.PipelineStatusFailed, "")
+	assert.Equal(t, 5*time.Second, tr.CalculateRetryInterval())
+	tr.RecordRetryAttempt(apistructs.PipelineTaskRetryReasonFailed)
+	assert.Equal(t, 10*time.Second, tr.CalculateRetryInterval())
+	tr.RecordRetryAttempt(apistructs.PipelineTaskRetryReasonFailed)
+	assert.Equal(t, 15*time.Second, tr.CalculateRetryInterval())
+}
+
+func TestTaskRun_PrepareRetry(t *testing.T) {
+	// 未声明 retry 或非失败状态时不重试，且不查询 pipeline 状态
+	tr := newRetryTaskRun(nil, apistructs.PipelineStatusFailed, "")
+	tr.Task.Extra.RetryOptions = nil
+	tr.PrepareRetry()
+	assert.False(t, tr.RetryPending)
+	assert.Equal(t, apistructs.PipelineStatusFailed, tr.Task.Status)
+
+	tr = newRetryTaskRun(&apistructs.PipelineTaskRetry{MaxAttempts: 2}, apistructs.PipelineStatusRunning, "")
+	tr.PrepareRetry()
+	assert.False(t, tr.RetryPending)
+}
+
+func TestTaskRun_ResetForRetry(t *testing.T) {
+	tr := newRetryTaskRun(&apistructs.PipelineTaskRetry{MaxAttempts: 2}, apistructs.PipelineStatusFailed, "exit code: 1")
+	tr.Task.TimeBegin = time.Now()
+	tr.Task.Result.Errors = []apistructs.ErrorResponse{{Msg: "failed"}}
+	tr.RecordRetryAttempt(apistructs.PipelineTaskRetryReasonFailed)
+	tr.resetForRetry()
+	assert.Equal(t, apistructs.PipelineStatusAnalyzed, tr.Task.Status)
+	assert.True(t, tr.Task.TimeBegin.IsZero())
+	assert.Empty(t, tr.Task.Result.Errors)
+	assert.Empty(t, tr.EndStatusDesc.Desc)
+	assert.Equal(t, apistructs.PipelineStatusFailed, tr.LastRetryAttemptStatus())
+}
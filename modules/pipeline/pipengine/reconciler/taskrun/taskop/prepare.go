@@ -21,6 +21,7 @@ import (
 	"math"
 	"net/http"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -37,9 +38,11 @@ import (
 	"github.com/erda-project/erda/modules/pipeline/services/apierrors"
 	"github.com/erda-project/erda/modules/pipeline/services/extmarketsvc"
 	"github.com/erda-project/erda/modules/pipeline/spec"
+	"github.com/erda-project/erda/pkg/desensitize"
 	"github.com/erda-project/erda/pkg/expression"
 	"github.com/erda-project/erda/pkg/httputil"
 	"github.com/erda-project/erda/pkg/parser/pipelineyml"
+	"github.com/erda-project/erda/pkg/strutil"
 )
 
 type prepare taskrun.TaskRun
@@ -122,8 +125,17 @@ func (pre *prepare) makeTaskRun() (needRetry bool, err error) {
 	}
 	pre.Ctx = context.WithValue(pre.Ctx, apistructs.NETPORTAL_URL, clusterInfo.Get(apistructs.NETPORTAL_URL))
 
+	// 集群配置了对象存储时，task 上下文与缓存通过对象存储传递
+	objectStorage, enableObjectStorage := pvolumes.GetObjectStorageConfig(clusterInfo)
+
 	// TODO 目前 initSQL 需要存储在 网盘上，暂时不能用 volume 来解
-	mountPoint := clusterInfo.MustGet(apistructs.DICE_STORAGE_MOUNTPOINT)
+	// 使用对象存储的集群可以不配置网盘
+	var mountPoint string
+	if enableObjectStorage {
+		mountPoint = clusterInfo.Get(apistructs.DICE_STORAGE_MOUNTPOINT)
+	} else {
+		mountPoint = clusterInfo.MustGet(apistructs.DICE_STORAGE_MOUNTPOINT)
+	}
 
 	// 解析 pipeline yml
 	refs := pipelineyml.Refs{}
@@ -228,12 +240,22 @@ func (pre *prepare) makeTaskRun() (needRetry bool, err error) {
 		newK := strings.Replace(strings.Replace(strings.ToUpper(k), ".", "_", -1), "-", "_", -1)
 		task.Extra.PrivateEnvs["ACTION_"+newK] = fmt.Sprintf("%v", v)
 	}
+	// matrix values -> envs
+	if action.MatrixOrigin != nil {
+		for k, v := range action.MatrixOrigin.Values {
+			newK := strings.Replace(strings.Replace(strings.ToUpper(k), ".", "_", -1), "-", "_", -1)
+			task.Extra.PrivateEnvs["MATRIX_"+newK] = v
+		}
+	}
 	// secrets -> envs
 	for k, v := range p.Snapshot.Secrets {
 		newK := strings.Replace(strings.Replace(strings.ToUpper(k), ".", "_", -1), "-", "_", -1)
 		task.Extra.PrivateEnvs["PIPELINE_SECRET_"+newK] = v
 		task.Extra.PrivateEnvs[newK] = v
 	}
+	// 加密存储及名称疑似 secret 的配置项需要在日志中脱敏，
+	// 通过 ((key)) 渲染到 ACTION_* 等 env 中的值由 agent 按值匹配脱敏
+	task.Extra.SecretEnvKeys = makeSecretEnvKeys(p.Snapshot.Secrets, p.Snapshot.EncryptSecretKeys)
 	// platform secrets -> envs
 	for k, v := range p.Snapshot.PlatformSecrets {
 		newK := strings.Replace(strings.Replace(strings.ToUpper(k), ".", "_", -1), "-", "_", -1)
@@ -374,6 +396,16 @@ func (pre *prepare) makeTaskRun() (needRetry bool, err error) {
 					continue continueContextVolumes
 				}
 			}
+			// 如果 action 显式声明了 needs，只注入直接及间接依赖的 actions 的 volume，不再按照 stage 顺序判断
+			if task.Extra.Action.DeclaredNeeds != nil {
+				for _, ns := range task.Extra.Action.NeedNamespaces {
+					if ns == out.Name {
+						task.Context.InStorages = append(task.Context.InStorages, out)
+						continue continueContextVolumes
+					}
+				}
+				continue
+			}
 			// 如果 stageOrder >= 当前 order，不注入，只注入前置 stage 的 volume
 			if len(out.Labels) == 0 {
 				continue
@@ -484,6 +516,10 @@ func (pre *prepare) makeTaskRun() (needRetry bool, err error) {
 		!p.Extra.StorageConfig.EnableShareVolume() &&
 		task.ExecutorKind == spec.PipelineTaskExecutorKindScheduler {
 		for _, namespace := range task.Extra.Action.Namespaces {
+			if enableObjectStorage {
+				task.Context.OutStorages = append(task.Context.OutStorages, pvolumes.GenerateTaskObjectStorage(objectStorage, *task, namespace))
+				continue
+			}
 			task.Context.OutStorages = append(task.Context.OutStorages, pvolumes.GenerateTaskVolume(*task, namespace, nil))
 		}
 	}
@@ -494,6 +530,12 @@ func (pre *prepare) makeTaskRun() (needRetry bool, err error) {
 		task.Extra.LoopOptions = getLoopOptions(*specYmlJob, action.Loop)
 	}
 
+	// retry
+	// 若 retryOptions != nil，说明已经是在重试了，不能重新赋值，否则会丢失历史执行记录
+	if task.Extra.RetryOptions == nil && action.Retry != nil {
+		task.Extra.RetryOptions = &apistructs.PipelineTaskRetryOptions{Retry: action.Retry}
+	}
+
 	// dedup context
 	task.Context.Dedup()
 	// cmd
@@ -510,7 +552,23 @@ func (pre *prepare) makeTaskRun() (needRetry bool, err error) {
 
 	if (p.Extra.StorageConfig.EnableNFSVolume() || p.Extra.StorageConfig.EnableShareVolume()) && task.ExecutorKind == spec.PipelineTaskExecutorKindScheduler {
 		// 处理 task caches
-		pvolumes.HandleTaskCacheVolumes(p, task, diceYmlJob, mountPoint)
+		if enableObjectStorage {
+			pvolumes.HandleTaskCacheObjectStorages(objectStorage, p, task)
+			// agent 通过环境变量获取对象存储配置，只下发限定在当前流水线上下文与 task 缓存的临时凭证
+			policy, err := pvolumes.MakeTaskObjectStoragePolicy(objectStorage, task)
+			if err != nil {
+				return false, apierrors.ErrRunPipeline.InternalError(err)
+			}
+			creds, err := objectStorage.AssumeRole(policy, pvolumes.TaskObjectStorageCredentialDuration(task))
+			if err != nil {
+				return true, fmt.Errorf("failed to assume role for object storage, err: %v", err)
+			}
+			for k, v := range objectStorage.AgentEnvs(creds) {
+				task.Extra.PrivateEnvs[k] = v
+			}
+		} else {
+			pvolumes.HandleTaskCacheVolumes(p, task, diceYmlJob, mountPoint)
+		}
 		// --- binds ---
 		task.Extra.Binds = pvolumes.GenerateTaskCommonBinds(mountPoint)
 		jobBinds, err := pvolumes.ParseDiceYmlJobBinds(diceYmlJob)
@@ -629,6 +687,10 @@ func getActionAgentTypeVersion() string {
 func contextVolumes(context spec.PipelineTaskContext) []apistructs.MetadataField {
 	vos := make([]apistructs.MetadataField, 0)
 	for _, vo := range append(context.InStorages, context.OutStorages...) {
+		// 对象存储由 agent 上传下载，无需挂载
+		if vo.Type == string(spec.StoreTypeOSS) || vo.Type == string(spec.StoreTypeDiceCacheOSS) {
+			continue
+		}
 		vos = append(vos, vo)
 	}
 	return vos
@@ -765,3 +827,18 @@ func condition(task *spec.PipelineTask) bool {
 
 	return false
 }
+
+// makeSecretEnvKeys 返回需要脱敏的配置项对应的 PIPELINE_SECRET_* env key，
+// 与对比流水线时一致，仅包含加密存储及名称疑似 secret 的配置项，避免 true、8080 等普通值被误伤
+func makeSecretEnvKeys(secrets map[string]string, encryptKeys []string) []string {
+	keys := make([]string, 0, len(secrets))
+	for k := range secrets {
+		if !strutil.Exist(encryptKeys, k) && !desensitize.IsSensitiveKey(k) {
+			continue
+		}
+		newK := strings.Replace(strings.Replace(strings.ToUpper(k), ".", "_", -1), "-", "_", -1)
+		keys = append(keys, "PIPELINE_SECRET_"+newK)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -186,6 +186,10 @@ func injectVolumeID(tr *taskrun.TaskRun, data interface{}) error {
 			// fake volume 没有实际逻辑，只是为了被引用到
 			continue
 		}
+		if declaredVolume.Type == string(spec.StoreTypeOSS) || declaredVolume.Type == string(spec.StoreTypeDiceCacheOSS) {
+			// 对象存储不挂载 volume
+			continue
+		}
 		// 判断在返回的 diceVolumes 中是否存在
 		diceVolume, ok := diceVolumesMap[declaredVolume.Value]
 		if !ok {
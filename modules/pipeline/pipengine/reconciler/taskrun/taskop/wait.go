@@ -103,6 +103,7 @@ func (w *wait) WhenDone(data interface{}) error {
 		return nil
 	}
 	endStatus := data.(apistructs.PipelineStatusDesc).Status
+	w.EndStatusDesc = data.(apistructs.PipelineStatusDesc)
 	w.Task.Status = endStatus
 	w.Task.TimeEnd = time.Now()
 	w.Task.CostTimeSec = costtimeutil.CalculateTaskCostTimeSec(w.Task)
@@ -128,6 +129,7 @@ func (w *wait) WhenTimeout() error {
 
 	w.QuitWaitTimeout = true
 	w.Task.Status = apistructs.PipelineStatusTimeout
+	w.EndStatusDesc = apistructs.PipelineStatusDesc{Status: apistructs.PipelineStatusTimeout}
 	w.Task.TimeEnd = time.Now()
 	w.Task.CostTimeSec = int64(w.Task.TimeEnd.Sub(w.Task.TimeBegin).Seconds())
 	_, err = w.Executor.Cancel(w.Ctx, w.Task)
@@ -56,6 +56,13 @@ type TaskRun struct {
 	// 轮训状态间隔期间可能任务已经是终态，FakeTimeout = true
 	FakeTimeout bool
 
+	// EndStatusDesc 任务结束时从 executor 获取的状态及描述，用于判断失败原因
+	EndStatusDesc apistructs.PipelineStatusDesc
+
+	// RetryPending 任务失败且需要重试，已记录本次执行并重置任务，等待 RetryInterval 后重新执行
+	RetryPending  bool
+	RetryInterval time.Duration
+
 	// svc
 	ActionAgentSvc *actionagentsvc.ActionAgentSvc
 	ExtMarketSvc   *extmarketsvc.ExtMarketSvc
@@ -17,7 +17,6 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/erda-project/erda/apistructs"
-	"github.com/erda-project/erda/modules/pipeline/aop"
 	"github.com/erda-project/erda/modules/pipeline/aop/aoptypes"
 	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler/rlog"
 	"github.com/erda-project/erda/pkg/strutil"
@@ -30,7 +29,10 @@ func (tr *TaskRun) Teardown() {
 	defer tr.TeardownConcurrencyCount()
 	defer tr.TeardownPriorityQueue()
 	// handle aop synchronously, then do subsequent tasks
-	_ = aop.Handle(aop.NewContextForTask(*tr.Task, *tr.P, aoptypes.TuneTriggerTaskAfterExec))
+	if err := tr.HandleAOP(aoptypes.TuneTriggerTaskAfterExec); err != nil && !tr.Task.Status.IsFailedStatus() {
+		tr.MarkFailedByAOP(aoptypes.TuneTriggerTaskAfterExec, err)
+		tr.Update()
+	}
 
 	// invalidate openapi oauth2 token
 	tokens := strutil.DedupSlice([]string{
@@ -24,6 +24,7 @@ import (
 	"github.com/erda-project/erda/modules/pipeline/aop"
 	"github.com/erda-project/erda/modules/pipeline/aop/aoptypes"
 	"github.com/erda-project/erda/modules/pipeline/commonutil/costtimeutil"
+	"github.com/erda-project/erda/modules/pipeline/metrics"
 	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler/rlog"
 	"github.com/erda-project/erda/modules/pipeline/spec"
 	"github.com/erda-project/erda/pkg/loop"
@@ -45,8 +46,7 @@ func (r *Reconciler) teardownPipeline(ctx context.Context, p *spec.PipelineWithT
 		// go metrics.PipelineCounterTotalAdd(*p.Pipeline, 1)
 		// go metrics.PipelineGaugeProcessingAdd(*p.Pipeline, -1)
 		// go metrics.PipelineEndEvent(*p.Pipeline)
-		// aop
-		_ = aop.Handle(aop.NewContextForPipeline(*p.Pipeline, aoptypes.TuneTriggerPipelineAfterExec))
+		metrics.PipelineEnd(*p.Pipeline)
 	}()
 	defer r.QueueManager.PopOutPipelineFromQueue(p.Pipeline.ID)
 	defer logrus.Infof("reconciler: pipelineID: %d, pipeline is completed", p.Pipeline.ID)
@@ -64,6 +64,18 @@ func (r *Reconciler) teardownPipeline(ctx context.Context, p *spec.PipelineWithT
 	if err := r.fulfillParentSnippetTask(p.Pipeline); err != nil {
 		logrus.Errorf("[alert] reconciler: pipelineID: %d, failed to teardown pipeline (failed to fulfillSnippetTask, err: %v)", p.Pipeline.ID, err)
 	}
+	// aop，中断时将流水线置为失败
+	if err := aop.Handle(aop.NewContextForPipeline(*p.Pipeline, aoptypes.TuneTriggerPipelineAfterExec)); aoptypes.IsAbortError(err) {
+		logrus.Errorf("reconciler: pipelineID: %d, aborted by aop, type: %s, mark pipeline failed, err: %v",
+			p.Pipeline.ID, aoptypes.TuneTriggerPipelineAfterExec, err)
+		if !p.Pipeline.Status.IsFailedStatus() {
+			p.Pipeline.Status = apistructs.PipelineStatusFailed
+			if err := r.updatePipelineStatus(p.Pipeline); err != nil {
+				logrus.Errorf("[alert] reconciler: pipelineID: %d, failed to teardown pipeline (failed to mark pipeline failed: %v)",
+					p.Pipeline.ID, err)
+			}
+		}
+	}
 	// 更新结束时间
 	now := time.Now()
 	p.Pipeline.TimeEnd = &now
@@ -0,0 +1,286 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/events"
+	"github.com/erda-project/erda/modules/pipeline/pipengine/actionexecutor"
+	"github.com/erda-project/erda/modules/pipeline/pipengine/actionexecutor/types"
+	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler/rlog"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+const (
+	// timeoutCheckInterval 执行超时检查间隔
+	timeoutCheckInterval = time.Second * 10
+
+	EventComponentReconciler = "Reconciler"
+)
+
+// waitPopFromQueue 等待流水线出队，配置了排队超时时间时，超时后将流水线置为超时。
+// 无论是否超时，都会等到 popCh 收到信号再返回，保证队列不会因为无人接收而阻塞。
+func (r *Reconciler) waitPopFromQueue(pipelineID uint64, popCh <-chan struct{}) {
+	p, err := r.dbClient.GetPipeline(pipelineID)
+	if err != nil || p.Status.IsEndStatus() || p.Extra.TimeoutOptions == nil || p.Extra.TimeoutOptions.TimeExecBegin != nil {
+		<-popCh
+		return
+	}
+	timeQueueBegin := time.Now()
+	if p.TimeBegin != nil {
+		timeQueueBegin = *p.TimeBegin
+	}
+	deadline, ok := p.Extra.TimeoutOptions.QueueDeadline(timeQueueBegin)
+	if !ok {
+		<-popCh
+		r.recordExecBegin(&p, timeQueueBegin)
+		return
+	}
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-popCh:
+		r.recordExecBegin(&p, timeQueueBegin)
+		return
+	case <-timer.C:
+	}
+
+	// 排队超时，先更新状态再出队，出队信号仍由当前协程接收
+	latest, err := r.dbClient.GetPipeline(pipelineID)
+	if err == nil && !latest.Status.IsEndStatus() && latest.Extra.TimeoutOptions != nil {
+		latest.Extra.TimeoutOptions.QueueCostTimeSec = int64(time.Since(timeQueueBegin).Seconds())
+		if err := r.timeoutPipeline(&latest, apistructs.PipelineTimeoutReasonQueue, nil); err != nil {
+			rlog.PErrorf(pipelineID, "failed to mark pipeline as queue timeout, err: %v", err)
+		}
+	}
+	go r.QueueManager.PopOutPipelineFromQueue(pipelineID)
+	<-popCh
+}
+
+// recordExecBegin 记录出队时间及排队耗时，执行超时从出队时开始计算
+func (r *Reconciler) recordExecBegin(p *spec.Pipeline, timeQueueBegin time.Time) {
+	now := time.Now()
+	p.Extra.TimeoutOptions.TimeExecBegin = &now
+	p.Extra.TimeoutOptions.QueueCostTimeSec = int64(now.Sub(timeQueueBegin).Seconds())
+	if err := r.dbClient.UpdatePipelineExtraExtraInfoByPipelineID(p.ID, p.Extra); err != nil {
+		rlog.PErrorf(p.ID, "failed to record pipeline exec begin time, err: %v", err)
+	}
+}
+
+// watchTimeout 流水线配置了执行或 stage 超时时间时，启动协程定期检查是否超时，每条流水线只会启动一个协程
+func (r *Reconciler) watchTimeout(ctx context.Context, p *spec.Pipeline) {
+	if !p.Extra.TimeoutOptions.NeedWatch() {
+		return
+	}
+	if _, loaded := r.timeoutWatchers.LoadOrStore(p.ID, struct{}{}); loaded {
+		return
+	}
+	go func() {
+		defer r.timeoutWatchers.Delete(p.ID)
+		ticker := time.NewTicker(timeoutCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				end, err := r.checkTimeout(p.ID)
+				if err != nil {
+					rlog.PErrorf(p.ID, "failed to check pipeline timeout, err: %v", err)
+					continue
+				}
+				if end {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// checkTimeout 检查流水线执行及 stage 是否超时，返回流水线是否已结束
+func (r *Reconciler) checkTimeout(pipelineID uint64) (bool, error) {
+	p, err := r.dbClient.GetPipeline(pipelineID)
+	if err != nil {
+		return false, err
+	}
+	if p.Status.IsEndStatus() {
+		return true, nil
+	}
+	opt := p.Extra.TimeoutOptions
+	now := time.Now()
+
+	// 兼容出队时未记录开始执行时间的情况
+	if opt.TimeoutSec > 0 && opt.TimeExecBegin == nil {
+		opt.TimeExecBegin = &now
+		if err := r.dbClient.UpdatePipelineExtraExtraInfoByPipelineID(p.ID, p.Extra); err != nil {
+			return false, err
+		}
+	}
+	if deadline, ok := opt.ExecDeadline(); ok && now.After(deadline) {
+		return true, r.timeoutPipeline(&p, apistructs.PipelineTimeoutReasonExec, nil)
+	}
+
+	if !opt.HasStageTimeout {
+		return false, nil
+	}
+	stages, err := r.dbClient.ListPipelineStageByPipelineID(p.ID)
+	if err != nil {
+		return false, err
+	}
+	for i := range stages {
+		stage := &stages[i]
+		if stage.Extra.TimeoutSec <= 0 || stage.Status.IsEndStatus() {
+			continue
+		}
+		tasks, err := r.dbClient.ListPipelineTasksByStageID(stage.ID)
+		if err != nil {
+			return false, err
+		}
+		// stage 开始时间以第一次观察到任务开始执行的时间为准，避免任务重试时重置
+		if stage.TimeBegin.IsZero() {
+			timeBegin, started := calculateStageTimeBegin(tasks)
+			if !started {
+				continue
+			}
+			stage.TimeBegin = timeBegin
+			if err := r.dbClient.UpdatePipelineStage(stage.ID, stage); err != nil {
+				return false, err
+			}
+		}
+		if isStageTimeout(stage, tasks, now) {
+			return true, r.timeoutPipeline(&p, apistructs.PipelineTimeoutReasonStage, stage)
+		}
+	}
+	return false, nil
+}
+
+// calculateStageTimeBegin 返回 stage 内最早开始执行的任务的开始时间
+func calculateStageTimeBegin(tasks []*spec.PipelineTask) (time.Time, bool) {
+	var timeBegin time.Time
+	for _, task := range tasks {
+		if task.TimeBegin.IsZero() {
+			continue
+		}
+		if timeBegin.IsZero() || task.TimeBegin.Before(timeBegin) {
+			timeBegin = task.TimeBegin
+		}
+	}
+	return timeBegin, !timeBegin.IsZero()
+}
+
+// isStageTimeout stage 内仍有未结束的任务且已超出 stage 超时时间
+func isStageTimeout(stage *spec.PipelineStage, tasks []*spec.PipelineTask, now time.Time) bool {
+	if stage.Extra.TimeoutSec <= 0 || stage.TimeBegin.IsZero() {
+		return false
+	}
+	allDone := true
+	for _, task := range tasks {
+		if !task.Status.IsEndStatus() && task.Status != apistructs.PipelineStatusDisabled {
+			allDone = false
+			break
+		}
+	}
+	if allDone {
+		return false
+	}
+	return now.Sub(stage.TimeBegin) > time.Duration(stage.Extra.TimeoutSec)*time.Second
+}
+
+// timeoutPipeline 取消所有执行中的任务，并将流水线（及超时的 stage）置为超时
+func (r *Reconciler) timeoutPipeline(p *spec.Pipeline, reason apistructs.PipelineTimeoutReason, timeoutStage *spec.PipelineStage) error {
+	rlog.PWarnf(p.ID, "pipeline timeout, reason: %s", reason)
+
+	// cancel running tasks
+	tasks, err := r.dbClient.ListPipelineTasksByPipelineID(p.ID)
+	if err != nil {
+		return err
+	}
+	for i := range tasks {
+		task := &tasks[i]
+		if task.IsSnippet {
+			if task.SnippetPipelineID == nil || task.Status.IsEndStatus() {
+				continue
+			}
+			sp, err := r.dbClient.GetPipeline(*task.SnippetPipelineID)
+			if err != nil {
+				rlog.TErrorf(p.ID, task.ID, "failed to get snippet pipeline when timeout, err: %v", err)
+				continue
+			}
+			if sp.Status.IsEndStatus() {
+				continue
+			}
+			if err := r.timeoutPipeline(&sp, reason, nil); err != nil {
+				rlog.TErrorf(p.ID, task.ID, "failed to timeout snippet pipeline, err: %v", err)
+			}
+			continue
+		}
+		if !task.Status.CanCancel() {
+			continue
+		}
+		// 获取 executor 失败时只记录日志，任务和流水线仍会被置为超时
+		executor, err := actionexecutor.GetManager().Get(types.Name(task.Extra.ExecutorName))
+		if err != nil {
+			rlog.TErrorf(p.ID, task.ID, "failed to get executor when timeout, executor: %s, err: %v", task.Extra.ExecutorName, err)
+			continue
+		}
+		if _, err := executor.Cancel(context.Background(), task); err != nil {
+			rlog.TErrorf(p.ID, task.ID, "failed to cancel task when timeout, err: %v", err)
+		}
+	}
+
+	// record timeout info
+	if p.Extra.TimeoutOptions == nil {
+		p.Extra.TimeoutOptions = &apistructs.PipelineTimeoutOptions{}
+	}
+	p.Extra.TimeoutOptions.Reason = reason
+	message := fmt.Sprintf("pipeline timeout, reason: %s", reason)
+	switch reason {
+	case apistructs.PipelineTimeoutReasonQueue:
+		message = fmt.Sprintf("pipeline queued for %ds, exceeded queue timeout %ds",
+			p.Extra.TimeoutOptions.QueueCostTimeSec, p.Extra.TimeoutOptions.QueueTimeoutSec)
+	case apistructs.PipelineTimeoutReasonExec:
+		message = fmt.Sprintf("pipeline exceeded execution timeout %ds (queue time excluded)", p.Extra.TimeoutOptions.TimeoutSec)
+	}
+	if timeoutStage != nil {
+		p.Extra.TimeoutOptions.TimeoutStageID = timeoutStage.ID
+		message = fmt.Sprintf("stage %d exceeded timeout %ds", timeoutStage.Extra.StageOrder+1, timeoutStage.Extra.TimeoutSec)
+	}
+	if err := r.dbClient.UpdatePipelineExtraExtraInfoByPipelineID(p.ID, p.Extra); err != nil {
+		return err
+	}
+
+	// update stage status
+	if timeoutStage != nil {
+		now := time.Now()
+		timeoutStage.Status = apistructs.PipelineStatusTimeout
+		timeoutStage.TimeEnd = now
+		timeoutStage.CostTimeSec = int64(now.Sub(timeoutStage.TimeBegin).Seconds())
+		if err := r.dbClient.UpdatePipelineStage(timeoutStage.ID, timeoutStage); err != nil {
+			return err
+		}
+	}
+
+	// update whole status
+	if err := r.dbClient.UpdateWholeStatusTimeout(p); err != nil {
+		return err
+	}
+	events.EmitPipelineStreamReasonEvent(p.ID, EventComponentReconciler, events.EventLevelWarning, reason.String(), message)
+	events.EmitPipelineInstanceEvent(p, p.GetRunUserID())
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package reconciler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+func TestCalculateStageTimeBegin(t *testing.T) {
+	now := time.Now()
+	_, started := calculateStageTimeBegin([]*spec.PipelineTask{{}, {}})
+	assert.False(t, started)
+
+	timeBegin, started := calculateStageTimeBegin([]*spec.PipelineTask{
+		{},
+		{TimeBegin: now},
+		{TimeBegin: now.Add(-time.Minute)},
+	})
+	assert.True(t, started)
+	assert.Equal(t, now.Add(-time.Minute), timeBegin)
+}
+
+func TestIsStageTimeout(t *testing.T) {
+	now := time.Now()
+	stage := &spec.PipelineStage{
+		Extra:     spec.PipelineStageExtra{TimeoutSec: 60},
+		TimeBegin: now.Add(-time.Minute * 2),
+	}
+	running := []*spec.PipelineTask{
+		{Status: apistructs.PipelineStatusSuccess},
+		{Status: apistructs.PipelineStatusRunning},
+	}
+	assert.True(t, isStageTimeout(stage, running, now))
+
+	// 未超时
+	assert.False(t, isStageTimeout(stage, running, now.Add(-time.Minute*3/2)))
+
+	// 所有任务已结束
+	done := []*spec.PipelineTask{
+		{Status: apistructs.PipelineStatusSuccess},
+		{Status: apistructs.PipelineStatusFailed},
+	}
+	assert.False(t, isStageTimeout(stage, done, now))
+
+	// 未配置超时或未开始
+	assert.False(t, isStageTimeout(&spec.PipelineStage{TimeBegin: stage.TimeBegin}, running, now))
+	assert.False(t, isStageTimeout(&spec.PipelineStage{Extra: stage.Extra}, running, now))
+}
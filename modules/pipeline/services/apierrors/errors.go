@@ -56,6 +56,11 @@ var (
 	ErrPreCheckPipeline      = err("ErrPreCheckPipeline", "流水线前置校验失败")
 	ErrGetOpenapiOAuth2Token = err("ErrGetOpenapiOAuth2Token", "申请 openapi oauth2 token 失败")
 	ErrQuerySnippetYaml      = err("ErrQuerySnippetYaml", "查询嵌套流水线片段失败")
+	ErrDryRunPipeline        = err("ErrDryRunPipeline", "试运行流水线失败")
+	ErrStreamPipelineStatus  = err("ErrStreamPipelineStatus", "订阅流水线状态失败")
+	ErrLintPipelineYml       = err("ErrLintPipelineYml", "校验 pipeline yml 文件失败")
+	ErrGetPipelineYmlSchema  = err("ErrGetPipelineYmlSchema", "获取 pipeline yml JSON Schema 失败")
+	ErrComparePipeline       = err("ErrComparePipeline", "对比流水线运行失败")
 
 	ErrCheckSecrets          = err("ErrCheckSecrets", "校验私有配置失败")
 	ErrMakeConfigNamespace   = err("ErrMakeConfigNamespace", "创建私有配置命名空间失败")
@@ -78,12 +83,17 @@ var (
 	ErrUpdatePipelineQueue  = err("ErrUpdatePipelineQueue", "更新流水线队列失败")
 	ErrDeletePipelineQueue  = err("ErrDeletePipelineQueue", "删除流水线队列失败")
 
+	ErrRegisterAOPHTTPPlugin   = err("ErrRegisterAOPHTTPPlugin", "注册 AOP 外部插件失败")
+	ErrListAOPHTTPPlugins      = err("ErrListAOPHTTPPlugins", "查询 AOP 外部插件失败")
+	ErrUnregisterAOPHTTPPlugin = err("ErrUnregisterAOPHTTPPlugin", "删除 AOP 外部插件失败")
+
 	ErrQueryBuildArtifact    = err("ErrQueryBuildArtifact", "查询构建产物失败")
 	ErrRegisterBuildArtifact = err("ErrRegisterBuildArtifact", "注册构建产物失败")
 	ErrDeleteBuildArtifact   = err("ErrDeleteBuildArtifact", "删除构建产物失败")
 
-	ErrQueryDicehub     = err("ErrQueryDicehub", "查询 Dicehub 失败")
-	ErrReportBuildCache = err("ErrReportBuildCache", "上报构建缓存失败")
+	ErrQueryDicehub        = err("ErrQueryDicehub", "查询 Dicehub 失败")
+	ErrReportBuildCache    = err("ErrReportBuildCache", "上报构建缓存失败")
+	ErrGetActionCacheStats = err("ErrGetActionCacheStats", "获取 action 缓存统计失败")
 
 	ErrCallback = err("ErrCallback", "回调平台失败")
 
@@ -97,11 +107,17 @@ var (
 	ErrDeletePipelineCmsConfigs = err("ErrDeletePipelineCmsConfigs", "删除流水线配置管理配置失败")
 	ErrGetPipelineCmsConfigs    = err("ErrGetPipelineCmsConfigs", "查询流水线配置管理配置失败")
 
+	ErrListPipelineCmsConfigRevisions = err("ErrListPipelineCmsConfigRevisions", "查询流水线配置管理变更记录失败")
+	ErrDiffPipelineCmsConfigRevisions = err("ErrDiffPipelineCmsConfigRevisions", "对比流水线配置管理变更记录失败")
+	ErrRollbackPipelineCmsConfigs     = err("ErrRollbackPipelineCmsConfigs", "回滚流水线配置管理配置失败")
+
 	ErrPipelineHealthCheck = err("ErrPipelineHealthCheck", "健康检查失败")
 
 	ErrCreatePipelineReport   = err("ErrCreatePipelineReport", "创建流水线报告失败")
 	ErrQueryPipelineReportSet = err("ErrQueryPipelineReportSet", "查询流水线报告集失败")
 	ErrPagingPipelineReports  = err("ErrPagingPipelineReports", "分页查询流水线报告集失败")
+	ErrQueryCoverageTrend     = err("ErrQueryCoverageTrend", "查询覆盖率趋势失败")
+	ErrQueryCoverageDelta     = err("ErrQueryCoverageDelta", "查询覆盖率变化失败")
 )
 
 func err(template, defaultValue string) *errorresp.APIError {
@@ -0,0 +1,100 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package archivesvc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/erda-project/erda/modules/pipeline/conf"
+	"github.com/erda-project/erda/modules/pipeline/dbclient"
+	"github.com/erda-project/erda/pkg/storage"
+)
+
+// ArchiveSvc 负责将 pipeline_archives 中的归档流水线导出到冷存储，并支持从冷存储回查
+type ArchiveSvc struct {
+	dbClient *dbclient.Client
+
+	// storage 为 nil 时不导出，也无法回查已导出的流水线
+	storage    storage.Storager
+	pathPrefix string
+
+	exportAfter       time.Duration
+	segmentSize       int
+	maxSegmentsPerRun int
+}
+
+func New(ops ...Option) *ArchiveSvc {
+	svc := ArchiveSvc{
+		exportAfter:       time.Hour * 24 * 30,
+		segmentSize:       500,
+		maxSegmentsPerRun: 20,
+	}
+
+	for _, op := range ops {
+		op(&svc)
+	}
+
+	return &svc
+}
+
+type Option func(*ArchiveSvc)
+
+func WithDBClient(dbClient *dbclient.Client) Option {
+	return func(svc *ArchiveSvc) {
+		svc.dbClient = dbClient
+	}
+}
+
+// WithStorage 指定冷存储及路径前缀，fs 为目录，oss/s3 为对象 key 前缀
+func WithStorage(s storage.Storager, pathPrefix string) Option {
+	return func(svc *ArchiveSvc) {
+		svc.storage = s
+		svc.pathPrefix = pathPrefix
+	}
+}
+
+// WithPolicy 指定导出策略：归档超过 exportAfter 的流水线按 segmentSize 个一段导出，每次最多导出 maxSegmentsPerRun 段
+func WithPolicy(exportAfter time.Duration, segmentSize, maxSegmentsPerRun int) Option {
+	return func(svc *ArchiveSvc) {
+		if exportAfter > 0 {
+			svc.exportAfter = exportAfter
+		}
+		if segmentSize > 0 {
+			svc.segmentSize = segmentSize
+		}
+		if maxSegmentsPerRun > 0 {
+			svc.maxSegmentsPerRun = maxSegmentsPerRun
+		}
+	}
+}
+
+// NewStorageFromConf 根据配置创建冷存储，未开启导出时返回 nil
+func NewStorageFromConf() (storage.Storager, error) {
+	if !conf.ArchiveExportEnabled() {
+		return nil, nil
+	}
+	switch storage.Type(conf.ArchiveExportStorageType()) {
+	case storage.TypeFileSystem:
+		return storage.NewFS(), nil
+	case storage.TypeOSS:
+		return storage.NewOSS(conf.ArchiveExportEndpoint(), conf.ArchiveExportAccessKeyID(), conf.ArchiveExportAccessKeySecret(),
+			conf.ArchiveExportBucket(), nil, nil), nil
+	case storage.TypeS3:
+		return storage.NewS3(conf.ArchiveExportEndpoint(), conf.ArchiveExportRegion(), conf.ArchiveExportAccessKeyID(),
+			conf.ArchiveExportAccessKeySecret(), conf.ArchiveExportBucket(), conf.ArchiveExportSecure()), nil
+	default:
+		return nil, fmt.Errorf("invalid archive export storage type: %s", conf.ArchiveExportStorageType())
+	}
+}
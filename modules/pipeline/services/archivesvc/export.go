@@ -0,0 +1,147 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package archivesvc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/modules/pipeline/metrics"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+	"github.com/erda-project/erda/pkg/dlock"
+)
+
+const (
+	archiveExportDLockKey = "/devops/pipeline/dlock/archive-export"
+	// 其他实例正在导出时，等待锁的时间
+	archiveExportDLockWait = time.Second * 5
+)
+
+// ExportArchives 将归档超过保留期的流水线分段导出到冷存储，校验通过后从 MySQL 中删除
+// 由定时任务在每个实例上触发，通过分布式锁保证同一时间只有一个实例在导出
+func (s *ArchiveSvc) ExportArchives() {
+	if s.storage == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lock, err := dlock.New(archiveExportDLockKey, func() {
+		metrics.DLockLost(metrics.DLockArchiveExport)
+		logrus.Errorf("[alert] archive export: dlock lost, stop export")
+		cancel()
+	}, dlock.WithTTL(30))
+	if err != nil {
+		logrus.Errorf("[alert] archive export: failed to create dlock, err: %v", err)
+		return
+	}
+	lockCtx, lockCancel := context.WithTimeout(ctx, archiveExportDLockWait)
+	err = lock.Lock(lockCtx)
+	lockCancel()
+	metrics.DLockAcquire(metrics.DLockArchiveExport, err)
+	if err != nil {
+		logrus.Infof("archive export: skip, another instance is exporting, err: %v", err)
+		return
+	}
+	defer func() {
+		if err := lock.UnlockAndClose(); err != nil {
+			logrus.Errorf("archive export: failed to unlock dlock, err: %v", err)
+		}
+	}()
+
+	before := time.Now().Add(-s.exportAfter)
+	for i := 0; i < s.maxSegmentsPerRun; i++ {
+		if ctx.Err() != nil {
+			return
+		}
+		archives, err := s.dbClient.ListPipelineArchivesBefore(before, s.segmentSize)
+		if err != nil {
+			logrus.Errorf("[alert] archive export: failed to list archives, err: %v", err)
+			return
+		}
+		if len(archives) == 0 {
+			return
+		}
+		segment, err := s.exportSegment(archives)
+		if err != nil {
+			logrus.Errorf("[alert] archive export: failed to export segment, err: %v", err)
+			return
+		}
+		logrus.Infof("archive export: exported %d archived pipelines to %s", len(archives), segment)
+	}
+}
+
+// exportSegment 写入并校验一个分段，成功后在同一事务中写入索引并删除归档记录，返回分段路径
+func (s *ArchiveSvc) exportSegment(archives []spec.PipelineArchive) (string, error) {
+	segment := s.makeSegmentPath(archives)
+	data, err := encodeSegment(archives)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode segment %s, err: %v", segment, err)
+	}
+	if err := s.storage.Write(segment, bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("failed to write segment %s, err: %v", segment, err)
+	}
+	if err := s.verify(segment, data, archives); err != nil {
+		s.deleteSegment(segment)
+		return "", fmt.Errorf("failed to verify segment %s, err: %v", segment, err)
+	}
+
+	exports := make([]spec.PipelineArchiveExport, 0, len(archives))
+	archiveIDs := make([]uint64, 0, len(archives))
+	for i, archive := range archives {
+		exports = append(exports, spec.PipelineArchiveExport{
+			PipelineID:      archive.PipelineID,
+			PipelineSource:  archive.PipelineSource,
+			PipelineYmlName: archive.PipelineYmlName,
+			Status:          archive.Status,
+			StorageType:     string(s.storage.Type()),
+			Segment:         segment,
+			SegmentLine:     i,
+			ArchivedAt:      archive.TimeCreated,
+		})
+		archiveIDs = append(archiveIDs, archive.ID)
+	}
+	if err := s.dbClient.ConfirmPipelineArchivesExported(exports, archiveIDs); err != nil {
+		s.deleteSegment(segment)
+		return "", fmt.Errorf("failed to confirm segment %s exported, err: %v", segment, err)
+	}
+	return segment, nil
+}
+
+func (s *ArchiveSvc) verify(segment string, written []byte, archives []spec.PipelineArchive) error {
+	r, err := s.readSegment(segment)
+	if err != nil {
+		return err
+	}
+	defer closeReader(r)
+	return verifySegment(r, written, archives)
+}
+
+func (s *ArchiveSvc) deleteSegment(segment string) {
+	if err := s.storage.Delete(segment); err != nil {
+		logrus.Errorf("[alert] archive export: failed to delete unconfirmed segment %s, err: %v", segment, err)
+	}
+}
+
+// makeSegmentPath 按归档月份组织分段，文件名使用首尾归档记录 ID 保证唯一
+func (s *ArchiveSvc) makeSegmentPath(archives []spec.PipelineArchive) string {
+	first, last := archives[0], archives[len(archives)-1]
+	return path.Join(s.pathPrefix, first.TimeCreated.Format("2006-01"),
+		fmt.Sprintf("pipeline-archives-%d-%d.jsonl.gz", first.ID, last.ID))
+}
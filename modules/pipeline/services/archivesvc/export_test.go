@@ -0,0 +1,86 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package archivesvc
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/modules/pipeline/spec"
+	"github.com/erda-project/erda/pkg/storage"
+)
+
+func makeArchives(pipelineIDs ...uint64) []spec.PipelineArchive {
+	var archives []spec.PipelineArchive
+	for i, id := range pipelineIDs {
+		archive := spec.PipelineArchive{ID: uint64(i + 1), PipelineID: id, TimeCreated: time.Date(2021, 6, 1, 0, 0, 0, 0, time.Local)}
+		archive.Content.Pipeline.ID = id
+		archives = append(archives, archive)
+	}
+	return archives
+}
+
+func TestVerifySegment(t *testing.T) {
+	archives := makeArchives(10, 11, 12)
+	data, err := encodeSegment(archives)
+	assert.NoError(t, err)
+
+	assert.NoError(t, verifySegment(bytes.NewReader(data), data, archives))
+
+	// 内容被截断
+	assert.Error(t, verifySegment(bytes.NewReader(data[:len(data)-1]), data, archives))
+
+	// 行数不一致
+	assert.Error(t, verifySegment(bytes.NewReader(data), data, archives[:2]))
+
+	// pipelineID 不一致
+	assert.Error(t, verifySegment(bytes.NewReader(data), data, makeArchives(10, 12, 11)))
+}
+
+func TestFindInSegment(t *testing.T) {
+	data, err := encodeSegment(makeArchives(10, 11, 12))
+	assert.NoError(t, err)
+
+	found, err := findInSegment(bytes.NewReader(data), spec.PipelineArchiveExport{PipelineID: 11})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(11), found.Content.Pipeline.ID)
+
+	_, err = findInSegment(bytes.NewReader(data), spec.PipelineArchiveExport{PipelineID: 13})
+	assert.Error(t, err)
+}
+
+func TestReadExported(t *testing.T) {
+	archives := makeArchives(10, 11)
+	svc := New(WithStorage(storage.NewFS(), t.TempDir()))
+	segment := svc.makeSegmentPath(archives)
+	assert.Equal(t, "2021-06", filepath.Base(filepath.Dir(segment)))
+	assert.Equal(t, "pipeline-archives-1-2.jsonl.gz", filepath.Base(segment))
+
+	data, err := encodeSegment(archives)
+	assert.NoError(t, err)
+	assert.NoError(t, svc.storage.Write(segment, bytes.NewReader(data)))
+	assert.NoError(t, svc.verify(segment, data, archives))
+
+	found, err := svc.readExported(spec.PipelineArchiveExport{PipelineID: 11, StorageType: string(storage.TypeFileSystem), Segment: segment})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(11), found.PipelineID)
+
+	// 存储类型与导出时不一致
+	_, err = svc.readExported(spec.PipelineArchiveExport{PipelineID: 11, StorageType: string(storage.TypeS3), Segment: segment})
+	assert.Error(t, err)
+}
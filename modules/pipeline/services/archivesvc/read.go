@@ -0,0 +1,86 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package archivesvc
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+// GetArchive 查询已归档的流水线，依次查找 MySQL 归档表和冷存储
+func (s *ArchiveSvc) GetArchive(pipelineID uint64) (*spec.PipelineArchive, bool, error) {
+	archive, exist, err := s.dbClient.GetPipelineArchiveByPipelineID(pipelineID)
+	if err != nil {
+		return nil, false, err
+	}
+	if exist {
+		return &archive, true, nil
+	}
+
+	export, exist, err := s.dbClient.GetPipelineArchiveExportByPipelineID(pipelineID)
+	if err != nil {
+		return nil, false, err
+	}
+	if !exist {
+		return nil, false, nil
+	}
+	exported, err := s.readExported(export)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read exported pipeline from %s, err: %v", export.Segment, err)
+	}
+	return exported, true, nil
+}
+
+func (s *ArchiveSvc) readExported(export spec.PipelineArchiveExport) (*spec.PipelineArchive, error) {
+	if s.storage == nil || string(s.storage.Type()) != export.StorageType {
+		return nil, fmt.Errorf("storage %q not configured", export.StorageType)
+	}
+	r, err := s.readSegment(export.Segment)
+	if err != nil {
+		return nil, err
+	}
+	defer closeReader(r)
+	return findInSegment(r, export)
+}
+
+// findInSegment 顺序解压分段，按 pipelineID 查找
+func findInSegment(r io.Reader, export spec.PipelineArchiveExport) (*spec.PipelineArchive, error) {
+	var found *spec.PipelineArchive
+	err := rangeSegment(r, func(line int, archive spec.PipelineArchive) bool {
+		if archive.PipelineID != export.PipelineID {
+			return false
+		}
+		found = &archive
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("pipeline %d not found in segment", export.PipelineID)
+	}
+	return found, nil
+}
+
+func (s *ArchiveSvc) readSegment(segment string) (io.Reader, error) {
+	return s.storage.Read(segment)
+}
+
+func closeReader(r io.Reader) {
+	if c, ok := r.(io.Closer); ok {
+		_ = c.Close()
+	}
+}
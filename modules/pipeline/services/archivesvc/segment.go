@@ -0,0 +1,97 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package archivesvc
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+// 分段格式：gzip 压缩的 json lines，每行为一条 spec.PipelineArchive，包含流水线及其 stages、tasks、labels、reports
+
+const segmentMaxLineBytes = 64 << 20
+
+func encodeSegment(archives []spec.PipelineArchive) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for i := range archives {
+		if err := enc.Encode(&archives[i]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// rangeSegment 逐行解析分段，f 返回 true 时停止
+func rangeSegment(r io.Reader, f func(line int, archive spec.PipelineArchive) (stop bool)) error {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(make([]byte, 0, 64<<10), segmentMaxLineBytes)
+	for line := 0; scanner.Scan(); line++ {
+		var archive spec.PipelineArchive
+		if err := json.Unmarshal(scanner.Bytes(), &archive); err != nil {
+			return fmt.Errorf("failed to decode line %d, err: %v", line, err)
+		}
+		if f(line, archive) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// verifySegment 校验读回的分段与写入内容一致，且逐行与原归档记录对应
+func verifySegment(r io.Reader, written []byte, archives []spec.PipelineArchive) error {
+	readBack, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if sha256.Sum256(readBack) != sha256.Sum256(written) {
+		return fmt.Errorf("checksum mismatch, written %d bytes, read back %d bytes", len(written), len(readBack))
+	}
+	var count int
+	var mismatch error
+	if err := rangeSegment(bytes.NewReader(readBack), func(line int, archive spec.PipelineArchive) bool {
+		if line >= len(archives) || archive.PipelineID != archives[line].PipelineID {
+			mismatch = fmt.Errorf("pipelineID mismatch at line %d", line)
+			return true
+		}
+		count++
+		return false
+	}); err != nil {
+		return err
+	}
+	if mismatch != nil {
+		return mismatch
+	}
+	if count != len(archives) {
+		return fmt.Errorf("line count mismatch, expected %d, actual %d", len(archives), count)
+	}
+	return nil
+}
@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/conf"
 	"github.com/erda-project/erda/modules/pipeline/dbclient"
 	"github.com/erda-project/erda/modules/pipeline/services/apierrors"
 	"github.com/erda-project/erda/modules/pipeline/spec"
@@ -57,3 +58,28 @@ func (s *BuildCacheSvc) Report(req *apistructs.BuildCacheImageReportRequest, cac
 
 	return nil
 }
+
+// GetActionCacheStats 获取项目 action 缓存的命中率与占用空间
+func (s *BuildCacheSvc) GetActionCacheStats(projectID uint64) (*apistructs.PipelineActionCacheStats, error) {
+	stat, err := s.dbClient.GetActionCacheStat(projectID)
+	if err != nil {
+		return nil, apierrors.ErrGetActionCacheStats.InternalError(err)
+	}
+	entries, size, err := s.dbClient.SumActionCacheSize(projectID)
+	if err != nil {
+		return nil, apierrors.ErrGetActionCacheStats.InternalError(err)
+	}
+	stats := apistructs.PipelineActionCacheStats{
+		ProjectID:      projectID,
+		Hits:           stat.Hits,
+		PartialHits:    stat.PartialHits,
+		Misses:         stat.Misses,
+		Entries:        entries,
+		TotalSizeBytes: size,
+		QuotaBytes:     conf.ActionCacheProjectQuotaBytes(),
+	}
+	if total := stat.Hits + stat.PartialHits + stat.Misses; total > 0 {
+		stats.HitRatio = float64(stat.Hits+stat.PartialHits) / float64(total)
+	}
+	return &stats, nil
+}
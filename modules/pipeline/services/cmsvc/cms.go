@@ -70,9 +70,10 @@ func (s *CMSvc) PrefixListNS(source apistructs.PipelineSource, nsPrefix string)
 	return namespaces, nil
 }
 
-func (s *CMSvc) UpdateConfigs(source apistructs.PipelineSource, ns string, kvs map[string]apistructs.PipelineCmsConfigValue) error {
+func (s *CMSvc) UpdateConfigs(source apistructs.PipelineSource, ns string, kvs map[string]apistructs.PipelineCmsConfigValue, operator string) error {
 	ctx := context.Background()
 	ctx = context.WithValue(ctx, cms.CtxKeyPipelineSource, source)
+	ctx = context.WithValue(ctx, cms.CtxKeyOperator, operator)
 
 	// 参数校验
 	if ns == "" {
@@ -86,11 +87,12 @@ func (s *CMSvc) UpdateConfigs(source apistructs.PipelineSource, ns string, kvs m
 	return nil
 }
 
-func (s *CMSvc) DeleteConfigs(source apistructs.PipelineSource, ns string, deleteKeys []string, forceDel bool) error {
+func (s *CMSvc) DeleteConfigs(source apistructs.PipelineSource, ns string, deleteKeys []string, forceDel bool, operator string) error {
 
 	ctx := context.Background()
 	ctx = context.WithValue(ctx, cms.CtxKeyPipelineSource, source)
 	ctx = context.WithValue(ctx, cms.CtxKeyForceDelete, forceDel)
+	ctx = context.WithValue(ctx, cms.CtxKeyOperator, operator)
 
 	// 参数校验
 	if ns == "" {
@@ -138,3 +140,51 @@ func (s *CMSvc) GetConfigs(source apistructs.PipelineSource, ns string, globalDe
 
 	return results, nil
 }
+
+func (s *CMSvc) ListConfigRevisions(source apistructs.PipelineSource, ns string, keys ...string) ([]apistructs.PipelineCmsConfigRevision, error) {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, cms.CtxKeyPipelineSource, source)
+
+	// 参数校验
+	if ns == "" {
+		return nil, apierrors.ErrListPipelineCmsConfigRevisions.InvalidParameter(errEmptyNs)
+	}
+
+	revisions, err := s.cm.ListConfigRevisions(ctx, ns, keys...)
+	if err != nil {
+		return nil, apierrors.ErrListPipelineCmsConfigRevisions.InternalError(err)
+	}
+	return revisions, nil
+}
+
+func (s *CMSvc) DiffConfigRevisions(source apistructs.PipelineSource, ns string, fromRevision, toRevision uint64) ([]apistructs.PipelineCmsConfigDiff, error) {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, cms.CtxKeyPipelineSource, source)
+
+	// 参数校验
+	if ns == "" {
+		return nil, apierrors.ErrDiffPipelineCmsConfigRevisions.InvalidParameter(errEmptyNs)
+	}
+
+	diffs, err := s.cm.DiffConfigRevisions(ctx, ns, fromRevision, toRevision)
+	if err != nil {
+		return nil, apierrors.ErrDiffPipelineCmsConfigRevisions.InternalError(err)
+	}
+	return diffs, nil
+}
+
+func (s *CMSvc) RollbackConfigs(source apistructs.PipelineSource, ns string, revision uint64, operator string) error {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, cms.CtxKeyPipelineSource, source)
+	ctx = context.WithValue(ctx, cms.CtxKeyOperator, operator)
+
+	// 参数校验
+	if ns == "" {
+		return apierrors.ErrRollbackPipelineCmsConfigs.InvalidParameter(errEmptyNs)
+	}
+
+	if err := s.cm.RollbackConfigs(ctx, ns, revision); err != nil {
+		return apierrors.ErrRollbackPipelineCmsConfigs.InternalError(err)
+	}
+	return nil
+}
@@ -15,6 +15,8 @@ package cmsvc
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/erda-project/erda/apistructs"
 	"github.com/erda-project/erda/modules/pkg/gitflowutil"
@@ -31,3 +33,20 @@ func (s *CMSvc) MakeBranchPrefixSecretNamespace(appID, branch string) (string, e
 	}
 	return fmt.Sprintf("%s-%s-%s", apistructs.PipelineAppConfigNameSpacePreFix, appID, branchPrefix), nil
 }
+
+// ParseAppIDFromSecretNamespace 从应用配置命名空间中解析 appID，格式: pipeline-secrets-app-{appID}-{suffix}
+func ParseAppIDFromSecretNamespace(ns string) (uint64, bool) {
+	prefix := apistructs.PipelineAppConfigNameSpacePreFix + "-"
+	if !strings.HasPrefix(ns, prefix) {
+		return 0, false
+	}
+	idx := strings.Index(ns[len(prefix):], "-")
+	if idx <= 0 {
+		return 0, false
+	}
+	appID, err := strconv.ParseUint(ns[len(prefix):len(prefix)+idx], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return appID, true
+}
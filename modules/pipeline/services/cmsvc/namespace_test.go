@@ -0,0 +1,35 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cmsvc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAppIDFromSecretNamespace(t *testing.T) {
+	appID, ok := ParseAppIDFromSecretNamespace("pipeline-secrets-app-123-default")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(123), appID)
+
+	appID, ok = ParseAppIDFromSecretNamespace("pipeline-secrets-app-45-feature")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(45), appID)
+
+	for _, ns := range []string{"", "pipeline-secrets-app-", "pipeline-secrets-app-abc-default", "pipeline-secrets-app-12", "dice-config-1"} {
+		_, ok = ParseAppIDFromSecretNamespace(ns)
+		assert.False(t, ok, ns)
+	}
+}
@@ -100,6 +100,28 @@ func (s *CrondSvc) ReloadCrond(pipelineCronFunc func(uint64)) ([]string, error)
 		logs = append(logs, fmt.Sprintf("loaded build cache clean cron task: %s", buildCacheCleanJobName))
 	}
 
+	// clean action cache cron task
+	actionCacheCleanJobName := makeCleanActionCacheJobName(conf.ActionCacheCleanJobCron())
+	if err = s.crond.AddFunc(conf.ActionCacheCleanJobCron(), s.CleanActionCaches, actionCacheCleanJobName); err != nil {
+		l := fmt.Sprintf("failed to load action cache clean cron task: %s, err: %v", actionCacheCleanJobName, err)
+		logs = append(logs, l)
+		logrus.Errorln("[alert]", l)
+	} else {
+		logs = append(logs, fmt.Sprintf("loaded action cache clean cron task: %s", actionCacheCleanJobName))
+	}
+
+	// export archived pipelines to cold storage cron task
+	if conf.ArchiveExportEnabled() && s.archiveExportFunc != nil {
+		archiveExportJobName := makeArchiveExportJobName(conf.ArchiveExportJobCron())
+		if err = s.crond.AddFunc(conf.ArchiveExportJobCron(), s.archiveExportFunc, archiveExportJobName); err != nil {
+			l := fmt.Sprintf("failed to load archive export cron task: %s, err: %v", archiveExportJobName, err)
+			logs = append(logs, l)
+			logrus.Errorln("[alert]", l)
+		} else {
+			logs = append(logs, fmt.Sprintf("loaded archive export cron task: %s", archiveExportJobName))
+		}
+	}
+
 	logs = append(logs, "reload crond DONE")
 	logs = append(logs, s.crondSnapshot()...)
 
@@ -130,3 +152,11 @@ func makePipelineCronName(cron spec.PipelineCron) string {
 func makeCleanBuildCacheJobName(cronExpr string) string {
 	return fmt.Sprintf("clean-build-cache-image-[%s]", cronExpr)
 }
+
+func makeCleanActionCacheJobName(cronExpr string) string {
+	return fmt.Sprintf("clean-action-cache-[%s]", cronExpr)
+}
+
+func makeArchiveExportJobName(cronExpr string) string {
+	return fmt.Sprintf("export-pipeline-archives-[%s]", cronExpr)
+}
@@ -29,6 +29,8 @@ type CrondSvc struct {
 	dbClient *dbclient.Client
 	bdl      *bundle.Bundle
 	js       jsonstore.JsonStore
+
+	archiveExportFunc func()
 }
 
 func New(dbClient *dbclient.Client, bdl *bundle.Bundle, js jsonstore.JsonStore) *CrondSvc {
@@ -40,3 +42,8 @@ func New(dbClient *dbclient.Client, bdl *bundle.Bundle, js jsonstore.JsonStore)
 	d.js = js
 	return &d
 }
+
+// WithArchiveExportFunc 设置归档流水线导出冷存储的定时任务
+func (s *CrondSvc) WithArchiveExportFunc(f func()) {
+	s.archiveExportFunc = f
+}
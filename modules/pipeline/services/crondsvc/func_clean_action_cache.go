@@ -0,0 +1,130 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package crondsvc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/actionagent/agenttool"
+	"github.com/erda-project/erda/modules/pipeline/conf"
+	"github.com/erda-project/erda/modules/pipeline/pipengine/pvolumes"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+// CleanActionCaches 项目缓存总大小超过配额时，按最近访问时间从旧到新淘汰缓存
+func (s *CrondSvc) CleanActionCaches() {
+	quota := conf.ActionCacheProjectQuotaBytes()
+	projects, err := s.dbClient.ListActionCacheProjectsOverQuota(quota)
+	if err != nil {
+		logrus.Errorf("[alert] failed to list action cache projects over quota, err: %v", err)
+		return
+	}
+
+	storages := make(map[string]*agenttool.ObjectStorage)
+	mountPoints := make(map[string]string)
+	for projectID, size := range projects {
+		caches, err := s.dbClient.ListActionCachesByProject(projectID)
+		if err != nil {
+			logrus.Errorf("[alert] failed to list action caches, projectID: %d, err: %v", projectID, err)
+			continue
+		}
+		for _, cache := range caches {
+			if size <= quota {
+				break
+			}
+			if err := s.removeActionCacheFile(cache, storages, mountPoints); err != nil {
+				logrus.Errorf("[alert] failed to remove action cache, projectID: %d, location: %s, err: %v", projectID, cache.Location, err)
+				continue
+			}
+			if err := s.dbClient.DeleteActionCache(cache.ID); err != nil {
+				logrus.Errorf("[alert] failed to delete action cache record, id: %d, err: %v", cache.ID, err)
+				continue
+			}
+			size -= cache.SizeBytes
+			logrus.Infof("evicted action cache, projectID: %d, key: %s, location: %s, size: %d", projectID, cache.CacheKey, cache.Location, cache.SizeBytes)
+		}
+	}
+}
+
+// removeActionCacheFile 删除缓存文件，只有确认文件已不存在时才返回 nil
+func (s *CrondSvc) removeActionCacheFile(cache spec.PipelineActionCache, storages map[string]*agenttool.ObjectStorage,
+	mountPoints map[string]string) error {
+	switch cache.StorageType {
+	case string(spec.StoreTypeDiceCacheOSS):
+		storage, ok := storages[cache.ClusterName]
+		if !ok {
+			clusterInfo, err := s.bdl.QueryClusterInfo(cache.ClusterName)
+			if err != nil {
+				return err
+			}
+			cfg, ok := pvolumes.GetObjectStorageConfig(clusterInfo)
+			if !ok {
+				return errors.Errorf("object storage is not configured for cluster %s", cache.ClusterName)
+			}
+			storage, err = agenttool.NewObjectStorage(cfg.Endpoint, cfg.Region, cfg.Bucket, cfg.AccessKey, cfg.SecretKey, cfg.SessionToken, cfg.Secure)
+			if err != nil {
+				return err
+			}
+			storages[cache.ClusterName] = storage
+		}
+		return storage.Remove(cache.Location)
+	default:
+		// 网盘缓存，location 为集群宿主机上的路径，需要映射到 pipeline 容器内挂载的网盘目录后再删除
+		localMountPoint, ok := conf.ActionCacheNFSMountPoints()[cache.ClusterName]
+		if !ok {
+			return errors.Errorf("nfs mount point of cluster %s is not configured", cache.ClusterName)
+		}
+		if fi, err := os.Stat(localMountPoint); err != nil || !fi.IsDir() {
+			return errors.Errorf("nfs mount point %s of cluster %s is not mounted", localMountPoint, cache.ClusterName)
+		}
+		clusterMountPoint, ok := mountPoints[cache.ClusterName]
+		if !ok {
+			clusterInfo, err := s.bdl.QueryClusterInfo(cache.ClusterName)
+			if err != nil {
+				return err
+			}
+			clusterMountPoint = clusterInfo.Get(apistructs.DICE_STORAGE_MOUNTPOINT)
+			if clusterMountPoint == "" {
+				return errors.Errorf("%s is not configured for cluster %s", apistructs.DICE_STORAGE_MOUNTPOINT, cache.ClusterName)
+			}
+			mountPoints[cache.ClusterName] = clusterMountPoint
+		}
+		path, err := makeLocalNFSCachePath(cache.Location, clusterMountPoint, localMountPoint)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			return errors.Errorf("cache file %s still exists after remove, err: %v", path, err)
+		}
+		return nil
+	}
+}
+
+// makeLocalNFSCachePath 将集群网盘上的缓存路径映射为 pipeline 容器内挂载目录下的路径
+func makeLocalNFSCachePath(location, clusterMountPoint, localMountPoint string) (string, error) {
+	rel, err := filepath.Rel(filepath.Clean(clusterMountPoint), filepath.Clean(location))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, "../") || filepath.IsAbs(rel) {
+		return "", errors.Errorf("cache location %s is not under nfs mount point %s", location, clusterMountPoint)
+	}
+	return filepath.Join(localMountPoint, rel), nil
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package crondsvc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeLocalNFSCachePath(t *testing.T) {
+	path, err := makeLocalNFSCachePath("/netdata/devops/ci/caches/1/2/m2.tar", "/netdata", "/mnt/terminus-dev")
+	assert.NoError(t, err)
+	assert.Equal(t, "/mnt/terminus-dev/devops/ci/caches/1/2/m2.tar", path)
+
+	_, err = makeLocalNFSCachePath("/netdata/../etc/passwd", "/netdata", "/mnt/terminus-dev")
+	assert.Error(t, err)
+
+	_, err = makeLocalNFSCachePath("/netdata2/a", "/netdata", "/mnt/terminus-dev")
+	assert.Error(t, err)
+
+	_, err = makeLocalNFSCachePath("/netdata", "/netdata", "/mnt/terminus-dev")
+	assert.Error(t, err)
+}
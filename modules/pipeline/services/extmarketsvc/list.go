@@ -97,14 +97,63 @@ func (s *ExtMarketSvc) SearchActions(items []string, ops ...OpOption) (map[strin
 			logrus.Errorf("[alert] %s, action's spec.yml: %#v", errMsg, action.Spec)
 			return nil, nil, errors.New(errMsg)
 		}
-		var actionSpec apistructs.ActionSpec
-		if err := yaml.Unmarshal([]byte(specYmlStr), &actionSpec); err != nil {
-			errMsg := fmt.Sprintf("failed to parse action's spec.yml, action: %s, err: %v", nameVersion, err)
-			logrus.Errorf("[alert] %s, action's spec.yml: %#v", errMsg, action.Spec)
-			return nil, nil, errors.New(errMsg)
+		actionSpec, err := parseActionSpec(nameVersion, specYmlStr)
+		if err != nil {
+			return nil, nil, err
 		}
-		actionSpecMap[nameVersion] = &actionSpec
+		actionSpecMap[nameVersion] = actionSpec
 	}
 
 	return actionDiceYmlJobMap, actionSpecMap, nil
 }
+
+// SearchActionSpecs 只查询 action 的 spec.yml，扩展市场中不存在的 action 对应的值为 nil
+func (s *ExtMarketSvc) SearchActionSpecs(items []string) (map[string]*apistructs.ActionSpec, error) {
+	actionSpecMap := make(map[string]*apistructs.ActionSpec, len(items))
+	if len(items) == 0 {
+		return actionSpecMap, nil
+	}
+	actions, err := s.bdl.SearchExtensions(apistructs.ExtensionSearchRequest{Extensions: items, YamlFormat: true})
+	if err != nil {
+		return nil, err
+	}
+	for nameVersion, action := range actions {
+		actionSpecMap[nameVersion] = nil
+		if action.NotExist() {
+			continue
+		}
+		specYmlStr, ok := action.Spec.(string)
+		if !ok {
+			return nil, fmt.Errorf("failed to search action from extension market, action: %s, err: %s", nameVersion, "action's spec.yml is not string")
+		}
+		actionSpec, err := parseActionSpec(nameVersion, specYmlStr)
+		if err != nil {
+			return nil, err
+		}
+		actionSpecMap[nameVersion] = actionSpec
+	}
+	return actionSpecMap, nil
+}
+
+// ListActionSpecs 查询扩展市场中所有公开 action 默认版本的 spec.yml，key 为 action type
+func (s *ExtMarketSvc) ListActionSpecs() (map[string]*apistructs.ActionSpec, error) {
+	extensions, err := s.bdl.QueryExtensions(apistructs.ExtensionQueryRequest{Type: "action"})
+	if err != nil {
+		return nil, err
+	}
+	var items []string
+	for _, ext := range extensions {
+		items = append(items, ext.Name)
+	}
+	return s.SearchActionSpecs(items)
+}
+
+func parseActionSpec(nameVersion, specYmlStr string) (*apistructs.ActionSpec, error) {
+	var actionSpec apistructs.ActionSpec
+	if err := yaml.Unmarshal([]byte(specYmlStr), &actionSpec); err != nil {
+		errMsg := fmt.Sprintf("failed to parse action's spec.yml, action: %s, err: %v", nameVersion, err)
+		logrus.Errorf("[alert] %s, action's spec.yml: %#v", errMsg, specYmlStr)
+		return nil, errors.New(errMsg)
+	}
+	return &actionSpec, nil
+}
@@ -16,6 +16,8 @@ package pipelinesvc
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -69,6 +71,10 @@ func (s *PipelineSvc) DealPipelineCallbackOfAction(data []byte) (err error) {
 	if err = s.doCallbackOfJarResource(&p, &task, cb); err != nil {
 		return err
 	}
+	// 3. action caches
+	if err = s.doCallbackOfActionCaches(&p, &task, cb); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -130,6 +136,45 @@ func (s *PipelineSvc) doCallbackOfJarResource(p *spec.Pipeline, task *spec.Pipel
 	return nil
 }
 
+// doCallbackOfActionCaches 记录缓存文件与命中情况，用于统计命中率和按项目淘汰缓存
+func (s *PipelineSvc) doCallbackOfActionCaches(p *spec.Pipeline, task *spec.PipelineTask, cb apistructs.ActionCallback) error {
+	if len(cb.CacheReports) == 0 {
+		return nil
+	}
+	projectID, _ := strconv.ParseUint(p.GetLabel(apistructs.LabelProjectID), 10, 64)
+	appID, _ := strconv.ParseUint(p.GetLabel(apistructs.LabelAppID), 10, 64)
+	now := time.Now()
+	for _, report := range cb.CacheReports {
+		switch report.Operation {
+		case apistructs.PipelineActionCacheOperationRestore:
+			if err := s.dbClient.IncrActionCacheStat(projectID, report.Result); err != nil {
+				return err
+			}
+			if report.Location == "" {
+				continue
+			}
+			if err := s.dbClient.TouchActionCache(p.ClusterName, report.Location, now); err != nil {
+				return err
+			}
+		case apistructs.PipelineActionCacheOperationStore:
+			if err := s.dbClient.UpsertActionCache(&spec.PipelineActionCache{
+				ProjectID:      projectID,
+				AppID:          appID,
+				ClusterName:    p.ClusterName,
+				StorageType:    report.StorageType,
+				Location:       report.Location,
+				CacheKey:       report.Key,
+				CachePath:      report.Path,
+				SizeBytes:      report.SizeBytes,
+				LastAccessedAt: now,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // findFlinkSparkTasks 寻找 depend 为指定值的 task
 func (s *PipelineSvc) findFlinkSparkTasks(p *spec.Pipeline, depend string) ([]spec.PipelineTask, error) {
 	tasks, err := s.dbClient.ListPipelineTasksByPipelineID(p.ID)
@@ -0,0 +1,311 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelinesvc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/commonutil/costtimeutil"
+	"github.com/erda-project/erda/modules/pipeline/commonutil/thirdparty/gittarutil"
+	"github.com/erda-project/erda/modules/pipeline/pipengine/pvolumes"
+	"github.com/erda-project/erda/modules/pipeline/services/apierrors"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+	"github.com/erda-project/erda/pkg/desensitize"
+	"github.com/erda-project/erda/pkg/discover"
+	"github.com/erda-project/erda/pkg/strutil"
+)
+
+const (
+	compareCommitsLimit  = 50
+	compareContextLines  = 3
+	compareMaskedValue   = "******"
+	compareMinSecretSize = 4 // 过短的值容易误伤普通文本，不做替换
+)
+
+// Compare 对比两次流水线运行，返回结构化差异，secret 均已脱敏
+func (s *PipelineSvc) Compare(basePipelineID, targetPipelineID uint64) (*apistructs.PipelineCompareData, error) {
+	base, baseTasks, err := s.getPipelineForCompare(basePipelineID)
+	if err != nil {
+		return nil, err
+	}
+	target, targetTasks, err := s.getPipelineForCompare(targetPipelineID)
+	if err != nil {
+		return nil, err
+	}
+	masker := newCompareMasker(base, target)
+
+	return &apistructs.PipelineCompareData{
+		Base:   makeCompareSummary(base),
+		Target: makeCompareSummary(target),
+		PipelineYml: compareYml(fmt.Sprintf("pipeline-%d", base.ID), fmt.Sprintf("pipeline-%d", target.ID),
+			masker.maskText(resolvedPipelineYml(base)), masker.maskText(resolvedPipelineYml(target))),
+		RunParams: compareValues(masker.maskValues(runParamsToMap(base)), masker.maskValues(runParamsToMap(target))),
+		Envs:      compareValues(masker.maskValues(base.Snapshot.Envs), masker.maskValues(target.Snapshot.Envs)),
+		Commits:   s.compareCommits(base, target),
+		Tasks:     compareTasks(baseTasks, targetTasks),
+	}, nil
+}
+
+func (s *PipelineSvc) getPipelineForCompare(pipelineID uint64) (*spec.Pipeline, []spec.PipelineTask, error) {
+	p, exist, err := s.dbClient.GetPipelineWithExistInfo(pipelineID)
+	if err != nil {
+		return nil, nil, apierrors.ErrComparePipeline.InternalError(err)
+	}
+	if !exist {
+		return nil, nil, apierrors.ErrComparePipeline.NotFound()
+	}
+	p.CostTimeSec = costtimeutil.CalculatePipelineCostTimeSec(&p)
+	tasks, err := s.dbClient.ListPipelineTasksByPipelineID(pipelineID)
+	if err != nil {
+		return nil, nil, apierrors.ErrComparePipeline.InternalError(err)
+	}
+	return &p, tasks, nil
+}
+
+func makeCompareSummary(p *spec.Pipeline) apistructs.PipelineCompareSummary {
+	return apistructs.PipelineCompareSummary{
+		PipelineID:  p.ID,
+		Status:      p.Status,
+		Branch:      p.Labels[apistructs.LabelBranch],
+		Commit:      p.GetCommitID(),
+		CostTimeSec: p.CostTimeSec,
+		TimeBegin:   p.TimeBegin,
+	}
+}
+
+// resolvedPipelineYml 优先使用运行时渲染后的 yml，未开始运行的流水线使用原始 yml
+func resolvedPipelineYml(p *spec.Pipeline) string {
+	if p.Snapshot.PipelineYml != "" {
+		return p.Snapshot.PipelineYml
+	}
+	return p.PipelineYml
+}
+
+func compareYml(baseName, targetName, base, target string) apistructs.PipelineCompareYmlDiff {
+	diff := unifiedDiff(baseName, targetName, base, target, compareContextLines)
+	return apistructs.PipelineCompareYmlDiff{Changed: diff != "", Diff: diff}
+}
+
+func runParamsToMap(p *spec.Pipeline) map[string]string {
+	result := make(map[string]string, len(p.Snapshot.RunPipelineParams))
+	for _, param := range p.Snapshot.RunPipelineParams {
+		result[param.Name] = getString(param.Value)
+	}
+	return result
+}
+
+// compareValues 按 key 排序返回变化的键值对
+func compareValues(base, target map[string]string) []apistructs.PipelineCompareValueDiff {
+	keys := make([]string, 0, len(base)+len(target))
+	for k := range base {
+		keys = append(keys, k)
+	}
+	for k := range target {
+		if _, ok := base[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var diffs []apistructs.PipelineCompareValueDiff
+	for _, k := range keys {
+		baseValue, inBase := base[k]
+		targetValue, inTarget := target[k]
+		diff := apistructs.PipelineCompareValueDiff{Key: k, Base: baseValue, Target: targetValue}
+		switch {
+		case !inBase:
+			diff.Type = apistructs.PipelineCompareChangeTypeAdded
+		case !inTarget:
+			diff.Type = apistructs.PipelineCompareChangeTypeRemoved
+		case baseValue != targetValue:
+			diff.Type = apistructs.PipelineCompareChangeTypeChanged
+		default:
+			continue
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs
+}
+
+// compareCommits 查询 base 到 target 之间新增的提交，查询失败不影响其他对比项
+func (s *PipelineSvc) compareCommits(base, target *spec.Pipeline) apistructs.PipelineCompareCommits {
+	result := apistructs.PipelineCompareCommits{
+		Repo: target.CommitDetail.RepoAbbr,
+		From: base.GetCommitID(),
+		To:   target.GetCommitID(),
+	}
+	if result.Repo == "" {
+		result.Repo = base.CommitDetail.RepoAbbr
+	}
+	if result.Repo == "" || result.From == "" || result.To == "" {
+		result.Error = "repo or commit not found"
+		return result
+	}
+	if result.From == result.To {
+		return result
+	}
+	compare, err := gittarutil.NewRepo(discover.Gittar(), result.Repo).CompareCommits(result.To, result.From, compareCommitsLimit)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.CommitsCount = compare.CommitsCount
+	for _, commit := range compare.Commits {
+		detail := apistructs.CommitDetail{
+			CommitID: commit.ID,
+			Repo:     result.Repo,
+			RepoAbbr: result.Repo,
+			Author:   commit.Committer.Name,
+			Email:    commit.Committer.Email,
+			Comment:  commit.CommitMessage,
+		}
+		if t, err := time.Parse(time.RFC3339, commit.Committer.When); err == nil {
+			detail.Time = &t
+		}
+		result.Commits = append(result.Commits, detail)
+	}
+	return result
+}
+
+// compareTasks 按任务名对齐，先按 base 中的顺序，再追加 target 中新增的任务
+func compareTasks(baseTasks, targetTasks []spec.PipelineTask) []apistructs.PipelineCompareTaskDiff {
+	targetByName := make(map[string]*spec.PipelineTask, len(targetTasks))
+	for i := range targetTasks {
+		targetByName[targetTasks[i].Name] = &targetTasks[i]
+	}
+	baseNames := make(map[string]struct{}, len(baseTasks))
+
+	var diffs []apistructs.PipelineCompareTaskDiff
+	for i := range baseTasks {
+		baseNames[baseTasks[i].Name] = struct{}{}
+		diffs = append(diffs, compareTask(baseTasks[i].Name, &baseTasks[i], targetByName[baseTasks[i].Name]))
+	}
+	for i := range targetTasks {
+		if _, ok := baseNames[targetTasks[i].Name]; ok {
+			continue
+		}
+		diffs = append(diffs, compareTask(targetTasks[i].Name, nil, &targetTasks[i]))
+	}
+	return diffs
+}
+
+func compareTask(name string, base, target *spec.PipelineTask) apistructs.PipelineCompareTaskDiff {
+	diff := apistructs.PipelineCompareTaskDiff{
+		Name:   name,
+		Base:   makeCompareTaskInfo(base),
+		Target: makeCompareTaskInfo(target),
+	}
+	if diff.Base == nil || diff.Target == nil {
+		return diff
+	}
+	diff.StatusChanged = diff.Base.Status != diff.Target.Status
+	diff.ActionVersionChanged = diff.Base.Type != diff.Target.Type || diff.Base.ActionVersion != diff.Target.ActionVersion
+	diff.ImageChanged = diff.Base.Image != diff.Target.Image
+	diff.CacheKeysChanged = strings.Join(diff.Base.CacheKeys, "\n") != strings.Join(diff.Target.CacheKeys, "\n")
+	if diff.Base.CostTimeSec >= 0 && diff.Target.CostTimeSec >= 0 {
+		delta := diff.Target.CostTimeSec - diff.Base.CostTimeSec
+		diff.CostTimeSecDelta = &delta
+	}
+	return diff
+}
+
+func makeCompareTaskInfo(task *spec.PipelineTask) *apistructs.PipelineCompareTaskInfo {
+	if task == nil {
+		return nil
+	}
+	return &apistructs.PipelineCompareTaskInfo{
+		TaskID:        task.ID,
+		Type:          task.Type,
+		ActionVersion: task.Extra.Action.Version,
+		Image:         task.Extra.Image,
+		Status:        task.Status,
+		CostTimeSec:   costtimeutil.CalculateTaskCostTimeSec(task),
+		CacheKeys:     taskCacheKeys(task),
+	}
+}
+
+// taskCacheKeys 从 task 的缓存挂载中获取缓存 key，动态缓存为运行前的 key 模板
+func taskCacheKeys(task *spec.PipelineTask) []string {
+	var keys []string
+	for _, storage := range task.Context.InStorages {
+		if !strings.HasPrefix(storage.Name, pvolumes.TaskCacheMame+"_") {
+			continue
+		}
+		key := storage.Labels[pvolumes.TaskCacheKey]
+		if key == "" {
+			key = storage.Value
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strutil.DedupSlice(keys)
+}
+
+// compareMasker 将加密配置项及敏感配置项的值替换为 ******
+type compareMasker struct {
+	replacer *strings.Replacer
+}
+
+func newCompareMasker(pipelines ...*spec.Pipeline) *compareMasker {
+	secretSet := make(map[string]struct{})
+	for _, p := range pipelines {
+		for _, secrets := range []map[string]string{p.Snapshot.Secrets, p.Snapshot.PlatformSecrets} {
+			for k, v := range secrets {
+				if len(v) < compareMinSecretSize {
+					continue
+				}
+				if strutil.Exist(p.Snapshot.EncryptSecretKeys, k) || desensitize.IsSensitiveKey(k) {
+					secretSet[v] = struct{}{}
+				}
+			}
+		}
+	}
+	secrets := make([]string, 0, len(secretSet))
+	for v := range secretSet {
+		secrets = append(secrets, v)
+	}
+	// 长的优先替换，避免被其子串先替换
+	sort.Slice(secrets, func(i, j int) bool {
+		if len(secrets[i]) != len(secrets[j]) {
+			return len(secrets[i]) > len(secrets[j])
+		}
+		return secrets[i] < secrets[j]
+	})
+	oldnew := make([]string, 0, len(secrets)*2)
+	for _, v := range secrets {
+		oldnew = append(oldnew, v, compareMaskedValue)
+	}
+	return &compareMasker{replacer: strings.NewReplacer(oldnew...)}
+}
+
+func (m *compareMasker) maskText(s string) string {
+	return m.replacer.Replace(s)
+}
+
+// maskValues key 为敏感名称时整体脱敏，否则替换其中出现的 secret
+func (m *compareMasker) maskValues(values map[string]string) map[string]string {
+	result := make(map[string]string, len(values))
+	for k, v := range values {
+		if v != "" && desensitize.IsSensitiveKey(k) {
+			result[k] = compareMaskedValue
+			continue
+		}
+		result[k] = m.maskText(v)
+	}
+	return result
+}
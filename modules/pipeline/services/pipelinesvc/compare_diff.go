@@ -0,0 +1,155 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelinesvc
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// maxLineDiffCells 行数乘积超过该值时不再计算最长公共子序列，直接视为整体替换
+	maxLineDiffCells = 4000000
+)
+
+type lineDiffOp struct {
+	kind byte // ' ', '-', '+'
+	line string
+	// baseLine, targetLine 该行之前已经过的行数
+	baseLine   int
+	targetLine int
+}
+
+// unifiedDiff 基于最长公共子序列生成 unified 格式的行级差异，内容相同时返回空
+func unifiedDiff(baseName, targetName, base, target string, contextLines int) string {
+	if base == target {
+		return ""
+	}
+	ops := diffLines(splitLines(base), splitLines(target))
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", baseName, targetName))
+	for _, hunk := range makeHunks(ops, contextLines) {
+		hunkOps := ops[hunk[0]:hunk[1]]
+		var baseCount, targetCount int
+		for _, op := range hunkOps {
+			if op.kind != '+' {
+				baseCount++
+			}
+			if op.kind != '-' {
+				targetCount++
+			}
+		}
+		b.WriteString(fmt.Sprintf("@@ -%s +%s @@\n",
+			hunkRange(hunkOps[0].baseLine, baseCount), hunkRange(hunkOps[0].targetLine, targetCount)))
+		for _, op := range hunkOps {
+			b.WriteByte(op.kind)
+			b.WriteString(op.line)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func diffLines(a, b []string) []lineDiffOp {
+	var ops []lineDiffOp
+	var i, j int
+	appendOp := func(kind byte, line string) {
+		ops = append(ops, lineDiffOp{kind: kind, line: line, baseLine: i, targetLine: j})
+		switch kind {
+		case ' ':
+			i++
+			j++
+		case '-':
+			i++
+		case '+':
+			j++
+		}
+	}
+
+	if len(a)*len(b) > maxLineDiffCells {
+		for _, line := range a {
+			appendOp('-', line)
+		}
+		for _, line := range b {
+			appendOp('+', line)
+		}
+		return ops
+	}
+
+	// lcs[x][y] 为 a[x:] 与 b[y:] 的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for x := range lcs {
+		lcs[x] = make([]int, len(b)+1)
+	}
+	for x := len(a) - 1; x >= 0; x-- {
+		for y := len(b) - 1; y >= 0; y-- {
+			if a[x] == b[y] {
+				lcs[x][y] = lcs[x+1][y+1] + 1
+			} else if lcs[x+1][y] >= lcs[x][y+1] {
+				lcs[x][y] = lcs[x+1][y]
+			} else {
+				lcs[x][y] = lcs[x][y+1]
+			}
+		}
+	}
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			appendOp(' ', a[i])
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			appendOp('+', b[j])
+		default:
+			appendOp('-', a[i])
+		}
+	}
+	return ops
+}
+
+// makeHunks 返回包含变化及其上下文的 ops 区间 [start, end)，相邻区间重叠时合并
+func makeHunks(ops []lineDiffOp, contextLines int) [][2]int {
+	var hunks [][2]int
+	for idx, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		start, end := idx-contextLines, idx+contextLines+1
+		if start < 0 {
+			start = 0
+		}
+		if end > len(ops) {
+			end = len(ops)
+		}
+		if len(hunks) > 0 && start <= hunks[len(hunks)-1][1] {
+			hunks[len(hunks)-1][1] = end
+			continue
+		}
+		hunks = append(hunks, [2]int{start, end})
+	}
+	return hunks
+}
+
+func hunkRange(passedLines, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", passedLines)
+	}
+	return fmt.Sprintf("%d,%d", passedLines+1, count)
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelinesvc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/pipengine/pvolumes"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	assert.Equal(t, "", unifiedDiff("a", "b", "x\ny\n", "x\ny\n", 3))
+
+	base := "version: \"1.1\"\nstages:\n  - stage:\n      - git-checkout:\n          version: \"1.0\"\n"
+	target := "version: \"1.1\"\nstages:\n  - stage:\n      - git-checkout:\n          version: \"2.0\"\n"
+	assert.Equal(t, "--- a\n+++ b\n@@ -4,2 +4,2 @@\n       - git-checkout:\n-          version: \"1.0\"\n+          version: \"2.0\"\n",
+		unifiedDiff("a", "b", base, target, 1))
+
+	// 新增内容
+	assert.Equal(t, "--- a\n+++ b\n@@ -0,0 +1,1 @@\n+x\n", unifiedDiff("a", "b", "", "x", 3))
+
+	// 相隔较远的变化拆分为多个 hunk
+	diff := unifiedDiff("a", "b", "1\n2\n3\n4\n5\n6\n7\n8\n", "0\n2\n3\n4\n5\n6\n7\n9\n", 1)
+	assert.Equal(t, "--- a\n+++ b\n@@ -1,2 +1,2 @@\n-1\n+0\n 2\n@@ -7,2 +7,2 @@\n 7\n-8\n+9\n", diff)
+}
+
+func TestCompareValues(t *testing.T) {
+	diffs := compareValues(
+		map[string]string{"a": "1", "b": "2", "c": "3"},
+		map[string]string{"a": "1", "b": "3", "d": "4"},
+	)
+	assert.Equal(t, []apistructs.PipelineCompareValueDiff{
+		{Key: "b", Type: apistructs.PipelineCompareChangeTypeChanged, Base: "2", Target: "3"},
+		{Key: "c", Type: apistructs.PipelineCompareChangeTypeRemoved, Base: "3"},
+		{Key: "d", Type: apistructs.PipelineCompareChangeTypeAdded, Target: "4"},
+	}, diffs)
+	assert.Empty(t, compareValues(nil, map[string]string{}))
+}
+
+func TestCompareTasks(t *testing.T) {
+	cacheStorage := func(key string) apistructs.MetadataField {
+		return apistructs.MetadataField{Name: pvolumes.TaskCacheMame + "_hash", Value: "/actions/caches/1/2/hash",
+			Labels: map[string]string{pvolumes.TaskCacheKey: key}}
+	}
+	baseTasks := []spec.PipelineTask{
+		{ID: 1, Name: "git", Type: "git-checkout", Status: apistructs.PipelineStatusSuccess, CostTimeSec: 10},
+		{ID: 2, Name: "build", Type: "buildpack", Status: apistructs.PipelineStatusSuccess, CostTimeSec: 60},
+		{ID: 3, Name: "removed", Type: "custom-script", Status: apistructs.PipelineStatusSuccess, CostTimeSec: 1},
+	}
+	baseTasks[1].Extra.Image = "buildpack:1"
+	baseTasks[1].Context.InStorages = []apistructs.MetadataField{cacheStorage("go-aaa")}
+	targetTasks := []spec.PipelineTask{
+		{ID: 11, Name: "git", Type: "git-checkout", Status: apistructs.PipelineStatusSuccess, CostTimeSec: 12},
+		{ID: 12, Name: "build", Type: "buildpack", Status: apistructs.PipelineStatusFailed, CostTimeSec: 30},
+		{ID: 13, Name: "added", Type: "custom-script", Status: apistructs.PipelineStatusAnalyzed, CostTimeSec: -1},
+	}
+	targetTasks[1].Extra.Image = "buildpack:2"
+	targetTasks[1].Extra.Action.Version = "2.0"
+	targetTasks[1].Context.InStorages = []apistructs.MetadataField{cacheStorage("go-bbb"), {Name: "other"}}
+
+	diffs := compareTasks(baseTasks, targetTasks)
+	assert.Len(t, diffs, 4)
+
+	assert.Equal(t, "git", diffs[0].Name)
+	assert.False(t, diffs[0].StatusChanged)
+	assert.Equal(t, int64(2), *diffs[0].CostTimeSecDelta)
+
+	build := diffs[1]
+	assert.True(t, build.StatusChanged)
+	assert.True(t, build.ImageChanged)
+	assert.True(t, build.ActionVersionChanged)
+	assert.True(t, build.CacheKeysChanged)
+	assert.Equal(t, []string{"go-bbb"}, build.Target.CacheKeys)
+	assert.Equal(t, int64(-30), *build.CostTimeSecDelta)
+
+	assert.Equal(t, "removed", diffs[2].Name)
+	assert.Nil(t, diffs[2].Target)
+	assert.Nil(t, diffs[2].CostTimeSecDelta)
+
+	assert.Equal(t, "added", diffs[3].Name)
+	assert.Nil(t, diffs[3].Base)
+}
+
+func TestCompareMasker(t *testing.T) {
+	base := &spec.Pipeline{}
+	base.Snapshot.Secrets = map[string]string{"db.password": "p@ss-1", "encrypted": "enc-value", "branch": "master", "short_token": "abc"}
+	base.Snapshot.EncryptSecretKeys = []string{"encrypted"}
+	target := &spec.Pipeline{}
+	target.Snapshot.PlatformSecrets = map[string]string{"dice.openapi.token": "tok-2"}
+
+	m := newCompareMasker(base, target)
+	assert.Equal(t, "url: ******@host, key: ******, branch: master, t: ******, abc",
+		m.maskText("url: p@ss-1@host, key: enc-value, branch: master, t: tok-2, abc"))
+	assert.Equal(t, map[string]string{"API_TOKEN": "******", "DSN": "root:******@db", "EMPTY_SECRET": ""},
+		m.maskValues(map[string]string{"API_TOKEN": "plain", "DSN": "root:p@ss-1@db", "EMPTY_SECRET": ""}))
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelinesvc
+
+import (
+	"fmt"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/events"
+	"github.com/erda-project/erda/modules/pipeline/services/apierrors"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+	"github.com/erda-project/erda/pkg/parser/pipelineyml"
+)
+
+const (
+	EventComponentConcurrencyGroup = "ConcurrencyGroup"
+)
+
+// makePipelineConcurrencyOptions 根据 yml 渲染并发组，未配置 concurrency 时返回 nil
+func makePipelineConcurrencyOptions(p *spec.Pipeline, s *pipelineyml.Spec) (*apistructs.PipelineConcurrencyOptions, error) {
+	if s.Concurrency == nil {
+		return nil, nil
+	}
+	group, err := pipelineyml.EvalConcurrencyGroup(s.Concurrency.Group, makeConcurrencyGroupContexts(p, s))
+	if err != nil {
+		return nil, err
+	}
+	if len(group) > maxSqlIndexLength {
+		return nil, fmt.Errorf("concurrency group is too long, max length: %d, group: %s", maxSqlIndexLength, group)
+	}
+	return &apistructs.PipelineConcurrencyOptions{
+		Group:            group,
+		CancelInProgress: s.Concurrency.CancelInProgress,
+	}, nil
+}
+
+// makeConcurrencyGroupContexts 并发组可引用的上下文，入参和环境变量以运行时传入的值优先
+func makeConcurrencyGroupContexts(p *spec.Pipeline, s *pipelineyml.Spec) map[string]string {
+	labels := p.MergeLabels()
+	contexts := map[string]string{
+		pipelineyml.ConcurrencyGroupContextBranch:    labels[apistructs.LabelBranch],
+		pipelineyml.ConcurrencyGroupContextSource:    p.PipelineSource.String(),
+		pipelineyml.ConcurrencyGroupContextYmlName:   p.PipelineYmlName,
+		pipelineyml.ConcurrencyGroupContextWorkspace: labels[apistructs.LabelDiceWorkspace],
+		pipelineyml.ConcurrencyGroupContextAppID:     labels[apistructs.LabelAppID],
+		pipelineyml.ConcurrencyGroupContextProjectID: labels[apistructs.LabelProjectID],
+		pipelineyml.ConcurrencyGroupContextOrgID:     labels[apistructs.LabelOrgID],
+	}
+	for _, param := range s.Params {
+		if param.Default != nil {
+			contexts[pipelineyml.MakeConcurrencyGroupParamsContextKey(param.Name)] = getString(param.Default)
+		}
+	}
+	for _, param := range p.Snapshot.RunPipelineParams {
+		if param.Value != nil {
+			contexts[pipelineyml.MakeConcurrencyGroupParamsContextKey(param.Name)] = getString(param.Value)
+		}
+	}
+	for k, v := range s.Envs {
+		contexts[pipelineyml.MakeConcurrencyGroupEnvsContextKey(k)] = v
+	}
+	for k, v := range p.Snapshot.Envs {
+		contexts[pipelineyml.MakeConcurrencyGroupEnvsContextKey(k)] = v
+	}
+	return contexts
+}
+
+// cancelConcurrencyGroupInProgress 配置了 cancel_in_progress 时，运行前取消同一并发组内运行中的流水线；
+// 未配置时由 reconciler 在入队前等待组内流水线结束
+func (s *PipelineSvc) cancelConcurrencyGroupInProgress(p *spec.Pipeline, identityInfo apistructs.IdentityInfo) error {
+	opt := p.Extra.ConcurrencyOptions
+	if opt == nil || !opt.CancelInProgress {
+		return nil
+	}
+	runningPipelineIDs, err := s.dbClient.ListConcurrencyGroupRunningPipelineIDs(p)
+	if err != nil {
+		return apierrors.ErrRunPipeline.InternalError(err)
+	}
+	for _, runningPipelineID := range runningPipelineIDs {
+		if err := s.Cancel(&apistructs.PipelineCancelRequest{
+			PipelineID:   runningPipelineID,
+			IdentityInfo: identityInfo,
+		}); err != nil {
+			return apierrors.ErrRunPipeline.InternalError(
+				fmt.Errorf("failed to cancel pipeline %d in concurrency group %s, err: %v", runningPipelineID, opt.Group, err))
+		}
+		events.EmitPipelineStreamReasonEvent(runningPipelineID, EventComponentConcurrencyGroup, events.EventLevelNormal,
+			apistructs.PipelineEventReasonConcurrencyGroupCanceled,
+			fmt.Sprintf("canceled by pipeline %d in the same concurrency group: %s", p.ID, opt.Group))
+	}
+	return nil
+}
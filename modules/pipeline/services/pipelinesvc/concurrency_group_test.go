@@ -0,0 +1,65 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelinesvc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+	"github.com/erda-project/erda/pkg/parser/pipelineyml"
+)
+
+func TestMakePipelineConcurrencyOptions(t *testing.T) {
+	p := &spec.Pipeline{
+		PipelineBase: spec.PipelineBase{PipelineSource: apistructs.PipelineSourceDice, PipelineYmlName: "pipeline.yml"},
+		Labels:       map[string]string{apistructs.LabelBranch: "feature/a", apistructs.LabelAppID: "1"},
+	}
+	p.Snapshot.Envs = map[string]string{"REGION": "hz"}
+	p.Snapshot.RunPipelineParams = apistructs.PipelineRunParamsWithValue{
+		{PipelineRunParam: apistructs.PipelineRunParam{Name: "env", Value: "prod"}},
+	}
+	s := &pipelineyml.Spec{
+		Params: []*pipelineyml.PipelineParam{{Name: "env", Default: "test"}, {Name: "tier", Default: 1}},
+		Envs:   map[string]string{"REGION": "sh"},
+	}
+
+	// 未配置 concurrency
+	opt, err := makePipelineConcurrencyOptions(p, s)
+	assert.NoError(t, err)
+	assert.Nil(t, opt)
+
+	// 运行时传入的参数和环境变量优先
+	s.Concurrency = &pipelineyml.ConcurrencyConfig{
+		Group:            "${{ app_id }}-${{ branch }}-${{ params.env }}-${{ params.tier }}-${{ envs.REGION }}",
+		CancelInProgress: true,
+	}
+	opt, err = makePipelineConcurrencyOptions(p, s)
+	assert.NoError(t, err)
+	assert.Equal(t, "1-feature/a-prod-1-hz", opt.Group)
+	assert.True(t, opt.CancelInProgress)
+
+	// 不存在的参数
+	s.Concurrency.Group = "${{ params.notexist }}"
+	_, err = makePipelineConcurrencyOptions(p, s)
+	assert.Error(t, err)
+
+	// 超过标签长度限制
+	s.Concurrency.Group = strings.Repeat("a", maxSqlIndexLength+1)
+	_, err = makePipelineConcurrencyOptions(p, s)
+	assert.Error(t, err)
+}
@@ -67,6 +67,8 @@ func (s *PipelineSvc) ConvertPipeline(p *spec.Pipeline) *apistructs.PipelineDTO
 	result.Extra.ConfigManageNamespaces = p.GetConfigManageNamespaces()
 	result.Extra.IsAutoRun = p.Extra.IsAutoRun
 	result.Extra.CallbackURLs = p.Extra.CallbackURLs
+	result.Extra.TimeoutOptions = p.Extra.TimeoutOptions
+	result.Extra.ConcurrencyOptions = p.Extra.ConcurrencyOptions
 	result.Progress = s.convertProgress(*p)
 
 	// from labels
@@ -99,6 +101,8 @@ func (s *PipelineSvc) Convert2PagePipeline(p *spec.Pipeline) *apistructs.PagePip
 			ConfigManageNamespaces: p.GetConfigManageNamespaces(),
 			IsAutoRun:              p.Extra.IsAutoRun,
 			CallbackURLs:           p.Extra.CallbackURLs,
+			TimeoutOptions:         p.Extra.TimeoutOptions,
+			ConcurrencyOptions:     p.Extra.ConcurrencyOptions,
 			PipelineYmlNameV1:      p.Extra.PipelineYmlNameV1,
 		},
 		FilterLabels:     p.Labels,
@@ -165,7 +165,8 @@ func (s *PipelineSvc) makePipelineFromRequest(req *apistructs.PipelineCreateRequ
 		return nil, apierrors.ErrParsePipelineYml.InternalError(err)
 	}
 	p.Extra.CronExpr = pipelineYml.Spec().Cron
-	if err := s.UpdatePipelineCron(p, nil, nil, pipelineYml.Spec().CronCompensator); err != nil {
+	p.Extra.TimeoutOptions = makePipelineTimeoutOptions(pipelineYml.Spec())
+	if err := s.UpdatePipelineCron(p, nil, nil, pipelineYml.Spec().CronCompensator, pipelineYml.Spec().ConcurrencyPolicy); err != nil {
 		return nil, apierrors.ErrCreatePipeline.InternalError(err)
 	}
 
@@ -236,80 +237,28 @@ func (s *PipelineSvc) createPipelineGraph(p *spec.Pipeline, passedDataOpt ...pas
 	}
 
 	// batch search extensions
-	var extItems []string
-	for _, stage := range pipelineYml.Spec().Stages {
-		for _, typedAction := range stage.Actions {
-			for _, action := range typedAction {
-				if action.Type.IsSnippet() {
-					continue
-				}
-				extItem := extmarketsvc.MakeActionTypeVersion(action)
-				// extension already searched, skip
-				if _, ok := passedData.actionJobDefines[extItem]; ok {
-					continue
-				}
-				extItems = append(extItems, extmarketsvc.MakeActionTypeVersion(action))
-			}
-		}
-	}
-	extItems = strutil.DedupSlice(extItems, true)
-	actionJobDefines, _, err := s.extMarketSvc.SearchActions(extItems)
-	if err != nil {
+	if err := s.searchActionJobDefines(pipelineYml, passedData.actionJobDefines); err != nil {
 		return apierrors.ErrCreatePipelineGraph.InternalError(err)
 	}
-	for extItem, actionJobDefine := range actionJobDefines {
-		passedData.actionJobDefines[extItem] = actionJobDefine
-	}
 
-	var snippetTasks []*spec.PipelineTask
+	stages, snippetTasks, err := s.makePipelineStages(p, pipelineYml, passedData.actionJobDefines, lastSuccessTaskMap)
+	if err != nil {
+		return err
+	}
 	var allStagedTasks [][]*spec.PipelineTask
-	for si, stage := range pipelineYml.Spec().Stages {
-		var stagedTasks []*spec.PipelineTask
-		ps := &spec.PipelineStage{
-			PipelineID:  p.ID,
-			Name:        "",
-			Status:      apistructs.PipelineStatusAnalyzed,
-			CostTimeSec: -1,
-			Extra:       spec.PipelineStageExtra{StageOrder: si},
-		}
-		if err := s.dbClient.CreatePipelineStage(ps, dbclient.WithTxSession(txSession.Session)); err != nil {
+	for _, stage := range stages {
+		if err := s.dbClient.CreatePipelineStage(stage.stage, dbclient.WithTxSession(txSession.Session)); err != nil {
 			return apierrors.ErrCreatePipelineGraph.InternalError(err)
 		}
-
-		// create tasks
-		for _, typedAction := range stage.Actions {
-			for actionType, action := range typedAction {
-				var pt *spec.PipelineTask
-				lastSuccessTask, ok := lastSuccessTaskMap[string(action.Alias)]
-				if ok {
-					pt = lastSuccessTask
-					pt.ID = 0
-					pt.PipelineID = p.ID
-					pt.StageID = ps.ID
-				} else {
-					switch actionType {
-					case apistructs.ActionTypeSnippet: // 生成嵌套流水线任务
-						pt, err = s.makeSnippetPipelineTask(p, ps, action)
-						if err != nil {
-							return apierrors.ErrCreatePipelineTask.InternalError(err)
-						}
-						snippetTasks = append(snippetTasks, pt)
-					default: // 生成普通任务
-						pt, err = s.makeNormalPipelineTask(p, ps, action, passedData.actionJobDefines[extmarketsvc.MakeActionTypeVersion(action)])
-						if err != nil {
-							return apierrors.ErrCreatePipelineTask.InternalError(err)
-						}
-					}
-				}
-				// 创建当前节点
-				if err := s.dbClient.CreatePipelineTask(pt, dbclient.WithTxSession(txSession.Session)); err != nil {
-					logrus.Errorf("[alert] failed to create pipeline task when create pipeline graph: %v", err)
-					return apierrors.ErrCreatePipelineTask.InternalError(err)
-				}
-				stagedTasks = append(stagedTasks, pt)
+		// 创建当前节点
+		for _, pt := range stage.tasks {
+			pt.StageID = stage.stage.ID
+			if err := s.dbClient.CreatePipelineTask(pt, dbclient.WithTxSession(txSession.Session)); err != nil {
+				logrus.Errorf("[alert] failed to create pipeline task when create pipeline graph: %v", err)
+				return apierrors.ErrCreatePipelineTask.InternalError(err)
 			}
 		}
-		allStagedTasks = append(allStagedTasks, stagedTasks)
+		allStagedTasks = append(allStagedTasks, stage.tasks)
 	}
 
 	// commit transaction
@@ -405,6 +354,95 @@ func (s *PipelineSvc) createPipelineGraph(p *spec.Pipeline, passedDataOpt ...pas
 	return nil
 }
 
+// pipelineStageWithTasks 根据 pipeline yml 生成的 stage 及其 task，尚未写入数据库
+type pipelineStageWithTasks struct {
+	stage *spec.PipelineStage
+	tasks []*spec.PipelineTask
+}
+
+// searchActionJobDefines 批量查询 yml 中引用的 action 定义，已查询过的 action 会被跳过
+func (s *PipelineSvc) searchActionJobDefines(pipelineYml *pipelineyml.PipelineYml, actionJobDefines map[string]*diceyml.Job) error {
+	var extItems []string
+	for _, stage := range pipelineYml.Spec().Stages {
+		for _, typedAction := range stage.Actions {
+			for _, action := range typedAction {
+				if action.Type.IsSnippet() {
+					continue
+				}
+				extItem := extmarketsvc.MakeActionTypeVersion(action)
+				// extension already searched, skip
+				if _, ok := actionJobDefines[extItem]; ok {
+					continue
+				}
+				extItems = append(extItems, extItem)
+			}
+		}
+	}
+	extItems = strutil.DedupSlice(extItems, true)
+	if len(extItems) == 0 {
+		return nil
+	}
+	searched, _, err := s.extMarketSvc.SearchActions(extItems)
+	if err != nil {
+		return err
+	}
+	for extItem, actionJobDefine := range searched {
+		actionJobDefines[extItem] = actionJobDefine
+	}
+	return nil
+}
+
+// makePipelineStages 根据 pipeline yml 生成 stage 与 task，不写入数据库，创建流水线与 dry-run 共用。
+// task 的 StageID 在 stage 创建后由调用方设置；actionJobDefines 中没有的 action 使用默认资源。
+func (s *PipelineSvc) makePipelineStages(p *spec.Pipeline, pipelineYml *pipelineyml.PipelineYml,
+	actionJobDefines map[string]*diceyml.Job, lastSuccessTaskMap map[string]*spec.PipelineTask) (
+	stages []*pipelineStageWithTasks, snippetTasks []*spec.PipelineTask, err error) {
+
+	for si, stage := range pipelineYml.Spec().Stages {
+		ps := &pipelineStageWithTasks{stage: &spec.PipelineStage{
+			PipelineID:  p.ID,
+			Name:        "",
+			Status:      apistructs.PipelineStatusAnalyzed,
+			CostTimeSec: -1,
+			Extra:       spec.PipelineStageExtra{StageOrder: si, TimeoutSec: stage.Timeout},
+		}}
+
+		// make tasks
+		for _, typedAction := range stage.Actions {
+			for actionType, action := range typedAction {
+				var pt *spec.PipelineTask
+				lastSuccessTask, ok := lastSuccessTaskMap[string(action.Alias)]
+				if ok {
+					pt = lastSuccessTask
+					pt.ID = 0
+					pt.PipelineID = p.ID
+				} else {
+					switch actionType {
+					case apistructs.ActionTypeSnippet: // 生成嵌套流水线任务
+						pt, err = s.makeSnippetPipelineTask(p, ps.stage, action)
+						if err != nil {
+							return nil, nil, apierrors.ErrCreatePipelineTask.InternalError(err)
+						}
+						snippetTasks = append(snippetTasks, pt)
+					default: // 生成普通任务
+						actionJobDefine, ok := actionJobDefines[extmarketsvc.MakeActionTypeVersion(action)]
+						if !ok {
+							actionJobDefine = &diceyml.Job{}
+						}
+						pt, err = s.makeNormalPipelineTask(p, ps.stage, action, actionJobDefine)
+						if err != nil {
+							return nil, nil, apierrors.ErrCreatePipelineTask.InternalError(err)
+						}
+					}
+				}
+				ps.tasks = append(ps.tasks, pt)
+			}
+		}
+		stages = append(stages, ps)
+	}
+	return stages, snippetTasks, nil
+}
+
 func getString(v interface{}) string {
 	if v == nil {
 		return ""
@@ -437,6 +475,19 @@ func (s *PipelineSvc) makePipelineFromCopy(o *spec.Pipeline) (p *spec.Pipeline,
 	p.Extra.RerunFailedDetail = nil
 	p.Extra.CronTriggerTime = nil
 	p.Extra.CompleteReconcilerGC = false
+	if o.Extra.TimeoutOptions != nil {
+		p.Extra.TimeoutOptions = &apistructs.PipelineTimeoutOptions{
+			TimeoutSec:      o.Extra.TimeoutOptions.TimeoutSec,
+			QueueTimeoutSec: o.Extra.TimeoutOptions.QueueTimeoutSec,
+			HasStageTimeout: o.Extra.TimeoutOptions.HasStageTimeout,
+		}
+	}
+	if o.Extra.ConcurrencyOptions != nil {
+		p.Extra.ConcurrencyOptions = &apistructs.PipelineConcurrencyOptions{
+			Group:            o.Extra.ConcurrencyOptions.Group,
+			CancelInProgress: o.Extra.ConcurrencyOptions.CancelInProgress,
+		}
+	}
 	p.TriggerMode = apistructs.PipelineTriggerModeManual // 手动触发
 	p.TimeCreated = &now
 	p.TimeUpdated = &now
@@ -179,6 +179,10 @@ func (s *PipelineSvc) judgeTaskExecutor(action *pipelineyml.Action) (spec.Pipeli
 	if action.Type == apistructs.ActionTypeAPITest {
 		return spec.PipelineTaskExecutorKindAPITest, spec.PipelineTaskExecutorNameAPITestDefault, nil
 	}
+	// 本地模式下有 commands 的 action 以宿主机进程运行
+	if conf.EnableLocalActionExecutor() && len(action.Commands) > 0 {
+		return spec.PipelineTaskExecutorKindLocal, spec.PipelineTaskExecutorNameLocalDefault, nil
+	}
 	return spec.PipelineTaskExecutorKindScheduler, spec.PipelineTaskExecutorNameSchedulerDefault, nil
 }
 
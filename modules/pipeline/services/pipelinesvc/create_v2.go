@@ -138,13 +138,25 @@ func logCompatibleFailed(key, value string, err error) {
 }
 
 func (s *PipelineSvc) makePipelineFromRequestV2(req *apistructs.PipelineCreateRequestV2) (*spec.Pipeline, error) {
+	p, pipelineYml, err := s.buildPipelineFromRequestV2(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.UpdatePipelineCron(p, req.CronStartFrom, req.ConfigManageNamespaces, pipelineYml.Spec().CronCompensator, pipelineYml.Spec().ConcurrencyPolicy); err != nil {
+		return nil, apierrors.ErrCreatePipeline.InternalError(err)
+	}
+	return p, nil
+}
+
+// buildPipelineFromRequestV2 根据请求生成 pipeline 对象，不写入任何数据，创建流水线与 dry-run 共用
+func (s *PipelineSvc) buildPipelineFromRequestV2(req *apistructs.PipelineCreateRequestV2) (*spec.Pipeline, *pipelineyml.PipelineYml, error) {
 	p := &spec.Pipeline{}
 
 	// 解析 pipeline yml 文件，生成最终 pipeline yml 文件
 	// 只解析最外层，获取 storage 和 cron 信息
 	pipelineYml, err := pipelineyml.New([]byte(req.PipelineYml), pipelineyml.WithEnvs(req.Envs))
 	if err != nil {
-		return nil, apierrors.ErrParsePipelineYml.InternalError(err)
+		return nil, nil, apierrors.ErrParsePipelineYml.InternalError(err)
 	}
 
 	p.PipelineYml = req.PipelineYml
@@ -193,7 +205,7 @@ func (s *PipelineSvc) makePipelineFromRequestV2(req *apistructs.PipelineCreateRe
 
 	version, err := pipelineyml.GetVersion([]byte(p.PipelineYml))
 	if err != nil {
-		return nil, apierrors.ErrParsePipelineYml.InvalidParameter(errors.Errorf("version (%v)", err))
+		return nil, nil, apierrors.ErrParsePipelineYml.InvalidParameter(errors.Errorf("version (%v)", err))
 	}
 	p.Extra.Version = version
 
@@ -238,7 +250,7 @@ func (s *PipelineSvc) makePipelineFromRequestV2(req *apistructs.PipelineCreateRe
 	if v, ok := labels[apistructs.LabelPipelineCronTriggerTime]; ok {
 		nano, err := strconv.ParseInt(v, 10, 64)
 		if err != nil {
-			return nil, apierrors.ErrCreatePipeline.InvalidParameter(err)
+			return nil, nil, apierrors.ErrCreatePipeline.InvalidParameter(err)
 		}
 		cronTriggerTime := time.Unix(0, nano)
 		p.Extra.CronTriggerTime = &cronTriggerTime
@@ -252,11 +264,11 @@ func (s *PipelineSvc) makePipelineFromRequestV2(req *apistructs.PipelineCreateRe
 	if v, ok := labels[apistructs.LabelPipelineCronID]; ok {
 		cronID, err := strconv.ParseUint(v, 10, 64)
 		if err != nil {
-			return nil, apierrors.ErrCreatePipeline.InvalidParameter(err)
+			return nil, nil, apierrors.ErrCreatePipeline.InvalidParameter(err)
 		}
 		pc, err := s.dbClient.GetPipelineCron(cronID)
 		if err != nil {
-			return nil, apierrors.ErrGetPipelineCron.InvalidParameter(err)
+			return nil, nil, apierrors.ErrGetPipelineCron.InvalidParameter(err)
 		}
 		p.CronID = &pc.ID
 		p.Extra.CronExpr = pc.CronExpr
@@ -276,10 +288,6 @@ func (s *PipelineSvc) makePipelineFromRequestV2(req *apistructs.PipelineCreateRe
 	// gc
 	p.Extra.GC = req.GC
 
-	if err := s.UpdatePipelineCron(p, req.CronStartFrom, req.ConfigManageNamespaces, pipelineYml.Spec().CronCompensator); err != nil {
-		return nil, apierrors.ErrCreatePipeline.InternalError(err)
-	}
-
 	// defined outputs
 	for _, output := range pipelineYml.Spec().Outputs {
 		p.Extra.DefinedOutputs = append(p.Extra.DefinedOutputs,
@@ -290,6 +298,19 @@ func (s *PipelineSvc) makePipelineFromRequestV2(req *apistructs.PipelineCreateRe
 			})
 	}
 
+	// timeout
+	p.Extra.TimeoutOptions = makePipelineTimeoutOptions(pipelineYml.Spec())
+
+	// concurrency group
+	concurrencyOptions, err := makePipelineConcurrencyOptions(p, pipelineYml.Spec())
+	if err != nil {
+		return nil, nil, apierrors.ErrCreatePipeline.InvalidParameter(err)
+	}
+	if concurrencyOptions != nil {
+		p.Extra.ConcurrencyOptions = concurrencyOptions
+		p.Labels[apistructs.LabelPipelineConcurrencyGroup] = concurrencyOptions.Group
+	}
+
 	// queue
 	if req.BindQueue != nil {
 		customPriority := req.BindQueue.Priority
@@ -306,19 +327,36 @@ func (s *PipelineSvc) makePipelineFromRequestV2(req *apistructs.PipelineCreateRe
 		}
 	}
 
-	return p, nil
+	return p, pipelineYml, nil
+}
+
+// makePipelineTimeoutOptions 根据 yml 生成超时配置，未配置任何超时时返回 nil
+func makePipelineTimeoutOptions(s *pipelineyml.Spec) *apistructs.PipelineTimeoutOptions {
+	opt := &apistructs.PipelineTimeoutOptions{
+		TimeoutSec:      s.Timeout,
+		QueueTimeoutSec: s.QueueTimeout,
+	}
+	for _, stage := range s.Stages {
+		if stage.Timeout > 0 {
+			opt.HasStageTimeout = true
+		}
+	}
+	if opt.TimeoutSec <= 0 && opt.QueueTimeoutSec <= 0 && !opt.HasStageTimeout {
+		return nil
+	}
+	return opt
 }
 
 // 非定时触发的，如果有定时配置，需要插入或更新 pipeline_crons enable 配置
 // 不管是定时还是非定时，只要定时配置是空的，就将pipeline_crons disable
-func (s *PipelineSvc) UpdatePipelineCron(p *spec.Pipeline, cronStartFrom *time.Time, configManageNamespaces []string, cronCompensator *pipelineyml.CronCompensator) error {
+func (s *PipelineSvc) UpdatePipelineCron(p *spec.Pipeline, cronStartFrom *time.Time, configManageNamespaces []string, cronCompensator *pipelineyml.CronCompensator, concurrencyPolicy string) error {
 
 	var cron *spec.PipelineCron
 
 	//是定时类型的流水线，切定时的表达式不为空，更新cron的配置
 	if p.TriggerMode != apistructs.PipelineTriggerModeCron && p.Extra.CronExpr != "" {
 
-		cron = constructPipelineCron(p, cronStartFrom, configManageNamespaces, cronCompensator)
+		cron = constructPipelineCron(p, cronStartFrom, configManageNamespaces, cronCompensator, concurrencyPolicy)
 
 		if err := s.dbClient.InsertOrUpdatePipelineCron(cron); err != nil {
 			return apierrors.ErrUpdatePipelineCron.InternalError(err)
@@ -332,7 +370,7 @@ func (s *PipelineSvc) UpdatePipelineCron(p *spec.Pipeline, cronStartFrom *time.T
 	//cron表达式为空，就需要关闭定时
 	if p.Extra.CronExpr == "" {
 
-		cron = constructPipelineCron(p, cronStartFrom, configManageNamespaces, cronCompensator)
+		cron = constructPipelineCron(p, cronStartFrom, configManageNamespaces, cronCompensator, concurrencyPolicy)
 		if err := s.dbClient.DisablePipelineCron(cron); err != nil {
 			return apierrors.ErrUpdatePipelineCron.InternalError(err)
 		}
@@ -346,7 +384,7 @@ func (s *PipelineSvc) UpdatePipelineCron(p *spec.Pipeline, cronStartFrom *time.T
 	return nil
 }
 
-func constructPipelineCron(p *spec.Pipeline, cronStartFrom *time.Time, configManageNamespaces []string, cronCompensator *pipelineyml.CronCompensator) *spec.PipelineCron {
+func constructPipelineCron(p *spec.Pipeline, cronStartFrom *time.Time, configManageNamespaces []string, cronCompensator *pipelineyml.CronCompensator, concurrencyPolicy string) *spec.PipelineCron {
 	appID, _ := strconv.ParseUint(p.Labels[apistructs.LabelAppID], 10, 64)
 	var compensator *apistructs.CronCompensator
 	if cronCompensator != nil {
@@ -373,6 +411,7 @@ func constructPipelineCron(p *spec.Pipeline, cronStartFrom *time.Time, configMan
 			Version:                "v2",
 			Compensator:            compensator,
 			LastCompensateAt:       nil,
+			ConcurrencyPolicy:      apistructs.PipelineCronConcurrencyPolicy(concurrencyPolicy),
 		},
 	}
 
@@ -80,6 +80,12 @@ func (s *PipelineSvc) RunCronPipelineFunc(id uint64) {
 		}
 	}
 
+	// 并发策略
+	var continueTrigger bool
+	if continueTrigger, err = s.applyCronConcurrencyPolicy(&pc, cronTriggerTime); err != nil || !continueTrigger {
+		return
+	}
+
 	// cron
 	if _, ok := pc.Extra.FilterLabels[apistructs.LabelPipelineTriggerMode]; ok {
 		pc.Extra.FilterLabels[apistructs.LabelPipelineTriggerMode] = apistructs.PipelineTriggerModeCron.String()
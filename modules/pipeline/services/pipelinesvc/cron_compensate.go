@@ -24,6 +24,8 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/events"
+	"github.com/erda-project/erda/modules/pipeline/metrics"
 	"github.com/erda-project/erda/modules/pipeline/pipengine/reconciler"
 	"github.com/erda-project/erda/modules/pipeline/spec"
 	"github.com/erda-project/erda/pkg/dlock"
@@ -93,6 +95,7 @@ func (s *PipelineSvc) getCompensateLock(cancel func()) (*dlock.DLock, error) {
 	lock, err := dlock.New(
 		cronCompensateDLockKey,
 		func() {
+			metrics.DLockLost(metrics.DLockCronCompensate)
 			compensateLog.Error("[alert] dlock lost, stop current compensate")
 			cancel()
 			time.Sleep(waitTimeIfLostDLock)
@@ -107,7 +110,9 @@ func (s *PipelineSvc) getCompensateLock(cancel func()) (*dlock.DLock, error) {
 		go s.ContinueCompensate()
 		return nil, err
 	}
-	if err := lock.Lock(context.Background()); err != nil {
+	err = lock.Lock(context.Background())
+	metrics.DLockAcquire(metrics.DLockCronCompensate, err)
+	if err != nil {
 		compensateLog.Errorf("[alert] failed to lock dlock, err: %v", err)
 		time.Sleep(waitTimeIfLostDLock)
 		go s.ContinueCompensate()
@@ -195,13 +200,28 @@ func (s *PipelineSvc) cronInterruptCompensate(pc spec.PipelineCron) error {
 		existPipelinesMap[getTriggeredTime(p)] = p
 	}
 
-	// 遍历 needTriggerTimes，若没创建，则需要中断补偿创建
-	for _, ntt := range needTriggerTimes {
-		p, ok := existPipelinesMap[ntt]
-		if ok {
-			compensateLog.Infof("no need do interrupt-compensate, cronID: %d, triggerTime: %v, exist pipelineID: %d", pc.ID, ntt, p.ID)
-			continue
+	// 非 Allow 并发策略下，存在运行中的流水线时本轮不补偿，等同于这些触发被跳过
+	compensateTriggerTimes := filterCronInterruptCompensateTimes(pc, needTriggerTimes, existPipelinesMap)
+	if len(compensateTriggerTimes) > 0 {
+		blockingPipelineIDs, err := s.listCronCompensateBlockingPipelineIDs(pc)
+		if err != nil {
+			return errors.Errorf("failed to list running pipelines, cronID: %d, err: %v", pc.ID, err)
 		}
+		if len(blockingPipelineIDs) > 0 {
+			compensateLog.Infof("skip interrupt-compensate, cronID: %d, triggerTimes: %v, running pipelineIDs: %v, concurrencyPolicy: %s",
+				pc.ID, compensateTriggerTimes, blockingPipelineIDs, pc.Extra.ConcurrencyPolicy)
+			for _, runningPipelineID := range blockingPipelineIDs {
+				events.EmitPipelineStreamReasonEvent(runningPipelineID, EventComponentCronDaemon, events.EventLevelNormal,
+					cronEventReasonCompensateSkipped,
+					fmt.Sprintf("interrupt-compensation of cron %d was skipped because pipeline is still running, concurrencyPolicy: %s",
+						pc.ID, pc.Extra.ConcurrencyPolicy))
+			}
+			compensateTriggerTimes = nil
+		}
+	}
+
+	// 遍历 compensateTriggerTimes，中断补偿创建
+	for _, ntt := range compensateTriggerTimes {
 		compensateLog.Infof("need do interrupt-compensate, cronID: %d, triggerTime: %v", pc.ID, ntt)
 		// create
 		created, err := s.createCronCompensatePipeline(pc, ntt)
@@ -247,6 +267,17 @@ func (s *PipelineSvc) cronNotExecuteCompensate(pc spec.PipelineCron) error {
 		return nil
 	}
 
+	// 非 Allow 并发策略下，存在运行中的流水线时不执行补偿，也不再等待回调，避免重复触发
+	blockingPipelineIDs, err := s.listCronCompensateBlockingPipelineIDs(pc)
+	if err != nil {
+		return errors.Errorf("failed to list running pipelines, cronID: %d, err: %v", pc.ID, err)
+	}
+	if len(blockingPipelineIDs) > 0 {
+		compensateLog.Infof("skip notexecute-compensate, cronID: %d, running pipelineIDs: %v, concurrencyPolicy: %s",
+			pc.ID, blockingPipelineIDs, pc.Extra.ConcurrencyPolicy)
+		return nil
+	}
+
 	now := time.Unix(time.Now().Unix(), 0)
 	oneDayBeforeNow := now.AddDate(0, 0, -1)
 
@@ -0,0 +1,134 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelinesvc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/events"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+const (
+	EventComponentCronDaemon = "CronDaemon"
+
+	cronEventReasonTriggerSkipped    = "CronTriggerSkipped"
+	cronEventReasonPipelineReplaced  = "CronPipelineReplaced"
+	cronEventReasonCompensateSkipped = "CronCompensateSkipped"
+)
+
+// applyCronConcurrencyPolicy 根据 cron 的并发策略处理同一 source + ymlName 下运行中的流水线，返回本次触发是否继续创建流水线。
+// 运行中的流水线与 limitParallelRunningPipelines 口径一致，即不区分是否为定时触发。
+// Forbid: 跳过本次触发，并记录跳过的触发时间，避免中断补偿再次补偿该时间点；
+// Replace: 取消运行中的流水线后继续触发。
+func (s *PipelineSvc) applyCronConcurrencyPolicy(pc *spec.PipelineCron, triggerTime time.Time) (bool, error) {
+	policy := pc.Extra.ConcurrencyPolicy
+	if policy.IsAllow() {
+		return true, nil
+	}
+
+	runningPipelineIDs, err := s.listRunningPipelineIDs(pc.PipelineSource, pc.PipelineYmlName)
+	if err != nil {
+		return false, err
+	}
+	if len(runningPipelineIDs) == 0 {
+		return true, nil
+	}
+
+	switch policy {
+	case apistructs.PipelineCronConcurrencyPolicyForbid:
+		for _, runningPipelineID := range runningPipelineIDs {
+			events.EmitPipelineStreamReasonEvent(runningPipelineID, EventComponentCronDaemon, events.EventLevelNormal,
+				cronEventReasonTriggerSkipped,
+				fmt.Sprintf("cron %d triggered at %s was skipped because pipeline is still running, concurrencyPolicy: %s",
+					pc.ID, triggerTime.Format(time.RFC3339), policy))
+		}
+		pc.Extra.LastSkippedAt = &triggerTime
+		if err := s.dbClient.UpdatePipelineCron(pc.ID, pc); err != nil {
+			return false, err
+		}
+		logrus.Infof("crond: pipelineCronID: %d, triggered but skipped, running pipelineIDs: %v, concurrencyPolicy: %s",
+			pc.ID, runningPipelineIDs, policy)
+		return false, nil
+
+	case apistructs.PipelineCronConcurrencyPolicyReplace:
+		for _, runningPipelineID := range runningPipelineIDs {
+			if err := s.Cancel(&apistructs.PipelineCancelRequest{
+				PipelineID: runningPipelineID,
+				IdentityInfo: apistructs.IdentityInfo{
+					UserID:         pc.Extra.NormalLabels[apistructs.LabelUserID],
+					InternalClient: "system-cron",
+				},
+			}); err != nil {
+				return false, fmt.Errorf("failed to cancel running pipeline %d, err: %v", runningPipelineID, err)
+			}
+			events.EmitPipelineStreamReasonEvent(runningPipelineID, EventComponentCronDaemon, events.EventLevelNormal,
+				cronEventReasonPipelineReplaced,
+				fmt.Sprintf("pipeline was canceled and replaced by cron %d triggered at %s, concurrencyPolicy: %s",
+					pc.ID, triggerTime.Format(time.RFC3339), policy))
+		}
+		logrus.Infof("crond: pipelineCronID: %d, replaced running pipelineIDs: %v, concurrencyPolicy: %s",
+			pc.ID, runningPipelineIDs, policy)
+		return true, nil
+
+	default:
+		return true, nil
+	}
+}
+
+// listCronCompensateBlockingPipelineIDs 非 Allow 策略下，运行中的流水线会阻塞补偿，防止补偿与运行中的流水线并发
+func (s *PipelineSvc) listCronCompensateBlockingPipelineIDs(pc spec.PipelineCron) ([]uint64, error) {
+	if pc.Extra.ConcurrencyPolicy.IsAllow() {
+		return nil, nil
+	}
+	return s.listRunningPipelineIDs(pc.PipelineSource, pc.PipelineYmlName)
+}
+
+// filterCronInterruptCompensateTimes 过滤出需要中断补偿的触发时间
+// Allow: 所有未创建流水线的触发时间都需要补偿；
+// Forbid/Replace: 只补偿晚于已创建流水线和已跳过触发的最近一个时间点，避免重复触发。
+func filterCronInterruptCompensateTimes(pc spec.PipelineCron, needTriggerTimes []time.Time, existPipelinesMap map[time.Time]spec.Pipeline) []time.Time {
+	allow := pc.Extra.ConcurrencyPolicy.IsAllow()
+
+	var latestHandledTime time.Time
+	if !allow {
+		if pc.Extra.LastSkippedAt != nil {
+			latestHandledTime = *pc.Extra.LastSkippedAt
+		}
+		for triggerTime := range existPipelinesMap {
+			if triggerTime.After(latestHandledTime) {
+				latestHandledTime = triggerTime
+			}
+		}
+	}
+
+	var result []time.Time
+	for _, ntt := range needTriggerTimes {
+		if _, ok := existPipelinesMap[ntt]; ok {
+			continue
+		}
+		if !allow && !ntt.After(latestHandledTime) {
+			continue
+		}
+		result = append(result, ntt)
+	}
+	if !allow && len(result) > 1 {
+		result = result[len(result)-1:]
+	}
+	return result
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelinesvc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+func Test_filterCronInterruptCompensateTimes(t *testing.T) {
+	base := time.Date(2021, 7, 1, 0, 0, 0, 0, time.Local)
+	t1, t2, t3, t4 := base, base.Add(time.Hour), base.Add(time.Hour*2), base.Add(time.Hour*3)
+	needTriggerTimes := []time.Time{t1, t2, t3, t4}
+	existPipelinesMap := map[time.Time]spec.Pipeline{t2: {}}
+
+	newCron := func(policy apistructs.PipelineCronConcurrencyPolicy, lastSkippedAt *time.Time) spec.PipelineCron {
+		return spec.PipelineCron{Extra: spec.PipelineCronExtra{ConcurrencyPolicy: policy, LastSkippedAt: lastSkippedAt}}
+	}
+
+	// 老的 cron 未配置策略，等同于 Allow，补偿所有缺失的触发
+	assert.Equal(t, []time.Time{t1, t3, t4}, filterCronInterruptCompensateTimes(newCron("", nil), needTriggerTimes, existPipelinesMap))
+	assert.Equal(t, []time.Time{t1, t3, t4}, filterCronInterruptCompensateTimes(newCron(apistructs.PipelineCronConcurrencyPolicyAllow, &t4), needTriggerTimes, existPipelinesMap))
+
+	// Forbid/Replace 只补偿最近一次
+	assert.Equal(t, []time.Time{t4}, filterCronInterruptCompensateTimes(newCron(apistructs.PipelineCronConcurrencyPolicyForbid, nil), needTriggerTimes, existPipelinesMap))
+	assert.Equal(t, []time.Time{t4}, filterCronInterruptCompensateTimes(newCron(apistructs.PipelineCronConcurrencyPolicyReplace, nil), needTriggerTimes, existPipelinesMap))
+
+	// 被跳过的触发不再补偿
+	skippedAt := t4.Add(time.Second)
+	assert.Empty(t, filterCronInterruptCompensateTimes(newCron(apistructs.PipelineCronConcurrencyPolicyForbid, &skippedAt), needTriggerTimes, existPipelinesMap))
+
+	// 早于已创建流水线的触发不再补偿
+	assert.Empty(t, filterCronInterruptCompensateTimes(newCron(apistructs.PipelineCronConcurrencyPolicyForbid, nil), needTriggerTimes[:3], map[time.Time]spec.Pipeline{t3: {}}))
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/erda-project/erda/modules/pipeline/pipengine"
 	"github.com/erda-project/erda/modules/pipeline/services/actionagentsvc"
 	"github.com/erda-project/erda/modules/pipeline/services/appsvc"
+	"github.com/erda-project/erda/modules/pipeline/services/archivesvc"
 	"github.com/erda-project/erda/modules/pipeline/services/cmsvc"
 	"github.com/erda-project/erda/modules/pipeline/services/crondsvc"
 	"github.com/erda-project/erda/modules/pipeline/services/extmarketsvc"
@@ -39,6 +40,7 @@ type PipelineSvc struct {
 	pipelineCronSvc *pipelinecronsvc.PipelineCronSvc
 	permissionSvc   *permissionsvc.PermissionSvc
 	queueManage     *queuemanage.QueueManage
+	archiveSvc      *archivesvc.ArchiveSvc
 
 	dbClient  *dbclient.Client
 	bdl       *bundle.Bundle
@@ -53,7 +55,7 @@ type PipelineSvc struct {
 func New(appSvc *appsvc.AppSvc, cmSvc *cmsvc.CMSvc, crondSvc *crondsvc.CrondSvc,
 	actionAgentSvc *actionagentsvc.ActionAgentSvc, extMarketSvc *extmarketsvc.ExtMarketSvc,
 	pipelineCronSvc *pipelinecronsvc.PipelineCronSvc, permissionSvc *permissionsvc.PermissionSvc,
-	queueManage *queuemanage.QueueManage,
+	queueManage *queuemanage.QueueManage, archiveSvc *archivesvc.ArchiveSvc,
 	dbClient *dbclient.Client, bdl *bundle.Bundle, publisher *websocket.Publisher,
 	engine *pipengine.Engine, js jsonstore.JsonStore, etcd *etcd.Store) *PipelineSvc {
 
@@ -66,6 +68,7 @@ func New(appSvc *appsvc.AppSvc, cmSvc *cmsvc.CMSvc, crondSvc *crondsvc.CrondSvc,
 	s.pipelineCronSvc = pipelineCronSvc
 	s.permissionSvc = permissionSvc
 	s.queueManage = queueManage
+	s.archiveSvc = archiveSvc
 	s.dbClient = dbClient
 	s.bdl = bdl
 	s.publisher = publisher
@@ -38,11 +38,13 @@ func (s *PipelineSvc) Get(pipelineID uint64) (*spec.Pipeline, error) {
 }
 
 func (s *PipelineSvc) Detail(pipelineID uint64) (*apistructs.PipelineDetailDTO, error) {
-	p, err := s.dbClient.GetPipeline(pipelineID)
-
+	p, exist, err := s.dbClient.GetPipelineWithExistInfo(pipelineID)
 	if err != nil {
 		return nil, apierrors.ErrGetPipelineDetail.InternalError(err)
 	}
+	if !exist {
+		return s.archivedDetail(pipelineID)
+	}
 
 	p.CostTimeSec = costtimeutil.CalculatePipelineCostTimeSec(&p)
 
@@ -139,6 +141,62 @@ func (s *PipelineSvc) Detail(pipelineID uint64) (*apistructs.PipelineDetailDTO,
 	return &detail, nil
 }
 
+// archivedDetail 从归档表或冷存储中组装已归档流水线的详情，已归档的流水线不支持任何操作
+func (s *PipelineSvc) archivedDetail(pipelineID uint64) (*apistructs.PipelineDetailDTO, error) {
+	if s.archiveSvc == nil {
+		return nil, apierrors.ErrGetPipelineDetail.NotFound()
+	}
+	archive, exist, err := s.archiveSvc.GetArchive(pipelineID)
+	if err != nil {
+		return nil, apierrors.ErrGetPipelineDetail.InternalError(err)
+	}
+	if !exist {
+		return nil, apierrors.ErrGetPipelineDetail.NotFound()
+	}
+	content := archive.Content
+	p := content.Pipeline
+	p.CostTimeSec = costtimeutil.CalculatePipelineCostTimeSec(&p)
+	if len(p.Extra.CronExpr) > 0 {
+		p.TimeCreated = p.Extra.CronTriggerTime
+	}
+	// 不展示 secret
+	p.Snapshot.Secrets = nil
+
+	tasksByStageID := make(map[uint64][]apistructs.PipelineTaskDTO, len(content.PipelineStages))
+	for _, task := range content.PipelineTasks {
+		task.CostTimeSec = costtimeutil.CalculateTaskCostTimeSec(&task)
+		tasksByStageID[task.StageID] = append(tasksByStageID[task.StageID], *task.Convert2DTO())
+	}
+	var stageDetailDTO []apistructs.PipelineStageDetailDTO
+	for _, stage := range content.PipelineStages {
+		taskDTOs := tasksByStageID[stage.ID]
+		if taskDTOs == nil {
+			taskDTOs = make([]apistructs.PipelineTaskDTO, 0)
+		}
+		stageDetailDTO = append(stageDetailDTO,
+			apistructs.PipelineStageDetailDTO{PipelineStageDTO: *stage.Convert2DTO(), PipelineTasks: taskDTOs})
+	}
+
+	var detail apistructs.PipelineDetailDTO
+	detail.PipelineDTO = *s.ConvertPipeline(&p)
+	labels := make(map[string]string, len(content.PipelineLabels))
+	for _, v := range content.PipelineLabels {
+		labels[v.Key] = v.Value
+	}
+	detail.PipelineDTO.Labels = labels
+	detail.PipelineStages = stageDetailDTO
+	detail.PipelineCron = &apistructs.PipelineCronDTO{}
+	s.setPipelineTaskActionDetail(&detail)
+
+	pipelineParams, err := getPipelineParams(p.PipelineYml, p.Snapshot.RunPipelineParams)
+	if err != nil {
+		return nil, err
+	}
+	detail.RunParams = pipelineParams
+
+	return &detail, nil
+}
+
 func getPipelineParams(pipelineYml string, runParams []apistructs.PipelineRunParamWithValue) ([]apistructs.PipelineParamDTO, error) {
 
 	pipeline, err := pipelineyml.New([]byte(pipelineYml))
@@ -0,0 +1,266 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelinesvc
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/conf"
+	"github.com/erda-project/erda/modules/pipeline/services/apierrors"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+	"github.com/erda-project/erda/pkg/expression"
+	"github.com/erda-project/erda/pkg/parser/diceyml"
+	"github.com/erda-project/erda/pkg/parser/pipelineyml"
+	"github.com/erda-project/erda/pkg/parser/pipelineyml/pexpr"
+)
+
+// maxDryRunSnippetDepth 嵌套流水线最大展开层数，防止循环引用
+const maxDryRunSnippetDepth = 10
+
+// runtimeContextRe 匹配只有在运行时才能确定的上下文，例如: outputs.xxx, dirs.xxx
+var runtimeContextRe = regexp.MustCompile(fmt.Sprintf(`\b(%s|%s|%s|%s)\.`,
+	expression.Outputs, expression.Dirs, expression.Configs, expression.Random))
+
+// DryRun 解析 pipeline.yml 并返回完整的执行计划，不会在数据库和集群中创建任何数据
+func (s *PipelineSvc) DryRun(req *apistructs.PipelineDryRunRequest) (*apistructs.PipelineDryRunPlan, error) {
+	if req == nil {
+		return nil, apierrors.ErrDryRunPipeline.MissingParameter("request")
+	}
+	if req.PipelineYml == "" {
+		return nil, apierrors.ErrDryRunPipeline.MissingParameter("pipelineYml")
+	}
+	if req.PipelineSource != "" && !req.PipelineSource.Valid() {
+		return nil, apierrors.ErrDryRunPipeline.InvalidParameter(fmt.Errorf("source: %s", req.PipelineSource))
+	}
+	if req.PipelineYmlName == "" {
+		req.PipelineYmlName = apistructs.DefaultPipelineYmlName
+	}
+
+	// 与创建流水线使用同样的请求，保证执行计划与实际创建的流水线一致
+	createReq := &apistructs.PipelineCreateRequestV2{
+		PipelineYml:            req.PipelineYml,
+		PipelineYmlName:        req.PipelineYmlName,
+		PipelineSource:         req.PipelineSource,
+		ClusterName:            req.ClusterName,
+		Labels:                 copyStringMap(req.Labels),
+		NormalLabels:           copyStringMap(req.NormalLabels),
+		Envs:                   req.Envs,
+		ConfigManageNamespaces: req.ConfigManageNamespaces,
+		RunParams:              req.RunParams,
+		IdentityInfo:           req.IdentityInfo,
+	}
+	secretSources, warns := s.collectDryRunSecretSources(createReq)
+	plan, err := s.makeDryRunPlan(createReq, secretSources, 0)
+	if err != nil {
+		return nil, err
+	}
+	plan.Warns = append(warns, plan.Warns...)
+	return plan, nil
+}
+
+// collectDryRunSecretSources 获取所有可用配置项的名称及来源，只读取名称，不解密。
+// 调用方需保证请求者有权访问 ConfigManageNamespaces 中的所有命名空间。
+func (s *PipelineSvc) collectDryRunSecretSources(req *apistructs.PipelineCreateRequestV2) (map[string]string, []string) {
+	var warns []string
+	sources := make(map[string]string)
+
+	// 配置管理
+	if len(req.ConfigManageNamespaces) > 0 {
+		if req.PipelineSource == "" {
+			warns = append(warns, "pipelineSource not specified, skip resolving configs from config manage namespaces")
+		}
+		for _, ns := range req.ConfigManageNamespaces {
+			if req.PipelineSource == "" {
+				break
+			}
+			configs, err := s.cmSvc.GetConfigs(req.PipelineSource, ns, false)
+			if err != nil {
+				warns = append(warns, fmt.Sprintf("failed to get configs of namespace %s, err: %v", ns, err))
+				continue
+			}
+			for _, c := range configs {
+				sources[c.Key] = ns
+			}
+		}
+	}
+
+	// 平台级别配置，优先级更高
+	if req.ClusterName == "" {
+		warns = append(warns, "clusterName not specified, skip resolving platform secrets")
+		return sources, warns
+	}
+	platformSecrets, err := s.FetchPlatformSecrets(&spec.Pipeline{
+		PipelineBase: spec.PipelineBase{
+			PipelineSource: req.PipelineSource,
+			ClusterName:    req.ClusterName,
+		},
+		PipelineExtra: spec.PipelineExtra{
+			NormalLabels: req.NormalLabels,
+		},
+		Labels: req.Labels,
+	}, nil)
+	if err != nil {
+		warns = append(warns, fmt.Sprintf("failed to resolve platform secrets, err: %v", err))
+		return sources, warns
+	}
+	for k := range platformSecrets {
+		sources[k] = apistructs.PipelineDryRunSecretFromPlatform
+	}
+	return sources, warns
+}
+
+// makeDryRunPlan 复用创建流水线时的 pipeline/stage/task 构造逻辑生成执行计划，不写入任何数据
+func (s *PipelineSvc) makeDryRunPlan(req *apistructs.PipelineCreateRequestV2, secretSources map[string]string, depth int) (*apistructs.PipelineDryRunPlan, error) {
+	// 填充参数默认值，并校验必填参数
+	realRunParams, err := getRealRunParams(req.RunParams, req.PipelineYml)
+	if err != nil {
+		return nil, apierrors.ErrDryRunPipeline.InvalidParameter(fmt.Errorf("pipeline: %s, err: %v", req.PipelineYmlName, err))
+	}
+	sort.SliceStable(realRunParams, func(i, j int) bool { return realRunParams[i].Name < realRunParams[j].Name })
+	req.RunParams = realRunParams
+
+	p, _, err := s.buildPipelineFromRequestV2(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 不传入 secrets，保留配置项占位符，避免在执行计划中暴露配置项的值
+	pipelineYml, err := pipelineyml.New([]byte(req.PipelineYml),
+		pipelineyml.WithEnvs(req.Envs),
+		pipelineyml.WithRunParams(p.Snapshot.RunPipelineParams),
+		pipelineyml.WithActionTypeMapping(conf.ActionTypeMapping()),
+	)
+	if err != nil {
+		return nil, apierrors.ErrParsePipelineYml.InvalidParameter(fmt.Errorf("pipeline: %s, err: %v", req.PipelineYmlName, err))
+	}
+
+	// 不查询扩展市场，task 使用默认资源
+	stages, _, err := s.makePipelineStages(p, pipelineYml, map[string]*diceyml.Job{}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &apistructs.PipelineDryRunPlan{
+		PipelineYmlName: p.PipelineYmlName,
+		PipelineSource:  p.PipelineSource,
+		Version:         pipelineYml.Spec().Version,
+		Cron:            p.Extra.CronExpr,
+		Envs:            pipelineYml.Spec().Envs,
+		RunParams:       realRunParams,
+		Warns:           pipelineYml.Warns(),
+	}
+
+	// secrets
+	for _, name := range pipelineyml.FindSecretReferences([]byte(req.PipelineYml)) {
+		from, found := secretSources[name]
+		plan.Secrets = append(plan.Secrets, apistructs.PipelineDryRunSecret{Name: name, Found: found, From: from})
+	}
+
+	for _, stage := range stages {
+		planStage := &apistructs.PipelineDryRunStage{}
+		for _, task := range stage.tasks {
+			planAction, err := s.makeDryRunAction(p, task, secretSources, depth)
+			if err != nil {
+				return nil, err
+			}
+			planStage.Actions = append(planStage.Actions, planAction)
+		}
+		plan.Stages = append(plan.Stages, planStage)
+	}
+
+	return plan, nil
+}
+
+func (s *PipelineSvc) makeDryRunAction(p *spec.Pipeline, task *spec.PipelineTask,
+	secretSources map[string]string, depth int) (*apistructs.PipelineDryRunAction, error) {
+
+	action := task.Extra.Action
+	planAction := &apistructs.PipelineDryRunAction{
+		Alias:    task.Name,
+		Type:     task.Type,
+		Version:  action.Version,
+		Image:    action.Image,
+		Commands: action.Commands,
+		Params:   action.Params,
+		Needs:    append([]string{}, task.Extra.RunAfter...),
+		If:       action.If,
+		Loop:     action.Loop,
+		Retry:    action.Retry,
+		Timeout:  action.Timeout,
+	}
+	if action.If != "" {
+		planAction.ConditionResult, planAction.ConditionMsg = evaluateDryRunCondition(action.If)
+	}
+	if action.MatrixOrigin != nil {
+		planAction.Matrix = &apistructs.PipelineDryRunMatrix{
+			Origin: action.MatrixOrigin.Alias.String(),
+			Values: action.MatrixOrigin.Values,
+		}
+	}
+
+	if !task.IsSnippet {
+		planAction.ExecutorKind, planAction.ExecutorName = string(task.ExecutorKind), task.Extra.ExecutorName
+		return planAction, nil
+	}
+
+	// 展开嵌套流水线
+	if action.SnippetConfig == nil {
+		return nil, apierrors.ErrDryRunPipeline.InvalidParameter(fmt.Errorf("snippet action %s missing snippet_config", task.Name))
+	}
+	if depth >= maxDryRunSnippetDepth {
+		return nil, apierrors.ErrDryRunPipeline.InvalidParameter(
+			fmt.Errorf("snippet action %s exceeds max nested depth %d", task.Name, maxDryRunSnippetDepth))
+	}
+	snippetYml, err := s.queryPipelineYAMLBySnippetConfig(&apistructs.SnippetConfig{
+		Source: action.SnippetConfig.Source,
+		Name:   action.SnippetConfig.Name,
+		Labels: action.SnippetConfig.Labels,
+	})
+	if err != nil {
+		return nil, apierrors.ErrQuerySnippetYaml.InternalError(err)
+	}
+	planAction.Snippet, err = s.makeDryRunPlan(makeSnippetPipelineCreateRequest(p, task, snippetYml), secretSources, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	return planAction, nil
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
+
+// evaluateDryRunCondition 计算 if 条件，依赖运行时上下文的条件无法提前计算
+func evaluateDryRunCondition(condition string) (apistructs.PipelineDryRunConditionResult, string) {
+	inner := expression.ReplacePlaceholder(strings.TrimSpace(condition))
+	if pexpr.LoosePhRe.MatchString(inner) || strings.Contains(inner, "((") || runtimeContextRe.MatchString(inner) {
+		return apistructs.PipelineDryRunConditionResultUnknown, "condition depends on runtime context"
+	}
+	sign := expression.Reconcile(condition)
+	if sign.Err != nil {
+		return apistructs.PipelineDryRunConditionResultError, sign.Err.Error()
+	}
+	if sign.Sign == expression.TaskJumpOver {
+		return apistructs.PipelineDryRunConditionResultFalse, sign.Msg
+	}
+	return apistructs.PipelineDryRunConditionResultTrue, ""
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelinesvc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+func TestPipelineSvc_DryRun(t *testing.T) {
+	yml := `version: "1.1"
+params:
+  - name: branch
+    default: master
+  - name: deploy
+    type: bool
+    default: false
+stages:
+  - stage:
+      - git-checkout:
+          alias: repo
+          params:
+            branch: ${{ params.branch }}
+            password: ((gittar.password))
+  - stage:
+      - custom-script:
+          alias: build
+          commands:
+            - echo ${{ configs.build.token }}
+          if: ${{ 1 == 1 }}
+      - custom-script:
+          alias: check
+          commands:
+            - echo check
+          if: ${{ outputs.repo.commit == 'abc' }}
+  - stage:
+      - custom-script:
+          alias: deploy
+          commands:
+            - echo deploy
+          if: ${{ params.deploy }}
+`
+	s := &PipelineSvc{}
+	plan, err := s.DryRun(&apistructs.PipelineDryRunRequest{
+		PipelineYml: yml,
+		RunParams:   apistructs.PipelineRunParams{{Name: "branch", Value: "feature/dry-run"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.DefaultPipelineYmlName, plan.PipelineYmlName)
+	assert.Equal(t, apistructs.PipelineRunParams{
+		{Name: "branch", Value: "feature/dry-run"},
+		{Name: "deploy", Value: false},
+	}, plan.RunParams)
+	assert.Len(t, plan.Stages, 3)
+
+	// params
+	repo := plan.Stages[0].Actions[0]
+	assert.Equal(t, "repo", repo.Alias)
+	assert.Equal(t, "feature/dry-run", repo.Params["branch"])
+	assert.Equal(t, "((gittar.password))", repo.Params["password"])
+	assert.Empty(t, repo.Needs)
+
+	// conditions and needs
+	build, check := plan.Stages[1].Actions[0], plan.Stages[1].Actions[1]
+	assert.Equal(t, apistructs.PipelineDryRunConditionResultTrue, build.ConditionResult)
+	assert.Equal(t, apistructs.PipelineDryRunConditionResultUnknown, check.ConditionResult)
+	assert.Equal(t, []string{"repo"}, build.Needs)
+	deploy := plan.Stages[2].Actions[0]
+	assert.Equal(t, apistructs.PipelineDryRunConditionResultFalse, deploy.ConditionResult)
+	assert.ElementsMatch(t, []string{"repo", "build", "check"}, deploy.Needs)
+
+	// secrets, names only
+	assert.Equal(t, []apistructs.PipelineDryRunSecret{
+		{Name: "build.token", Found: false},
+		{Name: "gittar.password", Found: false},
+	}, plan.Secrets)
+	assert.NotEmpty(t, plan.Warns)
+}
+
+func TestPipelineSvc_DryRunMissingRequiredParam(t *testing.T) {
+	yml := `version: "1.1"
+params:
+  - name: branch
+    required: true
+stages:
+  - stage:
+      - custom-script:
+          commands:
+            - echo ${{ params.branch }}
+`
+	s := &PipelineSvc{}
+	_, err := s.DryRun(&apistructs.PipelineDryRunRequest{
+		PipelineYml: yml,
+		RunParams:   apistructs.PipelineRunParams{{Name: "branch"}},
+	})
+	assert.Error(t, err)
+}
+
+func Test_evaluateDryRunCondition(t *testing.T) {
+	result, _ := evaluateDryRunCondition("${{ 'a' == 'a' }}")
+	assert.Equal(t, apistructs.PipelineDryRunConditionResultTrue, result)
+	result, _ = evaluateDryRunCondition("${{ 1 > 2 }}")
+	assert.Equal(t, apistructs.PipelineDryRunConditionResultFalse, result)
+	result, _ = evaluateDryRunCondition("${{ dirs.repo }} == ''")
+	assert.Equal(t, apistructs.PipelineDryRunConditionResultUnknown, result)
+	result, _ = evaluateDryRunCondition("${{ 1 >>> }}")
+	assert.Equal(t, apistructs.PipelineDryRunConditionResultError, result)
+}
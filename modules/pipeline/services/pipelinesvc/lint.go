@@ -0,0 +1,52 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelinesvc
+
+import (
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/services/apierrors"
+	"github.com/erda-project/erda/pkg/parser/pipelineyml"
+)
+
+// LintPipelineYml 校验 pipeline.yml，返回带位置信息的诊断结果
+func (s *PipelineSvc) LintPipelineYml(req *apistructs.PipelineYmlLintRequest) (*apistructs.PipelineYmlLintResponseData, error) {
+	if req.PipelineYmlContent == "" {
+		return nil, apierrors.ErrLintPipelineYml.MissingParameter("pipelineYmlContent")
+	}
+	var actionSpecs map[string]*apistructs.ActionSpec
+	if !req.SkipActionParams {
+		specs, err := s.extMarketSvc.SearchActionSpecs(pipelineyml.ListActionItems([]byte(req.PipelineYmlContent)))
+		if err != nil {
+			return nil, apierrors.ErrLintPipelineYml.InternalError(err)
+		}
+		actionSpecs = specs
+	}
+	diagnostics := pipelineyml.Lint([]byte(req.PipelineYmlContent), actionSpecs)
+	if diagnostics == nil {
+		diagnostics = make([]apistructs.PipelineYmlDiagnostic, 0)
+	}
+	return &apistructs.PipelineYmlLintResponseData{
+		Valid:       !pipelineyml.HasLintError(diagnostics),
+		Diagnostics: diagnostics,
+	}, nil
+}
+
+// PipelineYmlSchema 根据扩展市场中的 action 生成 pipeline.yml 的 JSON Schema
+func (s *PipelineSvc) PipelineYmlSchema() (map[string]interface{}, error) {
+	actionSpecs, err := s.extMarketSvc.ListActionSpecs()
+	if err != nil {
+		return nil, apierrors.ErrGetPipelineYmlSchema.InternalError(err)
+	}
+	return pipelineyml.GenerateJSONSchema(actionSpecs), nil
+}
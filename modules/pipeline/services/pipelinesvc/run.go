@@ -22,6 +22,7 @@ import (
 	"github.com/erda-project/erda/modules/pipeline/aop"
 	"github.com/erda-project/erda/modules/pipeline/aop/aoptypes"
 	"github.com/erda-project/erda/modules/pipeline/commonutil/linkutil"
+	"github.com/erda-project/erda/modules/pipeline/events"
 	"github.com/erda-project/erda/modules/pipeline/services/apierrors"
 	"github.com/erda-project/erda/modules/pipeline/spec"
 	"github.com/erda-project/erda/pkg/expression"
@@ -54,13 +55,16 @@ func (s *PipelineSvc) RunPipeline(req *apistructs.PipelineRunRequest) (*spec.Pip
 		}
 	}
 
-	// 校验已运行的 pipeline
-	if err := s.limitParallelRunningPipelines(&p); err != nil {
-		return nil, err
+	// 配置了并发组时由并发组控制并行，否则校验已运行的 pipeline
+	useConcurrencyGroup := p.Extra.ConcurrencyOptions != nil && !p.IsSnippet
+	if !useConcurrencyGroup {
+		if err := s.limitParallelRunningPipelines(&p); err != nil {
+			return nil, err
+		}
 	}
 
 	// cms
-	secrets, cmsDiceFiles, holdOnKeys, err := s.FetchSecrets(&p)
+	secrets, cmsDiceFiles, holdOnKeys, encryptSecretKeys, err := s.FetchSecrets(&p)
 	if err != nil {
 		return nil, apierrors.ErrRunPipeline.InternalError(err)
 	}
@@ -94,6 +98,7 @@ func (s *PipelineSvc) RunPipeline(req *apistructs.PipelineRunRequest) (*spec.Pip
 	// Snapshot 快照用于记录
 	p.Snapshot.PipelineYml = p.PipelineYml
 	p.Snapshot.Secrets = secrets
+	p.Snapshot.EncryptSecretKeys = encryptSecretKeys
 	p.Snapshot.PlatformSecrets = platformSecrets
 	p.Snapshot.CmsDiceFiles = cmsDiceFiles
 	// pipeline 运行时的参数
@@ -116,8 +121,22 @@ func (s *PipelineSvc) RunPipeline(req *apistructs.PipelineRunRequest) (*spec.Pip
 		return nil, apierrors.ErrRunPipeline.InternalError(err)
 	}
 
-	// aop
-	_ = aop.Handle(aop.NewContextForPipeline(p, aoptypes.TuneTriggerPipelineBeforeExec))
+	// aop，中断时将流水线置为失败，不再发送给引擎
+	if err := aop.Handle(aop.NewContextForPipeline(p, aoptypes.TuneTriggerPipelineBeforeExec)); aoptypes.IsAbortError(err) {
+		p.Status = apistructs.PipelineStatusFailed
+		if updateErr := s.dbClient.UpdatePipelineBaseStatus(p.ID, p.Status); updateErr != nil {
+			return nil, apierrors.ErrUpdatePipeline.InternalError(updateErr)
+		}
+		events.EmitPipelineInstanceEvent(&p, p.GetRunUserID())
+		return nil, apierrors.ErrRunPipeline.InvalidState(fmt.Sprintf("aborted by aop: %v", err))
+	}
+
+	// 所有可能失败的步骤完成后再取消并发组内运行中的 pipeline，避免本次运行失败时误取消
+	if useConcurrencyGroup {
+		if err := s.cancelConcurrencyGroupInProgress(&p, req.IdentityInfo); err != nil {
+			return nil, err
+		}
+	}
 
 	// send to pipengine reconciler
 	s.engine.Send(p.ID)
@@ -177,14 +196,7 @@ func getRealRunParams(runParams []apistructs.PipelineRunParam, yml string) (resu
 }
 
 func (s *PipelineSvc) stopRunningPipelines(p *spec.Pipeline, identityInfo apistructs.IdentityInfo) error {
-	var runningPipelineIDs []uint64
-	err := s.dbClient.Table(&spec.PipelineBase{}).
-		Select("id").In("status", apistructs.ReconcilerRunningStatuses()).
-		Where("is_snippet = ?", false).
-		Find(&runningPipelineIDs, &spec.PipelineBase{
-			PipelineSource:  p.PipelineSource,
-			PipelineYmlName: p.PipelineYmlName,
-		})
+	runningPipelineIDs, err := s.listRunningPipelineIDs(p.PipelineSource, p.PipelineYmlName)
 	if err != nil {
 		return apierrors.ErrParallelRunPipeline.InternalError(err)
 	}
@@ -206,14 +218,7 @@ func (s *PipelineSvc) limitParallelRunningPipelines(p *spec.Pipeline) error {
 	if p.IsSnippet {
 		return nil
 	}
-	var runningPipelineIDs []uint64
-	err := s.dbClient.Table(&spec.PipelineBase{}).
-		Select("id").In("status", apistructs.ReconcilerRunningStatuses()).
-		Where("is_snippet = ?", false).
-		Find(&runningPipelineIDs, &spec.PipelineBase{
-			PipelineSource:  p.PipelineSource,
-			PipelineYmlName: p.PipelineYmlName,
-		})
+	runningPipelineIDs, err := s.listRunningPipelineIDs(p.PipelineSource, p.PipelineYmlName)
 	if err != nil {
 		return apierrors.ErrParallelRunPipeline.InternalError(err)
 	}
@@ -236,6 +241,19 @@ func (s *PipelineSvc) limitParallelRunningPipelines(p *spec.Pipeline) error {
 	return nil
 }
 
+// listRunningPipelineIDs 查询 pipelineSource + pipelineYmlName 下运行中的非嵌套流水线
+func (s *PipelineSvc) listRunningPipelineIDs(source apistructs.PipelineSource, ymlName string) ([]uint64, error) {
+	var runningPipelineIDs []uint64
+	err := s.dbClient.Table(&spec.PipelineBase{}).
+		Select("id").In("status", apistructs.ReconcilerRunningStatuses()).
+		Where("is_snippet = ?", false).
+		Find(&runningPipelineIDs, &spec.PipelineBase{
+			PipelineSource:  source,
+			PipelineYmlName: ymlName,
+		})
+	return runningPipelineIDs, err
+}
+
 func makeCmsDiceFileEnvKey(diceFileUUID string) string {
 	return fmt.Sprintf("PIPELINE_CMS_DICE_FILE_UUID_%s", diceFileUUID)
 }
@@ -175,7 +175,7 @@ func getCenterOrSaaSURL(diceCluster, requestCluster, center, sass string) string
 
 // FetchSecrets return secrets, cmsDiceFiles and error.
 // holdOnKeys: 声明 key 需要持有，不能被平台 secrets 覆盖，与 ignoreKeys 配合使用
-func (s *PipelineSvc) FetchSecrets(p *spec.Pipeline) (secrets, cmsDiceFiles map[string]string, holdOnKeys []string, err error) {
+func (s *PipelineSvc) FetchSecrets(p *spec.Pipeline) (secrets, cmsDiceFiles map[string]string, holdOnKeys, encryptKeys []string, err error) {
 	secrets = make(map[string]string)
 	cmsDiceFiles = make(map[string]string)
 
@@ -188,11 +188,11 @@ func (s *PipelineSvc) FetchSecrets(p *spec.Pipeline) (secrets, cmsDiceFiles map[
 		if orgIDStr := p.Labels[apistructs.LabelOrgID]; orgIDStr != "" {
 			orgID, err := strconv.ParseUint(orgIDStr, 10, 64)
 			if err != nil {
-				return nil, nil, nil, errors.Errorf("invalid org id from label %q, err: %v", apistructs.LabelOrgID, err)
+				return nil, nil, nil, nil, errors.Errorf("invalid org id from label %q, err: %v", apistructs.LabelOrgID, err)
 			}
 			org, err := s.bdl.GetOrg(orgID)
 			if err != nil {
-				return nil, nil, nil, err
+				return nil, nil, nil, nil, err
 			}
 			if org.EnableReleaseCrossCluster {
 				namespaces = append(namespaces, nexus.MakeOrgPipelineCmsNs(orgID))
@@ -205,10 +205,13 @@ func (s *PipelineSvc) FetchSecrets(p *spec.Pipeline) (secrets, cmsDiceFiles map[
 	for _, ns := range namespaces {
 		configs, err := s.cmSvc.GetConfigs(p.PipelineSource, ns, true)
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 		for _, c := range configs {
 			secrets[c.Key] = c.Value
+			if c.EncryptInDB {
+				encryptKeys = append(encryptKeys, c.Key)
+			}
 
 			// DiceFile 类型，value 为 diceFileUUID
 			if c.Type == apistructs.PipelineCmsConfigTypeDiceFile {
@@ -227,7 +230,8 @@ func (s *PipelineSvc) FetchSecrets(p *spec.Pipeline) (secrets, cmsDiceFiles map[
 			secretKeyDockerArtifactRegistryUsername,
 			secretKeyDockerArtifactRegistryPassword,
 		)
+		encryptKeys = append(encryptKeys, secretKeyDockerArtifactRegistryPassword)
 	}
 
-	return secrets, cmsDiceFiles, holdOnKeys, nil
+	return secrets, cmsDiceFiles, holdOnKeys, strutil.DedupSlice(encryptKeys), nil
 }
@@ -126,6 +126,23 @@ func (s *PipelineSvc) batchQueryPipelineYAMLBySnippetConfigs(snippetConfigs []ap
 
 // createSnippetPipeline4Create 为 snippetTask 创建流水线对象
 func (s *PipelineSvc) makeSnippetPipeline4Create(p *spec.Pipeline, snippetTask *spec.PipelineTask, yamlContent string) (*spec.Pipeline, error) {
+	snippetPipelineCreateReq := makeSnippetPipelineCreateRequest(p, snippetTask, yamlContent)
+	if err := s.validateCreateRequest(snippetPipelineCreateReq); err != nil {
+		return nil, apierrors.ErrCreateSnippetPipeline.InternalError(err)
+	}
+	snippetP, err := s.makePipelineFromRequestV2(snippetPipelineCreateReq)
+	if err != nil {
+		return nil, err
+	}
+	snippetP.IsSnippet = true
+	snippetP.ParentPipelineID = &p.ID
+	snippetP.ParentTaskID = &snippetTask.ID
+	snippetP.Extra.SnippetChain = append(p.Extra.SnippetChain, p.ID)
+	return snippetP, nil
+}
+
+// makeSnippetPipelineCreateRequest 将 snippetTask 转换为流水线创建请求，创建流水线与 dry-run 共用
+func makeSnippetPipelineCreateRequest(p *spec.Pipeline, snippetTask *spec.PipelineTask, yamlContent string) *apistructs.PipelineCreateRequestV2 {
 	snippetConfig := snippetTask.Extra.Action.SnippetConfig
 	// runParams
 	var runParams []apistructs.PipelineRunParam
@@ -146,16 +163,5 @@ func (s *PipelineSvc) makeSnippetPipeline4Create(p *spec.Pipeline, snippetTask *
 		RunParams:              runParams,
 		IdentityInfo:           p.GenIdentityInfo(),
 	}
-	if err := s.validateCreateRequest(&snippetPipelineCreateReq); err != nil {
-		return nil, apierrors.ErrCreateSnippetPipeline.InternalError(err)
-	}
-	snippetP, err := s.makePipelineFromRequestV2(&snippetPipelineCreateReq)
-	if err != nil {
-		return nil, err
-	}
-	snippetP.IsSnippet = true
-	snippetP.ParentPipelineID = &p.ID
-	snippetP.ParentTaskID = &snippetTask.ID
-	snippetP.Extra.SnippetChain = append(p.Extra.SnippetChain, p.ID)
-	return snippetP, nil
+	return &snippetPipelineCreateReq
 }
@@ -0,0 +1,96 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelinesvc
+
+import (
+	"time"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/commonutil/costtimeutil"
+	"github.com/erda-project/erda/modules/pipeline/commonutil/statusutil"
+	"github.com/erda-project/erda/modules/pipeline/services/apierrors"
+	"github.com/erda-project/erda/modules/pipeline/spec"
+)
+
+// StatusStreamSnapshot 返回流水线当前的 pipeline/stage/task 状态，用于状态流首次订阅或无法续传时下发全量状态。
+// 快照事件使用订阅时的 cursor 作为事件 ID，客户端断线后可从该位置续传。
+func (s *PipelineSvc) StatusStreamSnapshot(pipelineID uint64, cursor string) ([]apistructs.PipelineStatusStreamEvent, error) {
+	p, err := s.dbClient.GetPipeline(pipelineID)
+	if err != nil {
+		return nil, apierrors.ErrGetPipeline.InvalidParameter(err)
+	}
+	stages, err := s.dbClient.ListPipelineStageByPipelineID(pipelineID)
+	if err != nil {
+		return nil, apierrors.ErrStreamPipelineStatus.InternalError(err)
+	}
+	tasks, err := s.dbClient.ListPipelineTasksByPipelineID(pipelineID)
+	if err != nil {
+		return nil, apierrors.ErrStreamPipelineStatus.InternalError(err)
+	}
+	return makeStatusStreamSnapshot(&p, stages, tasks, cursor), nil
+}
+
+func makeStatusStreamSnapshot(p *spec.Pipeline, stages []spec.PipelineStage, tasks []spec.PipelineTask, cursor string) []apistructs.PipelineStatusStreamEvent {
+	now := time.Now()
+	result := []apistructs.PipelineStatusStreamEvent{{
+		ID:          cursor,
+		Type:        apistructs.PipelineStatusStreamEventTypePipeline,
+		PipelineID:  p.ID,
+		Status:      p.Status,
+		CostTimeSec: costtimeutil.CalculatePipelineCostTimeSec(p),
+		Timestamp:   now,
+	}}
+
+	stageTasks := make(map[uint64][]*spec.PipelineTask, len(stages))
+	for i := range tasks {
+		stageTasks[tasks[i].StageID] = append(stageTasks[tasks[i].StageID], &tasks[i])
+	}
+	for _, stage := range stages {
+		status, err := statusutil.CalculatePipelineStageStatus(&spec.PipelineStageWithTask{PipelineStage: stage, PipelineTasks: stageTasks[stage.ID]})
+		if err != nil {
+			status = stage.Status
+		}
+		result = append(result, apistructs.PipelineStatusStreamEvent{
+			ID:         cursor,
+			Type:       apistructs.PipelineStatusStreamEventTypeStage,
+			PipelineID: p.ID,
+			StageID:    stage.ID,
+			Status:     status,
+			Timestamp:  now,
+		})
+		for _, task := range stageTasks[stage.ID] {
+			status := task.Status
+			if task.Type == "manual-review" {
+				status = task.Status.ChangeStateForManualReview()
+			}
+			var loopedTimes uint64
+			if task.Extra.LoopOptions != nil {
+				loopedTimes = task.Extra.LoopOptions.LoopedTimes
+			}
+			result = append(result, apistructs.PipelineStatusStreamEvent{
+				ID:          cursor,
+				Type:        apistructs.PipelineStatusStreamEventTypeTask,
+				PipelineID:  p.ID,
+				StageID:     stage.ID,
+				TaskID:      task.ID,
+				TaskName:    task.Name,
+				Status:      status,
+				LoopedTimes: loopedTimes,
+				CostTimeSec: costtimeutil.CalculateTaskCostTimeSec(task),
+				Timestamp:   now,
+			})
+		}
+	}
+	return result
+}
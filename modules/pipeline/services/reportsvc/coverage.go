@@ -0,0 +1,180 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package reportsvc
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/modules/pipeline/services/apierrors"
+)
+
+// GetCoverageTrend 查询应用分支最近若干次流水线的覆盖率，按流水线 ID 倒序
+func (svc *ReportSvc) GetCoverageTrend(req apistructs.PipelineCoverageTrendRequest) ([]apistructs.PipelineCoverageSummary, error) {
+	if err := req.Validate(); err != nil {
+		return nil, apierrors.ErrQueryCoverageTrend.InvalidParameter(err)
+	}
+	summaries, _, err := svc.listBranchCoverages(req.AppID, req.Branch, req.Sources, req.Limit)
+	if err != nil {
+		return nil, apierrors.ErrQueryCoverageTrend.InternalError(err)
+	}
+	return summaries, nil
+}
+
+// GetCoverageDelta 查询源分支最新覆盖率相对目标分支最新覆盖率的变化
+func (svc *ReportSvc) GetCoverageDelta(req apistructs.PipelineCoverageDeltaRequest) (*apistructs.PipelineCoverageDelta, error) {
+	if err := req.Validate(); err != nil {
+		return nil, apierrors.ErrQueryCoverageDelta.InvalidParameter(err)
+	}
+	sourceSummaries, sourceReports, err := svc.listBranchCoverages(req.AppID, req.SourceBranch, req.Sources, 1)
+	if err != nil {
+		return nil, apierrors.ErrQueryCoverageDelta.InternalError(err)
+	}
+	targetSummaries, targetReports, err := svc.listBranchCoverages(req.AppID, req.TargetBranch, req.Sources, 1)
+	if err != nil {
+		return nil, apierrors.ErrQueryCoverageDelta.InternalError(err)
+	}
+	if len(sourceSummaries) == 0 {
+		return nil, apierrors.ErrQueryCoverageDelta.NotFound()
+	}
+	var target *apistructs.PipelineCoverageSummary
+	var targetReport *apistructs.CoverageReport
+	if len(targetSummaries) > 0 {
+		target, targetReport = &targetSummaries[0], targetReports[0]
+	}
+	return calculateCoverageDelta(&sourceSummaries[0], target, sourceReports[0], targetReport), nil
+}
+
+// listBranchCoverages 返回分支最近 limit 次流水线的覆盖率汇总及对应的完整报告
+func (svc *ReportSvc) listBranchCoverages(appID uint64, branch string, sources []apistructs.PipelineSource, limit int) (
+	[]apistructs.PipelineCoverageSummary, []*apistructs.CoverageReport, error) {
+	sets, _, err := svc.dbClient.PagingPipelineReportSets(apistructs.PipelineReportSetPagingRequest{
+		Sources: sources,
+		Types:   []apistructs.PipelineReportType{apistructs.PipelineReportTypeCoverage},
+		MustMatchLabelsQueryParams: []string{
+			fmt.Sprintf("%s=%d", apistructs.LabelAppID, appID),
+			fmt.Sprintf("%s=%s", apistructs.LabelBranch, branch),
+		},
+		PageNum:  1,
+		PageSize: limit,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(sets) == 0 {
+		return nil, nil, nil
+	}
+
+	var pipelineIDs []uint64
+	for _, set := range sets {
+		pipelineIDs = append(pipelineIDs, set.PipelineID)
+	}
+	pipelines, err := svc.dbClient.ListPipelinesByIDs(pipelineIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	commits := make(map[uint64]string, len(pipelines))
+	for _, p := range pipelines {
+		commits[p.ID] = p.GetCommitID()
+	}
+
+	summaries := make([]apistructs.PipelineCoverageSummary, 0, len(sets))
+	reports := make([]*apistructs.CoverageReport, 0, len(sets))
+	for _, set := range sets {
+		// 一条流水线内多个任务的覆盖率合并计算
+		merged := &apistructs.CoverageReport{}
+		for _, report := range set.Reports {
+			r, err := decodeCoverageReport(report.Meta)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid coverage report, reportID: %d, err: %v", report.ID, err)
+			}
+			merged.Merge(r)
+		}
+		summary := apistructs.PipelineCoverageSummary{
+			PipelineID: set.PipelineID,
+			Branch:     branch,
+			Commit:     commits[set.PipelineID],
+			Lines:      merged.Lines,
+			Branches:   merged.Branches,
+			LineRate:   merged.Lines.Rate(),
+			BranchRate: merged.Branches.Rate(),
+		}
+		if len(set.Reports) > 0 {
+			createdAt := set.Reports[0].CreatedAt
+			summary.TimeCreated = &createdAt
+		}
+		summaries = append(summaries, summary)
+		reports = append(reports, merged)
+	}
+	return summaries, reports, nil
+}
+
+func decodeCoverageReport(meta apistructs.PipelineReportMeta) (*apistructs.CoverageReport, error) {
+	v, ok := meta[apistructs.CoverageMetaKey]
+	if !ok {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var report apistructs.CoverageReport
+	if err := json.Unmarshal(b, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func calculateCoverageDelta(source, target *apistructs.PipelineCoverageSummary, sourceReport, targetReport *apistructs.CoverageReport) *apistructs.PipelineCoverageDelta {
+	delta := &apistructs.PipelineCoverageDelta{Source: source, Target: target}
+	if target == nil {
+		target = &apistructs.PipelineCoverageSummary{}
+	}
+	delta.LineRateDelta = roundRate(source.LineRate - target.LineRate)
+	delta.BranchRateDelta = roundRate(source.BranchRate - target.BranchRate)
+
+	pkgs := make(map[string]*apistructs.PipelineCoveragePackageDelta)
+	get := func(name string) *apistructs.PipelineCoveragePackageDelta {
+		if _, ok := pkgs[name]; !ok {
+			pkgs[name] = &apistructs.PipelineCoveragePackageDelta{Name: name}
+		}
+		return pkgs[name]
+	}
+	if sourceReport != nil {
+		for _, pkg := range sourceReport.Packages {
+			d := get(pkg.Name)
+			d.SourceLineRate, d.SourceBranchRate = pkg.Lines.Rate(), pkg.Branches.Rate()
+		}
+	}
+	if targetReport != nil {
+		for _, pkg := range targetReport.Packages {
+			d := get(pkg.Name)
+			d.TargetLineRate, d.TargetBranchRate = pkg.Lines.Rate(), pkg.Branches.Rate()
+		}
+	}
+	for _, d := range pkgs {
+		d.LineRateDelta = roundRate(d.SourceLineRate - d.TargetLineRate)
+		d.BranchRateDelta = roundRate(d.SourceBranchRate - d.TargetBranchRate)
+		delta.Packages = append(delta.Packages, *d)
+	}
+	sort.Slice(delta.Packages, func(i, j int) bool { return delta.Packages[i].Name < delta.Packages[j].Name })
+	return delta
+}
+
+func roundRate(rate float64) float64 {
+	return math.Round(rate*100) / 100
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package reportsvc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+func TestCalculateCoverageDelta(t *testing.T) {
+	sourceReport := &apistructs.CoverageReport{Packages: []apistructs.CoveragePackage{
+		{Name: "a", Lines: apistructs.CoverageCounter{Covered: 3, Total: 4}},
+		{Name: "c", Lines: apistructs.CoverageCounter{Covered: 1, Total: 1}},
+	}}
+	sourceReport.Aggregate()
+	targetReport := &apistructs.CoverageReport{Packages: []apistructs.CoveragePackage{
+		{Name: "a", Lines: apistructs.CoverageCounter{Covered: 1, Total: 4}},
+		{Name: "b", Lines: apistructs.CoverageCounter{Covered: 1, Total: 2}},
+	}}
+	targetReport.Aggregate()
+	source := &apistructs.PipelineCoverageSummary{LineRate: sourceReport.Lines.Rate()}
+	target := &apistructs.PipelineCoverageSummary{LineRate: targetReport.Lines.Rate()}
+
+	delta := calculateCoverageDelta(source, target, sourceReport, targetReport)
+	assert.Equal(t, float64(46.67), delta.LineRateDelta)
+	assert.Len(t, delta.Packages, 3)
+	assert.Equal(t, float64(50), delta.Packages[0].LineRateDelta)
+	assert.Equal(t, float64(-50), delta.Packages[1].LineRateDelta)
+	assert.Equal(t, float64(100), delta.Packages[2].LineRateDelta)
+
+	// 目标分支无覆盖率时，变化即为源分支覆盖率
+	delta = calculateCoverageDelta(source, nil, sourceReport, nil)
+	assert.Nil(t, delta.Target)
+	assert.Equal(t, float64(80), delta.LineRateDelta)
+}
+
+func TestDecodeCoverageReport(t *testing.T) {
+	r, err := decodeCoverageReport(apistructs.PipelineReportMeta{})
+	assert.NoError(t, err)
+	assert.Nil(t, r)
+
+	r, err = decodeCoverageReport(apistructs.PipelineReportMeta{
+		"coverage": map[string]interface{}{"format": "lcov", "lines": map[string]interface{}{"covered": 1, "total": 2}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.CoverageFormatLCOV, r.Format)
+	assert.Equal(t, float64(50), r.Lines.Rate())
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package spec
+
+import (
+	"time"
+)
+
+// PipelineActionCache action 缓存文件，用于按项目统计缓存大小并按最近使用时间淘汰
+type PipelineActionCache struct {
+	ID uint64 `json:"id" xorm:"pk autoincr"`
+
+	ProjectID   uint64 `json:"projectID"`
+	AppID       uint64 `json:"appID"`
+	ClusterName string `json:"clusterName"`
+
+	StorageType string `json:"storageType"`
+	// Location 缓存文件路径或 object key，同一集群下唯一
+	Location  string `json:"location"`
+	CacheKey  string `json:"cacheKey"`
+	CachePath string `json:"cachePath"`
+	SizeBytes int64  `json:"sizeBytes"`
+	HitCount  uint64 `json:"hitCount"`
+
+	LastAccessedAt time.Time  `json:"lastAccessedAt"`
+	CreatedAt      *time.Time `json:"createdAt,omitempty" xorm:"created"`
+	UpdatedAt      *time.Time `json:"updatedAt,omitempty" xorm:"updated"`
+}
+
+func (PipelineActionCache) TableName() string {
+	return "dice_pipeline_action_caches"
+}
+
+// PipelineActionCacheStat 项目维度的 action 缓存命中统计
+type PipelineActionCacheStat struct {
+	ID uint64 `json:"id" xorm:"pk autoincr"`
+
+	ProjectID   uint64 `json:"projectID"`
+	Hits        uint64 `json:"hits"`
+	PartialHits uint64 `json:"partialHits"`
+	Misses      uint64 `json:"misses"`
+
+	CreatedAt *time.Time `json:"createdAt,omitempty" xorm:"created"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty" xorm:"updated"`
+}
+
+func (PipelineActionCacheStat) TableName() string {
+	return "dice_pipeline_action_cache_stats"
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package spec
+
+import (
+	"time"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+// PipelineAOPHTTPPlugin 通过 API 注册的 AOP 外部插件，持久化后所有实例共享
+type PipelineAOPHTTPPlugin struct {
+	ID          uint64    `json:"id" xorm:"pk autoincr"`
+	TimeCreated time.Time `json:"timeCreated" xorm:"created"`
+	TimeUpdated time.Time `json:"timeUpdated" xorm:"updated"`
+
+	Name          string                                        `json:"name"`
+	Type          string                                        `json:"type"`
+	Trigger       string                                        `json:"trigger"`
+	URL           string                                        `json:"url" xorm:"url"`
+	TimeoutSec    int64                                         `json:"timeoutSec"`
+	FailurePolicy apistructs.PipelineAOPHTTPPluginFailurePolicy `json:"failurePolicy"`
+	Headers       map[string]string                             `json:"headers" xorm:"json"`
+}
+
+func (*PipelineAOPHTTPPlugin) TableName() string {
+	return "pipeline_aop_http_plugins"
+}
+
+func (p *PipelineAOPHTTPPlugin) Convert2Config() apistructs.PipelineAOPHTTPPlugin {
+	return apistructs.PipelineAOPHTTPPlugin{
+		Name:          p.Name,
+		Type:          p.Type,
+		Trigger:       p.Trigger,
+		URL:           p.URL,
+		TimeoutSec:    p.TimeoutSec,
+		FailurePolicy: p.FailurePolicy,
+		Headers:       p.Headers,
+	}
+}
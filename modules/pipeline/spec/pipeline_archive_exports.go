@@ -0,0 +1,45 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package spec
+
+import (
+	"time"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+// PipelineArchiveExport 已导出到冷存储的归档流水线索引，用于根据 pipelineID 定位所在分段
+type PipelineArchiveExport struct {
+	ID          uint64    `json:"id" xorm:"pk autoincr"`
+	TimeCreated time.Time `json:"timeCreated" xorm:"created"`
+	TimeUpdated time.Time `json:"timeUpdated" xorm:"updated"`
+
+	PipelineID      uint64                    `json:"pipelineID"`
+	PipelineSource  apistructs.PipelineSource `json:"pipelineSource"`
+	PipelineYmlName string                    `json:"pipelineYmlName"`
+	Status          apistructs.PipelineStatus `json:"status"`
+
+	// StorageType 导出时使用的存储类型，fs/oss/s3
+	StorageType string `json:"storageType"`
+	// Segment 分段文件路径或对象 key
+	Segment string `json:"segment"`
+	// SegmentLine 在分段中的行号，从 0 开始
+	SegmentLine int `json:"segmentLine"`
+	// ArchivedAt 归档到 pipeline_archives 的时间
+	ArchivedAt time.Time `json:"archivedAt"`
+}
+
+func (*PipelineArchiveExport) TableName() string {
+	return "pipeline_archive_exports"
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package spec
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+// PipelineCmsConfigRevision 配置项变更记录，只插入不修改
+type PipelineCmsConfigRevision struct {
+	ID uint64 `json:"id" xorm:"pk autoincr"`
+
+	NsID uint64 `json:"nsID"`
+
+	// Revision 同一个 ns 下递增，一次变更操作中的所有配置项共用一个 revision
+	Revision uint64 `json:"revision"`
+
+	Key string `json:"key" xorm:"'config_key'"`
+
+	Operation apistructs.PipelineCmsConfigRevisionOperation `json:"operation"`
+
+	Detail PipelineCmsConfigRevisionDetail `json:"detail" xorm:"json"`
+
+	Operator string `json:"operator"`
+
+	CreatedAt *time.Time `json:"createdAt,omitempty" xorm:"created"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty" xorm:"updated"`
+}
+
+func (PipelineCmsConfigRevision) TableName() string {
+	return "dice_pipeline_cms_config_revisions"
+}
+
+// PipelineCmsConfigRevisionDetail 变更前后的配置项快照，为 nil 表示配置项不存在
+type PipelineCmsConfigRevisionDetail struct {
+	Old *PipelineCmsConfigSnapshot `json:"old,omitempty"`
+	New *PipelineCmsConfigSnapshot `json:"new,omitempty"`
+}
+
+// PipelineCmsConfigSnapshot 配置项快照
+type PipelineCmsConfigSnapshot struct {
+	// Value 与 dice_pipeline_cms_configs 中存储的值一致，加密存储的配置项为密文
+	Value   string                           `json:"value"`
+	Encrypt bool                             `json:"encrypt"`
+	Type    apistructs.PipelineCmsConfigType `json:"type"`
+	Extra   PipelineCmsConfigExtra           `json:"extra"`
+	// Sealed 表示 Value 在变更记录中被加密保存，未加密存储的配置项不在变更记录中保存明文
+	Sealed bool `json:"sealed,omitempty"`
+}
+
+// Snapshot 生成配置项快照
+func (c PipelineCmsConfig) Snapshot() *PipelineCmsConfigSnapshot {
+	return &PipelineCmsConfigSnapshot{
+		Value:   c.Value,
+		Encrypt: c.Encrypt != nil && *c.Encrypt,
+		Type:    c.Type,
+		Extra:   c.Extra,
+	}
+}
+
+// ToConfig 根据快照还原配置项
+func (s PipelineCmsConfigSnapshot) ToConfig(nsID uint64, key string) PipelineCmsConfig {
+	return PipelineCmsConfig{
+		NsID:    nsID,
+		Key:     key,
+		Value:   s.Value,
+		Encrypt: &[]bool{s.Encrypt}[0],
+		Type:    s.Type,
+		Extra:   s.Extra,
+	}
+}
+
+// EqualExceptValue 比较除 value 外的其他属性，加密值每次加密结果不同，需要解密后单独比较
+func (s PipelineCmsConfigSnapshot) EqualExceptValue(another PipelineCmsConfigSnapshot) bool {
+	return s.Encrypt == another.Encrypt &&
+		s.Type == another.Type &&
+		reflect.DeepEqual(s.Extra, another.Extra)
+}
@@ -64,6 +64,12 @@ type PipelineCronExtra struct {
 	Compensator *apistructs.CronCompensator `json:"compensator,omitempty"`
 	//每次中断补偿执行的时间，下次中断补偿从这个时间开始查询
 	LastCompensateAt *time.Time `json:"lastCompensateAt,omitempty"`
+
+	// concurrency
+	// ConcurrencyPolicy 为空时等同于 Allow
+	ConcurrencyPolicy apistructs.PipelineCronConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+	// LastSkippedAt 最近一次因并发策略被跳过的触发时间，中断补偿不会再补偿该时间点及之前的触发
+	LastSkippedAt *time.Time `json:"lastSkippedAt,omitempty"`
 }
 
 func (PipelineCron) TableName() string {
@@ -85,6 +91,8 @@ func (pc *PipelineCron) Convert2DTO() *apistructs.PipelineCronDTO {
 		PipelineYmlName: pc.PipelineYmlName,
 		BasePipelineID:  pc.BasePipelineID,
 		Enable:          pc.Enable,
+
+		ConcurrencyPolicy: pc.Extra.ConcurrencyPolicy,
 	}
 }
 
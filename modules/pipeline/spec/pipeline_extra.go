@@ -112,6 +112,12 @@ type PipelineExtraInfo struct {
 	SnippetChain []uint64 `json:"snippetChain,omitempty"`
 
 	QueueInfo *QueueInfo `json:"queueInfo,omitempty"`
+
+	// TimeoutOptions 流水线级别超时配置，未配置任何超时时为空
+	TimeoutOptions *apistructs.PipelineTimeoutOptions `json:"timeoutOptions,omitempty"`
+
+	// ConcurrencyOptions 并发组配置，未配置 concurrency 时为空
+	ConcurrencyOptions *apistructs.PipelineConcurrencyOptions `json:"concurrencyOptions,omitempty"`
 }
 
 type QueueInfo struct {
@@ -126,6 +132,8 @@ type Snapshot struct {
 	CmsDiceFiles    map[string]string `json:"cmsDiceFiles,omitempty"`
 	Envs            map[string]string `json:"envs,omitempty"`
 
+	EncryptSecretKeys []string `json:"encryptSecretKeys,omitempty"` // 加密存储的配置项，执行时在日志中脱敏
+
 	AnalyzedCrossCluster *bool `json:"analyzedCrossCluster,omitempty"`
 
 	RunPipelineParams apistructs.PipelineRunParamsWithValue `json:"runPipelineParams,omitempty"` // 流水线运行时参数
@@ -36,7 +36,8 @@ type PipelineStage struct {
 
 type PipelineStageExtra struct {
 	PreStage   *PreStageSimple `json:"preStage,omitempty"`
-	StageOrder int             `json:"stageOrder"` // 0,1,2,...
+	StageOrder int             `json:"stageOrder"`           // 0,1,2,...
+	TimeoutSec int64           `json:"timeoutSec,omitempty"` // 超时时间，从 stage 内第一个任务开始执行时计算
 }
 
 type PreStageSimple struct {
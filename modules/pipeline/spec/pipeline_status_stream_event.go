@@ -0,0 +1,35 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package spec
+
+import (
+	"time"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+// PipelineStatusStreamEvent 状态流事件，所有实例写入同一张表并从中拉取，自增 ID 即为事件 ID
+type PipelineStatusStreamEvent struct {
+	ID          uint64    `xorm:"pk autoincr"`
+	TimeCreated time.Time `xorm:"created"`
+	TimeUpdated time.Time `xorm:"updated"`
+
+	PipelineID uint64
+	Labels     map[string]string                    `xorm:"json"`
+	Event      apistructs.PipelineStatusStreamEvent `xorm:"json"`
+}
+
+func (*PipelineStatusStreamEvent) TableName() string {
+	return "pipeline_status_stream_events"
+}
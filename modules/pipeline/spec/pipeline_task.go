@@ -86,6 +86,8 @@ type PipelineTaskExtra struct {
 	// RunAfter indicates the tasks this task depends.
 	RunAfter []string `json:"runAfter"`
 
+	SecretEnvKeys []string `json:"secretEnvKeys,omitempty"` // 值为 secret 的 PrivateEnvs，由 agent 在日志中脱敏
+
 	FlinkSparkConf FlinkSparkConf `json:"flinkSparkConf,omitempty"`
 
 	Action pipelineyml.Action `json:"action,omitempty"`
@@ -94,6 +96,8 @@ type PipelineTaskExtra struct {
 
 	LoopOptions *apistructs.PipelineTaskLoopOptions `json:"loopOptions,omitempty"` // 开始执行后保证不为空
 
+	RetryOptions *apistructs.PipelineTaskRetryOptions `json:"retryOptions,omitempty"` // 声明了 retry 时开始执行后不为空
+
 	AppliedResources apistructs.PipelineAppliedResources `json:"appliedResources,omitempty"`
 }
 
@@ -135,12 +139,14 @@ var (
 	PipelineTaskExecutorKindScheduler PipelineTaskExecutorKind = "SCHEDULER"
 	PipelineTaskExecutorKindMemory    PipelineTaskExecutorKind = "MEMORY"
 	PipelineTaskExecutorKindAPITest   PipelineTaskExecutorKind = "APITEST"
+	PipelineTaskExecutorKindLocal     PipelineTaskExecutorKind = "LOCAL"
 )
 
 var (
 	PipelineTaskExecutorNameEmpty            = ""
 	PipelineTaskExecutorNameSchedulerDefault = "scheduler"
 	PipelineTaskExecutorNameAPITestDefault   = "api-test"
+	PipelineTaskExecutorNameLocalDefault     = "local"
 )
 
 type RuntimeResource struct {
@@ -184,6 +190,10 @@ func (pt *PipelineTask) Convert2DTO() *apistructs.PipelineTaskDTO {
 		SnippetPipelineID:     pt.SnippetPipelineID,
 		SnippetPipelineDetail: pt.SnippetPipelineDetail,
 	}
+	// retry attempts
+	if pt.Extra.RetryOptions != nil {
+		task.Extra.RetryAttempts = pt.Extra.RetryOptions.Attempts
+	}
 	// handle metadata
 	for _, field := range task.Result.Metadata {
 		field.Level = field.GetLevel()
@@ -33,12 +33,17 @@ func GetValidBranchByGitReference(ref string, branchRules []*apistructs.BranchRu
 		for _, branchFilter := range branchFilters {
 			if IsRefPatternMatch(ref, []string{branchFilter}) {
 				return &apistructs.ValidBranch{
-					Name:              ref,
-					IsProtect:         branchRule.IsProtect,
-					NeedApproval:      branchRule.NeedApproval,
-					IsTriggerPipeline: branchRule.IsTriggerPipeline,
-					Workspace:         branchRule.Workspace,
-					ArtifactWorkspace: branchRule.ArtifactWorkspace,
+					Name:                     ref,
+					IsProtect:                branchRule.IsProtect,
+					NeedApproval:             branchRule.NeedApproval,
+					IsTriggerPipeline:        branchRule.IsTriggerPipeline,
+					Workspace:                branchRule.Workspace,
+					ArtifactWorkspace:        branchRule.ArtifactWorkspace,
+					AllowedMergeMethods:      branchRule.AllowedMergeMethods,
+					RequiredCheckRuns:        branchRule.RequiredCheckRuns,
+					RequiredApprovals:        branchRule.RequiredApprovals,
+					DismissStaleApprovals:    branchRule.DismissStaleApprovals,
+					RequireCodeOwnerApproval: branchRule.RequireCodeOwnerApproval,
 				}
 			}
 		}
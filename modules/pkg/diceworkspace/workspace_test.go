@@ -97,3 +97,40 @@ func TestGetWorkspaceByBranch(t *testing.T) {
 	ws, err = GetByGitReference("upgrade/1", rules)
 	require.Error(t, err)
 }
+
+func TestGetValidBranchByGitReference_AllowedMergeMethods(t *testing.T) {
+	rules := []*apistructs.BranchRule{
+		{
+			Rule:                "master,release/*",
+			IsProtect:           true,
+			AllowedMergeMethods: "squash,rebase",
+		},
+	}
+	branch := GetValidBranchByGitReference("release/1.0", rules)
+	require.True(t, branch.IsProtect)
+	require.Equal(t, "squash,rebase", branch.AllowedMergeMethods)
+
+	branch = GetValidBranchByGitReference("feature/foo", rules)
+	require.Equal(t, "", branch.AllowedMergeMethods)
+}
+
+func TestGetValidBranchByGitReference_MergeRequirements(t *testing.T) {
+	rules := []*apistructs.BranchRule{
+		{
+			Rule:                     "master",
+			IsProtect:                true,
+			RequiredCheckRuns:        "unit-test,lint",
+			RequiredApprovals:        2,
+			DismissStaleApprovals:    true,
+			RequireCodeOwnerApproval: true,
+		},
+	}
+	branch := GetValidBranchByGitReference("master", rules)
+	require.Equal(t, "unit-test,lint", branch.RequiredCheckRuns)
+	require.Equal(t, 2, branch.RequiredApprovals)
+	require.True(t, branch.DismissStaleApprovals)
+	require.True(t, branch.RequireCodeOwnerApproval)
+
+	branch = GetValidBranchByGitReference("develop", rules)
+	require.Equal(t, 0, branch.RequiredApprovals)
+}
@@ -62,3 +62,10 @@ func TestEmail(t *testing.T) {
 	require.Equal(t, "abc*e", Email("abcde"))
 	require.Equal(t, "abc**f", Email("abcdef"))
 }
+
+func TestIsSensitiveKey(t *testing.T) {
+	require.True(t, IsSensitiveKey("DOCKER_PASSWORD"))
+	require.True(t, IsSensitiveKey("oss.access_key.secret"))
+	require.True(t, IsSensitiveKey("dice.openapi.token"))
+	require.False(t, IsSensitiveKey("PIPELINE_ID"))
+}
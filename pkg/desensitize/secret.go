@@ -0,0 +1,24 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package desensitize
+
+import "regexp"
+
+// sensitiveKeyRe 匹配名称疑似 secret 的配置项或 env，例如 DOCKER_PASSWORD, oss.access.key.secret
+var sensitiveKeyRe = regexp.MustCompile(`(?i)(PASSWORD|PASSWD|SECRET|TOKEN|PRIVATE_KEY|ACCESS_KEY)`)
+
+// IsSensitiveKey 判断名称是否疑似 secret
+func IsSensitiveKey(key string) bool {
+	return sensitiveKeyRe.MatchString(key)
+}
@@ -38,6 +38,7 @@ const (
 	Params  = "params"
 	Globals = "globals"
 	Configs = "configs"
+	Matrix  = "matrix"
 )
 
 const (
@@ -39,6 +39,16 @@ type Spec struct {
 
 	Cron            string           `yaml:"cron,omitempty"`
 	CronCompensator *CronCompensator `yaml:"cron_compensator,omitempty"`
+	// ConcurrencyPolicy 定时触发时上一次流水线仍在运行的处理策略: Allow/Forbid/Replace
+	ConcurrencyPolicy string `yaml:"concurrency_policy,omitempty"`
+
+	// Timeout 流水线执行超时时间，单位秒，不包含排队时间；-1 表示永不超时
+	Timeout int64 `yaml:"timeout,omitempty"`
+	// QueueTimeout 流水线排队超时时间，单位秒；-1 表示永不超时
+	QueueTimeout int64 `yaml:"queue_timeout,omitempty"`
+
+	// Concurrency 并发组配置，同一并发组内的流水线不会同时运行
+	Concurrency *ConcurrencyConfig `yaml:"concurrency,omitempty"`
 
 	Stages []*Stage `yaml:"stages"`
 
@@ -53,9 +63,16 @@ type Spec struct {
 	errs []error
 	// warns collect occurred warns when parse
 	warns []string
+	// diagnostics collect occurred errors and warns with their locations, used by lint
+	diagnostics []specDiagnostic
 
 	// allActions represents all actions from all stages
 	allActions map[ActionAlias]*indexedAction
+
+	// matrixOrigins represents actions declared with matrix before expanded
+	matrixOrigins map[ActionAlias]*Action
+	// matrixActions represents expanded actions of each matrix action
+	matrixActions map[ActionAlias][]ActionAlias
 }
 
 // describe the use of network hook in the pipeline
@@ -93,6 +110,8 @@ type indexedAction struct {
 // Actions under a same stage executes in parallel.
 type Stage struct {
 	Actions []typedActionMap `yaml:"stage"`
+	// Timeout stage 执行超时时间，单位秒，从 stage 内第一个任务开始执行时计算；-1 表示永不超时
+	Timeout int64 `yaml:"timeout,omitempty"`
 }
 
 type PipelineParam struct {
@@ -124,6 +143,10 @@ type Action struct {
 	Commands  []string                     `yaml:"commands,omitempty"`
 	Loop      *apistructs.PipelineTaskLoop `yaml:"loop,omitempty"`
 
+	Retry *apistructs.PipelineTaskRetry `yaml:"retry,omitempty"` // 失败重试
+
+	Coverage *apistructs.ActionCoverage `yaml:"coverage,omitempty"` // 覆盖率文件
+
 	Timeout int64 `yaml:"timeout,omitempty"` // unit: second
 
 	Resources Resources `yaml:"resources,omitempty"`
@@ -136,18 +159,25 @@ type Action struct {
 
 	If string `yaml:"if,omitempty"` // 条件执行
 
-	// TODO 在未来版本中，可能去除 stage，依赖关系则必须通过 Needs 来声明。
-	// 目前不开放给用户使用。由 parser 自动赋值。
-	// Needs 显式声明依赖的 actions。隐式依赖关系是下一个 stage 依赖之前所有 stage 里的 action。
-	// Needs 可以绕开 stage 限制，以 DAG 方式声明依赖关系。
-	// Needs 一旦声明，只包含声明的值，不会注入其他依赖。
+	Matrix       *ActionMatrix `yaml:"matrix,omitempty"` // 矩阵配置，解析时展开为多个 action
+	MatrixOrigin *MatrixOrigin `yaml:"-"`                // 由 matrix 展开的 action 的来源信息，由 parser 自动赋值
+
+	// Needs 为 action 最终生效的依赖 actions，由 parser 自动赋值。
+	// 若声明了 DeclaredNeeds，则 Needs = DeclaredNeeds；
+	// 否则使用隐式依赖关系，即下一个 stage 依赖之前所有 stage 里的 action。
 	Needs []ActionAlias `yaml:"-"`
 
+	// DeclaredNeeds 为用户在 pipeline.yml 中通过 needs 显式声明依赖的 actions。
+	// needs 可以绕开 stage 限制，以 DAG 方式声明依赖关系，依赖的 actions 全部完成后即可开始执行。
+	// needs 一旦声明，只包含声明的值，不会注入其他依赖；needs: [] 表示不依赖任何 action。
+	DeclaredNeeds []ActionAlias `yaml:"needs,omitempty"`
+
 	// TODO 该字段目前是兼容字段。
 	// 在 1.1 版本中，Needs = NeedNamespaces
 	// 在 1.0 版本中，Needs <= NeedNamespaces
 	// 目前不开放给用户使用。由 parser 自动赋值。
 	// NeedNamespaces 显式声明依赖的 namespaces。隐式依赖关系是下一个 stage 依赖之前所有 stage 的 namespaces。
+	// 若声明了 needs，则为所有直接及间接依赖的 actions 的 namespaces。
 	// NeedNamespaces 一旦声明，只包含声明的值，不会注入其他依赖。
 	NeedNamespaces []string `yaml:"-"`
 
@@ -186,8 +216,28 @@ type ActionCache struct {
 	// 缓存生成的 key 或者是用户指定的 key
 	// 用户指定的话 需要 {{basePath}}/路径/{{endPath}} 来自定义 key
 	// 用户没有指定 key 有一定的生成规则, 具体生成规则看 prepare.go 的 setActionCacheStorageAndBinds 方法
+	// key 中可以使用 ${{ hashFiles('**/go.sum') }} 表达式，在 action 容器中根据文件内容计算
 	Key  string `yaml:"key,omitempty"`
 	Path string `yaml:"path,omitempty"` // 指定那个目录被缓存, 只能是由 / 开始的绝对路径
+	// RestoreKeys key 未命中时按顺序使用的前缀列表，命中前缀的缓存中取最近更新的一个
+	RestoreKeys []string `yaml:"restore_keys,omitempty"`
+}
+
+// ActionMatrix 声明 action 的矩阵配置，解析时按照维度的笛卡尔积展开为多个 action。
+type ActionMatrix struct {
+	Dimensions  map[string][]string `yaml:"dimensions,omitempty"`   // 维度，例如 jdk: [8, 11]
+	Include     []map[string]string `yaml:"include,omitempty"`      // 额外追加的组合
+	Exclude     []map[string]string `yaml:"exclude,omitempty"`      // 需要排除的组合
+	MaxParallel int                 `yaml:"max-parallel,omitempty"` // 最大并行数，0 表示不限制
+}
+
+// MatrixOrigin 表示 matrix 展开后的 action 的来源
+type MatrixOrigin struct {
+	Alias  ActionAlias       // 展开前的 action alias
+	Index  int               // 在展开结果中的序号
+	Values map[string]string // 当前组合的维度值
+	// MaxParallel 同一 matrix 展开的 actions 最多同时执行的数量，由调度时限制，0 表示不限制
+	MaxParallel int
 }
 
 type ActionType string
@@ -232,10 +282,52 @@ type CronCompensator struct {
 	StopIfLatterExecuted bool `yaml:"stop_if_latter_executed"`
 }
 
+// yamlField 可作为 appendError/appendWarn 的最后一个 index，表示出错的字段名，用于 lint 定位到具体行
+type yamlField string
+
+// specDiagnostic 记录错误发生的位置信息，在 lint 时根据 yaml 节点树转换为行列
+type specDiagnostic struct {
+	severity   apistructs.PipelineYmlDiagnosticSeverity
+	message    string
+	stageIndex int
+	action     interface{} // ActionAlias, string or action index inside a stage
+	field      string
+}
+
+func (s *Spec) appendDiagnostic(severity apistructs.PipelineYmlDiagnosticSeverity, message string, field yamlField, indices []interface{}) {
+	d := specDiagnostic{severity: severity, message: message, stageIndex: -1, field: string(field)}
+	if len(indices) > 0 {
+		if stageIndex, ok := indices[0].(int); ok {
+			d.stageIndex = stageIndex
+		}
+	}
+	if len(indices) > 1 {
+		d.action = indices[1]
+	}
+	s.diagnostics = append(s.diagnostics, d)
+}
+
+// popYamlField 取出 indices 中最后一个 yamlField
+func popYamlField(indices []interface{}) (yamlField, []interface{}) {
+	if len(indices) == 0 {
+		return "", indices
+	}
+	if field, ok := indices[len(indices)-1].(yamlField); ok {
+		return field, indices[:len(indices)-1]
+	}
+	return "", indices
+}
+
 // indices:
 // 0: stage index
 // 1: action name or index inside a stage
+// last: optional yamlField
 func (s *Spec) appendError(err error, indices ...interface{}) {
+	var field yamlField
+	field, indices = popYamlField(indices)
+	if err != nil {
+		s.appendDiagnostic(apistructs.PipelineYmlDiagnosticSeverityError, err.Error(), field, indices)
+	}
 	var prefix string
 	defer func() {
 		if r := recover(); r != nil {
@@ -275,6 +367,11 @@ func (s *Spec) mergeErrors() error {
 }
 
 func (s *Spec) appendWarn(warn string, indices ...interface{}) {
+	var field yamlField
+	field, indices = popYamlField(indices)
+	if warn != "" {
+		s.appendDiagnostic(apistructs.PipelineYmlDiagnosticSeverityWarning, warn, field, indices)
+	}
 	var prefix string
 	defer func() {
 		if r := recover(); r != nil {
@@ -24,10 +24,13 @@ import (
 // GenerateYml 根据 spec 重新生成 yaml 文本，一般用于对 spec 进行调整后重新生成 yaml 文本
 func GenerateYml(s *Spec) ([]byte, error) {
 	polishNamespaces(s)
+	// matrix 展开的 actions 还原为原始 action
+	collapsed := *s
+	collapsed.Stages = s.collapsedStages()
 	var newYmlBuf bytes.Buffer
 	encoder := yaml.NewEncoder(&newYmlBuf)
 	encoder.SetIndent(1)
-	if err := encoder.Encode(s); err != nil {
+	if err := encoder.Encode(&collapsed); err != nil {
 		return nil, err
 	}
 	return newYmlBuf.Bytes(), nil
@@ -36,8 +36,11 @@ func ConvertGraphPipelineYmlContent(data []byte) ([]byte, error) {
 			StopIfLatterExecuted: frontendYmlSpec.CronCompensator.StopIfLatterExecuted,
 		}
 	}
+	s.ConcurrencyPolicy = frontendYmlSpec.ConcurrencyPolicy
+	s.Timeout = frontendYmlSpec.Timeout
+	s.QueueTimeout = frontendYmlSpec.QueueTimeout
 	s.Stages = make([]*Stage, 0)
-	for _, stage := range frontendYmlSpec.Stages {
+	for stageIndex, stage := range frontendYmlSpec.Stages {
 		actions := make([]typedActionMap, 0)
 		for _, frontendAction := range stage {
 
@@ -52,6 +55,8 @@ func ConvertGraphPipelineYmlContent(data []byte) ([]byte, error) {
 					Timeout:     frontendAction.Timeout,
 					If:          frontendAction.If,
 					Loop:        frontendAction.Loop,
+					Retry:       frontendAction.Retry,
+					Coverage:    frontendAction.Coverage,
 					Type:        ActionType(frontendAction.Type),
 					Namespaces:  frontendAction.Namespaces,
 					Resources: Resources{
@@ -61,6 +66,23 @@ func ConvertGraphPipelineYmlContent(data []byte) ([]byte, error) {
 					},
 				}}
 
+			if frontendAction.Matrix != nil {
+				maps[ActionType(frontendAction.Type)].Matrix = &ActionMatrix{
+					Dimensions:  frontendAction.Matrix.Dimensions,
+					Include:     frontendAction.Matrix.Include,
+					Exclude:     frontendAction.Matrix.Exclude,
+					MaxParallel: frontendAction.Matrix.MaxParallel,
+				}
+			}
+
+			if frontendAction.Needs != nil {
+				needs := make([]ActionAlias, 0, len(frontendAction.Needs))
+				for _, need := range frontendAction.Needs {
+					needs = append(needs, ActionAlias(need))
+				}
+				maps[ActionType(frontendAction.Type)].DeclaredNeeds = needs
+			}
+
 			if frontendAction.SnippetConfig != nil {
 				maps[ActionType(frontendAction.Type)].SnippetConfig = &SnippetConfig{
 					Name:   frontendAction.SnippetConfig.Name,
@@ -71,7 +93,11 @@ func ConvertGraphPipelineYmlContent(data []byte) ([]byte, error) {
 
 			actions = append(actions, maps)
 		}
-		s.Stages = append(s.Stages, &Stage{Actions: actions})
+		var stageTimeout int64
+		if stageIndex < len(frontendYmlSpec.StageTimeouts) {
+			stageTimeout = frontendYmlSpec.StageTimeouts[stageIndex]
+		}
+		s.Stages = append(s.Stages, &Stage{Actions: actions, Timeout: stageTimeout})
 	}
 
 	var pipelineParams []*PipelineParam
@@ -154,13 +180,16 @@ func ConvertToGraphPipelineYml(data []byte) (*apistructs.PipelineYml, error) {
 		}
 	}
 	result := &apistructs.PipelineYml{
-		Version:     pipelineYml.Spec().Version,
-		Envs:        pipelineYml.Spec().Envs,
-		Cron:        pipelineYml.Spec().Cron,
-		NeedUpgrade: pipelineYml.needUpgrade,
-		Params:      pipelineParams,
-		Outputs:     pipelineOutputs,
-		On:          on,
+		Version:           pipelineYml.Spec().Version,
+		Envs:              pipelineYml.Spec().Envs,
+		Cron:              pipelineYml.Spec().Cron,
+		ConcurrencyPolicy: pipelineYml.Spec().ConcurrencyPolicy,
+		Timeout:           pipelineYml.Spec().Timeout,
+		QueueTimeout:      pipelineYml.Spec().QueueTimeout,
+		NeedUpgrade:       pipelineYml.needUpgrade,
+		Params:            pipelineParams,
+		Outputs:           pipelineOutputs,
+		On:                on,
 	}
 
 	var lifecycle []*apistructs.NetworkHookInfo
@@ -184,47 +213,82 @@ func ConvertToGraphPipelineYml(data []byte) (*apistructs.PipelineYml, error) {
 		result.YmlContent = string(graphYmlContent)
 	}
 
-	for _, stage := range pipelineYml.Spec().Stages {
+	for _, stage := range pipelineYml.Spec().collapsedStages() {
 		stageActions := make([]*apistructs.PipelineYmlAction, 0)
 		for _, typedAction := range stage.Actions {
 			for _, action := range typedAction {
-				resultAction := &apistructs.PipelineYmlAction{}
-				resultAction.Type = action.Type.String()
-				resultAction.Alias = action.Alias.String()
-				resultAction.Version = action.Version
-				resultAction.Params = action.Params
-				resultAction.Image = action.Image
-				resultAction.Commands = action.Commands
-				resultAction.Timeout = action.Timeout
-				resultAction.Namespaces = action.Namespaces
-				resultAction.If = action.If
-				resultAction.Loop = action.Loop
-				resultAction.Resources = apistructs.Resources{Cpu: action.Resources.CPU, Mem: float64(action.Resources.Mem), Disk: float64(action.Resources.Disk)}
-
-				caches := action.Caches
-				if caches != nil {
-					var resultActionCaches []apistructs.ActionCache
-					for _, v := range caches {
-						resultActionCaches = append(resultActionCaches, apistructs.ActionCache{
-							Path: v.Path,
-							Key:  v.Key,
-						})
-					}
-					resultAction.Caches = resultActionCaches
-				}
+				resultAction := toApiAction(action)
 
-				if action.SnippetConfig != nil {
-					resultAction.SnippetConfig = action.SnippetConfig.toApiSnippetConfig()
+				// matrix 展开
+				if action.Matrix != nil {
+					resultAction.Matrix = &apistructs.ActionMatrix{
+						Dimensions:  action.Matrix.Dimensions,
+						Include:     action.Matrix.Include,
+						Exclude:     action.Matrix.Exclude,
+						MaxParallel: action.Matrix.MaxParallel,
+					}
+					for _, expanded := range pipelineYml.Spec().MatrixExpandedActions(action.Alias) {
+						expandedAction := toApiAction(expanded)
+						expandedAction.MatrixValues = expanded.MatrixOrigin.Values
+						resultAction.MatrixActions = append(resultAction.MatrixActions, expandedAction)
+					}
 				}
 
 				stageActions = append(stageActions, resultAction)
 			}
 		}
 		result.Stages = append(result.Stages, stageActions)
+		if stage.Timeout != 0 {
+			if len(result.StageTimeouts) == 0 {
+				result.StageTimeouts = make([]int64, len(pipelineYml.Spec().Stages))
+			}
+			result.StageTimeouts[len(result.Stages)-1] = stage.Timeout
+		}
 	}
 	return result, nil
 }
 
+func toApiAction(action *Action) *apistructs.PipelineYmlAction {
+	resultAction := &apistructs.PipelineYmlAction{}
+	resultAction.Type = action.Type.String()
+	resultAction.Alias = action.Alias.String()
+	resultAction.Version = action.Version
+	resultAction.Params = action.Params
+	resultAction.Image = action.Image
+	resultAction.Commands = action.Commands
+	resultAction.Timeout = action.Timeout
+	resultAction.Namespaces = action.Namespaces
+	resultAction.If = action.If
+	resultAction.Loop = action.Loop
+	resultAction.Retry = action.Retry
+	resultAction.Coverage = action.Coverage
+	resultAction.Resources = apistructs.Resources{Cpu: action.Resources.CPU, Mem: float64(action.Resources.Mem), Disk: float64(action.Resources.Disk)}
+	if action.DeclaredNeeds != nil {
+		resultAction.Needs = make([]string, 0, len(action.DeclaredNeeds))
+		for _, need := range action.DeclaredNeeds {
+			resultAction.Needs = append(resultAction.Needs, need.String())
+		}
+	}
+
+	caches := action.Caches
+	if caches != nil {
+		var resultActionCaches []apistructs.ActionCache
+		for _, v := range caches {
+			resultActionCaches = append(resultActionCaches, apistructs.ActionCache{
+				Path:        v.Path,
+				Key:         v.Key,
+				RestoreKeys: v.RestoreKeys,
+			})
+		}
+		resultAction.Caches = resultActionCaches
+	}
+
+	if action.SnippetConfig != nil {
+		resultAction.SnippetConfig = action.SnippetConfig.toApiSnippetConfig()
+	}
+	return resultAction
+}
+
 func toApiParam(pipelineInput *PipelineParam) (params *apistructs.PipelineParam) {
 	return &apistructs.PipelineParam{
 		Name:     pipelineInput.Name,
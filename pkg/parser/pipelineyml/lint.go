@@ -0,0 +1,363 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelineyml
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/erda-project/erda/apistructs"
+	"github.com/erda-project/erda/pkg/strutil"
+)
+
+var (
+	// 匹配 yaml 错误中的行号，例如 yaml: line 3: did not find expected key
+	yamlErrLineRe = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+	// 匹配 yaml 严格模式下的未知字段，例如 field foo not found in type pipelineyml.Action
+	yamlUnknownFieldRe = regexp.MustCompile(`^field (\S+) not found in type \S+$`)
+)
+
+// Lint 校验 pipeline.yml，返回带行列信息的诊断结果，按位置排序。
+// actionSpecs 的 key 为 action type 或 type@version，value 为空表示扩展市场中不存在该 action；
+// actionSpecs 为 nil 时不校验 action params。
+func Lint(b []byte, actionSpecs map[string]*apistructs.ActionSpec) []apistructs.PipelineYmlDiagnostic {
+	b = strutil.NormalizeNewlines(b)
+	l := linter{lines: strings.Split(string(b), "\n")}
+	l.lint(b, actionSpecs)
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		pi, pj := l.diagnostics[i].Range.Start, l.diagnostics[j].Range.Start
+		if pi.Line != pj.Line {
+			return pi.Line < pj.Line
+		}
+		return pi.Column < pj.Column
+	})
+	return l.diagnostics
+}
+
+// HasLintError 诊断结果中是否有 error 级别
+func HasLintError(diagnostics []apistructs.PipelineYmlDiagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == apistructs.PipelineYmlDiagnosticSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// ListActionItems 返回 pipeline.yml 中声明的 action，格式为 type 或 type@version，不包含 snippet
+func ListActionItems(b []byte) []string {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(strutil.NormalizeNewlines(b), &doc); err != nil {
+		return nil
+	}
+	var items []string
+	for _, stage := range newNodeIndex(&doc).stages {
+		for _, action := range stage.actions {
+			if item, ok := action.item(); ok {
+				items = append(items, item)
+			}
+		}
+	}
+	return strutil.DedupSlice(items)
+}
+
+type linter struct {
+	lines       []string
+	diagnostics []apistructs.PipelineYmlDiagnostic
+}
+
+func (l *linter) lint(b []byte, actionSpecs map[string]*apistructs.ActionSpec) {
+	// 语法错误
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		l.appendYamlError(err)
+		return
+	}
+	idx := newNodeIndex(&doc)
+
+	version, err := GetVersion(b)
+	if err != nil {
+		l.appendYamlError(err)
+		return
+	}
+	if version == Version1dot0 || version == Version1 {
+		// 1.0 由 parser 自动升级后再解析，无法定位到原始位置
+		d := specDiagnostic{severity: apistructs.PipelineYmlDiagnosticSeverityWarning, stageIndex: -1, field: "version",
+			message: fmt.Sprintf("version %s is deprecated, please upgrade to %s", version, Version1dot1)}
+		l.append(d, idx.locate(d))
+		if _, err := New(b); err != nil {
+			l.append(specDiagnostic{severity: apistructs.PipelineYmlDiagnosticSeverityError, stageIndex: -1, message: err.Error()},
+				documentStartRange())
+		}
+		return
+	}
+
+	// 严格模式解析，类型错误为 error，未知字段为 warning
+	if version == Version1dot1 {
+		var strict Spec
+		decoder := yaml.NewDecoder(bytes.NewReader(b))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&strict); err != nil {
+			l.appendYamlError(err)
+		}
+		if HasLintError(l.diagnostics) {
+			return
+		}
+	}
+
+	y, err := New(b)
+	if y == nil {
+		if err != nil {
+			l.append(specDiagnostic{severity: apistructs.PipelineYmlDiagnosticSeverityError, stageIndex: -1, message: err.Error()},
+				documentStartRange())
+		}
+		return
+	}
+	for _, d := range y.s.diagnostics {
+		// matrix 展开后的 action 定位到展开前的 action
+		if alias, ok := d.action.(ActionAlias); ok {
+			if action, ok := y.s.allActions[alias]; ok && action.MatrixOrigin != nil {
+				d.action = action.MatrixOrigin.Alias
+			}
+		}
+		l.append(d, idx.locate(d), l.quickFixes(d, idx, y.s)...)
+	}
+
+	if actionSpecs != nil {
+		l.lintActionParams(idx, actionSpecs)
+	}
+}
+
+func (l *linter) append(d specDiagnostic, r apistructs.PipelineYmlRange, fixes ...apistructs.PipelineYmlQuickFix) {
+	diagnostic := apistructs.PipelineYmlDiagnostic{
+		Severity:   d.severity,
+		Message:    d.message,
+		Range:      r,
+		StageIndex: d.stageIndex,
+		Field:      d.field,
+		QuickFixes: fixes,
+	}
+	if d.action != nil {
+		diagnostic.ActionAlias = fmt.Sprintf("%v", d.action)
+	}
+	l.diagnostics = append(l.diagnostics, diagnostic)
+}
+
+// appendYamlError 解析 yaml 库返回的错误，每个错误定位到对应的行
+func (l *linter) appendYamlError(err error) {
+	var msgs []string
+	if typeErr, ok := err.(*yaml.TypeError); ok {
+		msgs = typeErr.Errors
+	} else {
+		msgs = []string{err.Error()}
+	}
+	for _, msg := range msgs {
+		msg = strings.TrimSpace(msg)
+		d := specDiagnostic{severity: apistructs.PipelineYmlDiagnosticSeverityError, stageIndex: -1, message: msg}
+		r := documentStartRange()
+		matches := yamlErrLineRe.FindStringSubmatch(msg)
+		if len(matches) != 3 {
+			l.append(d, r)
+			continue
+		}
+		line, _ := strconv.Atoi(matches[1])
+		d.message = matches[2]
+		r = lineRange(l.lines, line)
+		var fixes []apistructs.PipelineYmlQuickFix
+		if field := yamlUnknownFieldRe.FindStringSubmatch(d.message); len(field) == 2 {
+			d.severity = apistructs.PipelineYmlDiagnosticSeverityWarning
+			d.message = fmt.Sprintf("unknown field %q", field[1])
+			if line >= 1 && line <= len(l.lines) {
+				if column := strings.Index(l.lines[line-1], field[1]+":"); column >= 0 {
+					r.Start.Column = column + 1
+					r.End.Column = column + 1 + len(field[1])
+				}
+			}
+			fixes = append(fixes, apistructs.PipelineYmlQuickFix{Title: fmt.Sprintf("remove field %q", field[1])})
+		}
+		l.append(d, r, fixes...)
+	}
+}
+
+// quickFixes 根据出错字段给出修复建议
+func (l *linter) quickFixes(d specDiagnostic, idx *nodeIndex, s *Spec) []apistructs.PipelineYmlQuickFix {
+	value := idx.field(d)
+	var candidates []string
+	switch d.field {
+	case "version":
+		candidates = []string{Version1dot1}
+	case "concurrency_policy":
+		candidates = []string{apistructs.PipelineCronConcurrencyPolicyAllow.String(), apistructs.PipelineCronConcurrencyPolicyForbid.String(),
+			apistructs.PipelineCronConcurrencyPolicyReplace.String()}
+	case "retry.backoff":
+		candidates = []string{string(apistructs.PipelineTaskRetryBackoffFixed), string(apistructs.PipelineTaskRetryBackoffExponential)}
+	case "coverage.format":
+		candidates = []string{string(apistructs.CoverageFormatCobertura), string(apistructs.CoverageFormatJaCoCo),
+			string(apistructs.CoverageFormatLCOV), string(apistructs.CoverageFormatGoCover)}
+	case "needs":
+		// needs 中未知的 action 给出相似的 alias
+		for alias := range s.allActions {
+			candidates = append(candidates, alias.String())
+		}
+		sort.Strings(candidates)
+		var fixes []apistructs.PipelineYmlQuickFix
+		if value == nil || value.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for _, need := range value.Content {
+			if _, ok := s.allActions[ActionAlias(need.Value)]; ok || need.Kind != yaml.ScalarNode {
+				continue
+			}
+			if suggestion, ok := suggest(need.Value, candidates); ok {
+				r := scalarRange(need)
+				fixes = append(fixes, apistructs.PipelineYmlQuickFix{Title: fmt.Sprintf("did you mean %q?", suggestion), Range: &r, NewText: suggestion})
+			}
+		}
+		return fixes
+	default:
+		return nil
+	}
+	if value == nil || value.Kind != yaml.ScalarNode {
+		return nil
+	}
+	suggestion, ok := suggest(value.Value, candidates)
+	if !ok && len(candidates) == 1 {
+		suggestion, ok = candidates[0], true
+	}
+	if !ok || suggestion == value.Value {
+		return nil
+	}
+	r := scalarRange(value)
+	return []apistructs.PipelineYmlQuickFix{{Title: fmt.Sprintf("replace with %q", suggestion), Range: &r, NewText: suggestion}}
+}
+
+// lintActionParams 根据扩展市场中 action 的 spec.yml 校验 params
+func (l *linter) lintActionParams(idx *nodeIndex, actionSpecs map[string]*apistructs.ActionSpec) {
+	for stageIndex, stage := range idx.stages {
+		for _, action := range stage.actions {
+			item, ok := action.item()
+			if !ok {
+				continue
+			}
+			actionSpec, queried := actionSpecs[item]
+			if !queried {
+				continue
+			}
+			base := specDiagnostic{severity: apistructs.PipelineYmlDiagnosticSeverityError, stageIndex: stageIndex, action: action.alias}
+			if actionSpec == nil {
+				d := base
+				d.message = fmt.Sprintf("action %q not exist in Extension Market", item)
+				l.append(d, keyRange(action.key))
+				continue
+			}
+
+			paramsKey, params := lookupKey(action.value, "params")
+			declared := make(map[string]struct{})
+			var specParamNames []string
+			for _, p := range actionSpec.Params {
+				specParamNames = append(specParamNames, p.Name)
+			}
+			if params != nil && params.Kind == yaml.MappingNode {
+				for i := 0; i+1 < len(params.Content); i += 2 {
+					key := params.Content[i]
+					declared[key.Value] = struct{}{}
+					if strutil.Exist(specParamNames, key.Value) {
+						continue
+					}
+					d := base
+					d.severity = apistructs.PipelineYmlDiagnosticSeverityWarning
+					d.field = "params." + key.Value
+					d.message = fmt.Sprintf("unknown param %q", key.Value)
+					var fixes []apistructs.PipelineYmlQuickFix
+					if suggestion, ok := suggest(key.Value, specParamNames); ok {
+						r := scalarRange(key)
+						fixes = append(fixes, apistructs.PipelineYmlQuickFix{Title: fmt.Sprintf("did you mean %q?", suggestion), Range: &r, NewText: suggestion})
+					}
+					l.append(d, scalarRange(key), fixes...)
+				}
+			}
+			for _, p := range actionSpec.Params {
+				if !p.Required || p.Default != nil {
+					continue
+				}
+				if _, ok := declared[p.Name]; ok {
+					continue
+				}
+				d := base
+				d.field = "params"
+				d.message = fmt.Sprintf("missing required param %q", p.Name)
+				owner := action.key
+				if paramsKey != nil {
+					owner = paramsKey
+				}
+				l.append(d, keyRange(owner), apistructs.PipelineYmlQuickFix{Title: fmt.Sprintf("add param %q", p.Name)})
+			}
+		}
+	}
+}
+
+// suggest 从候选值中找到与 value 最相近的一个，忽略大小写相等或编辑距离不超过 2
+func suggest(value string, candidates []string) (string, bool) {
+	var (
+		best     string
+		bestDist = -1
+	)
+	for _, c := range candidates {
+		if strings.EqualFold(c, value) {
+			return c, true
+		}
+		dist := editDistance(strings.ToLower(value), strings.ToLower(c))
+		if dist <= 2 && (bestDist < 0 || dist < bestDist) {
+			best, bestDist = c, dist
+		}
+	}
+	return best, bestDist >= 0
+}
+
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelineyml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+func TestLint_SyntaxError(t *testing.T) {
+	diagnostics := Lint([]byte("version: \"1.1\"\nstages:\n  - stage:\n    - git-checkout:\n   bad: [\n"), nil)
+	assert.True(t, HasLintError(diagnostics))
+	assert.True(t, diagnostics[0].Range.Start.Line > 0)
+}
+
+func TestLint_TypeErrorAndUnknownField(t *testing.T) {
+	y := `version: "1.1"
+timeout: abc
+stages:
+  - stage:
+      - git-checkout:
+          foo: bar
+`
+	diagnostics := Lint([]byte(y), nil)
+	assert.Len(t, diagnostics, 2)
+
+	assert.Equal(t, apistructs.PipelineYmlDiagnosticSeverityError, diagnostics[0].Severity)
+	assert.Equal(t, 2, diagnostics[0].Range.Start.Line)
+
+	assert.Equal(t, apistructs.PipelineYmlDiagnosticSeverityWarning, diagnostics[1].Severity)
+	assert.Equal(t, `unknown field "foo"`, diagnostics[1].Message)
+	assert.Equal(t, apistructs.PipelineYmlPosition{Line: 6, Column: 11}, diagnostics[1].Range.Start)
+	assert.Equal(t, apistructs.PipelineYmlPosition{Line: 6, Column: 14}, diagnostics[1].Range.End)
+}
+
+func TestLint_VisitorErrors(t *testing.T) {
+	y := `version: "1.1"
+cron: "0 * * * * ?"
+concurrency_policy: forbid
+stages:
+  - stage:
+      - git-checkout:
+          alias: repo
+      - custom-script:
+          alias: build
+          timeout: -2
+          retry:
+            max_attempts: 2
+            backoff: Fixed
+  - stage:
+      - custom-script:
+          alias: deploy
+          needs: [bulid]
+`
+	diagnostics := Lint([]byte(y), nil)
+	assert.Len(t, diagnostics, 4)
+
+	policy := diagnostics[0]
+	assert.Equal(t, "concurrency_policy", policy.Field)
+	assert.Equal(t, apistructs.PipelineYmlPosition{Line: 3, Column: 21}, policy.Range.Start)
+	assert.Equal(t, "Forbid", policy.QuickFixes[0].NewText)
+
+	timeout := diagnostics[1]
+	assert.Equal(t, 0, timeout.StageIndex)
+	assert.Equal(t, "build", timeout.ActionAlias)
+	assert.Equal(t, apistructs.PipelineYmlPosition{Line: 10, Column: 20}, timeout.Range.Start)
+
+	backoff := diagnostics[2]
+	assert.Equal(t, "retry.backoff", backoff.Field)
+	assert.Equal(t, 13, backoff.Range.Start.Line)
+	assert.Equal(t, "fixed", backoff.QuickFixes[0].NewText)
+
+	needs := diagnostics[3]
+	assert.Equal(t, 1, needs.StageIndex)
+	assert.Equal(t, 17, needs.Range.Start.Line)
+	assert.Equal(t, "build", needs.QuickFixes[0].NewText)
+	assert.Equal(t, apistructs.PipelineYmlPosition{Line: 17, Column: 19}, needs.QuickFixes[0].Range.Start)
+}
+
+func TestLint_ActionParams(t *testing.T) {
+	y := `version: "1.1"
+stages:
+  - stage:
+      - git-checkout:
+          params:
+            depht: 1
+      - unknown-action:
+      - custom-script:
+          version: "1.0"
+`
+	specs := map[string]*apistructs.ActionSpec{
+		"git-checkout": {Params: []apistructs.ActionSpecParam{
+			{Name: "uri", Required: true},
+			{Name: "branch", Required: true, Default: "master"},
+			{Name: "depth"},
+		}},
+		"unknown-action": nil,
+	}
+	diagnostics := Lint([]byte(y), specs)
+	assert.Len(t, diagnostics, 3)
+
+	assert.Equal(t, `missing required param "uri"`, diagnostics[0].Message)
+	assert.Equal(t, 5, diagnostics[0].Range.Start.Line)
+
+	assert.Equal(t, `unknown param "depht"`, diagnostics[1].Message)
+	assert.Equal(t, apistructs.PipelineYmlDiagnosticSeverityWarning, diagnostics[1].Severity)
+	assert.Equal(t, "depth", diagnostics[1].QuickFixes[0].NewText)
+
+	assert.Equal(t, apistructs.PipelineYmlDiagnosticSeverityError, diagnostics[2].Severity)
+	assert.Equal(t, 7, diagnostics[2].Range.Start.Line)
+}
+
+func TestSuggest(t *testing.T) {
+	s, ok := suggest("REPLACE", []string{"Allow", "Replace"})
+	assert.True(t, ok)
+	assert.Equal(t, "Replace", s)
+
+	s, ok = suggest("biuld", []string{"build", "deploy"})
+	assert.True(t, ok)
+	assert.Equal(t, "build", s)
+
+	_, ok = suggest("test", []string{"build", "deploy"})
+	assert.False(t, ok)
+}
+
+func TestListActionItems(t *testing.T) {
+	y := `version: "1.1"
+stages:
+  - stage:
+      - git-checkout:
+      - snippet:
+          snippet_config:
+            name: a.yml
+  - stage:
+      - custom-script:
+          version: "1.0"
+      - git-checkout:
+          alias: repo2
+`
+	assert.Equal(t, []string{"git-checkout", "custom-script@1.0"}, ListActionItems([]byte(y)))
+}
@@ -125,12 +125,20 @@ func New(b []byte, ops ...Option) (_ *PipelineYml, err error) {
 		}
 	}
 
+	// 展开 matrix action，需要在占位符渲染完成后、stageVisitor 之前执行
+	y.s.Accept(NewMatrixVisitor())
+
 	// 遍历 action，为 render ref,output 做准备
 	// 不做 flatParams，JSON 序列化在最后进行，防止简单 render 后 JSON 无效
 	y.s.Accept(NewStageVisitor(false))
+	// 校验并计算显式声明的 needs
+	y.s.Accept(NewNeedsVisitor())
 
 	y.s.Accept(NewCronVisitor())
 	y.s.Accept(NewTimeoutVisitor())
+	y.s.Accept(NewConcurrencyVisitor())
+	y.s.Accept(NewRetryVisitor())
+	y.s.Accept(NewCoverageVisitor())
 
 	if len(y.aliasToCheckRefOp) > 0 {
 		y.s.Accept(NewRefOpVisitor(y.aliasToCheckRefOp, y.refs, y.outputs, y.allowMissingCustomScriptOutputs, y.globalSnippetConfigLabels))
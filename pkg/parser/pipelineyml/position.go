@@ -0,0 +1,229 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelineyml
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+// nodeIndex 记录 pipeline.yml 各部分对应的 yaml 节点，用于将诊断信息定位到行列
+type nodeIndex struct {
+	root   *yaml.Node
+	stages []stageNode
+}
+
+type stageNode struct {
+	key     *yaml.Node // stage 关键字
+	value   *yaml.Node // stage 所在的 mapping
+	actions []actionNode
+}
+
+type actionNode struct {
+	alias ActionAlias
+	key   *yaml.Node // action type
+	value *yaml.Node // action 配置，可能为空
+}
+
+func newNodeIndex(doc *yaml.Node) *nodeIndex {
+	idx := nodeIndex{}
+	if doc == nil || doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return &idx
+	}
+	idx.root = doc.Content[0]
+	_, stages := lookupKey(idx.root, "stages")
+	if stages == nil || stages.Kind != yaml.SequenceNode {
+		return &idx
+	}
+	for _, stage := range stages.Content {
+		sn := stageNode{value: stage}
+		var actions *yaml.Node
+		sn.key, actions = lookupKey(stage, "stage")
+		if actions != nil && actions.Kind == yaml.SequenceNode {
+			for _, typed := range actions.Content {
+				if typed.Kind != yaml.MappingNode || len(typed.Content) < 2 {
+					sn.actions = append(sn.actions, actionNode{key: typed})
+					continue
+				}
+				an := actionNode{key: typed.Content[0], value: typed.Content[1], alias: ActionAlias(typed.Content[0].Value)}
+				if _, alias := lookupKey(an.value, "alias"); alias != nil && alias.Value != "" {
+					an.alias = ActionAlias(alias.Value)
+				}
+				sn.actions = append(sn.actions, an)
+			}
+		}
+		idx.stages = append(idx.stages, sn)
+	}
+	return &idx
+}
+
+// locate 返回诊断信息对应的位置；无法精确定位时逐级回退到 action、stage、文档开头
+func (idx *nodeIndex) locate(d specDiagnostic) apistructs.PipelineYmlRange {
+	owner, mapping := idx.owner(d)
+	if r, ok := fieldRange(mapping, d.field); ok {
+		return r
+	}
+	if owner != nil {
+		return keyRange(owner)
+	}
+	return documentStartRange()
+}
+
+// field 返回诊断信息中字段对应的 value 节点
+func (idx *nodeIndex) field(d specDiagnostic) *yaml.Node {
+	if d.field == "" {
+		return nil
+	}
+	_, mapping := idx.owner(d)
+	_, value := lookupPath(mapping, d.field)
+	return value
+}
+
+// owner 返回诊断信息所属的 action 或 stage 节点，以及用于查找字段的 mapping 节点
+func (idx *nodeIndex) owner(d specDiagnostic) (*yaml.Node, *yaml.Node) {
+	if d.stageIndex < 0 || d.stageIndex >= len(idx.stages) {
+		return nil, idx.root
+	}
+	stage := idx.stages[d.stageIndex]
+	if action, ok := stage.findAction(d.action); ok {
+		return action.key, action.value
+	}
+	owner := stage.key
+	if owner == nil {
+		owner = stage.value
+	}
+	if d.action == nil {
+		return owner, stage.value
+	}
+	return owner, nil
+}
+
+// item 返回扩展市场中的查询条件，格式为 type 或 type@version
+func (a actionNode) item() (string, bool) {
+	if a.key == nil || a.key.Kind != yaml.ScalarNode || a.key.Value == "" || a.key.Value == apistructs.ActionTypeSnippet {
+		return "", false
+	}
+	if _, version := lookupKey(a.value, "version"); version != nil && version.Value != "" {
+		return fmt.Sprintf("%s@%s", a.key.Value, version.Value), true
+	}
+	return a.key.Value, true
+}
+
+func (s stageNode) findAction(action interface{}) (actionNode, bool) {
+	switch v := action.(type) {
+	case int:
+		if v >= 0 && v < len(s.actions) {
+			return s.actions[v], true
+		}
+	case ActionAlias:
+		return s.findActionByAlias(v)
+	case string:
+		return s.findActionByAlias(ActionAlias(v))
+	}
+	return actionNode{}, false
+}
+
+func (s stageNode) findActionByAlias(alias ActionAlias) (actionNode, bool) {
+	for _, action := range s.actions {
+		if action.alias == alias {
+			return action, true
+		}
+	}
+	return actionNode{}, false
+}
+
+// lookupKey 在 mapping 节点中查找 key，返回 key 和 value 节点
+func lookupKey(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// lookupPath 按 a.b.c 格式逐级查找
+func lookupPath(mapping *yaml.Node, path string) (*yaml.Node, *yaml.Node) {
+	var key, value *yaml.Node
+	for _, part := range strings.Split(path, ".") {
+		key, value = lookupKey(mapping, part)
+		if key == nil {
+			return nil, nil
+		}
+		mapping = value
+	}
+	return key, value
+}
+
+// fieldRange 字段值为单行标量时定位到值，否则定位到字段名
+func fieldRange(mapping *yaml.Node, field string) (apistructs.PipelineYmlRange, bool) {
+	if field == "" {
+		return apistructs.PipelineYmlRange{}, false
+	}
+	key, value := lookupPath(mapping, field)
+	if key == nil {
+		return apistructs.PipelineYmlRange{}, false
+	}
+	if value != nil && value.Kind == yaml.ScalarNode && value.Value != "" &&
+		value.Style&(yaml.LiteralStyle|yaml.FoldedStyle) == 0 && !strings.Contains(value.Value, "\n") {
+		return scalarRange(value), true
+	}
+	return keyRange(key), true
+}
+
+func keyRange(node *yaml.Node) apistructs.PipelineYmlRange {
+	if node.Kind == yaml.ScalarNode {
+		return scalarRange(node)
+	}
+	start := apistructs.PipelineYmlPosition{Line: node.Line, Column: node.Column}
+	return apistructs.PipelineYmlRange{Start: start, End: apistructs.PipelineYmlPosition{Line: node.Line, Column: node.Column + 1}}
+}
+
+func scalarRange(node *yaml.Node) apistructs.PipelineYmlRange {
+	width := utf8.RuneCountInString(node.Value)
+	if node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+		width += 2
+	}
+	if width == 0 {
+		width = 1
+	}
+	return apistructs.PipelineYmlRange{
+		Start: apistructs.PipelineYmlPosition{Line: node.Line, Column: node.Column},
+		End:   apistructs.PipelineYmlPosition{Line: node.Line, Column: node.Column + width},
+	}
+}
+
+func documentStartRange() apistructs.PipelineYmlRange {
+	return lineRange(nil, 1)
+}
+
+// lineRange 返回整行的位置
+func lineRange(lines []string, line int) apistructs.PipelineYmlRange {
+	width := 0
+	if line >= 1 && line <= len(lines) {
+		width = utf8.RuneCountInString(lines[line-1])
+	}
+	return apistructs.PipelineYmlRange{
+		Start: apistructs.PipelineYmlPosition{Line: line, Column: 1},
+		End:   apistructs.PipelineYmlPosition{Line: line, Column: width + 1},
+	}
+}
@@ -0,0 +1,277 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelineyml
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// placeholderPattern 匹配 ${{ }}、${ }、(( )) 等占位符，非 string 类型的参数也允许使用占位符
+const placeholderPattern = `(\$\{\{.*\}\})|(\$\{.*\})|(\(\(.*\)\))`
+
+// GenerateJSONSchema 生成 pipeline.yml 的 JSON Schema (draft-07)。
+// actionSpecs 的 key 为 action type，用于生成各 action 的 params 定义；未包含的 action type 只校验通用字段。
+func GenerateJSONSchema(actionSpecs map[string]*apistructs.ActionSpec) map[string]interface{} {
+	var actionTypes []string
+	for actionType, spec := range actionSpecs {
+		if spec != nil {
+			actionTypes = append(actionTypes, actionType)
+		}
+	}
+	sort.Strings(actionTypes)
+	typedActionProperties := make(map[string]interface{}, len(actionTypes))
+	for _, actionType := range actionTypes {
+		typedActionProperties[actionType] = actionSchema(actionSpecs[actionType])
+	}
+
+	return map[string]interface{}{
+		"$schema":     jsonSchemaDraft,
+		"title":       "pipeline.yml",
+		"type":        "object",
+		"required":    []string{"version"},
+		"definitions": map[string]interface{}{"action": actionSchema(nil)},
+		"properties": map[string]interface{}{
+			"version": map[string]interface{}{"type": "string", "enum": []string{Version1dot1}},
+			"on": objectSchema(map[string]interface{}{
+				"push":  objectSchema(map[string]interface{}{"branches": stringArraySchema(), "tags": stringArraySchema()}),
+				"merge": objectSchema(map[string]interface{}{"branches": stringArraySchema()}),
+			}),
+			"storage": objectSchema(map[string]interface{}{"context": stringSchema()}),
+			"envs":    map[string]interface{}{"type": "object", "additionalProperties": stringSchema()},
+			"cron":    map[string]interface{}{"type": "string", "description": "cron expression"},
+			"cron_compensator": objectSchema(map[string]interface{}{
+				"enable":                  booleanSchema(),
+				"latest_first":            booleanSchema(),
+				"stop_if_latter_executed": booleanSchema(),
+			}),
+			"concurrency_policy": enumSchema(apistructs.PipelineCronConcurrencyPolicyAllow.String(),
+				apistructs.PipelineCronConcurrencyPolicyForbid.String(), apistructs.PipelineCronConcurrencyPolicyReplace.String()),
+			"timeout":       timeoutSchema(),
+			"queue_timeout": timeoutSchema(),
+			"concurrency":   concurrencySchema(),
+			"stages": map[string]interface{}{
+				"type": "array",
+				"items": objectSchema(map[string]interface{}{
+					"stage": map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type":                 "object",
+							"minProperties":        1,
+							"maxProperties":        1,
+							"properties":           typedActionProperties,
+							"additionalProperties": map[string]interface{}{"$ref": "#/definitions/action"},
+						},
+					},
+					"timeout": timeoutSchema(),
+				}),
+			},
+			"params": map[string]interface{}{
+				"type": "array",
+				"items": objectSchema(map[string]interface{}{
+					"name":     stringSchema(),
+					"required": booleanSchema(),
+					"default":  map[string]interface{}{},
+					"desc":     stringSchema(),
+					"type":     stringSchema(),
+				}),
+			},
+			"outputs": map[string]interface{}{
+				"type":  "array",
+				"items": objectSchema(map[string]interface{}{"name": stringSchema(), "desc": stringSchema(), "ref": stringSchema()}),
+			},
+			"lifecycle": map[string]interface{}{
+				"type": "array",
+				"items": objectSchema(map[string]interface{}{
+					"hook":   stringSchema(),
+					"client": stringSchema(),
+					"labels": map[string]interface{}{"type": "object"},
+				}),
+			},
+		},
+		"additionalProperties": false,
+	}
+}
+
+// actionSchema 生成单个 action 的 schema，spec 为空时 params 不做限制
+func actionSchema(spec *apistructs.ActionSpec) map[string]interface{} {
+	params := map[string]interface{}{"type": "object"}
+	if spec != nil {
+		params = paramsSchema(spec.Params)
+	}
+	var retryReasons []string
+	for _, reason := range []apistructs.PipelineTaskRetryReason{
+		apistructs.PipelineTaskRetryReasonFailed, apistructs.PipelineTaskRetryReasonTimeout, apistructs.PipelineTaskRetryReasonExecutorError,
+		apistructs.PipelineTaskRetryReasonImagePull, apistructs.PipelineTaskRetryReasonOOM, apistructs.PipelineTaskRetryReasonExitCode,
+	} {
+		retryReasons = append(retryReasons, string(reason))
+	}
+	schema := objectSchema(map[string]interface{}{
+		"alias":       map[string]interface{}{"type": "string", "pattern": aliasRegex.String()},
+		"description": stringSchema(),
+		"version":     stringSchema(),
+		"params":      params,
+		"labels":      map[string]interface{}{"type": "object", "additionalProperties": stringSchema()},
+		"workspace":   stringSchema(),
+		"image":       stringSchema(),
+		"commands":    stringArraySchema(),
+		"loop": objectSchema(map[string]interface{}{
+			"break": stringSchema(),
+			"strategy": objectSchema(map[string]interface{}{
+				"max_times":         integerSchema(),
+				"decline_ratio":     numberSchema(),
+				"decline_limit_sec": integerSchema(),
+				"interval_sec":      integerSchema(),
+			}),
+		}),
+		"retry": objectSchema(map[string]interface{}{
+			"max_attempts":     map[string]interface{}{"type": "integer", "minimum": 1, "maximum": maxRetryAttempts},
+			"backoff":          enumSchema(string(apistructs.PipelineTaskRetryBackoffFixed), string(apistructs.PipelineTaskRetryBackoffExponential)),
+			"interval_sec":     integerSchema(),
+			"max_interval_sec": integerSchema(),
+			"when":             map[string]interface{}{"type": "array", "items": enumSchema(retryReasons...)},
+			"exit_codes":       map[string]interface{}{"type": "array", "items": integerSchema()},
+		}),
+		"coverage": objectSchema(map[string]interface{}{
+			"format": enumSchema(string(apistructs.CoverageFormatCobertura), string(apistructs.CoverageFormatJaCoCo),
+				string(apistructs.CoverageFormatLCOV), string(apistructs.CoverageFormatGoCover)),
+			"path": stringSchema(),
+		}),
+		"timeout": timeoutSchema(),
+		"resources": objectSchema(map[string]interface{}{
+			"cpu":     numberSchema(),
+			"max_cpu": numberSchema(),
+			"mem":     integerSchema(),
+			"disk":    integerSchema(),
+			"network": map[string]interface{}{"type": "object", "additionalProperties": stringSchema()},
+		}),
+		"caches": map[string]interface{}{
+			"type": "array",
+			"items": objectSchema(map[string]interface{}{
+				"key":          stringSchema(),
+				"path":         stringSchema(),
+				"restore_keys": stringArraySchema(),
+			}),
+		},
+		"snippet_config": objectSchema(map[string]interface{}{
+			"source": stringSchema(),
+			"name":   stringSchema(),
+			"labels": map[string]interface{}{"type": "object", "additionalProperties": stringSchema()},
+		}),
+		"if": stringSchema(),
+		"matrix": objectSchema(map[string]interface{}{
+			"dimensions":   map[string]interface{}{"type": "object", "additionalProperties": stringArraySchema()},
+			"include":      map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "object"}},
+			"exclude":      map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "object"}},
+			"max-parallel": integerSchema(),
+		}),
+		"needs":      stringArraySchema(),
+		"namespaces": stringArraySchema(),
+	})
+	if spec != nil && spec.Desc != "" {
+		schema["description"] = spec.Desc
+	}
+	return schema
+}
+
+// paramsSchema 根据 spec.yml 中的 params 生成 schema
+func paramsSchema(specParams []apistructs.ActionSpecParam) map[string]interface{} {
+	properties := make(map[string]interface{}, len(specParams))
+	var required []string
+	for _, p := range specParams {
+		properties[p.Name] = paramSchema(p)
+		if p.Required && p.Default == nil {
+			required = append(required, p.Name)
+		}
+	}
+	schema := objectSchema(properties)
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func paramSchema(p apistructs.ActionSpecParam) map[string]interface{} {
+	var schema map[string]interface{}
+	switch t := strings.ToLower(p.Type); {
+	case t == "" || t == "string":
+		schema = stringSchema()
+	case t == "int" || t == "integer" || t == "long":
+		schema = integerSchema()
+	case t == "float" || t == "double" || t == "number":
+		schema = numberSchema()
+	case t == "bool" || t == "boolean":
+		schema = booleanSchema()
+	case t == "map":
+		schema = map[string]interface{}{"type": "object"}
+	case t == "struct":
+		schema = paramsSchema(p.Struct)
+	case t == "struct_array":
+		schema = map[string]interface{}{"type": "array", "items": paramsSchema(p.Struct)}
+	case strings.HasSuffix(t, "_array") || t == "array":
+		items := map[string]interface{}{}
+		if elem := strings.TrimSuffix(t, "_array"); elem != t {
+			items = paramSchema(apistructs.ActionSpecParam{Type: elem})
+		}
+		schema = map[string]interface{}{"type": "array", "items": items}
+	default:
+		schema = map[string]interface{}{}
+	}
+	// 非 string 类型允许使用占位符
+	if schemaType, ok := schema["type"]; ok && schemaType != "string" {
+		schema = map[string]interface{}{
+			"anyOf": []interface{}{schema, map[string]interface{}{"type": "string", "pattern": placeholderPattern}},
+		}
+	}
+	if p.Desc != "" {
+		schema["description"] = p.Desc
+	}
+	if p.Default != nil {
+		schema["default"] = p.Default
+	}
+	return schema
+}
+
+func objectSchema(properties map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "object", "properties": properties, "additionalProperties": false}
+}
+
+func enumSchema(values ...string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "enum": values}
+}
+
+func timeoutSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "integer", "minimum": TimeoutDuration4Forever, "description": "unit: second, -1 means forever"}
+}
+
+func concurrencySchema() map[string]interface{} {
+	schema := objectSchema(map[string]interface{}{
+		"group":              map[string]interface{}{"type": "string", "minLength": 1, "description": "concurrency group, e.g. ${{ branch }}"},
+		"cancel_in_progress": booleanSchema(),
+	})
+	schema["required"] = []string{"group"}
+	return schema
+}
+
+func stringSchema() map[string]interface{}  { return map[string]interface{}{"type": "string"} }
+func integerSchema() map[string]interface{} { return map[string]interface{}{"type": "integer"} }
+func numberSchema() map[string]interface{}  { return map[string]interface{}{"type": "number"} }
+func booleanSchema() map[string]interface{} { return map[string]interface{}{"type": "boolean"} }
+func stringArraySchema() map[string]interface{} {
+	return map[string]interface{}{"type": "array", "items": stringSchema()}
+}
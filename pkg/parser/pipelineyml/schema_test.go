@@ -0,0 +1,61 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelineyml
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+func TestGenerateJSONSchema(t *testing.T) {
+	schema := GenerateJSONSchema(map[string]*apistructs.ActionSpec{
+		"git-checkout": {Params: []apistructs.ActionSpecParam{
+			{Name: "uri", Required: true},
+			{Name: "depth", Type: "int"},
+			{Name: "branch", Required: true, Default: "master"},
+		}},
+		"not-exist": nil,
+	})
+	_, err := json.Marshal(schema)
+	assert.NoError(t, err)
+
+	stages := schema["properties"].(map[string]interface{})["stages"].(map[string]interface{})
+	stage := stages["items"].(map[string]interface{})["properties"].(map[string]interface{})["stage"].(map[string]interface{})
+	typedActions := stage["items"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Len(t, typedActions, 1)
+
+	params := typedActions["git-checkout"].(map[string]interface{})["properties"].(map[string]interface{})["params"].(map[string]interface{})
+	assert.Equal(t, []string{"uri"}, params["required"])
+	assert.Equal(t, false, params["additionalProperties"])
+	depth := params["properties"].(map[string]interface{})["depth"].(map[string]interface{})
+	assert.Len(t, depth["anyOf"], 2)
+}
+
+func TestParamSchema(t *testing.T) {
+	s := paramSchema(apistructs.ActionSpecParam{Type: "string_array"})
+	assert.Len(t, s["anyOf"], 2)
+
+	s = paramSchema(apistructs.ActionSpecParam{Type: "struct", Struct: []apistructs.ActionSpecParam{{Name: "a", Required: true}}})
+	assert.Len(t, s["anyOf"], 2)
+	structSchema := s["anyOf"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, []string{"a"}, structSchema["required"])
+
+	s = paramSchema(apistructs.ActionSpecParam{Desc: "uri", Default: "x"})
+	assert.Equal(t, "string", s["type"])
+	assert.Equal(t, "x", s["default"])
+}
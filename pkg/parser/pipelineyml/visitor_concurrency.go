@@ -0,0 +1,122 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelineyml
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/erda-project/erda/pkg/parser/pipelineyml/pexpr"
+	"github.com/erda-project/erda/pkg/strutil"
+)
+
+// concurrency group 中可以引用的上下文
+const (
+	ConcurrencyGroupContextBranch    = "branch"
+	ConcurrencyGroupContextSource    = "source"
+	ConcurrencyGroupContextYmlName   = "yml_name"
+	ConcurrencyGroupContextWorkspace = "workspace"
+	ConcurrencyGroupContextAppID     = "app_id"
+	ConcurrencyGroupContextProjectID = "project_id"
+	ConcurrencyGroupContextOrgID     = "org_id"
+
+	concurrencyGroupContextParamsPrefix = "params."
+	concurrencyGroupContextEnvsPrefix   = "envs."
+)
+
+var concurrencyGroupContexts = []string{
+	ConcurrencyGroupContextBranch, ConcurrencyGroupContextSource, ConcurrencyGroupContextYmlName, ConcurrencyGroupContextWorkspace,
+	ConcurrencyGroupContextAppID, ConcurrencyGroupContextProjectID, ConcurrencyGroupContextOrgID,
+}
+
+// ConcurrencyConfig 并发组配置，同一并发组内同时只有一条流水线在运行
+type ConcurrencyConfig struct {
+	// Group 并发组，支持占位符，例如 ${{ branch }}
+	Group string `yaml:"group"`
+	// CancelInProgress 为 true 时取消组内运行中的流水线，否则新流水线排队等待
+	CancelInProgress bool `yaml:"cancel_in_progress,omitempty"`
+}
+
+type ConcurrencyVisitor struct{}
+
+func NewConcurrencyVisitor() *ConcurrencyVisitor {
+	return &ConcurrencyVisitor{}
+}
+
+func (v *ConcurrencyVisitor) Visit(s *Spec) {
+	if s.Concurrency == nil {
+		return
+	}
+	if strings.TrimSpace(s.Concurrency.Group) == "" {
+		s.appendError(errors.New("missing concurrency group"), yamlField("concurrency"))
+		return
+	}
+	if invalidPhs := pexpr.FindInvalidPlaceholders(s.Concurrency.Group); len(invalidPhs) > 0 {
+		s.appendError(errors.Errorf("invalid concurrency group placeholders: %s (must match: %s)",
+			strings.Join(invalidPhs, ", "), pexpr.PhRe.String()), yamlField("concurrency.group"))
+		return
+	}
+	for _, subs := range pexpr.PhRe.FindAllStringSubmatch(s.Concurrency.Group, -1) {
+		if !isValidConcurrencyGroupContext(subs[1]) {
+			s.appendError(errors.Errorf("unknown concurrency group context: %s (only %s, params.xxx, envs.xxx)",
+				subs[1], strings.Join(concurrencyGroupContexts, ", ")), yamlField("concurrency.group"))
+		}
+	}
+}
+
+func isValidConcurrencyGroupContext(key string) bool {
+	if strutil.Exist(concurrencyGroupContexts, key) {
+		return true
+	}
+	for _, prefix := range []string{concurrencyGroupContextParamsPrefix, concurrencyGroupContextEnvsPrefix} {
+		if strings.HasPrefix(key, prefix) && len(key) > len(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// EvalConcurrencyGroup 使用上下文渲染并发组中的占位符，上下文中不存在的占位符返回错误。
+// contexts 的 key 为占位符内容，例如 branch、params.key、envs.key
+func EvalConcurrencyGroup(group string, contexts map[string]string) (string, error) {
+	var notFound []string
+	result := strutil.ReplaceAllStringSubmatchFunc(pexpr.PhRe, group, func(subs []string) string {
+		v, ok := contexts[subs[1]]
+		if !ok {
+			notFound = append(notFound, subs[0])
+			return subs[0]
+		}
+		return v
+	})
+	if len(notFound) > 0 {
+		return "", fmt.Errorf("failed to eval concurrency group %q, not found: %s", group, strings.Join(notFound, ", "))
+	}
+	result = strings.TrimSpace(result)
+	if result == "" {
+		return "", fmt.Errorf("concurrency group %q is empty after eval", group)
+	}
+	return result, nil
+}
+
+// MakeConcurrencyGroupParamsContextKey 生成流水线入参在并发组上下文中的 key
+func MakeConcurrencyGroupParamsContextKey(name string) string {
+	return concurrencyGroupContextParamsPrefix + name
+}
+
+// MakeConcurrencyGroupEnvsContextKey 生成环境变量在并发组上下文中的 key
+func MakeConcurrencyGroupEnvsContextKey(name string) string {
+	return concurrencyGroupContextEnvsPrefix + name
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelineyml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyVisitor_Visit(t *testing.T) {
+	y, err := New([]byte(`version: "1.1"
+concurrency:
+  group: deploy-${{ branch }}-${{ params.env }}
+  cancel_in_progress: true
+stages:
+  - stage:
+      - custom-script:
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, "deploy-${{ branch }}-${{ params.env }}", y.Spec().Concurrency.Group)
+	assert.True(t, y.Spec().Concurrency.CancelInProgress)
+
+	invalids := []string{
+		"concurrency:\n  cancel_in_progress: true\n",
+		"concurrency:\n  group: ${{branch}}\n",
+		"concurrency:\n  group: ${{ outputs.a.b }}\n",
+		"concurrency:\n  group: ${{ params. }}\n",
+	}
+	for _, invalid := range invalids {
+		_, err := New([]byte("version: \"1.1\"\n" + invalid + "stages:\n  - stage:\n      - custom-script:\n"))
+		assert.Error(t, err, invalid)
+	}
+
+	// 诊断定位到 group 字段
+	diagnostics := Lint([]byte("version: \"1.1\"\nconcurrency:\n  group: ${{ unknown }}\nstages:\n  - stage:\n      - custom-script:\n"), nil)
+	if assert.Len(t, diagnostics, 1) {
+		assert.Equal(t, 3, diagnostics[0].Range.Start.Line)
+	}
+}
+
+func TestEvalConcurrencyGroup(t *testing.T) {
+	contexts := map[string]string{
+		ConcurrencyGroupContextBranch:               "feature/a",
+		MakeConcurrencyGroupParamsContextKey("env"): "test",
+	}
+	group, err := EvalConcurrencyGroup("deploy-${{ branch }}-${{ params.env }}", contexts)
+	assert.NoError(t, err)
+	assert.Equal(t, "deploy-feature/a-test", group)
+
+	group, err = EvalConcurrencyGroup("static", contexts)
+	assert.NoError(t, err)
+	assert.Equal(t, "static", group)
+
+	_, err = EvalConcurrencyGroup("${{ envs.A }}", contexts)
+	assert.Error(t, err)
+
+	_, err = EvalConcurrencyGroup(" ${{ branch }} ", map[string]string{ConcurrencyGroupContextBranch: ""})
+	assert.Error(t, err)
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelineyml
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+type CoverageVisitor struct{}
+
+func NewCoverageVisitor() *CoverageVisitor {
+	return &CoverageVisitor{}
+}
+
+func (v *CoverageVisitor) Visit(s *Spec) {
+	for stageIndex, stage := range s.Stages {
+		for _, typedActionMap := range stage.Actions {
+			for _, action := range typedActionMap {
+				if action.Coverage == nil {
+					continue
+				}
+				if !action.Coverage.Format.Valid() {
+					s.appendError(errors.Errorf("invalid coverage format: %s (only %s, %s, %s, %s)", action.Coverage.Format,
+						apistructs.CoverageFormatCobertura, apistructs.CoverageFormatJaCoCo, apistructs.CoverageFormatLCOV, apistructs.CoverageFormatGoCover),
+						stageIndex, action.Alias, yamlField("coverage.format"))
+				}
+				if action.Coverage.Path == "" {
+					s.appendError(errors.New("missing coverage path"), stageIndex, action.Alias, yamlField("coverage"))
+				}
+			}
+		}
+	}
+}
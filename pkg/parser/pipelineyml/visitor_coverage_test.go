@@ -0,0 +1,52 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelineyml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+func TestCoverageVisitor_Visit(t *testing.T) {
+	y, err := New([]byte(`version: "1.1"
+stages:
+  - stage:
+      - custom-script:
+          commands:
+            - go test -coverprofile=coverage.out ./...
+          coverage:
+            format: gocover
+            path: coverage.out
+`))
+	assert.NoError(t, err)
+	action, err := GetAction(y.Spec(), "custom-script")
+	assert.NoError(t, err)
+	assert.Equal(t, &apistructs.ActionCoverage{Format: apistructs.CoverageFormatGoCover, Path: "coverage.out"}, action.Coverage)
+
+	invalids := []string{
+		`{format: clover, path: clover.xml}`,
+		`{format: jacoco}`,
+	}
+	for _, coverage := range invalids {
+		_, err := New([]byte(`version: "1.1"
+stages:
+  - stage:
+      - custom-script:
+          coverage: ` + coverage + "\n"))
+		assert.Error(t, err, coverage)
+	}
+}
@@ -14,9 +14,11 @@
 package pipelineyml
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
+	"github.com/erda-project/erda/apistructs"
 	"github.com/erda-project/erda/pkg/cron"
 )
 
@@ -73,6 +75,7 @@ func WithListNextScheduleCount(count int) CronVisitorOption {
 func (v *CronVisitor) Visit(s *Spec) {
 	if s.Cron == "" {
 		s.CronCompensator = nil
+		s.ConcurrencyPolicy = ""
 		v.isCron = false
 		return
 	}
@@ -95,7 +98,7 @@ func (v *CronVisitor) Visit(s *Spec) {
 		schedule, err = cron.ParseStandard(s.Cron)
 	}
 	if err != nil {
-		s.appendError(err)
+		s.appendError(err, yamlField("cron"))
 		return
 	}
 
@@ -124,6 +127,15 @@ func (v *CronVisitor) Visit(s *Spec) {
 	if s.CronCompensator == nil {
 		s.CronCompensator = &DefaultCronCompensator
 	}
+
+	// concurrency policy
+	if s.ConcurrencyPolicy == "" {
+		s.ConcurrencyPolicy = apistructs.PipelineCronConcurrencyPolicyAllow.String()
+	}
+	if !apistructs.PipelineCronConcurrencyPolicy(s.ConcurrencyPolicy).Valid() {
+		s.appendError(fmt.Errorf("invalid concurrency_policy: %s, only support: %s, %s, %s", s.ConcurrencyPolicy,
+			apistructs.PipelineCronConcurrencyPolicyAllow, apistructs.PipelineCronConcurrencyPolicyForbid, apistructs.PipelineCronConcurrencyPolicyReplace), yamlField("concurrency_policy"))
+	}
 }
 
 func ListNextCronTime(cronExpr string, ops ...CronVisitorOption) ([]time.Time, error) {
@@ -102,3 +102,26 @@ func TestListNextCronTime2(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, len(nextTimes) == 9)
 }
+
+func TestCronVisitor_ConcurrencyPolicy(t *testing.T) {
+	// 未配置时默认为 Allow
+	s := Spec{Cron: everyMin}
+	s.Accept(NewCronVisitor())
+	assert.NoError(t, s.mergeErrors())
+	assert.Equal(t, "Allow", s.ConcurrencyPolicy)
+
+	s = Spec{Cron: everyMin, ConcurrencyPolicy: "Forbid"}
+	s.Accept(NewCronVisitor())
+	assert.NoError(t, s.mergeErrors())
+	assert.Equal(t, "Forbid", s.ConcurrencyPolicy)
+
+	s = Spec{Cron: everyMin, ConcurrencyPolicy: "forbid"}
+	s.Accept(NewCronVisitor())
+	assert.Error(t, s.mergeErrors())
+
+	// 非定时流水线忽略该配置
+	s = Spec{ConcurrencyPolicy: "Replace"}
+	s.Accept(NewCronVisitor())
+	assert.NoError(t, s.mergeErrors())
+	assert.Equal(t, "", s.ConcurrencyPolicy)
+}
@@ -0,0 +1,373 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelineyml
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/erda-project/erda/pkg/expression"
+)
+
+const (
+	// maxMatrixCombinations 单个 action 矩阵展开的最大组合数
+	maxMatrixCombinations = 256
+)
+
+var (
+	// 匹配 ${{ matrix.xxx }}
+	matrixPlaceholderRe = regexp.MustCompile(`\$\{\{\s*` + expression.Matrix + `\.([^\s{}]+)\s*\}\}`)
+	// alias 中维度值只保留的字符
+	matrixAliasInvalidCharRe = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+)
+
+// MatrixVisitor 将声明了 matrix 的 action 展开为多个具体的 action。
+// 展开后的 action 位于原 action 所在 stage，alias 唯一，维度值通过 ${{ matrix.xxx }} 渲染到 params、commands 等字段中。
+// 必须在 StageVisitor 之前执行。
+type MatrixVisitor struct{}
+
+func NewMatrixVisitor() *MatrixVisitor {
+	return &MatrixVisitor{}
+}
+
+func (v *MatrixVisitor) Visit(s *Spec) {
+	s.matrixOrigins = nil
+	s.matrixActions = nil
+
+	for stageIndex, stage := range s.Stages {
+		if stage == nil {
+			continue
+		}
+		var expandedActions []typedActionMap
+		for _, typedActionMap := range stage.Actions {
+			var matrixAction *Action
+			var matrixActionType ActionType
+			for actionType, action := range typedActionMap {
+				if action != nil && action.Matrix != nil {
+					matrixAction, matrixActionType = action, actionType
+				}
+			}
+			// 非 matrix action 或缩进错误的情况由 StageVisitor 处理
+			if matrixAction == nil || len(typedActionMap) > 1 {
+				expandedActions = append(expandedActions, typedActionMap)
+				continue
+			}
+
+			if matrixAction.Alias == "" {
+				matrixAction.Alias = ActionAlias(matrixActionType)
+			}
+			matrixAction.Type = matrixActionType
+			if matrixActionType.IsSnippet() {
+				s.appendError(errors.New("matrix is not supported for snippet action"), stageIndex, matrixAction.Alias, yamlField("matrix"))
+				expandedActions = append(expandedActions, typedActionMap)
+				continue
+			}
+			combinations, err := matrixAction.Matrix.combinations()
+			if err != nil {
+				s.appendError(errors.Errorf("invalid matrix, err: %v", err), stageIndex, matrixAction.Alias, yamlField("matrix"))
+				expandedActions = append(expandedActions, typedActionMap)
+				continue
+			}
+
+			if s.matrixOrigins == nil {
+				s.matrixOrigins = make(map[ActionAlias]*Action)
+				s.matrixActions = make(map[ActionAlias][]ActionAlias)
+			}
+			s.matrixOrigins[matrixAction.Alias] = matrixAction
+			usedAliases := make(map[ActionAlias]struct{}, len(combinations))
+			for i, values := range combinations {
+				expanded := matrixAction.expandMatrix(i, values, usedAliases)
+				s.matrixActions[matrixAction.Alias] = append(s.matrixActions[matrixAction.Alias], expanded.Alias)
+				expandedActions = append(expandedActions, map[ActionType]*Action{matrixActionType: expanded})
+			}
+		}
+		stage.Actions = expandedActions
+	}
+}
+
+// combinations 计算矩阵的所有组合，维度按照名称排序后做笛卡尔积，再处理 exclude 和 include。
+func (m *ActionMatrix) combinations() ([]map[string]string, error) {
+	if m.MaxParallel < 0 {
+		return nil, errors.Errorf("invalid max-parallel: %d", m.MaxParallel)
+	}
+
+	var keys []string
+	for key, values := range m.Dimensions {
+		if len(values) == 0 {
+			return nil, errors.Errorf("dimension %q doesn't have any values", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var combinations []map[string]string
+	if len(keys) > 0 {
+		combinations = []map[string]string{{}}
+		for _, key := range keys {
+			var next []map[string]string
+			for _, c := range combinations {
+				for _, value := range m.Dimensions[key] {
+					n := make(map[string]string, len(c)+1)
+					for k, v := range c {
+						n[k] = v
+					}
+					n[key] = value
+					next = append(next, n)
+				}
+			}
+			combinations = next
+			if len(combinations) > maxMatrixCombinations {
+				return nil, errors.Errorf("too many combinations, max: %d", maxMatrixCombinations)
+			}
+		}
+	}
+
+	// exclude: 组合包含 exclude 中的所有键值时被排除
+	var result []map[string]string
+	for _, c := range combinations {
+		excluded := false
+		for _, ex := range m.Exclude {
+			if len(ex) > 0 && matchMatrixValues(c, ex) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			result = append(result, c)
+		}
+	}
+
+	// include: 与已有组合的维度值一致时，为其追加额外的值；否则作为新组合追加
+	for _, in := range m.Include {
+		if len(in) == 0 {
+			continue
+		}
+		matched := false
+		for _, c := range result {
+			if matchMatrixDimensions(c, in, keys) {
+				for k, v := range in {
+					c[k] = v
+				}
+				matched = true
+			}
+		}
+		if !matched {
+			n := make(map[string]string, len(in))
+			for k, v := range in {
+				n[k] = v
+			}
+			result = append(result, n)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, errors.New("no combination left")
+	}
+	if len(result) > maxMatrixCombinations {
+		return nil, errors.Errorf("too many combinations, max: %d", maxMatrixCombinations)
+	}
+	return result, nil
+}
+
+// matchMatrixValues 判断组合 c 是否包含 target 中的所有键值
+func matchMatrixValues(c, target map[string]string) bool {
+	for k, v := range target {
+		if cv, ok := c[k]; !ok || cv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// matchMatrixDimensions 判断 include 项 in 是否声明了维度值，且所有维度值与组合 c 一致
+func matchMatrixDimensions(c, in map[string]string, keys []string) bool {
+	hasDimension := false
+	for _, key := range keys {
+		v, ok := in[key]
+		if !ok {
+			continue
+		}
+		hasDimension = true
+		if c[key] != v {
+			return false
+		}
+	}
+	return hasDimension
+}
+
+// expandMatrix 根据组合生成展开后的 action
+func (action *Action) expandMatrix(index int, values map[string]string, usedAliases map[ActionAlias]struct{}) *Action {
+	var keys []string
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	// alias: <alias>-<value1>-<value2>...
+	aliasParts := []string{action.Alias.String()}
+	for _, k := range keys {
+		if part := strings.Trim(matrixAliasInvalidCharRe.ReplaceAllString(values[k], "-"), "-"); part != "" {
+			aliasParts = append(aliasParts, part)
+		}
+	}
+	alias := ActionAlias(strings.Join(aliasParts, "-"))
+	if _, ok := usedAliases[alias]; ok || len(aliasParts) == 1 {
+		alias = ActionAlias(fmt.Sprintf("%s-%d", alias, index+1))
+	}
+	usedAliases[alias] = struct{}{}
+
+	expanded := &Action{
+		Alias:         alias,
+		Description:   renderMatrixString(action.Description, values),
+		Version:       action.Version,
+		Params:        nil,
+		Labels:        nil,
+		Workspace:     renderMatrixString(action.Workspace, values),
+		Image:         renderMatrixString(action.Image, values),
+		Commands:      nil,
+		Loop:          action.Loop,
+		Retry:         action.Retry,
+		Coverage:      action.Coverage,
+		Timeout:       action.Timeout,
+		Resources:     action.Resources,
+		Type:          action.Type,
+		SnippetConfig: action.SnippetConfig,
+		If:            renderMatrixString(action.If, values),
+		DeclaredNeeds: action.DeclaredNeeds,
+		MatrixOrigin: &MatrixOrigin{
+			Alias:       action.Alias,
+			Index:       index,
+			Values:      values,
+			MaxParallel: action.Matrix.MaxParallel,
+		},
+	}
+	if action.Params != nil {
+		expanded.Params = renderMatrixValue(action.Params, values).(map[string]interface{})
+	}
+	if action.Labels != nil {
+		expanded.Labels = make(map[string]string, len(action.Labels))
+		for k, v := range action.Labels {
+			expanded.Labels[k] = renderMatrixString(v, values)
+		}
+	}
+	for _, command := range action.Commands {
+		expanded.Commands = append(expanded.Commands, renderMatrixString(command, values))
+	}
+	for _, cache := range action.Caches {
+		var restoreKeys []string
+		for _, restoreKey := range cache.RestoreKeys {
+			restoreKeys = append(restoreKeys, renderMatrixString(restoreKey, values))
+		}
+		expanded.Caches = append(expanded.Caches, ActionCache{
+			Key:         renderMatrixString(cache.Key, values),
+			Path:        renderMatrixString(cache.Path, values),
+			RestoreKeys: restoreKeys,
+		})
+	}
+	// namespace 按序号加后缀，避免展开后的 actions 声明重复的 namespace
+	for _, ns := range action.Namespaces {
+		expanded.Namespaces = append(expanded.Namespaces, fmt.Sprintf("%s-%d", renderMatrixString(ns, values), index+1))
+	}
+	return expanded
+}
+
+// renderMatrixString 渲染字符串中的 ${{ matrix.xxx }}，不存在的维度保持原样
+func renderMatrixString(str string, values map[string]string) string {
+	return matrixPlaceholderRe.ReplaceAllStringFunc(str, func(ph string) string {
+		key := matrixPlaceholderRe.FindStringSubmatch(ph)[1]
+		if v, ok := values[key]; ok {
+			return v
+		}
+		return ph
+	})
+}
+
+// renderMatrixValue 递归渲染 params 中的 ${{ matrix.xxx }}，返回新的值，不修改原值
+func renderMatrixValue(i interface{}, values map[string]string) interface{} {
+	switch x := i.(type) {
+	case string:
+		return renderMatrixString(x, values)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(x))
+		for k, v := range x {
+			m[k] = renderMatrixValue(v, values)
+		}
+		return m
+	case map[interface{}]interface{}:
+		m := make(map[interface{}]interface{}, len(x))
+		for k, v := range x {
+			m[k] = renderMatrixValue(v, values)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, 0, len(x))
+		for _, v := range x {
+			l = append(l, renderMatrixValue(v, values))
+		}
+		return l
+	default:
+		return i
+	}
+}
+
+// collapsedStages 返回将 matrix 展开的 actions 还原为原始 action 后的 stages
+func (s *Spec) collapsedStages() []*Stage {
+	if len(s.matrixOrigins) == 0 {
+		return s.Stages
+	}
+	stages := make([]*Stage, 0, len(s.Stages))
+	for _, stage := range s.Stages {
+		if stage == nil {
+			stages = append(stages, stage)
+			continue
+		}
+		collapsed := &Stage{Timeout: stage.Timeout}
+		collapsedOrigins := make(map[ActionAlias]struct{})
+		for _, typedActionMap := range stage.Actions {
+			var origin *Action
+			var originType ActionType
+			for actionType, action := range typedActionMap {
+				if action != nil && action.MatrixOrigin != nil {
+					origin, originType = s.matrixOrigins[action.MatrixOrigin.Alias], actionType
+				}
+			}
+			if origin == nil {
+				collapsed.Actions = append(collapsed.Actions, typedActionMap)
+				continue
+			}
+			if _, ok := collapsedOrigins[origin.Alias]; ok {
+				continue
+			}
+			collapsedOrigins[origin.Alias] = struct{}{}
+			collapsed.Actions = append(collapsed.Actions, map[ActionType]*Action{originType: origin})
+		}
+		stages = append(stages, collapsed)
+	}
+	return stages
+}
+
+// MatrixExpandedActions 返回 matrix action 展开后的 actions
+func (s *Spec) MatrixExpandedActions(alias ActionAlias) []*Action {
+	var actions []*Action
+	for _, expandedAlias := range s.matrixActions[alias] {
+		if action, ok := s.allActions[expandedAlias]; ok {
+			actions = append(actions, action.Action)
+		}
+	}
+	return actions
+}
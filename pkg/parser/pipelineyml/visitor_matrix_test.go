@@ -0,0 +1,147 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelineyml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const matrixYml = `version: "1.1"
+stages:
+  - stage:
+      - git-checkout:
+          alias: repo
+  - stage:
+      - custom-script:
+          alias: test
+          image: openjdk:${{ matrix.jdk }}
+          commands:
+            - echo ${{ matrix.jdk }} ${{ matrix.os }}
+          params:
+            target: ${{ matrix.os }}
+          namespaces:
+            - test-result
+          matrix:
+            dimensions:
+              jdk: [8, 11]
+              os: [linux, windows]
+            exclude:
+              - jdk: 8
+                os: windows
+            include:
+              - jdk: 17
+                os: linux
+            max-parallel: 2
+  - stage:
+      - custom-script:
+          alias: report
+          needs: [test]
+`
+
+func TestMatrixVisitor_Visit(t *testing.T) {
+	y, err := New([]byte(matrixYml))
+	assert.NoError(t, err)
+
+	expanded := y.Spec().MatrixExpandedActions("test")
+	var aliases []ActionAlias
+	for _, action := range expanded {
+		aliases = append(aliases, action.Alias)
+	}
+	assert.Equal(t, []ActionAlias{"test-8-linux", "test-11-linux", "test-11-windows", "test-17-linux"}, aliases)
+
+	first := expanded[0]
+	assert.Equal(t, "openjdk:8", first.Image)
+	assert.Equal(t, []string{"echo 8 linux"}, first.Commands)
+	assert.Equal(t, "linux", first.Params["target"])
+	assert.Equal(t, map[string]string{"jdk": "8", "os": "linux"}, first.MatrixOrigin.Values)
+	assert.Contains(t, first.Namespaces, "test-result-1")
+	assert.Contains(t, expanded[3].Namespaces, "test-result-4")
+
+	// max-parallel 由调度限制，不增加依赖
+	third := expanded[2]
+	assert.Equal(t, []ActionAlias{"repo"}, third.Needs)
+	assert.Equal(t, 2, third.MatrixOrigin.MaxParallel)
+
+	// needs matrix action means needs all expanded actions
+	report, err := GetAction(y.Spec(), "report")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, aliases, report.Needs)
+
+	// generated yml keeps the matrix declaration
+	b, err := GenerateYml(y.Spec())
+	assert.NoError(t, err)
+	regenerated, err := New(b)
+	assert.NoError(t, err)
+	assert.Len(t, regenerated.Spec().MatrixExpandedActions("test"), 4)
+	assert.Len(t, regenerated.Spec().Stages[1].Actions, 4)
+
+	// graph shows the matrix action with expanded actions
+	graph, err := ConvertToGraphPipelineYml([]byte(matrixYml))
+	assert.NoError(t, err)
+	assert.Len(t, graph.Stages[1], 1)
+	assert.NotNil(t, graph.Stages[1][0].Matrix)
+	assert.Len(t, graph.Stages[1][0].MatrixActions, 4)
+}
+
+func TestMatrixVisitor_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		yml  string
+	}{
+		{
+			name: "empty dimension",
+			yml: `version: "1.1"
+stages:
+  - stage:
+      - custom-script:
+          matrix:
+            dimensions:
+              jdk: []
+`,
+		},
+		{
+			name: "all excluded",
+			yml: `version: "1.1"
+stages:
+  - stage:
+      - custom-script:
+          matrix:
+            dimensions:
+              jdk: [8]
+            exclude:
+              - jdk: 8
+`,
+		},
+		{
+			name: "invalid max-parallel",
+			yml: `version: "1.1"
+stages:
+  - stage:
+      - custom-script:
+          matrix:
+            dimensions:
+              jdk: [8]
+            max-parallel: -1
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New([]byte(tt.yml))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelineyml
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/erda-project/erda/pkg/dag"
+)
+
+// NeedsVisitor 校验 action 显式声明的 needs，并计算对应的 Needs 与 NeedNamespaces。
+// 必须在 StageVisitor 之后执行。
+type NeedsVisitor struct{}
+
+func NewNeedsVisitor() *NeedsVisitor {
+	return &NeedsVisitor{}
+}
+
+func (v *NeedsVisitor) Visit(s *Spec) {
+	if len(s.allActions) == 0 {
+		return
+	}
+
+	// validate declared needs
+	var hasDeclared, invalid bool
+	for stageIndex, stage := range s.Stages {
+		for _, typedActionMap := range stage.Actions {
+			for _, action := range typedActionMap {
+				if action == nil || action.DeclaredNeeds == nil {
+					continue
+				}
+				hasDeclared = true
+				for _, need := range action.DeclaredNeeds {
+					if need == action.Alias || (action.MatrixOrigin != nil && need == action.MatrixOrigin.Alias) {
+						s.appendError(errors.Errorf("needs itself"), stageIndex, action.Alias, yamlField("needs"))
+						invalid = true
+						continue
+					}
+					if _, ok := s.matrixActions[need]; ok {
+						continue
+					}
+					if _, ok := s.allActions[need]; !ok {
+						s.appendError(errors.Errorf("needs an unknown action %q", need), stageIndex, action.Alias, yamlField("needs"))
+						invalid = true
+					}
+				}
+			}
+		}
+	}
+	if !hasDeclared || invalid {
+		return
+	}
+
+	// update needs
+	for _, action := range s.allActions {
+		if action.DeclaredNeeds == nil {
+			continue
+		}
+		// 依赖 matrix action 即依赖其展开后的所有 actions
+		var needs []ActionAlias
+		for _, need := range action.DeclaredNeeds {
+			if expanded, ok := s.matrixActions[need]; ok {
+				needs = append(needs, expanded...)
+				continue
+			}
+			needs = append(needs, need)
+		}
+		action.Needs = dedupActionAliases(needs)
+	}
+
+	// cycle check
+	var nodes []dag.NamedNode
+	s.LoopStagesActions(func(stage int, action *Action) {
+		nodes = append(nodes, &needsNode{action: action})
+	})
+	if _, err := dag.New(nodes); err != nil {
+		s.appendError(errors.Errorf("invalid needs, err: %v", err))
+		return
+	}
+
+	// update needNamespaces
+	for _, action := range s.allActions {
+		if action.DeclaredNeeds == nil {
+			continue
+		}
+		namespaces := make(map[string]struct{})
+		visited := make(map[ActionAlias]struct{})
+		collectUpstreamNamespaces(s, action.Action, visited, namespaces)
+		action.NeedNamespaces = toListStr(namespaces)
+		sort.Strings(action.NeedNamespaces)
+	}
+}
+
+// collectUpstreamNamespaces 收集 action 直接及间接依赖的 actions 的 namespaces
+func collectUpstreamNamespaces(s *Spec, action *Action, visited map[ActionAlias]struct{}, namespaces map[string]struct{}) {
+	for _, need := range action.Needs {
+		if _, ok := visited[need]; ok {
+			continue
+		}
+		visited[need] = struct{}{}
+		upstream, ok := s.allActions[need]
+		if !ok {
+			continue
+		}
+		for _, ns := range upstream.Namespaces {
+			namespaces[ns] = struct{}{}
+		}
+		collectUpstreamNamespaces(s, upstream.Action, visited, namespaces)
+	}
+}
+
+func dedupActionAliases(aliases []ActionAlias) []ActionAlias {
+	result := make([]ActionAlias, 0, len(aliases))
+	seen := make(map[ActionAlias]struct{}, len(aliases))
+	for _, alias := range aliases {
+		if _, ok := seen[alias]; ok {
+			continue
+		}
+		seen[alias] = struct{}{}
+		result = append(result, alias)
+	}
+	return result
+}
+
+// needsNode implements dag.NamedNode
+type needsNode struct {
+	action *Action
+}
+
+func (n *needsNode) NodeName() string {
+	return n.action.Alias.String()
+}
+
+func (n *needsNode) PrevNodeNames() []string {
+	var prevs []string
+	for _, need := range n.action.Needs {
+		prevs = append(prevs, need.String())
+	}
+	return prevs
+}
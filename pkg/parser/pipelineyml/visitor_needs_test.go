@@ -0,0 +1,131 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelineyml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNeedsVisitor_Visit(t *testing.T) {
+	yml := `version: "1.1"
+stages:
+  - stage:
+      - git-checkout:
+          alias: repo
+  - stage:
+      - custom-script:
+          alias: build-a
+          needs: [repo]
+      - custom-script:
+          alias: build-b
+      - custom-script:
+          alias: lint
+          needs: []
+  - stage:
+      - custom-script:
+          alias: deploy
+          needs: [build-a, lint]
+      - custom-script:
+          alias: notify
+`
+	y, err := New([]byte(yml))
+	assert.NoError(t, err)
+
+	buildA, err := GetAction(y.Spec(), "build-a")
+	assert.NoError(t, err)
+	assert.Equal(t, []ActionAlias{"repo"}, buildA.Needs)
+	assert.Equal(t, []string{"repo"}, buildA.NeedNamespaces)
+
+	lint, err := GetAction(y.Spec(), "lint")
+	assert.NoError(t, err)
+	assert.Empty(t, lint.Needs)
+	assert.Empty(t, lint.NeedNamespaces)
+
+	deploy, err := GetAction(y.Spec(), "deploy")
+	assert.NoError(t, err)
+	assert.Equal(t, []ActionAlias{"build-a", "lint"}, deploy.Needs)
+	assert.Equal(t, []string{"build-a", "lint", "repo"}, deploy.NeedNamespaces)
+
+	// actions without needs keep the implicit stage dependencies
+	notify, err := GetAction(y.Spec(), "notify")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []ActionAlias{"repo", "build-a", "build-b", "lint"}, notify.Needs)
+
+	// declared needs are kept when generating yml
+	b, err := GenerateYml(y.Spec())
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "needs:")
+}
+
+func TestNeedsVisitor_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		yml  string
+	}{
+		{
+			name: "unknown alias",
+			yml: `version: "1.1"
+stages:
+  - stage:
+      - custom-script:
+          alias: a
+          needs: [not-exist]
+`,
+		},
+		{
+			name: "needs itself",
+			yml: `version: "1.1"
+stages:
+  - stage:
+      - custom-script:
+          alias: a
+          needs: [a]
+`,
+		},
+		{
+			name: "cycle",
+			yml: `version: "1.1"
+stages:
+  - stage:
+      - custom-script:
+          alias: a
+          needs: [b]
+      - custom-script:
+          alias: b
+          needs: [a]
+`,
+		},
+		{
+			name: "cycle with implicit stage dependency",
+			yml: `version: "1.1"
+stages:
+  - stage:
+      - custom-script:
+          alias: a
+          needs: [b]
+  - stage:
+      - custom-script:
+          alias: b
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New([]byte(tt.yml))
+			assert.Error(t, err)
+		})
+	}
+}
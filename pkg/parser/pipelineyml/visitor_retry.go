@@ -0,0 +1,62 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelineyml
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+const (
+	maxRetryAttempts = 10
+)
+
+type RetryVisitor struct{}
+
+func NewRetryVisitor() *RetryVisitor {
+	return &RetryVisitor{}
+}
+
+func (v *RetryVisitor) Visit(s *Spec) {
+	for stageIndex, stage := range s.Stages {
+		for _, typedActionMap := range stage.Actions {
+			for _, action := range typedActionMap {
+				if action.Retry == nil {
+					continue
+				}
+				retry := action.Retry
+				if retry.MaxAttempts < 1 || retry.MaxAttempts > maxRetryAttempts {
+					s.appendError(errors.Errorf("invalid retry max_attempts: %d (should be in [1, %d])", retry.MaxAttempts, maxRetryAttempts),
+						stageIndex, action.Alias, yamlField("retry.max_attempts"))
+				}
+				switch retry.Backoff {
+				case "", apistructs.PipelineTaskRetryBackoffFixed, apistructs.PipelineTaskRetryBackoffExponential:
+				default:
+					s.appendError(errors.Errorf("invalid retry backoff: %s (only %s, %s)", retry.Backoff,
+						apistructs.PipelineTaskRetryBackoffFixed, apistructs.PipelineTaskRetryBackoffExponential), stageIndex, action.Alias, yamlField("retry.backoff"))
+				}
+				if retry.MaxIntervalSec > 0 && retry.MaxIntervalSec < retry.IntervalSec {
+					s.appendError(errors.Errorf("invalid retry max_interval_sec: %d (should not be less than interval_sec %d)", retry.MaxIntervalSec, retry.IntervalSec),
+						stageIndex, action.Alias, yamlField("retry.max_interval_sec"))
+				}
+				for _, reason := range retry.When {
+					if !reason.Valid() {
+						s.appendError(errors.Errorf("invalid retry reason: %s", reason), stageIndex, action.Alias, yamlField("retry.when"))
+					}
+				}
+			}
+		}
+	}
+}
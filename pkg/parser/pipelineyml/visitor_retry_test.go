@@ -0,0 +1,62 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelineyml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+func TestRetryVisitor_Visit(t *testing.T) {
+	y, err := New([]byte(`version: "1.1"
+stages:
+  - stage:
+      - custom-script:
+          retry:
+            max_attempts: 3
+            backoff: exponential
+            interval_sec: 5
+            when: [image_pull, oom]
+            exit_codes: [2]
+`))
+	assert.NoError(t, err)
+	action, err := GetAction(y.Spec(), "custom-script")
+	assert.NoError(t, err)
+	assert.Equal(t, &apistructs.PipelineTaskRetry{
+		MaxAttempts: 3,
+		Backoff:     apistructs.PipelineTaskRetryBackoffExponential,
+		IntervalSec: 5,
+		When:        []apistructs.PipelineTaskRetryReason{apistructs.PipelineTaskRetryReasonImagePull, apistructs.PipelineTaskRetryReasonOOM},
+		ExitCodes:   []int{2},
+	}, action.Retry)
+
+	invalids := []string{
+		`max_attempts: 0`,
+		`max_attempts: 100`,
+		`{max_attempts: 2, backoff: linear}`,
+		`{max_attempts: 2, when: [unknown]}`,
+		`{max_attempts: 2, interval_sec: 10, max_interval_sec: 5}`,
+	}
+	for _, retry := range invalids {
+		_, err := New([]byte(`version: "1.1"
+stages:
+  - stage:
+      - custom-script:
+          retry: ` + retry + "\n"))
+		assert.Error(t, err, retry)
+	}
+}
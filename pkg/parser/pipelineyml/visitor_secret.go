@@ -15,12 +15,14 @@ package pipelineyml
 
 import (
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
 
 	"github.com/erda-project/erda/pkg/expression"
+	"github.com/erda-project/erda/pkg/parser/pipelineyml/pexpr"
 	"github.com/erda-project/erda/pkg/strutil"
 )
 
@@ -113,3 +115,22 @@ func RenderSecrets(input []byte, secrets map[string]string) ([]byte, error) {
 
 	return []byte(replaced), nil
 }
+
+// FindSecretReferences 返回 input 中引用的所有配置项名称，包括 ((xxx)) 和 ${{ configs.xxx }}，已去重排序
+func FindSecretReferences(input []byte) []string {
+	names := make(map[string]struct{})
+	for _, sec := range validSecretRegexp.FindAllString(string(input), -1) {
+		names[unwrapSecret(sec)] = struct{}{}
+	}
+	for _, subs := range pexpr.PhRe.FindAllStringSubmatch(string(input), -1) {
+		if strings.HasPrefix(subs[1], expression.Configs+".") {
+			names[strings.TrimPrefix(subs[1], expression.Configs+".")] = struct{}{}
+		}
+	}
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -42,6 +42,22 @@ envs:
 	assert.Error(t, s.mergeErrors())
 }
 
+func TestFindSecretReferences(t *testing.T) {
+	input := []byte(`version: 1.1
+envs:
+  ENV_1: ((env_1))
+stages:
+- stage:
+  - git-checkout:
+      params:
+        depth: ((depth))
+        uri: ${{ configs.git.uri }}
+        branch: ${{ params.branch }}
+        token: ((env_1))
+`)
+	assert.Equal(t, []string{"depth", "env_1", "git.uri"}, FindSecretReferences(input))
+}
+
 //func TestRenderSecrets(t *testing.T) {
 //	input := []byte("((a))((b))((c))")
 //	secret := map[string]string{
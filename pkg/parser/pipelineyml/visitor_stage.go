@@ -87,10 +87,10 @@ func (v *StageVisitor) Visit(s *Spec) {
 				}
 				// find duplicated action name
 				if _, ok := s.allActions[action.Alias]; ok {
-					s.appendError(errors.Errorf("action name %q is duplicated", action.Alias), stageIndex, action.Alias)
+					s.appendError(errors.Errorf("action name %q is duplicated", action.Alias), stageIndex, action.Alias, yamlField("alias"))
 				}
 				if !aliasRegex.MatchString(string(action.Alias)) {
-					s.appendError(errors.Errorf("invalid action alias name: %s, regex: %s", action.Alias, aliasRegex.String()))
+					s.appendError(errors.Errorf("invalid action alias name: %s, regex: %s", action.Alias, aliasRegex.String()), stageIndex, action.Alias, yamlField("alias"))
 				}
 
 				action.Type = actionType
@@ -105,13 +105,16 @@ func (v *StageVisitor) Visit(s *Spec) {
 				}
 
 				// needs
-				if len(action.Needs) == 0 {
-					action.Needs = toList(availableActions)
-				}
+				// 显式声明的 needs 及对应的 needNamespaces 由 NeedsVisitor 处理
+				if action.DeclaredNeeds == nil {
+					if len(action.Needs) == 0 {
+						action.Needs = toList(availableActions)
+					}
 
-				// needNamespaces
-				if len(action.NeedNamespaces) == 0 {
-					action.NeedNamespaces = toListStr(availableNamespaces)
+					// needNamespaces
+					if len(action.NeedNamespaces) == 0 {
+						action.NeedNamespaces = toListStr(availableNamespaces)
+					}
 				}
 
 				// namespaces
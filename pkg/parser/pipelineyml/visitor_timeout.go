@@ -28,12 +28,27 @@ func NewTimeoutVisitor() *TimeoutVisitor {
 }
 
 func (v *TimeoutVisitor) Visit(s *Spec) {
+	if s.Timeout < TimeoutDuration4Forever {
+		s.appendError(errors.Errorf("invalid pipeline timeout: %d (only %d means forever)", s.Timeout, TimeoutDuration4Forever), yamlField("timeout"))
+	}
+	if s.QueueTimeout < TimeoutDuration4Forever {
+		s.appendError(errors.Errorf("invalid pipeline queue_timeout: %d (only %d means forever)", s.QueueTimeout, TimeoutDuration4Forever), yamlField("queue_timeout"))
+	}
 	for stageIndex, stage := range s.Stages {
+		if stage.Timeout < TimeoutDuration4Forever {
+			s.appendError(errors.Errorf("invalid stage timeout: %d (only %d means forever)", stage.Timeout, TimeoutDuration4Forever),
+				stageIndex, yamlField("timeout"))
+		}
+		// stage 超时时间不能超过流水线执行超时时间
+		if s.Timeout > 0 && stage.Timeout > s.Timeout {
+			s.appendError(errors.Errorf("stage timeout %d exceeds pipeline timeout %d", stage.Timeout, s.Timeout),
+				stageIndex, yamlField("timeout"))
+		}
 		for _, typedActionMap := range stage.Actions {
 			for _, action := range typedActionMap {
 				if action.Timeout < TimeoutDuration4Forever {
 					s.appendError(errors.Errorf("invalid timeout: %d (only %d means forever)", action.Timeout, TimeoutDuration4Forever),
-						stageIndex, action.Alias)
+						stageIndex, action.Alias, yamlField("timeout"))
 				}
 			}
 		}
@@ -0,0 +1,78 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package pipelineyml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestTimeoutVisitor_Visit(t *testing.T) {
+	y, err := New([]byte(`version: "1.1"
+timeout: 3600
+queue_timeout: 600
+stages:
+  - stage:
+      - custom-script:
+          timeout: 60
+    timeout: 1800
+  - stage:
+      - custom-script:
+          alias: s2
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3600), y.Spec().Timeout)
+	assert.Equal(t, int64(600), y.Spec().QueueTimeout)
+	assert.Equal(t, int64(1800), y.Spec().Stages[0].Timeout)
+	assert.Equal(t, int64(0), y.Spec().Stages[1].Timeout)
+
+	invalids := []string{
+		"timeout: -2\nstages:\n  - stage:\n      - custom-script:\n",
+		"queue_timeout: -2\nstages:\n  - stage:\n      - custom-script:\n",
+		"stages:\n  - stage:\n      - custom-script:\n    timeout: -2\n",
+		"timeout: 60\nstages:\n  - stage:\n      - custom-script:\n    timeout: 120\n",
+	}
+	for _, invalid := range invalids {
+		_, err := New([]byte("version: \"1.1\"\n" + invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestConvertGraphPipelineYml_Timeout(t *testing.T) {
+	graph, err := ConvertToGraphPipelineYml([]byte(`version: "1.1"
+timeout: 3600
+stages:
+  - stage:
+      - custom-script:
+  - stage:
+      - custom-script:
+          alias: s2
+    timeout: 1800
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3600), graph.Timeout)
+	assert.Equal(t, []int64{0, 1800}, graph.StageTimeouts)
+
+	// graph -> yml
+	b, err := yaml.Marshal(graph)
+	assert.NoError(t, err)
+	content, err := ConvertGraphPipelineYmlContent(b)
+	assert.NoError(t, err)
+	y, err := New(content)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3600), y.Spec().Timeout)
+	assert.Equal(t, int64(1800), y.Spec().Stages[1].Timeout)
+}
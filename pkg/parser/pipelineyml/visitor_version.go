@@ -27,11 +27,11 @@ func NewVersionVisitor() *VersionVisitor {
 func (v *VersionVisitor) Visit(s *Spec) {
 	switch s.Version {
 	case "":
-		s.appendError(errors.New("no version"))
+		s.appendError(errors.New("no version"), yamlField("version"))
 	case Version1dot1:
 		return
 	default:
-		s.appendError(errors.Errorf("invalid version: %s, only support 1.1", s.Version))
+		s.appendError(errors.Errorf("invalid version: %s, only support 1.1", s.Version), yamlField("version"))
 	}
 }
 
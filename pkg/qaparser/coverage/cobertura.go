@@ -0,0 +1,85 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package coverage
+
+import (
+	"encoding/xml"
+	"regexp"
+	"strconv"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+// condition-coverage="50% (1/2)"
+var coberturaConditionRe = regexp.MustCompile(`\((\d+)/(\d+)\)`)
+
+type coberturaCoverage struct {
+	Packages []coberturaPackage `xml:"packages>package"`
+}
+
+type coberturaPackage struct {
+	Name    string           `xml:"name,attr"`
+	Classes []coberturaClass `xml:"classes>class"`
+}
+
+type coberturaClass struct {
+	Filename string          `xml:"filename,attr"`
+	Lines    []coberturaLine `xml:"lines>line"`
+}
+
+type coberturaLine struct {
+	Number            int    `xml:"number,attr"`
+	Hits              int64  `xml:"hits,attr"`
+	Branch            bool   `xml:"branch,attr"`
+	ConditionCoverage string `xml:"condition-coverage,attr"`
+}
+
+// IngestCobertura 解析 Cobertura XML，使用 class 下的 lines 统计，忽略 methods 下重复的行
+func IngestCobertura(data []byte) (*apistructs.CoverageReport, error) {
+	var coverage coberturaCoverage
+	if err := xml.Unmarshal(data, &coverage); err != nil {
+		return nil, err
+	}
+	collector := newPackageCollector()
+	for _, p := range coverage.Packages {
+		name := p.Name
+		if name == "" {
+			name = "."
+		}
+		pkg := collector.get(name)
+		for _, class := range p.Classes {
+			// 同一个文件可能拆分为多个 class（如内部类），按行号去重
+			seen := make(map[int]struct{}, len(class.Lines))
+			for _, line := range class.Lines {
+				if _, ok := seen[line.Number]; ok {
+					continue
+				}
+				seen[line.Number] = struct{}{}
+				pkg.Lines.Total++
+				if line.Hits > 0 {
+					pkg.Lines.Covered++
+				}
+				if !line.Branch {
+					continue
+				}
+				if matches := coberturaConditionRe.FindStringSubmatch(line.ConditionCoverage); len(matches) == 3 {
+					covered, _ := strconv.ParseInt(matches[1], 10, 64)
+					total, _ := strconv.ParseInt(matches[2], 10, 64)
+					pkg.Branches.Add(apistructs.CoverageCounter{Covered: covered, Total: total})
+				}
+			}
+		}
+	}
+	return collector.report(), nil
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package coverage 解析常见的代码覆盖率文件，统一转换为包级别的行覆盖率与分支覆盖率
+package coverage
+
+import (
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+// IngestFile 按照指定格式解析覆盖率文件
+func IngestFile(format apistructs.CoverageFormat, filename string) (*apistructs.CoverageReport, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Ingest(format, data)
+}
+
+// Ingest 按照指定格式解析覆盖率数据
+func Ingest(format apistructs.CoverageFormat, data []byte) (*apistructs.CoverageReport, error) {
+	var (
+		report *apistructs.CoverageReport
+		err    error
+	)
+	switch format {
+	case apistructs.CoverageFormatCobertura:
+		report, err = IngestCobertura(data)
+	case apistructs.CoverageFormatJaCoCo:
+		report, err = IngestJaCoCo(data)
+	case apistructs.CoverageFormatLCOV:
+		report, err = IngestLCOV(data)
+	case apistructs.CoverageFormatGoCover:
+		report, err = IngestGoCover(data)
+	default:
+		return nil, errors.Errorf("not supported coverage format: %s", format)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s coverage", format)
+	}
+	report.Format = format
+	report.Aggregate()
+	return report, nil
+}
+
+// packageCollector 按包名累加计数
+type packageCollector struct {
+	packages map[string]*apistructs.CoveragePackage
+}
+
+func newPackageCollector() *packageCollector {
+	return &packageCollector{packages: make(map[string]*apistructs.CoveragePackage)}
+}
+
+func (c *packageCollector) get(name string) *apistructs.CoveragePackage {
+	pkg, ok := c.packages[name]
+	if !ok {
+		pkg = &apistructs.CoveragePackage{Name: name}
+		c.packages[name] = pkg
+	}
+	return pkg
+}
+
+func (c *packageCollector) report() *apistructs.CoverageReport {
+	report := &apistructs.CoverageReport{}
+	for _, pkg := range c.packages {
+		report.Packages = append(report.Packages, *pkg)
+	}
+	return report
+}
+
+// packageOfFile 使用文件所在目录作为包名
+func packageOfFile(filename string) string {
+	dir := path.Dir(strings.ReplaceAll(filename, "\\", "/"))
+	if dir == "" {
+		return "."
+	}
+	return dir
+}
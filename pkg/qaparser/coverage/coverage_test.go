@@ -0,0 +1,81 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package coverage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+func counter(covered, total int64) apistructs.CoverageCounter {
+	return apistructs.CoverageCounter{Covered: covered, Total: total}
+}
+
+func TestIngestCobertura(t *testing.T) {
+	report, err := IngestFile(apistructs.CoverageFormatCobertura, "../testdata/cobertura-coverage.xml")
+	assert.NoError(t, err)
+	assert.Equal(t, apistructs.CoverageFormatCobertura, report.Format)
+	assert.Equal(t, []apistructs.CoveragePackage{
+		{Name: "app.service", Lines: counter(2, 3), Branches: counter(1, 2)},
+		{Name: "app.util", Lines: counter(1, 2)},
+	}, report.Packages)
+	assert.Equal(t, counter(3, 5), report.Lines)
+	assert.Equal(t, float64(60), report.Lines.Rate())
+	assert.Equal(t, float64(50), report.Branches.Rate())
+}
+
+func TestIngestJaCoCo(t *testing.T) {
+	report, err := IngestFile(apistructs.CoverageFormatJaCoCo, "../testdata/jacoco.xml")
+	assert.NoError(t, err)
+	assert.Equal(t, []apistructs.CoveragePackage{
+		{Name: "io.terminus.demo.service", Lines: counter(9, 10), Branches: counter(6, 8)},
+		{Name: "io.terminus.demo.util", Lines: counter(5, 10)},
+	}, report.Packages)
+	assert.Equal(t, counter(14, 20), report.Lines)
+	assert.Equal(t, counter(6, 8), report.Branches)
+}
+
+func TestIngestLCOV(t *testing.T) {
+	report, err := IngestFile(apistructs.CoverageFormatLCOV, "../testdata/lcov.info")
+	assert.NoError(t, err)
+	assert.Equal(t, []apistructs.CoveragePackage{
+		{Name: "src", Lines: counter(4, 10)},
+		{Name: "src/components", Lines: counter(4, 5), Branches: counter(1, 2)},
+	}, report.Packages)
+	assert.Equal(t, counter(8, 15), report.Lines)
+
+	_, err = Ingest(apistructs.CoverageFormatLCOV, []byte("SF:a.js\nDA:1\nend_of_record\n"))
+	assert.Error(t, err)
+}
+
+func TestIngestGoCover(t *testing.T) {
+	report, err := IngestFile(apistructs.CoverageFormatGoCover, "../testdata/coverprofile.out")
+	assert.NoError(t, err)
+	assert.Equal(t, []apistructs.CoveragePackage{
+		{Name: "github.com/erda-project/demo/pkg/a", Lines: counter(3, 3)},
+		{Name: "github.com/erda-project/demo/pkg/b", Lines: counter(0, 3)},
+	}, report.Packages)
+	assert.Equal(t, float64(50), report.Lines.Rate())
+
+	_, err = Ingest(apistructs.CoverageFormatGoCover, []byte("mode: set\na.go:1.1,2.2 x 1\n"))
+	assert.Error(t, err)
+}
+
+func TestIngest_UnknownFormat(t *testing.T) {
+	_, err := Ingest("clover", []byte(""))
+	assert.Error(t, err)
+}
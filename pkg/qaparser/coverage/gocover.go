@@ -0,0 +1,94 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package coverage
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+type goCoverBlock struct {
+	numStmts int64
+	count    int64
+}
+
+// IngestGoCover 解析 go test -coverprofile 生成的文件，按语句统计，记录在行覆盖率中。
+// 多个 profile 拼接时同一代码块可能出现多次，取最大执行次数。
+func IngestGoCover(data []byte) (*apistructs.CoverageReport, error) {
+	// file -> block position -> block
+	files := make(map[string]map[string]*goCoverBlock)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		// name.go:line.column,line.column numberOfStatements count
+		colon := strings.LastIndex(line, ":")
+		if colon < 0 {
+			return nil, errors.Errorf("invalid profile line %d: %s", lineNo, line)
+		}
+		fields := strings.Fields(line[colon+1:])
+		if len(fields) != 3 {
+			return nil, errors.Errorf("invalid profile line %d: %s", lineNo, line)
+		}
+		numStmts, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid profile line %d: %s", lineNo, line)
+		}
+		count, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid profile line %d: %s", lineNo, line)
+		}
+		file := line[:colon]
+		blocks, ok := files[file]
+		if !ok {
+			blocks = make(map[string]*goCoverBlock)
+			files[file] = blocks
+		}
+		block, ok := blocks[fields[0]]
+		if !ok {
+			blocks[fields[0]] = &goCoverBlock{numStmts: numStmts, count: count}
+			continue
+		}
+		if count > block.count {
+			block.count = count
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	collector := newPackageCollector()
+	for file, blocks := range files {
+		pkg := collector.get(packageOfFile(file))
+		for _, block := range blocks {
+			pkg.Lines.Total += block.numStmts
+			if block.count > 0 {
+				pkg.Lines.Covered += block.numStmts
+			}
+		}
+	}
+	return collector.report(), nil
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package coverage
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+const (
+	jacocoCounterLine   = "LINE"
+	jacocoCounterBranch = "BRANCH"
+)
+
+type jacocoReport struct {
+	Packages []jacocoPackage `xml:"package"`
+	// report 直接包含的 group
+	Groups []jacocoGroup `xml:"group"`
+}
+
+type jacocoGroup struct {
+	Packages []jacocoPackage `xml:"package"`
+	Groups   []jacocoGroup   `xml:"group"`
+}
+
+type jacocoPackage struct {
+	Name     string          `xml:"name,attr"`
+	Counters []jacocoCounter `xml:"counter"`
+}
+
+type jacocoCounter struct {
+	Type    string `xml:"type,attr"`
+	Missed  int64  `xml:"missed,attr"`
+	Covered int64  `xml:"covered,attr"`
+}
+
+// IngestJaCoCo 解析 JaCoCo XML，使用 package 级别的 LINE/BRANCH counter
+func IngestJaCoCo(data []byte) (*apistructs.CoverageReport, error) {
+	var report jacocoReport
+	if err := xml.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	collector := newPackageCollector()
+	collectJaCoCoPackages(collector, report.Packages)
+	for _, group := range report.Groups {
+		collectJaCoCoGroup(collector, group)
+	}
+	return collector.report(), nil
+}
+
+func collectJaCoCoGroup(collector *packageCollector, group jacocoGroup) {
+	collectJaCoCoPackages(collector, group.Packages)
+	for _, sub := range group.Groups {
+		collectJaCoCoGroup(collector, sub)
+	}
+}
+
+func collectJaCoCoPackages(collector *packageCollector, packages []jacocoPackage) {
+	for _, p := range packages {
+		name := strings.ReplaceAll(p.Name, "/", ".")
+		if name == "" {
+			name = "."
+		}
+		pkg := collector.get(name)
+		for _, counter := range p.Counters {
+			c := apistructs.CoverageCounter{Covered: counter.Covered, Total: counter.Covered + counter.Missed}
+			switch counter.Type {
+			case jacocoCounterLine:
+				pkg.Lines.Add(c)
+			case jacocoCounterBranch:
+				pkg.Branches.Add(c)
+			}
+		}
+	}
+}
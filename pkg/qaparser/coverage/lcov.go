@@ -0,0 +1,134 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package coverage
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/erda-project/erda/apistructs"
+)
+
+type lcovRecord struct {
+	file string
+	// line -> hits
+	lines map[string]int64
+	// line,block,branch -> taken
+	branches map[string]int64
+	// LF/LH/BRF/BRH 汇总，没有明细时使用
+	summary apistructs.CoveragePackage
+}
+
+// IngestLCOV 解析 LCOV tracefile，按源文件所在目录归类到包
+func IngestLCOV(data []byte) (*apistructs.CoverageReport, error) {
+	collector := newPackageCollector()
+	var record *lcovRecord
+	flush := func() {
+		if record == nil {
+			return
+		}
+		pkg := collector.get(packageOfFile(record.file))
+		if len(record.lines) > 0 {
+			for _, hits := range record.lines {
+				pkg.Lines.Total++
+				if hits > 0 {
+					pkg.Lines.Covered++
+				}
+			}
+		} else {
+			pkg.Lines.Add(record.summary.Lines)
+		}
+		if len(record.branches) > 0 {
+			for _, taken := range record.branches {
+				pkg.Branches.Total++
+				if taken > 0 {
+					pkg.Branches.Covered++
+				}
+			}
+		} else {
+			pkg.Branches.Add(record.summary.Branches)
+		}
+		record = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if line == "end_of_record" {
+			flush()
+			continue
+		}
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key, value := kv[0], kv[1]
+		if key == "SF" {
+			flush()
+			record = &lcovRecord{file: value, lines: make(map[string]int64), branches: make(map[string]int64)}
+			continue
+		}
+		if record == nil {
+			continue
+		}
+		fields := strings.Split(value, ",")
+		switch key {
+		case "DA":
+			if len(fields) < 2 {
+				return nil, errors.Errorf("invalid DA at line %d: %s", lineNo, line)
+			}
+			hits, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return nil, errors.Errorf("invalid DA at line %d: %s", lineNo, line)
+			}
+			record.lines[fields[0]] += hits
+		case "BRDA":
+			if len(fields) != 4 {
+				return nil, errors.Errorf("invalid BRDA at line %d: %s", lineNo, line)
+			}
+			var taken int64
+			if fields[3] != "-" {
+				t, err := strconv.ParseInt(fields[3], 10, 64)
+				if err != nil {
+					return nil, errors.Errorf("invalid BRDA at line %d: %s", lineNo, line)
+				}
+				taken = t
+			}
+			record.branches[strings.Join(fields[:3], ",")] += taken
+		case "LF":
+			record.summary.Lines.Total, _ = strconv.ParseInt(value, 10, 64)
+		case "LH":
+			record.summary.Lines.Covered, _ = strconv.ParseInt(value, 10, 64)
+		case "BRF":
+			record.summary.Branches.Total, _ = strconv.ParseInt(value, 10, 64)
+		case "BRH":
+			record.summary.Branches.Covered, _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return collector.report(), nil
+}
@@ -16,6 +16,7 @@ package storage
 import (
 	"io"
 	"os"
+	"path/filepath"
 )
 
 type FS struct{}
@@ -33,10 +34,14 @@ func (fs *FS) Read(path string) (io.Reader, error) {
 }
 
 func (fs *FS) Write(path string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
 	dst, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 	if err != nil {
 		return err
 	}
+	defer dst.Close()
 	if _, err = io.Copy(dst, r); err != nil {
 		return err
 	}
@@ -0,0 +1,83 @@
+// Copyright (c) 2021 Terminus, Inc.
+//
+// This program is free software: you can use, redistribute, and/or modify
+// it under the terms of the GNU Affero General Public License, version 3
+// or later ("AGPL"), as published by the Free Software Foundation.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"io"
+
+	"github.com/minio/minio-go"
+)
+
+// S3 S3 兼容的对象存储
+type S3 struct {
+	endpoint        string
+	region          string
+	accessKeyID     string
+	accessKeySecret string
+	bucket          string
+	secure          bool
+}
+
+func NewS3(endpoint, region, accessKeyID, accessKeySecret, bucket string, secure bool) *S3 {
+	var s S3
+	s.endpoint = endpoint
+	s.region = region
+	s.accessKeyID = accessKeyID
+	s.accessKeySecret = accessKeySecret
+	s.bucket = bucket
+	s.secure = secure
+	return &s
+}
+
+func (s *S3) Type() Type {
+	return TypeS3
+}
+
+func (s *S3) Read(path string) (io.Reader, error) {
+	client, err := s.newClient()
+	if err != nil {
+		return nil, err
+	}
+	obj, err := client.GetObject(s.bucket, handlePath(path), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// GetObject 不会真正发起请求，这里提前确认对象存在
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (s *S3) Write(path string, r io.Reader) error {
+	client, err := s.newClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.PutObject(s.bucket, handlePath(path), r, -1, minio.PutObjectOptions{})
+	return err
+}
+
+func (s *S3) Delete(path string) error {
+	client, err := s.newClient()
+	if err != nil {
+		return err
+	}
+	return client.RemoveObject(s.bucket, handlePath(path))
+}
+
+func (s *S3) newClient() (*minio.Client, error) {
+	return minio.NewWithRegion(s.endpoint, s.accessKeyID, s.accessKeySecret, s.secure, s.region)
+}
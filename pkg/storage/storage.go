@@ -27,4 +27,5 @@ type Type string
 var (
 	TypeFileSystem Type = "fs"
 	TypeOSS        Type = "oss"
+	TypeS3         Type = "s3"
 )
@@ -15,7 +15,11 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/pkg/errors"
+
+	"github.com/erda-project/erda/apistructs"
 	"github.com/erda-project/erda/pkg/footnote"
 	"github.com/erda-project/erda/pkg/parser/diceyml"
 	"github.com/erda-project/erda/tools/cli/command"
@@ -25,20 +29,26 @@ import (
 // CHECK command
 var CHECK = command.Command{
 	Name:      "check",
-	ShortHelp: "Validate dice.yml file",
+	ShortHelp: "Validate dice.yml or pipeline.yml file",
 	Example: `
   $ dice check -f dice.yml
+  $ dice check -p pipeline.yml
 `,
 	Flags: []command.Flag{
 		command.StringFlag{Short: "f", Name: "file",
 			Doc: "Specify the path of dice.yml file, default: .dice/dice.yml", DefaultValue: ""},
+		command.StringFlag{Short: "p", Name: "pipeline",
+			Doc: "Specify the path of pipeline.yml file, validated by pipeline lint api", DefaultValue: ""},
 	},
 	Run:    RunCheck,
 	Hidden: true,
 }
 
-// RunCheck validates dice.yml file
-func RunCheck(ctx *command.Context, ymlPath string) error {
+// RunCheck validates dice.yml file, or lints the pipeline.yml file given by -p through pipeline lint api
+func RunCheck(ctx *command.Context, ymlPath, pipelineYmlPath string) error {
+	if pipelineYmlPath != "" {
+		return checkPipelineYml(ctx, pipelineYmlPath)
+	}
 	var yml []byte
 	var err error
 	if ymlPath != "" {
@@ -72,3 +82,45 @@ func RunCheck(ctx *command.Context, ymlPath string) error {
 	fmt.Printf("%+v\n", fnote.Dump())
 	return nil
 }
+
+// checkPipelineYml validates pipeline.yml file by pipeline lint api
+func checkPipelineYml(ctx *command.Context, ymlPath string) error {
+	yml, err := format.ReadYml(ymlPath)
+	if err != nil {
+		return err
+	}
+	var lintResp apistructs.PipelineYmlLintResponse
+	resp, err := ctx.Post().Path("/api/pipelines/actions/pipeline-yml-lint").
+		JSONBody(apistructs.PipelineYmlLintRequest{PipelineYmlContent: string(yml)}).Do().JSON(&lintResp)
+	if err != nil {
+		return err
+	}
+	if !resp.IsOK() || !lintResp.Success {
+		return errors.Errorf("failed to lint pipeline.yml, status code: %d, err: %+v", resp.StatusCode(), lintResp.Error)
+	}
+	if len(lintResp.Data.Diagnostics) == 0 {
+		ctx.Succ("OK")
+		return nil
+	}
+	notes := make(map[int][]string)
+	for _, d := range lintResp.Data.Diagnostics {
+		note := fmt.Sprintf("%s: %s", d.Severity, d.Message)
+		for _, fix := range d.QuickFixes {
+			note += fmt.Sprintf(" (%s)", fix.Title)
+		}
+		line := d.Range.Start.Line - 1
+		if line < 0 {
+			line = 0
+		}
+		notes[line] = append(notes[line], note)
+	}
+	fnote := footnote.New(string(yml))
+	for line, lineNotes := range notes {
+		fnote.NoteLine(line, strings.Join(lineNotes, "; "))
+	}
+	fmt.Printf("%+v\n", fnote.Dump())
+	if !lintResp.Data.Valid {
+		return errors.New("pipeline.yml is invalid")
+	}
+	return nil
+}